@@ -0,0 +1,266 @@
+package main
+
+import (
+	"backend-antrian/internal/buildinfo"
+	"backend-antrian/internal/config"
+	"backend-antrian/internal/http/handler"
+	"backend-antrian/internal/http/middleware"
+	"backend-antrian/internal/permission"
+	"backend-antrian/internal/validation"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+	"github.com/gofiber/fiber/v2/middleware/filesystem"
+
+	// "github.com/gofiber/fiber/v2/middleware/limiter"
+	fiberRecover "github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/websocket/v2"
+)
+
+// newApp susun app Fiber: config, middleware global, dan semua route ke h.
+// Tidak membuka koneksi apa pun — DB & service harus sudah di-init.
+func newApp(h *handler.Handler) *fiber.App {
+	app := fiber.New(fiber.Config{
+		BodyLimit:     50 * 1024 * 1024,
+		Prefork:       false,
+		CaseSensitive: true,
+		StrictRouting: true,
+		ReadTimeout:   30 * time.Second,
+		WriteTimeout:  30 * time.Second,
+		IdleTimeout:   120 * time.Second,
+		// Semua request lewat nginx; c.IP() (lockout login, audit, sesi)
+		// harus IP klien asli dari X-Real-IP, bukan IP nginx
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          config.TrustedProxies(),
+		ProxyHeader:             "X-Real-IP",
+		EnableIPValidation:      true, // header kosong/tidak valid jatuh ke IP koneksi
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			var ve *validation.Error
+			if errors.As(err, &ve) {
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(ve.Body())
+			}
+
+			code := fiber.StatusInternalServerError
+			if e, ok := err.(*fiber.Error); ok {
+				code = e.Code
+			}
+			level := slog.LevelError
+			if code < fiber.StatusInternalServerError {
+				level = slog.LevelDebug
+			}
+			slog.Log(c.UserContext(), level, "request error",
+				"method", c.Method(),
+				"path", c.Path(),
+				"status", code,
+				"err", err,
+			)
+			return c.Status(code).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		},
+	})
+
+	// Request ID + access log; paling awal supaya semua log request membawa request_id
+	app.Use(middleware.RequestID())
+
+	// Recover middleware
+	app.Use(fiberRecover.New(fiberRecover.Config{
+		EnableStackTrace: true,
+		StackTraceHandler: func(c *fiber.Ctx, e interface{}) {
+			slog.ErrorContext(c.UserContext(), "panic",
+				"method", c.Method(),
+				"path", c.Path(),
+				"panic", fmt.Sprint(e),
+				"stack", string(debug.Stack()),
+			)
+		},
+	}))
+
+	app.Use(cors.New(cors.Config{
+		AllowOrigins:     "*",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-Request-ID",
+		AllowMethods:     "GET, POST, PUT, DELETE, OPTIONS",
+		ExposeHeaders:    "Content-Disposition, Content-Type, Content-Length, X-Request-ID",
+		AllowCredentials: false,
+	}))
+	//   app.Use(cors.New(cors.Config{
+	//             AllowOrigins:  "https://sandigi.lotusaja.com",
+	//             AllowHeaders:  "Origin, Content-Type, Accept, Authorization",
+	//             AllowMethods:  "GET, POST, PUT, DELETE, OPTIONS",
+	//             ExposeHeaders: "Content-Disposition, Content-Type, Content-Length",
+	//             AllowCredentials: true,
+	//     }))
+	// Rate limiting untuk WebSocket
+	// app.Use("/ws/*", limiter.New(limiter.Config{
+	// 	Max:        1000,
+	// 	Expiration: 1 * time.Minute,
+	// 	LimitReached: func(c *fiber.Ctx) error {
+	// 		log.Printf("[RATE_LIMIT] IP: %s", c.IP())
+	// 		return c.Status(429).JSON(fiber.Map{
+	// 			"error": "Too many connections",
+	// 		})
+	// 	},
+	// }))
+
+	app.Static("/", "./public")
+	app.Use("/audio", filesystem.New(filesystem.Config{
+		Root:   http.Dir("./public/audio"),
+		Browse: false,
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{
+			"message": "Antrian API jalan",
+			"version": buildinfo.Version(),
+		})
+	})
+
+	// Liveness & readiness (load balancer, Docker HEALTHCHECK)
+	app.Get("/healthz", handler.Healthz)
+	app.Get("/readyz", handler.Readyz)
+
+	// Metrik Prometheus (scraper pakai Basic Auth)
+	app.Get("/metrics", middleware.BasicAuth(), handler.GetMetrics)
+
+	// Auth
+	app.Get("/san/captcha", handler.GetCaptchaChallenge)
+	app.Post("/san/login", h.Login)
+	app.Post("/san/login/2fa", h.VerifyLoginTwoFactor)
+	app.Post("/san/login/2fa/setup", h.SetupLoginTwoFactor)
+	app.Post("/san/kiosk/auth", h.AuthenticateKiosk)
+	app.Post("/san/login-kiosk", h.LoginKiosk) // deprecated, pakai /san/kiosk/auth
+	app.Post("/san/refresh", h.RefreshSession)
+	app.Post("/san/password/reset", h.ResetPasswordWithToken)
+	app.Post("/san/display/pair", h.PairDisplay)
+
+	// Public endpoints
+	app.Get("/api/units", h.GetAllUnits)
+	app.Get("/api/units/paginate", h.GetAllUnitsPagination)
+	app.Get("/api/units/:id", h.GetUnitByID)
+	app.Get("/api/config", h.GetConfig)
+	app.Get("/api/services/unit", h.GetServicesByUnitID)
+	app.Get("/api/services/unit/status", h.GetServicesByUnitIDWithStatus)
+	app.Get("/api/audio", h.GetAllAudios)
+	app.Get("/api/queue/display", h.GetQueueDisplay)
+	app.Get("/api/faqs", h.GetAllFAQs)
+
+	// WebSocket endpoints (public)
+	app.Get("/ws/units", websocket.New(h.UnitsWS))
+	app.Get("/ws/queue", websocket.New(h.QueueWebSocket))
+
+	// Protected API
+	api := app.Group("/api", middleware.JWTAuth(), middleware.Audit())
+	api.Post("/logout", h.Logout)
+	api.Get("/me", h.GetMe)
+	api.Put("/me/password", h.ChangeMyPassword)
+	api.Get("/me/units", h.GetMyUnits)
+	api.Post("/me/unit", h.SwitchActiveUnit)
+	api.Get("/me/2fa", h.GetMyTwoFactor)
+	api.Post("/me/2fa/setup", h.SetupMyTwoFactor)
+	api.Post("/me/2fa/enable", h.EnableMyTwoFactor)
+	api.Post("/me/2fa/recovery-codes", h.RegenerateMyRecoveryCodes)
+	api.Delete("/me/2fa", h.DisableMyTwoFactor)
+
+	// ADMIN ROUTES (akses per permission, lihat tabel role_permissions)
+	api.Get("/users/paginate", middleware.Require(permission.UserView), h.GetAllUsersPagination)
+	api.Get("/users", middleware.Require(permission.UserView), h.GetAllUsers)
+	api.Get("/users/:id", middleware.Require(permission.UserView), h.GetUserByID)
+	api.Post("/users", middleware.Require(permission.UserManage), h.CreateUser)
+	api.Put("/users/:id", middleware.Require(permission.UserManage), h.UpdateUser)
+	api.Delete("/users/:id/permanent", middleware.Require(permission.UserManage), h.HardDeleteUser)
+	api.Delete("/users/:id/2fa", middleware.Require(permission.UserManage), h.ResetUserTwoFactor)
+	api.Post("/users/:id/password-reset", middleware.Require(permission.UserManage), h.ForcePasswordReset)
+
+	// Role & permission
+	api.Get("/permissions", middleware.Require(permission.RoleView), handler.GetPermissionCatalog)
+	api.Get("/roles", middleware.Require(permission.RoleView), h.GetAllRoles)
+	api.Get("/roles/:name", middleware.Require(permission.RoleView), h.GetRoleByName)
+	api.Post("/roles", middleware.Require(permission.RoleManage), h.CreateRole)
+	api.Put("/roles/:name", middleware.Require(permission.RoleManage), h.UpdateRole)
+	api.Delete("/roles/:name", middleware.Require(permission.RoleManage), h.DeleteRole)
+
+	// Lockout login (brute-force)
+	api.Post("/security/unlock", middleware.Require(permission.SecurityManage), handler.UnlockLogin)
+
+	// Audit log
+	api.Get("/audit-logs", middleware.Require(permission.AuditView), handler.GetAuditLogs)
+	api.Get("/audit-logs/export", middleware.Require(permission.AuditView), handler.ExportAuditLogs)
+
+	api.Post("/queue/take", middleware.Require(permission.QueueTake), h.TakeQueue)
+	api.Get("/audio/usage", middleware.Require(permission.AudioManage), h.GetAudioUsage)
+	api.Post("/audio", middleware.Require(permission.AudioManage), h.CreateAudio)
+	api.Post("/audio/import", middleware.Require(permission.AudioManage), h.ImportAudioZip)
+	api.Put("/audio/:id", middleware.Require(permission.AudioManage), h.UpdateAudio)
+	api.Put("/audio/:id/rename", middleware.Require(permission.AudioManage), h.RenameAudio)
+	api.Delete("/audio/:id", middleware.Require(permission.AudioManage), h.DeleteAudio)
+
+	api.Post("/units", middleware.Require(permission.UnitManage), h.CreateUnit)
+	api.Put("/units/:id", middleware.Require(permission.UnitManage), h.UpdateUnit)
+	api.Delete("/units/:id", middleware.Require(permission.UnitManage), h.DeleteUnit)
+	api.Delete("/units/:id/permanent", middleware.Require(permission.UnitManage), h.HardDeleteUnit)
+
+	// Display (layar antrian) registry
+	api.Get("/displays", middleware.Require(permission.DisplayView), h.GetAllDisplays)
+	api.Get("/displays/health", middleware.Require(permission.DisplayView), h.GetDisplayHealth)
+	api.Get("/displays/:id", middleware.Require(permission.DisplayView), h.GetDisplayByID)
+	api.Post("/displays", middleware.Require(permission.DisplayManage), h.CreateDisplay)
+	api.Put("/displays/:id", middleware.Require(permission.DisplayManage), h.UpdateDisplay)
+	api.Post("/displays/:id/pairing-code", middleware.Require(permission.DisplayManage), h.RegeneratePairingCode)
+	api.Delete("/displays/:id", middleware.Require(permission.DisplayManage), h.DeleteDisplay)
+	api.Get("/displays/:id/commands", middleware.Require(permission.DisplayView), h.GetDisplayCommands)
+	api.Post("/displays/:id/commands", middleware.Require(permission.DisplayManage), h.SendDisplayCommand)
+
+	// Kiosk (mesin ambil antrian) registry
+	api.Get("/kiosks", middleware.Require(permission.KioskView), h.GetAllKiosks)
+	api.Get("/kiosks/:id", middleware.Require(permission.KioskView), h.GetKioskByID)
+	api.Post("/kiosks", middleware.Require(permission.KioskManage), h.CreateKiosk)
+	api.Put("/kiosks/:id", middleware.Require(permission.KioskManage), h.UpdateKiosk)
+	api.Post("/kiosks/:id/secret", middleware.Require(permission.KioskManage), h.RotateKioskSecret)
+	api.Delete("/kiosks/:id", middleware.Require(permission.KioskManage), h.DeleteKiosk)
+
+	// Unit schedules (jam operasional per unit)
+	api.Get("/units/:id/schedules", middleware.Require(permission.ScheduleView), h.GetUnitSchedules)
+	api.Post("/units/:id/schedules", middleware.Require(permission.ScheduleManage), h.UpsertUnitSchedules)
+	api.Delete("/units/:id/schedules/:schedule_id", middleware.Require(permission.ScheduleManage), h.DeleteUnitSchedule)
+
+	api.Post("/config", middleware.Require(permission.ConfigManage), h.CreateConfig)
+	api.Put("/config", middleware.Require(permission.ConfigManage), h.UpdateConfig)
+	api.Get("/backup/database", middleware.Require(permission.BackupExport), middleware.AuditAction("backup.download"), handler.ExportDatabase)
+	api.Get("/reports/visitors/export", middleware.Require(permission.ReportExport), h.ExportVisitorReport)
+	api.Get("/reports/visitors/statistics", middleware.Require(permission.ReportView), h.GetVisitorStatistics)
+
+	api.Get("/faqs/paginate", middleware.Require(permission.FAQView), h.GetAllFAQsPagination)
+	api.Get("/faqs/:id", middleware.Require(permission.FAQView), h.GetFAQByID)
+	api.Post("/faqs", middleware.Require(permission.FAQManage), h.CreateFAQ)
+	api.Put("/faqs/:id", middleware.Require(permission.FAQManage), h.UpdateFAQ)
+	api.Delete("/faqs/:id", middleware.Require(permission.FAQManage), h.HardDeleteFAQ)
+
+	// KIOSK ROUTES (hanya ambil antrian + endpoint baca publik)
+	api.Get("/kiosk/me", middleware.RoleAuth("kiosk"), h.GetKioskMe)
+
+	// UNIT ROUTES (user terikat unit)
+	api.Get("/services", middleware.Require(permission.ServiceView), h.GetAllServices)
+	api.Get("/services/paginate", middleware.Require(permission.ServiceView), h.GetAllServicesPagination)
+	api.Get("/services/:id", middleware.Require(permission.ServiceView), h.GetServiceByID)
+	api.Post("/services", middleware.Require(permission.ServiceManage), h.CreateService)
+	api.Put("/services/:id", middleware.Require(permission.ServiceManage), h.UpdateService)
+	api.Delete("/services/:id", middleware.Require(permission.ServiceManage), h.DeleteService)
+	api.Delete("/services/:id/permanent", middleware.Require(permission.ServiceManage), h.HardDeleteService)
+
+	api.Post("/queue/call-next", middleware.Require(permission.QueueCall), h.CallNextQueue)
+	api.Post("/queue/skip-and-next", middleware.Require(permission.QueueCall), h.SkipAndNext)
+	api.Post("/queue/update-status", middleware.Require(permission.QueueCall), h.UpdateQueueStatus)
+	api.Post("/queue/recall/:id", middleware.Require(permission.QueueCall), h.RecallQueue)
+	api.Post("/queue/announce/:id", middleware.Require(permission.QueueCall), h.RepeatAnnouncement)
+	api.Get("/reports/unit/visitors/export", middleware.Require(permission.ReportUnitExport), h.ExportUnitVisitorReport)
+	api.Get("/reports/unit/visitors/statistics", middleware.Require(permission.ReportUnitView), h.GetUnitVisitorStatistics)
+	api.Get("/dashboard/unit/statistics", middleware.Require(permission.DashboardUnitView), h.GetUnitDashboardStatistics)
+
+	return app
+}
@@ -0,0 +1,1152 @@
+package main
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/config"
+	"backend-antrian/internal/http/handler"
+	"backend-antrian/internal/totp"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fasthttp/websocket"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+|--------------------------------------------------------------------------
+| End-to-end test
+|--------------------------------------------------------------------------
+| Server di-boot lewat wiring yang sama dengan main (InitDB, checkSchema,
+| initServices, newApp, startWorkers) di atas file SQLite sementara, lalu
+| diuji lewat HTTP & WebSocket sungguhan di port acak.
+*/
+
+const (
+	adminEmail    = "admin@e2e.test"
+	adminPassword = "Admin#E2e2026"
+)
+
+var baseURL string
+
+// unitCounter - kode unit unik per test (A, B, C, ...)
+var unitCounter atomic.Int32
+
+// fixedClock - Rabu 14 Okt 2026 pukul hh:mm WIB
+func fixedClock(hour, minute int) clock.Clock {
+	return clock.Fixed(time.Date(2026, 10, 14, hour, minute, 0, 0, clock.Location()))
+}
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "antrian-e2e-")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	env := map[string]string{
+		"APP_TIMEZONE":            "Asia/Jakarta",
+		"DB_DRIVER":               "sqlite",
+		"DB_PATH":                 filepath.Join(dir, "e2e.db"),
+		"DB_AUTO_MIGRATE":         "true",
+		"JWT_SECRET":              "e2e-secret-e2e-secret-e2e-secret",
+		"CAPTCHA_PROVIDER":        "none",
+		"DISPLAY_ALLOW_ANONYMOUS": "true",
+		"TRUSTED_PROXIES":         "127.0.0.1",
+		"BASIC_AUTH_USER":         "prometheus",
+		"BASIC_AUTH_PASS":         "scrape-secret",
+	}
+	for k, v := range env {
+		os.Setenv(k, v)
+	}
+	flag.Parse()
+	// ./public/audio & ./temp (upload, export, /readyz) ditulis di folder sementara
+	if err := os.Chdir(dir); err != nil {
+		log.Fatal(err)
+	}
+	if testing.Verbose() {
+		config.InitLogger()
+	} else {
+		log.SetOutput(io.Discard)
+	}
+
+	config.InitTimezone()
+	config.InitDB()
+	checkSchema()
+	seedAdmin()
+
+	h := initServices()
+	app := newApp(h)
+	startWorkers(h)
+	clock.Set(fixedClock(10, 0))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatal(err)
+	}
+	baseURL = "http://" + ln.Addr().String()
+	go app.Listener(ln)
+
+	code := m.Run()
+
+	http.DefaultClient.CloseIdleConnections()
+	_ = app.ShutdownWithTimeout(5 * time.Second)
+	config.CloseDB()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// seedAdmin - super_user awal; 2FA dimatikan supaya login cukup password
+func seedAdmin() {
+	hash, err := bcrypt.GenerateFromPassword([]byte(adminPassword), bcrypt.MinCost)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := config.DB.Exec("UPDATE roles SET require_2fa = 'n'"); err != nil {
+		log.Fatal(err)
+	}
+	_, err = config.DB.Exec(`
+		INSERT INTO users (nama, email, password, role, is_banned, must_change_password)
+		VALUES ('Admin', ?, ?, 'super_user', 'n', 'n')
+	`, adminEmail, string(hash))
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+/*
+|--------------------------------------------------------------------------
+| HTTP client
+|--------------------------------------------------------------------------
+*/
+
+type apiClient struct {
+	token string
+	ip    string // X-Real-IP seolah request diteruskan nginx
+}
+
+// call - kirim request JSON, kembalikan status & body ter-decode
+func (a apiClient) call(t *testing.T, method, path string, body any) (int, map[string]any) {
+	t.Helper()
+	var r io.Reader
+	if body != nil {
+		b, _ := json.Marshal(body)
+		r = bytes.NewReader(b)
+	}
+	req, _ := http.NewRequest(method, baseURL+path, r)
+	req.Header.Set("Content-Type", "application/json")
+	if a.token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	}
+	if a.ip != "" {
+		req.Header.Set("X-Real-IP", a.ip)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	var out map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out
+}
+
+// mustCall - seperti call tapi gagal jika status tidak sesuai
+func (a apiClient) mustCall(t *testing.T, want int, method, path string, body any) map[string]any {
+	t.Helper()
+	status, out := a.call(t, method, path, body)
+	if status != want {
+		t.Fatalf("%s %s = %d, want %d: %v", method, path, status, want, out)
+	}
+	return out
+}
+
+func login(t *testing.T, email, password string) apiClient {
+	t.Helper()
+	out := apiClient{}.mustCall(t, http.StatusOK, "POST", "/san/login", map[string]any{
+		"email":    email,
+		"password": password,
+	})
+	token, _ := out["token"].(string)
+	if token == "" {
+		t.Fatalf("login %s tanpa token: %v", email, out)
+	}
+	return apiClient{token: token}
+}
+
+// userCounter - email unik untuk user yang dibuat test
+var userCounter atomic.Int32
+
+// newUser - super_user baru tanpa unit, kembalikan email-nya
+func newUser(t *testing.T, admin apiClient, password string) string {
+	t.Helper()
+	email := fmt.Sprintf("user%d@e2e.test", userCounter.Add(1))
+	admin.mustCall(t, http.StatusCreated, "POST", "/api/users", map[string]any{
+		"nama":       "User " + email,
+		"user_email": email,
+		"password":   password,
+		"role":       "super_user",
+	})
+	return email
+}
+
+// totpCode - kode authenticator untuk secret pada jam aplikasi
+func totpCode(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := totp.CodeAt(secret, totp.Step(clock.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// enableTwoFactor - aktifkan 2FA user yang sedang login, kembalikan secret
+// dan kode pemulihan
+func enableTwoFactor(t *testing.T, user apiClient) (string, []string) {
+	t.Helper()
+	secret := dataOf(user.mustCall(t, http.StatusOK, "POST", "/api/me/2fa/setup", nil))["secret"].(string)
+	out := user.mustCall(t, http.StatusOK, "POST", "/api/me/2fa/enable", map[string]any{"code": totpCode(t, secret)})
+	var codes []string
+	for _, c := range dataOf(out)["recovery_codes"].([]any) {
+		codes = append(codes, c.(string))
+	}
+	return secret, codes
+}
+
+// loginChallenge - login password untuk user ber-2FA, kembalikan challenge token
+func loginChallenge(t *testing.T, email, password string) string {
+	t.Helper()
+	out := apiClient{}.mustCall(t, http.StatusOK, "POST", "/san/login", map[string]any{
+		"email":    email,
+		"password": password,
+	})
+	if out["mfa_required"] != true || out["token"] != nil {
+		t.Fatalf("login %s tanpa langkah 2FA: %v", email, out)
+	}
+	return out["challenge_token"].(string)
+}
+
+// fixture - satu unit buka Rabu 08:00-15:00 dengan satu layanan, satu
+// petugas, dan satu kiosk untuk mengambil nomor
+type fixture struct {
+	admin, petugas, kiosk apiClient
+	unitID, serviceID     int64
+	unitCode, serviceKey  string
+	petugasEmail          string
+	kioskDevice           string
+	kioskSecret           string
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	n := unitCounter.Add(1)
+	letter := string(rune('A' + n - 1))
+	f := fixture{
+		admin:      login(t, adminEmail, adminPassword),
+		unitCode:   letter,
+		serviceKey: "SV" + letter,
+	}
+
+	unit := f.admin.mustCall(t, http.StatusCreated, "POST", "/api/units", map[string]any{
+		"code":      f.unitCode,
+		"nama_unit": "Unit " + f.unitCode,
+	})
+	f.unitID = int64(unit["data"].(map[string]any)["id"].(float64))
+
+	f.admin.mustCall(t, http.StatusOK, "POST", fmt.Sprintf("/api/units/%d/schedules", f.unitID), map[string]any{
+		"schedules": []map[string]any{
+			{"day_of_week": 3, "jam_buka": "08:00", "jam_tutup": "15:00", "is_active": "y"},
+		},
+	})
+
+	email := fmt.Sprintf("petugas%d@e2e.test", n)
+	f.admin.mustCall(t, http.StatusCreated, "POST", "/api/users", map[string]any{
+		"nama":       "Petugas " + f.unitCode,
+		"user_email": email,
+		"password":   "Petugas#2026x",
+		"role":       "unit",
+		"unit_id":    f.unitID,
+	})
+	f.petugasEmail = email
+	f.petugas = login(t, email, "Petugas#2026x")
+
+	service := f.petugas.mustCall(t, http.StatusCreated, "POST", "/api/services", map[string]any{
+		"nama_service": "Layanan " + f.serviceKey,
+		"code":         f.serviceKey,
+	})
+	f.serviceID = int64(service["data"].(map[string]any)["id"].(float64))
+
+	kiosk := dataOf(f.admin.mustCall(t, http.StatusCreated, "POST", "/api/kiosks", map[string]any{
+		"nama":     "Kiosk " + f.unitCode,
+		"unit_ids": []int64{f.unitID},
+	}))
+	f.kioskDevice = kiosk["kiosk"].(map[string]any)["device_id"].(string)
+	f.kioskSecret = kiosk["secret"].(string)
+	f.kiosk = f.kioskLogin(t)
+	return f
+}
+
+// kioskLogin - login kiosk fixture dengan device_id + secret
+func (f fixture) kioskLogin(t *testing.T) apiClient {
+	t.Helper()
+	auth := apiClient{}.mustCall(t, http.StatusOK, "POST", "/san/kiosk/auth", map[string]any{
+		"device_id": f.kioskDevice,
+		"secret":    f.kioskSecret,
+	})
+	return apiClient{token: auth["token"].(string)}
+}
+
+// take - ambil nomor antrian lewat kiosk, kembalikan ticket_code
+func (f fixture) take(t *testing.T) string {
+	t.Helper()
+	out := f.kiosk.mustCall(t, http.StatusCreated, "POST", "/api/queue/take", map[string]any{
+		"unit_id":    f.unitID,
+		"service_id": f.serviceID,
+	})
+	return out["data"].(map[string]any)["ticket"].(map[string]any)["ticket_code"].(string)
+}
+
+// pairDisplay - buat display audio untuk unit fixture lalu pasangkan,
+// kembalikan device_id & token perangkat
+func (f fixture) pairDisplay(t *testing.T) (string, string) {
+	t.Helper()
+	display := dataOf(f.admin.mustCall(t, http.StatusCreated, "POST", "/api/displays", map[string]any{
+		"nama":        "Display " + f.unitCode,
+		"plays_audio": "y",
+		"unit_ids":    []int64{f.unitID},
+	}))
+	deviceID := "tv-" + f.unitCode
+	paired := dataOf(apiClient{}.mustCall(t, http.StatusOK, "POST", "/san/display/pair", map[string]any{
+		"pairing_code": display["pairing_code"],
+		"device_id":    deviceID,
+	}))
+	return deviceID, paired["token"].(string)
+}
+
+// setClock - pindah jam aplikasi ke hh:mm lalu login ulang admin & kiosk;
+// token lama sudah kedaluwarsa menurut clock yang sama
+func (f *fixture) setClock(t *testing.T, hour, minute int) {
+	t.Helper()
+	clock.Set(fixedClock(hour, minute))
+	f.admin = login(t, adminEmail, adminPassword)
+	f.kiosk = f.kioskLogin(t)
+}
+
+func dataOf(out map[string]any) map[string]any {
+	data, _ := out["data"].(map[string]any)
+	return data
+}
+
+/*
+|--------------------------------------------------------------------------
+| WebSocket client
+|--------------------------------------------------------------------------
+*/
+
+func dialQueue(t *testing.T) *websocket.Conn {
+	t.Helper()
+	return dialQueueQuery(t, "protocol=delta")
+}
+
+// dialDisplay - /ws/queue sebagai display terpasang (device_id + token)
+func dialDisplay(t *testing.T, deviceID, token string) *websocket.Conn {
+	t.Helper()
+	return dialQueueQuery(t, "protocol=delta&device_id="+url.QueryEscape(deviceID)+"&token="+url.QueryEscape(token))
+}
+
+func dialQueueQuery(t *testing.T, query string) *websocket.Conn {
+	t.Helper()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+baseURL[len("http"):]+"/ws/queue?"+query, nil)
+	if err != nil {
+		t.Fatal("dial /ws/queue: ", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readUntil - baca pesan sampai match mengembalikan true (maks 5 detik)
+func readUntil(t *testing.T, conn *websocket.Conn, what string, match func(map[string]any) bool) map[string]any {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, raw, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("menunggu %s: %v", what, err)
+		}
+		var msg map[string]any
+		if json.Unmarshal(raw, &msg) == nil && match(msg) {
+			return msg
+		}
+	}
+}
+
+func ofType(typ string) func(map[string]any) bool {
+	return func(msg map[string]any) bool { return msg["type"] == typ }
+}
+
+/*
+|--------------------------------------------------------------------------
+| Tests
+|--------------------------------------------------------------------------
+*/
+
+func TestQueueFlow(t *testing.T) {
+	f := newFixture(t)
+
+	ws := dialQueue(t)
+	readUntil(t, ws, "snapshot awal", ofType("queue_update"))
+
+	first, second := f.take(t), f.take(t)
+	if first != f.serviceKey+"1" || second != f.serviceKey+"2" {
+		t.Fatalf("ticket code = %s, %s", first, second)
+	}
+	readUntil(t, ws, "stats_changed setelah take", func(msg map[string]any) bool {
+		if msg["type"] != "stats_changed" || dataOf(msg)["service_id"] != float64(f.serviceID) {
+			return false
+		}
+		stats, _ := dataOf(msg)["stats"].(map[string]any)
+		return stats["waiting_count"] == float64(2)
+	})
+
+	// call-next → ticket pertama dipanggil, display menerima ticket_called
+	call := dataOf(f.petugas.mustCall(t, http.StatusOK, "POST", "/api/queue/call-next", map[string]any{"service_id": f.serviceID}))
+	if call["ticket_code"] != first {
+		t.Fatalf("call-next = %v, want %s", call, first)
+	}
+	firstID := int64(call["ticket_id"].(float64))
+	called := readUntil(t, ws, "ticket_called", ofType("ticket_called"))
+	if ticket, _ := dataOf(called)["ticket"].(map[string]any); ticket["ticket_code"] != first || ticket["status"] != "called" {
+		t.Fatalf("ticket_called = %v", called)
+	}
+
+	// skip-and-next → pertama skipped, kedua dipanggil
+	next := dataOf(f.petugas.mustCall(t, http.StatusOK, "POST", "/api/queue/skip-and-next", map[string]any{"service_id": f.serviceID}))
+	if next["ticket_code"] != second {
+		t.Fatalf("skip-and-next = %v, want %s", next, second)
+	}
+	finished := readUntil(t, ws, "ticket_finished", ofType("ticket_finished"))
+	if dataOf(finished)["ticket_id"] != float64(firstID) || dataOf(finished)["status"] != "skipped" {
+		t.Fatalf("ticket_finished = %v", finished)
+	}
+
+	// recall → ticket pertama kembali waiting dan jadi berikutnya
+	f.petugas.mustCall(t, http.StatusOK, "POST", fmt.Sprintf("/api/queue/recall/%d", firstID), nil)
+	if status, _ := f.petugas.call(t, "POST", fmt.Sprintf("/api/queue/recall/%d", firstID), nil); status != http.StatusBadRequest {
+		t.Fatalf("recall ticket waiting = %d, want 400", status)
+	}
+
+	// finish ticket kedua, lalu panggil ulang yang di-recall
+	secondID := int64(next["ticket_id"].(float64))
+	f.petugas.mustCall(t, http.StatusOK, "POST", "/api/queue/update-status", map[string]any{"ticket_id": secondID, "status": "done"})
+	recalled := dataOf(f.petugas.mustCall(t, http.StatusOK, "POST", "/api/queue/call-next", map[string]any{"service_id": f.serviceID}))
+	if recalled["ticket_code"] != first {
+		t.Fatalf("call-next setelah recall = %v, want %s", recalled, first)
+	}
+	f.petugas.mustCall(t, http.StatusOK, "POST", "/api/queue/update-status", map[string]any{"ticket_id": firstID, "status": "done"})
+	if status, _ := f.petugas.call(t, "POST", "/api/queue/call-next", map[string]any{"service_id": f.serviceID}); status != http.StatusNotFound {
+		t.Fatalf("call-next antrian kosong = %d, want 404", status)
+	}
+
+	// Laporan & dashboard
+	dash := dataOf(f.petugas.mustCall(t, http.StatusOK, "GET", "/api/dashboard/unit/statistics", nil))
+	summary := dash["summary"].(map[string]any)
+	if summary["total_visitors"] != float64(2) || summary["total_served"] != float64(2) {
+		t.Fatalf("dashboard summary = %v", summary)
+	}
+
+	today := clock.Today(nil).Date()
+	report := dataOf(f.admin.mustCall(t, http.StatusOK, "GET", "/api/reports/visitors/statistics?start_date="+today+"&end_date="+today, nil))
+	found := false
+	for _, row := range report["instansi_data"].([]any) {
+		r := row.(map[string]any)
+		if r["nama"] == "Unit "+f.unitCode {
+			found = r["total"] == float64(2)
+		}
+	}
+	if !found {
+		t.Fatalf("laporan instansi = %v", report["instansi_data"])
+	}
+
+	// Riwayat transaksi lengkap: take x2, call, skip, call, recall, finish, call, finish
+	var events int
+	config.DB.QueryRow(`
+		SELECT COUNT(*) FROM queue_transactions qt
+		JOIN queue_tickets t ON t.id = qt.ticket_id
+		WHERE t.service_id = ?
+	`, f.serviceID).Scan(&events)
+	if events != 9 {
+		t.Fatalf("queue_transactions = %d, want 9", events)
+	}
+}
+
+func TestUpdateQueueStatusOtherUnit(t *testing.T) {
+	a, b := newFixture(t), newFixture(t)
+
+	a.take(t)
+	call := dataOf(a.petugas.mustCall(t, http.StatusOK, "POST", "/api/queue/call-next", map[string]any{"service_id": a.serviceID}))
+	ticketID := int64(call["ticket_id"].(float64))
+
+	// Petugas unit lain tidak boleh menyelesaikan / skip ticket unit A
+	for _, status := range []string{"done", "skipped"} {
+		if code, out := b.petugas.call(t, "POST", "/api/queue/update-status", map[string]any{"ticket_id": ticketID, "status": status}); code != http.StatusForbidden {
+			t.Fatalf("update-status %s lintas unit = %d %v, want 403", status, code, out)
+		}
+	}
+	var current string
+	config.DB.QueryRow("SELECT status FROM queue_tickets WHERE id = ?", ticketID).Scan(&current)
+	if current != "called" {
+		t.Fatalf("status ticket = %s, want called", current)
+	}
+
+	if code, _ := b.petugas.call(t, "POST", "/api/queue/update-status", map[string]any{"ticket_id": 999999, "status": "done"}); code != http.StatusNotFound {
+		t.Fatalf("update-status ticket tidak ada = %d, want 404", code)
+	}
+	a.petugas.mustCall(t, http.StatusOK, "POST", "/api/queue/update-status", map[string]any{"ticket_id": ticketID, "status": "done"})
+}
+
+func TestKioskAuthAndTake(t *testing.T) {
+	f := newFixture(t)
+
+	// Token kiosk dicek di middleware (tokens_valid_after, denylist) tiap request
+	if code := f.take(t); code != f.serviceKey+"1" {
+		t.Fatalf("ticket kiosk = %v, want %s1", code, f.serviceKey)
+	}
+
+	// queue.take sudah dicabut dari super_user (migrasi 0014)
+	if status, out := f.admin.call(t, "POST", "/api/queue/take", map[string]any{"unit_id": f.unitID, "service_id": f.serviceID}); status != http.StatusForbidden {
+		t.Fatalf("take oleh super_user = %d %v, want 403", status, out)
+	}
+}
+
+func TestLegacyKioskLogin(t *testing.T) {
+	f := newFixture(t)
+	anon := apiClient{}
+	legacy := func(email, password string) (int, map[string]any) {
+		return anon.call(t, "POST", "/san/login-kiosk", map[string]any{"email": email, "password": password})
+	}
+
+	// Tanpa KIOSK_LEGACY_DEVICE_ID endpoint mati
+	if status, _ := legacy(adminEmail, adminPassword); status != http.StatusGone {
+		t.Fatalf("login-kiosk tanpa konfigurasi = %d, want 410", status)
+	}
+	t.Setenv("KIOSK_LEGACY_DEVICE_ID", f.kioskDevice)
+
+	if status, _ := legacy(adminEmail, "salah"); status != http.StatusUnauthorized {
+		t.Fatalf("login-kiosk password salah = %d, want 401", status)
+	}
+	// Petugas unit tidak punya kiosk.manage
+	if status, _ := legacy(f.petugasEmail, "Petugas#2026x"); status != http.StatusForbidden {
+		t.Fatalf("login-kiosk petugas unit = %d, want 403", status)
+	}
+
+	status, out := legacy(adminEmail, adminPassword)
+	if status != http.StatusOK || out["refresh_token"] != nil {
+		t.Fatalf("login-kiosk = %d %v, want 200 tanpa sesi user", status, out)
+	}
+	kiosk := apiClient{token: out["token"].(string)}
+
+	// Token ber-role kiosk: boleh take, tidak boleh endpoint admin
+	kiosk.mustCall(t, http.StatusCreated, "POST", "/api/queue/take", map[string]any{
+		"unit_id":    f.unitID,
+		"service_id": f.serviceID,
+	})
+	if status, _ := kiosk.call(t, "GET", "/api/users", nil); status != http.StatusForbidden {
+		t.Fatalf("token login-kiosk ke /api/users = %d, want 403", status)
+	}
+}
+
+func TestAnnouncementReclaimBySameDisplay(t *testing.T) {
+	f := newFixture(t)
+	t.Cleanup(func() { clock.Set(fixedClock(10, 0)) })
+	deviceID, token := f.pairDisplay(t)
+	conn := dialDisplay(t, deviceID, token)
+
+	f.take(t)
+	f.petugas.mustCall(t, http.StatusOK, "POST", "/api/queue/call-next", map[string]any{"service_id": f.serviceID})
+
+	pull := func() map[string]any {
+		t.Helper()
+		if err := conn.WriteJSON(map[string]any{"type": "announcement_pull"}); err != nil {
+			t.Fatal(err)
+		}
+		return readUntil(t, conn, "hasil pull", func(msg map[string]any) bool {
+			return msg["type"] == "announcement" || msg["type"] == "announcement_empty"
+		})
+	}
+
+	first := pull()
+	if first["type"] != "announcement" {
+		t.Fatalf("pull pertama = %v", first)
+	}
+	seq := dataOf(first)["seq"]
+
+	// Tidak di-ack; selama lease dan jeda klaim ulang item tidak dikirim lagi
+	if msg := pull(); msg["type"] != "announcement_empty" {
+		t.Fatalf("pull saat lease aktif = %v", msg)
+	}
+	clock.Set(clock.Fixed(time.Date(2026, 10, 14, 10, 0, 31, 0, clock.Location())))
+	if msg := pull(); msg["type"] != "announcement_empty" {
+		t.Fatalf("pull dalam jeda klaim ulang = %v", msg)
+	}
+
+	// Satu-satunya display audio tetap mendapat item itu lagi
+	clock.Set(clock.Fixed(time.Date(2026, 10, 14, 10, 0, 40, 0, clock.Location())))
+	again := pull()
+	if again["type"] != "announcement" || dataOf(again)["seq"] != seq || dataOf(again)["attempt"] != float64(2) {
+		t.Fatalf("pull setelah lease habis = %v, want seq %v attempt 2", again, seq)
+	}
+
+	// Lewat announcementMaxAge item dibuang, tidak diputar lagi
+	clock.Set(clock.Fixed(time.Date(2026, 10, 14, 10, 3, 0, 0, clock.Location())))
+	if msg := pull(); msg["type"] != "announcement_empty" {
+		t.Fatalf("pull setelah kedaluwarsa = %v", msg)
+	}
+}
+
+func TestAnonymousDisplayPlaysAudio(t *testing.T) {
+	f := newFixture(t)
+	deviceID, token := f.pairDisplay(t)
+
+	// Display lama: tanpa pairing dan tanpa protocol=delta
+	anon := dialQueueQuery(t, "")
+	readUntil(t, anon, "snapshot awal", ofType("queue_update"))
+	paired := dialQueueQuery(t, "device_id="+url.QueryEscape(deviceID)+"&token="+url.QueryEscape(token))
+	readUntil(t, paired, "snapshot awal", ofType("queue_update"))
+
+	code := f.take(t)
+	f.petugas.mustCall(t, http.StatusOK, "POST", "/api/queue/call-next", map[string]any{"service_id": f.serviceID})
+
+	calledRow := func(conn *websocket.Conn) map[string]any {
+		t.Helper()
+		var row map[string]any
+		readUntil(t, conn, "queue_update setelah call-next", func(msg map[string]any) bool {
+			if msg["type"] != "queue_update" {
+				return false
+			}
+			items, _ := msg["data"].([]any)
+			for _, item := range items {
+				if q := item.(map[string]any); q["ticket_code"] == code && q["status"] == "called" {
+					row = q
+					return true
+				}
+			}
+			return false
+		})
+		return row
+	}
+
+	if row := calledRow(anon); row["should_play_audio"] != true {
+		t.Fatalf("display anonim: should_play_audio = %v, want true", row["should_play_audio"])
+	}
+	// Display terpasang memutar lewat announcement queue, bukan flag lama
+	if row := calledRow(paired); row["should_play_audio"] != false {
+		t.Fatalf("display terpasang: should_play_audio = %v, want false", row["should_play_audio"])
+	}
+}
+
+func TestPairDisplayBruteForceGuard(t *testing.T) {
+	f := newFixture(t)
+	// Counter IP dipakai bersama login; buka lagi supaya test lain tidak kena
+	t.Cleanup(func() {
+		f.admin.mustCall(t, http.StatusOK, "POST", "/api/security/unlock", map[string]any{"ip": "127.0.0.1"})
+	})
+
+	display := dataOf(f.admin.mustCall(t, http.StatusCreated, "POST", "/api/displays", map[string]any{
+		"nama":     "Display " + f.unitCode,
+		"unit_ids": []int64{f.unitID},
+	}))
+	expires, _ := time.Parse(time.RFC3339, display["pairing_expires_at"].(string))
+	if ttl := expires.Sub(clock.Now()); ttl <= 0 || ttl > 15*time.Minute {
+		t.Fatalf("pairing code berlaku %v, want <= 15m", ttl)
+	}
+
+	pair := func(code string) (int, map[string]any) {
+		return apiClient{}.call(t, "POST", "/san/display/pair", map[string]any{
+			"pairing_code": code,
+			"device_id":    "tv-guess-" + f.unitCode,
+		})
+	}
+	var status int
+	for i := 0; i < 20 && status != http.StatusTooManyRequests; i++ {
+		status, _ = pair(fmt.Sprintf("ZZZZ%02d", i))
+	}
+	if status != http.StatusTooManyRequests {
+		t.Fatalf("tebakan pairing code berulang = %d, want 429", status)
+	}
+
+	// Selama terkunci kode yang benar pun ditolak
+	if status, out := pair(display["pairing_code"].(string)); status != http.StatusTooManyRequests {
+		t.Fatalf("pair saat terkunci = %d %v, want 429", status, out)
+	}
+}
+
+func TestLoginLockoutPerClientIP(t *testing.T) {
+	admin := login(t, adminEmail, adminPassword)
+	const ipA, ipB = "203.0.113.10", "203.0.113.20"
+	t.Cleanup(func() {
+		for _, ip := range []string{ipA, ipB} {
+			admin.mustCall(t, http.StatusOK, "POST", "/api/security/unlock", map[string]any{"ip": ip})
+		}
+	})
+
+	// Email berbeda tiap percobaan: hanya counter IP yang bertambah
+	attempt := func(ip string, i int) int {
+		status, _ := apiClient{ip: ip}.call(t, "POST", "/san/login", map[string]any{
+			"email":    fmt.Sprintf("tebak%d-%d@e2e.test", i, time.Now().UnixNano()),
+			"password": "salah",
+		})
+		return status
+	}
+	var status int
+	for i := 0; i < 20 && status != http.StatusTooManyRequests; i++ {
+		status = attempt(ipA, i)
+	}
+	if status != http.StatusTooManyRequests {
+		t.Fatalf("gagal login berulang dari %s = %d, want 429", ipA, status)
+	}
+
+	// Klien lain di belakang nginx yang sama punya counter sendiri
+	if status := attempt(ipB, 0); status != http.StatusUnauthorized {
+		t.Fatalf("login dari %s = %d, want 401", ipB, status)
+	}
+	apiClient{ip: ipB}.mustCall(t, http.StatusOK, "POST", "/san/login", map[string]any{
+		"email":    adminEmail,
+		"password": adminPassword,
+	})
+}
+
+func TestRecoveryCodeLogin(t *testing.T) {
+	admin := login(t, adminEmail, adminPassword)
+	const password = "Pemulihan#2026"
+	email := newUser(t, admin, password)
+	_, codes := enableTwoFactor(t, login(t, email, password))
+
+	verify := func(code string) (int, map[string]any) {
+		return apiClient{}.call(t, "POST", "/san/login/2fa", map[string]any{
+			"challenge_token": loginChallenge(t, email, password),
+			"recovery_code":   code,
+		})
+	}
+	status, out := verify(codes[0])
+	if status != http.StatusOK || out["token"] == nil {
+		t.Fatalf("login dengan kode pemulihan = %d %v, want 200 + token", status, out)
+	}
+	if out["recovery_codes_remaining"] != float64(len(codes)-1) {
+		t.Fatalf("sisa kode pemulihan = %v, want %d", out["recovery_codes_remaining"], len(codes)-1)
+	}
+
+	// Kode pemulihan sekali pakai
+	if status, out := verify(codes[0]); status != http.StatusUnauthorized {
+		t.Fatalf("kode pemulihan dipakai ulang = %d %v, want 401", status, out)
+	}
+}
+
+func TestTwoFactorChallenge(t *testing.T) {
+	admin := login(t, adminEmail, adminPassword)
+	const password = "Dualangkah#2026"
+	email := newUser(t, admin, password)
+	secret, _ := enableTwoFactor(t, login(t, email, password))
+	t.Cleanup(func() { clock.Set(fixedClock(10, 0)) })
+
+	// Kode TOTP hanya berlaku sekali per langkah 30 detik; geser jam supaya
+	// tiap verifikasi memakai langkah baru
+	at := func(minute, second int) {
+		clock.Set(clock.Fixed(time.Date(2026, 10, 14, 10, minute, second, 0, clock.Location())))
+	}
+	verify := func(challenge, code string) (int, map[string]any) {
+		return apiClient{}.call(t, "POST", "/san/login/2fa", map[string]any{
+			"challenge_token": challenge,
+			"code":            code,
+		})
+	}
+
+	// Password saja tidak menghasilkan sesi; challenge bukan access token
+	challenge := loginChallenge(t, email, password)
+	if status, _ := (apiClient{token: challenge}).call(t, "GET", "/api/me", nil); status != http.StatusUnauthorized {
+		t.Fatalf("challenge sebagai bearer = %d, want 401", status)
+	}
+	if status, _ := verify(challenge, ""); status != http.StatusUnprocessableEntity {
+		t.Fatalf("verifikasi tanpa kode = %d, want 422", status)
+	}
+	if status, _ := verify("bukan-challenge", totpCode(t, secret)); status != http.StatusUnauthorized {
+		t.Fatalf("challenge palsu = %d, want 401", status)
+	}
+
+	// Challenge kedaluwarsa setelah 5 menit walau kodenya benar
+	at(6, 0)
+	if status, out := verify(challenge, totpCode(t, secret)); status != http.StatusUnauthorized {
+		t.Fatalf("challenge kedaluwarsa = %d %v, want 401", status, out)
+	}
+
+	challenge = loginChallenge(t, email, password)
+	status, out := verify(challenge, totpCode(t, secret))
+	if status != http.StatusOK || out["token"] == nil {
+		t.Fatalf("verifikasi = %d %v, want 200 + token", status, out)
+	}
+
+	// Challenge sekali pakai, dan kode yang sama tidak bisa dipakai ulang
+	at(6, 30)
+	if status, _ := verify(challenge, totpCode(t, secret)); status != http.StatusUnauthorized {
+		t.Fatalf("challenge dipakai ulang = %d, want 401", status)
+	}
+	at(7, 0)
+	code := totpCode(t, secret)
+	if status, _ := verify(loginChallenge(t, email, password), code); status != http.StatusOK {
+		t.Fatalf("verifikasi langkah baru = %d, want 200", status)
+	}
+	if status, _ := verify(loginChallenge(t, email, password), code); status != http.StatusUnauthorized {
+		t.Fatalf("kode TOTP dipakai ulang = %d, want 401", status)
+	}
+}
+
+func TestRefreshTokenRotationAndLogout(t *testing.T) {
+	admin := login(t, adminEmail, adminPassword)
+	const password = "Rotasi#2026x"
+	email := newUser(t, admin, password)
+	anon := apiClient{}
+
+	session := func() map[string]any {
+		t.Helper()
+		return anon.mustCall(t, http.StatusOK, "POST", "/san/login", map[string]any{"email": email, "password": password})
+	}
+	refresh := func(token any) (int, map[string]any) {
+		t.Helper()
+		return anon.call(t, "POST", "/san/refresh", map[string]any{"refresh_token": token})
+	}
+
+	// Rotasi: refresh token lama tidak berlaku lagi, dan memakainya ulang
+	// mengakhiri seluruh sesi (termasuk token hasil rotasi)
+	first := session()["refresh_token"]
+	status, out := refresh(first)
+	if status != http.StatusOK || out["refresh_token"] == nil || out["refresh_token"] == first {
+		t.Fatalf("refresh = %d %v, want 200 + refresh token baru", status, out)
+	}
+	rotated := out["refresh_token"]
+	if status, out := refresh(first); status != http.StatusUnauthorized {
+		t.Fatalf("refresh token lama = %d %v, want 401", status, out)
+	}
+	if status, out := refresh(rotated); status != http.StatusUnauthorized {
+		t.Fatalf("refresh setelah reuse terdeteksi = %d %v, want 401", status, out)
+	}
+
+	// Logout: access token & refresh token sesi itu ditolak
+	out = session()
+	user := apiClient{token: out["token"].(string)}
+	user.mustCall(t, http.StatusOK, "POST", "/api/logout", nil)
+	if status, _ := refresh(out["refresh_token"]); status != http.StatusUnauthorized {
+		t.Fatalf("refresh setelah logout = %d, want 401", status)
+	}
+	if status, _ := user.call(t, "GET", "/api/me", nil); status != http.StatusUnauthorized {
+		t.Fatalf("access token setelah logout = %d, want 401", status)
+	}
+
+	// Logout semua perangkat mengakhiri sesi lain juga
+	other := session()
+	out = session()
+	apiClient{token: out["token"].(string)}.mustCall(t, http.StatusOK, "POST", "/api/logout", map[string]any{"all": true})
+	if status, _ := refresh(other["refresh_token"]); status != http.StatusUnauthorized {
+		t.Fatalf("refresh sesi lain setelah logout all = %d, want 401", status)
+	}
+}
+
+func TestPasswordResetTokenSingleUse(t *testing.T) {
+	admin := login(t, adminEmail, adminPassword)
+	const password = "Awal#2026x"
+	email := newUser(t, admin, password)
+	me := dataOf(login(t, email, password).mustCall(t, http.StatusOK, "GET", "/api/me", nil))
+	userID := me["user"].(map[string]any)["id"]
+
+	reset := dataOf(admin.mustCall(t, http.StatusOK, "POST", fmt.Sprintf("/api/users/%v/password-reset", userID), nil))
+	token := reset["reset_token"].(string)
+	anon := apiClient{}
+
+	// Password lama ditolak riwayat; token belum terpakai
+	if status, out := anon.call(t, "POST", "/san/password/reset", map[string]any{"token": token, "new_password": password}); status != http.StatusBadRequest {
+		t.Fatalf("reset ke password lama = %d %v, want 400", status, out)
+	}
+	anon.mustCall(t, http.StatusOK, "POST", "/san/password/reset", map[string]any{"token": token, "new_password": "Baru#2026x"})
+	if status, out := anon.call(t, "POST", "/san/password/reset", map[string]any{"token": token, "new_password": "Lain#2026x"}); status != http.StatusBadRequest {
+		t.Fatalf("token dipakai ulang = %d %v, want 400", status, out)
+	}
+
+	if status, _ := anon.call(t, "POST", "/san/login", map[string]any{"email": email, "password": password}); status != http.StatusUnauthorized {
+		t.Fatalf("login password lama = %d, want 401", status)
+	}
+	login(t, email, "Baru#2026x")
+}
+
+func TestPasswordHistory(t *testing.T) {
+	// Riwayat pendek supaya bcrypt tidak memperlambat test
+	t.Setenv("PASSWORD_HISTORY", "3")
+	admin := login(t, adminEmail, adminPassword)
+	passwords := []string{"Riwayat#0x", "Riwayat#1x", "Riwayat#2x", "Riwayat#3x"}
+	email := newUser(t, admin, passwords[0])
+	user := login(t, email, passwords[0])
+
+	change := func(from, to string) (int, map[string]any) {
+		return user.call(t, "PUT", "/api/me/password", map[string]any{"current_password": from, "new_password": to})
+	}
+
+	// PASSWORD_HISTORY=3: password saat ini + 2 sebelumnya tidak boleh dipakai
+	for i := 1; i < len(passwords); i++ {
+		if status, out := change(passwords[i-1], passwords[i]); status != http.StatusOK {
+			t.Fatalf("ganti ke %s = %d %v", passwords[i], status, out)
+		}
+	}
+	current := passwords[len(passwords)-1]
+	for _, reused := range passwords[1:] {
+		if status, out := change(current, reused); status != http.StatusBadRequest {
+			t.Fatalf("pakai ulang %s = %d %v, want 400", reused, status, out)
+		}
+	}
+
+	// Password yang sudah keluar dari riwayat boleh dipakai lagi
+	if status, out := change(current, passwords[0]); status != http.StatusOK {
+		t.Fatalf("pakai password di luar riwayat = %d %v, want 200", status, out)
+	}
+}
+
+func TestUpdateUserPasswordNotEmail(t *testing.T) {
+	admin := login(t, adminEmail, adminPassword)
+	email := newUser(t, admin, "Awal#2026x")
+	me := dataOf(login(t, email, "Awal#2026x").mustCall(t, http.StatusOK, "GET", "/api/me", nil))
+	path := fmt.Sprintf("/api/users/%v", me["user"].(map[string]any)["id"])
+
+	// Email tidak diubah: password tetap dicek terhadap email yang tersimpan
+	if status, out := admin.call(t, "PUT", path, map[string]any{"password": strings.ToUpper(email)}); status != http.StatusBadRequest {
+		t.Fatalf("password = email lama = %d %v, want 400", status, out)
+	}
+	if status, out := admin.call(t, "PUT", path, map[string]any{"password": "Ganti" + email, "user_email": "Ganti" + email}); status != http.StatusBadRequest {
+		t.Fatalf("password = email baru = %d %v, want 400", status, out)
+	}
+	admin.mustCall(t, http.StatusOK, "PUT", path, map[string]any{"password": "Baru#2026x"})
+}
+
+func TestTakeQueueFollowsClock(t *testing.T) {
+	f := newFixture(t)
+	t.Cleanup(func() { clock.Set(fixedClock(10, 0)) })
+
+	stale := f.kiosk
+	f.setClock(t, 20, 0)
+	// Masa berlaku access token dihitung dari clock aplikasi
+	if status, out := stale.call(t, "POST", "/api/queue/take", map[string]any{"unit_id": f.unitID, "service_id": f.serviceID}); status != http.StatusUnauthorized {
+		t.Fatalf("token terbit 10:00 dipakai 20:00 = %d %v, want 401", status, out)
+	}
+	status, out := f.kiosk.call(t, "POST", "/api/queue/take", map[string]any{"unit_id": f.unitID, "service_id": f.serviceID})
+	if status != http.StatusBadRequest {
+		t.Fatalf("take di luar jam = %d %v, want 400", status, out)
+	}
+
+	f.setClock(t, 14, 59)
+	f.take(t)
+}
+
+func TestUnitTimezone(t *testing.T) {
+	f := newFixture(t)
+	t.Cleanup(func() { clock.Set(fixedClock(10, 0)) })
+	path := fmt.Sprintf("/api/units/%d", f.unitID)
+
+	if status, _ := f.admin.call(t, "PUT", path, map[string]any{"timezone": "Asia/Bandung"}); status != http.StatusUnprocessableEntity {
+		t.Fatalf("timezone tidak valid = %d, want 422", status)
+	}
+	f.admin.mustCall(t, http.StatusOK, "PUT", path, map[string]any{"timezone": "Asia/Makassar"})
+
+	// Jadwal 08:00-15:00 dibaca di WITA: 14:30 WIB = 15:30 WITA sudah tutup
+	f.setClock(t, 14, 30)
+	if status, out := f.kiosk.call(t, "POST", "/api/queue/take", map[string]any{"unit_id": f.unitID, "service_id": f.serviceID}); status != http.StatusBadRequest {
+		t.Fatalf("take 15:30 WITA = %d %v, want 400", status, out)
+	}
+
+	f.setClock(t, 13, 30)
+	if code := f.take(t); code != f.serviceKey+"1" {
+		t.Fatalf("ticket = %s, want %s1", code, f.serviceKey)
+	}
+}
+
+func TestConcurrentCallNext(t *testing.T) {
+	f := newFixture(t)
+
+	// Paksa request benar-benar paralel walau mesin CI hanya 1 core
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(max(4, runtime.NumCPU())))
+
+	const n = 20
+	for i := 0; i < n; i++ {
+		f.take(t)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		called  = make(map[string]int)
+		notOK   []int
+		request = map[string]any{"service_id": f.serviceID}
+	)
+	start := make(chan struct{})
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			status, out := f.petugas.call(t, "POST", "/api/queue/call-next", request)
+			mu.Lock()
+			defer mu.Unlock()
+			if status != http.StatusOK {
+				notOK = append(notOK, status)
+				return
+			}
+			called[dataOf(out)["ticket_code"].(string)]++
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	// Setiap request dapat ticket berbeda — tidak ada nomor dipanggil dua kali
+	if len(notOK) > 0 || len(called) != n {
+		t.Fatalf("call-next serentak: gagal %v, ticket %v", notOK, called)
+	}
+	for code, count := range called {
+		if count != 1 {
+			t.Fatalf("ticket %s dipanggil %d kali", code, count)
+		}
+	}
+
+	var dup int
+	config.DB.QueryRow(`
+		SELECT COUNT(*) FROM (
+			SELECT qt.ticket_id FROM queue_transactions qt
+			JOIN queue_tickets t ON t.id = qt.ticket_id
+			WHERE t.service_id = ? AND qt.event = 'call'
+			GROUP BY qt.ticket_id HAVING COUNT(*) > 1
+		) d
+	`, f.serviceID).Scan(&dup)
+	if dup != 0 {
+		t.Fatalf("%d ticket punya lebih dari satu transaksi call", dup)
+	}
+
+	// Call-next berurutan: hanya ticket terakhir yang masih called, sisanya
+	// masing-masing ditutup tepat sekali
+	var stillCalled, finished int
+	config.DB.QueryRow(`SELECT COUNT(*) FROM queue_tickets WHERE service_id = ? AND status = 'called'`,
+		f.serviceID).Scan(&stillCalled)
+	config.DB.QueryRow(`
+		SELECT COUNT(*) FROM queue_transactions qt
+		JOIN queue_tickets t ON t.id = qt.ticket_id
+		WHERE t.service_id = ? AND qt.event = 'finish'
+	`, f.serviceID).Scan(&finished)
+	if stillCalled != 1 || finished != n-1 {
+		t.Fatalf("called = %d (want 1), finish = %d (want %d)", stillCalled, finished, n-1)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	f := newFixture(t)
+	f.take(t)
+
+	if status, _ := (apiClient{}).call(t, "GET", "/metrics", nil); status != http.StatusUnauthorized {
+		t.Fatalf("/metrics tanpa auth = %d, want 401", status)
+	}
+
+	req, _ := http.NewRequest("GET", baseURL+"/metrics", nil)
+	req.SetBasicAuth("prometheus", "scrape-secret")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("/metrics = %d: %s", resp.StatusCode, body)
+	}
+
+	for _, want := range []string{
+		fmt.Sprintf(`antrian_queue_waiting_tickets{unit_id="%d",unit="%s",service_id="%d",service="%s"} 1`,
+			f.unitID, f.unitCode, f.serviceID, f.serviceKey),
+		fmt.Sprintf(`antrian_tickets_taken_total{unit_id="%d",service_id="%d"} 1`, f.unitID, f.serviceID),
+		`http_request_duration_seconds_count{method="POST",route="/api/queue/take",status="201"}`,
+		`antrian_ws_clients{endpoint="queue"}`,
+		"db_open_connections ",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("/metrics tanpa %q", want)
+		}
+	}
+}
+
+func TestHealthAndReadiness(t *testing.T) {
+	apiClient{}.mustCall(t, http.StatusOK, "GET", "/healthz", nil)
+
+	out := apiClient{}.mustCall(t, http.StatusOK, "GET", "/readyz", nil)
+	if out["status"] != "ready" || out["version"] == "" {
+		t.Fatalf("/readyz = %v", out)
+	}
+	checks, _ := out["checks"].(map[string]any)
+	want := map[string]string{
+		"database":          "ok",
+		"redis":             "skipped",
+		"migrations":        "ok",
+		"storage_audio":     "ok",
+		"storage_temp":      "ok",
+		"realtime_bus":      "ok",
+		"units_broadcaster": "ok",
+		"queue_worker":      "ok",
+	}
+	for name, status := range want {
+		check, _ := checks[name].(map[string]any)
+		if check["status"] != status {
+			t.Errorf("check %s = %v, want %s", name, check, status)
+		}
+	}
+}
+
+func TestCloseWebSocketsForShutdown(t *testing.T) {
+	queue := dialQueue(t)
+	readUntil(t, queue, "snapshot awal", ofType("queue_update"))
+
+	units, _, err := websocket.DefaultDialer.Dial("ws"+baseURL[len("http"):]+"/ws/units", nil)
+	if err != nil {
+		t.Fatal("dial /ws/units: ", err)
+	}
+	t.Cleanup(func() { units.Close() })
+	readUntil(t, units, "status unit awal", ofType("units_status"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := handler.CloseWebSockets(ctx, "reconnect"); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, conn := range map[string]*websocket.Conn{"/ws/queue": queue, "/ws/units": units} {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var closeErr *websocket.CloseError
+		for {
+			_, _, err := conn.ReadMessage()
+			if err == nil {
+				continue
+			}
+			if !errors.As(err, &closeErr) {
+				t.Fatalf("%s: %v, want close frame", name, err)
+			}
+			break
+		}
+		if closeErr.Code != websocket.CloseServiceRestart || closeErr.Text != "reconnect" {
+			t.Fatalf("%s close = %d %q, want 1012 \"reconnect\"", name, closeErr.Code, closeErr.Text)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"backend-antrian/internal/config"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// runHealthcheck - subcommand `server healthcheck [/healthz|/readyz]` untuk
+// HEALTHCHECK Docker (image runtime tidak punya curl/wget). Exit 0 jika 200.
+func runHealthcheck(args []string) {
+	path := "/healthz"
+	if len(args) > 0 {
+		path = args[0]
+	}
+	_ = godotenv.Load() // diam: tanpa log startup
+
+	url := fmt.Sprintf("http://127.0.0.1:%s%s", config.GetEnv("APP_PORT", "8080"), path)
+	client := http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "healthcheck:", err)
+		os.Exit(1)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		fmt.Fprintln(os.Stderr, "healthcheck:", url, resp.Status)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"backend-antrian/internal/config"
+	"backend-antrian/internal/migrate"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+const migrateUsage = `Penggunaan: server migrate <perintah>
+
+Perintah:
+  up              terapkan semua migrasi yang belum ada
+  down [N]        batalkan N migrasi terakhir (default 1)
+  status          tampilkan status tiap migrasi
+  create <nama>   buat pasangan file .up.sql/.down.sql baru untuk mysql dan sqlite
+                  (-dir untuk satu direktori saja)
+  force <versi>   tandai skema di versi tsb tanpa menjalankan SQL (database lama / pulih dari dirty)`
+
+// runMigrate - subcommand `server migrate ...`
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Println(migrateUsage)
+		os.Exit(2)
+	}
+
+	if args[0] == "create" {
+		// Setiap versi wajib ada untuk kedua backend
+		dirs := []string{"internal/migrate/mysql", "internal/migrate/sqlite"}
+		rest := args[1:]
+		if len(rest) >= 2 && rest[0] == "-dir" {
+			dirs, rest = []string{rest[1]}, rest[2:]
+		}
+		if len(rest) != 1 {
+			log.Fatal("create: nama migrasi wajib diisi")
+		}
+		for _, dir := range dirs {
+			up, down, err := migrate.Create(dir, rest[0])
+			if err != nil {
+				log.Fatal("create: ", err)
+			}
+			fmt.Println("Dibuat:", up)
+			fmt.Println("Dibuat:", down)
+		}
+		return
+	}
+
+	config.LoadEnv()
+	config.InitTimezone()
+	config.InitDB()
+	defer config.CloseDB()
+
+	m, err := migrate.New(config.DB, config.DBDriver)
+	if err != nil {
+		log.Fatal("migrate: ", err)
+	}
+	ctx := context.Background()
+
+	switch args[0] {
+	case "up":
+		done, err := m.Up(ctx)
+		for _, mig := range done {
+			fmt.Printf("up   %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			log.Fatal("up: ", err)
+		}
+		if len(done) == 0 {
+			fmt.Println("Skema sudah versi terbaru")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				log.Fatal("down: jumlah langkah tidak valid")
+			}
+		}
+		done, err := m.Down(ctx, steps)
+		for _, mig := range done {
+			fmt.Printf("down %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			log.Fatal("down: ", err)
+		}
+
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			log.Fatal("status: ", err)
+		}
+		for _, s := range statuses {
+			state := "pending"
+			if s.Dirty {
+				state = "DIRTY"
+			} else if s.AppliedAt != nil {
+				state = "applied " + s.AppliedAt.Format(time.DateTime)
+			}
+			fmt.Printf("%04d_%-28s %s\n", s.Version, s.Name, state)
+		}
+
+	case "force":
+		if len(args) != 2 {
+			log.Fatal("force: versi wajib diisi")
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 0 {
+			log.Fatal("force: versi tidak valid")
+		}
+		if err := m.Force(ctx, version); err != nil {
+			log.Fatal("force: ", err)
+		}
+		fmt.Printf("Skema ditandai versi %d\n", version)
+
+	default:
+		fmt.Println(migrateUsage)
+		os.Exit(2)
+	}
+}
+
+// checkSchema - tolak start jika skema belum versi terbaru.
+// DB_AUTO_MIGRATE=true menerapkan migrasi pending terlebih dahulu.
+func checkSchema() {
+	m, err := migrate.New(config.DB, config.DBDriver)
+	if err != nil {
+		fatal("Migrasi gagal dimuat", "err", err)
+	}
+	ctx := context.Background()
+
+	if config.GetEnv("DB_AUTO_MIGRATE", "false") == "true" {
+		done, err := m.Up(ctx)
+		for _, mig := range done {
+			slog.Info("Migrasi diterapkan", "version", mig.Version, "name", mig.Name)
+		}
+		if err != nil {
+			fatal("Migrasi gagal", "err", err)
+		}
+	}
+
+	if err := m.Check(ctx); err != nil {
+		if errors.Is(err, migrate.ErrOutdated) || errors.Is(err, migrate.ErrDirty) {
+			fatal("Server tidak dijalankan", "err", err)
+		}
+		fatal("Gagal memeriksa versi skema", "err", err)
+	}
+	slog.Info("Skema database", "version", m.Latest())
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"backend-antrian/internal/config"
+	"backend-antrian/internal/http/handler"
+	"backend-antrian/internal/realtime"
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// shutdownReason reason close frame WebSocket; display menyambung ulang segera
+const shutdownReason = "reconnect"
+
+// gracefulShutdown hentikan server dalam batas ctx:
+//  1. tutup listener, tunggu request HTTP yang sedang jalan (mis. call-next)
+//  2. kirim close frame "reconnect" ke semua display /ws/queue & /ws/units
+//  3. tunggu broadcast debounce & delta yang masih terjadwal
+//  4. tutup realtime bus lalu DB
+//
+// Langkah yang melewati batas waktu dicatat lalu dilewati.
+func gracefulShutdown(ctx context.Context, app *fiber.App) {
+	start := time.Now()
+
+	httpDone := make(chan error, 1)
+	go func() { httpDone <- app.ShutdownWithContext(ctx) }()
+
+	// WebSocket di-hijack dari fasthttp, tidak ditunggu Shutdown — putus sekarang
+	if err := handler.CloseWebSockets(ctx, shutdownReason); err != nil {
+		slog.Warn("Shutdown: tutup websocket", "err", err)
+	}
+	if err := <-httpDone; err != nil {
+		slog.Warn("Shutdown: request HTTP belum selesai", "err", err)
+	}
+	if err := handler.DrainBroadcasts(ctx); err != nil {
+		slog.Warn("Shutdown: broadcast belum selesai", "err", err)
+	}
+
+	if err := realtime.Bus.Close(); err != nil {
+		slog.Warn("Shutdown: realtime bus", "err", err)
+	}
+	config.CloseDB()
+
+	slog.Info("Server berhenti", "duration_ms", time.Since(start).Milliseconds())
+}
@@ -0,0 +1,237 @@
+package audit
+
+import (
+	"backend-antrian/internal/config"
+	"backend-antrian/internal/logger"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Entry satu baris audit log
+type Entry struct {
+	ActorType  string // user, kiosk, system
+	ActorID    *int64
+	ActorName  string
+	Action     string
+	Entity     string
+	EntityID   string
+	Before     interface{}
+	After      interface{}
+	StatusCode int
+	Method     string
+	Path       string
+	IP         string
+	UserAgent  string
+}
+
+var auditLog = logger.For("audit")
+
+// Change perubahan satu field
+type Change struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Record simpan entry ke audit_logs. Diff dihitung otomatis jika
+// Before dan After sama-sama snapshot baris (map).
+func Record(ctx context.Context, e Entry) error {
+	if e.ActorType == "" {
+		e.ActorType = "system"
+	}
+
+	var diff interface{}
+	before, okBefore := e.Before.(map[string]interface{})
+	after, okAfter := e.After.(map[string]interface{})
+	if okBefore && okAfter {
+		diff = Diff(before, after)
+	}
+
+	_, err := config.DB.ExecContext(ctx, `
+		INSERT INTO audit_logs
+		(actor_type, actor_id, actor_name, action, entity, entity_id,
+		 before_data, after_data, diff, status_code, method, path, ip_address, user_agent)
+		VALUES (?, ?, NULLIF(?, ''), ?, NULLIF(?, ''), NULLIF(?, ''), ?, ?, ?, ?, NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''), NULLIF(?, ''))
+	`, e.ActorType, e.ActorID, e.ActorName, e.Action, e.Entity, e.EntityID,
+		toJSON(e.Before), toJSON(e.After), toJSON(diff), e.StatusCode,
+		e.Method, truncate(e.Path, 255), e.IP, truncate(e.UserAgent, 255))
+	if err != nil {
+		auditLog.ErrorContext(ctx, "record error", "action", e.Action, "err", err)
+	}
+	return err
+}
+
+// Diff field yang berubah antara dua snapshot
+func Diff(before, after map[string]interface{}) map[string]Change {
+	changes := map[string]Change{}
+	for k, b := range before {
+		a, ok := after[k]
+		if !ok || !reflect.DeepEqual(b, a) {
+			changes[k] = Change{Before: b, After: a}
+		}
+	}
+	for k, a := range after {
+		if _, ok := before[k]; !ok {
+			changes[k] = Change{Before: nil, After: a}
+		}
+	}
+	return changes
+}
+
+/*
+|--------------------------------------------------------------------------
+| Snapshot entity
+|--------------------------------------------------------------------------
+| Entity (segmen pertama path /api/...) dipetakan ke tabel supaya middleware
+| bisa mengambil snapshot baris sebelum dan sesudah handler berjalan.
+*/
+
+type snapshotter func(id string) (interface{}, error)
+
+var entities = map[string]snapshotter{
+	"users":          rowBy("users", "id"),
+	"roles":          rowBy("roles", "name"),
+	"units":          rowBy("units", "id"),
+	"unit_schedules": rowsBy("unit_schedules", "unit_id"),
+	"services":       rowBy("services", "id"),
+	"faqs":           rowBy("faqs", "id"),
+	"audio":          rowBy("tts_audio_cache", "id"),
+	"displays":       rowBy("displays", "id"),
+	"kiosks":         rowBy("kiosks", "id"),
+	"queue_tickets":  rowBy("queue_tickets", "id"),
+	"config":         firstRow("configs"),
+}
+
+// Known cek apakah entity punya snapshotter
+func Known(entity string) bool {
+	_, ok := entities[entity]
+	return ok
+}
+
+// Snapshot ambil kondisi entity saat ini; nil jika tidak dikenal / tidak ada.
+func Snapshot(ctx context.Context, entity, id string) interface{} {
+	snap, ok := entities[entity]
+	if !ok {
+		return nil
+	}
+	data, err := snap(id)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			auditLog.ErrorContext(ctx, "snapshot error", "entity", entity, "entity_id", id, "err", err)
+		}
+		return nil
+	}
+	return data
+}
+
+func rowBy(table, column string) snapshotter {
+	return func(id string) (interface{}, error) {
+		if id == "" {
+			return nil, sql.ErrNoRows
+		}
+		rows, err := queryMaps(fmt.Sprintf("SELECT * FROM %s WHERE %s = ? LIMIT 1", table, column), id)
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			return nil, sql.ErrNoRows
+		}
+		return rows[0], nil
+	}
+}
+
+func rowsBy(table, column string) snapshotter {
+	return func(id string) (interface{}, error) {
+		if id == "" {
+			return nil, sql.ErrNoRows
+		}
+		return queryMaps(fmt.Sprintf("SELECT * FROM %s WHERE %s = ? ORDER BY id", table, column), id)
+	}
+}
+
+func firstRow(table string) snapshotter {
+	return func(string) (interface{}, error) {
+		rows, err := queryMaps(fmt.Sprintf("SELECT * FROM %s ORDER BY id LIMIT 1", table))
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == 0 {
+			return nil, sql.ErrNoRows
+		}
+		return rows[0], nil
+	}
+}
+
+// queryMaps jalankan query dan ubah setiap baris jadi map kolom → nilai.
+// Kolom rahasia (password, secret, token hash) tidak ikut disimpan.
+func queryMaps(query string, args ...interface{}) ([]map[string]interface{}, error) {
+	rows, err := config.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []map[string]interface{}{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+
+		row := map[string]interface{}{}
+		for i, col := range columns {
+			if sensitiveColumn(col) {
+				continue
+			}
+			switch v := values[i].(type) {
+			case []byte:
+				row[col] = string(v)
+			case time.Time:
+				row[col] = v.Format("2006-01-02 15:04:05")
+			default:
+				row[col] = v
+			}
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+func sensitiveColumn(col string) bool {
+	col = strings.ToLower(col)
+	return strings.Contains(col, "password") || strings.Contains(col, "secret") || strings.HasSuffix(col, "token_hash")
+}
+
+func toJSON(v interface{}) interface{} {
+	if v == nil {
+		return nil
+	}
+	if m, ok := v.(map[string]Change); ok && len(m) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return string(b)
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,49 @@
+// Package buildinfo versi binary dari informasi build Go (modul & VCS).
+package buildinfo
+
+import (
+	"runtime/debug"
+	"sync"
+)
+
+// Version versi binary: tag/pseudo-version modul jika ada, selain itu
+// revisi git (12 karakter, "+dirty" jika ada perubahan belum di-commit),
+// "dev" jika dibangun tanpa info VCS (mis. go run / tanpa .git).
+var Version = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "dev"
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+
+	var revision string
+	var dirty bool
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			revision = s.Value
+		case "vcs.modified":
+			dirty = s.Value == "true"
+		}
+	}
+	if revision == "" {
+		return "dev"
+	}
+	if len(revision) > 12 {
+		revision = revision[:12]
+	}
+	if dirty {
+		revision += "+dirty"
+	}
+	return revision
+})
+
+// GoVersion versi toolchain yang membangun binary
+func GoVersion() string {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		return info.GoVersion
+	}
+	return ""
+}
@@ -0,0 +1,158 @@
+// Package captcha verifikasi token captcha dengan provider yang bisa diganti:
+// reCAPTCHA, API siteverify sejenis (hCaptcha, Turnstile), proof-of-work
+// lokal untuk jaringan tanpa akses internet, dan no-op untuk test.
+package captcha
+
+import (
+	"backend-antrian/internal/config"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Result hasil verifikasi dari provider
+type Result struct {
+	Success  bool
+	Score    float64 // 0..1; provider tanpa skor mengisi 1 jika sukses
+	HasScore bool
+	Action   string
+}
+
+// CaptchaVerifier provider captcha
+type CaptchaVerifier interface {
+	Name() string
+	Verify(ctx context.Context, token, remoteIP string) (Result, error)
+}
+
+// ChallengeIssuer provider yang menerbitkan challenge sendiri (proof-of-work)
+type ChallengeIssuer interface {
+	Challenge() (Challenge, error)
+}
+
+// Challenge data untuk frontend sebelum submit form
+type Challenge struct {
+	Challenge  string `json:"challenge"`
+	Difficulty int    `json:"difficulty"`
+	ExpiresAt  int64  `json:"expires_at"`
+}
+
+var (
+	ErrMissingToken = errors.New("captcha: token kosong")
+	ErrRejected     = errors.New("captcha: verifikasi ditolak")
+)
+
+// Guard provider + aturan skor dan action
+type Guard struct {
+	Verifier CaptchaVerifier
+	MinScore float64 // skor minimum; diabaikan jika provider tidak memberi skor
+	Action   string  // action yang wajib dikembalikan provider; kosong = tidak dicek
+	SiteKey  string  // site key publik untuk frontend
+}
+
+// Check verifikasi token; error ErrMissingToken / ErrRejected untuk token
+// yang tidak lolos, error lain untuk kegagalan jaringan/provider.
+func (g *Guard) Check(ctx context.Context, token, remoteIP string) error {
+	if _, ok := g.Verifier.(NoopVerifier); ok {
+		return nil
+	}
+	if strings.TrimSpace(token) == "" {
+		return ErrMissingToken
+	}
+
+	res, err := g.Verifier.Verify(ctx, token, remoteIP)
+	if err != nil {
+		return err
+	}
+	if !res.Success {
+		return ErrRejected
+	}
+	if res.HasScore && res.Score < g.MinScore {
+		return fmt.Errorf("%w: skor %.2f < %.2f", ErrRejected, res.Score, g.MinScore)
+	}
+	// Action wajib cocok bila dikonfigurasi; token tanpa action juga ditolak
+	if g.Action != "" && res.Action != g.Action {
+		return fmt.Errorf("%w: action %q", ErrRejected, res.Action)
+	}
+	return nil
+}
+
+// Default guard aplikasi; Configure mengisi dari env saat start.
+var Default = &Guard{Verifier: NoopVerifier{}}
+
+// Configure pilih provider dari env:
+//
+//	CAPTCHA_PROVIDER   recaptcha (default) | hcaptcha | turnstile | pow | none
+//	CAPTCHA_SECRET     secret key provider (fallback RECAPTCHA_SECRET_KEY)
+//	CAPTCHA_SITE_KEY   site key publik, dikirim ke frontend
+//	CAPTCHA_VERIFY_URL URL siteverify (mis. proxy intranet / self-hosted)
+//	CAPTCHA_MIN_SCORE  skor minimum, default 0.5
+//	CAPTCHA_ACTION     action yang wajib cocok (reCAPTCHA v3, Turnstile), kosong = tidak dicek
+//	CAPTCHA_POW_DIFFICULTY jumlah bit nol proof-of-work, default 20
+//
+// replay mencatat challenge proof-of-work terpakai; berikan store Redis
+// supaya berlaku lintas replica.
+func Configure(replay ReplayStore) error {
+	secret := config.GetEnv("CAPTCHA_SECRET", os.Getenv("RECAPTCHA_SECRET_KEY"))
+	verifyURL := os.Getenv("CAPTCHA_VERIFY_URL")
+
+	minScore := 0.5
+	if v := os.Getenv("CAPTCHA_MIN_SCORE"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f < 0 || f > 1 {
+			return fmt.Errorf("captcha: CAPTCHA_MIN_SCORE harus 0..1")
+		}
+		minScore = f
+	}
+
+	var verifier CaptchaVerifier
+	switch provider := strings.ToLower(config.GetEnv("CAPTCHA_PROVIDER", "recaptcha")); provider {
+	case "recaptcha":
+		verifier = NewSiteVerify(provider, secret, config.GetEnv("CAPTCHA_VERIFY_URL", RecaptchaURL))
+	case "hcaptcha":
+		verifier = NewSiteVerify(provider, secret, config.GetEnv("CAPTCHA_VERIFY_URL", HCaptchaURL))
+	case "turnstile":
+		verifier = NewSiteVerify(provider, secret, config.GetEnv("CAPTCHA_VERIFY_URL", TurnstileURL))
+	case "siteverify":
+		if verifyURL == "" {
+			return fmt.Errorf("captcha: CAPTCHA_VERIFY_URL wajib untuk provider siteverify")
+		}
+		verifier = NewSiteVerify(provider, secret, verifyURL)
+	case "pow":
+		key := config.GetEnv("CAPTCHA_POW_SECRET", os.Getenv("JWT_SECRET"))
+		if key == "" {
+			return fmt.Errorf("captcha: CAPTCHA_POW_SECRET atau JWT_SECRET wajib untuk provider pow")
+		}
+		verifier = NewProofOfWork([]byte(key), config.GetEnvInt("CAPTCHA_POW_DIFFICULTY", DefaultPoWDifficulty), replay)
+	case "none":
+		slog.Warn("Captcha dimatikan (CAPTCHA_PROVIDER=none)")
+		verifier = NoopVerifier{}
+	default:
+		return fmt.Errorf("captcha: provider %q tidak dikenal", provider)
+	}
+
+	action := os.Getenv("CAPTCHA_ACTION")
+	if _, ok := verifier.(*ProofOfWork); ok && action != "" {
+		return fmt.Errorf("captcha: CAPTCHA_ACTION tidak didukung provider pow")
+	}
+
+	Default = &Guard{
+		Verifier: verifier,
+		MinScore: minScore,
+		Action:   action,
+		SiteKey:  os.Getenv("CAPTCHA_SITE_KEY"),
+	}
+	return nil
+}
+
+// NoopVerifier selalu lolos; untuk test dan development.
+type NoopVerifier struct{}
+
+func (NoopVerifier) Name() string { return "none" }
+
+func (NoopVerifier) Verify(context.Context, string, string) (Result, error) {
+	return Result{Success: true, Score: 1}, nil
+}
@@ -0,0 +1,133 @@
+package captcha
+
+import (
+	"backend-antrian/internal/loginguard"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func siteVerifyServer(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		if r.PostForm.Get("secret") != "s3cret" || r.PostForm.Get("response") == "" {
+			t.Errorf("form tidak lengkap: %v", r.PostForm)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSiteVerifyScoreAndAction(t *testing.T) {
+	cases := []struct {
+		name string
+		body string
+		want error
+	}{
+		{"lolos", `{"success":true,"score":0.9,"action":"login"}`, nil},
+		{"skor rendah", `{"success":true,"score":0.3,"action":"login"}`, ErrRejected},
+		{"action beda", `{"success":true,"score":0.9,"action":"signup"}`, ErrRejected},
+		{"gagal", `{"success":false,"error-codes":["invalid-input-response"]}`, ErrRejected},
+		{"tanpa skor", `{"success":true,"action":"login"}`, nil},
+		{"tanpa action", `{"success":true,"score":0.9}`, ErrRejected},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := siteVerifyServer(t, tc.body)
+			g := &Guard{Verifier: NewSiteVerify("hcaptcha", "s3cret", srv.URL), MinScore: 0.5, Action: "login"}
+
+			err := g.Check(context.Background(), "token", "10.0.0.1")
+			if !errors.Is(err, tc.want) && !(tc.want == nil && err == nil) {
+				t.Fatalf("Check() = %v, mau %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestMissingToken(t *testing.T) {
+	g := &Guard{Verifier: NewSiteVerify("recaptcha", "s3cret", "http://127.0.0.1:0")}
+	if err := g.Check(context.Background(), " ", ""); !errors.Is(err, ErrMissingToken) {
+		t.Fatalf("Check() = %v, mau ErrMissingToken", err)
+	}
+}
+
+func TestNoopAcceptsEmptyToken(t *testing.T) {
+	g := &Guard{Verifier: NoopVerifier{}, MinScore: 0.9}
+	if err := g.Check(context.Background(), "", ""); err != nil {
+		t.Fatalf("Check() = %v", err)
+	}
+}
+
+func TestProofOfWork(t *testing.T) {
+	pow := NewProofOfWork([]byte("kunci"), 8, nil)
+	g := &Guard{Verifier: pow, MinScore: 0.5}
+
+	ch, err := pow.Challenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := ch.Challenge + ":" + Solve(ch.Challenge, ch.Difficulty)
+
+	if err := g.Check(context.Background(), token, ""); err != nil {
+		t.Fatalf("token valid ditolak: %v", err)
+	}
+	if err := g.Check(context.Background(), token, ""); !errors.Is(err, ErrRejected) {
+		t.Fatalf("replay = %v, mau ErrRejected", err)
+	}
+
+	// Challenge dari key lain tidak berlaku
+	other, _ := NewProofOfWork([]byte("lain"), 8, nil).Challenge()
+	forged := other.Challenge + ":" + Solve(other.Challenge, other.Difficulty)
+	if err := g.Check(context.Background(), forged, ""); !errors.Is(err, ErrRejected) {
+		t.Fatalf("challenge palsu = %v, mau ErrRejected", err)
+	}
+}
+
+func TestProofOfWorkReplayAcrossReplicas(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := func() ReplayStore {
+		client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { client.Close() })
+		return loginguard.NewRedisStore(client, "antrian:login:")
+	}
+
+	// Dua replica dengan key sama berbagi catatan challenge lewat Redis
+	replicaA := &Guard{Verifier: NewProofOfWork([]byte("kunci"), 8, store())}
+	replicaB := &Guard{Verifier: NewProofOfWork([]byte("kunci"), 8, store())}
+
+	ch, err := replicaA.Verifier.(ChallengeIssuer).Challenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	token := ch.Challenge + ":" + Solve(ch.Challenge, ch.Difficulty)
+
+	if err := replicaA.Check(context.Background(), token, ""); err != nil {
+		t.Fatalf("token valid ditolak: %v", err)
+	}
+	if err := replicaB.Check(context.Background(), token, ""); !errors.Is(err, ErrRejected) {
+		t.Fatalf("replay di replica lain = %v, mau ErrRejected", err)
+	}
+}
+
+func TestActionRequiredWhenConfigured(t *testing.T) {
+	g := &Guard{Verifier: NewProofOfWork([]byte("kunci"), 8, nil), Action: "login"}
+	ch, _ := g.Verifier.(ChallengeIssuer).Challenge()
+	token := ch.Challenge + ":" + Solve(ch.Challenge, ch.Difficulty)
+
+	// Provider tanpa action tidak lolos bila action dikonfigurasi
+	if err := g.Check(context.Background(), token, ""); !errors.Is(err, ErrRejected) {
+		t.Fatalf("Check() = %v, mau ErrRejected", err)
+	}
+}
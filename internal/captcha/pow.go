@@ -0,0 +1,177 @@
+package captcha
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultPoWDifficulty = 20
+	powChallengeTTL      = 5 * time.Minute
+)
+
+/*
+|--------------------------------------------------------------------------
+| Proof-of-work lokal
+|--------------------------------------------------------------------------
+| Tanpa layanan pihak ketiga: server menerbitkan challenge bertanda tangan
+| HMAC "exp.difficulty.rand.sig", klien mencari nonce sehingga
+| sha256(challenge + ":" + nonce) diawali minimal `difficulty` bit nol, lalu
+| mengirim token "challenge:nonce". Challenge stateless (bisa diverifikasi
+| replica mana pun); challenge terpakai dicatat di ReplayStore. Dengan Redis
+| (store lockout login) catatan itu dipakai bersama semua replica. Tanpa
+| Redis catatan hanya per proses: di belakang load balancer satu token bisa
+| dipakai ulang sekali di tiap replica lain sampai challenge kedaluwarsa.
+*/
+
+// ReplayStore penanda challenge sekali pakai; Claim false jika sudah dipakai.
+// loginguard.Store memenuhi interface ini.
+type ReplayStore interface {
+	Claim(ctx context.Context, key string, d time.Duration) (bool, error)
+}
+
+type ProofOfWork struct {
+	key        []byte
+	difficulty int
+	now        func() time.Time
+	replay     ReplayStore
+}
+
+// NewProofOfWork buat provider proof-of-work; replay nil = catatan per proses.
+func NewProofOfWork(key []byte, difficulty int, replay ReplayStore) *ProofOfWork {
+	if difficulty < 1 || difficulty > 32 {
+		difficulty = DefaultPoWDifficulty
+	}
+	p := &ProofOfWork{
+		key:        key,
+		difficulty: difficulty,
+		now:        time.Now,
+		replay:     replay,
+	}
+	if p.replay == nil {
+		p.replay = &memoryReplay{used: map[string]time.Time{}, now: func() time.Time { return p.now() }}
+	}
+	return p
+}
+
+func (p *ProofOfWork) Name() string { return "pow" }
+
+// Challenge terbitkan challenge baru
+func (p *ProofOfWork) Challenge() (Challenge, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return Challenge{}, err
+	}
+	exp := p.now().Add(powChallengeTTL).Unix()
+	payload := fmt.Sprintf("%d.%d.%s", exp, p.difficulty, hex.EncodeToString(b))
+
+	return Challenge{
+		Challenge:  payload + "." + p.sign(payload),
+		Difficulty: p.difficulty,
+		ExpiresAt:  exp,
+	}, nil
+}
+
+func (p *ProofOfWork) Verify(ctx context.Context, token, remoteIP string) (Result, error) {
+	idx := strings.LastIndex(token, ":")
+	if idx < 0 {
+		return Result{}, nil
+	}
+	challenge, nonce := token[:idx], token[idx+1:]
+
+	parts := strings.Split(challenge, ".")
+	if len(parts) != 4 {
+		return Result{}, nil
+	}
+	payload := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(p.sign(payload))) {
+		return Result{}, nil
+	}
+
+	exp, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || p.now().Unix() > exp {
+		return Result{}, nil
+	}
+	difficulty, err := strconv.Atoi(parts[1])
+	if err != nil || difficulty < p.difficulty {
+		return Result{}, nil
+	}
+
+	if LeadingZeroBits(sha256.Sum256([]byte(challenge+":"+nonce))) < difficulty {
+		return Result{}, nil
+	}
+
+	// Satu challenge hanya boleh dipakai sekali; simpan sampai kedaluwarsa
+	ttl := time.Unix(exp, 0).Sub(p.now()) + time.Second
+	fresh, err := p.replay.Claim(ctx, "captcha:pow:"+parts[2], ttl)
+	if err != nil {
+		return Result{}, fmt.Errorf("captcha: catat challenge: %w", err)
+	}
+	if !fresh {
+		return Result{}, nil
+	}
+
+	return Result{Success: true, Score: 1}, nil
+}
+
+// memoryReplay ReplayStore per proses, dipakai jika Redis tidak dikonfigurasi
+type memoryReplay struct {
+	mu   sync.Mutex
+	used map[string]time.Time // key → expiry
+	now  func() time.Time
+}
+
+func (m *memoryReplay) Claim(ctx context.Context, key string, d time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for k, e := range m.used {
+		if !now.Before(e) {
+			delete(m.used, k)
+		}
+	}
+	if _, seen := m.used[key]; seen {
+		return false, nil
+	}
+	m.used[key] = now.Add(d)
+	return true, nil
+}
+
+func (p *ProofOfWork) sign(payload string) string {
+	mac := hmac.New(sha256.New, p.key)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// LeadingZeroBits jumlah bit nol di awal hash
+func LeadingZeroBits(sum [sha256.Size]byte) int {
+	n := 0
+	for _, b := range sum {
+		if b == 0 {
+			n += 8
+			continue
+		}
+		return n + bits.LeadingZeros8(b)
+	}
+	return n
+}
+
+// Solve cari nonce untuk challenge (dipakai test dan klien Go).
+func Solve(challenge string, difficulty int) string {
+	for i := 0; ; i++ {
+		nonce := strconv.Itoa(i)
+		if LeadingZeroBits(sha256.Sum256([]byte(challenge+":"+nonce))) >= difficulty {
+			return nonce
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Package clock sumber waktu aplikasi: jam yang bisa diganti di test dan
+// zona waktu aplikasi (APP_TIMEZONE, default Asia/Jakarta).
+//
+// Semua timestamp di database disimpan sebagai jam dinding zona aplikasi:
+// sesi MySQL diset ke offset zona ini dan NOW() / CURDATE() SQLite memakai
+// Now(). Batas "hari ini" dihitung di Go (Day) lalu dikirim ke SQL sebagai
+// parameter, sehingga unit dengan zona sendiri (mis. WITA) tetap benar.
+package clock
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	// Image tanpa tzdata (alpine/scratch) tetap kenal Asia/Makassar dkk.
+	_ "time/tzdata"
+)
+
+// DefaultTimezone zona bawaan jika APP_TIMEZONE kosong
+const DefaultTimezone = "Asia/Jakarta"
+
+// SQLLayout format DATETIME di database
+const SQLLayout = "2006-01-02 15:04:05"
+
+// Clock sumber waktu sekarang
+type Clock interface {
+	Now() time.Time
+}
+
+// System jam sistem
+type System struct{}
+
+func (System) Now() time.Time { return time.Now() }
+
+// Fixed jam beku — untuk test
+type Fixed time.Time
+
+func (f Fixed) Now() time.Time { return time.Time(f) }
+
+var (
+	// current jam yang dipakai Now(); diganti lewat Set di test.
+	// Atomic karena worker background membaca jam saat test menggantinya.
+	current atomic.Pointer[Clock]
+
+	// location zona waktu aplikasi; diatur lewat SetLocation saat startup
+	location atomic.Pointer[time.Location]
+
+	locations sync.Map // nama zona -> *time.Location
+)
+
+func init() {
+	Reset()
+	location.Store(mustLoad(DefaultTimezone))
+}
+
+// Set ganti jam aplikasi (mis. Fixed di test)
+func Set(c Clock) {
+	current.Store(&c)
+}
+
+// Reset kembalikan jam aplikasi ke jam sistem
+func Reset() {
+	Set(System{})
+}
+
+// Default jam yang sedang dipakai Now()
+func Default() Clock {
+	return *current.Load()
+}
+
+// Location zona waktu aplikasi
+func Location() *time.Location {
+	return location.Load()
+}
+
+// SetLocation ganti zona waktu aplikasi (nama IANA, mis. "Asia/Makassar")
+func SetLocation(name string) error {
+	loc, err := LoadLocation(name)
+	if err != nil {
+		return err
+	}
+	location.Store(loc)
+	return nil
+}
+
+// LoadLocation time.LoadLocation dengan cache
+func LoadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// In zona unit; kosong atau tidak dikenal jatuh ke zona aplikasi
+func In(name string) *time.Location {
+	if name == "" {
+		return Location()
+	}
+	loc, err := LoadLocation(name)
+	if err != nil {
+		return Location()
+	}
+	return loc
+}
+
+// Now waktu sekarang di zona aplikasi
+func Now() time.Time {
+	return Default().Now().In(Location())
+}
+
+// SQL format t sebagai DATETIME jam dinding zona aplikasi
+func SQL(t time.Time) string {
+	return t.In(Location()).Format(SQLLayout)
+}
+
+// Day rentang [Start, End) satu hari kalender di zona tertentu
+type Day struct {
+	Start time.Time
+	End   time.Time
+}
+
+// DayOf hari kalender t di zona t sendiri
+func DayOf(t time.Time) Day {
+	y, m, d := t.Date()
+	return Day{
+		Start: time.Date(y, m, d, 0, 0, 0, 0, t.Location()),
+		End:   time.Date(y, m, d+1, 0, 0, 0, 0, t.Location()),
+	}
+}
+
+// Today hari ini di zona loc (nil = zona aplikasi)
+func Today(loc *time.Location) Day {
+	if loc == nil {
+		loc = Location()
+	}
+	return DayOf(Default().Now().In(loc))
+}
+
+// Date tanggal hari (YYYY-MM-DD) di zonanya sendiri
+func (d Day) Date() string {
+	return d.Start.Format(time.DateOnly)
+}
+
+// Bounds batas hari sebagai parameter SQL: created_at >= ? AND created_at < ?
+func (d Day) Bounds() (string, string) {
+	return SQL(d.Start), SQL(d.End)
+}
+
+func mustLoad(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
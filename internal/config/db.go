@@ -1,45 +1,92 @@
 package config
 
 import (
+	"backend-antrian/internal/dialect"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"os"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
+	_ "modernc.org/sqlite"
 )
 
 var DB *sql.DB
 
+// DBDriver backend database aktif: "mysql" (default) atau "sqlite" (env DB_DRIVER)
+var DBDriver = "mysql"
+
 func InitDB() {
-	var err error
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true",
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_NAME"),
-	)
-
-	DB, err = sql.Open("mysql", dsn)
+	DBDriver = GetEnv("DB_DRIVER", "mysql")
+
+	d, err := dialect.For(DBDriver)
 	if err != nil {
-		log.Fatal("Error opening database:", err)
+		fatal("Error opening database", "err", err)
 	}
+	dialect.Current = d
 
-	DB.SetMaxOpenConns(25)
-	DB.SetMaxIdleConns(25)
-	DB.SetConnMaxLifetime(5 * time.Minute)
+	DB, err = OpenDB(DBDriver)
+	if err != nil {
+		fatal("Error opening database", "driver", DBDriver, "err", err)
+	}
 
 	if err = DB.Ping(); err != nil {
-		log.Fatal("Error connecting to database:", err)
+		fatal("Error connecting to database", "driver", DBDriver, "err", err)
+	}
+
+	slog.Info("Database connected successfully", "driver", DBDriver)
+}
+
+// OpenDB buka pool database untuk driver dari konfigurasi env
+func OpenDB(driver string) (*sql.DB, error) {
+	switch driver {
+	case "sqlite":
+		return OpenSQLite(GetEnv("DB_PATH", "antrian.db"))
+	case "mysql":
+		dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?parseTime=true%s",
+			os.Getenv("DB_USER"),
+			os.Getenv("DB_PASSWORD"),
+			os.Getenv("DB_HOST"),
+			os.Getenv("DB_PORT"),
+			os.Getenv("DB_NAME"),
+			mysqlTimezoneParams(),
+		)
+		db, err := sql.Open("mysql", dsn)
+		if err != nil {
+			return nil, err
+		}
+		db.SetMaxOpenConns(25)
+		db.SetMaxIdleConns(25)
+		db.SetConnMaxLifetime(5 * time.Minute)
+		return db, nil
 	}
+	return nil, fmt.Errorf("DB_DRIVER %q tidak dikenal (mysql|sqlite)", driver)
+}
 
-	log.Println("Database connected successfully")
+// OpenSQLite buka file SQLite (dibuat jika belum ada).
+// WAL supaya pembaca tidak menunggu penulis; transaksi BEGIN IMMEDIATE
+// supaya SELECT ... lalu UPDATE di dalam transaksi tidak saling deadlock.
+func OpenSQLite(path string) (*sql.DB, error) {
+	dsn := "file:" + path +
+		"?_pragma=foreign_keys(1)" +
+		"&_pragma=busy_timeout(10000)" +
+		"&_pragma=journal_mode(WAL)" +
+		"&_pragma=synchronous(NORMAL)" +
+		"&_time_format=sqlite" +
+		"&_txlock=immediate"
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, err
+	}
+	// Satu file, satu penulis: pool kecil cukup
+	db.SetMaxOpenConns(8)
+	db.SetMaxIdleConns(8)
+	return db, nil
 }
 
 func CloseDB() {
 	if DB != nil {
 		DB.Close()
 	}
-}
\ No newline at end of file
+}
@@ -1,8 +1,10 @@
 package config
 
 import (
-	"log"
+	"log/slog"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -10,7 +12,7 @@ import (
 func LoadEnv() {
 	err := godotenv.Load()
 	if err != nil {
-		log.Println(".env tidak ditemukan, pakai env system")
+		slog.Info(".env tidak ditemukan, pakai env system")
 	}
 }
 
@@ -21,3 +23,21 @@ func GetEnv(key string, defaultVal string) string {
 	}
 	return val
 }
+
+// GetEnvInt baca env sebagai bilangan bulat positif, default jika kosong/tidak valid.
+func GetEnvInt(key string, defaultVal int) int {
+	n, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || n <= 0 {
+		return defaultVal
+	}
+	return n
+}
+
+// GetEnvDuration baca env sebagai durasi Go (mis. "15m"), default jika kosong/tidak valid.
+func GetEnvDuration(key string, defaultVal time.Duration) time.Duration {
+	d, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || d <= 0 {
+		return defaultVal
+	}
+	return d
+}
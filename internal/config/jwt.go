@@ -1,42 +1,89 @@
 package config
 
 import (
+	"backend-antrian/internal/clock"
+	"crypto/rand"
+	"encoding/hex"
 	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+const (
+	defaultAccessTokenTTL  = 15 * time.Minute
+	defaultRefreshTokenTTL = 7 * 24 * time.Hour
+)
+
 type JWTClaims struct {
 	UserID    int64  `json:"user_id"`
 	Nama      string `json:"nama"`
 	Email     string `json:"email"`
 	Role      string `json:"role"`
 	UnitID    *int64 `json:"unit_id,omitempty"`
+	SessionID string `json:"sid,omitempty"`
+	KioskID   int64  `json:"kiosk_id,omitempty"` // hanya untuk principal kiosk
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID int64, nama, email, role string, unitID *int64) (string, error) {
-	claims := JWTClaims{
+// AccessTokenTTL umur access token (env JWT_ACCESS_TTL, mis. "15m").
+func AccessTokenTTL() time.Duration {
+	return GetEnvDuration("JWT_ACCESS_TTL", defaultAccessTokenTTL)
+}
+
+// RefreshTokenTTL umur refresh token (env JWT_REFRESH_TTL, mis. "168h").
+func RefreshTokenTTL() time.Duration {
+	return GetEnvDuration("JWT_REFRESH_TTL", defaultRefreshTokenTTL)
+}
+
+// GenerateAccessToken buat access token berumur pendek untuk satu sesi.
+// jti unik dipakai untuk denylist saat logout.
+func GenerateAccessToken(userID int64, nama, email, role string, unitID *int64, sessionID string) (string, *JWTClaims, error) {
+	return signAccessToken(&JWTClaims{
 		UserID:    userID,
 		Nama:      nama,
 		Email:     email,
 		Role:      role,
 		UnitID:    unitID,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-		},
+		SessionID: sessionID,
+	})
+}
+
+// GenerateKioskToken buat access token untuk perangkat kiosk (role "kiosk").
+// Kiosk tidak punya refresh token; perangkat cukup autentikasi ulang.
+func GenerateKioskToken(kioskID int64, nama string) (string, *JWTClaims, error) {
+	return signAccessToken(&JWTClaims{
+		Nama:    nama,
+		Role:    "kiosk",
+		KioskID: kioskID,
+	})
+}
+
+func signAccessToken(claims *JWTClaims) (string, *JWTClaims, error) {
+	jti, err := RandomHex(16)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := clock.Now()
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        jti,
+		ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL())),
+		IssuedAt:  jwt.NewNumericDate(now),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	signed, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		return "", nil, err
+	}
+	return signed, claims, nil
 }
 
 func ValidateToken(tokenString string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithTimeFunc(clock.Now))
 
 	if err != nil {
 		return nil, err
@@ -47,4 +94,13 @@ func ValidateToken(tokenString string) (*JWTClaims, error) {
 	}
 
 	return nil, jwt.ErrSignatureInvalid
-}
\ No newline at end of file
+}
+
+// RandomHex string acak kriptografis sepanjang n byte (2n karakter hex).
+func RandomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
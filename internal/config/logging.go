@@ -0,0 +1,24 @@
+package config
+
+import (
+	"backend-antrian/internal/logger"
+	"log/slog"
+	"os"
+)
+
+// InitLogger pasang slog default dari LOG_LEVEL (debug|info|warn|error,
+// default info) dan LOG_FORMAT (json default, text untuk development).
+// log.Printf bawaan (library pihak ketiga) ikut keluar lewat handler ini.
+func InitLogger() {
+	level, err := logger.ParseLevel(os.Getenv("LOG_LEVEL"))
+	if err != nil {
+		fatal("LOG_LEVEL tidak valid", "err", err)
+	}
+	slog.SetDefault(logger.New(os.Stdout, level, GetEnv("LOG_FORMAT", "json")))
+}
+
+// fatal log error lalu keluar — padanan log.Fatal dalam format slog
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
@@ -0,0 +1,19 @@
+package config
+
+import "strings"
+
+// DefaultTrustedProxies subnet jaringan docker tempat container nginx berada
+const DefaultTrustedProxies = "172.18.0.0/16"
+
+// TrustedProxies IP/CIDR reverse proxy (nginx) yang header X-Real-IP-nya
+// dipercaya, dari TRUSTED_PROXIES (pisah koma). Request dari alamat lain
+// memakai IP koneksi apa adanya, jadi header tidak bisa dipalsukan klien.
+func TrustedProxies() []string {
+	var proxies []string
+	for _, p := range strings.Split(GetEnv("TRUSTED_PROXIES", DefaultTrustedProxies), ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			proxies = append(proxies, p)
+		}
+	}
+	return proxies
+}
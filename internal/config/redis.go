@@ -2,7 +2,7 @@ package config
 
 import (
 	"context"
-	"log"
+	"log/slog"
 	"os"
 	"strconv"
 
@@ -27,8 +27,8 @@ func InitRedis() {
 	})
 
 	if err := Redis.Ping(Ctx).Err(); err != nil {
-		log.Fatal("Redis tidak nyambung:", err)
+		fatal("Redis tidak nyambung", "addr", os.Getenv("REDIS_ADDR"), "err", err)
 	}
 
-	log.Println("Redis connected (DB", db, ")")
+	slog.Info("Redis connected", "db", db)
 }
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"backend-antrian/internal/clock"
+	"log/slog"
+	"net/url"
+	"os"
+	"time"
+)
+
+// InitTimezone set zona waktu aplikasi dari APP_TIMEZONE (default Asia/Jakarta).
+// Dipanggil sebelum InitDB: zona lokal proses ikut disamakan supaya
+// datetime('now', 'localtime') SQLite dan time.Now() tidak bergantung TZ image.
+func InitTimezone() {
+	name := GetEnv("APP_TIMEZONE", clock.DefaultTimezone)
+	if err := clock.SetLocation(name); err != nil {
+		fatal("APP_TIMEZONE tidak valid", "timezone", name, "err", err)
+	}
+
+	time.Local = clock.Location()
+	os.Setenv("TZ", name)
+
+	slog.Info("Timezone aplikasi", "timezone", name, "offset", clock.Now().Format("-07:00"))
+}
+
+// mysqlTimezoneParams parameter DSN supaya NOW() / CURDATE() sesi MySQL dan
+// nilai time.Time dari Go sama-sama jam dinding zona aplikasi.
+// Offset dipakai (bukan nama zona) karena tabel zona MySQL sering kosong.
+func mysqlTimezoneParams() string {
+	offset := clock.Now().Format("-07:00")
+	return "&loc=" + url.QueryEscape(clock.Location().String()) +
+		"&time_zone=" + url.QueryEscape("'"+offset+"'")
+}
@@ -0,0 +1,50 @@
+// Package dialect menampung perbedaan sintaks SQL antara MySQL dan SQLite.
+// Query ditulis dengan gaya MySQL; bagian yang tidak portabel (upsert,
+// INSERT IGNORE, FOR UPDATE, aritmetika INTERVAL) diambil dari Dialect aktif.
+// NOW() dan CURDATE() didaftarkan sebagai fungsi SQLite sehingga query yang
+// hanya memakai keduanya berjalan apa adanya di kedua backend.
+package dialect
+
+import "fmt"
+
+// Dialect potongan SQL yang berbeda per backend
+type Dialect interface {
+	// Name nama driver database/sql sekaligus direktori migrasi
+	Name() string
+
+	// InsertIgnore awalan INSERT yang melewati baris duplikat
+	InsertIgnore() string
+	// OnConflict awal klausa upsert untuk unique key cols; diikuti daftar
+	// "kolom = nilai" seperti SET
+	OnConflict(cols ...string) string
+	// Inserted nilai kolom dari baris yang batal di-insert, dipakai di klausa upsert
+	Inserted(col string) string
+	// ForUpdate akhiran SELECT untuk lock baris di dalam transaksi
+	ForUpdate() string
+	// ForUpdateSkipLocked seperti ForUpdate tapi melewati baris yang sedang di-lock
+	ForUpdateSkipLocked() string
+
+	// AddSeconds ekspresi waktu expr ditambah ? detik (placeholder diisi pemanggil)
+	AddSeconds(expr string) string
+	// SecondsBetween selisih detik (to - from) sebagai bilangan bulat
+	SecondsBetween(from, to string) string
+
+	// IsDuplicate error pelanggaran unique key
+	IsDuplicate(err error) bool
+	// IsReferenced error hapus/ubah baris yang masih dirujuk foreign key
+	IsReferenced(err error) bool
+}
+
+// Current dialect database yang sedang dipakai (diisi config.InitDB)
+var Current Dialect = MySQL{}
+
+// For dialect untuk nama driver DB_DRIVER
+func For(driver string) (Dialect, error) {
+	switch driver {
+	case "mysql":
+		return MySQL{}, nil
+	case "sqlite":
+		return SQLite{}, nil
+	}
+	return nil, fmt.Errorf("DB_DRIVER %q tidak dikenal (mysql|sqlite)", driver)
+}
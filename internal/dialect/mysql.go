@@ -0,0 +1,40 @@
+package dialect
+
+import (
+	"errors"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// MySQL dialect MySQL / MariaDB (go-sql-driver/mysql)
+type MySQL struct{}
+
+func (MySQL) Name() string { return "mysql" }
+
+func (MySQL) InsertIgnore() string { return "INSERT IGNORE" }
+
+func (MySQL) OnConflict(cols ...string) string { return "ON DUPLICATE KEY UPDATE" }
+
+func (MySQL) Inserted(col string) string { return "VALUES(" + col + ")" }
+
+func (MySQL) ForUpdate() string { return "FOR UPDATE" }
+
+func (MySQL) ForUpdateSkipLocked() string { return "FOR UPDATE SKIP LOCKED" }
+
+func (MySQL) AddSeconds(expr string) string { return "DATE_ADD(" + expr + ", INTERVAL ? SECOND)" }
+
+func (MySQL) SecondsBetween(from, to string) string {
+	return "TIMESTAMPDIFF(SECOND, " + from + ", " + to + ")"
+}
+
+func (MySQL) IsDuplicate(err error) bool { return mysqlErrno(err) == 1062 } // ER_DUP_ENTRY
+
+func (MySQL) IsReferenced(err error) bool { return mysqlErrno(err) == 1451 } // ER_ROW_IS_REFERENCED_2
+
+func mysqlErrno(err error) uint16 {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) {
+		return me.Number
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+package dialect
+
+import (
+	"backend-antrian/internal/clock"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQLite dialect SQLite (modernc.org/sqlite, tanpa cgo).
+// Waktu disimpan sebagai teks "YYYY-MM-DD HH:MM:SS" jam dinding zona aplikasi
+// (clock.Now), sama dengan hasil NOW() di bawah. Default kolom di migrasi
+// sqlite memakai 'localtime', yang disamakan dengan zona aplikasi oleh
+// config.InitTimezone.
+type SQLite struct{}
+
+// sqliteTimeLayout format teks DATETIME untuk NOW()
+const sqliteTimeLayout = "2006-01-02 15:04:05"
+
+func init() {
+	// Fungsi MySQL yang dipakai banyak query; tidak deterministik (tergantung jam).
+	// Memakai clock supaya jam di test bisa dibekukan.
+	sqlite.MustRegisterScalarFunction("NOW", 0, func(*sqlite.FunctionContext, []driver.Value) (driver.Value, error) {
+		return clock.Now().Format(sqliteTimeLayout), nil
+	})
+	sqlite.MustRegisterScalarFunction("CURDATE", 0, func(*sqlite.FunctionContext, []driver.Value) (driver.Value, error) {
+		return clock.Now().Format(time.DateOnly), nil
+	})
+}
+
+func (SQLite) Name() string { return "sqlite" }
+
+func (SQLite) InsertIgnore() string { return "INSERT OR IGNORE" }
+
+func (SQLite) OnConflict(cols ...string) string {
+	return "ON CONFLICT (" + strings.Join(cols, ", ") + ") DO UPDATE SET"
+}
+
+func (SQLite) Inserted(col string) string { return "excluded." + col }
+
+// ForUpdate kosong: transaksi SQLite dibuka dengan BEGIN IMMEDIATE (_txlock)
+// sehingga seluruh database sudah ter-lock untuk penulis lain
+func (SQLite) ForUpdate() string { return "" }
+
+func (SQLite) ForUpdateSkipLocked() string { return "" }
+
+func (SQLite) AddSeconds(expr string) string {
+	return "datetime(" + expr + ", '+' || ? || ' seconds')"
+}
+
+func (SQLite) SecondsBetween(from, to string) string {
+	return "CAST(ROUND((julianday(" + to + ") - julianday(" + from + ")) * 86400) AS INTEGER)"
+}
+
+func (SQLite) IsDuplicate(err error) bool {
+	code := sqliteCode(err)
+	return code == sqlite3.SQLITE_CONSTRAINT_UNIQUE || code == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY
+}
+
+func (SQLite) IsReferenced(err error) bool {
+	return sqliteCode(err) == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY
+}
+
+func sqliteCode(err error) int {
+	var se *sqlite.Error
+	if errors.As(err, &se) {
+		return se.Code()
+	}
+	return 0
+}
@@ -1,7 +1,8 @@
 package helper
 
 import (
-	"database/sql"
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/models"
 	"strings"
 	"time"
 )
@@ -17,38 +18,39 @@ type UnitScheduleStatus struct {
 	IsActiveDay bool
 }
 
-// IsUnitOpen mengecek apakah unit tertentu sedang buka berdasarkan unit_schedules.
+// Today hari ini (0=Minggu..6=Sabtu) di zona loc (nil = zona aplikasi).
+// MySQL DAYOFWEEK: 1=Minggu..7=Sabtu, kita pakai 0=Minggu..6=Sabtu
+// time.Weekday(): 0=Minggu..6=Sabtu — sudah sama persis
+func Today(loc *time.Location) int {
+	return int(nowIn(loc).Weekday())
+}
+
+// ScheduleStatus mengecek apakah unit sedang buka berdasarkan jadwal hari ini
+// (baris unit_schedules untuk Today(loc)); jam buka/tutup dibaca di zona loc.
 // Logika:
-//  - Jika tidak ada baris jadwal untuk hari ini → tutup (HasSchedule=false)
+//  - Jika tidak ada jadwal hari ini (schedule nil) → tutup (HasSchedule=false)
 //  - Jika ada tapi is_active='n' → tutup (libur)
 //  - Jika ada, is_active='y', dan waktu sekarang dalam rentang jam_buka–jam_tutup → buka
-func IsUnitOpen(db *sql.DB, unitID int64) UnitScheduleStatus {
-	loc, err := time.LoadLocation("Asia/Jakarta")
-	if err != nil {
-		return UnitScheduleStatus{}
-	}
-
-	now := time.Now().In(loc)
-	// MySQL DAYOFWEEK: 1=Minggu..7=Sabtu, kita pakai 0=Minggu..6=Sabtu
-	// time.Weekday(): 0=Minggu..6=Sabtu — sudah sama persis
-	dayOfWeek := int(now.Weekday())
+func ScheduleStatus(schedule *models.UnitSchedule, loc *time.Location) UnitScheduleStatus {
+	now := nowIn(loc)
+	return scheduleStatusAt(now, now.Location(), schedule)
+}
 
-	var jamBuka, jamTutup, isActive string
-	err = db.QueryRow(`
-		SELECT jam_buka, jam_tutup, is_active
-		FROM unit_schedules
-		WHERE unit_id = ? AND day_of_week = ?
-	`, unitID, dayOfWeek).Scan(&jamBuka, &jamTutup, &isActive)
+// nowIn jam sekarang (clock.Default()) di zona loc; nil = zona aplikasi
+func nowIn(loc *time.Location) time.Time {
+	if loc == nil {
+		loc = clock.Location()
+	}
+	return clock.Default().Now().In(loc)
+}
 
-	if err == sql.ErrNoRows {
+func scheduleStatusAt(now time.Time, loc *time.Location, schedule *models.UnitSchedule) UnitScheduleStatus {
+	if schedule == nil {
 		// Tidak ada jadwal hari ini
 		return UnitScheduleStatus{IsOpen: false, HasSchedule: false}
 	}
-	if err != nil {
-		return UnitScheduleStatus{IsOpen: false, HasSchedule: false}
-	}
 
-	if isActive != "y" {
+	if schedule.IsActive != "y" {
 		// Hari ini libur / tidak beroperasi — jangan tampilkan jam operasional
 		return UnitScheduleStatus{
 			IsOpen:      false,
@@ -59,14 +61,14 @@ func IsUnitOpen(db *sql.DB, unitID int64) UnitScheduleStatus {
 	}
 
 	// Cek rentang waktu
-	isOpen := checkTimeRange(now, loc, jamBuka, jamTutup)
+	isOpen := checkTimeRange(now, loc, schedule.JamBuka, schedule.JamTutup)
 
 	return UnitScheduleStatus{
 		IsOpen:      isOpen,
 		HasSchedule: true,
 		IsActiveDay: true,
-		JamBuka:     jamBuka,
-		JamTutup:    jamTutup,
+		JamBuka:     schedule.JamBuka,
+		JamTutup:    schedule.JamTutup,
 	}
 }
 
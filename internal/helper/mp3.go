@@ -0,0 +1,153 @@
+package helper
+
+import (
+	"errors"
+)
+
+var (
+	ErrMP3TooShort     = errors.New("file terlalu pendek untuk MP3")
+	ErrMP3InvalidID3   = errors.New("header ID3 tidak valid")
+	ErrMP3NoFrameSync  = errors.New("frame MPEG audio tidak ditemukan")
+	ErrMP3InvalidFrame = errors.New("header frame MPEG audio tidak valid")
+)
+
+// mp3SearchLimit batas byte yang dipindai untuk mencari frame pertama
+// setelah tag ID3 (beberapa encoder menaruh padding sebelum frame).
+const mp3SearchLimit = 64 * 1024
+
+// Bitrate (kbps) per [versi][layer][index]. Versi: 0=MPEG1, 1=MPEG2/2.5.
+// Layer: 0=Layer I, 1=Layer II, 2=Layer III.
+var mp3Bitrates = [2][3][16]int{
+	{
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448, 0},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384, 0},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 0},
+	},
+	{
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160, 0},
+	},
+}
+
+// Sample rate (Hz) per versi MPEG: index 0=MPEG1, 1=MPEG2, 2=MPEG2.5.
+var mp3SampleRates = [3][3]int{
+	{44100, 48000, 32000},
+	{22050, 24000, 16000},
+	{11025, 12000, 8000},
+}
+
+// mp3Frame info hasil parsing 4 byte header frame
+type mp3Frame struct {
+	length int
+}
+
+// ValidateMP3 mengecek isi file benar-benar MP3 (bukan sekadar extension/Content-Type).
+// Logika:
+//   - Lewati tag ID3v2 jika ada
+//   - Cari frame sync MPEG audio pertama
+//   - Header frame harus valid (versi, layer, bitrate, sample rate)
+//   - Jika data cukup, frame berikutnya juga harus valid (hindari false positive)
+func ValidateMP3(data []byte) error {
+	if len(data) < 4 {
+		return ErrMP3TooShort
+	}
+
+	offset := 0
+	if string(data[:3]) == "ID3" {
+		if len(data) < 10 {
+			return ErrMP3InvalidID3
+		}
+		// Ukuran tag pakai syncsafe integer (7 bit per byte)
+		for _, b := range data[6:10] {
+			if b&0x80 != 0 {
+				return ErrMP3InvalidID3
+			}
+		}
+		size := int(data[6])<<21 | int(data[7])<<14 | int(data[8])<<7 | int(data[9])
+		offset = 10 + size
+		// Flag footer ID3v2.4 menambah 10 byte
+		if data[5]&0x10 != 0 {
+			offset += 10
+		}
+		if offset >= len(data) {
+			return ErrMP3NoFrameSync
+		}
+	}
+
+	limit := offset + mp3SearchLimit
+	if limit > len(data)-4 {
+		limit = len(data) - 4
+	}
+
+	for i := offset; i <= limit; i++ {
+		if data[i] != 0xFF || data[i+1]&0xE0 != 0xE0 {
+			continue
+		}
+
+		frame, err := parseMP3FrameHeader(data[i : i+4])
+		if err != nil {
+			continue
+		}
+
+		next := i + frame.length
+		if next+4 > len(data) {
+			// File hanya berisi satu frame — tetap diterima
+			return nil
+		}
+		if _, err := parseMP3FrameHeader(data[next : next+4]); err == nil {
+			return nil
+		}
+	}
+
+	if offset > 0 {
+		return ErrMP3InvalidFrame
+	}
+	return ErrMP3NoFrameSync
+}
+
+func parseMP3FrameHeader(h []byte) (mp3Frame, error) {
+	if h[0] != 0xFF || h[1]&0xE0 != 0xE0 {
+		return mp3Frame{}, ErrMP3InvalidFrame
+	}
+
+	versionBits := (h[1] >> 3) & 0x03 // 00=2.5, 01=reserved, 10=2, 11=1
+	layerBits := (h[1] >> 1) & 0x03   // 00=reserved, 01=III, 10=II, 11=I
+	bitrateIdx := (h[2] >> 4) & 0x0F
+	sampleIdx := (h[2] >> 2) & 0x03
+	padding := int((h[2] >> 1) & 0x01)
+
+	if versionBits == 0x01 || layerBits == 0x00 || bitrateIdx == 0x00 || bitrateIdx == 0x0F || sampleIdx == 0x03 {
+		return mp3Frame{}, ErrMP3InvalidFrame
+	}
+
+	var versionRow, rateRow int
+	switch versionBits {
+	case 0x03:
+		versionRow, rateRow = 0, 0
+	case 0x02:
+		versionRow, rateRow = 1, 1
+	default:
+		versionRow, rateRow = 1, 2
+	}
+
+	layer := 3 - int(layerBits) // 0=I, 1=II, 2=III
+	bitrate := mp3Bitrates[versionRow][layer][bitrateIdx] * 1000
+	sampleRate := mp3SampleRates[rateRow][sampleIdx]
+
+	var length int
+	switch {
+	case layer == 0:
+		length = (12*bitrate/sampleRate + padding) * 4
+	case layer == 2 && versionRow == 1:
+		length = 72*bitrate/sampleRate + padding
+	default:
+		length = 144*bitrate/sampleRate + padding
+	}
+
+	if length < 4 {
+		return mp3Frame{}, ErrMP3InvalidFrame
+	}
+
+	return mp3Frame{length: length}, nil
+}
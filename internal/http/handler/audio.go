@@ -1,11 +1,14 @@
 package handler
 
 import (
-	"backend-antrian/internal/config"
+	"backend-antrian/internal/helper"
 	"backend-antrian/internal/models"
-	"database/sql"
+	"backend-antrian/internal/repository"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"mime/multipart"
 	"os"
 	"path/filepath"
 	"strings"
@@ -19,37 +22,13 @@ const (
 )
 
 // GetAllAudios - Ambil semua audio (public endpoint)
-func GetAllAudios(c *fiber.Ctx) error {
-	query := `
-		SELECT id, tts_text, nama_audio, path_audio, created_at, updated_at 
-		FROM tts_audio_cache 
-		ORDER BY created_at DESC
-	`
-
-	rows, err := config.DB.Query(query)
+func (h *Handler) GetAllAudios(c *fiber.Ctx) error {
+	audios, err := h.repos.Audios.List(c.UserContext())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal mengambil data audio",
 		})
 	}
-	defer rows.Close()
-
-	audios := []models.Audio{}
-	for rows.Next() {
-		var audio models.Audio
-		err := rows.Scan(
-			&audio.ID,
-			&audio.TTSText,
-			&audio.NamaAudio,
-			&audio.PathAudio,
-			&audio.CreatedAt,
-			&audio.UpdatedAt,
-		)
-		if err != nil {
-			continue
-		}
-		audios = append(audios, audio)
-	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -58,51 +37,31 @@ func GetAllAudios(c *fiber.Ctx) error {
 }
 
 // CreateAudio - Upload audio baru (super_user only)
-func CreateAudio(c *fiber.Ctx) error {
-	// Parse multipart form
-	ttsText := c.FormValue("tts_text")
-	namaAudio := c.FormValue("nama_audio")
-
-	// Validasi input
-	if ttsText == "" || namaAudio == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "tts_text dan nama_audio wajib diisi",
-		})
+func (h *Handler) CreateAudio(c *fiber.Ctx) error {
+	// Field multipart divalidasi seperti body JSON
+	var req models.CreateAudioRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
 	}
+	ttsText := req.TTSText
 
-	// Normalisasi nama_audio
-	namaAudio = strings.ToLower(strings.TrimSpace(namaAudio))
-	if !strings.HasSuffix(namaAudio, ".mp3") {
-		namaAudio = namaAudio + ".mp3"
-	}
-
-	// Validasi nama_audio (hanya huruf, angka, underscore, dash, dan .mp3)
-	// Misal: satu.mp3, sepuluh.mp3, dua_belas.mp3
-	validName := true
-	nameWithoutExt := strings.TrimSuffix(namaAudio, ".mp3")
-	for _, char := range nameWithoutExt {
-		if !((char >= 'a' && char <= 'z') || (char >= '0' && char <= '9') || char == '_' || char == '-') {
-			validName = false
-			break
-		}
-	}
-
-	if !validName {
+	// Normalisasi & validasi nama_audio
+	namaAudio, ok := normalizeAudioName(req.NamaAudio)
+	if !ok {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "nama_audio hanya boleh mengandung huruf kecil, angka, underscore, dan dash",
 		})
 	}
 
 	// Cek apakah nama_audio sudah ada (unique constraint)
-	var count int
-	err := config.DB.QueryRow("SELECT COUNT(*) FROM tts_audio_cache WHERE nama_audio = ?", namaAudio).Scan(&count)
+	exists, err := h.repos.Audios.NameExists(c.UserContext(), namaAudio, 0)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal validasi nama audio",
 		})
 	}
 
-	if count > 0 {
+	if exists {
 		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 			"error": "Nama audio sudah digunakan",
 		})
@@ -123,58 +82,29 @@ func CreateAudio(c *fiber.Ctx) error {
 		})
 	}
 
-	// Validasi tipe file (harus MP3)
-	if file.Header.Get("Content-Type") != "audio/mpeg" && file.Header.Get("Content-Type") != "audio/mp3" {
-		// Double check dari extension
-		ext := strings.ToLower(filepath.Ext(file.Filename))
-		if ext != ".mp3" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "File harus berformat MP3",
-			})
-		}
-	}
-
-	// Pastikan direktori audio ada
-	if err := os.MkdirAll(AudioBasePath, 0755); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Gagal membuat direktori audio",
-		})
-	}
-
-	// Path tujuan file
-	destinationPath := filepath.Join(AudioBasePath, namaAudio)
-	pathAudioDB := fmt.Sprintf("public/audio/%s", namaAudio)
-
-	// Buka file upload
-	src, err := file.Open()
+	// Validasi isi file (header MP3), bukan Content-Type/extension
+	data, err := readAudioUpload(file)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Gagal membuka file upload",
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
 		})
 	}
-	defer src.Close()
 
-	// Buat file tujuan
-	dst, err := os.Create(destinationPath)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Gagal menyimpan file",
-		})
-	}
-	defer dst.Close()
+	destinationPath := filepath.Join(AudioBasePath, namaAudio)
+	pathAudioDB := audioDBPath(namaAudio)
 
-	// Copy file
-	if _, err := io.Copy(dst, src); err != nil {
-		// Hapus file jika gagal copy
-		os.Remove(destinationPath)
+	if err := writeAudioFileAtomic(namaAudio, data); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal menyimpan file",
 		})
 	}
 
 	// Insert ke database
-	query := "INSERT INTO tts_audio_cache (tts_text, nama_audio, path_audio) VALUES (?, ?, ?)"
-	result, err := config.DB.Exec(query, ttsText, namaAudio, pathAudioDB)
+	id, err := h.repos.Audios.Create(c.UserContext(), models.Audio{
+		TTSText:   ttsText,
+		NamaAudio: namaAudio,
+		PathAudio: pathAudioDB,
+	})
 	if err != nil {
 		// Hapus file jika gagal insert ke DB
 		os.Remove(destinationPath)
@@ -183,14 +113,8 @@ func CreateAudio(c *fiber.Ctx) error {
 		})
 	}
 
-	id, _ := result.LastInsertId()
-
 	// Ambil data yang baru dibuat
-	var audio models.Audio
-	config.DB.QueryRow(
-		"SELECT id, tts_text, nama_audio, path_audio, created_at, updated_at FROM tts_audio_cache WHERE id = ?",
-		id,
-	).Scan(&audio.ID, &audio.TTSText, &audio.NamaAudio, &audio.PathAudio, &audio.CreatedAt, &audio.UpdatedAt)
+	audio, _ := h.repos.Audios.Get(c.UserContext(), id)
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"success": true,
@@ -200,23 +124,12 @@ func CreateAudio(c *fiber.Ctx) error {
 }
 
 // DeleteAudio - Hapus audio berdasarkan ID (super_user only)
-func DeleteAudio(c *fiber.Ctx) error {
-	id := c.Params("id")
+func (h *Handler) DeleteAudio(c *fiber.Ctx) error {
+	id := paramID(c, "id")
 
 	// Ambil data audio dari database untuk mendapatkan path file
-	var audio models.Audio
-	query := "SELECT id, tts_text, nama_audio, path_audio, created_at, updated_at FROM tts_audio_cache WHERE id = ?"
-
-	err := config.DB.QueryRow(query, id).Scan(
-		&audio.ID,
-		&audio.TTSText,
-		&audio.NamaAudio,
-		&audio.PathAudio,
-		&audio.CreatedAt,
-		&audio.UpdatedAt,
-	)
-
-	if err == sql.ErrNoRows {
+	audio, err := h.repos.Audios.Get(c.UserContext(), id)
+	if errors.Is(err, repository.ErrNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Audio tidak ditemukan",
 		})
@@ -229,8 +142,7 @@ func DeleteAudio(c *fiber.Ctx) error {
 	}
 
 	// Hapus dari database terlebih dahulu
-	_, err = config.DB.Exec("DELETE FROM tts_audio_cache WHERE id = ?", id)
-	if err != nil {
+	if err := h.repos.Audios.Delete(c.UserContext(), id); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal menghapus data audio dari database",
 		})
@@ -241,11 +153,115 @@ func DeleteAudio(c *fiber.Ctx) error {
 	if err := os.Remove(filePath); err != nil {
 		// Log error tapi tidak return error, karena data sudah terhapus dari DB
 		// File mungkin sudah terhapus manual atau tidak ada
-		fmt.Printf("Warning: Gagal menghapus file %s: %v\n", filePath, err)
+		audioLog.WarnContext(c.UserContext(), "gagal menghapus file", "path", filePath, "err", err)
 	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
 		"message": "Audio berhasil dihapus",
 	})
-}
\ No newline at end of file
+}
+/*
+|--------------------------------------------------------------------------
+| Helper
+|--------------------------------------------------------------------------
+*/
+
+// normalizeAudioName - lowercase, trim, tambah .mp3, lalu validasi karakter.
+// Misal: satu.mp3, sepuluh.mp3, dua_belas.mp3
+func normalizeAudioName(name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !strings.HasSuffix(name, ".mp3") {
+		name = name + ".mp3"
+	}
+
+	nameWithoutExt := strings.TrimSuffix(name, ".mp3")
+	if nameWithoutExt == "" {
+		return name, false
+	}
+
+	for _, char := range nameWithoutExt {
+		if !((char >= 'a' && char <= 'z') || (char >= '0' && char <= '9') || char == '_' || char == '-') {
+			return name, false
+		}
+	}
+
+	return name, true
+}
+
+// audioDBPath - path_audio yang disimpan di tts_audio_cache
+func audioDBPath(namaAudio string) string {
+	return fmt.Sprintf("public/audio/%s", namaAudio)
+}
+
+// readAudioUpload baca file upload ke memory dan validasi header MP3
+func readAudioUpload(file *multipart.FileHeader) ([]byte, error) {
+	if file.Size > MaxAudioSize {
+		return nil, fmt.Errorf("Ukuran file maksimal %d MB", MaxAudioSize/(1024*1024))
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("Gagal membuka file upload")
+	}
+	defer src.Close()
+
+	data, err := io.ReadAll(io.LimitReader(src, MaxAudioSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("Gagal membaca file upload")
+	}
+	if len(data) > MaxAudioSize {
+		return nil, fmt.Errorf("Ukuran file maksimal %d MB", MaxAudioSize/(1024*1024))
+	}
+
+	if err := helper.ValidateMP3(data); err != nil {
+		return nil, fmt.Errorf("File harus berformat MP3 yang valid: %v", err)
+	}
+
+	return data, nil
+}
+
+// writeAudioFileAtomic tulis ke file sementara di direktori yang sama lalu rename.
+// Rename di filesystem yang sama bersifat atomic, jadi client yang sedang
+// memutar audio tidak pernah mendapat file setengah jadi.
+func writeAudioFileAtomic(namaAudio string, data []byte) error {
+	if err := os.MkdirAll(AudioBasePath, 0755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(AudioBasePath, ".upload-*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, 0644); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, filepath.Join(AudioBasePath, namaAudio)); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return nil
+}
+
+func (h *Handler) getAudioByID(ctx context.Context, id int64) (models.Audio, error) {
+	return h.repos.Audios.Get(ctx, id)
+}
@@ -0,0 +1,364 @@
+package handler
+
+import (
+	"archive/zip"
+	"backend-antrian/internal/helper"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	MaxAudioImportEntries = 500
+	// MaxAudioImportBytes batas total ukuran MP3 setelah diekstrak per import
+	MaxAudioImportBytes = 200 * 1024 * 1024
+	audioManifestName   = "manifest.csv"
+)
+
+// audioImportItem satu file MP3 yang lolos validasi dari ZIP, sudah
+// diekstrak ke file sementara di AudioBasePath
+type audioImportItem struct {
+	NamaAudio string
+	TTSText   string
+	tmpPath   string
+}
+
+// ImportAudioZip - Bulk import audio dari file ZIP (super_user only).
+//
+// Isi ZIP: file *.mp3 (boleh di dalam folder) dan opsional manifest.csv
+// dengan kolom nama_audio,tts_text. Jika tidak ada di manifest, tts_text
+// diambil dari nama file (underscore/dash jadi spasi).
+//
+// Semua file divalidasi dulu; jika ada satu saja yang gagal, tidak ada
+// yang disimpan. Insert ke tts_audio_cache dilakukan dalam satu transaksi.
+// Entry diekstrak satu per satu ke file sementara (bukan ke memory) dan
+// total ukurannya dibatasi MaxAudioImportBytes.
+func (h *Handler) ImportAudioZip(c *fiber.Ctx) error {
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "File ZIP wajib diupload",
+		})
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal membuka file upload",
+		})
+	}
+	defer src.Close()
+
+	zr, err := zip.NewReader(src, file.Size)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "File harus berformat ZIP yang valid",
+		})
+	}
+
+	if err := os.MkdirAll(AudioBasePath, 0755); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal membuat direktori audio",
+		})
+	}
+
+	items, skipped, errs := readAudioZip(zr)
+	cleanupTemps := func() {
+		for _, item := range items {
+			if item.tmpPath != "" {
+				os.Remove(item.tmpPath)
+			}
+		}
+	}
+
+	if len(errs) == 0 && len(items) == 0 {
+		errs = append(errs, "Tidak ada file MP3 di dalam ZIP")
+	}
+	if len(errs) == 0 {
+		errs = h.checkAudioImportConflicts(c.UserContext(), items)
+	}
+	if len(errs) > 0 {
+		cleanupTemps()
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":  "Import dibatalkan, periksa kembali isi ZIP",
+			"errors": errs,
+		})
+	}
+
+	// Pasang file sementara ke nama final lewat hard link: gagal kalau target
+	// sudah ada (misal dibuat request lain setelah cek konflik), jadi file
+	// orang lain tidak tertimpa. Rollback hanya menghapus file yang dibuat
+	// request ini.
+	var created []string
+	rollbackCreated := func() {
+		for _, p := range created {
+			os.Remove(p)
+		}
+	}
+
+	for _, item := range items {
+		finalPath := filepath.Join(AudioBasePath, item.NamaAudio)
+		if err := os.Link(item.tmpPath, finalPath); err != nil {
+			rollbackCreated()
+			cleanupTemps()
+			if errors.Is(err, os.ErrExist) {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"error": fmt.Sprintf("%s: file sudah ada di server", item.NamaAudio),
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Gagal menyimpan file",
+			})
+		}
+		created = append(created, finalPath)
+		os.Remove(item.tmpPath)
+		item.tmpPath = ""
+	}
+
+	audios := make([]models.Audio, 0, len(items))
+	for _, item := range items {
+		audios = append(audios, models.Audio{
+			TTSText:   item.TTSText,
+			NamaAudio: item.NamaAudio,
+			PathAudio: audioDBPath(item.NamaAudio),
+		})
+	}
+	ids, err := h.repos.Audios.CreateMany(c.UserContext(), audios)
+	if err != nil {
+		rollbackCreated()
+		if errors.Is(err, repository.ErrDuplicate) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Nama audio sudah digunakan",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal menyimpan data audio ke database",
+		})
+	}
+
+	imported := make([]models.Audio, 0, len(ids))
+	for _, id := range ids {
+		if a, err := h.getAudioByID(c.UserContext(), id); err == nil {
+			imported = append(imported, a)
+		}
+	}
+	sort.Slice(imported, func(i, j int) bool { return imported[i].NamaAudio < imported[j].NamaAudio })
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": fmt.Sprintf("%d audio berhasil diimport", len(items)),
+		"data": fiber.Map{
+			"imported": imported,
+			"skipped":  skipped,
+		},
+	})
+}
+
+// readAudioZip baca & validasi isi ZIP, ekstrak MP3 yang valid ke file
+// sementara. Entry non-MP3 dicatat sebagai skipped. Pemanggil wajib
+// menghapus tmpPath item bila import dibatalkan.
+func readAudioZip(zr *zip.Reader) ([]*audioImportItem, []string, []string) {
+	var (
+		items   []*audioImportItem
+		skipped = []string{}
+		errs    []string
+		total   int64
+	)
+
+	manifest := map[string]string{}
+	for _, f := range zr.File {
+		if strings.EqualFold(path.Base(f.Name), audioManifestName) {
+			m, err := readAudioManifest(f)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", f.Name, err))
+			}
+			manifest = m
+			break
+		}
+	}
+
+	seen := map[string]string{}
+	for _, f := range zr.File {
+		base := path.Base(f.Name)
+
+		if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") || strings.HasPrefix(base, ".") {
+			continue
+		}
+		if strings.EqualFold(base, audioManifestName) {
+			continue
+		}
+		if !strings.EqualFold(path.Ext(base), ".mp3") {
+			skipped = append(skipped, f.Name)
+			continue
+		}
+
+		if len(items) >= MaxAudioImportEntries {
+			errs = append(errs, fmt.Sprintf("Maksimal %d file per import", MaxAudioImportEntries))
+			break
+		}
+
+		namaAudio, ok := normalizeAudioName(base)
+		if !ok {
+			errs = append(errs, fmt.Sprintf("%s: nama file hanya boleh huruf kecil, angka, underscore, dan dash", f.Name))
+			continue
+		}
+
+		if prev, dup := seen[namaAudio]; dup {
+			errs = append(errs, fmt.Sprintf("%s: nama %s duplikat dengan %s", f.Name, namaAudio, prev))
+			continue
+		}
+		seen[namaAudio] = f.Name
+
+		if f.UncompressedSize64 > MaxAudioSize {
+			errs = append(errs, fmt.Sprintf("%s: ukuran file maksimal %d MB", f.Name, MaxAudioSize/(1024*1024)))
+			continue
+		}
+
+		data, err := readZipEntry(f)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", f.Name, err))
+			continue
+		}
+
+		// Batas total dihitung dari byte yang benar-benar diekstrak
+		total += int64(len(data))
+		if total > MaxAudioImportBytes {
+			errs = append(errs, fmt.Sprintf("Total ukuran audio maksimal %d MB per import", MaxAudioImportBytes/(1024*1024)))
+			break
+		}
+
+		if err := helper.ValidateMP3(data); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: bukan MP3 yang valid (%v)", f.Name, err))
+			continue
+		}
+
+		tmpPath, err := writeImportTemp(data)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: gagal menyimpan file sementara", f.Name))
+			continue
+		}
+
+		ttsText := manifest[namaAudio]
+		if ttsText == "" {
+			ttsText = ttsTextFromName(namaAudio)
+		}
+
+		items = append(items, &audioImportItem{
+			NamaAudio: namaAudio,
+			TTSText:   ttsText,
+			tmpPath:   tmpPath,
+		})
+	}
+
+	return items, skipped, errs
+}
+
+// writeImportTemp tulis satu entry ke file sementara di AudioBasePath
+// (satu filesystem dengan tujuan supaya bisa di-os.Link)
+func writeImportTemp(data []byte) (string, error) {
+	tmp, err := os.CreateTemp(AudioBasePath, ".import-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	_, werr := tmp.Write(data)
+	cerr := tmp.Close()
+	if werr != nil || cerr != nil {
+		os.Remove(tmp.Name())
+		return "", errors.Join(werr, cerr)
+	}
+	os.Chmod(tmp.Name(), 0644)
+	return tmp.Name(), nil
+}
+
+// checkAudioImportConflicts pastikan nama belum dipakai di DB maupun di disk
+func (h *Handler) checkAudioImportConflicts(ctx context.Context, items []*audioImportItem) []string {
+	var errs []string
+
+	for _, item := range items {
+		exists, err := h.repos.Audios.NameExists(ctx, item.NamaAudio, 0)
+		if err != nil {
+			return []string{"Gagal validasi nama audio"}
+		}
+		if exists {
+			errs = append(errs, fmt.Sprintf("%s: nama audio sudah digunakan", item.NamaAudio))
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(AudioBasePath, item.NamaAudio)); err == nil {
+			errs = append(errs, fmt.Sprintf("%s: file sudah ada di server", item.NamaAudio))
+		}
+	}
+
+	return errs
+}
+
+func readZipEntry(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuka entry ZIP")
+	}
+	defer rc.Close()
+
+	// Jangan percaya UncompressedSize64 di header — batasi saat membaca
+	data, err := io.ReadAll(io.LimitReader(rc, MaxAudioSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca entry ZIP")
+	}
+	if len(data) > MaxAudioSize {
+		return nil, fmt.Errorf("ukuran file maksimal %d MB", MaxAudioSize/(1024*1024))
+	}
+	return data, nil
+}
+
+// readAudioManifest baca manifest.csv: nama_audio,tts_text (baris header opsional)
+func readAudioManifest(f *zip.File) (map[string]string, error) {
+	result := map[string]string{}
+
+	rc, err := f.Open()
+	if err != nil {
+		return result, fmt.Errorf("gagal membuka manifest")
+	}
+	defer rc.Close()
+
+	r := csv.NewReader(io.LimitReader(rc, 1024*1024))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return result, fmt.Errorf("format manifest tidak valid")
+	}
+
+	for i, rec := range records {
+		if len(rec) < 2 {
+			continue
+		}
+		if i == 0 && strings.EqualFold(strings.TrimSpace(rec[0]), "nama_audio") {
+			continue
+		}
+		name, ok := normalizeAudioName(rec[0])
+		if !ok {
+			continue
+		}
+		result[name] = strings.TrimSpace(rec[1])
+	}
+
+	return result, nil
+}
+
+// ttsTextFromName: dinas_sosial.mp3 -> "dinas sosial"
+func ttsTextFromName(namaAudio string) string {
+	name := strings.TrimSuffix(namaAudio, ".mp3")
+	name = strings.NewReplacer("_", " ", "-", " ").Replace(name)
+	return strings.TrimSpace(name)
+}
@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// UpdateAudio - Ganti file dan/atau tts_text audio (super_user only).
+// File diganti secara atomic sehingga unit yang memakai audio_file ini tidak terputus.
+func (h *Handler) UpdateAudio(c *fiber.Ctx) error {
+	id := paramID(c, "id")
+
+	audio, err := h.getAudioByID(c.UserContext(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Audio tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil data audio",
+		})
+	}
+
+	var req models.UpdateAudioRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+	ttsText := strings.TrimSpace(req.TTSText)
+	file, fileErr := c.FormFile("file")
+
+	if ttsText == "" && fileErr != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Tidak ada data yang diupdate",
+		})
+	}
+
+	// Simpan isi file lama untuk rollback jika update DB gagal; file lama
+	// yang tidak ada di disk di-rollback dengan menghapus file baru
+	var (
+		oldData      []byte
+		hadOldFile   bool
+		fileReplaced bool
+	)
+
+	if fileErr == nil {
+		data, err := readAudioUpload(file)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+
+		oldData, err = os.ReadFile(filepath.Join(AudioBasePath, audio.NamaAudio))
+		if err != nil && !os.IsNotExist(err) {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Gagal membaca file audio lama",
+			})
+		}
+		hadOldFile = err == nil
+
+		if err := writeAudioFileAtomic(audio.NamaAudio, data); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Gagal mengganti file audio",
+			})
+		}
+		fileReplaced = true
+	}
+
+	if ttsText == "" {
+		ttsText = audio.TTSText
+	}
+
+	if err := h.repos.Audios.UpdateText(c.UserContext(), audio.ID, ttsText); err != nil {
+		if fileReplaced {
+			var rbErr error
+			if hadOldFile {
+				rbErr = writeAudioFileAtomic(audio.NamaAudio, oldData)
+			} else {
+				rbErr = os.Remove(filepath.Join(AudioBasePath, audio.NamaAudio))
+			}
+			if rbErr != nil {
+				audioLog.ErrorContext(c.UserContext(), "rollback file gagal", "audio", audio.NamaAudio, "err", rbErr)
+			}
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengupdate data audio",
+		})
+	}
+
+	audio, _ = h.getAudioByID(c.UserContext(), audio.ID)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Audio berhasil diupdate",
+		"data":    audio,
+	})
+}
+
+// RenameAudio - Ganti nama_audio dan cascade ke units.audio_file (super_user only)
+func (h *Handler) RenameAudio(c *fiber.Ctx) error {
+	id := paramID(c, "id")
+
+	var req models.RenameAudioRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	newName, ok := normalizeAudioName(req.NamaAudio)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "nama_audio hanya boleh mengandung huruf kecil, angka, underscore, dan dash",
+		})
+	}
+
+	audio, err := h.getAudioByID(c.UserContext(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Audio tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil data audio",
+		})
+	}
+
+	oldName := audio.NamaAudio
+	if newName == oldName {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Nama audio baru sama dengan nama lama",
+		})
+	}
+
+	exists, err := h.repos.Audios.NameExists(c.UserContext(), newName, audio.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal validasi nama audio",
+		})
+	}
+	if exists {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Nama audio sudah digunakan",
+		})
+	}
+
+	oldPath := filepath.Join(AudioBasePath, oldName)
+	newPath := filepath.Join(AudioBasePath, newName)
+
+	// Jangan timpa file fisik yang tidak tercatat di database
+	if _, err := os.Stat(newPath); err == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "File dengan nama tersebut sudah ada di server",
+		})
+	}
+
+	// Rename file fisik dulu; kalau update DB gagal, nama file dikembalikan
+	if err := os.Rename(oldPath, newPath); err != nil && !os.IsNotExist(err) {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengganti nama file audio",
+		})
+	}
+
+	// Rename audio + cascade ke units dalam satu transaksi lintas tabel
+	unitsUpdated, err := h.repos.Audios.Rename(c.UserContext(), audio.ID, newName, audioDBPath(newName))
+	if err != nil {
+		if rbErr := os.Rename(newPath, oldPath); rbErr != nil && !os.IsNotExist(rbErr) {
+			audioLog.ErrorContext(c.UserContext(), "rollback rename gagal", "from", newName, "to", oldName, "err", rbErr)
+		}
+		if errors.Is(err, repository.ErrDuplicate) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Nama audio sudah digunakan",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal menyimpan perubahan",
+		})
+	}
+
+	// Audio path unit ikut berubah — display perlu payload baru
+	if unitsUpdated > 0 {
+		h.BroadcastUnitsStatus()
+		BroadcastQueueUpdate()
+	}
+
+	audio, _ = h.getAudioByID(c.UserContext(), audio.ID)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Nama audio berhasil diganti",
+		"data": fiber.Map{
+			"audio":         audio,
+			"units_updated": unitsUpdated,
+		},
+	})
+}
+
+// GetAudioUsage - Daftar audio beserta unit yang memakainya (super_user only).
+// Query opsional: audio_id untuk satu audio saja, unused=true untuk audio yang tidak dipakai.
+func (h *Handler) GetAudioUsage(c *fiber.Ctx) error {
+	unusedOnly := c.Query("unused") == "true"
+
+	var audioID int64
+	if raw := c.Query("audio_id"); raw != "" {
+		// ID tidak valid pasti tidak ditemukan
+		if audioID, _ = strconv.ParseInt(raw, 10, 64); audioID <= 0 {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Audio tidak ditemukan",
+			})
+		}
+	}
+
+	usages, err := h.repos.Audios.Usage(c.UserContext(), audioID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil data pemakaian audio",
+		})
+	}
+
+	if audioID > 0 && len(usages) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Audio tidak ditemukan",
+		})
+	}
+
+	result := []models.AudioUsage{}
+	for _, u := range usages {
+		if unusedOnly && u.UsageCount > 0 {
+			continue
+		}
+		result = append(result, u)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
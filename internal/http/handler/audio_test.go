@@ -0,0 +1,355 @@
+package handler
+
+import (
+	"archive/zip"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"backend-antrian/internal/repository/memory"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// audioApp - route audio di atas repository in-memory; ./public/audio
+// ditulis di folder sementara
+func audioApp(t *testing.T, repos *repository.Repos) (*Handler, *fiber.App) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	h := New(repos)
+
+	app := newFiber()
+	app.Post("/audio", h.CreateAudio)
+	app.Post("/audio/import", h.ImportAudioZip)
+	app.Put("/audio/:id", h.UpdateAudio)
+	app.Put("/audio/:id/rename", h.RenameAudio)
+	app.Get("/audio/usage", h.GetAudioUsage)
+	return h, app
+}
+
+// mp3Data - dua frame MPEG1 Layer III 128 kbps 44.1 kHz berisi byte fill
+func mp3Data(fill byte) []byte {
+	const frameLen = 417
+	frame := bytes.Repeat([]byte{fill}, frameLen)
+	copy(frame, []byte{0xFF, 0xFB, 0x90, 0x00})
+	return append(append([]byte{}, frame...), frame...)
+}
+
+// upload - kirim multipart dengan satu file di field "file" plus field teks
+func upload(t *testing.T, app *fiber.App, method, path, filename string, data []byte, fields map[string]string) (int, map[string]any) {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for k, v := range fields {
+		w.WriteField(k, v)
+	}
+	part, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(data)
+	w.Close()
+
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	var out map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out
+}
+
+// zipOf - arsip ZIP dari nama file -> isi
+func zipOf(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, data := range files {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// seedAudio - row tts_audio_cache beserta file di disk
+func seedAudio(t *testing.T, h *Handler, name string, data []byte) int64 {
+	t.Helper()
+	if err := writeAudioFileAtomic(name, data); err != nil {
+		t.Fatal(err)
+	}
+	id, err := h.repos.Audios.Create(context.Background(), models.Audio{TTSText: name, NamaAudio: name, PathAudio: audioDBPath(name)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// failingAudios - UpdateText selalu gagal untuk menguji rollback file
+type failingAudios struct {
+	repository.AudioRepo
+}
+
+func (failingAudios) UpdateText(context.Context, int64, string) error {
+	return errors.New("db down")
+}
+
+// racingAudios - file dengan nama yang sudah lolos cek konflik dibuat
+// "request lain" sebelum import memasang file-nya
+type racingAudios struct {
+	repository.AudioRepo
+	checked []string
+}
+
+func (r *racingAudios) NameExists(ctx context.Context, name string, excludeID int64) (bool, error) {
+	if len(r.checked) == 2 {
+		os.WriteFile(filepath.Join(AudioBasePath, r.checked[1]), []byte("milik orang lain"), 0644)
+	}
+	r.checked = append(r.checked, name)
+	return r.AudioRepo.NameExists(ctx, name, excludeID)
+}
+
+func TestCreateAudioValidatesForm(t *testing.T) {
+	h, app := audioApp(t, memory.New())
+
+	// Field multipart kosong/spasi ditolak 422 seperti body JSON
+	status, body := upload(t, app, "POST", "/audio", "loket.mp3", mp3Data(1), map[string]string{"nama_audio": "  "})
+	if status != fiber.StatusUnprocessableEntity || len(body["errors"].([]any)) != 2 {
+		t.Fatalf("field kosong = %d %v, want 422", status, body)
+	}
+
+	status, body = upload(t, app, "POST", "/audio", "loket.mp3", mp3Data(1), map[string]string{"tts_text": "loket", "nama_audio": "loket"})
+	if status != fiber.StatusCreated {
+		t.Fatalf("create = %d %v", status, body)
+	}
+	if ok, _ := h.repos.Audios.NameExists(context.Background(), "loket.mp3", 0); !ok {
+		t.Fatal("audio tidak tersimpan")
+	}
+}
+
+func TestUpdateAudioReplacesFile(t *testing.T) {
+	h, app := audioApp(t, memory.New())
+	id := seedAudio(t, h, "loket.mp3", mp3Data(1))
+	path := filepath.Join(AudioBasePath, "loket.mp3")
+
+	if status, body := upload(t, app, "PUT", fmt.Sprintf("/audio/%d", id), "baru.mp3", mp3Data(2), map[string]string{"tts_text": "loket baru"}); status != fiber.StatusOK {
+		t.Fatalf("ganti file = %d %v", status, body)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, mp3Data(2)) {
+		t.Fatal("isi file tidak diganti")
+	}
+	if a, _ := h.repos.Audios.Get(context.Background(), id); a.TTSText != "loket baru" {
+		t.Fatalf("tts_text = %q", a.TTSText)
+	}
+
+	if status, _ := upload(t, app, "PUT", fmt.Sprintf("/audio/%d", id), "rusak.mp3", []byte("bukan mp3"), nil); status != fiber.StatusBadRequest {
+		t.Fatalf("file bukan MP3 = %d, want 400", status)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, mp3Data(2)) {
+		t.Fatal("file berubah walau upload ditolak")
+	}
+}
+
+func TestUpdateAudioRollback(t *testing.T) {
+	repos := memory.New()
+	repos.Audios = failingAudios{repos.Audios}
+	h, app := audioApp(t, repos)
+
+	// Update DB gagal: file lama dikembalikan
+	id := seedAudio(t, h, "loket.mp3", mp3Data(1))
+	if status, _ := upload(t, app, "PUT", fmt.Sprintf("/audio/%d", id), "baru.mp3", mp3Data(2), nil); status != fiber.StatusInternalServerError {
+		t.Fatalf("update gagal = %d, want 500", status)
+	}
+	if data, _ := os.ReadFile(filepath.Join(AudioBasePath, "loket.mp3")); !bytes.Equal(data, mp3Data(1)) {
+		t.Fatal("file lama tidak dikembalikan")
+	}
+
+	// File lama tidak ada di disk: file baru dihapus lagi
+	orphan, _ := h.repos.Audios.Create(context.Background(), models.Audio{TTSText: "x", NamaAudio: "hilang.mp3", PathAudio: audioDBPath("hilang.mp3")})
+	if status, _ := upload(t, app, "PUT", fmt.Sprintf("/audio/%d", orphan), "baru.mp3", mp3Data(2), nil); status != fiber.StatusInternalServerError {
+		t.Fatalf("update gagal = %d, want 500", status)
+	}
+	if _, err := os.Stat(filepath.Join(AudioBasePath, "hilang.mp3")); !os.IsNotExist(err) {
+		t.Fatalf("file baru tertinggal: %v", err)
+	}
+}
+
+func TestRenameAudioCascadesToUnits(t *testing.T) {
+	h, app := audioApp(t, memory.New())
+	ctx := context.Background()
+
+	id := seedAudio(t, h, "loket.mp3", mp3Data(1))
+	seedAudio(t, h, "kasir.mp3", mp3Data(2))
+	file := "loket.mp3"
+	unitA, _ := h.repos.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Dukcapil", AudioFile: &file, IsActive: "y", MainDisplay: "active"})
+	unitB, _ := h.repos.Units.Create(ctx, models.Unit{Code: "B", NamaUnit: "Pajak", IsActive: "y", MainDisplay: "active"})
+
+	if status, _ := do(t, app, "PUT", fmt.Sprintf("/audio/%d/rename", id), `{"nama_audio":" "}`); status != fiber.StatusUnprocessableEntity {
+		t.Fatalf("nama kosong = %d, want 422", status)
+	}
+	if status, _ := do(t, app, "PUT", fmt.Sprintf("/audio/%d/rename", id), `{"nama_audio":"kasir"}`); status != fiber.StatusConflict {
+		t.Fatalf("nama dipakai = %d, want 409", status)
+	}
+
+	status, body := do(t, app, "PUT", fmt.Sprintf("/audio/%d/rename", id), `{"nama_audio":"loket_1"}`)
+	if status != fiber.StatusOK {
+		t.Fatalf("rename = %d %v", status, body)
+	}
+	if n := body["data"].(map[string]any)["units_updated"]; n != float64(1) {
+		t.Fatalf("units_updated = %v, want 1", n)
+	}
+
+	if u, _ := h.repos.Units.Get(ctx, unitA); u.AudioFile == nil || *u.AudioFile != "loket_1.mp3" {
+		t.Fatalf("audio_file unit A = %v", u.AudioFile)
+	}
+	if u, _ := h.repos.Units.Get(ctx, unitB); u.AudioFile != nil {
+		t.Fatalf("audio_file unit B ikut berubah: %v", *u.AudioFile)
+	}
+	if a, _ := h.repos.Audios.Get(ctx, id); a.NamaAudio != "loket_1.mp3" || a.PathAudio != audioDBPath("loket_1.mp3") {
+		t.Fatalf("audio = %+v", a)
+	}
+	if _, err := os.Stat(filepath.Join(AudioBasePath, "loket.mp3")); !os.IsNotExist(err) {
+		t.Fatalf("file lama masih ada: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(AudioBasePath, "loket_1.mp3")); !bytes.Equal(data, mp3Data(1)) {
+		t.Fatal("file tidak ikut di-rename")
+	}
+}
+
+func TestAudioUsage(t *testing.T) {
+	h, app := audioApp(t, memory.New())
+	ctx := context.Background()
+
+	loket := seedAudio(t, h, "loket.mp3", mp3Data(1))
+	seedAudio(t, h, "kasir.mp3", mp3Data(2))
+	file := "loket.mp3"
+	h.repos.Units.Create(ctx, models.Unit{Code: "B", NamaUnit: "Pajak", AudioFile: &file, IsActive: "y", MainDisplay: "active"})
+	h.repos.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Dukcapil", AudioFile: &file, IsActive: "n", MainDisplay: "active"})
+
+	status, body := do(t, app, "GET", "/audio/usage", "")
+	if status != fiber.StatusOK {
+		t.Fatalf("usage = %d %v", status, body)
+	}
+	data := body["data"].([]any)
+	if len(data) != 2 || data[0].(map[string]any)["nama_audio"] != "kasir.mp3" {
+		t.Fatalf("data = %v", data)
+	}
+	usage := data[1].(map[string]any)
+	units := usage["units"].([]any)
+	if usage["usage_count"] != float64(2) || units[0].(map[string]any)["nama_unit"] != "Dukcapil" {
+		t.Fatalf("usage loket = %v", usage)
+	}
+
+	_, body = do(t, app, "GET", "/audio/usage?unused=true", "")
+	if data := body["data"].([]any); len(data) != 1 || data[0].(map[string]any)["nama_audio"] != "kasir.mp3" {
+		t.Fatalf("unused = %v", data)
+	}
+	_, body = do(t, app, "GET", fmt.Sprintf("/audio/usage?audio_id=%d", loket), "")
+	if data := body["data"].([]any); len(data) != 1 {
+		t.Fatalf("audio_id = %v", data)
+	}
+	for _, q := range []string{"99", "abc"} {
+		if status, _ := do(t, app, "GET", "/audio/usage?audio_id="+q, ""); status != fiber.StatusNotFound {
+			t.Fatalf("audio_id=%s = %d, want 404", q, status)
+		}
+	}
+}
+
+func TestImportAudioZip(t *testing.T) {
+	h, app := audioApp(t, memory.New())
+	ctx := context.Background()
+
+	// Satu entry rusak: tidak ada yang tersimpan
+	bad := zipOf(t, map[string][]byte{
+		"audio/loket_a.mp3": mp3Data(1),
+		"audio/loket_b.mp3": mp3Data(2),
+		"audio/rusak.mp3":   []byte("bukan mp3"),
+	})
+	status, body := upload(t, app, "POST", "/audio/import", "audio.zip", bad, nil)
+	if status != fiber.StatusBadRequest || len(body["errors"].([]any)) != 1 {
+		t.Fatalf("import rusak = %d %v, want 400 dengan satu error", status, body)
+	}
+	if audios, _ := h.repos.Audios.List(ctx); len(audios) != 0 {
+		t.Fatalf("audio tersimpan walau import dibatalkan: %v", audios)
+	}
+	if entries, _ := os.ReadDir(AudioBasePath); len(entries) != 0 {
+		t.Fatalf("file tertinggal di disk: %v", entries)
+	}
+
+	good := zipOf(t, map[string][]byte{
+		"loket_a.mp3":  mp3Data(1),
+		"loket_b.mp3":  mp3Data(2),
+		"manifest.csv": []byte("nama_audio,tts_text\nloket_a,Loket A\n"),
+		"catatan.txt":  []byte("abaikan"),
+	})
+	status, body = upload(t, app, "POST", "/audio/import", "audio.zip", good, nil)
+	if status != fiber.StatusCreated {
+		t.Fatalf("import = %d %v", status, body)
+	}
+	data := body["data"].(map[string]any)
+	imported := data["imported"].([]any)
+	if len(imported) != 2 || len(data["skipped"].([]any)) != 1 {
+		t.Fatalf("hasil import = %v", data)
+	}
+	if first := imported[0].(map[string]any); first["nama_audio"] != "loket_a.mp3" || first["tts_text"] != "Loket A" {
+		t.Fatalf("audio pertama = %v", first)
+	}
+	if got, _ := os.ReadFile(filepath.Join(AudioBasePath, "loket_b.mp3")); !bytes.Equal(got, mp3Data(2)) {
+		t.Fatal("file loket_b tidak tersimpan")
+	}
+
+	// Import ulang nama yang sama ditolak seluruhnya
+	if status, _ := upload(t, app, "POST", "/audio/import", "audio.zip", good, nil); status != fiber.StatusBadRequest {
+		t.Fatalf("import ulang = %d, want 400", status)
+	}
+	if audios, _ := h.repos.Audios.List(ctx); len(audios) != 2 {
+		t.Fatalf("jumlah audio = %d, want 2", len(audios))
+	}
+}
+
+func TestImportAudioZipDoesNotOverwrite(t *testing.T) {
+	repos := memory.New()
+	racing := &racingAudios{AudioRepo: repos.Audios}
+	repos.Audios = racing
+	h, app := audioApp(t, repos)
+
+	archive := zipOf(t, map[string][]byte{
+		"loket_a.mp3": mp3Data(1),
+		"loket_b.mp3": mp3Data(2),
+		"loket_c.mp3": mp3Data(3),
+	})
+	if status, body := upload(t, app, "POST", "/audio/import", "audio.zip", archive, nil); status != fiber.StatusConflict {
+		t.Fatalf("import saat file dibuat request lain = %d %v, want 409", status, body)
+	}
+
+	// File milik request lain utuh; file yang sudah dipasang import ini
+	// (entry sebelumnya) dihapus lagi
+	taken := racing.checked[1]
+	if data, _ := os.ReadFile(filepath.Join(AudioBasePath, taken)); string(data) != "milik orang lain" {
+		t.Fatalf("%s tertimpa: %q", taken, data)
+	}
+	entries, _ := os.ReadDir(AudioBasePath)
+	if len(entries) != 1 || entries[0].Name() != taken {
+		t.Fatalf("isi direktori audio = %v, want hanya %s", entries, taken)
+	}
+	if audios, _ := h.repos.Audios.List(context.Background()); len(audios) != 0 {
+		t.Fatalf("audio tersimpan walau import gagal: %v", audios)
+	}
+}
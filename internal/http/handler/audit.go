@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/config"
+	"backend-antrian/internal/models"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// auditExportLimit batas baris per file ekspor CSV
+const auditExportLimit = 50000
+
+// auditFilter bangun klausa WHERE dari query string.
+// Filter: actor_type, actor_id, action (prefix), entity, entity_id,
+// start_date & end_date (YYYY-MM-DD), status (success|failed).
+func auditFilter(c *fiber.Ctx) (string, []interface{}, error) {
+	where := " WHERE 1=1"
+	args := []interface{}{}
+
+	if v := c.Query("actor_type"); v != "" {
+		where += " AND actor_type = ?"
+		args = append(args, v)
+	}
+	if v := c.Query("actor_id"); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("actor_id tidak valid")
+		}
+		where += " AND actor_id = ?"
+		args = append(args, id)
+	}
+	if v := strings.TrimSpace(c.Query("action")); v != "" {
+		where += " AND action LIKE ?"
+		args = append(args, v+"%")
+	}
+	if v := c.Query("entity"); v != "" {
+		where += " AND entity = ?"
+		args = append(args, v)
+	}
+	if v := c.Query("entity_id"); v != "" {
+		where += " AND entity_id = ?"
+		args = append(args, v)
+	}
+	if v := c.Query("start_date"); v != "" {
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			return "", nil, fmt.Errorf("Format start_date harus YYYY-MM-DD")
+		}
+		where += " AND created_at >= ?"
+		args = append(args, v+" 00:00:00")
+	}
+	if v := c.Query("end_date"); v != "" {
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			return "", nil, fmt.Errorf("Format end_date harus YYYY-MM-DD")
+		}
+		where += " AND created_at <= ?"
+		args = append(args, v+" 23:59:59")
+	}
+	switch c.Query("status") {
+	case "":
+	case "success":
+		where += " AND status_code < 400"
+	case "failed":
+		where += " AND status_code >= 400"
+	default:
+		return "", nil, fmt.Errorf("status harus success atau failed")
+	}
+
+	return where, args, nil
+}
+
+const auditSelect = `
+	SELECT id, actor_type, actor_id, actor_name, action, entity, entity_id,
+	       before_data, after_data, diff, status_code, method, path,
+	       ip_address, user_agent, created_at
+	FROM audit_logs`
+
+func scanAuditLog(rows *sql.Rows) (models.AuditLog, error) {
+	var l models.AuditLog
+	var before, after, diff sql.NullString
+	err := rows.Scan(
+		&l.ID, &l.ActorType, &l.ActorID, &l.ActorName, &l.Action, &l.Entity, &l.EntityID,
+		&before, &after, &diff, &l.StatusCode, &l.Method, &l.Path,
+		&l.IPAddress, &l.UserAgent, &l.CreatedAt,
+	)
+	if before.Valid {
+		l.Before = json.RawMessage(before.String)
+	}
+	if after.Valid {
+		l.After = json.RawMessage(after.String)
+	}
+	if diff.Valid {
+		l.Diff = json.RawMessage(diff.String)
+	}
+	return l, err
+}
+
+// GetAuditLogs - Daftar audit log dengan filter dan pagination
+func GetAuditLogs(c *fiber.Ctx) error {
+	page := c.QueryInt("page", 1)
+	limit := c.QueryInt("limit", 20)
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := (page - 1) * limit
+
+	where, args, err := auditFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	var totalData int
+	if err := config.DB.QueryRow("SELECT COUNT(*) FROM audit_logs"+where, args...).Scan(&totalData); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal menghitung total data",
+		})
+	}
+
+	rows, err := config.DB.Query(
+		auditSelect+where+" ORDER BY id DESC LIMIT ? OFFSET ?",
+		append(args, limit, offset)...,
+	)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil audit log",
+		})
+	}
+	defer rows.Close()
+
+	logs := []models.AuditLog{}
+	for rows.Next() {
+		l, err := scanAuditLog(rows)
+		if err != nil {
+			continue
+		}
+		logs = append(logs, l)
+	}
+
+	totalPages := (totalData + limit - 1) / limit
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    logs,
+		"pagination": fiber.Map{
+			"page":        page,
+			"limit":       limit,
+			"total_data":  totalData,
+			"total_pages": totalPages,
+		},
+	})
+}
+
+// ExportAuditLogs - Unduh audit log (filter sama dengan GetAuditLogs) sebagai CSV
+func ExportAuditLogs(c *fiber.Ctx) error {
+	where, args, err := auditFilter(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	rows, err := config.DB.Query(
+		auditSelect+where+" ORDER BY id DESC LIMIT ?",
+		append(args, auditExportLimit)...,
+	)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil audit log",
+		})
+	}
+	defer rows.Close()
+
+	filename := fmt.Sprintf("audit_log_%s.csv", clock.Now().Format("20060102_150405"))
+	c.Set("Content-Type", "text/csv; charset=utf-8")
+	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+
+	w := csv.NewWriter(c)
+	w.Write([]string{
+		"id", "waktu", "actor_type", "actor_id", "actor_name", "action", "entity", "entity_id",
+		"status_code", "method", "path", "ip_address", "user_agent", "diff",
+	})
+
+	for rows.Next() {
+		l, err := scanAuditLog(rows)
+		if err != nil {
+			continue
+		}
+		actorID := ""
+		if l.ActorID != nil {
+			actorID = strconv.FormatInt(*l.ActorID, 10)
+		}
+		w.Write([]string{
+			strconv.FormatInt(l.ID, 10),
+			l.CreatedAt.Format("2006-01-02 15:04:05"),
+			l.ActorType,
+			actorID,
+			strOrEmpty(l.ActorName),
+			l.Action,
+			strOrEmpty(l.Entity),
+			strOrEmpty(l.EntityID),
+			strconv.Itoa(l.StatusCode),
+			strOrEmpty(l.Method),
+			strOrEmpty(l.Path),
+			strOrEmpty(l.IPAddress),
+			strOrEmpty(l.UserAgent),
+			string(l.Diff),
+		})
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func strOrEmpty(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -1,62 +1,39 @@
 package handler
 
 import (
-	"backend-antrian/internal/config"
+	"backend-antrian/internal/captcha"
 	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
 	"database/sql"
+	"errors"
 
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func Login(c *fiber.Ctx) error {
+func (h *Handler) Login(c *fiber.Ctx) error {
 	var req models.LoginRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := bindBody(c, &req); err != nil {
+		return err
 	}
 
-	if req.Email == "" || req.Password == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Email dan password harus diisi",
-		})
-	}
-	
-	if req.RecaptchaToken == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "reCAPTCHA token tidak valid",
-		})
+	// Tolak lebih awal jika akun / IP sedang terkunci karena terlalu banyak gagal
+	guardKeys := loginKeys(c, "user", req.Email)
+	if blocked, err := loginBlocked(c, guardKeys); blocked {
+		return err
 	}
 
-	ok, score, err := config.VerifyRecaptcha(req.RecaptchaToken)
-	if err != nil {
-		println("❌ reCAPTCHA error:", err.Error())
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Gagal verifikasi reCAPTCHA",
-		})
+	captchaToken := req.CaptchaToken
+	if captchaToken == "" {
+		captchaToken = req.RecaptchaToken
 	}
-
-	if !ok || score < 0.5 {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "Aktivitas mencurigakan terdeteksi",
-		})
+	if err := captcha.Default.Check(c.UserContext(), captchaToken, c.IP()); err != nil {
+		return captchaError(c, err)
 	}
 
-	var user models.User
-	query := `SELECT id, nama, email, password, role, is_banned, unit_id
-	          FROM users WHERE email = ?`
-	err = config.DB.QueryRow(query, req.Email).Scan(
-		&user.ID,
-		&user.Nama,
-		&user.Email,
-		&user.Password,
-		&user.Role,
-		&user.IsBanned,
-		&user.UnitID,
-	)
-
-	if err == sql.ErrNoRows {
+	creds, err := h.repos.Users.GetByEmail(c.UserContext(), req.Email)
+	if errors.Is(err, repository.ErrNotFound) {
+		recordLoginFailure(c, "user", nil, req.Email, "unknown_email", guardKeys)
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "Email atau password salah",
 		})
@@ -67,6 +44,7 @@ func Login(c *fiber.Ctx) error {
 			"error": "Database error",
 		})
 	}
+	user := creds.User
 
 	// Check if user is banned
 	if user.IsBanned == "y" {
@@ -77,97 +55,106 @@ func Login(c *fiber.Ctx) error {
 
 	// Verify password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		recordLoginFailure(c, "user", &user.ID, req.Email, "wrong_password", guardKeys)
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "Email atau password salah",
 		})
 	}
-
-	// Handle nullable unit_id
-	var unitID *int64
-	if user.UnitID.Valid {
-		unitID = &user.UnitID.Int64
+	recordLoginSuccess(c, "user", req.Email)
+
+	// Tentukan unit aktif: pilihan saat login, atau unit default user
+	if req.UnitID != nil {
+		member, err := h.repos.Users.IsUnitMember(c.UserContext(), user.ID, *req.UnitID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Database error",
+			})
+		}
+		if !member {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Anda bukan anggota unit ini",
+			})
+		}
+		user.UnitID = sql.NullInt64{Int64: *req.UnitID, Valid: true}
+	} else {
+		user.UnitID, err = h.resolveActiveUnit(c.UserContext(), user.ID, user.UnitID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Database error",
+			})
+		}
+	}
+
+	// 2FA: user yang sudah enrol atau role-nya wajib 2FA lanjut ke langkah kedua
+	required, enrolled, err := h.twoFactorState(c.UserContext(), user.ID, user.Role)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if required || enrolled {
+		return h.twoFactorChallengeResponse(c, user, enrolled)
 	}
 
-	// Generate JWT token
-	token, err := config.GenerateToken(user.ID, user.Nama, user.Email, user.Role, unitID)
+	// Buat sesi: access token (JWT) + refresh token
+	response, err := h.createSession(c, user)
 	if err != nil {
+		authLog.ErrorContext(c.UserContext(), "create session error", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to generate token",
 		})
 	}
 
 	// Return response dengan pesan welcome
-	return c.JSON(fiber.Map{
-		"token": token,
-		"user":  models.ToUserResponse(user),
-		"message": "Login berhasil! Selamat datang kembali, " + user.Nama,
-	})
+	response["user"] = models.ToUserResponse(user)
+	response["units"], _ = h.userUnits(c.UserContext(), user.ID)
+	response["must_change_password"] = creds.MustChangePassword
+	response["message"] = "Login berhasil! Selamat datang kembali, " + user.Nama
+	return c.JSON(response)
 }
-func LoginKiosk(c *fiber.Ctx) error {
-	var req models.LoginRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
-	}
 
-	if req.Email == "" || req.Password == "" {
+// captchaError response untuk token captcha yang tidak lolos
+func captchaError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, captcha.ErrMissingToken):
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Email dan password harus diisi",
+			"error": "Token captcha tidak valid",
 		})
-	}
-
-	var user models.User
-	query := `SELECT id, nama, email, password, role, is_banned, unit_id
-	          FROM users WHERE email = ?`
-	err := config.DB.QueryRow(query, req.Email).Scan(
-		&user.ID,
-		&user.Nama,
-		&user.Email,
-		&user.Password,
-		&user.Role,
-		&user.IsBanned,
-		&user.UnitID,
-	)
-
-	if err == sql.ErrNoRows {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Email atau password salah",
-		})
-	}
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Database error",
-		})
-	}
-
-	if user.IsBanned == "y" {
+	case errors.Is(err, captcha.ErrRejected):
+		authLog.InfoContext(c.UserContext(), "captcha ditolak", "ip", c.IP(), "err", err)
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "Akun Anda telah diblokir",
+			"error": "Aktivitas mencurigakan terdeteksi",
 		})
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Email atau password salah",
-		})
-	}
+	authLog.ErrorContext(c.UserContext(), "captcha error", "provider", captcha.Default.Verifier.Name(), "err", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Gagal verifikasi captcha",
+	})
+}
 
-	var unitID *int64
-	if user.UnitID.Valid {
-		unitID = &user.UnitID.Int64
+// GetCaptchaChallenge - Info provider captcha untuk frontend; untuk provider
+// proof-of-work sekaligus menerbitkan challenge baru.
+func GetCaptchaChallenge(c *fiber.Ctx) error {
+	guard := captcha.Default
+	data := fiber.Map{
+		"provider": guard.Verifier.Name(),
+		"site_key": guard.SiteKey,
+		"action":   guard.Action,
 	}
 
-	token, err := config.GenerateToken(user.ID, user.Nama, user.Email, user.Role, unitID)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to generate token",
-		})
+	if issuer, ok := guard.Verifier.(captcha.ChallengeIssuer); ok {
+		ch, err := issuer.Challenge()
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Gagal membuat challenge captcha",
+			})
+		}
+		data["challenge"] = ch
 	}
 
 	return c.JSON(fiber.Map{
-		"token":   token,
-		"user":    models.ToUserResponse(user),
-		"message": "Login berhasil! Selamat datang, " + user.Nama,
+		"success": true,
+		"data":    data,
 	})
-}
\ No newline at end of file
+}
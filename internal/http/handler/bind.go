@@ -0,0 +1,17 @@
+package handler
+
+import (
+	"backend-antrian/internal/validation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// bindBody parse body request lalu jalankan tag `validate` pada struct tujuan.
+// Error dikembalikan apa adanya supaya ErrorHandler merender 400 (body rusak)
+// atau 422 dengan daftar field yang gagal, dalam bahasa dari Accept-Language.
+func bindBody(c *fiber.Ctx, out interface{}) error {
+	if err := c.BodyParser(out); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	return validation.Struct(out, validation.Lang(c.Get(fiber.HeaderAcceptLanguage)))
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"backend-antrian/internal/permission"
+	"backend-antrian/internal/realtime"
+	"context"
+	"encoding/json"
+	"time"
+)
+
+/*
+|--------------------------------------------------------------------------
+| Realtime Fan-out
+|--------------------------------------------------------------------------
+| Update antrian, unit, pengumuman, dan event display di-publish ke
+| realtime.Bus. Setiap replica subscribe dan meneruskan ke socket lokalnya
+| (queueClients & realtime.Units), jadi ticket yang dipanggil lewat replica A
+| tetap sampai ke display yang terhubung ke replica B.
+*/
+
+const realtimePublishTimeout = 2 * time.Second
+
+// realtimeHandlers handler lokal per channel — juga dipakai sebagai fallback
+// jika publish gagal (mis. Redis putus), supaya minimal replica ini tetap update.
+// Diisi SubscribeRealtime.
+var realtimeHandlers map[string]realtime.Handler
+
+// SubscribeRealtime daftarkan handler fan-out lokal ke realtime.Bus.
+// Dipanggil sekali dari main sebelum realtime.Bus.Start.
+func (h *Handler) SubscribeRealtime() {
+	realtimeHandlers = map[string]realtime.Handler{
+		realtime.ChannelQueueChanges:  h.onQueueChanges,
+		realtime.ChannelQueueRefresh:  h.onQueueRefresh,
+		realtime.ChannelAnnouncements: onAnnouncement,
+		realtime.ChannelUnitsStatus:   h.onUnitsStatus,
+		realtime.ChannelDisplayEvents: h.onDisplayEvent,
+		realtime.ChannelRoleChanges:   onRoleChange,
+	}
+	for channel, fn := range realtimeHandlers {
+		realtime.Bus.Subscribe(channel, fn)
+	}
+}
+
+// publishRealtime kirim payload ke semua replica. ctx membawa request_id
+// pemicu (jika ada) untuk log.
+func publishRealtime(ctx context.Context, channel string, payload []byte) {
+	ctx, cancel := context.WithTimeout(ctx, realtimePublishTimeout)
+	defer cancel()
+
+	if err := realtime.Bus.Publish(ctx, channel, payload); err != nil {
+		realtimeLog.WarnContext(ctx, "publish error, fallback ke fan-out lokal", "channel", channel, "err", err)
+		if fn, ok := realtimeHandlers[channel]; ok {
+			fn(payload)
+		}
+	}
+}
+
+// displayEvent - event display antar replica
+type displayEvent struct {
+	Type      string          `json:"type"` // refresh, disconnect, command
+	DisplayID int64           `json:"display_id"`
+	Reason    string          `json:"reason,omitempty"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+}
+
+// announcementEvent - notifikasi pengumuman antar replica
+type announcementEvent struct {
+	Seq       int64 `json:"seq"`
+	UnitID    int64 `json:"unit_id"`
+	ServiceID int64 `json:"service_id"`
+}
+
+func publishDisplayEvent(ev displayEvent) {
+	payload, _ := json.Marshal(ev)
+	publishRealtime(context.Background(), realtime.ChannelDisplayEvents, payload)
+}
+
+func (h *Handler) onQueueChanges(payload []byte) {
+	var changes []TicketChange
+	if err := json.Unmarshal(payload, &changes); err != nil {
+		realtimeLog.Error("invalid queue changes", "err", err)
+		return
+	}
+	h.enqueueQueueChange(changes)
+}
+
+func (h *Handler) onQueueRefresh([]byte) {
+	h.scheduleQueueRebuild()
+}
+
+func onAnnouncement(payload []byte) {
+	var ev announcementEvent
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		realtimeLog.Error("invalid announcement event", "err", err)
+		return
+	}
+	fanoutAnnouncementAvailable(ev.Seq, ev.UnitID, ev.ServiceID)
+}
+
+func (h *Handler) onUnitsStatus(payload []byte) {
+	// Unit berubah di replica mana pun — zona waktunya mungkin ikut berubah
+	h.invalidateUnitZones()
+	realtime.Units.Broadcast <- payload
+}
+
+func onRoleChange([]byte) {
+	permission.Invalidate()
+}
+
+func (h *Handler) onDisplayEvent(payload []byte) {
+	var ev displayEvent
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		realtimeLog.Error("invalid display event", "err", err)
+		return
+	}
+
+	switch ev.Type {
+	case "refresh":
+		h.refreshLocalDisplayClients(ev.DisplayID)
+	case "disconnect":
+		disconnectLocalDisplayClients(ev.DisplayID, ev.Reason)
+	case "command":
+		for _, client := range displayClients(ev.DisplayID) {
+			writeToClient(client, ev.Payload)
+		}
+	}
+}
@@ -1,23 +1,16 @@
 package handler
 
 import (
-	"backend-antrian/internal/config"
 	"backend-antrian/internal/models"
-	"database/sql"
+	"backend-antrian/internal/repository"
+	"errors"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func GetConfig(c *fiber.Ctx) error {
-	var cfg models.Config
-	query := "SELECT id, text_marque FROM configs LIMIT 1"
-
-	err := config.DB.QueryRow(query).Scan(
-		&cfg.ID,
-		&cfg.TextMarque,
-	)
-
-	if err == sql.ErrNoRows {
+func (h *Handler) GetConfig(c *fiber.Ctx) error {
+	cfg, err := h.repos.Configs.Get(c.UserContext())
+	if errors.Is(err, repository.ErrNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Konfigurasi belum diatur",
 		})
@@ -36,79 +29,50 @@ func GetConfig(c *fiber.Ctx) error {
 }
 
 // CreateConfig - Buat konfigurasi baru (hanya jika belum ada)
-func CreateConfig(c *fiber.Ctx) error {
-	var req struct {
-		TextMarque string `json:"text_marque"`
-	}
+func (h *Handler) CreateConfig(c *fiber.Ctx) error {
+	var req models.CreateConfigRequest
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := bindBody(c, &req); err != nil {
+		return err
 	}
 
-	if req.TextMarque == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Text marque wajib diisi",
+	// Cek apakah sudah ada data
+	_, err := h.repos.Configs.Get(c.UserContext())
+	if err == nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Konfigurasi sudah ada, gunakan update untuk mengubah",
 		})
 	}
-
-	// Cek apakah sudah ada data
-	var count int
-	err := config.DB.QueryRow("SELECT COUNT(*) FROM configs").Scan(&count)
-	if err != nil {
+	if !errors.Is(err, repository.ErrNotFound) {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal validasi konfigurasi",
 		})
 	}
 
-	if count > 0 {
-		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-			"error": "Konfigurasi sudah ada, gunakan update untuk mengubah",
-		})
-	}
-
-	result, err := config.DB.Exec("INSERT INTO configs (text_marque) VALUES (?)", req.TextMarque)
+	id, err := h.repos.Configs.Create(c.UserContext(), req.TextMarque)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal membuat konfigurasi",
 		})
 	}
 
-	id, _ := result.LastInsertId()
-
-	var cfg models.Config
-	config.DB.QueryRow("SELECT id, text_marque FROM configs WHERE id = ?", id).
-		Scan(&cfg.ID, &cfg.TextMarque)
-
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"success": true,
 		"message": "Konfigurasi berhasil dibuat",
-		"data":    cfg,
+		"data":    models.Config{ID: id, TextMarque: req.TextMarque},
 	})
 }
 
 // UpdateConfig - Update konfigurasi yang sudah ada
-func UpdateConfig(c *fiber.Ctx) error {
-	var req struct {
-		TextMarque string `json:"text_marque"`
-	}
+func (h *Handler) UpdateConfig(c *fiber.Ctx) error {
+	var req models.UpdateConfigRequest
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := bindBody(c, &req); err != nil {
+		return err
 	}
 
-	if req.TextMarque == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Text marque wajib diisi",
-		})
-	}
-
-	var configID int64
-	err := config.DB.QueryRow("SELECT id FROM configs LIMIT 1").Scan(&configID)
-	if err == sql.ErrNoRows {
+	cfg, err := h.repos.Configs.Get(c.UserContext())
+	if errors.Is(err, repository.ErrNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Konfigurasi belum dibuat, gunakan create terlebih dahulu",
 		})
@@ -119,20 +83,16 @@ func UpdateConfig(c *fiber.Ctx) error {
 		})
 	}
 
-	_, err = config.DB.Exec("UPDATE configs SET text_marque = ? WHERE id = ?", req.TextMarque, configID)
-	if err != nil {
+	if err := h.repos.Configs.Update(c.UserContext(), cfg.ID, req.TextMarque); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal mengupdate konfigurasi",
 		})
 	}
-
-	var cfg models.Config
-	config.DB.QueryRow("SELECT id, text_marque FROM configs WHERE id = ?", configID).
-		Scan(&cfg.ID, &cfg.TextMarque)
+	cfg.TextMarque = req.TextMarque
 
 	return c.JSON(fiber.Map{
 		"success": true,
 		"message": "Konfigurasi berhasil diupdate",
 		"data":    cfg,
 	})
-}
\ No newline at end of file
+}
@@ -1,74 +1,38 @@
 package handler
 
 import (
-	"backend-antrian/internal/config"
-	"time"
+	"backend-antrian/internal/repository"
+	"sort"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // GetUnitDashboardStatistics - Endpoint untuk dashboard unit (hari ini saja)
-func GetUnitDashboardStatistics(c *fiber.Ctx) error {
+func (h *Handler) GetUnitDashboardStatistics(c *fiber.Ctx) error {
 	// Ambil unit_id dari JWT claims
-	unitID := c.Locals("unit_id")
-	if unitID == nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unit ID tidak ditemukan",
-		})
-	}
-
-	// Get today's date
-	today := time.Now().Format("2006-01-02")
-
-	// ===========================
-	// 1. SUMMARY DATA
-	// ===========================
-
-	// Total Kunjungan (semua status, hari ini, unit ini)
-	var totalVisitors int
-	queryTotalVisitors := `
-		SELECT COUNT(qt.id)
-		FROM queue_tickets qt
-		WHERE qt.unit_id = ?
-		AND DATE(qt.created_at) = ?
-	`
-	err := config.DB.QueryRow(queryTotalVisitors, unitID, today).Scan(&totalVisitors)
+	unitID, err := h.activeUnitID(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Gagal mengambil total kunjungan",
-		})
+		return unitAccessError(c, err)
 	}
 
-	// Total Dilayani (status 'done' saja)
-	var totalServed int
-	queryTotalServed := `
-		SELECT COUNT(qt.id)
-		FROM queue_tickets qt
-		WHERE qt.unit_id = ?
-		AND DATE(qt.created_at) = ?
-		AND (qt.status = 'done' OR qt.status = 'called')
-	`
-	err = config.DB.QueryRow(queryTotalServed, unitID, today).Scan(&totalServed)
+	// Rekap hari ini (zona unit) per layanan
+	stats, err := h.repos.Tickets.StatsToday(c.UserContext(), unitID, h.unitTodayByID(c.UserContext(), unitID))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Gagal mengambil total dilayani",
+			"error": "Gagal mengambil statistik unit",
 		})
 	}
 
-	// Total Skip (status 'skipped' saja)
-	var totalSkipped int
-	queryTotalSkipped := `
-		SELECT COUNT(qt.id)
-		FROM queue_tickets qt
-		WHERE qt.unit_id = ?
-		AND DATE(qt.created_at) = ?
-		AND qt.status = 'skipped'
-	`
-	err = config.DB.QueryRow(queryTotalSkipped, unitID, today).Scan(&totalSkipped)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Gagal mengambil total skip",
-		})
+	// ===========================
+	// 1. SUMMARY DATA
+	// ===========================
+
+	// Total Kunjungan (semua status), Dilayani (done / called), Skip
+	var totalVisitors, totalServed, totalSkipped int
+	for _, st := range stats {
+		totalVisitors += st.Total
+		totalServed += st.Served
+		totalSkipped += st.Skipped
 	}
 
 	// ===========================
@@ -79,35 +43,20 @@ func GetUnitDashboardStatistics(c *fiber.Ctx) error {
 		Total int    `json:"total"`
 	}
 
-	queryLayanan := `
-		SELECT 
-			s.nama_service as nama,
-			COUNT(qt.id) as total
-		FROM queue_tickets qt
-		INNER JOIN services s ON qt.service_id = s.id
-		WHERE qt.unit_id = ?
-		AND DATE(qt.created_at) = ?
-		GROUP BY qt.service_id, s.nama_service
-		HAVING total > 0
-		ORDER BY total DESC
-	`
-
-	rows, err := config.DB.Query(queryLayanan, unitID, today)
+	services, err := h.repos.Services.List(c.UserContext(), repository.ServiceFilter{UnitID: unitID})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal mengambil data layanan",
 		})
 	}
-	defer rows.Close()
 
 	layananData := []LayananData{}
-	for rows.Next() {
-		var ld LayananData
-		if err := rows.Scan(&ld.Nama, &ld.Total); err != nil {
-			continue
+	for _, s := range services {
+		if total := stats[s.ID].Total; total > 0 {
+			layananData = append(layananData, LayananData{Nama: s.NamaService, Total: total})
 		}
-		layananData = append(layananData, ld)
 	}
+	sort.SliceStable(layananData, func(i, j int) bool { return layananData[i].Total > layananData[j].Total })
 
 	// ===========================
 	// RESPONSE
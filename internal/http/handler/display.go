@@ -0,0 +1,410 @@
+package handler
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/loginguard"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	pairingCodeLength   = 6
+	pairingCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789" // tanpa 0/O/1/I
+	// Kode pendek mudah ditebak — berlaku singkat dan percobaan gagal
+	// dibatasi per IP (loginguard) di PairDisplay
+	pairingCodeTTL = 15 * time.Minute
+)
+
+// GetAllDisplays - Daftar semua display terdaftar (super_user only)
+func (h *Handler) GetAllDisplays(c *fiber.Ctx) error {
+	displays, err := h.repos.Displays.List(c.UserContext())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil data display",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    displays,
+	})
+}
+
+// GetDisplayByID - Detail satu display (super_user only)
+func (h *Handler) GetDisplayByID(c *fiber.Ctx) error {
+	display, err := h.repos.Displays.Get(c.UserContext(), paramID(c, "id"))
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Display tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil data display",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    display,
+	})
+}
+
+// CreateDisplay - Daftarkan display baru dan buat pairing code (super_user only)
+func (h *Handler) CreateDisplay(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	var req models.CreateDisplayRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	req.Nama = strings.TrimSpace(req.Nama)
+
+	if req.Theme == "" {
+		req.Theme = "default"
+	}
+	if req.PlaysAudio == "" {
+		req.PlaysAudio = "n"
+	}
+	if req.IsActive == "" {
+		req.IsActive = "y"
+	}
+	if (req.PlaysAudio != "y" && req.PlaysAudio != "n") || (req.IsActive != "y" && req.IsActive != "n") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "plays_audio dan is_active harus 'y' atau 'n'",
+		})
+	}
+
+	if msg := h.validateDisplayScope(ctx, req.UnitIDs, req.ServiceIDs); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	code, err := generatePairingCode()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal membuat pairing code",
+		})
+	}
+	expiresAt := clock.Now().Add(pairingCodeTTL)
+
+	id, err := h.repos.Displays.Create(ctx, models.Display{
+		Nama:             req.Nama,
+		PairingCode:      &code,
+		PairingExpiresAt: &expiresAt,
+		Theme:            req.Theme,
+		PlaysAudio:       req.PlaysAudio,
+		IsActive:         req.IsActive,
+		UnitIDs:          req.UnitIDs,
+		ServiceIDs:       req.ServiceIDs,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal membuat display",
+		})
+	}
+
+	display, _ := h.repos.Displays.Get(ctx, id)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Display berhasil dibuat, masukkan pairing code di perangkat",
+		"data":    display,
+	})
+}
+
+// UpdateDisplay - Update konfigurasi display (super_user only).
+// Perubahan langsung dikirim ke perangkat yang sedang terhubung.
+func (h *Handler) UpdateDisplay(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	var req models.UpdateDisplayRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	display, err := h.repos.Displays.Get(ctx, paramID(c, "id"))
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Display tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil data display",
+		})
+	}
+
+	patch := repository.DisplayPatch{
+		Nama:       strings.TrimSpace(req.Nama),
+		Theme:      req.Theme,
+		PlaysAudio: req.PlaysAudio,
+		IsActive:   req.IsActive,
+		UnitIDs:    req.UnitIDs,
+		ServiceIDs: req.ServiceIDs,
+	}
+
+	if patch.PlaysAudio != "" && patch.PlaysAudio != "y" && patch.PlaysAudio != "n" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "plays_audio harus 'y' atau 'n'",
+		})
+	}
+	if patch.IsActive != "" && patch.IsActive != "y" && patch.IsActive != "n" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "is_active harus 'y' atau 'n'",
+		})
+	}
+
+	scopeChanged := req.UnitIDs != nil || req.ServiceIDs != nil
+	if scopeChanged {
+		unitIDs, serviceIDs := display.UnitIDs, display.ServiceIDs
+		if req.UnitIDs != nil {
+			unitIDs = *req.UnitIDs
+		}
+		if req.ServiceIDs != nil {
+			serviceIDs = *req.ServiceIDs
+		}
+		if msg := h.validateDisplayScope(ctx, unitIDs, serviceIDs); msg != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": msg,
+			})
+		}
+	}
+
+	if patch.Nama == "" && patch.Theme == "" && patch.PlaysAudio == "" && patch.IsActive == "" && !scopeChanged {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Tidak ada data yang diupdate",
+		})
+	}
+
+	if err := h.repos.Displays.Update(ctx, display.ID, patch); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengupdate display",
+		})
+	}
+
+	refreshDisplayClients(display.ID)
+
+	display, _ = h.repos.Displays.Get(ctx, display.ID)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Display berhasil diupdate",
+		"data":    display,
+	})
+}
+
+// RegeneratePairingCode - Buat pairing code baru dan cabut token lama (super_user only).
+// Dipakai saat perangkat diganti atau token bocor.
+func (h *Handler) RegeneratePairingCode(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	display, err := h.repos.Displays.Get(ctx, paramID(c, "id"))
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Display tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil data display",
+		})
+	}
+
+	code, err := generatePairingCode()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal membuat pairing code",
+		})
+	}
+
+	if err := h.repos.Displays.ResetPairing(ctx, display.ID, code, clock.Now().Add(pairingCodeTTL)); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal menyimpan pairing code",
+		})
+	}
+
+	disconnectDisplayClients(display.ID, "unpaired")
+
+	display, _ = h.repos.Displays.Get(ctx, display.ID)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Pairing code baru berhasil dibuat",
+		"data":    display,
+	})
+}
+
+// DeleteDisplay - Hapus display permanent (super_user only)
+func (h *Handler) DeleteDisplay(c *fiber.Ctx) error {
+	id := paramID(c, "id")
+
+	err := h.repos.Displays.Delete(c.UserContext(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Display tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal menghapus display",
+		})
+	}
+
+	disconnectDisplayClients(id, "deleted")
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Display berhasil dihapus",
+	})
+}
+
+// PairDisplay - Perangkat menukar pairing code dengan token (public).
+// Token hanya ditampilkan sekali; server hanya menyimpan hash-nya.
+func (h *Handler) PairDisplay(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	var req models.PairDisplayRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	req.PairingCode = strings.ToUpper(strings.TrimSpace(req.PairingCode))
+	req.DeviceID = strings.TrimSpace(req.DeviceID)
+
+	// Pairing code ditebak = brute-force; pakai counter per IP yang sama dengan login
+	ipKey := loginguard.IP(c.IP())
+	if blocked, err := loginBlocked(c, []loginguard.Key{ipKey}); blocked {
+		return err
+	}
+
+	display, err := h.repos.Displays.ByPairingCode(ctx, req.PairingCode)
+	if errors.Is(err, repository.ErrNotFound) {
+		if _, err := loginguard.Default.Fail(ctx, ipKey); err != nil {
+			displayLog.ErrorContext(ctx, "loginguard fail error", "err", err)
+		}
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Pairing code tidak valid",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal memvalidasi pairing code",
+		})
+	}
+
+	if display.PairingExpiresAt == nil || clock.Now().After(*display.PairingExpiresAt) {
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{
+			"error": "Pairing code sudah kedaluwarsa",
+		})
+	}
+	if display.IsActive != "y" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Display tidak aktif",
+		})
+	}
+
+	if used, _ := h.repos.Displays.DeviceIDExists(ctx, req.DeviceID, display.ID); used {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "device_id sudah terdaftar di display lain",
+		})
+	}
+
+	token, err := generateDeviceToken()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal membuat token perangkat",
+		})
+	}
+
+	if err := h.repos.Displays.Pair(ctx, display.ID, req.DeviceID, hashDeviceToken(token)); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal menyimpan pairing",
+		})
+	}
+
+	display, _ = h.repos.Displays.Get(ctx, display.ID)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Display berhasil dipasangkan",
+		"data": fiber.Map{
+			"device_id": req.DeviceID,
+			"token":     token,
+			"display":   display,
+		},
+	})
+}
+
+/*
+|--------------------------------------------------------------------------
+| Helper
+|--------------------------------------------------------------------------
+*/
+
+// validateDisplayScope pastikan unit_ids & service_ids ada di database
+func (h *Handler) validateDisplayScope(ctx context.Context, unitIDs, serviceIDs []int64) string {
+	for _, id := range uniqueIDs(unitIDs) {
+		if _, err := h.repos.Units.Get(ctx, id); err != nil {
+			return fmt.Sprintf("Unit ID %d tidak ditemukan", id)
+		}
+	}
+	for _, id := range uniqueIDs(serviceIDs) {
+		if _, err := h.repos.Services.Get(ctx, id); err != nil {
+			return fmt.Sprintf("Service ID %d tidak ditemukan", id)
+		}
+	}
+	return ""
+}
+
+func uniqueIDs(ids []int64) []int64 {
+	seen := map[int64]bool{}
+	result := []int64{}
+	for _, id := range ids {
+		if id <= 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		result = append(result, id)
+	}
+	return result
+}
+
+func generatePairingCode() (string, error) {
+	max := big.NewInt(int64(len(pairingCodeAlphabet)))
+	code := make([]byte, pairingCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = pairingCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+func generateDeviceToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashDeviceToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,306 @@
+package handler
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+)
+
+/*
+|--------------------------------------------------------------------------
+| Display Health & Remote Command
+|--------------------------------------------------------------------------
+| Heartbeat display (pong) disimpan ke display_status supaya admin bisa
+| melihat TV mana yang mati. Super user bisa mengirim perintah remote
+| (reload, mute, volume, pesan) dan display membalas dengan command_ack.
+*/
+
+const (
+	displayHeartbeatInterval = 30 * time.Second // throttle tulis last_seen_at
+	displayDeadAfter         = 90 * time.Second // sama dengan batas cleanup pong
+	displayCommandAckTimeout = 30 * time.Second
+	displayCommandDefaultDur = 10 // detik, untuk show_message
+)
+
+// recordDisplayConnect simpan awal koneksi display.
+func (h *Handler) recordDisplayConnect(client *ClientInfo) {
+	profile := client.display.Load()
+	if profile == nil {
+		return
+	}
+
+	client.lastHeartbeatSaved = clock.Now()
+
+	err := h.repos.Displays.Connected(context.Background(), repository.DisplayConnection{
+		DisplayID:  profile.ID,
+		ClientID:   client.id,
+		IPAddress:  client.remoteIP,
+		AppVersion: client.appVersion,
+	})
+	if err != nil {
+		displayLog.Error("record connect error", "client", client.id, "err", err)
+	}
+}
+
+// recordDisplayHeartbeat update last_seen_at, maksimal sekali per displayHeartbeatInterval.
+// Dipanggil dari goroutine read loop client (pong handler).
+func (h *Handler) recordDisplayHeartbeat(client *ClientInfo) {
+	profile := client.display.Load()
+	if profile == nil {
+		return
+	}
+
+	now := clock.Now()
+	if now.Sub(client.lastHeartbeatSaved) < displayHeartbeatInterval {
+		return
+	}
+	client.lastHeartbeatSaved = now
+
+	go func(displayID int64, clientID string) {
+		if err := h.repos.Displays.Heartbeat(context.Background(), displayID, clientID); err != nil {
+			displayLog.Error("heartbeat error", "client", clientID, "err", err)
+		}
+	}(profile.ID, client.id)
+}
+
+// recordDisplayDisconnect tandai display putus. Jika device yang sama sudah
+// connect ulang dengan client lain, status tidak diubah.
+func (h *Handler) recordDisplayDisconnect(client *ClientInfo) {
+	profile := client.display.Load()
+	if profile == nil {
+		return
+	}
+
+	if err := h.repos.Displays.Disconnected(context.Background(), profile.ID, client.id); err != nil {
+		displayLog.Error("record disconnect error", "client", client.id, "err", err)
+	}
+}
+
+// recordDisplayAppVersion simpan versi aplikasi yang dilaporkan display (pesan hello).
+func (h *Handler) recordDisplayAppVersion(client *ClientInfo, version string) {
+	profile := client.display.Load()
+	if profile == nil {
+		return
+	}
+
+	version = strings.TrimSpace(version)
+	if version == "" || len(version) > 50 {
+		return
+	}
+	client.appVersion = version
+
+	if err := h.repos.Displays.SetAppVersion(context.Background(), profile.ID, client.id, version); err != nil {
+		displayLog.Error("app version error", "client", client.id, "err", err)
+	}
+}
+
+// clientIP ambil IP asli display. Server berjalan di belakang nginx sehingga
+// X-Real-IP / X-Forwarded-For diutamakan — hanya untuk informasi, bukan otorisasi.
+func clientIP(c *websocket.Conn) string {
+	if ip := strings.TrimSpace(c.Headers("X-Real-IP")); ip != "" {
+		return ip
+	}
+	if xff := c.Headers("X-Forwarded-For"); xff != "" {
+		return strings.TrimSpace(strings.Split(xff, ",")[0])
+	}
+	host, _, err := net.SplitHostPort(c.RemoteAddr().String())
+	if err != nil {
+		return c.RemoteAddr().String()
+	}
+	return host
+}
+
+// GetDisplayHealth - Daftar display beserta status live/dead (super_user only).
+// Query opsional: status=live|dead|never
+func (h *Handler) GetDisplayHealth(c *fiber.Ctx) error {
+	filter := c.Query("status")
+
+	rows, err := h.repos.Displays.Health(c.UserContext(), clock.Now())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil status display",
+		})
+	}
+
+	result := []models.DisplayHealth{}
+	summary := map[string]int{"live": 0, "dead": 0, "never": 0}
+
+	for _, row := range rows {
+		health := row.DisplayHealth
+		health.LiveConnections = len(displayClients(health.ID))
+
+		switch {
+		case health.LastSeenAt == nil:
+			health.Status = "never"
+		case health.DisconnectedAt == nil && row.SecondsSince <= int64(displayDeadAfter.Seconds()):
+			health.Status = "live"
+		case health.LiveConnections > 0:
+			// Masih terhubung di instance ini walau heartbeat DB tertinggal
+			health.Status = "live"
+		default:
+			health.Status = "dead"
+			offline := row.SecondsSince
+			health.SecondsOffline = &offline
+		}
+
+		summary[health.Status]++
+		if filter != "" && filter != health.Status {
+			continue
+		}
+		result = append(result, health)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+		"summary": summary,
+	})
+}
+
+// SendDisplayCommand - Kirim perintah remote ke display yang sedang online (super_user only)
+func (h *Handler) SendDisplayCommand(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	var req models.DisplayCommandRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	params := map[string]interface{}{}
+	switch req.Command {
+	case "set_volume":
+		params["volume"] = *req.Volume
+	case "show_message":
+		req.Message = strings.TrimSpace(req.Message)
+		if req.Duration == 0 {
+			req.Duration = displayCommandDefaultDur
+		}
+		params["message"] = req.Message
+		params["duration"] = req.Duration
+	}
+
+	display, err := h.repos.Displays.Get(ctx, paramID(c, "id"))
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Display tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil data display",
+		})
+	}
+
+	if !h.displayOnline(ctx, display.ID) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Display sedang offline",
+		})
+	}
+
+	paramsJSON, _ := json.Marshal(params)
+
+	cmd := repository.NewDisplayCommand{DisplayID: display.ID, Command: req.Command, Params: string(paramsJSON)}
+	if userID, ok := c.Locals("user_id").(int64); ok {
+		cmd.IssuedBy = &userID
+	}
+
+	commandID, err := h.repos.Displays.CreateCommand(ctx, cmd)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal menyimpan perintah",
+		})
+	}
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type": "command",
+		"data": map[string]interface{}{
+			"id":      commandID,
+			"command": req.Command,
+			"params":  params,
+		},
+	})
+	// Display bisa terhubung ke replica mana pun
+	publishDisplayEvent(displayEvent{Type: "command", DisplayID: display.ID, Payload: payload})
+
+	command, _ := h.repos.Displays.Command(ctx, commandID)
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"success": true,
+		"message": "Perintah dikirim, menunggu ack",
+		"data":    command,
+	})
+}
+
+// displayOnline cek display terhubung ke replica ini atau heartbeat-nya masih baru
+// (terhubung ke replica lain).
+func (h *Handler) displayOnline(ctx context.Context, displayID int64) bool {
+	if len(displayClients(displayID)) > 0 {
+		return true
+	}
+
+	live, err := h.repos.Displays.Online(ctx, displayID, clock.Now().Add(-displayDeadAfter))
+	if err != nil {
+		displayLog.ErrorContext(ctx, "display online error", "display_id", displayID, "err", err)
+	}
+	return live
+}
+
+// GetDisplayCommands - Riwayat perintah remote satu display (super_user only)
+func (h *Handler) GetDisplayCommands(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	limit := c.QueryInt("limit", 50)
+	if limit < 1 || limit > 200 {
+		limit = 50
+	}
+
+	h.expireDisplayCommands(ctx)
+
+	commands, err := h.repos.Displays.Commands(ctx, paramID(c, "id"), limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil riwayat perintah",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    commands,
+	})
+}
+
+// ackDisplayCommand catat ack dari display. Hanya display tujuan yang boleh ack.
+func (h *Handler) ackDisplayCommand(client *ClientInfo, commandID int64, ok bool, errMsg string) (bool, error) {
+	profile := client.display.Load()
+	if profile == nil {
+		return false, nil
+	}
+
+	status := "acked"
+	var errVal *string
+	if !ok {
+		status = "failed"
+		if len(errMsg) > 255 {
+			errMsg = errMsg[:255]
+		}
+		errVal = &errMsg
+	}
+
+	return h.repos.Displays.AckCommand(context.Background(), commandID, profile.ID, client.id, status, errVal)
+}
+
+// expireDisplayCommands tandai perintah yang tidak di-ack dalam batas waktu.
+func (h *Handler) expireDisplayCommands(ctx context.Context) {
+	if err := h.repos.Displays.ExpireCommands(ctx, clock.Now().Add(-displayCommandAckTimeout)); err != nil {
+		displayLog.ErrorContext(ctx, "expire commands error", "err", err)
+	}
+}
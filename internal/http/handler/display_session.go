@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"backend-antrian/internal/config"
+	"backend-antrian/internal/repository"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gofiber/websocket/v2"
+)
+
+/*
+|--------------------------------------------------------------------------
+| Display Session
+|--------------------------------------------------------------------------
+| Display yang terhubung ke /ws/queue mengirim device_id & token hasil
+| pairing. Profil display menentukan unit/layanan yang ditampilkan dan
+| apakah display ini yang memutar audio pengumuman.
+*/
+
+var (
+	ErrDisplayUnauthorized = errors.New("device_id atau token tidak valid")
+	ErrDisplayInactive     = errors.New("display tidak aktif")
+	ErrDisplayNotPaired    = errors.New("display belum dipasangkan")
+)
+
+// DisplayProfile - konfigurasi display yang sedang terhubung.
+// UnitIDs & ServiceIDs kosong artinya menampilkan semua.
+type DisplayProfile struct {
+	ID         int64
+	DeviceID   string
+	Nama       string
+	Theme      string
+	PlaysAudio bool
+	UnitIDs    map[int64]bool
+	ServiceIDs map[int64]bool
+}
+
+// Allows cek apakah antrian unit/layanan ini ditampilkan di display.
+// Unit yang di-assign mencakup semua layanannya; layanan bisa di-assign satuan.
+func (p *DisplayProfile) Allows(unitID, serviceID int64) bool {
+	if p == nil || (len(p.UnitIDs) == 0 && len(p.ServiceIDs) == 0) {
+		return true
+	}
+	return p.UnitIDs[unitID] || p.ServiceIDs[serviceID]
+}
+
+func (p *DisplayProfile) config() map[string]interface{} {
+	unitIDs := make([]int64, 0, len(p.UnitIDs))
+	for id := range p.UnitIDs {
+		unitIDs = append(unitIDs, id)
+	}
+	serviceIDs := make([]int64, 0, len(p.ServiceIDs))
+	for id := range p.ServiceIDs {
+		serviceIDs = append(serviceIDs, id)
+	}
+
+	return map[string]interface{}{
+		"id":          p.ID,
+		"device_id":   p.DeviceID,
+		"nama":        p.Nama,
+		"theme":       p.Theme,
+		"plays_audio": p.PlaysAudio,
+		"unit_ids":    unitIDs,
+		"service_ids": serviceIDs,
+	}
+}
+
+// displayAnonymousWarn - peringatan deprecation cukup sekali per proses
+var displayAnonymousWarn sync.Once
+
+// displayAnonymousAllowed - izinkan display lama tanpa pairing (tampil semua unit,
+// audio lewat flag should_play_audio di queue_update seperti sebelumnya).
+// Default true supaya display yang belum di-pairing tidak mati setelah upgrade;
+// mode ini deprecated dan default-nya akan jadi false.
+func displayAnonymousAllowed() bool {
+	if config.GetEnv("DISPLAY_ALLOW_ANONYMOUS", "true") != "true" {
+		return false
+	}
+	displayAnonymousWarn.Do(func() {
+		displayLog.Warn("display tanpa pairing diterima (DISPLAY_ALLOW_ANONYMOUS=true, deprecated); pairing semua display lalu set DISPLAY_ALLOW_ANONYMOUS=false")
+	})
+	return true
+}
+
+// authenticateDisplay validasi device_id + token dari query string WebSocket.
+func (h *Handler) authenticateDisplay(ctx context.Context, deviceID, token string) (*DisplayProfile, error) {
+	if deviceID == "" || token == "" {
+		return nil, ErrDisplayUnauthorized
+	}
+
+	cred, err := h.repos.Displays.Token(ctx, deviceID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrDisplayUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if cred.TokenHash == "" {
+		return nil, ErrDisplayNotPaired
+	}
+	if subtle.ConstantTimeCompare([]byte(cred.TokenHash), []byte(hashDeviceToken(token))) != 1 {
+		return nil, ErrDisplayUnauthorized
+	}
+	if cred.IsActive != "y" {
+		return nil, ErrDisplayInactive
+	}
+
+	return h.loadDisplayProfile(ctx, cred.ID)
+}
+
+// loadDisplayProfile baca profil display terbaru dari repository.
+// Mengembalikan repository.ErrNotFound jika display sudah dihapus.
+func (h *Handler) loadDisplayProfile(ctx context.Context, displayID int64) (*DisplayProfile, error) {
+	d, err := h.repos.Displays.Get(ctx, displayID)
+	if err != nil {
+		return nil, err
+	}
+	if d.DeviceID == nil {
+		return nil, ErrDisplayNotPaired
+	}
+	if d.IsActive != "y" {
+		return nil, ErrDisplayInactive
+	}
+
+	p := &DisplayProfile{
+		ID:         d.ID,
+		DeviceID:   *d.DeviceID,
+		Nama:       d.Nama,
+		Theme:      d.Theme,
+		PlaysAudio: d.PlaysAudio == "y",
+		UnitIDs:    make(map[int64]bool, len(d.UnitIDs)),
+		ServiceIDs: make(map[int64]bool, len(d.ServiceIDs)),
+	}
+	for _, id := range d.UnitIDs {
+		p.UnitIDs[id] = true
+	}
+	for _, id := range d.ServiceIDs {
+		p.ServiceIDs[id] = true
+	}
+
+	return p, nil
+}
+
+// sendDisplayConfig kirim konfigurasi display (tema, scope, audio) ke client.
+func sendDisplayConfig(client *ClientInfo) {
+	profile := client.display.Load()
+	if profile == nil {
+		return
+	}
+
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type": "display_config",
+		"data": profile.config(),
+	})
+	writeToClient(client, payload)
+}
+
+// displayClients snapshot client yang terhubung sebagai display tertentu.
+func displayClients(displayID int64) []*ClientInfo {
+	queueMutex.RLock()
+	defer queueMutex.RUnlock()
+
+	var clients []*ClientInfo
+	for _, client := range queueClients {
+		if p := client.display.Load(); p != nil && p.ID == displayID {
+			clients = append(clients, client)
+		}
+	}
+	return clients
+}
+
+// refreshDisplayClients minta semua replica memuat ulang profil display.
+func refreshDisplayClients(displayID int64) {
+	publishDisplayEvent(displayEvent{Type: "refresh", DisplayID: displayID})
+}
+
+// refreshLocalDisplayClients muat ulang profil display yang terhubung ke replica ini
+// setelah diubah admin, lalu kirim config & data antrian sesuai scope baru.
+func (h *Handler) refreshLocalDisplayClients(displayID int64) {
+	clients := displayClients(displayID)
+	if len(clients) == 0 {
+		return
+	}
+
+	profile, err := h.loadDisplayProfile(context.Background(), displayID)
+	if err != nil {
+		reason := "revoked"
+		if errors.Is(err, ErrDisplayInactive) {
+			reason = "inactive"
+		}
+		disconnectLocalDisplayClients(displayID, reason)
+		return
+	}
+
+	for _, client := range clients {
+		client.display.Store(profile)
+		sendDisplayConfig(client)
+		h.sendToClient(client)
+	}
+}
+
+// disconnectDisplayClients putus koneksi display di semua replica
+// (display dihapus/dinonaktifkan/di-pair ulang).
+func disconnectDisplayClients(displayID int64, reason string) {
+	publishDisplayEvent(displayEvent{Type: "disconnect", DisplayID: displayID, Reason: reason})
+}
+
+func disconnectLocalDisplayClients(displayID int64, reason string) {
+	for _, client := range displayClients(displayID) {
+		payload, _ := json.Marshal(map[string]interface{}{
+			"type":   "display_revoked",
+			"reason": reason,
+		})
+		writeToClient(client, payload)
+		closeClient(client, websocket.ClosePolicyViolation, reason)
+		queueLog.Info("client disconnected", "client", client.id, "display_id", displayID, "reason", reason)
+	}
+}
+
+// closeClient kirim close frame lalu tutup koneksi. Read loop akan
+// keluar dan unregisterClient membersihkan sisanya.
+func closeClient(c *ClientInfo, code int, reason string) {
+	c.writeMux.Lock()
+	defer c.writeMux.Unlock()
+
+	if c.closed {
+		return
+	}
+
+	c.conn.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(code, reason),
+		time.Now().Add(time.Second),
+	)
+	c.closed = true
+	close(c.closeChan)
+	c.conn.Close()
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"backend-antrian/internal/repository/memory"
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestDisplayPairing(t *testing.T) {
+	h := New(memory.New())
+	ctx := context.Background()
+	app := newFiber()
+	app.Post("/displays", h.CreateDisplay)
+	app.Put("/displays/:id", h.UpdateDisplay)
+	app.Post("/displays/:id/pairing-code", h.RegeneratePairingCode)
+	app.Post("/pair", h.PairDisplay)
+
+	unitID, _ := h.repos.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Dukcapil", IsActive: "y", MainDisplay: "active"})
+
+	if status, _ := do(t, app, "POST", "/displays", `{"nama":"TV","unit_ids":[99]}`); status != fiber.StatusBadRequest {
+		t.Fatalf("unit asing = %d, want 400", status)
+	}
+	status, body := do(t, app, "POST", "/displays", fmt.Sprintf(`{"nama":" TV Lobi ","unit_ids":[%d,%d]}`, unitID, unitID))
+	display := body["data"].(map[string]any)
+	if status != fiber.StatusCreated || display["nama"] != "TV Lobi" || display["theme"] != "default" || len(display["unit_ids"].([]any)) != 1 {
+		t.Fatalf("create = %d %v", status, body)
+	}
+	code := display["pairing_code"].(string)
+
+	if status, _ := do(t, app, "POST", "/pair", `{"pairing_code":"SALAH1","device_id":"tv-1"}`); status != fiber.StatusNotFound {
+		t.Fatalf("kode salah = %d, want 404", status)
+	}
+	status, body = do(t, app, "POST", "/pair", fmt.Sprintf(`{"pairing_code":%q,"device_id":"tv-1"}`, code))
+	if status != fiber.StatusOK {
+		t.Fatalf("pair = %d %v", status, body)
+	}
+	token := body["data"].(map[string]any)["token"].(string)
+
+	profile, err := h.authenticateDisplay(ctx, "tv-1", token)
+	if err != nil || !profile.UnitIDs[unitID] || profile.PlaysAudio {
+		t.Fatalf("authenticateDisplay = %+v %v", profile, err)
+	}
+	if _, err := h.authenticateDisplay(ctx, "tv-1", "salah"); !errors.Is(err, ErrDisplayUnauthorized) {
+		t.Fatalf("token salah = %v", err)
+	}
+
+	// device_id yang sama tidak boleh dipakai display lain
+	_, body = do(t, app, "POST", "/displays", `{"nama":"TV Lain"}`)
+	other := body["data"].(map[string]any)["pairing_code"].(string)
+	if status, _ := do(t, app, "POST", "/pair", fmt.Sprintf(`{"pairing_code":%q,"device_id":"tv-1"}`, other)); status != fiber.StatusConflict {
+		t.Fatalf("device_id dipakai = %d, want 409", status)
+	}
+
+	// Ubah service saja: unit scope tetap
+	status, body = do(t, app, "PUT", "/displays/1", `{"plays_audio":"y","service_ids":[]}`)
+	display = body["data"].(map[string]any)
+	if status != fiber.StatusOK || display["plays_audio"] != "y" || len(display["unit_ids"].([]any)) != 1 {
+		t.Fatalf("update = %d %v", status, body)
+	}
+
+	// Pairing ulang mencabut device & token lama
+	status, body = do(t, app, "POST", "/displays/1/pairing-code", "")
+	if status != fiber.StatusOK || body["data"].(map[string]any)["device_id"] != nil {
+		t.Fatalf("regenerate = %d %v", status, body)
+	}
+	if _, err := h.authenticateDisplay(ctx, "tv-1", token); !errors.Is(err, ErrDisplayUnauthorized) {
+		t.Fatalf("token setelah pairing ulang = %v", err)
+	}
+
+	// Kode kedaluwarsa
+	clock.Set(clock.Fixed(clock.Now().Add(pairingCodeTTL + time.Minute)))
+	t.Cleanup(clock.Reset)
+	if status, _ := do(t, app, "POST", "/pair", fmt.Sprintf(`{"pairing_code":%q,"device_id":"tv-2"}`, other)); status != fiber.StatusGone {
+		t.Fatalf("kode kedaluwarsa = %d, want 410", status)
+	}
+}
+
+func TestDisplayCommands(t *testing.T) {
+	start := time.Date(2026, 2, 3, 9, 0, 0, 0, clock.Location())
+	clock.Set(clock.Fixed(start))
+	t.Cleanup(clock.Reset)
+
+	h := New(memory.New())
+	ctx := context.Background()
+	app := newFiber()
+	app.Get("/displays/health", h.GetDisplayHealth)
+	app.Get("/displays/:id/commands", h.GetDisplayCommands)
+	app.Post("/displays/:id/commands", h.SendDisplayCommand)
+
+	id, _ := h.repos.Displays.Create(ctx, models.Display{Nama: "TV", Theme: "default", PlaysAudio: "n", IsActive: "y"})
+	h.repos.Displays.Create(ctx, models.Display{Nama: "TV Baru", Theme: "default", PlaysAudio: "n", IsActive: "y"})
+	path := fmt.Sprintf("/displays/%d/commands", id)
+
+	if status, _ := do(t, app, "POST", path, `{"command":"reload"}`); status != fiber.StatusConflict {
+		t.Fatalf("display offline = %d, want 409", status)
+	}
+	if status, _ := do(t, app, "POST", path, `{"command":"set_volume"}`); status != fiber.StatusUnprocessableEntity {
+		t.Fatalf("set_volume tanpa volume = %d, want 422", status)
+	}
+	if status, _ := do(t, app, "POST", path, `{"command":"show_message","message":"  "}`); status != fiber.StatusUnprocessableEntity {
+		t.Fatalf("show_message kosong = %d, want 422", status)
+	}
+
+	h.repos.Displays.Connected(ctx, repository.DisplayConnection{DisplayID: id, ClientID: "c1", IPAddress: "10.0.0.2"})
+	status, body := do(t, app, "POST", path, `{"command":"set_volume","volume":40}`)
+	cmd := body["data"].(map[string]any)
+	if status != fiber.StatusAccepted || cmd["status"] != "sent" || cmd["params"].(map[string]any)["volume"] != float64(40) {
+		t.Fatalf("send = %d %v", status, body)
+	}
+
+	client := &ClientInfo{id: "c1"}
+	client.display.Store(&DisplayProfile{ID: id})
+	if ok, err := h.ackDisplayCommand(client, int64(cmd["id"].(float64)), true, ""); !ok || err != nil {
+		t.Fatalf("ack = %v %v", ok, err)
+	}
+	do(t, app, "POST", path, `{"command":"reload"}`)
+
+	// Heartbeat terakhir > displayDeadAfter: dead; perintah tanpa ack expired
+	clock.Set(clock.Fixed(start.Add(2 * time.Minute)))
+	_, body = do(t, app, "GET", path, "")
+	commands := body["data"].([]any)
+	if len(commands) != 2 || commands[0].(map[string]any)["status"] != "expired" || commands[1].(map[string]any)["status"] != "acked" {
+		t.Fatalf("commands = %v", commands)
+	}
+
+	_, body = do(t, app, "GET", "/displays/health", "")
+	summary := body["summary"].(map[string]any)
+	health := body["data"].([]any)[0].(map[string]any)
+	if summary["dead"] != float64(1) || summary["never"] != float64(1) || health["seconds_offline"] != float64(120) {
+		t.Fatalf("health = %v", body)
+	}
+	if status, _ := do(t, app, "POST", path, `{"command":"reload"}`); status != fiber.StatusConflict {
+		t.Fatalf("heartbeat basi = %d, want 409", status)
+	}
+}
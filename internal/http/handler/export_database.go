@@ -1,16 +1,22 @@
 package handler
 
 import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/config"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
-	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 func ExportDatabase(c *fiber.Ctx) error {
+	// SQLite tidak butuh mysqldump: salin file database langsung
+	if config.DBDriver == "sqlite" {
+		return ExportDatabaseSQLite(c)
+	}
+
 	mode := os.Getenv("BACKUP_MODE")
 
 	switch mode {
@@ -31,7 +37,7 @@ func ExportDatabase(c *fiber.Ctx) error {
 }
 
 func ExportDatabaseWindows(c *fiber.Ctx) error {
-	fileName := fmt.Sprintf("backup-%s.sql", time.Now().Format("20060102-150405"))
+	fileName := fmt.Sprintf("backup-%s.sql", clock.Now().Format("20060102-150405"))
 	filePath := filepath.Join(os.TempDir(), fileName)
 	mysqldumpPath := `D:\laragon\bin\mysql\mysql-8.0.30-winx64\bin\mysqldump.exe`
 
@@ -75,7 +81,7 @@ func ExportDatabaseWindows(c *fiber.Ctx) error {
 }
 
 func ExportDatabaseDockerExec(c *fiber.Ctx) error {
-	fileName := fmt.Sprintf("backup-%s.sql", time.Now().Format("20060102-150405"))
+	fileName := fmt.Sprintf("backup-%s.sql", clock.Now().Format("20060102-150405"))
 	filePath := "/tmp/" + fileName
 
 	cmd := exec.Command(
@@ -113,7 +119,7 @@ func ExportDatabaseDockerExec(c *fiber.Ctx) error {
 }
 
 func ExportDatabaseDirect(c *fiber.Ctx) error {
-	fileName := fmt.Sprintf("backup-%s.sql", time.Now().Format("20060102-150405"))
+	fileName := fmt.Sprintf("backup-%s.sql", clock.Now().Format("20060102-150405"))
 	filePath := "/tmp/" + fileName
 
 	cmd := exec.Command(
@@ -150,4 +156,20 @@ func ExportDatabaseDirect(c *fiber.Ctx) error {
 	c.Set("Content-Type", "application/sql")
 	
 	return c.SendFile(filePath)
-}
\ No newline at end of file
+}
+
+// ExportDatabaseSQLite - snapshot konsisten file SQLite lewat VACUUM INTO
+func ExportDatabaseSQLite(c *fiber.Ctx) error {
+	fileName := fmt.Sprintf("backup-%s.db", clock.Now().Format("20060102-150405"))
+	filePath := filepath.Join(os.TempDir(), fileName)
+	defer os.Remove(filePath)
+
+	if _, err := config.DB.Exec("VACUUM INTO ?", filePath); err != nil {
+		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	c.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, fileName))
+	c.Set("Content-Type", "application/vnd.sqlite3")
+
+	return c.SendFile(filePath)
+}
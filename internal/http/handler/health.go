@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"backend-antrian/internal/buildinfo"
+	"backend-antrian/internal/config"
+	"backend-antrian/internal/migrate"
+	"backend-antrian/internal/realtime"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+|--------------------------------------------------------------------------
+| Health & readiness
+|--------------------------------------------------------------------------
+|
+| GET /healthz - liveness: proses hidup dan bisa melayani HTTP. Tidak
+|                menyentuh dependency supaya DB down tidak memicu restart.
+| GET /readyz  - readiness: DB, Redis, storage, versi skema, dan goroutine
+|                broadcaster. 503 jika ada komponen gagal; load balancer
+|                berhenti mengirim trafik sampai pulih.
+*/
+
+const (
+	readyCheckTimeout = 2 * time.Second
+	// heartbeat lebih tua dari ini dianggap goroutine macet / mati
+	heartbeatMaxAge = 3 * realtime.HeartbeatInterval
+	// TempDir folder file sementara export laporan
+	TempDir = "./temp"
+)
+
+// readyCheck satu komponen readiness; errCheckSkipped = tidak dikonfigurasi
+type readyCheck struct {
+	name string
+	fn   func(ctx context.Context) (detail string, err error)
+}
+
+// errCheckSkipped komponen tidak dipakai di deployment ini (mis. Redis)
+var errCheckSkipped = errors.New("tidak dikonfigurasi")
+
+// CheckResult hasil pemeriksaan satu komponen
+type CheckResult struct {
+	Status     string  `json:"status"` // ok, fail, skipped
+	Detail     string  `json:"detail,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+}
+
+var readyChecks = []readyCheck{
+	{"database", checkDatabase},
+	{"redis", checkRedis},
+	{"migrations", checkMigrations},
+	{"storage_audio", checkWritable(AudioBasePath)},
+	{"storage_temp", checkWritable(TempDir)},
+	{"realtime_bus", checkRealtimeBus},
+	{"units_broadcaster", checkHeartbeat(&realtime.Units.Heartbeat)},
+	{"queue_worker", checkHeartbeat(&queueWorkerHeartbeat)},
+}
+
+// Healthz - GET /healthz
+func Healthz(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"status":  "ok",
+		"version": buildinfo.Version(),
+	})
+}
+
+// Readyz - GET /readyz, semua pemeriksaan jalan paralel dengan timeout
+func Readyz(c *fiber.Ctx) error {
+	results := runReadyChecks(c.UserContext())
+
+	status, code := "ready", fiber.StatusOK
+	for _, r := range results {
+		if r.Status == "fail" {
+			status, code = "not_ready", fiber.StatusServiceUnavailable
+			break
+		}
+	}
+	if code != fiber.StatusOK {
+		failed := map[string]string{}
+		for name, r := range results {
+			if r.Status == "fail" {
+				failed[name] = r.Error
+			}
+		}
+		healthLog.WarnContext(c.UserContext(), "readiness gagal", "checks", failed)
+	}
+
+	return c.Status(code).JSON(fiber.Map{
+		"status":  status,
+		"version": buildinfo.Version(),
+		"go":      buildinfo.GoVersion(),
+		"checks":  results,
+	})
+}
+
+func runReadyChecks(parent context.Context) map[string]CheckResult {
+	ctx, cancel := context.WithTimeout(parent, readyCheckTimeout)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]CheckResult, len(readyChecks))
+	)
+	for _, check := range readyChecks {
+		wg.Add(1)
+		go func(check readyCheck) {
+			defer wg.Done()
+			start := time.Now()
+			detail, err := check.fn(ctx)
+
+			r := CheckResult{
+				Status:     "ok",
+				Detail:     detail,
+				DurationMS: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			switch {
+			case errors.Is(err, errCheckSkipped):
+				r.Status = "skipped"
+			case err != nil:
+				r.Status = "fail"
+				r.Error = err.Error()
+			}
+
+			mu.Lock()
+			results[check.name] = r
+			mu.Unlock()
+		}(check)
+	}
+	wg.Wait()
+	return results
+}
+
+func checkDatabase(ctx context.Context) (string, error) {
+	if config.DB == nil {
+		return "", fmt.Errorf("belum diinisialisasi")
+	}
+	if err := config.DB.PingContext(ctx); err != nil {
+		return "", err
+	}
+	return config.DBDriver, nil
+}
+
+func checkRedis(ctx context.Context) (string, error) {
+	if config.Redis == nil {
+		return "", errCheckSkipped
+	}
+	return "", config.Redis.Ping(ctx).Err()
+}
+
+// readyMigrator dimuat sekali — file migrasi di-embed, tidak berubah selama proses
+var readyMigrator = sync.OnceValues(func() (*migrate.Migrator, error) {
+	return migrate.New(config.DB, config.DBDriver)
+})
+
+func checkMigrations(ctx context.Context) (string, error) {
+	m, err := readyMigrator()
+	if err != nil {
+		return "", err
+	}
+	version, _, err := m.Version(ctx)
+	if err != nil {
+		return "", err
+	}
+	detail := fmt.Sprintf("versi %d, terbaru %d", version, m.Latest())
+	return detail, m.Check(ctx)
+}
+
+// checkWritable buat lalu hapus file kecil di dir (dibuat jika belum ada)
+func checkWritable(dir string) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return dir, err
+		}
+		f, err := os.CreateTemp(dir, ".readyz-*")
+		if err != nil {
+			return dir, err
+		}
+		name := f.Name()
+		f.Close()
+		return dir, os.Remove(name)
+	}
+}
+
+func checkRealtimeBus(ctx context.Context) (string, error) {
+	return fmt.Sprintf("%T", realtime.Bus), realtime.Bus.Alive(ctx)
+}
+
+func checkHeartbeat(h *realtime.Heartbeat) func(context.Context) (string, error) {
+	return func(context.Context) (string, error) {
+		return "", h.Alive(heartbeatMaxAge)
+	}
+}
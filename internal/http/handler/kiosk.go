@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/config"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetAllKiosks - Daftar semua kiosk terdaftar (super_user only)
+func (h *Handler) GetAllKiosks(c *fiber.Ctx) error {
+	kiosks, err := h.repos.Kiosks.List(c.UserContext())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil data kiosk",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    kiosks,
+	})
+}
+
+// GetKioskByID - Detail satu kiosk (super_user only)
+func (h *Handler) GetKioskByID(c *fiber.Ctx) error {
+	kiosk, err := h.repos.Kiosks.Get(c.UserContext(), paramID(c, "id"))
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Kiosk tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil data kiosk",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    kiosk,
+	})
+}
+
+// CreateKiosk - Daftarkan kiosk baru (super_user only).
+// Secret hanya ditampilkan sekali; server hanya menyimpan hash-nya.
+func (h *Handler) CreateKiosk(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	var req models.CreateKioskRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	req.Nama = strings.TrimSpace(req.Nama)
+
+	if req.IsActive == "" {
+		req.IsActive = "y"
+	}
+	if req.IsActive != "y" && req.IsActive != "n" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "is_active harus 'y' atau 'n'",
+		})
+	}
+
+	if msg := h.validateKioskUnits(ctx, req.UnitIDs); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	publicKey, fingerprint, msg := normalizeKioskPublicKey(req.PublicKey)
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	deviceID, err := generateKioskDeviceID()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal membuat device_id kiosk",
+		})
+	}
+	secret, err := generateDeviceToken()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal membuat secret kiosk",
+		})
+	}
+
+	id, err := h.repos.Kiosks.Create(ctx, repository.NewKiosk{
+		Nama:       req.Nama,
+		DeviceID:   deviceID,
+		SecretHash: hashDeviceToken(secret),
+		Key:        repository.KioskKey{PublicKey: publicKey, Fingerprint: fingerprint},
+		IsActive:   req.IsActive,
+		UnitIDs:    req.UnitIDs,
+		ValidAfter: clock.Now(),
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal membuat kiosk",
+		})
+	}
+
+	kiosk, _ := h.repos.Kiosks.Get(ctx, id)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Kiosk berhasil dibuat, simpan secret di perangkat",
+		"data": fiber.Map{
+			"kiosk":  kiosk,
+			"secret": secret,
+		},
+	})
+}
+
+// UpdateKiosk - Update nama, status, public key, atau unit kiosk (super_user only).
+// Unit dicek ulang setiap TakeQueue, jadi perubahan berlaku langsung.
+func (h *Handler) UpdateKiosk(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	var req models.UpdateKioskRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	kiosk, err := h.repos.Kiosks.Get(ctx, paramID(c, "id"))
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Kiosk tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil data kiosk",
+		})
+	}
+
+	patch := repository.KioskPatch{
+		Nama:     strings.TrimSpace(req.Nama),
+		IsActive: req.IsActive,
+		UnitIDs:  req.UnitIDs,
+	}
+
+	if patch.IsActive != "" && patch.IsActive != "y" && patch.IsActive != "n" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "is_active harus 'y' atau 'n'",
+		})
+	}
+
+	// public_key: "" = hapus, PEM = ganti
+	if req.PublicKey != nil {
+		publicKey, fingerprint, msg := normalizeKioskPublicKey(*req.PublicKey)
+		if msg != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": msg,
+			})
+		}
+		patch.Key = &repository.KioskKey{PublicKey: publicKey, Fingerprint: fingerprint}
+	}
+
+	if req.UnitIDs != nil {
+		if msg := h.validateKioskUnits(ctx, *req.UnitIDs); msg != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": msg,
+			})
+		}
+	}
+
+	if patch.Nama == "" && patch.IsActive == "" && patch.Key == nil && patch.UnitIDs == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Tidak ada data yang diupdate",
+		})
+	}
+
+	if err := h.repos.Kiosks.Update(ctx, kiosk.ID, patch); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengupdate kiosk",
+		})
+	}
+
+	kiosk, _ = h.repos.Kiosks.Get(ctx, kiosk.ID)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Kiosk berhasil diupdate",
+		"data":    kiosk,
+	})
+}
+
+// RotateKioskSecret - Buat secret baru dan cabut semua token kiosk yang sudah terbit (super_user only)
+func (h *Handler) RotateKioskSecret(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	kiosk, err := h.repos.Kiosks.Get(ctx, paramID(c, "id"))
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Kiosk tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil data kiosk",
+		})
+	}
+
+	secret, err := generateDeviceToken()
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal membuat secret kiosk",
+		})
+	}
+
+	if err := h.repos.Kiosks.RotateSecret(ctx, kiosk.ID, hashDeviceToken(secret), clock.Now()); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal menyimpan secret kiosk",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Secret kiosk berhasil diganti, token lama tidak berlaku lagi",
+		"data": fiber.Map{
+			"device_id": kiosk.DeviceID,
+			"secret":    secret,
+		},
+	})
+}
+
+// DeleteKiosk - Hapus kiosk permanent (super_user only)
+func (h *Handler) DeleteKiosk(c *fiber.Ctx) error {
+	err := h.repos.Kiosks.Delete(c.UserContext(), paramID(c, "id"))
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Kiosk tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal menghapus kiosk",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Kiosk berhasil dihapus",
+	})
+}
+
+/*
+|--------------------------------------------------------------------------
+| Helper
+|--------------------------------------------------------------------------
+*/
+
+// validateKioskUnits kiosk wajib terikat minimal satu unit yang ada di database
+func (h *Handler) validateKioskUnits(ctx context.Context, unitIDs []int64) string {
+	ids := uniqueIDs(unitIDs)
+	if len(ids) == 0 {
+		return "Kiosk wajib memiliki minimal satu unit"
+	}
+	for _, id := range ids {
+		if _, err := h.repos.Units.Get(ctx, id); err != nil {
+			return fmt.Sprintf("Unit ID %d tidak ditemukan", id)
+		}
+	}
+	return ""
+}
+
+func generateKioskDeviceID() (string, error) {
+	suffix, err := config.RandomHex(5)
+	if err != nil {
+		return "", err
+	}
+	return "kiosk-" + suffix, nil
+}
@@ -0,0 +1,362 @@
+package handler
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/config"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/permission"
+	"backend-antrian/internal/repository"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+|--------------------------------------------------------------------------
+| Kiosk Authentication
+|--------------------------------------------------------------------------
+| Kiosk login dengan device_id + secret, atau dengan tanda tangan atas
+| "<device_id>\n<timestamp>" memakai private key yang public key /
+| sertifikatnya didaftarkan super user. Token kiosk ber-role "kiosk" dan
+| hanya boleh dipakai untuk TakeQueue + endpoint baca.
+*/
+
+// Selisih jam perangkat yang masih diterima untuk tanda tangan
+const kioskAssertionSkew = 5 * time.Minute
+
+var errKioskCredential = errors.New("kredensial kiosk tidak valid")
+
+// AuthenticateKiosk - Tukar kredensial perangkat kiosk dengan access token (public)
+func (h *Handler) AuthenticateKiosk(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+
+	var req models.KioskAuthRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	req.DeviceID = strings.TrimSpace(req.DeviceID)
+
+	guardKeys := loginKeys(c, "kiosk", req.DeviceID)
+	if blocked, err := loginBlocked(c, guardKeys); blocked {
+		return err
+	}
+
+	kiosk, err := h.repos.Kiosks.Credentials(ctx, req.DeviceID)
+	if errors.Is(err, repository.ErrNotFound) {
+		recordLoginFailure(c, "kiosk", nil, req.DeviceID, "unknown_device", guardKeys)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Kredensial kiosk tidak valid",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	if req.Signature != "" {
+		err = h.verifyKioskAssertion(ctx, kiosk.ID, kiosk.PublicKey, req)
+	} else if subtle.ConstantTimeCompare([]byte(hashDeviceToken(req.Secret)), []byte(kiosk.SecretHash)) != 1 {
+		err = errKioskCredential
+	}
+	if err != nil {
+		authLog.InfoContext(ctx, "kiosk ditolak", "device_id", req.DeviceID, "err", err)
+		recordLoginFailure(c, "kiosk", &kiosk.ID, req.DeviceID, err.Error(), guardKeys)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Kredensial kiosk tidak valid",
+		})
+	}
+
+	recordLoginSuccess(c, "kiosk", req.DeviceID)
+
+	if kiosk.IsActive != "y" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Kiosk tidak aktif",
+		})
+	}
+
+	return h.kioskTokenResponse(c, kiosk.Kiosk)
+}
+
+// kioskTokenResponse terbitkan access token kiosk dan catat waktu / IP login
+func (h *Handler) kioskTokenResponse(c *fiber.Ctx, kiosk models.Kiosk) error {
+	ctx := c.UserContext()
+
+	token, _, err := config.GenerateKioskToken(kiosk.ID, kiosk.Nama)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate token",
+		})
+	}
+
+	if err := h.repos.Kiosks.RecordAuth(ctx, kiosk.ID, c.IP()); err != nil {
+		authLog.ErrorContext(ctx, "kiosk record auth error", "kiosk_id", kiosk.ID, "err", err)
+	}
+	kiosk, _ = h.repos.Kiosks.Get(ctx, kiosk.ID)
+
+	return c.JSON(fiber.Map{
+		"token":      token,
+		"token_type": "Bearer",
+		"expires_in": int(config.AccessTokenTTL().Seconds()),
+		"kiosk":      kiosk,
+		"message":    "Login kiosk berhasil",
+	})
+}
+
+// kioskLegacyWarn - peringatan deprecation cukup sekali per proses
+var kioskLegacyWarn sync.Once
+
+// LoginKiosk - Endpoint lama /san/login-kiosk (deprecated, public).
+// Kiosk lama login dengan email + password akun pengelola kiosk
+// (permission kiosk.manage); hasilnya token ber-role kiosk untuk satu
+// perangkat yang ditetapkan operator lewat KIOSK_LEGACY_DEVICE_ID, bukan
+// sesi user. Tanpa env itu endpoint mati. Pindahkan kiosk ke /san/kiosk/auth.
+func (h *Handler) LoginKiosk(c *fiber.Ctx) error {
+	c.Set("Deprecation", "true")
+	c.Set(fiber.HeaderLink, `</san/kiosk/auth>; rel="successor-version"`)
+	kioskLegacyWarn.Do(func() {
+		authLog.Warn("/san/login-kiosk dipakai (deprecated); daftarkan kiosk di /api/kiosks lalu login lewat /san/kiosk/auth")
+	})
+
+	var req models.LoginRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	deviceID := strings.TrimSpace(config.GetEnv("KIOSK_LEGACY_DEVICE_ID", ""))
+	if deviceID == "" {
+		return c.Status(fiber.StatusGone).JSON(fiber.Map{
+			"error": "Login kiosk lama tidak aktif, gunakan /san/kiosk/auth",
+		})
+	}
+
+	guardKeys := loginKeys(c, "user", req.Email)
+	if blocked, err := loginBlocked(c, guardKeys); blocked {
+		return err
+	}
+
+	creds, err := h.repos.Users.GetByEmail(c.UserContext(), req.Email)
+	if errors.Is(err, repository.ErrNotFound) {
+		recordLoginFailure(c, "user", nil, req.Email, "unknown_email", guardKeys)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Email atau password salah",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	user := creds.User
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		recordLoginFailure(c, "user", &user.ID, req.Email, "wrong_password", guardKeys)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Email atau password salah",
+		})
+	}
+	recordLoginSuccess(c, "user", req.Email)
+
+	// Status blokir & hak akses baru dibuka setelah password terbukti benar
+	if user.IsBanned == "y" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Akun Anda telah diblokir",
+		})
+	}
+	if !permission.Has(user.Role, permission.KioskManage) {
+		authLog.WarnContext(c.UserContext(), "login kiosk lama ditolak", "user_id", user.ID, "role", user.Role)
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Akun tidak berhak mengelola kiosk",
+		})
+	}
+
+	kiosk, err := h.repos.Kiosks.Credentials(c.UserContext(), deviceID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Kiosk tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if kiosk.IsActive != "y" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Kiosk tidak aktif",
+		})
+	}
+
+	authLog.InfoContext(c.UserContext(), "login kiosk lama", "user_id", user.ID, "device_id", deviceID)
+	return h.kioskTokenResponse(c, kiosk.Kiosk)
+}
+
+// GetKioskMe - Info kiosk yang sedang login beserta unit yang boleh dilayani (kiosk only)
+func (h *Handler) GetKioskMe(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	kioskID, _ := c.Locals("kiosk_id").(int64)
+
+	kiosk, err := h.repos.Kiosks.Get(ctx, kioskID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Kiosk tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil data kiosk",
+		})
+	}
+
+	kioskUnits, err := h.repos.Kiosks.Units(ctx, kioskID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil unit kiosk",
+		})
+	}
+
+	units := []fiber.Map{}
+	for _, u := range kioskUnits {
+		units = append(units, fiber.Map{
+			"id":        u.ID,
+			"nama_unit": u.NamaUnit,
+			"is_active": u.IsActive,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"kiosk": kiosk,
+			"units": units,
+		},
+	})
+}
+
+// verifyKioskAssertion cek tanda tangan perangkat dan tolak replay:
+// timestamp harus dalam batas skew dan lebih baru dari assertion sebelumnya.
+func (h *Handler) verifyKioskAssertion(ctx context.Context, kioskID int64, publicKeyPEM string, req models.KioskAuthRequest) error {
+	if publicKeyPEM == "" {
+		return errors.New("kiosk belum punya public key")
+	}
+
+	now := clock.Now()
+	signedAt := time.Unix(req.Timestamp, 0)
+	if signedAt.Before(now.Add(-kioskAssertionSkew)) || signedAt.After(now.Add(kioskAssertionSkew)) {
+		return errors.New("timestamp di luar batas")
+	}
+
+	pub, cert, err := parseKioskPublicKey(publicKeyPEM)
+	if err != nil {
+		return err
+	}
+	if cert != nil && (now.Before(cert.NotBefore) || now.After(cert.NotAfter)) {
+		return errors.New("sertifikat kiosk tidak berlaku")
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(req.Signature)
+	if err != nil {
+		if signature, err = base64.RawURLEncoding.DecodeString(req.Signature); err != nil {
+			return errors.New("signature bukan base64")
+		}
+	}
+
+	message := []byte(fmt.Sprintf("%s\n%d", req.DeviceID, req.Timestamp))
+	if !verifyKioskSignature(pub, message, signature) {
+		return errKioskCredential
+	}
+
+	fresh, err := h.repos.Kiosks.UseAssertion(ctx, kioskID, req.Timestamp)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return errors.New("assertion sudah dipakai (replay)")
+	}
+	return nil
+}
+
+func verifyKioskSignature(pub crypto.PublicKey, message, signature []byte) bool {
+	digest := sha256.Sum256(message)
+
+	switch key := pub.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, message, signature)
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(key, digest[:], signature)
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	}
+	return false
+}
+
+// parseKioskPublicKey terima PEM "PUBLIC KEY" atau "CERTIFICATE" (Ed25519, ECDSA, RSA)
+func parseKioskPublicKey(pemStr string) (crypto.PublicKey, *x509.Certificate, error) {
+	block, _ := pem.Decode([]byte(pemStr))
+	if block == nil {
+		return nil, nil, errors.New("public key harus berformat PEM")
+	}
+
+	switch block.Type {
+	case "CERTIFICATE":
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("sertifikat tidak valid: %w", err)
+		}
+		return cert.PublicKey, cert, nil
+	case "PUBLIC KEY":
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("public key tidak valid: %w", err)
+		}
+		return pub, nil, nil
+	}
+	return nil, nil, fmt.Errorf("tipe PEM %q tidak didukung", block.Type)
+}
+
+// normalizeKioskPublicKey validasi PEM dari admin dan hitung fingerprint SHA-256 (SPKI).
+// String kosong berarti kiosk tanpa public key.
+func normalizeKioskPublicKey(pemStr string) (sql.NullString, sql.NullString, string) {
+	pemStr = strings.TrimSpace(pemStr)
+	if pemStr == "" {
+		return sql.NullString{}, sql.NullString{}, ""
+	}
+
+	pub, _, err := parseKioskPublicKey(pemStr)
+	if err != nil {
+		return sql.NullString{}, sql.NullString{}, err.Error()
+	}
+
+	switch pub.(type) {
+	case ed25519.PublicKey, *ecdsa.PublicKey, *rsa.PublicKey:
+	default:
+		return sql.NullString{}, sql.NullString{}, "Tipe public key tidak didukung (gunakan Ed25519, ECDSA, atau RSA)"
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return sql.NullString{}, sql.NullString{}, "Public key tidak valid"
+	}
+	sum := sha256.Sum256(der)
+
+	return sql.NullString{String: pemStr, Valid: true},
+		sql.NullString{String: hex.EncodeToString(sum[:]), Valid: true}, ""
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository/memory"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestKioskCredentials(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test-secret")
+	h := New(memory.New())
+	ctx := context.Background()
+	app := newFiber()
+	app.Post("/kiosks", h.CreateKiosk)
+	app.Put("/kiosks/:id", h.UpdateKiosk)
+	app.Post("/kiosks/:id/secret", h.RotateKioskSecret)
+	app.Post("/kiosk/auth", h.AuthenticateKiosk)
+	app.Get("/kiosk/me", func(c *fiber.Ctx) error {
+		c.Locals("kiosk_id", int64(1))
+		return c.Next()
+	}, h.GetKioskMe)
+
+	pajak, _ := h.repos.Units.Create(ctx, models.Unit{Code: "B", NamaUnit: "Pajak", IsActive: "y", MainDisplay: "active"})
+	dukcapil, _ := h.repos.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Dukcapil", IsActive: "y", MainDisplay: "active"})
+
+	if status, _ := do(t, app, "POST", "/kiosks", `{"nama":"Kiosk","unit_ids":[99]}`); status != fiber.StatusBadRequest {
+		t.Fatalf("unit asing = %d, want 400", status)
+	}
+	status, body := do(t, app, "POST", "/kiosks", fmt.Sprintf(`{"nama":"Kiosk Lobi","unit_ids":[%d,%d]}`, pajak, dukcapil))
+	if status != fiber.StatusCreated {
+		t.Fatalf("create = %d %v", status, body)
+	}
+	data := body["data"].(map[string]any)
+	kiosk := data["kiosk"].(map[string]any)
+	deviceID, secret := kiosk["device_id"].(string), data["secret"].(string)
+
+	status, body = do(t, app, "POST", "/kiosk/auth", fmt.Sprintf(`{"device_id":%q,"secret":%q}`, deviceID, secret))
+	if status != fiber.StatusOK || body["token"] == nil || body["kiosk"].(map[string]any)["last_auth_ip"] == nil {
+		t.Fatalf("auth secret = %d %v", status, body)
+	}
+
+	_, body = do(t, app, "GET", "/kiosk/me", "")
+	units := body["data"].(map[string]any)["units"].([]any)
+	if len(units) != 2 || units[0].(map[string]any)["nama_unit"] != "Dukcapil" {
+		t.Fatalf("kiosk/me = %v", body)
+	}
+
+	// Public key baru & unit dipersempit
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	der, _ := x509.MarshalPKIXPublicKey(pub)
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+	status, body = do(t, app, "PUT", "/kiosks/1", fmt.Sprintf(`{"public_key":%q,"unit_ids":[%d]}`, pemKey, pajak))
+	kiosk = body["data"].(map[string]any)
+	if status != fiber.StatusOK || kiosk["has_public_key"] != true || len(kiosk["unit_ids"].([]any)) != 1 {
+		t.Fatalf("update = %d %v", status, body)
+	}
+	if ok, _ := h.repos.Kiosks.AllowsUnit(ctx, 1, dukcapil); ok {
+		t.Fatal("unit yang dilepas masih diizinkan")
+	}
+
+	ts := clock.Now().Unix()
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(fmt.Sprintf("%s\n%d", deviceID, ts))))
+	status, body = do(t, app, "POST", "/kiosk/auth", fmt.Sprintf(`{"device_id":%q,"timestamp":%d,"signature":%q}`, deviceID, ts, sig))
+	if status != fiber.StatusOK {
+		t.Fatalf("auth signature = %d %v", status, body)
+	}
+	creds, _ := h.repos.Kiosks.Credentials(ctx, deviceID)
+	req := models.KioskAuthRequest{DeviceID: deviceID, Timestamp: ts, Signature: sig}
+	if err := h.verifyKioskAssertion(ctx, creds.ID, creds.PublicKey, req); err == nil {
+		t.Fatal("replay assertion diterima")
+	}
+
+	// Rotasi secret
+	status, body = do(t, app, "POST", "/kiosks/1/secret", "")
+	if status != fiber.StatusOK {
+		t.Fatalf("rotate = %d %v", status, body)
+	}
+	rotated, _ := h.repos.Kiosks.Credentials(ctx, deviceID)
+	if rotated.SecretHash == creds.SecretHash || rotated.SecretHash != hashDeviceToken(body["data"].(map[string]any)["secret"].(string)) {
+		t.Fatal("secret tidak diganti")
+	}
+}
@@ -0,0 +1,19 @@
+package handler
+
+import "backend-antrian/internal/logger"
+
+// Logger per komponen. Pakai varian *Context(c.UserContext(), ...) di handler
+// HTTP supaya request_id ikut tercatat.
+var (
+	authLog         = logger.For("auth")
+	sessionLog      = logger.For("session")
+	userLog         = logger.For("user")
+	audioLog        = logger.For("audio")
+	queueLog        = logger.For("queue")
+	announcementLog = logger.For("announcement")
+	displayLog      = logger.For("display")
+	realtimeLog     = logger.For("realtime")
+	clockLog        = logger.For("clock")
+	reportLog       = logger.For("report")
+	healthLog       = logger.For("health")
+)
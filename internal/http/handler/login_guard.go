@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"backend-antrian/internal/audit"
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/loginguard"
+	"backend-antrian/internal/models"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+|--------------------------------------------------------------------------
+| Proteksi brute-force login
+|--------------------------------------------------------------------------
+| Setiap login gagal dihitung per akun (email user / device_id kiosk) dan per
+| IP. Setelah beberapa kali gagal, percobaan berikutnya ditahan dengan jeda
+| yang berlipat ganda, lalu lockout penuh. Jika store error (mis. Redis mati)
+| login tetap diizinkan supaya layanan tidak ikut lumpuh.
+*/
+
+// loginKeys key akun + IP untuk satu percobaan login
+func loginKeys(c *fiber.Ctx, kind, account string) []loginguard.Key {
+	return []loginguard.Key{
+		loginguard.Account(kind, normalizeLoginAccount(account)),
+		loginguard.IP(c.IP()),
+	}
+}
+
+func normalizeLoginAccount(account string) string {
+	return strings.ToLower(strings.TrimSpace(account))
+}
+
+// loginBlocked kirim 429 jika salah satu key sedang terkunci.
+func loginBlocked(c *fiber.Ctx, keys []loginguard.Key) (bool, error) {
+	wait, err := loginguard.Default.Check(c.UserContext(), keys...)
+	if err != nil {
+		authLog.ErrorContext(c.UserContext(), "loginguard check error", "err", err)
+		return false, nil
+	}
+	if wait <= 0 {
+		return false, nil
+	}
+
+	seconds := int(math.Ceil(wait.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(seconds))
+	return true, c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error":       fmt.Sprintf("Terlalu banyak percobaan login gagal. Coba lagi dalam %d detik", seconds),
+		"retry_after": seconds,
+	})
+}
+
+// recordLoginFailure catat gagal ke guard dan audit trail.
+// actorType "user"/"kiosk", actorID nil jika akun tidak ditemukan.
+func recordLoginFailure(c *fiber.Ctx, actorType string, actorID *int64, account, reason string, keys []loginguard.Key) {
+	results, err := loginguard.Default.Fail(c.UserContext(), keys...)
+	if err != nil {
+		authLog.ErrorContext(c.UserContext(), "loginguard fail error", "err", err)
+	}
+
+	entity := "users"
+	if actorType == "kiosk" {
+		entity = "kiosks"
+	}
+	entityID := ""
+	if actorID != nil {
+		entityID = strconv.FormatInt(*actorID, 10)
+	}
+
+	base := audit.Entry{
+		ActorType:  actorType,
+		ActorID:    actorID,
+		ActorName:  account,
+		Entity:     entity,
+		EntityID:   entityID,
+		StatusCode: fiber.StatusUnauthorized,
+		Method:     c.Method(),
+		Path:       c.OriginalURL(),
+		IP:         c.IP(),
+		UserAgent:  c.Get("User-Agent"),
+	}
+
+	failed := base
+	failed.Action = actorType + ".login_failed"
+	failed.After = fiber.Map{"reason": reason, "attempts": failureCounts(results)}
+	audit.Record(c.UserContext(), failed)
+
+	for _, res := range results {
+		if !res.Lockout {
+			continue
+		}
+		locked := base
+		locked.Action = actorType + ".lockout"
+		locked.After = fiber.Map{
+			"scope":           res.Key.Scope,
+			"key":             res.Key.ID,
+			"failures":        res.Failures,
+			"locked_until":    clock.Now().Add(res.LockFor).Format("2006-01-02 15:04:05"),
+			"lockout_seconds": int(res.LockFor.Seconds()),
+		}
+		audit.Record(c.UserContext(), locked)
+		authLog.WarnContext(c.UserContext(), "lockout", "scope", res.Key.Scope, "key", res.Key.ID, "failures", res.Failures)
+	}
+}
+
+func failureCounts(results []loginguard.Result) map[string]int {
+	counts := map[string]int{}
+	for _, res := range results {
+		counts[res.Key.Scope] = res.Failures
+	}
+	return counts
+}
+
+// recordLoginSuccess reset counter akun; counter IP dibiarkan.
+func recordLoginSuccess(c *fiber.Ctx, kind, account string) {
+	if err := loginguard.Default.Success(c.UserContext(), loginguard.Account(kind, normalizeLoginAccount(account))); err != nil {
+		authLog.ErrorContext(c.UserContext(), "loginguard reset error", "err", err)
+	}
+}
+
+// UnlockLogin - Buka lockout login untuk email user, device_id kiosk, dan/atau IP (admin)
+func UnlockLogin(c *fiber.Ctx) error {
+	var req models.UnlockLoginRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	keys := []loginguard.Key{}
+	if req.Email != "" {
+		keys = append(keys, loginguard.Account("user", normalizeLoginAccount(req.Email)))
+	}
+	if req.DeviceID != "" {
+		keys = append(keys, loginguard.Account("kiosk", normalizeLoginAccount(req.DeviceID)))
+	}
+	if ip := strings.TrimSpace(req.IP); ip != "" {
+		keys = append(keys, loginguard.IP(ip))
+	}
+	if len(keys) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Isi minimal salah satu: email, device_id, atau ip",
+		})
+	}
+
+	if err := loginguard.Default.Unlock(c.UserContext(), keys...); err != nil {
+		authLog.ErrorContext(c.UserContext(), "unlock login error", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal membuka lockout",
+		})
+	}
+
+	unlocked := []string{}
+	for _, k := range keys {
+		unlocked = append(unlocked, k.Scope+":"+k.ID)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Lockout login berhasil dibuka",
+		"data": fiber.Map{
+			"unlocked": unlocked,
+		},
+	})
+}
@@ -1,8 +1,52 @@
 package handler
 
-import "github.com/gofiber/fiber/v2"
+import (
+	"backend-antrian/internal/config"
+	"backend-antrian/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Logout - Akhiri sesi saat ini (atau semua sesi dengan {"all": true}).
+// Access token langsung masuk denylist sehingga tidak bisa dipakai lagi.
+func (h *Handler) Logout(c *fiber.Ctx) error {
+	var req models.LogoutRequest
+	_ = c.BodyParser(&req) // body opsional
+
+	claims, _ := c.Locals("claims").(*config.JWTClaims)
+	if claims == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Token tidak valid",
+		})
+	}
+
+	ctx := c.UserContext()
+	if err := h.denylistToken(ctx, claims); err != nil {
+		sessionLog.ErrorContext(c.UserContext(), "denylist error", "jti", claims.ID, "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal logout",
+		})
+	}
+
+	if req.All {
+		revoked, err := h.revokeUserSessions(ctx, claims.UserID, "logout_all")
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Gagal logout",
+			})
+		}
+		return c.JSON(fiber.Map{
+			"message":          "Logout dari semua perangkat berhasil",
+			"sessions_revoked": revoked,
+		})
+	}
+
+	if err := h.revokeSession(ctx, claims.SessionID, "logout"); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal logout",
+		})
+	}
 
-func Logout(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
 		"message": "Logout berhasil",
 	})
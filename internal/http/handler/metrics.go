@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/config"
+	"backend-antrian/internal/metrics"
+	"backend-antrian/internal/realtime"
+	"backend-antrian/internal/repository"
+	"context"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+|--------------------------------------------------------------------------
+| Metrics (Prometheus)
+|--------------------------------------------------------------------------
+|
+| GET /metrics (BasicAuth). Counter & histogram dicatat di tempat kejadian;
+| jumlah client, pool DB dan antrian menunggu dihitung saat scrape.
+| Gauge antrian berasal dari DB sehingga sama di semua replica — cukup
+| agregasi max() per unit/service, bukan sum().
+*/
+
+// metricsQueryTimeout batas query DB per scrape
+const metricsQueryTimeout = 3 * time.Second
+
+var (
+	ticketsTaken = metrics.NewCounter("antrian_tickets_taken_total",
+		"Jumlah tiket yang diambil lewat replica ini", "unit_id", "service_id")
+	snapshotBuildDuration = metrics.NewHistogram("antrian_queue_snapshot_build_seconds",
+		"Durasi query + susun snapshot antrian penuh", metrics.DefBuckets)
+)
+
+func init() {
+	metrics.Collect("antrian_ws_clients", "Client WebSocket yang terhubung ke replica ini", "gauge",
+		func(emit func(float64, ...string)) {
+			queueMutex.RLock()
+			n := len(queueClients)
+			queueMutex.RUnlock()
+			emit(float64(n), "queue")
+			emit(float64(realtime.Units.Count()), "units")
+		}, "endpoint")
+
+	metrics.Collect("go_goroutines", "Jumlah goroutine", "gauge",
+		func(emit func(float64, ...string)) { emit(float64(runtime.NumGoroutine())) })
+
+	collectDBStats()
+}
+
+// RegisterMetrics daftarkan metrik yang membaca data antrian lewat repository.
+// Dipanggil sekali dari main.
+func (h *Handler) RegisterMetrics() {
+	metrics.Collect("antrian_tickets_taken_last_minute", "Tiket diambil dalam 60 detik terakhir (semua replica)", "gauge",
+		h.collectTakenLastMinute, "unit_id", "unit", "service_id", "service")
+	metrics.Collect("antrian_queue_waiting_tickets", "Tiket menunggu hari ini per unit/layanan", "gauge",
+		h.collectWaitingTickets, "unit_id", "unit", "service_id", "service")
+}
+
+// collectDBStats statistik pool koneksi config.DB
+func collectDBStats() {
+	stat := func(name, help, typ string, fn func() float64) {
+		metrics.Collect(name, help, typ, func(emit func(float64, ...string)) {
+			if config.DB != nil {
+				emit(fn())
+			}
+		})
+	}
+	stat("db_max_open_connections", "Batas koneksi terbuka pool DB", "gauge",
+		func() float64 { return float64(config.DB.Stats().MaxOpenConnections) })
+	stat("db_open_connections", "Koneksi DB terbuka (dipakai + idle)", "gauge",
+		func() float64 { return float64(config.DB.Stats().OpenConnections) })
+	stat("db_in_use_connections", "Koneksi DB yang sedang dipakai", "gauge",
+		func() float64 { return float64(config.DB.Stats().InUse) })
+	stat("db_idle_connections", "Koneksi DB idle", "gauge",
+		func() float64 { return float64(config.DB.Stats().Idle) })
+	stat("db_wait_count_total", "Jumlah tunggu koneksi karena pool penuh", "counter",
+		func() float64 { return float64(config.DB.Stats().WaitCount) })
+	stat("db_wait_duration_seconds_total", "Total waktu menunggu koneksi pool", "counter",
+		func() float64 { return config.DB.Stats().WaitDuration.Seconds() })
+	stat("db_max_idle_closed_total", "Koneksi ditutup karena melebihi batas idle", "counter",
+		func() float64 { return float64(config.DB.Stats().MaxIdleClosed) })
+	stat("db_max_lifetime_closed_total", "Koneksi ditutup karena melebihi umur maksimal", "counter",
+		func() float64 { return float64(config.DB.Stats().MaxLifetimeClosed) })
+}
+
+// collectWaitingTickets tiket status waiting hari ini (zona masing-masing unit),
+// layanan tanpa antrian tetap muncul dengan nilai 0
+func (h *Handler) collectWaitingTickets(emit func(float64, ...string)) {
+	ctx, cancel := context.WithTimeout(context.Background(), metricsQueryTimeout)
+	defer cancel()
+
+	counts, err := h.repos.Tickets.WaitingToday(ctx, h.today(ctx), 0)
+	if err != nil {
+		queueLog.ErrorContext(ctx, "metrics query error", "err", err)
+		return
+	}
+	h.emitServiceCounts(ctx, emit, counts)
+}
+
+// collectTakenLastMinute tiket yang dibuat dalam satu menit terakhir
+func (h *Handler) collectTakenLastMinute(emit func(float64, ...string)) {
+	ctx, cancel := context.WithTimeout(context.Background(), metricsQueryTimeout)
+	defer cancel()
+
+	counts, err := h.repos.Tickets.CreatedSince(ctx, clock.Now().Add(-time.Minute))
+	if err != nil {
+		queueLog.ErrorContext(ctx, "metrics query error", "err", err)
+		return
+	}
+	h.emitServiceCounts(ctx, emit, counts)
+}
+
+// emitServiceCounts emit satu series (unit_id, unit, service_id, service) per
+// layanan; layanan yang tidak ada di counts bernilai 0. Error cukup dicatat —
+// scrape tetap jalan.
+func (h *Handler) emitServiceCounts(ctx context.Context, emit func(float64, ...string), counts map[int64]int) {
+	units, err := h.repos.Units.List(ctx, repository.UnitFilter{})
+	if err != nil {
+		queueLog.ErrorContext(ctx, "metrics query error", "err", err)
+		return
+	}
+	services, err := h.repos.Services.List(ctx, repository.ServiceFilter{OldestFirst: true})
+	if err != nil {
+		queueLog.ErrorContext(ctx, "metrics query error", "err", err)
+		return
+	}
+
+	unitCodes := make(map[int64]string, len(units))
+	for _, u := range units {
+		unitCodes[u.ID] = u.Code
+	}
+	for _, s := range services {
+		emit(float64(counts[s.ID]), strconv.FormatInt(s.UnitID, 10), unitCodes[s.UnitID], strconv.FormatInt(s.ID, 10), s.Code)
+	}
+}
+
+// observeSince catat durasi sejak start (detik) — dipakai dengan defer
+func observeSince(h *metrics.Histogram, start time.Time, labelValues ...string) {
+	h.Observe(time.Since(start).Seconds(), labelValues...)
+}
+
+// GetMetrics - GET /metrics, format teks Prometheus
+func GetMetrics(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+	_, err := metrics.Default.WriteTo(c)
+	return err
+}
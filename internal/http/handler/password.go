@@ -0,0 +1,388 @@
+package handler
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/config"
+	"backend-antrian/internal/loginguard"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/permission"
+	"backend-antrian/internal/repository"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+/*
+|--------------------------------------------------------------------------
+| Password policy
+|--------------------------------------------------------------------------
+| Dipakai CreateUser, UpdateUser, ganti password sendiri, dan reset token.
+| Env: PASSWORD_MIN_LENGTH (default 8), PASSWORD_HISTORY (default 5 password
+| terakhir tidak boleh dipakai ulang).
+*/
+
+const bcryptMaxPasswordBytes = 72
+
+func passwordMinLength() int {
+	return config.GetEnvInt("PASSWORD_MIN_LENGTH", 8)
+}
+
+func passwordHistorySize() int {
+	return config.GetEnvInt("PASSWORD_HISTORY", 5)
+}
+
+// validatePassword cek aturan password; string kosong berarti lolos
+func validatePassword(password, email string) string {
+	if len(password) < passwordMinLength() {
+		return fmt.Sprintf("Password minimal %d karakter", passwordMinLength())
+	}
+	if len(password) > bcryptMaxPasswordBytes {
+		return fmt.Sprintf("Password maksimal %d karakter", bcryptMaxPasswordBytes)
+	}
+
+	var hasLetter, hasDigit bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLetter(r):
+			hasLetter = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		}
+	}
+	if !hasLetter || !hasDigit {
+		return "Password harus mengandung huruf dan angka"
+	}
+
+	if email != "" && strings.EqualFold(password, email) {
+		return "Password tidak boleh sama dengan email"
+	}
+	return ""
+}
+
+// passwordReused cek password terhadap password saat ini dan riwayat terakhir
+func (h *Handler) passwordReused(ctx context.Context, userID int64, password string) (bool, error) {
+	hashes, err := h.repos.Passwords.Recent(ctx, userID, passwordHistorySize())
+	if err != nil {
+		return false, err
+	}
+	for _, hash := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// checkNewPassword gabungan policy + riwayat; userID 0 untuk user baru
+func (h *Handler) checkNewPassword(ctx context.Context, userID int64, password, email string) (string, error) {
+	if msg := validatePassword(password, email); msg != "" {
+		return msg, nil
+	}
+	if userID == 0 {
+		return "", nil
+	}
+	reused, err := h.passwordReused(ctx, userID, password)
+	if err != nil {
+		return "", err
+	}
+	if reused {
+		return fmt.Sprintf("Password tidak boleh sama dengan %d password terakhir", passwordHistorySize()), nil
+	}
+	return "", nil
+}
+
+// recordPasswordHistory simpan hash baru ke riwayat dan buang yang melebihi batas
+func (h *Handler) recordPasswordHistory(ctx context.Context, userID int64, hash string) {
+	if err := h.repos.Passwords.Record(ctx, userID, hash, passwordHistorySize()); err != nil {
+		userLog.ErrorContext(ctx, "simpan riwayat password error", "user_id", userID, "err", err)
+	}
+}
+
+// setUserPassword hash + simpan password baru. mustChange jika password
+// di-set orang lain (admin) sehingga user wajib menggantinya.
+func (h *Handler) setUserPassword(ctx context.Context, userID int64, password string, mustChange bool) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	return h.repos.Passwords.Set(ctx, userID, string(hash), mustChange, passwordHistorySize())
+}
+
+/*
+|--------------------------------------------------------------------------
+| Profil & password sendiri (/api/me)
+|--------------------------------------------------------------------------
+*/
+
+// GetMe - Profil user yang sedang login beserta unit, permission, dan status keamanan
+func (h *Handler) GetMe(c *fiber.Ctx) error {
+	userID, _, _, ok := currentUser(c)
+	if !ok {
+		return userOnly(c)
+	}
+
+	user, err := h.repos.Users.Get(c.UserContext(), userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil data user",
+		})
+	}
+	creds, err := h.repos.Users.Credentials(c.UserContext(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil data user",
+		})
+	}
+
+	profile := models.ToUserDetailResponse(user.User, user.UnitName)
+	profile.UnitIDs, _ = h.repos.Users.UnitIDs(c.UserContext(), user.ID)
+	units, _ := h.userUnits(c.UserContext(), user.ID)
+
+	var activeUnitID *int64
+	if id, ok := c.Locals("unit_id").(int64); ok {
+		activeUnitID = &id
+	}
+
+	required2FA, enabled2FA, _ := h.twoFactorState(c.UserContext(), user.ID, user.Role)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"user":                 profile,
+			"units":                units,
+			"active_unit_id":       activeUnitID,
+			"permissions":          permission.ForRole(user.Role),
+			"must_change_password": creds.MustChangePassword,
+			"password_changed_at":  creds.PasswordChangedAt,
+			"two_factor": fiber.Map{
+				"enabled":  enabled2FA,
+				"required": required2FA,
+			},
+		},
+	})
+}
+
+// ChangeMyPassword - Ganti password sendiri (wajib password lama). Sesi lain di-revoke.
+func (h *Handler) ChangeMyPassword(c *fiber.Ctx) error {
+	userID, email, _, ok := currentUser(c)
+	if !ok {
+		return userOnly(c)
+	}
+
+	var req models.ChangePasswordRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	creds, err := h.repos.Users.Credentials(c.UserContext(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if bcrypt.CompareHashAndPassword([]byte(creds.Password), []byte(req.CurrentPassword)) != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Password lama salah",
+		})
+	}
+
+	msg, err := h.checkNewPassword(c.UserContext(), userID, req.NewPassword, email)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal validasi password",
+		})
+	}
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if err := h.setUserPassword(c.UserContext(), userID, req.NewPassword, false); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengganti password",
+		})
+	}
+
+	sessionID, _ := c.Locals("session_id").(string)
+	revoked, err := h.repos.Sessions.RevokeOthers(c.UserContext(), userID, sessionID, "password_changed")
+	if err != nil {
+		sessionLog.ErrorContext(c.UserContext(), "revoke sessions error", "user_id", userID, "err", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success":          true,
+		"message":          "Password berhasil diganti",
+		"sessions_revoked": revoked,
+	})
+}
+
+/*
+|--------------------------------------------------------------------------
+| Reset password oleh admin
+|--------------------------------------------------------------------------
+| Admin tidak lagi menentukan password: force reset mengunci password lama,
+| me-revoke semua sesi, dan menerbitkan token sekali pakai yang diserahkan
+| ke user. User memilih password sendiri lewat POST /san/password/reset.
+*/
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ForcePasswordReset - Admin paksa reset password user dan terbitkan token sekali pakai
+func (h *Handler) ForcePasswordReset(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+	userID := int64(id)
+
+	_, err = h.repos.Users.Get(c.UserContext(), userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	token, err := config.RandomHex(32)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal membuat token reset",
+		})
+	}
+	// Password lama diganti nilai acak yang tidak diketahui siapa pun
+	locked, err := config.RandomHex(32)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal membuat token reset",
+		})
+	}
+	lockedHash, err := bcrypt.GenerateFromPassword([]byte(locked), bcrypt.DefaultCost)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengenkripsi password",
+		})
+	}
+
+	expiresAt := clock.Now().Add(config.GetEnvDuration("PASSWORD_RESET_TTL", 24*time.Hour))
+	var createdBy *int64
+	if adminID, ok := c.Locals("user_id").(int64); ok {
+		createdBy = &adminID
+	}
+
+	// Token lama yang belum dipakai tidak berlaku lagi
+	if err := h.repos.Passwords.ForceReset(c.UserContext(), repository.NewResetToken{
+		Hash:      hashResetToken(token),
+		UserID:    userID,
+		CreatedBy: createdBy,
+		ExpiresAt: expiresAt,
+	}, string(lockedHash)); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mereset password",
+		})
+	}
+
+	revoked, err := h.revokeUserSessions(c.UserContext(), userID, "password_reset")
+	if err != nil {
+		sessionLog.ErrorContext(c.UserContext(), "revoke sessions error", "user_id", userID, "err", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Password user direset. Serahkan token reset kepada user (hanya ditampilkan sekali)",
+		"data": fiber.Map{
+			"user_id":          userID,
+			"reset_token":      token,
+			"expires_at":       expiresAt,
+			"sessions_revoked": revoked,
+		},
+	})
+}
+
+// ResetPasswordWithToken - User set password baru memakai token reset dari admin (publik)
+func (h *Handler) ResetPasswordWithToken(c *fiber.Ctx) error {
+	var req models.ResetPasswordRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	// Token ditebak = brute-force; pakai counter per IP yang sama dengan login
+	ipKey := loginguard.IP(c.IP())
+	if blocked, err := loginBlocked(c, []loginguard.Key{ipKey}); blocked {
+		return err
+	}
+
+	user, err := h.repos.Passwords.ResetTokenUser(c.UserContext(), hashResetToken(req.Token), clock.Now())
+	if errors.Is(err, repository.ErrNotFound) {
+		if _, err := loginguard.Default.Fail(c.UserContext(), ipKey); err != nil {
+			authLog.ErrorContext(c.UserContext(), "loginguard fail error", "err", err)
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Token reset tidak valid atau kedaluwarsa",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	msg, err := h.checkNewPassword(c.UserContext(), user.ID, req.NewPassword, user.Email)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal validasi password",
+		})
+	}
+	if msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	// Tandai terpakai dulu (bersyarat) supaya token tidak bisa dipakai dua kali paralel
+	err = h.repos.Passwords.UseResetToken(c.UserContext(), hashResetToken(req.Token))
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Token reset tidak valid atau kedaluwarsa",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	if err := h.setUserPassword(c.UserContext(), user.ID, req.NewPassword, false); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal menyimpan password",
+		})
+	}
+	recordLoginSuccess(c, "user", user.Email)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Password berhasil diatur, silakan login",
+	})
+}
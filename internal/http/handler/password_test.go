@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/totp"
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestChangeAndResetPassword(t *testing.T) {
+	h, app, user := sessionApp(t)
+	ctx := context.Background()
+
+	_, login := do(t, app, "POST", "/login", "")
+	access := login["token"].(string)
+	change := func(current, next string) int {
+		t.Helper()
+		status, _ := do(t, app, "PUT", "/api/me/password?token="+access,
+			fmt.Sprintf(`{"current_password":%q,"new_password":%q}`, current, next))
+		return status
+	}
+
+	if status := change("salah", "baru12345"); status != fiber.StatusBadRequest {
+		t.Fatalf("password lama salah = %d, want 400", status)
+	}
+	if status := change(userPassword, userPassword); status != fiber.StatusBadRequest {
+		t.Fatalf("password sama = %d, want 400", status)
+	}
+	if status := change(userPassword, "baru12345"); status != fiber.StatusOK {
+		t.Fatalf("ganti password = %d", status)
+	}
+	// Password lama masuk riwayat
+	if status := change("baru12345", userPassword); status != fiber.StatusBadRequest {
+		t.Fatalf("pakai ulang password lama = %d, want 400", status)
+	}
+
+	status, body := do(t, app, "GET", "/api/me?token="+access, "")
+	data := body["data"].(map[string]any)
+	if status != fiber.StatusOK || data["must_change_password"] != false || data["password_changed_at"] == nil {
+		t.Fatalf("me = %d %v", status, body)
+	}
+
+	status, body = do(t, app, "POST", fmt.Sprintf("/users/%d/password-reset", user.ID), "")
+	if status != fiber.StatusOK {
+		t.Fatalf("force reset = %d %v", status, body)
+	}
+	token := body["data"].(map[string]any)["reset_token"].(string)
+	if status, _ := refresh(t, app, login["refresh_token"].(string)); status != fiber.StatusUnauthorized {
+		t.Fatalf("refresh setelah force reset = %d, want 401", status)
+	}
+	if creds, _ := h.repos.Users.Credentials(ctx, user.ID); !creds.MustChangePassword {
+		t.Fatal("force reset tanpa must_change_password")
+	}
+
+	reset := func(token, next string) int {
+		t.Helper()
+		status, _ := do(t, app, "POST", "/san/password/reset", fmt.Sprintf(`{"token":%q,"new_password":%q}`, token, next))
+		return status
+	}
+	if status := reset("salah", "lain12345"); status != fiber.StatusBadRequest {
+		t.Fatalf("token asing = %d, want 400", status)
+	}
+	if status := reset(token, "baru12345"); status != fiber.StatusBadRequest {
+		t.Fatalf("reset ke password riwayat = %d, want 400", status)
+	}
+	if status := reset(token, "lain12345"); status != fiber.StatusOK {
+		t.Fatalf("reset = %d", status)
+	}
+	if status := reset(token, "lain67890"); status != fiber.StatusBadRequest {
+		t.Fatalf("token dipakai ulang = %d, want 400", status)
+	}
+
+	creds, _ := h.repos.Users.Credentials(ctx, user.ID)
+	if creds.MustChangePassword || bcrypt.CompareHashAndPassword([]byte(creds.Password), []byte("lain12345")) != nil {
+		t.Fatalf("setelah reset = %+v", creds)
+	}
+}
+
+func TestLoginTwoFactor(t *testing.T) {
+	_, app, user := sessionApp(t)
+
+	_, login := do(t, app, "POST", "/login", "")
+	access := login["token"].(string)
+
+	status, body := do(t, app, "POST", "/api/me/2fa/setup?token="+access, "")
+	if status != fiber.StatusOK {
+		t.Fatalf("setup = %d %v", status, body)
+	}
+	secret := body["data"].(map[string]any)["secret"].(string)
+	code, _ := totp.CodeAt(secret, totp.Step(clock.Now()))
+	status, body = do(t, app, "POST", "/api/me/2fa/enable?token="+access, fmt.Sprintf(`{"code":%q}`, code))
+	if status != fiber.StatusOK {
+		t.Fatalf("enable = %d %v", status, body)
+	}
+	codes := body["data"].(map[string]any)["recovery_codes"].([]any)
+	if len(codes) != recoveryCodeCount {
+		t.Fatalf("recovery codes = %v", codes)
+	}
+
+	status, body = do(t, app, "POST", "/san/login", fmt.Sprintf(`{"email":%q,"password":%q}`, user.Email, userPassword))
+	if status != fiber.StatusOK || body["mfa_required"] != true || body["enrollment_required"] != false {
+		t.Fatalf("login = %d %v", status, body)
+	}
+	challenge := body["challenge_token"].(string)
+
+	verify := fmt.Sprintf(`{"challenge_token":%q,"recovery_code":%q}`, challenge, codes[0])
+	status, body = do(t, app, "POST", "/san/login/2fa", verify)
+	if status != fiber.StatusOK || body["token"] == nil || body["recovery_codes_remaining"] != float64(recoveryCodeCount-1) {
+		t.Fatalf("verify = %d %v", status, body)
+	}
+	// Challenge sekali pakai
+	if status, _ := do(t, app, "POST", "/san/login/2fa", verify); status != fiber.StatusUnauthorized {
+		t.Fatalf("challenge dipakai ulang = %d, want 401", status)
+	}
+
+	status, body = do(t, app, "GET", "/api/me/2fa?token="+access, "")
+	data := body["data"].(map[string]any)
+	if status != fiber.StatusOK || data["enabled"] != true || data["recovery_codes_remaining"] != float64(recoveryCodeCount-1) {
+		t.Fatalf("status 2fa = %d %v", status, body)
+	}
+}
@@ -1,50 +1,51 @@
 package handler
 
 import (
-	"backend-antrian/internal/config"
-	"backend-antrian/internal/helper"
-	"backend-antrian/internal/models"
-	"database/sql"
+	"backend-antrian/internal/repository"
+	"errors"
 	"fmt"
-	"log"
+	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // TakeQueueRequest - Request body untuk mengambil nomor antrian
 type TakeQueueRequest struct {
-	UnitID    int64 `json:"unit_id"`
-	ServiceID int64 `json:"service_id"`
+	UnitID    int64 `json:"unit_id" validate:"required"`
+	ServiceID int64 `json:"service_id" validate:"required"`
 }
 
 // TakeQueue - Endpoint untuk mengambil nomor antrian
-func TakeQueue(c *fiber.Ctx) error {
+func (h *Handler) TakeQueue(c *fiber.Ctx) error {
 	var req TakeQueueRequest
-	userID := c.Locals("user_id").(int64)
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   "Invalid request body",
-		})
-	}
-
-	// Validasi input
-	if req.UnitID == 0 || req.ServiceID == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   "unit_id dan service_id wajib diisi",
-		})
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	// Pengambil tiket: kiosk (dibatasi unit scope) atau user super_user
+	var userID, kioskID *int64
+	if id, ok := c.Locals("kiosk_id").(int64); ok {
+		allowed, err := h.repos.Kiosks.AllowsUnit(c.UserContext(), id, req.UnitID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"success": false,
+				"error":   "Gagal memvalidasi kiosk",
+			})
+		}
+		if !allowed {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"error":   "Kiosk ini tidak melayani unit tersebut",
+			})
+		}
+		kioskID = &id
+	} else if id, ok := c.Locals("user_id").(int64); ok {
+		userID = &id
 	}
 
 	// 1. Cek apakah unit aktif
-	var unitActive string
-	var unitName string
-	err := config.DB.QueryRow(
-		"SELECT is_active, nama_unit FROM units WHERE id = ?",
-		req.UnitID,
-	).Scan(&unitActive, &unitName)
-
-	if err == sql.ErrNoRows {
+	unit, err := h.repos.Units.Get(c.UserContext(), req.UnitID)
+	if errors.Is(err, repository.ErrNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
 			"error":   "Unit tidak ditemukan",
@@ -58,7 +59,8 @@ func TakeQueue(c *fiber.Ctx) error {
 		})
 	}
 
-	if unitActive != "y" {
+	unitName := unit.NamaUnit
+	if unit.IsActive != "y" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
 			"error":   fmt.Sprintf("Unit %s sedang tidak aktif", unitName),
@@ -66,7 +68,7 @@ func TakeQueue(c *fiber.Ctx) error {
 	}
 
 	// 2. Cek apakah unit sedang buka berdasarkan jadwal hari ini
-	unitStatus := helper.IsUnitOpen(config.DB, req.UnitID)
+	unitStatus := h.unitOpenStatus(c.UserContext(), unit)
 	if !unitStatus.IsOpen {
 		msg := fmt.Sprintf("Unit %s sedang tutup", unitName)
 		if unitStatus.IsActiveDay && unitStatus.JamBuka != "" {
@@ -79,18 +81,8 @@ func TakeQueue(c *fiber.Ctx) error {
 	}
 
 	// 3. Cek apakah service ada, aktif, dan sesuai dengan unit
-	var serviceActive string
-	var serviceName string
-	var serviceCode string
-	var limitsQueue int
-	var serviceUnitID int64
-
-	err = config.DB.QueryRow(
-		"SELECT is_active, nama_service, code, limits_queue, unit_id FROM services WHERE id = ?",
-		req.ServiceID,
-	).Scan(&serviceActive, &serviceName, &serviceCode, &limitsQueue, &serviceUnitID)
-
-	if err == sql.ErrNoRows {
+	service, err := h.repos.Services.Get(c.UserContext(), req.ServiceID)
+	if errors.Is(err, repository.ErrNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
 			"error":   "Layanan tidak ditemukan",
@@ -105,7 +97,7 @@ func TakeQueue(c *fiber.Ctx) error {
 	}
 
 	// Validasi service sesuai dengan unit
-	if serviceUnitID != req.UnitID {
+	if service.UnitID != req.UnitID {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
 			"error":   "Layanan tidak tersedia di unit ini",
@@ -113,7 +105,8 @@ func TakeQueue(c *fiber.Ctx) error {
 	}
 
 	// Validasi service aktif
-	if serviceActive != "y" {
+	serviceName := service.NamaService
+	if service.IsActive != "y" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
 			"error":   fmt.Sprintf("Layanan %s di unit %s sedang tutup", serviceName, unitName),
@@ -121,18 +114,9 @@ func TakeQueue(c *fiber.Ctx) error {
 	}
 
 	// 4. Hitung jumlah antrian hari ini untuk service ini
-	// PERBAIKAN: Gunakan DATE() di MySQL untuk handle timezone lebih baik
-	var todayQueueCount int
-	err = config.DB.QueryRow(`
-		SELECT COUNT(*) 
-		FROM queue_tickets 
-		WHERE service_id = ? 
-		AND unit_id = ? 
-		AND DATE(created_at) = CURDATE()
-	`, req.ServiceID, req.UnitID).Scan(&todayQueueCount)
-
+	todayQueueCount, err := h.repos.Tickets.CountToday(c.UserContext(), req.UnitID, req.ServiceID, unitToday(unit))
 	if err != nil {
-		log.Printf("[TakeQueue] Error counting queue: %v", err)
+		queueLog.ErrorContext(c.UserContext(), "take: count today error", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   "Gagal menghitung antrian hari ini",
@@ -140,6 +124,7 @@ func TakeQueue(c *fiber.Ctx) error {
 	}
 
 	// 5. Validasi limit queue (jika limits_queue > 0)
+	limitsQueue := service.LimitsQueue
 	if limitsQueue > 0 && todayQueueCount >= limitsQueue {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
@@ -150,73 +135,40 @@ func TakeQueue(c *fiber.Ctx) error {
 	// 6. Generate ticket code
 	// Format: KODE_SERVICE + NOMOR_URUT (misal: KTP001, KTP002, dst)
 	queueNumber := todayQueueCount + 1
-	ticketCode := fmt.Sprintf("%s%d", serviceCode, queueNumber)
-
-	// 7. Insert ticket ke database
-	query := `
-		INSERT INTO queue_tickets 
-		(ticket_code, unit_id, service_id, user_id, status, created_at, updated_at) 
-		VALUES (?, ?, ?, ?, 'waiting', NOW(), NOW())
-	`
-	
-	result, err := config.DB.Exec(query, ticketCode, req.UnitID, req.ServiceID, userID)
+	ticketCode := fmt.Sprintf("%s%d", service.Code, queueNumber)
+
+	// 7. Simpan ticket + transaction log (event: take)
+	ticketID, err := h.repos.Tickets.Create(c.UserContext(), repository.NewTicket{
+		TicketCode: ticketCode,
+		UnitID:     req.UnitID,
+		ServiceID:  req.ServiceID,
+		UserID:     userID,
+		KioskID:    kioskID,
+	})
 	if err != nil {
-		log.Printf("[TakeQueue] Error inserting ticket: %v", err)
+		queueLog.ErrorContext(c.UserContext(), "take: insert ticket error", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   "Gagal membuat nomor antrian",
 		})
 	}
 
-	ticketID, _ := result.LastInsertId()
-	// 8. Insert transaction log (event: take)
-	_, err = config.DB.Exec(`
-		INSERT INTO queue_transactions 
-		(ticket_id, event, actor_user_id, created_at, updated_at) 
-		VALUES (?, 'take', ?, NOW(), NOW())
-	`, ticketID, userID)
-
-	if err != nil {
-		log.Printf("[TakeQueue] Error inserting transaction: %v", err)
-		// Rollback ticket jika gagal insert transaction
-		config.DB.Exec("DELETE FROM queue_tickets WHERE id = ?", ticketID)
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "Gagal mencatat transaksi antrian",
-		})
-	}
-
-	// 9. Ambil data ticket yang baru dibuat
-	var ticket models.QueueTicket
-	err = config.DB.QueryRow(`
-		SELECT id, ticket_code, unit_id, service_id, user_id, status, 
-		       last_called_at, created_at, updated_at 
-		FROM queue_tickets 
-		WHERE id = ?
-	`, ticketID).Scan(
-		&ticket.ID,
-		&ticket.TicketCode,
-		&ticket.UnitID,
-		&ticket.ServiceID,
-		&ticket.UserID,
-		&ticket.Status,
-		&ticket.LastCalledAt,
-		&ticket.CreatedAt,
-		&ticket.UpdatedAt,
-	)
-
+	// 8. Ambil data ticket yang baru dibuat
+	ticket, err := h.repos.Tickets.Get(c.UserContext(), ticketID)
 	if err != nil {
-		log.Printf("[TakeQueue] Error fetching ticket: %v", err)
+		queueLog.ErrorContext(c.UserContext(), "take: fetch ticket error", "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   "Gagal mengambil data antrian",
 		})
 	}
 
-	// Broadcast update ke WebSocket display
-	BroadcastQueueUpdate()
+	ticketsTaken.Inc(strconv.FormatInt(req.UnitID, 10), strconv.FormatInt(req.ServiceID, 10))
+
+	// Kirim delta ke WebSocket display
+	publishQueueChange(c.UserContext(), TicketChange{TicketID: ticketID, Event: "taken"})
 
-	// 10. Return response dengan info tambahan
+	// 9. Return response dengan info tambahan
 	remaining := 0
 	if limitsQueue > 0 {
 		remaining = limitsQueue - queueNumber
@@ -0,0 +1,478 @@
+package handler
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/config"
+	"backend-antrian/internal/dialect"
+	"backend-antrian/internal/realtime"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+|--------------------------------------------------------------------------
+| Announcement Queue
+|--------------------------------------------------------------------------
+| Setiap panggilan (call/recall) masuk ke tabel queue_announcements dengan
+| nomor urut (id). Display menarik item berikutnya lewat /ws/queue, memutar
+| audio, lalu mengirim ack. Item yang tidak di-ack sampai lease habis (atau
+| display-nya putus) dikirim ulang ke display lain.
+|
+| Hanya display dengan plays_audio = 'y' yang menerima pengumuman, dan hanya
+| untuk unit/layanan yang di-assign ke display tersebut.
+*/
+
+const (
+	announcementLease       = 30 * time.Second
+	announcementMaxAge      = 2 * time.Minute
+	announcementMaxAttempts = 3
+	announcementWatchPeriod = 5 * time.Second
+	// display yang lease-nya habis baru boleh klaim ulang item yang sama
+	// setelah jeda ini; display lain didahulukan
+	announcementReclaimDelay = announcementWatchPeriod
+)
+
+// Announcement - satu item pengumuman yang dikirim ke display
+type Announcement struct {
+	Seq         int64    `json:"seq"`
+	TicketID    int64    `json:"ticket_id"`
+	TicketCode  string   `json:"ticket_code"`
+	UnitID      int64    `json:"unit_id"`
+	UnitName    string   `json:"unit_name"`
+	ServiceID   int64    `json:"service_id"`
+	ServiceName string   `json:"service_name"`
+	Loket       string   `json:"loket"`
+	Event       string   `json:"event"` // call, recall
+	AudioPaths  []string `json:"audio_paths"`
+	Attempt     int      `json:"attempt"`
+	CreatedAt   string   `json:"created_at"`
+}
+
+// clientMessage - pesan dari display ke server
+type clientMessage struct {
+	Type       string `json:"type"`
+	Seq        int64  `json:"seq"`
+	Since      *int64 `json:"since"`       // resync
+	Epoch      string `json:"epoch"`       // resync
+	ID         int64  `json:"id"`          // command_ack
+	OK         *bool  `json:"ok"`          // command_ack, default true
+	Error      string `json:"error"`       // command_ack
+	AppVersion string `json:"app_version"` // hello
+}
+
+// EnqueueAnnouncement masukkan pengumuman baru untuk ticket lalu beri tahu display.
+func EnqueueAnnouncement(ctx context.Context, ticketID int64, event string) (int64, error) {
+	var (
+		ticketCode string
+		unitID     int64
+		serviceID  int64
+		audioFile  sql.NullString
+	)
+
+	err := config.DB.QueryRowContext(ctx, `
+		SELECT qt.ticket_code, qt.unit_id, qt.service_id, u.audio_file
+		FROM queue_tickets qt
+		JOIN units u ON qt.unit_id = u.id
+		WHERE qt.id = ?
+	`, ticketID).Scan(&ticketCode, &unitID, &serviceID, &audioFile)
+	if err != nil {
+		return 0, fmt.Errorf("ambil ticket %d: %w", ticketID, err)
+	}
+
+	paths, _ := json.Marshal(generateAudioPaths(ticketCode, audioFile.String))
+
+	result, err := config.DB.ExecContext(ctx, `
+		INSERT INTO queue_announcements
+		(ticket_id, unit_id, service_id, ticket_code, event, audio_paths, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, 'pending', NOW(), NOW())
+	`, ticketID, unitID, serviceID, ticketCode, event, string(paths))
+	if err != nil {
+		return 0, fmt.Errorf("insert announcement: %w", err)
+	}
+
+	seq, _ := result.LastInsertId()
+	notifyAnnouncementAvailable(ctx, seq, unitID, serviceID)
+
+	return seq, nil
+}
+
+// claimNextAnnouncement ambil item pending tertua yang belum di-lease dan
+// masuk scope display. Display lain boleh mengambil alih begitu lease habis;
+// client pemegang lease lama baru bisa klaim ulang setelah
+// announcementReclaimDelay, sehingga pada setup satu display audio item yang
+// tidak di-ack tetap diputar ulang.
+func claimNextAnnouncement(clientID string, profile *DisplayProfile) (*Announcement, error) {
+	expireStaleAnnouncements()
+
+	scopeSQL, scopeArgs := announcementScope(profile)
+	now := clock.Now()
+	args := append([]interface{}{clock.SQL(now), clientID, clock.SQL(now.Add(-announcementReclaimDelay))}, scopeArgs...)
+
+	tx, err := config.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var seq int64
+	err = tx.QueryRow(`
+		SELECT id
+		FROM queue_announcements
+		WHERE status = 'pending'
+		  AND (
+		    lease_until IS NULL
+		    OR (lease_until < ? AND (claimed_by IS NULL OR claimed_by <> ?))
+		    OR lease_until < ?
+		  )
+		  `+scopeSQL+`
+		ORDER BY id ASC
+		LIMIT 1
+		`+dialect.Current.ForUpdateSkipLocked()+`
+	`, args...).Scan(&seq)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE queue_announcements
+		SET claimed_by = ?,
+		    claimed_at = NOW(),
+		    lease_until = `+dialect.Current.AddSeconds("NOW()")+`,
+		    attempts = attempts + 1,
+		    updated_at = NOW()
+		WHERE id = ?
+	`, clientID, int(announcementLease.Seconds()), seq)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return getAnnouncement(seq)
+}
+
+// announcementScope filter SQL unit/layanan sesuai DisplayProfile.Allows.
+func announcementScope(profile *DisplayProfile) (string, []interface{}) {
+	if profile == nil || (len(profile.UnitIDs) == 0 && len(profile.ServiceIDs) == 0) {
+		return "", nil
+	}
+
+	var (
+		conds []string
+		args  []interface{}
+	)
+	if len(profile.UnitIDs) > 0 {
+		conds = append(conds, "unit_id IN ("+strings.TrimSuffix(strings.Repeat("?,", len(profile.UnitIDs)), ",")+")")
+		for id := range profile.UnitIDs {
+			args = append(args, id)
+		}
+	}
+	if len(profile.ServiceIDs) > 0 {
+		conds = append(conds, "service_id IN ("+strings.TrimSuffix(strings.Repeat("?,", len(profile.ServiceIDs)), ",")+")")
+		for id := range profile.ServiceIDs {
+			args = append(args, id)
+		}
+	}
+
+	return "AND (" + strings.Join(conds, " OR ") + ")", args
+}
+
+func getAnnouncement(seq int64) (*Announcement, error) {
+	var (
+		a         Announcement
+		paths     string
+		createdAt time.Time
+	)
+
+	err := config.DB.QueryRow(`
+		SELECT a.id, a.ticket_id, a.ticket_code, a.unit_id, u.nama_unit,
+		       a.service_id, s.nama_service, a.event, a.audio_paths, a.attempts, a.created_at
+		FROM queue_announcements a
+		JOIN units u ON a.unit_id = u.id
+		JOIN services s ON a.service_id = s.id
+		WHERE a.id = ?
+	`, seq).Scan(
+		&a.Seq, &a.TicketID, &a.TicketCode, &a.UnitID, &a.UnitName,
+		&a.ServiceID, &a.ServiceName, &a.Event, &paths, &a.Attempt, &createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	a.Loket = a.UnitName
+	a.CreatedAt = createdAt.Format("2006-01-02 15:04:05")
+	if err := json.Unmarshal([]byte(paths), &a.AudioPaths); err != nil {
+		a.AudioPaths = []string{}
+	}
+
+	return &a, nil
+}
+
+// ackAnnouncement tandai selesai diputar. Hanya pemegang lease yang boleh ack.
+func ackAnnouncement(clientID string, seq int64) (bool, error) {
+	result, err := config.DB.Exec(`
+		UPDATE queue_announcements
+		SET status = 'done',
+		    acked_by = ?,
+		    acked_at = NOW(),
+		    lease_until = NULL,
+		    updated_at = NOW()
+		WHERE id = ? AND status = 'pending' AND claimed_by = ?
+	`, clientID, seq, clientID)
+	if err != nil {
+		return false, err
+	}
+
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// releaseClientAnnouncements lepas lease milik client yang disconnect
+// supaya langsung bisa diambil display lain.
+func releaseClientAnnouncements(clientID string) {
+	result, err := config.DB.Exec(`
+		UPDATE queue_announcements
+		SET lease_until = NULL, updated_at = NOW()
+		WHERE status = 'pending' AND claimed_by = ? AND lease_until IS NOT NULL
+	`, clientID)
+	if err != nil {
+		announcementLog.Error("release error", "client", clientID, "err", err)
+		return
+	}
+
+	if n, _ := result.RowsAffected(); n > 0 {
+		announcementLog.Info("released", "client", clientID, "count", n)
+		notifyAnnouncementAvailable(context.Background(), 0, 0, 0)
+	}
+}
+
+// expireStaleAnnouncements buang pengumuman yang sudah terlalu lama atau gagal berulang kali.
+// Panggilan yang sudah lewat 2 menit tidak ada gunanya diputar lagi.
+func expireStaleAnnouncements() {
+	now := clock.Now()
+	_, err := config.DB.Exec(`
+		UPDATE queue_announcements
+		SET status = 'expired', lease_until = NULL, updated_at = NOW()
+		WHERE status = 'pending'
+		  AND (
+		    created_at < ?
+		    OR (attempts >= ? AND lease_until < ?)
+		  )
+	`, clock.SQL(now.Add(-announcementMaxAge)), announcementMaxAttempts, clock.SQL(now))
+	if err != nil {
+		announcementLog.Error("expire error", "err", err)
+	}
+}
+
+// notifyAnnouncementAvailable beri tahu display audio di semua replica bahwa
+// ada item yang bisa ditarik. unitID 0 artinya tidak diketahui — semua display
+// audio diberi tahu.
+func notifyAnnouncementAvailable(ctx context.Context, seq, unitID, serviceID int64) {
+	payload, _ := json.Marshal(announcementEvent{Seq: seq, UnitID: unitID, ServiceID: serviceID})
+	publishRealtime(ctx, realtime.ChannelAnnouncements, payload)
+}
+
+// fanoutAnnouncementAvailable kirim notifikasi ke display audio lokal.
+func fanoutAnnouncementAvailable(seq, unitID, serviceID int64) {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type": "announcement_available",
+		"seq":  seq,
+	})
+	broadcastEach("announcement", func(client *ClientInfo) []byte {
+		profile := client.display.Load()
+		if profile == nil || !profile.PlaysAudio {
+			return nil
+		}
+		if unitID != 0 && !profile.Allows(unitID, serviceID) {
+			return nil
+		}
+		return payload
+	})
+}
+
+// RunAnnouncementWatcher cek berkala lease yang habis dan kirim ulang notifikasi.
+func RunAnnouncementWatcher() {
+	ticker := time.NewTicker(announcementWatchPeriod)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		queueMutex.RLock()
+		hasClients := len(queueClients) > 0
+		queueMutex.RUnlock()
+		if !hasClients {
+			continue
+		}
+
+		expireStaleAnnouncements()
+
+		var pending int
+		err := config.DB.QueryRow(`
+			SELECT COUNT(*)
+			FROM queue_announcements
+			WHERE status = 'pending'
+			  AND lease_until IS NOT NULL
+			  AND lease_until < ?
+		`, clock.SQL(clock.Now())).Scan(&pending)
+		if err != nil {
+			announcementLog.Error("watcher error", "err", err)
+			continue
+		}
+
+		if pending > 0 {
+			notifyAnnouncementAvailable(context.Background(), 0, 0, 0)
+		}
+	}
+}
+
+// handleClientMessage proses pesan dari display (pengumuman, resync, hello, ack perintah).
+func (h *Handler) handleClientMessage(client *ClientInfo, raw []byte) {
+	var msg clientMessage
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return
+	}
+
+	profile := client.display.Load()
+
+	switch msg.Type {
+	case "announcement_pull":
+		if profile == nil || !profile.PlaysAudio {
+			payload, _ := json.Marshal(map[string]interface{}{
+				"type":  "error",
+				"error": "Display ini tidak memutar audio",
+			})
+			writeToClient(client, payload)
+			return
+		}
+
+		a, err := claimNextAnnouncement(client.id, profile)
+		if err != nil {
+			announcementLog.Error("pull error", "client", client.id, "err", err)
+			return
+		}
+		if a == nil {
+			payload, _ := json.Marshal(map[string]interface{}{
+				"type": "announcement_empty",
+			})
+			writeToClient(client, payload)
+			return
+		}
+		payload, _ := json.Marshal(map[string]interface{}{
+			"type":             "announcement",
+			"data":             a,
+			"lease_expires_in": int(announcementLease.Seconds()),
+		})
+		writeToClient(client, payload)
+
+	case "announcement_ack":
+		ok, err := ackAnnouncement(client.id, msg.Seq)
+		if err != nil {
+			announcementLog.Error("ack error", "client", client.id, "seq", msg.Seq, "err", err)
+			return
+		}
+		payload, _ := json.Marshal(map[string]interface{}{
+			"type":     "announcement_ack_result",
+			"seq":      msg.Seq,
+			"accepted": ok,
+		})
+		writeToClient(client, payload)
+
+	case "resync":
+		since := int64(-1) // tanpa since = minta snapshot penuh
+		if msg.Since != nil {
+			since = *msg.Since
+		}
+		h.resyncClient(client, since, msg.Epoch)
+
+	case "hello":
+		h.recordDisplayAppVersion(client, msg.AppVersion)
+
+	case "command_ack":
+		success := msg.OK == nil || *msg.OK
+		ok, err := h.ackDisplayCommand(client, msg.ID, success, msg.Error)
+		if err != nil {
+			displayLog.Error("command ack error", "client", client.id, "command_id", msg.ID, "err", err)
+			return
+		}
+		payload, _ := json.Marshal(map[string]interface{}{
+			"type":     "command_ack_result",
+			"id":       msg.ID,
+			"accepted": ok,
+		})
+		writeToClient(client, payload)
+	}
+}
+
+// RepeatAnnouncement - Panggil ulang (recall) ticket yang sedang dipanggil
+func (h *Handler) RepeatAnnouncement(c *fiber.Ctx) error {
+	ticketID := c.Params("id")
+
+	userUnitID, err := h.activeUnitID(c)
+	if err != nil {
+		return unitAccessError(c, err)
+	}
+
+	var (
+		id           int64
+		status       string
+		ticketUnitID int64
+	)
+	err = config.DB.QueryRow(`
+		SELECT id, status, unit_id FROM queue_tickets WHERE id = ?
+	`, ticketID).Scan(&id, &status, &ticketUnitID)
+
+	if err == sql.ErrNoRows {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error":   "Ticket tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mengambil data ticket",
+		})
+	}
+
+	if ticketUnitID != userUnitID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error":   "Anda tidak memiliki akses ke ticket ini",
+		})
+	}
+
+	if status != "called" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   fmt.Sprintf("Hanya ticket yang sedang dipanggil yang bisa dipanggil ulang. Status saat ini: %s", status),
+		})
+	}
+
+	seq, err := EnqueueAnnouncement(c.UserContext(), id, "recall")
+	if err != nil {
+		announcementLog.ErrorContext(c.UserContext(), "repeat error", "ticket_id", id, "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal memanggil ulang antrian",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Antrian berhasil dipanggil ulang",
+		"data": fiber.Map{
+			"ticket_id": id,
+			"seq":       seq,
+		},
+	})
+}
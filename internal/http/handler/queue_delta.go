@@ -0,0 +1,390 @@
+package handler
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/logger"
+	"backend-antrian/internal/realtime"
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+)
+
+/*
+|--------------------------------------------------------------------------
+| Queue Delta
+|--------------------------------------------------------------------------
+| Perubahan ticket tidak lagi memicu query UNION penuh. Handler memanggil
+| publishQueueChange, worker hanya me-refresh baris layanan yang berubah,
+| menerapkannya ke snapshot di memori, lalu mengirim delta bernomor urut:
+|
+|   ticket_called   - ticket dipanggil
+|   ticket_finished - ticket selesai / dilewati
+|   stats_changed   - jumlah waiting layanan berubah
+|
+| Setiap delta membawa seq (naik monoton per proses) dan epoch (ID proses).
+| seq boleh loncat untuk display yang scope-nya tidak mencakup layanan tsb.
+| Client yang tertinggal kirim {"type":"resync","since":N,"epoch":"..."}
+| atau connect ulang dengan ?since=N&epoch=...; jika riwayat tidak cukup,
+| server mengirim snapshot penuh (queue_update). Client lama tanpa
+| ?protocol=delta tetap menerima queue_update penuh seperti sebelumnya.
+*/
+
+const (
+	queueDeltaHistorySize = 1000
+	queueChangeBuffer     = 256
+)
+
+// TicketChange - satu perubahan ticket dari handler antrian
+type TicketChange struct {
+	TicketID  int64  `json:"ticket_id"`
+	Event     string `json:"event"`                // called, finished, taken, recalled
+	RequestID string `json:"request_id,omitempty"` // request pemicu, untuk korelasi log
+}
+
+// queueDelta - satu delta; Message di-marshal sekali setelah seq diberikan
+// dan disimpan untuk replay
+type queueDelta struct {
+	Seq       int64
+	Type      string
+	UnitID    int64
+	ServiceID int64
+	Data      map[string]interface{}
+	Message   []byte
+}
+
+type queueStateStore struct {
+	mu          sync.Mutex
+	seq         int64
+	snapshot    *queueSnapshot
+	snapshotSeq int64 // seq rebuild penuh terakhir — delta sebelum ini tidak bisa di-replay
+	history     []queueDelta
+}
+
+var (
+	queueState   queueStateStore
+	queueChanges = make(chan []TicketChange, queueChangeBuffer)
+
+	// Debounce queue_update untuk client lama setelah delta
+	legacyTimer   *time.Timer
+	legacyTimerMu sync.Mutex
+)
+
+// snapshotFresh snapshot ada dan dibuat setelah pergantian hari terakhir
+// (di zona aplikasi maupun zona unit menurut data h). Caller memegang mu.
+func (s *queueStateStore) snapshotFresh(h *Handler) bool {
+	return s.snapshot != nil &&
+		!s.snapshot.CreatedAt.Before(h.latestDayStart(context.Background()))
+}
+
+// rebuild query DB penuh lewat h dan reset riwayat delta. Caller memegang mu.
+func (s *queueStateStore) rebuild(h *Handler) error {
+	snapshot, err := h.buildSnapshot()
+	if err != nil {
+		return err
+	}
+
+	s.seq++
+	snapshot.Seq = s.seq
+	s.snapshot = snapshot
+	s.snapshotSeq = s.seq
+	s.history = nil
+	return nil
+}
+
+// publishQueueChange kirim perubahan ticket ke semua replica untuk dijadikan delta.
+// request_id dari ctx ikut dikirim supaya log broadcast di replica mana pun
+// bisa ditelusuri balik ke request pemicunya.
+func publishQueueChange(ctx context.Context, changes ...TicketChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	requestID := logger.RequestID(ctx)
+	for i := range changes {
+		changes[i].RequestID = requestID
+	}
+
+	payload, _ := json.Marshal(changes)
+	publishRealtime(ctx, realtime.ChannelQueueChanges, payload)
+}
+
+// enqueueQueueChange antrekan perubahan ke worker lokal.
+// Jika worker tertinggal, jatuh ke rebuild snapshot penuh.
+func (h *Handler) enqueueQueueChange(changes []TicketChange) {
+	select {
+	case queueChanges <- changes:
+	default:
+		queueLog.Warn("change buffer full, fallback to full broadcast")
+		h.scheduleQueueRebuild()
+	}
+}
+
+// queueWorkerHeartbeat dipukul RunQueueChangeWorker, diperiksa /readyz
+var queueWorkerHeartbeat realtime.Heartbeat
+
+// RunQueueChangeWorker proses perubahan ticket secara berurutan.
+func (h *Handler) RunQueueChangeWorker() {
+	ticker := time.NewTicker(realtime.HeartbeatInterval)
+	defer ticker.Stop()
+	queueWorkerHeartbeat.Beat()
+
+	for {
+		select {
+		case changes := <-queueChanges:
+			h.applyQueueChange(changes)
+			queueWorkerHeartbeat.Beat()
+		case <-ticker.C:
+			queueWorkerHeartbeat.Beat()
+		}
+	}
+}
+
+func (h *Handler) applyQueueChange(changes []TicketChange) {
+	ctx := logger.WithRequestID(context.Background(), changes[0].RequestID)
+
+	queueState.mu.Lock()
+	defer queueState.mu.Unlock()
+
+	// Sedang shutdown: client sudah diputus, snapshot tidak dipakai lagi
+	if shuttingDown.Load() {
+		return
+	}
+
+	// Belum ada snapshot atau sudah ganti hari — rebuild penuh saja
+	if !queueState.snapshotFresh(h) {
+		if err := queueState.rebuild(h); err != nil {
+			queueLog.ErrorContext(ctx, "delta rebuild error", "err", err)
+			return
+		}
+		broadcastSnapshotLocked(queueState.snapshot, nil)
+		return
+	}
+
+	first, err := h.repos.Tickets.Get(ctx, changes[0].TicketID)
+	if err != nil {
+		queueLog.ErrorContext(ctx, "delta ticket lookup error", "ticket_id", changes[0].TicketID, "err", err)
+		return
+	}
+	unitID, serviceID := first.UnitID, first.ServiceID
+
+	rows, err := h.getQueueData(serviceID)
+	if err != nil {
+		queueLog.ErrorContext(ctx, "delta service query error", "service_id", serviceID, "err", err)
+		return
+	}
+	if rows == nil {
+		rows = []QueueData{}
+	}
+	stats := h.calculateServiceStats(serviceID)
+
+	old := queueState.snapshot
+	oldStat, hadStat := old.ServiceStats[serviceID]
+	newStat, hasStat := stats[serviceID]
+
+	// Salin snapshot lama, ganti baris & stats layanan ini
+	next := &queueSnapshot{
+		Queues:       make([]QueueData, 0, len(old.Queues)+len(rows)),
+		ServiceStats: make(map[int64]ServiceStats, len(old.ServiceStats)+1),
+		CreatedAt:    clock.Now(),
+	}
+	for _, q := range old.Queues {
+		if q.ServiceID != serviceID {
+			next.Queues = append(next.Queues, q)
+		}
+	}
+	next.Queues = append(next.Queues, rows...)
+	sortQueueData(next.Queues)
+	for id, st := range old.ServiceStats {
+		if id != serviceID {
+			next.ServiceStats[id] = st
+		}
+	}
+	if hasStat {
+		next.ServiceStats[serviceID] = newStat
+	}
+
+	var deltas []queueDelta
+	statsForced := false
+
+	for _, change := range changes {
+		switch change.Event {
+		case "called":
+			var ticket *QueueData
+			for i := range rows {
+				if rows[i].ID == change.TicketID {
+					ticket = &rows[i]
+					break
+				}
+			}
+			deltas = append(deltas, newQueueDelta("ticket_called", unitID, serviceID, map[string]interface{}{
+				"ticket":         ticket,
+				"service_queues": rows,
+			}))
+		case "finished":
+			var status string
+			if t, err := h.repos.Tickets.Get(ctx, change.TicketID); err == nil {
+				status = t.Status
+			}
+			deltas = append(deltas, newQueueDelta("ticket_finished", unitID, serviceID, map[string]interface{}{
+				"ticket_id":      change.TicketID,
+				"status":         status,
+				"service_queues": rows,
+			}))
+		default:
+			// taken, recalled — hanya mengubah daftar waiting
+			statsForced = true
+		}
+	}
+
+	if statsForced || hadStat != hasStat || oldStat != newStat {
+		deltas = append(deltas, newQueueDelta("stats_changed", unitID, serviceID, map[string]interface{}{
+			"stats":          newStat,
+			"service_queues": rows,
+		}))
+	}
+
+	for i := range deltas {
+		queueState.seq++
+		deltas[i].Seq = queueState.seq
+		deltas[i].Message = deltas[i].marshal()
+		deltas[i].Data = nil
+	}
+	next.Seq = queueState.seq
+	queueState.snapshot = next
+
+	queueState.history = append(queueState.history, deltas...)
+	if over := len(queueState.history) - queueDeltaHistorySize; over > 0 {
+		queueState.history = append([]queueDelta(nil), queueState.history[over:]...)
+	}
+
+	for _, d := range deltas {
+		delta := d
+		sent := broadcastEach("queue_delta", func(client *ClientInfo) []byte {
+			if !client.deltas || !client.synced {
+				return nil
+			}
+			if !client.display.Load().Allows(delta.UnitID, delta.ServiceID) {
+				return nil
+			}
+			return delta.Message
+		})
+		queueLog.InfoContext(ctx, "delta broadcast",
+			"seq", delta.Seq, "type", delta.Type, "service_id", delta.ServiceID, "clients", sent)
+	}
+
+	scheduleLegacyBroadcast()
+}
+
+func newQueueDelta(deltaType string, unitID, serviceID int64, data map[string]interface{}) queueDelta {
+	data["unit_id"] = unitID
+	data["service_id"] = serviceID
+	return queueDelta{Type: deltaType, UnitID: unitID, ServiceID: serviceID, Data: data}
+}
+
+func (d queueDelta) marshal() []byte {
+	message, _ := json.Marshal(map[string]interface{}{
+		"type":      d.Type,
+		"seq":       d.Seq,
+		"epoch":     instanceID,
+		"data":      d.Data,
+		"timestamp": clock.Now().Format(time.RFC3339),
+	})
+	return message
+}
+
+// scheduleLegacyBroadcast kirim queue_update penuh (dari memori, tanpa query)
+// ke client lama. Di-debounce supaya burst delta tetap 1x kirim.
+func scheduleLegacyBroadcast() {
+	legacyTimerMu.Lock()
+	defer legacyTimerMu.Unlock()
+
+	if shuttingDown.Load() {
+		return
+	}
+	if legacyTimer != nil {
+		if !legacyTimer.Reset(broadcastDelay) {
+			pendingBroadcasts.Add(1)
+		}
+		return
+	}
+
+	pendingBroadcasts.Add(1)
+	legacyTimer = time.AfterFunc(broadcastDelay, func() {
+		defer pendingBroadcasts.Done()
+
+		legacyTimerMu.Lock()
+		legacyTimer = nil
+		legacyTimerMu.Unlock()
+
+		queueState.mu.Lock()
+		defer queueState.mu.Unlock()
+
+		if queueState.snapshot == nil {
+			return
+		}
+		broadcastSnapshotLocked(queueState.snapshot, func(client *ClientInfo) bool {
+			return !client.deltas
+		})
+	})
+}
+
+// resyncClient kirim delta setelah since jika riwayat masih lengkap,
+// selain itu kirim snapshot penuh.
+func (h *Handler) resyncClient(client *ClientInfo, since int64, epoch string) {
+	queueState.mu.Lock()
+	defer queueState.mu.Unlock()
+
+	client.deltas = true
+
+	if !queueState.snapshotFresh(h) {
+		if err := queueState.rebuild(h); err != nil {
+			queueLog.Error("resync error", "client", client.id, "err", err)
+			return
+		}
+		sendSnapshotLocked(client, queueState.snapshot)
+		return
+	}
+
+	canReplay := epoch == instanceID &&
+		since >= queueState.snapshotSeq &&
+		since <= queueState.seq
+	if canReplay && len(queueState.history) > 0 && queueState.history[0].Seq > since+1 {
+		canReplay = false // riwayat sudah terpotong
+	}
+
+	if !canReplay {
+		sendSnapshotLocked(client, queueState.snapshot)
+		return
+	}
+
+	profile := client.display.Load()
+	for _, d := range queueState.history {
+		if d.Seq <= since || !profile.Allows(d.UnitID, d.ServiceID) {
+			continue
+		}
+		writeToClient(client, d.Message)
+	}
+
+	// Beri tahu client posisi terakhir walau tidak ada delta yang relevan
+	payload, _ := json.Marshal(map[string]interface{}{
+		"type":  "resync_done",
+		"seq":   queueState.seq,
+		"epoch": instanceID,
+	})
+	writeToClient(client, payload)
+	client.synced = true
+}
+
+// parseSince baca parameter since dari query string.
+func parseSince(raw string) (int64, bool) {
+	if raw == "" {
+		return 0, false
+	}
+	since, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || since < 0 {
+		return 0, false
+	}
+	return since, true
+}
@@ -1,10 +1,10 @@
 package handler
 
 import (
-	"backend-antrian/internal/config"
-	"database/sql"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
 	"fmt"
-	"time"
+	"sort"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -23,107 +23,72 @@ type DisplayQueueData struct {
 }
 
 // GetQueueDisplay - Public endpoint untuk display antrian
-func GetQueueDisplay(c *fiber.Ctx) error {
-	now := time.Now()
-	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour)
-
-	// Query untuk ambil semua unit & service yang aktif dengan current queue
-	query := `
-		SELECT 
-			u.id as unit_id,
-			u.nama_unit,
-			s.id as service_id,
-			s.nama_service,
-			s.code as service_code,
-			u.nama_unit as loket,
-			(
-				SELECT qt.ticket_code 
-				FROM queue_tickets qt 
-				WHERE qt.unit_id = u.id 
-				AND qt.service_id = s.id 
-				AND qt.status = 'called'
-				AND qt.created_at >= ? 
-				AND qt.created_at < ?
-				ORDER BY qt.last_called_at DESC 
-				LIMIT 1
-			) as current_ticket,
-			(
-				SELECT COUNT(*) 
-				FROM queue_tickets qt 
-				WHERE qt.unit_id = u.id 
-				AND qt.service_id = s.id 
-				AND qt.status = 'waiting'
-				AND qt.created_at >= ? 
-				AND qt.created_at < ?
-			) as total_waiting,
-			(
-				SELECT COUNT(*) 
-				FROM queue_tickets qt 
-				WHERE qt.unit_id = u.id 
-				AND qt.service_id = s.id 
-				AND qt.status IN ('called', 'done')
-				AND qt.created_at >= ? 
-				AND qt.created_at < ?
-			) as total_called_today
-		FROM units u
-		INNER JOIN services s ON s.unit_id = u.id
-		WHERE u.is_active = 'y' 
-		AND s.is_active = 'y'
-		ORDER BY u.nama_unit, s.nama_service
-	`
-
-	rows, err := config.DB.Query(query, 
-		startOfDay, endOfDay,  // current_ticket
-		startOfDay, endOfDay,  // total_waiting
-		startOfDay, endOfDay,  // total_called_today
-	)
-
-	if err != nil {
+func (h *Handler) GetQueueDisplay(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	failed := func() error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   "Gagal mengambil data display antrian",
 		})
 	}
-	defer rows.Close()
 
-	var displays []DisplayQueueData
-
-	for rows.Next() {
-		var display DisplayQueueData
-		var currentTicket sql.NullString
-		var loket string
+	// Semua unit & service yang aktif
+	units, err := h.repos.Units.List(ctx, repository.UnitFilter{IsActive: "y"})
+	if err != nil {
+		return failed()
+	}
+	services, err := h.repos.Services.List(ctx, repository.ServiceFilter{IsActive: "y"})
+	if err != nil {
+		return failed()
+	}
+	byUnit := map[int64][]models.Service{}
+	for _, s := range services {
+		byUnit[s.UnitID] = append(byUnit[s.UnitID], s)
+	}
 
-		err := rows.Scan(
-			&display.UnitID,
-			&display.UnitName,
-			&display.ServiceID,
-			&display.ServiceName,
-			&display.ServiceCode,
-			&loket,
-			&currentTicket,
-			&display.TotalWaiting,
-			&display.TotalCalledToday,
-		)
+	sort.SliceStable(units, func(i, j int) bool { return units[i].NamaUnit < units[j].NamaUnit })
 
-		if err != nil {
+	var displays []DisplayQueueData
+	for _, u := range units {
+		list := byUnit[u.ID]
+		if len(list) == 0 {
 			continue
 		}
 
-		// Set current ticket (default: CODE000)
-		if currentTicket.Valid && currentTicket.String != "" {
-			display.CurrentTicket = currentTicket.String
-			display.CurrentLoket = loket
-		} else {
-			display.CurrentTicket = fmt.Sprintf("%s000", display.ServiceCode)
-			display.CurrentLoket = ""
+		// Batas hari ini menurut zona unit
+		stats, err := h.repos.Tickets.StatsToday(ctx, u.ID, unitToday(u))
+		if err != nil {
+			return failed()
 		}
 
-		displays = append(displays, display)
+		sort.SliceStable(list, func(i, j int) bool { return list[i].NamaService < list[j].NamaService })
+		for _, s := range list {
+			st := stats[s.ID]
+			display := DisplayQueueData{
+				UnitID:           u.ID,
+				UnitName:         u.NamaUnit,
+				ServiceID:        s.ID,
+				ServiceName:      s.NamaService,
+				ServiceCode:      s.Code,
+				TotalWaiting:     st.Waiting,
+				TotalCalledToday: st.Served,
+			}
+
+			// Set current ticket (default: CODE000)
+			if st.CurrentTicket != "" {
+				display.CurrentTicket = st.CurrentTicket
+				display.CurrentLoket = u.NamaUnit
+			} else {
+				display.CurrentTicket = fmt.Sprintf("%s000", display.ServiceCode)
+				display.CurrentLoket = ""
+			}
+
+			displays = append(displays, display)
+		}
 	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
 		"data":    displays,
 	})
-}
\ No newline at end of file
+}
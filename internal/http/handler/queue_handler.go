@@ -1,8 +1,9 @@
 package handler
 
 import (
-	"backend-antrian/internal/config"
-	"database/sql"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"errors"
 	"fmt"
 
 	"github.com/gofiber/fiber/v2"
@@ -10,42 +11,49 @@ import (
 
 // CallNextQueueRequest - Request untuk panggil antrian berikutnya
 type CallNextQueueRequest struct {
-	ServiceID int64 `json:"service_id"`
+	ServiceID int64 `json:"service_id" validate:"required"`
 }
 
 // UpdateQueueStatusRequest - Request untuk update status antrian
 type UpdateQueueStatusRequest struct {
-	TicketID int64  `json:"ticket_id"`
-	Status   string `json:"status"` // done, skipped
+	TicketID int64  `json:"ticket_id" validate:"required"`
+	Status   string `json:"status" validate:"required,oneof=done skipped"`
 }
 
 // CallNextQueue - Endpoint untuk panggil antrian berikutnya
-func CallNextQueue(c *fiber.Ctx) error {
+func (h *Handler) CallNextQueue(c *fiber.Ctx) error {
+	// Ticket yang sedang called selesai (done)
+	return h.advanceQueue(c, "done", "finish", "Antrian berhasil dipanggil")
+}
+
+// SkipAndNext - Endpoint untuk skip antrian saat ini dan panggil berikutnya
+func (h *Handler) SkipAndNext(c *fiber.Ctx) error {
+	// Ticket yang sedang called di-skip
+	return h.advanceQueue(c, "skipped", "skip", "Antrian di-skip dan antrian berikutnya berhasil dipanggil")
+}
+
+// advanceQueue - tutup ticket called saat ini (status/event) lalu panggil waiting berikutnya
+func (h *Handler) advanceQueue(c *fiber.Ctx, currentStatus, currentEvent, message string) error {
 	var req CallNextQueueRequest
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   "Invalid request body",
-		})
+	if err := bindBody(c, &req); err != nil {
+		return err
 	}
 
 	// Ambil user_id dan unit_id dari JWT context
-	userID := c.Locals("user_id").(int64)
-	userUnitID, ok := c.Locals("unit_id").(int64)
+	userID, ok := c.Locals("user_id").(int64)
 	if !ok {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"success": false,
-			"error":   "User tidak memiliki unit",
-		})
+		return userOnly(c)
+	}
+	userUnitID, err := h.activeUnitID(c)
+	if err != nil {
+		return unitAccessError(c, err)
 	}
+	ctx := c.UserContext()
 
 	// Validasi: Cek apakah service_id milik unit user
-	var serviceUnitID int64
-	err := config.DB.QueryRow("SELECT unit_id FROM services WHERE id = ?", req.ServiceID).
-		Scan(&serviceUnitID)
-
-	if err == sql.ErrNoRows {
+	service, err := h.repos.Services.Get(ctx, req.ServiceID)
+	if errors.Is(err, repository.ErrNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
 			"error":   "Service tidak ditemukan",
@@ -59,32 +67,19 @@ func CallNextQueue(c *fiber.Ctx) error {
 		})
 	}
 
-	if serviceUnitID != userUnitID {
+	if service.UnitID != userUnitID {
 		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"success": false,
 			"error":   "Anda tidak memiliki akses ke service ini",
 		})
 	}
 
-	// STEP 1: CEK ANTRIAN WAITING BERIKUTNYA DULU
-	var nextTicketID int64
-	var nextTicketCode string
-	var nextUnitID int64
-
-	queryNext := `
-		SELECT id, ticket_code, unit_id 
-		FROM queue_tickets 
-		WHERE service_id = ? 
-		AND status = 'waiting'
-		AND DATE(created_at) = CURDATE()
-		ORDER BY created_at ASC 
-		LIMIT 1
-	`
-
-	err = config.DB.QueryRow(queryNext, req.ServiceID).
-		Scan(&nextTicketID, &nextTicketCode, &nextUnitID)
-
-	if err == sql.ErrNoRows {
+	// Tutup ticket called saat ini (jika ada) lalu panggil waiting berikutnya
+	// dalam satu transaksi — call-next serentak (klik ganda / dua petugas)
+	// untuk service yang sama dijalankan berurutan
+	today := h.unitTodayByID(ctx, service.UnitID)
+	res, err := h.repos.Tickets.CallNext(ctx, req.ServiceID, today, userID, currentStatus, currentEvent)
+	if errors.Is(err, repository.ErrNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
 			"error":   "Tidak ada antrian yang menunggu",
@@ -92,294 +87,99 @@ func CallNextQueue(c *fiber.Ctx) error {
 	}
 
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "Gagal mengambil antrian",
-		})
-	}
-
-	// STEP 2: UPDATE CURRENT TICKET (CALLED) JADI DONE (jika ada)
-	var currentTicketID int64
-	err = config.DB.QueryRow(`
-		SELECT id FROM queue_tickets 
-		WHERE service_id = ? AND status = 'called'
-		LIMIT 1
-	`, req.ServiceID).Scan(&currentTicketID)
-
-	if err == nil {
-		// Ada ticket yang sedang called, update jadi done
-		_, err = config.DB.Exec(`
-			UPDATE queue_tickets 
-			SET status = 'done', updated_at = NOW()
-			WHERE id = ?
-		`, currentTicketID)
-
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"success": false,
-				"error":   "Gagal mengupdate status antrian saat ini",
-			})
-		}
-
-		// Insert transaction log untuk finish
-		_, _ = config.DB.Exec(`
-			INSERT INTO queue_transactions 
-			(ticket_id, event, actor_user_id, created_at, updated_at) 
-			VALUES (?, 'finish', ?, NOW(), NOW())
-		`, currentTicketID, userID)
-	}
-
-	// STEP 3: PANGGIL ANTRIAN BERIKUTNYA
-	_, err = config.DB.Exec(`
-		UPDATE queue_tickets 
-		SET status = 'called', 
-		    last_called_at = NOW(),
-		    user_id = ?,
-		    updated_at = NOW()
-		WHERE id = ?
-	`, userID, nextTicketID)
-
-	if err != nil {
+		queueLog.ErrorContext(ctx, "call next error", "service_id", req.ServiceID, "err", err)
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   "Gagal memanggil antrian",
 		})
 	}
 
-	// Insert transaction log untuk call
-	_, err = config.DB.Exec(`
-		INSERT INTO queue_transactions 
-		(ticket_id, event, actor_user_id, created_at, updated_at) 
-		VALUES (?, 'call', ?, NOW(), NOW())
-	`, nextTicketID, userID)
+	next := res.Called
+	var changes []TicketChange
+	if res.ClosedID != 0 {
+		changes = append(changes, TicketChange{TicketID: res.ClosedID, Event: "finished"})
+	}
 
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "Gagal mencatat transaksi",
-		})
+	// Masukkan ke announcement queue — display yang memutar audio
+	if _, err := EnqueueAnnouncement(ctx, next.ID, "call"); err != nil {
+		queueLog.ErrorContext(ctx, "enqueue announcement error", "ticket_id", next.ID, "err", err)
 	}
 
-	// Broadcast update via WebSocket
-	BroadcastQueueUpdate()
+	// Kirim delta via WebSocket
+	publishQueueChange(ctx, append(changes, TicketChange{TicketID: next.ID, Event: "called"})...)
 
 	return c.JSON(fiber.Map{
 		"success": true,
-		"message": "Antrian berhasil dipanggil",
+		"message": message,
 		"data": fiber.Map{
-			"ticket_id":   nextTicketID,
-			"ticket_code": nextTicketCode,
-			"unit_id":     nextUnitID,
+			"ticket_id":   next.ID,
+			"ticket_code": next.TicketCode,
+			"unit_id":     next.UnitID,
 			"service_id":  req.ServiceID,
 		},
 	})
 }
 
-// SkipAndNext - Endpoint untuk skip antrian saat ini dan panggil berikutnya
-func SkipAndNext(c *fiber.Ctx) error {
-	var req CallNextQueueRequest
+// UpdateQueueStatus - Endpoint untuk update status antrian (done/skip)
+func (h *Handler) UpdateQueueStatus(c *fiber.Ctx) error {
+	var req UpdateQueueStatusRequest
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   "Invalid request body",
-		})
+	if err := bindBody(c, &req); err != nil {
+		return err
 	}
 
 	// Ambil user_id dan unit_id dari JWT context
-	userID := c.Locals("user_id").(int64)
-	userUnitID, ok := c.Locals("unit_id").(int64)
+	userID, ok := c.Locals("user_id").(int64)
 	if !ok {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"success": false,
-			"error":   "User tidak memiliki unit",
-		})
-	}
-
-	// Validasi: Cek apakah service_id milik unit user
-	var serviceUnitID int64
-	err := config.DB.QueryRow("SELECT unit_id FROM services WHERE id = ?", req.ServiceID).
-		Scan(&serviceUnitID)
-
-	if err == sql.ErrNoRows {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"success": false,
-			"error":   "Service tidak ditemukan",
-		})
-	}
-
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "Gagal validasi service",
-		})
-	}
-
-	if serviceUnitID != userUnitID {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"success": false,
-			"error":   "Anda tidak memiliki akses ke service ini",
-		})
-	}
-
-	// STEP 1: CEK ANTRIAN WAITING BERIKUTNYA DULU
-	var nextTicketID int64
-	var nextTicketCode string
-	var nextUnitID int64
-
-	queryNext := `
-		SELECT id, ticket_code, unit_id 
-		FROM queue_tickets 
-		WHERE service_id = ? 
-		AND status = 'waiting'
-		AND DATE(created_at) = CURDATE()
-		ORDER BY created_at ASC 
-		LIMIT 1
-	`
-
-	err = config.DB.QueryRow(queryNext, req.ServiceID).
-		Scan(&nextTicketID, &nextTicketCode, &nextUnitID)
-
-	if err == sql.ErrNoRows {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"success": false,
-			"error":   "Tidak ada antrian yang menunggu",
-		})
+		return userOnly(c)
 	}
-
+	userUnitID, err := h.activeUnitID(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "Gagal mengambil antrian",
-		})
+		return unitAccessError(c, err)
 	}
+	ctx := c.UserContext()
 
-	// STEP 2: UPDATE CURRENT TICKET (CALLED) JADI SKIPPED (jika ada)
-	var currentTicketID int64
-	err = config.DB.QueryRow(`
-		SELECT id FROM queue_tickets 
-		WHERE service_id = ? AND status = 'called'
-		LIMIT 1
-	`, req.ServiceID).Scan(&currentTicketID)
-
+	// Cek apakah ticket ada; unit ticket mengikuti unit service-nya
+	var ticketUnitID int64
+	ticket, err := h.repos.Tickets.Get(ctx, req.TicketID)
 	if err == nil {
-		// Ada ticket yang sedang called, update jadi skipped
-		_, err = config.DB.Exec(`
-			UPDATE queue_tickets 
-			SET status = 'skipped', updated_at = NOW()
-			WHERE id = ?
-		`, currentTicketID)
-
-		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"success": false,
-				"error":   "Gagal mengupdate status antrian saat ini",
-			})
-		}
-
-		// Insert transaction log untuk skip
-		_, _ = config.DB.Exec(`
-			INSERT INTO queue_transactions 
-			(ticket_id, event, actor_user_id, created_at, updated_at) 
-			VALUES (?, 'skip', ?, NOW(), NOW())
-		`, currentTicketID, userID)
+		var service models.Service
+		service, err = h.repos.Services.Get(ctx, ticket.ServiceID)
+		ticketUnitID = service.UnitID
 	}
 
-	// STEP 3: PANGGIL ANTRIAN BERIKUTNYA
-	_, err = config.DB.Exec(`
-		UPDATE queue_tickets 
-		SET status = 'called', 
-		    last_called_at = NOW(),
-		    user_id = ?,
-		    updated_at = NOW()
-		WHERE id = ?
-	`, userID, nextTicketID)
-
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
-			"error":   "Gagal memanggil antrian",
+			"error":   "Ticket tidak ditemukan",
 		})
 	}
 
-	// Insert transaction log untuk call
-	_, err = config.DB.Exec(`
-		INSERT INTO queue_transactions 
-		(ticket_id, event, actor_user_id, created_at, updated_at) 
-		VALUES (?, 'call', ?, NOW(), NOW())
-	`, nextTicketID, userID)
-
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
-			"error":   "Gagal mencatat transaksi",
-		})
-	}
-
-	// Broadcast update via WebSocket
-	BroadcastQueueUpdate()
-
-	return c.JSON(fiber.Map{
-		"success": true,
-		"message": "Antrian di-skip dan antrian berikutnya berhasil dipanggil",
-		"data": fiber.Map{
-			"ticket_id":   nextTicketID,
-			"ticket_code": nextTicketCode,
-			"unit_id":     nextUnitID,
-			"service_id":  req.ServiceID,
-		},
-	})
-}
-
-// UpdateQueueStatus - Endpoint untuk update status antrian (done/skip)
-func UpdateQueueStatus(c *fiber.Ctx) error {
-	var req UpdateQueueStatusRequest
-
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   "Invalid request body",
-		})
-	}
-
-	// Validasi status
-	if req.Status != "done" && req.Status != "skipped" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   "Status harus 'done' atau 'skipped'",
+			"error":   "Gagal mengambil data ticket",
 		})
 	}
 
-	// Ambil user_id dari JWT context
-	userID := c.Locals("user_id").(int64)
-
-	// Cek apakah ticket ada dan statusnya 'called'
-	var currentStatus string
-	err := config.DB.QueryRow("SELECT status FROM queue_tickets WHERE id = ?", req.TicketID).
-		Scan(&currentStatus)
-
-	if err == sql.ErrNoRows {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+	// Validasi: Cek apakah ticket milik unit user
+	if ticketUnitID != userUnitID {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"success": false,
-			"error":   "Ticket tidak ditemukan",
+			"error":   "Anda tidak memiliki akses ke ticket ini",
 		})
 	}
 
-	if currentStatus != "called" {
+	// Hanya ticket berstatus 'called' yang bisa diselesaikan / di-skip
+	if ticket.Status != "called" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
-			"error":   fmt.Sprintf("Ticket tidak bisa diubah. Status saat ini: %s", currentStatus),
+			"error":   fmt.Sprintf("Ticket tidak bisa diubah. Status saat ini: %s", ticket.Status),
 		})
 	}
 
 	// Update status
-	_, err = config.DB.Exec(`
-		UPDATE queue_tickets 
-		SET status = ?, updated_at = NOW()
-		WHERE id = ?
-	`, req.Status, req.TicketID)
-
-	if err != nil {
+	if err := h.repos.Tickets.SetStatus(ctx, req.TicketID, req.Status); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   "Gagal mengupdate status",
@@ -392,21 +192,15 @@ func UpdateQueueStatus(c *fiber.Ctx) error {
 		event = "skip"
 	}
 
-	_, err = config.DB.Exec(`
-		INSERT INTO queue_transactions 
-		(ticket_id, event, actor_user_id, created_at, updated_at) 
-		VALUES (?, ?, ?, NOW(), NOW())
-	`, req.TicketID, event, userID)
-
-	if err != nil {
+	if err := h.repos.Tickets.AddTransaction(ctx, req.TicketID, event, &userID); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   "Gagal mencatat transaksi",
 		})
 	}
 
-	// Broadcast update via WebSocket
-	BroadcastQueueUpdate()
+	// Kirim delta via WebSocket
+	publishQueueChange(c.UserContext(), TicketChange{TicketID: req.TicketID, Event: "finished"})
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -415,30 +209,30 @@ func UpdateQueueStatus(c *fiber.Ctx) error {
 }
 
 // RecallQueue - Endpoint untuk recall antrian (bisa dari status skipped, done, atau called)
-func RecallQueue(c *fiber.Ctx) error {
-	ticketID := c.Params("id")
+func (h *Handler) RecallQueue(c *fiber.Ctx) error {
+	ticketID := paramID(c, "id")
 
 	// Ambil user_id dan unit_id dari JWT context
-	userID := c.Locals("user_id").(int64)
-	userUnitID, ok := c.Locals("unit_id").(int64)
+	userID, ok := c.Locals("user_id").(int64)
 	if !ok {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"success": false,
-			"error":   "User tidak memiliki unit",
-		})
+		return userOnly(c)
+	}
+	userUnitID, err := h.activeUnitID(c)
+	if err != nil {
+		return unitAccessError(c, err)
 	}
+	ctx := c.UserContext()
 
-	// Cek apakah ticket ada dan validasi unit_id
-	var currentStatus string
+	// Cek apakah ticket ada; unit ticket mengikuti unit service-nya
 	var ticketUnitID int64
-	err := config.DB.QueryRow(`
-		SELECT qt.status, s.unit_id 
-		FROM queue_tickets qt
-		JOIN services s ON qt.service_id = s.id
-		WHERE qt.id = ?
-	`, ticketID).Scan(&currentStatus, &ticketUnitID)
-
-	if err == sql.ErrNoRows {
+	ticket, err := h.repos.Tickets.Get(ctx, ticketID)
+	if err == nil {
+		var service models.Service
+		service, err = h.repos.Services.Get(ctx, ticket.ServiceID)
+		ticketUnitID = service.UnitID
+	}
+
+	if errors.Is(err, repository.ErrNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
 			"error":   "Ticket tidak ditemukan",
@@ -461,7 +255,7 @@ func RecallQueue(c *fiber.Ctx) error {
 	}
 
 	// Validasi: tidak bisa recall jika sudah waiting
-	if currentStatus == "waiting" {
+	if ticket.Status == "waiting" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
 			"error":   "Ticket sudah dalam status waiting",
@@ -469,14 +263,7 @@ func RecallQueue(c *fiber.Ctx) error {
 	}
 
 	// Update status jadi waiting
-	_, err = config.DB.Exec(`
-		UPDATE queue_tickets
-		SET status = 'waiting',
-			updated_at = NOW()
-		WHERE id = ?
-	`, ticketID)
-
-	if err != nil {
+	if err := h.repos.Tickets.SetStatus(ctx, ticketID, "waiting"); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   "Gagal recall antrian",
@@ -484,24 +271,18 @@ func RecallQueue(c *fiber.Ctx) error {
 	}
 
 	// Insert transaction log
-	_, err = config.DB.Exec(`
-		INSERT INTO queue_transactions 
-		(ticket_id, event, actor_user_id, created_at, updated_at) 
-		VALUES (?, 'recall', ?, NOW(), NOW())
-	`, ticketID, userID)
-
-	if err != nil {
+	if err := h.repos.Tickets.AddTransaction(ctx, ticketID, "recall", &userID); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   "Gagal mencatat transaksi",
 		})
 	}
 
-	// Broadcast update via WebSocket
-	BroadcastQueueUpdate()
+	// Kirim delta via WebSocket
+	publishQueueChange(c.UserContext(), TicketChange{TicketID: ticketID, Event: "recalled"})
 
 	return c.JSON(fiber.Map{
 		"success": true,
-		"message": fmt.Sprintf("Antrian berhasil di-recall dari status '%s' dan masuk ke antrian kembali", currentStatus),
+		"message": fmt.Sprintf("Antrian berhasil di-recall dari status '%s' dan masuk ke antrian kembali", ticket.Status),
 	})
-}
\ No newline at end of file
+}
@@ -1,11 +1,14 @@
 package handler
 
 import (
-	"backend-antrian/internal/config"
-	"database/sql"
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/realtime"
+	"backend-antrian/internal/repository"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"log"
 	"regexp"
 	"sort"
 	"strconv"
@@ -33,9 +36,11 @@ type QueueData struct {
 	ServiceCode     string   `json:"service_code"`
 	Loket           string   `json:"loket"`
 	Status          string   `json:"status"`
-	ShouldPlayAudio bool     `json:"should_play_audio"`
 	AudioPaths      []string `json:"audio_paths"`
 	LastCalledAt    *string  `json:"last_called_at"`
+	ShouldPlayAudio bool     `json:"should_play_audio"`
+
+	mainDisplay bool // unit tampil di display utama (units.main_display)
 }
 
 type ServiceStats struct {
@@ -56,6 +61,16 @@ type ClientInfo struct {
 	closed       bool
 	lastPongTime time.Time
 	id           string
+	display      atomic.Pointer[DisplayProfile] // nil = display anonim
+
+	// Info heartbeat display — hanya diakses dari goroutine read loop
+	remoteIP           string
+	appVersion         string
+	lastHeartbeatSaved time.Time
+
+	// Protokol delta — diakses di bawah queueState.mu
+	deltas bool // client minta pesan delta (?protocol=delta atau ?since=)
+	synced bool // sudah menerima snapshot awal / replay
 }
 
 var (
@@ -64,15 +79,15 @@ var (
 	clientCounter  uint64 // atomic
 	cleanupRunning bool
 
+	// instanceID bikin client ID unik antar restart proses — dipakai sebagai
+	// pemegang lease di queue_announcements
+	instanceID = newInstanceID()
+
 	// Debounce broadcast — cegah burst DB query
 	broadcastTimer   *time.Timer
 	broadcastTimerMu sync.Mutex
 	broadcastDelay   = 50 * time.Millisecond
 
-	// Cache last broadcast — valid selama masih hari yang sama
-	lastBroadcastMsg   []byte
-	lastBroadcastTime  time.Time
-	lastBroadcastMsgMu sync.RWMutex
 )
 
 /*
@@ -81,9 +96,9 @@ var (
 |--------------------------------------------------------------------------
 */
 
-func QueueWebSocket(c *websocket.Conn) {
+func (h *Handler) QueueWebSocket(c *websocket.Conn) {
 	id := atomic.AddUint64(&clientCounter, 1)
-	clientID := fmt.Sprintf("client-%d", id)
+	clientID := fmt.Sprintf("client-%s-%d", instanceID, id)
 
 	client := &ClientInfo{
 		conn:         c,
@@ -91,12 +106,41 @@ func QueueWebSocket(c *websocket.Conn) {
 		closed:       false,
 		lastPongTime: time.Now(),
 		id:           clientID,
+		remoteIP:     clientIP(c),
 	}
+	if v := strings.TrimSpace(c.Query("app_version")); len(v) <= 50 {
+		client.appVersion = v
+	}
+
+	since, hasSince := parseSince(c.Query("since"))
+	client.deltas = c.Query("protocol") == "delta" || hasSince
 
-	log.Printf("[queue] %s connecting from %s", clientID, c.RemoteAddr())
+	// Autentikasi display: /ws/queue?device_id=...&token=...
+	deviceID := c.Query("device_id")
+	if deviceID != "" || !displayAnonymousAllowed() {
+		profile, err := h.authenticateDisplay(context.Background(), deviceID, c.Query("token"))
+		if err != nil {
+			queueLog.Info("client rejected", "client", clientID, "remote", c.RemoteAddr().String(), "err", err)
+			payload, _ := json.Marshal(map[string]interface{}{
+				"type":  "error",
+				"error": err.Error(),
+			})
+			writeToClient(client, payload)
+			closeClient(client, websocket.ClosePolicyViolation, "unauthorized")
+			return
+		}
+		client.display.Store(profile)
+		clientID = fmt.Sprintf("%s-display-%d", clientID, profile.ID)
+		client.id = clientID
+	}
+
+	queueLog.Info("client connecting", "client", clientID, "remote", c.RemoteAddr().String(), "request_id", c.Locals("request_id"))
 	registerClient(c, client)
 	defer unregisterClient(c, clientID)
 
+	h.recordDisplayConnect(client)
+	defer h.recordDisplayDisconnect(client)
+
 	// Ping/pong handler
 	c.SetReadDeadline(time.Now().Add(60 * time.Second))
 	c.SetPongHandler(func(string) error {
@@ -104,13 +148,19 @@ func QueueWebSocket(c *websocket.Conn) {
 		client.lastPongTime = time.Now()
 		client.writeMux.Unlock()
 		c.SetReadDeadline(time.Now().Add(60 * time.Second))
+		h.recordDisplayHeartbeat(client)
 		return nil
 	})
 
-	// Kirim data awal ke client ini saja — selalu fresh dari DB
+	// Kirim config display & data awal ke client ini saja
 	go func() {
 		time.Sleep(100 * time.Millisecond)
-		sendToClient(client)
+		sendDisplayConfig(client)
+		if hasSince {
+			h.resyncClient(client, since, c.Query("epoch"))
+		} else {
+			h.sendToClient(client)
+		}
 	}()
 
 	// Ping ticker setiap 20 detik
@@ -131,7 +181,7 @@ func QueueWebSocket(c *websocket.Conn) {
 				client.writeMux.Unlock()
 
 				if err != nil {
-					log.Printf("[queue] %s ping error: %v", clientID, err)
+					queueLog.Debug("ping error", "client", clientID, "err", err)
 					return
 				}
 			case <-client.closeChan:
@@ -140,40 +190,57 @@ func QueueWebSocket(c *websocket.Conn) {
 		}
 	}()
 
-	// Read loop
+	// Read loop — pesan dari display (pull/ack pengumuman, ack perintah)
 	for {
-		if _, _, err := c.ReadMessage(); err != nil {
+		_, msg, err := c.ReadMessage()
+		if err != nil {
 			if websocket.IsUnexpectedCloseError(err,
 				websocket.CloseGoingAway,
 				websocket.CloseAbnormalClosure,
 				websocket.CloseNormalClosure,
 			) {
-				log.Printf("[queue] %s unexpected close: %v", clientID, err)
+				queueLog.Warn("unexpected close", "client", clientID, "err", err)
 			} else {
-				log.Printf("[queue] %s closed normally", clientID)
+				queueLog.Debug("closed normally", "client", clientID)
 			}
 			return
 		}
+		h.handleClientMessage(client, msg)
 	}
 }
 
-// BroadcastQueueUpdate dipanggil dari luar.
-// Pakai debounce 50ms — burst 10 event tetap 1x query DB.
+// BroadcastQueueUpdate dipanggil dari luar — minta semua replica rebuild
+// snapshot penuh (perubahan unit/layanan/audio, bukan perubahan ticket).
 func BroadcastQueueUpdate() {
+	publishRealtime(context.Background(), realtime.ChannelQueueRefresh, nil)
+}
+
+// scheduleQueueRebuild rebuild & broadcast snapshot ke client lokal.
+// Pakai debounce 50ms — burst 10 event tetap 1x query DB.
+func (h *Handler) scheduleQueueRebuild() {
 	broadcastTimerMu.Lock()
 	defer broadcastTimerMu.Unlock()
 
+	if shuttingDown.Load() {
+		return
+	}
 	if broadcastTimer != nil {
-		broadcastTimer.Reset(broadcastDelay)
+		// Reset timer yang sudah fire menjadwalkan callback sekali lagi
+		if !broadcastTimer.Reset(broadcastDelay) {
+			pendingBroadcasts.Add(1)
+		}
 		return
 	}
 
+	pendingBroadcasts.Add(1)
 	broadcastTimer = time.AfterFunc(broadcastDelay, func() {
+		defer pendingBroadcasts.Done()
+
 		broadcastTimerMu.Lock()
 		broadcastTimer = nil
 		broadcastTimerMu.Unlock()
 
-		broadcastQueueData()
+		h.broadcastQueueData()
 	})
 }
 
@@ -193,7 +260,7 @@ func registerClient(c *websocket.Conn, client *ClientInfo) {
 	}
 	queueMutex.Unlock()
 
-	log.Printf("[queue] %s registered, total: %d", client.id, totalClients)
+	queueLog.Info("client registered", "client", client.id, "total", totalClients)
 
 	if startCleanup {
 		go periodicCleanup()
@@ -216,10 +283,12 @@ func unregisterClient(c *websocket.Conn, clientID string) {
 	queueMutex.Unlock()
 
 	_ = c.Close()
-	log.Printf("[queue] %s unregistered, total: %d", clientID, totalClients)
+	queueLog.Info("client unregistered", "client", clientID, "total", totalClients)
+
+	releaseClientAnnouncements(clientID)
 }
 
-// periodicCleanup hapus dead connections setiap 30 detik.
+// periodicCleanup hentikan dead connections setiap 30 detik.
 func periodicCleanup() {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
@@ -229,7 +298,7 @@ func periodicCleanup() {
 		if len(queueClients) == 0 {
 			cleanupRunning = false
 			queueMutex.Unlock()
-			log.Println("[queue] No clients, stopping cleanup goroutine")
+			queueLog.Debug("no clients, stopping cleanup goroutine")
 			return
 		}
 		queueMutex.Unlock()
@@ -244,7 +313,7 @@ func periodicCleanup() {
 			client.writeMux.Unlock()
 
 			if stale {
-				log.Printf("[queue] %s dead (no pong), marking for removal", client.id)
+				queueLog.Info("client dead (no pong), marking for removal", "client", client.id)
 				toRemove = append(toRemove, conn)
 			}
 		}
@@ -254,22 +323,20 @@ func periodicCleanup() {
 			continue
 		}
 
-		queueMutex.Lock()
+		// Read loop masing-masing client yang unregister & menutup koneksi
+		queueMutex.RLock()
 		for _, conn := range toRemove {
 			if client, exists := queueClients[conn]; exists {
 				client.writeMux.Lock()
 				if !client.closed {
-					client.closed = true
-					close(client.closeChan)
+					stopClientLocked(client)
 				}
 				client.writeMux.Unlock()
-				delete(queueClients, conn)
-				conn.Close()
-				log.Printf("[queue] %s cleaned up", client.id)
+				queueLog.Debug("client stopped", "client", client.id)
 			}
 		}
-		log.Printf("[queue] Cleaned %d dead clients, remaining: %d", len(toRemove), len(queueClients))
-		queueMutex.Unlock()
+		queueMutex.RUnlock()
+		queueLog.Info("stopped dead clients", "count", len(toRemove))
 	}
 }
 
@@ -279,68 +346,158 @@ func periodicCleanup() {
 |--------------------------------------------------------------------------
 */
 
-// buildMessage query DB dan marshal payload — dipakai broadcast & initial data.
-func buildMessage() ([]byte, error) {
-	queues, err := getQueueData()
+// queueSnapshot state antrian semua unit — difilter per display saat dikirim.
+// Tidak pernah diubah setelah dibuat; delta membuat salinan baru.
+type queueSnapshot struct {
+	Seq          int64
+	Queues       []QueueData
+	ServiceStats map[int64]ServiceStats
+	CreatedAt    time.Time
+}
+
+// buildSnapshot query DB sekali — dipakai broadcast & initial data.
+func (h *Handler) buildSnapshot() (*queueSnapshot, error) {
+	defer observeSince(snapshotBuildDuration, time.Now())
+
+	queues, err := h.getQueueData(0)
 	if err != nil {
 		return nil, fmt.Errorf("getQueueData: %w", err)
 	}
 
 	sortQueueData(queues)
-	currentlyPlaying := findCurrentlyPlaying(queues)
-	serviceStats := calculateServiceStats()
+
+	return &queueSnapshot{
+		Queues:       queues,
+		ServiceStats: h.calculateServiceStats(0),
+		CreatedAt:    clock.Now(),
+	}, nil
+}
+
+// messageFor marshal payload queue_update sesuai scope display.
+// profile nil (display anonim) menerima semua data plus flag lama
+// should_play_audio, karena belum bisa menarik announcement queue.
+func (s *queueSnapshot) messageFor(profile *DisplayProfile) ([]byte, error) {
+	queues := s.Queues
+	serviceStats := s.ServiceStats
+
+	if profile == nil {
+		queues = make([]QueueData, len(s.Queues))
+		for i, q := range s.Queues {
+			q.ShouldPlayAudio = q.mainDisplay && q.Status == "called" && q.LastCalledAt != nil
+			queues[i] = q
+		}
+	}
+
+	if profile != nil && (len(profile.UnitIDs) > 0 || len(profile.ServiceIDs) > 0) {
+		queues = make([]QueueData, 0, len(s.Queues))
+		for _, q := range s.Queues {
+			if profile.Allows(q.UnitID, q.ServiceID) {
+				queues = append(queues, q)
+			}
+		}
+
+		serviceStats = make(map[int64]ServiceStats)
+		for _, q := range queues {
+			if stat, ok := s.ServiceStats[q.ServiceID]; ok {
+				serviceStats[q.ServiceID] = stat
+			}
+		}
+	}
 
 	payload := map[string]interface{}{
 		"type":              "queue_update",
+		"seq":               s.Seq,
+		"epoch":             instanceID,
 		"data":              queues,
-		"currently_playing": currentlyPlaying,
+		"currently_playing": findCurrentlyPlaying(queues),
 		"service_stats":     serviceStats,
-		"timestamp":         time.Now().Format(time.RFC3339),
+		"timestamp":         s.CreatedAt.Format(time.RFC3339),
 	}
 
 	return json.Marshal(payload)
 }
 
-// sendToClient kirim data ke satu client baru.
+// sendToClient kirim snapshot penuh ke satu client (initial data / resync).
 // Pakai cache kalau masih hari yang sama, query DB kalau beda hari atau cache kosong.
-func sendToClient(client *ClientInfo) {
-	lastBroadcastMsgMu.RLock()
-	cached := lastBroadcastMsg
-	cacheTime := lastBroadcastTime
-	lastBroadcastMsgMu.RUnlock()
-
-	now := time.Now()
-	cacheValid := len(cached) > 0 &&
-		now.Format("2006-01-02") == cacheTime.Format("2006-01-02")
-
-	if cacheValid {
-		writeToClient(client, cached)
-		return
+func (h *Handler) sendToClient(client *ClientInfo) {
+	queueState.mu.Lock()
+	defer queueState.mu.Unlock()
+
+	if !queueState.snapshotFresh(h) {
+		// Cache kosong atau beda hari — query DB fresh
+		if err := queueState.rebuild(h); err != nil {
+			queueLog.Error("send snapshot error", "client", client.id, "err", err)
+			return
+		}
 	}
 
-	// Cache kosong atau beda hari — query DB fresh
-	message, err := buildMessage()
+	sendSnapshotLocked(client, queueState.snapshot)
+}
+
+// sendSnapshotLocked kirim snapshot ke client. Caller memegang queueState.mu.
+func sendSnapshotLocked(client *ClientInfo, snapshot *queueSnapshot) {
+	message, err := snapshot.messageFor(client.display.Load())
 	if err != nil {
-		log.Printf("[queue] sendToClient error: %v", err)
+		queueLog.Error("marshal snapshot error", "client", client.id, "err", err)
 		return
 	}
 	writeToClient(client, message)
+	client.synced = true
 }
 
-// broadcastQueueData kirim ke semua client yang terhubung.
-func broadcastQueueData() {
-	message, err := buildMessage()
-	if err != nil {
-		log.Printf("[queue] broadcastQueueData error: %v", err)
+// broadcastQueueData rebuild snapshot dari DB lalu kirim ke semua client.
+// Payload di-marshal sekali per display, bukan per koneksi.
+func (h *Handler) broadcastQueueData() {
+	queueState.mu.Lock()
+	defer queueState.mu.Unlock()
+
+	if err := queueState.rebuild(h); err != nil {
+		queueLog.Error("broadcast snapshot error", "err", err)
 		return
 	}
 
-	// Update cache
-	lastBroadcastMsgMu.Lock()
-	lastBroadcastMsg = message
-	lastBroadcastTime = time.Now()
-	lastBroadcastMsgMu.Unlock()
+	broadcastSnapshotLocked(queueState.snapshot, nil)
+}
+
+// broadcastSnapshotLocked kirim snapshot ke client yang lolos filter (nil = semua).
+// Caller memegang queueState.mu.
+func broadcastSnapshotLocked(snapshot *queueSnapshot, filter func(*ClientInfo) bool) {
+	messages := make(map[int64][]byte) // key display ID, 0 = anonim
+	broadcastEach("queue_snapshot", func(client *ClientInfo) []byte {
+		if filter != nil && !filter(client) {
+			return nil
+		}
+
+		profile := client.display.Load()
+		var key int64
+		if profile != nil {
+			key = profile.ID
+		}
+		client.synced = true
 
+		// Dipanggil dari satu goroutine (lihat broadcastEach) — map aman
+		if msg, ok := messages[key]; ok {
+			return msg
+		}
+		msg, err := snapshot.messageFor(profile)
+		if err != nil {
+			queueLog.Error("marshal snapshot error", "client", client.id, "err", err)
+			return nil
+		}
+		messages[key] = msg
+		return msg
+	})
+}
+
+// broadcastToClients kirim message yang sama ke semua client yang terhubung.
+func broadcastToClients(message []byte) {
+	broadcastEach("queue", func(*ClientInfo) []byte { return message })
+}
+
+// broadcastEach kirim message hasil messageFn ke setiap client.
+// messageFn dipanggil berurutan; nil artinya client dilewati.
+// kind label metrik broadcast (queue_snapshot, queue_delta, announcement).
+func broadcastEach(kind string, messageFn func(*ClientInfo) []byte) int {
 	// Snapshot clients
 	queueMutex.RLock()
 	clients := make([]*ClientInfo, 0, len(queueClients))
@@ -350,28 +507,43 @@ func broadcastQueueData() {
 	queueMutex.RUnlock()
 
 	if len(clients) == 0 {
-		return
+		return 0
 	}
 
+	start := time.Now()
+	defer func() {
+		realtime.BroadcastDuration.Observe(time.Since(start).Seconds(), kind)
+	}()
+
 	// Worker pool max 20 goroutine
 	const maxWorkers = 20
 	sem := make(chan struct{}, maxWorkers)
 	var wg sync.WaitGroup
+	sent := 0
 
 	for _, client := range clients {
+		message := messageFn(client)
+		if message == nil {
+			continue
+		}
+
+		sent++
+		realtime.BroadcastBytes.Observe(float64(len(message)), kind)
 		wg.Add(1)
 		sem <- struct{}{}
-		go func(c *ClientInfo) {
+		go func(c *ClientInfo, msg []byte) {
 			defer wg.Done()
 			defer func() { <-sem }()
-			writeToClient(c, message)
-		}(client)
+			writeToClient(c, msg)
+		}(client, message)
 	}
 
 	wg.Wait()
+	return sent
 }
 
-// writeToClient kirim message ke satu client, handle error & cleanup.
+// writeToClient kirim message ke satu client. Gagal tulis hanya menandai
+// client tertutup — handler yang menghapus & menutup koneksi.
 func writeToClient(c *ClientInfo, message []byte) {
 	c.writeMux.Lock()
 	defer c.writeMux.Unlock()
@@ -382,24 +554,22 @@ func writeToClient(c *ClientInfo, message []byte) {
 
 	c.conn.SetWriteDeadline(time.Now().Add(3 * time.Second))
 	if err := c.conn.WriteMessage(websocket.TextMessage, message); err != nil {
-		log.Printf("[queue] %s write error: %v", c.id, err)
-		c.closed = true
-		select {
-		case <-c.closeChan:
-		default:
-			close(c.closeChan)
-		}
-
-		go func(conn *websocket.Conn, id string) {
-			queueMutex.Lock()
-			delete(queueClients, conn)
-			queueMutex.Unlock()
-			conn.Close()
-			log.Printf("[queue] %s removed after write error", id)
-		}(c.conn, c.id)
+		queueLog.Info("write error", "client", c.id, "err", err)
+		stopClientLocked(c)
 	}
 }
 
+// stopClientLocked tandai client tertutup lalu bangunkan read loop lewat
+// read deadline. Koneksi tidak ditutup di sini: setelah handler return,
+// fiber bisa memakai ulang *websocket.Conn, jadi hanya read loop /
+// unregisterClient yang menghapus dan menutupnya. Caller memegang c.writeMux;
+// selama closed masih false handler belum melewati unregisterClient.
+func stopClientLocked(c *ClientInfo) {
+	c.closed = true
+	close(c.closeChan)
+	c.conn.SetReadDeadline(time.Now())
+}
+
 func findCurrentlyPlaying(queues []QueueData) *QueueData {
 	var latest *QueueData
 	var latestTime time.Time
@@ -435,151 +605,80 @@ func sortQueueData(queues []QueueData) {
 |--------------------------------------------------------------------------
 */
 
-func getQueueData() ([]QueueData, error) {
-	query := `
-		SELECT 
-			s.id as service_id,
-			s.nama_service,
-			s.code as service_code,
-			s.unit_id,
-			u.nama_unit,
-			u.main_display,
-			u.audio_file,
-			COALESCE(qt.id, 0) as ticket_id,
-			COALESCE(qt.ticket_code, '-') as ticket_code,
-			COALESCE(qt.status, 'waiting') as status,
-			qt.last_called_at
-		FROM services s
-		JOIN units u ON s.unit_id = u.id
-		LEFT JOIN (
-			SELECT qt1.* 
-			FROM queue_tickets qt1
-			INNER JOIN (
-				SELECT service_id, MAX(last_called_at) as max_called
-				FROM queue_tickets
-				WHERE last_called_at IS NOT NULL
-				  AND DATE(created_at) = CURDATE()
-				GROUP BY service_id
-			) qt2 ON qt1.service_id = qt2.service_id 
-				 AND qt1.last_called_at = qt2.max_called
-			
-			UNION ALL
-			
-			SELECT qt3.* 
-			FROM queue_tickets qt3
-			INNER JOIN (
-				SELECT service_id, MIN(created_at) as min_created
-				FROM queue_tickets
-				WHERE status = 'waiting' 
-				  AND DATE(created_at) = CURDATE()
-				GROUP BY service_id
-			) qt4 ON qt3.service_id = qt4.service_id 
-				 AND qt3.created_at = qt4.min_created
-			WHERE qt3.status = 'waiting'
-		) qt ON s.id = qt.service_id
-		WHERE s.is_active = 'y'
-		ORDER BY u.nama_unit ASC, qt.id DESC
-	`
-
-	rows, err := config.DB.Query(query)
+// getQueueData ambil ticket terakhir dipanggil + ticket waiting tertua per layanan.
+// serviceID > 0 membatasi ke satu layanan (dipakai delta).
+func (h *Handler) getQueueData(serviceID int64) ([]QueueData, error) {
+	ctx := context.Background()
+	rows, err := h.repos.Tickets.DisplayRows(ctx, h.today(ctx), serviceID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
 	var result []QueueData
-	for rows.Next() {
-		queue, err := scanQueueRow(rows)
-		if err != nil {
-			log.Printf("[queue] scan error: %v", err)
-			continue
-		}
-		result = append(result, queue)
+	for _, row := range rows {
+		result = append(result, queueRow(row))
 	}
-
 	return result, nil
 }
 
-func scanQueueRow(rows *sql.Rows) (QueueData, error) {
-	var (
-		q           QueueData
-		mainDisplay string
-		audioFile   sql.NullString
-		lastCalled  sql.NullTime
-	)
-
-	err := rows.Scan(
-		&q.ServiceID,
-		&q.ServiceName,
-		&q.ServiceCode,
-		&q.UnitID,
-		&q.UnitName,
-		&mainDisplay,
-		&audioFile,
-		&q.ID,
-		&q.TicketCode,
-		&q.Status,
-		&lastCalled,
-	)
-	if err != nil {
-		return q, err
-	}
-
-	q.Loket = q.UnitName
-
-	if lastCalled.Valid {
-		t := lastCalled.Time.Format("2006-01-02 15:04:05")
+func queueRow(row repository.DisplayRow) QueueData {
+	q := QueueData{
+		ID:          row.TicketID,
+		TicketCode:  row.TicketCode,
+		UnitID:      row.UnitID,
+		UnitName:    row.UnitName,
+		ServiceID:   row.ServiceID,
+		ServiceName: row.ServiceName,
+		ServiceCode: row.ServiceCode,
+		Loket:       row.UnitName,
+		Status:      row.Status,
+		mainDisplay: row.MainDisplay == "active",
+	}
+
+	if row.LastCalledAt != nil {
+		t := row.LastCalledAt.Format("2006-01-02 15:04:05")
 		q.LastCalledAt = &t
 	}
 
-	q.ShouldPlayAudio = mainDisplay == "active" &&
-		q.Status == "called" &&
-		q.LastCalledAt != nil
-
+	// Display terpasang menarik audio dari announcement queue
+	// (lihat queue_announcement.go); should_play_audio hanya diisi
+	// messageFor untuk display anonim.
 	audioFileName := ""
-	if audioFile.Valid {
-		audioFileName = audioFile.String
+	if row.AudioFile != nil {
+		audioFileName = *row.AudioFile
 	}
 	q.AudioPaths = generateAudioPaths(q.TicketCode, audioFileName)
 
-	return q, nil
+	return q
 }
 
-func calculateServiceStats() map[int64]ServiceStats {
-	query := `
-		SELECT 
-			service_id,
-			COUNT(*) as waiting_count
-		FROM queue_tickets
-		WHERE status = 'waiting'
-		  AND DATE(created_at) = CURDATE()
-		GROUP BY service_id
-	`
-
-	rows, err := config.DB.Query(query)
+// calculateServiceStats jumlah waiting hari ini per layanan (serviceID > 0 untuk satu layanan).
+func (h *Handler) calculateServiceStats(serviceID int64) map[int64]ServiceStats {
+	ctx := context.Background()
+	counts, err := h.repos.Tickets.WaitingToday(ctx, h.today(ctx), serviceID)
 	if err != nil {
-		log.Printf("[queue] failed to calculate service stats: %v", err)
+		queueLog.Error("calculate service stats error", "err", err)
 		return make(map[int64]ServiceStats)
 	}
-	defer rows.Close()
 
 	stats := make(map[int64]ServiceStats)
-	for rows.Next() {
-		var serviceID int64
-		var count int
-		if err := rows.Scan(&serviceID, &count); err != nil {
-			log.Printf("[queue] scan error in service stats: %v", err)
-			continue
-		}
-		stats[serviceID] = ServiceStats{
+	for id, count := range counts {
+		stats[id] = ServiceStats{
 			WaitingCount: count,
 			HasNext:      count > 0,
 		}
 	}
-
 	return stats
 }
 
+func newInstanceID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return hex.EncodeToString(b)
+}
+
 func extractNumber(ticketCode string) int {
 	re := regexp.MustCompile(`\d+`)
 	match := re.FindString(ticketCode)
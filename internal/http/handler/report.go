@@ -1,10 +1,14 @@
 package handler
 
 import (
-	"backend-antrian/internal/config"
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -26,7 +30,7 @@ type ServiceByUnit struct {
 }
 
 // ExportVisitorReport generates and downloads visitor report in Excel format
-func ExportVisitorReport(c *fiber.Ctx) error {
+func (h *Handler) ExportVisitorReport(c *fiber.Ctx) error {
 	// Parse query parameters
 	startDateStr := c.Query("start_date")
 	endDateStr := c.Query("end_date")
@@ -62,7 +66,7 @@ func ExportVisitorReport(c *fiber.Ctx) error {
 	dateColumns := generateDateColumns(startDate, endDate)
 
 	// Get unit report data
-	unitReportData, err := getUnitReportData(startDate, endDate, dateColumns)
+	unitReportData, err := h.getUnitReportData(c.UserContext(), dateColumns)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to generate unit report: " + err.Error(),
@@ -72,7 +76,7 @@ func ExportVisitorReport(c *fiber.Ctx) error {
 	// Get service report data if requested (grouped by unit)
 	var servicesByUnit []ServiceByUnit
 	if includeServices {
-		servicesByUnit, err = getServiceReportDataGroupedByUnit(startDate, endDate, dateColumns)
+		servicesByUnit, err = h.getServiceReportDataGroupedByUnit(c.UserContext(), dateColumns)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to generate service report: " + err.Error(),
@@ -84,9 +88,9 @@ func ExportVisitorReport(c *fiber.Ctx) error {
 	htmlContent := generateHTMLReport(unitReportData, servicesByUnit, dateColumns, includeServices)
 
 	// Create temporary file
-	timestamp := time.Now().Format("20060102_150405")
+	timestamp := clock.Now().Format("20060102_150405")
 	filename := fmt.Sprintf("laporan_kunjungan_%s.xls", timestamp)
-	tempDir := "./temp"
+	tempDir := TempDir
 	
 	// Create temp directory if not exists
 	if err := os.MkdirAll(tempDir, 0755); err != nil {
@@ -119,10 +123,10 @@ func ExportVisitorReport(c *fiber.Ctx) error {
 			time.Sleep(10 * time.Second) 
 			
 			if err := os.Remove(path); err == nil {
-				fmt.Printf("Successfully deleted temp file: %s\n", path)
+				reportLog.Debug("temp file dihapus", "path", path)
 				return
 			} else if i == maxRetries-1 {
-				fmt.Printf("Failed to delete after %d retries: %s - %v\n", maxRetries, path, err)
+				reportLog.Error("gagal hapus temp file", "path", path, "retries", maxRetries, "err", err)
 			}
 		}
 	}(filePath)
@@ -145,65 +149,22 @@ func generateDateColumns(start, end time.Time) []string {
 }
 
 // getUnitReportData retrieves visitor count data grouped by unit
-func getUnitReportData(startDate, endDate time.Time, dateColumns []string) ([]VisitorReportData, error) {
-	db := config.DB
-
-	// Query to get all units (tanpa filter is_active)
-	type Unit struct {
-		ID       int64
-		NamaUnit string
-	}
-
-	var units []Unit
-	query := `SELECT id, nama_unit FROM units ORDER BY nama_unit`
-	
-	rows, err := db.Query(query)
+func (h *Handler) getUnitReportData(ctx context.Context, dateColumns []string) ([]VisitorReportData, error) {
+	// Semua unit (tanpa filter is_active), urut nama
+	units, err := h.repos.Units.List(ctx, repository.UnitFilter{})
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var unit Unit
-		if err := rows.Scan(&unit.ID, &unit.NamaUnit); err != nil {
-			continue
-		}
-		units = append(units, unit)
-	}
 
 	var reportData []VisitorReportData
 
 	for idx, unit := range units {
-		data := VisitorReportData{
-			No:         idx + 1,
-			Name:       unit.NamaUnit,
-			DateCounts: make(map[string]int),
-			Total:      0,
-		}
-
-		// Get visitor counts per date for this unit
-		for _, dateStr := range dateColumns {
-			// Parse date string back to time.Time for querying
-			date, _ := time.Parse("02/01/06", dateStr)
-			dayStart := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-			dayEnd := time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 0, date.Location())
-
-			var count int
-			countQuery := `
-				SELECT COUNT(*) 
-				FROM queue_tickets 
-				WHERE unit_id = ? 
-				AND created_at >= ? 
-				AND created_at <= ?
-			`
-			if err := db.QueryRow(countQuery, unit.ID, dayStart, dayEnd).Scan(&count); err != nil {
-				return nil, err
-			}
-
-			data.DateCounts[dateStr] = count
-			data.Total += count
+		data, err := h.countPerDate(ctx, unit.ID, 0, dateColumns)
+		if err != nil {
+			return nil, err
 		}
-
+		data.No = idx + 1
+		data.Name = unit.NamaUnit
 		reportData = append(reportData, data)
 	}
 
@@ -211,31 +172,12 @@ func getUnitReportData(startDate, endDate time.Time, dateColumns []string) ([]Vi
 }
 
 // getServiceReportDataGroupedByUnit retrieves visitor count data grouped by unit and their services
-func getServiceReportDataGroupedByUnit(startDate, endDate time.Time, dateColumns []string) ([]ServiceByUnit, error) {
-	db := config.DB
-
-	// Query to get all units (tanpa filter is_active)
-	type Unit struct {
-		ID       int64
-		NamaUnit string
-	}
-
-	var units []Unit
-	unitQuery := `SELECT id, nama_unit FROM units ORDER BY nama_unit`
-	
-	rows, err := db.Query(unitQuery)
+func (h *Handler) getServiceReportDataGroupedByUnit(ctx context.Context, dateColumns []string) ([]ServiceByUnit, error) {
+	// Semua unit (tanpa filter is_active), urut nama
+	units, err := h.repos.Units.List(ctx, repository.UnitFilter{})
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var unit Unit
-		if err := rows.Scan(&unit.ID, &unit.NamaUnit); err != nil {
-			continue
-		}
-		units = append(units, unit)
-	}
 
 	var servicesByUnit []ServiceByUnit
 
@@ -247,65 +189,18 @@ func getServiceReportDataGroupedByUnit(startDate, endDate time.Time, dateColumns
 			Services: []VisitorReportData{},
 		}
 
-		// Query to get services for this unit (tanpa filter is_active)
-		type Service struct {
-			ID          int64
-			NamaService string
-		}
-
-		var services []Service
-		serviceQuery := `
-			SELECT id, nama_service 
-			FROM services 
-			WHERE unit_id = ?
-			ORDER BY nama_service
-		`
-		
-		serviceRows, err := db.Query(serviceQuery, unit.ID)
+		services, err := h.servicesByName(ctx, unit.ID)
 		if err != nil {
 			return nil, err
 		}
 
-		for serviceRows.Next() {
-			var service Service
-			if err := serviceRows.Scan(&service.ID, &service.NamaService); err != nil {
-				continue
-			}
-			services = append(services, service)
-		}
-		serviceRows.Close()
-
 		// Get visitor counts for each service
 		for _, service := range services {
-			data := VisitorReportData{
-				Name:       service.NamaService,
-				DateCounts: make(map[string]int),
-				Total:      0,
-			}
-
-			// Get visitor counts per date for this service
-			for _, dateStr := range dateColumns {
-				// Parse date string back to time.Time for querying
-				date, _ := time.Parse("02/01/06", dateStr)
-				dayStart := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-				dayEnd := time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 0, date.Location())
-
-				var count int
-				countQuery := `
-					SELECT COUNT(*) 
-					FROM queue_tickets 
-					WHERE service_id = ? 
-					AND created_at >= ? 
-					AND created_at <= ?
-				`
-				if err := db.QueryRow(countQuery, service.ID, dayStart, dayEnd).Scan(&count); err != nil {
-					return nil, err
-				}
-
-				data.DateCounts[dateStr] = count
-				data.Total += count
+			data, err := h.countPerDate(ctx, 0, service.ID, dateColumns)
+			if err != nil {
+				return nil, err
 			}
-
+			data.Name = service.NamaService
 			serviceByUnit.Services = append(serviceByUnit.Services, data)
 		}
 
@@ -318,6 +213,43 @@ func getServiceReportDataGroupedByUnit(startDate, endDate time.Time, dateColumns
 	return servicesByUnit, nil
 }
 
+// servicesByName layanan satu unit (tanpa filter is_active), urut nama
+func (h *Handler) servicesByName(ctx context.Context, unitID int64) ([]models.Service, error) {
+	services, err := h.repos.Services.List(ctx, repository.ServiceFilter{UnitID: unitID, OldestFirst: true})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(services, func(i, j int) bool {
+		return services[i].NamaService < services[j].NamaService
+	})
+	return services, nil
+}
+
+// countPerDate jumlah kunjungan per tanggal kolom laporan untuk satu unit
+// atau satu layanan
+func (h *Handler) countPerDate(ctx context.Context, unitID, serviceID int64, dateColumns []string) (VisitorReportData, error) {
+	data := VisitorReportData{
+		DateCounts: make(map[string]int),
+	}
+
+	for _, dateStr := range dateColumns {
+		// Parse date string back to time.Time for querying
+		date, _ := time.Parse("02/01/06", dateStr)
+		dayStart := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+		dayEnd := time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 0, date.Location())
+
+		count, err := h.repos.Reports.CountBetween(ctx, unitID, serviceID, dayStart, dayEnd)
+		if err != nil {
+			return data, err
+		}
+
+		data.DateCounts[dateStr] = count
+		data.Total += count
+	}
+
+	return data, nil
+}
+
 // generateHTMLReport creates HTML table for Excel export
 func generateHTMLReport(unitData []VisitorReportData, servicesByUnit []ServiceByUnit, dateColumns []string, includeServices bool) string {
 	html := "<table border='1'>"
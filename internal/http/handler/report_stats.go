@@ -1,14 +1,14 @@
 package handler
 
 import (
-	"backend-antrian/internal/config"
+	"backend-antrian/internal/repository"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // GetVisitorStatistics - Endpoint untuk data visualisasi laporan
-func GetVisitorStatistics(c *fiber.Ctx) error {
+func (h *Handler) GetVisitorStatistics(c *fiber.Ctx) error {
 	// Parse query parameters
 	startDate := c.Query("start_date")
 	endDate := c.Query("end_date")
@@ -41,52 +41,20 @@ func GetVisitorStatistics(c *fiber.Ctx) error {
 	// Hitung jumlah hari
 	diffDays := int(end.Sub(start).Hours()/24) + 1
 
+	ctx := c.UserContext()
+	filter := repository.ReportFilter{From: startDate, To: endDate}
+
 	// ===========================
 	// 1. SUMMARY DATA
 	// ===========================
-	var totalVisitors int
-	queryTotalVisitors := `
-		SELECT COUNT(qt.id)
-		FROM queue_tickets qt
-		INNER JOIN units u ON qt.unit_id = u.id
-		WHERE DATE(qt.created_at) BETWEEN ? AND ?
-	`
-	err := config.DB.QueryRow(queryTotalVisitors, startDate, endDate).Scan(&totalVisitors)
+	// Total kunjungan, instansi & layanan yang punya queue dalam range
+	summary, err := h.repos.Reports.Summary(ctx, filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal mengambil total kunjungan",
 		})
 	}
-
-	// Total Instansi (unit aktif yang punya queue dalam range)
-	var totalInstansi int
-	queryTotalInstansi := `
-		SELECT COUNT(DISTINCT qt.unit_id)
-		FROM queue_tickets qt
-		INNER JOIN units u ON qt.unit_id = u.id
-		WHERE DATE(qt.created_at) BETWEEN ? AND ?
-	`
-	err = config.DB.QueryRow(queryTotalInstansi, startDate, endDate).Scan(&totalInstansi)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Gagal mengambil total instansi",
-		})
-	}
-
-	// Total Layanan (service yang punya queue dalam range)
-	var totalLayanan int
-	queryTotalLayanan := `
-		SELECT COUNT(DISTINCT qt.service_id)
-		FROM queue_tickets qt
-		INNER JOIN units u ON qt.unit_id = u.id
-		WHERE DATE(qt.created_at) BETWEEN ? AND ?
-	`
-	err = config.DB.QueryRow(queryTotalLayanan, startDate, endDate).Scan(&totalLayanan)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Gagal mengambil total layanan",
-		})
-	}
+	totalVisitors := summary.Visitors
 
 	// Rata-rata per hari
 	avgPerDay := 0
@@ -97,116 +65,32 @@ func GetVisitorStatistics(c *fiber.Ctx) error {
 	// ===========================
 	// 2. DAILY VISITORS
 	// ===========================
-	type DailyVisitor struct {
-		Date  string `json:"date"`
-		Total int    `json:"total"`
-	}
-
-	queryDaily := `
-		SELECT 
-			t.date,
-			COUNT(*) AS total
-		FROM (
-			SELECT DATE(qt.created_at) AS date
-			FROM queue_tickets qt
-			JOIN units u ON qt.unit_id = u.id
-			WHERE DATE(qt.created_at) BETWEEN ? AND ?
-		) t
-		GROUP BY t.date
-		ORDER BY t.date ASC;
-	`
-
-	rows, err := config.DB.Query(queryDaily, startDate, endDate)
+	daily, err := h.repos.Reports.Daily(ctx, filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Gagal mengambil data harian" + err.Error(),
+			"error": "Gagal mengambil data harian",
 		})
 	}
-	defer rows.Close()
-
-	dailyVisitors := []DailyVisitor{}
-	for rows.Next() {
-		var dv DailyVisitor
-		if err := rows.Scan(&dv.Date, &dv.Total); err != nil {
-			continue
-		}
-		dailyVisitors = append(dailyVisitors, dv)
-	}
 
 	// ===========================
 	// 3. INSTANSI DATA
 	// ===========================
-	type InstansiData struct {
-		Nama  string `json:"nama"`
-		Total int    `json:"total"`
-	}
-
-	queryInstansi := `
-		SELECT 
-			u.nama_unit as nama,
-			COUNT(qt.id) as total
-		FROM queue_tickets qt
-		INNER JOIN units u ON qt.unit_id = u.id
-		WHERE DATE(qt.created_at) BETWEEN ? AND ?
-		GROUP BY qt.unit_id, u.nama_unit
-		HAVING total > 0
-		ORDER BY total DESC
-	`
-
-	rows, err = config.DB.Query(queryInstansi, startDate, endDate)
+	byUnit, err := h.repos.Reports.ByUnit(ctx, filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal mengambil data instansi",
 		})
 	}
-	defer rows.Close()
-
-	instansiData := []InstansiData{}
-	for rows.Next() {
-		var id InstansiData
-		if err := rows.Scan(&id.Nama, &id.Total); err != nil {
-			continue
-		}
-		instansiData = append(instansiData, id)
-	}
 
 	// ===========================
 	// 4. LAYANAN DATA
 	// ===========================
-	type LayananData struct {
-		Nama  string `json:"nama"`
-		Total int    `json:"total"`
-	}
-
-	queryLayanan := `
-		SELECT 
-			s.nama_service as nama,
-			COUNT(qt.id) as total
-		FROM queue_tickets qt
-		INNER JOIN units u ON qt.unit_id = u.id
-		INNER JOIN services s ON qt.service_id = s.id
-		WHERE DATE(qt.created_at) BETWEEN ? AND ?
-		GROUP BY qt.service_id, s.nama_service
-		HAVING total > 0
-		ORDER BY total DESC
-	`
-
-	rows, err = config.DB.Query(queryLayanan, startDate, endDate)
+	byService, err := h.repos.Reports.ByService(ctx, filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal mengambil data layanan",
 		})
 	}
-	defer rows.Close()
-
-	layananData := []LayananData{}
-	for rows.Next() {
-		var ld LayananData
-		if err := rows.Scan(&ld.Nama, &ld.Total); err != nil {
-			continue
-		}
-		layananData = append(layananData, ld)
-	}
 
 	// ===========================
 	// RESPONSE
@@ -216,13 +100,40 @@ func GetVisitorStatistics(c *fiber.Ctx) error {
 		"data": fiber.Map{
 			"summary": fiber.Map{
 				"total_visitors": totalVisitors,
-				"total_instansi": totalInstansi,
-				"total_layanan":  totalLayanan,
+				"total_instansi": summary.Units,
+				"total_layanan":  summary.Services,
 				"avg_per_day":    avgPerDay,
 			},
-			"daily_visitors": dailyVisitors,
-			"instansi_data":  instansiData,
-			"layanan_data":   layananData,
+			"daily_visitors": dailyVisitors(daily),
+			"instansi_data":  nameTotals(byUnit),
+			"layanan_data":   nameTotals(byService),
 		},
 	})
-}
\ No newline at end of file
+}
+// DailyVisitor - jumlah kunjungan per tanggal (YYYY-MM-DD)
+type DailyVisitor struct {
+	Date  string `json:"date"`
+	Total int    `json:"total"`
+}
+
+// NameTotal - jumlah kunjungan per instansi / layanan
+type NameTotal struct {
+	Nama  string `json:"nama"`
+	Total int    `json:"total"`
+}
+
+func dailyVisitors(days []repository.DayCount) []DailyVisitor {
+	result := make([]DailyVisitor, 0, len(days))
+	for _, d := range days {
+		result = append(result, DailyVisitor{Date: d.Date, Total: d.Total})
+	}
+	return result
+}
+
+func nameTotals(counts []repository.NameCount) []NameTotal {
+	result := make([]NameTotal, 0, len(counts))
+	for _, n := range counts {
+		result = append(result, NameTotal{Nama: n.Name, Total: n.Total})
+	}
+	return result
+}
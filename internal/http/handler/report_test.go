@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"backend-antrian/internal/repository/memory"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestVisitorStatistics(t *testing.T) {
+	clock.Set(clock.Fixed(time.Date(2026, 2, 3, 9, 0, 0, 0, clock.Location())))
+	t.Cleanup(clock.Reset)
+
+	h := New(memory.New())
+	ctx := context.Background()
+	app := newFiber()
+	app.Get("/reports/visitors/statistics", h.GetVisitorStatistics)
+
+	dukcapil, _ := h.repos.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Dukcapil", IsActive: "y", MainDisplay: "active"})
+	pajak, _ := h.repos.Units.Create(ctx, models.Unit{Code: "B", NamaUnit: "Pajak", IsActive: "y", MainDisplay: "active"})
+	ktp, _ := h.repos.Services.Create(ctx, models.Service{UnitID: dukcapil, NamaService: "KTP", Code: "KTP", IsActive: "y"})
+	kk, _ := h.repos.Services.Create(ctx, models.Service{UnitID: dukcapil, NamaService: "KK", Code: "KK", IsActive: "y"})
+	pbb, _ := h.repos.Services.Create(ctx, models.Service{UnitID: pajak, NamaService: "PBB", Code: "PBB", IsActive: "y"})
+	take := func(unitID, serviceID int64) {
+		t.Helper()
+		if _, err := h.repos.Tickets.Create(ctx, repository.NewTicket{TicketCode: "X", UnitID: unitID, ServiceID: serviceID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	take(dukcapil, ktp)
+	take(dukcapil, ktp)
+	take(dukcapil, kk)
+	clock.Set(clock.Fixed(time.Date(2026, 2, 4, 9, 0, 0, 0, clock.Location())))
+	take(pajak, pbb)
+	clock.Set(clock.Fixed(time.Date(2026, 2, 6, 9, 0, 0, 0, clock.Location())))
+	take(pajak, pbb) // di luar rentang
+
+	if status, _ := do(t, app, "GET", "/reports/visitors/statistics?start_date=2026-02-04&end_date=2026-02-03", ""); status != fiber.StatusBadRequest {
+		t.Fatalf("rentang terbalik = %d, want 400", status)
+	}
+
+	status, body := do(t, app, "GET", "/reports/visitors/statistics?start_date=2026-02-03&end_date=2026-02-04", "")
+	if status != fiber.StatusOK {
+		t.Fatalf("statistics = %d %v", status, body)
+	}
+	data := body["data"].(map[string]any)
+	summary := data["summary"].(map[string]any)
+	if summary["total_visitors"] != float64(4) || summary["total_instansi"] != float64(2) ||
+		summary["total_layanan"] != float64(3) || summary["avg_per_day"] != float64(2) {
+		t.Fatalf("summary = %v", summary)
+	}
+	daily := data["daily_visitors"].([]any)
+	if len(daily) != 2 || daily[0].(map[string]any)["date"] != "2026-02-03" || daily[0].(map[string]any)["total"] != float64(3) {
+		t.Fatalf("daily = %v", daily)
+	}
+	instansi := data["instansi_data"].([]any)
+	if len(instansi) != 2 || instansi[0].(map[string]any)["nama"] != "Dukcapil" || instansi[0].(map[string]any)["total"] != float64(3) {
+		t.Fatalf("instansi = %v", instansi)
+	}
+	layanan := data["layanan_data"].([]any)
+	if len(layanan) != 3 || layanan[0].(map[string]any)["nama"] != "KTP" {
+		t.Fatalf("layanan = %v", layanan)
+	}
+}
+
+func TestReportCountPerDate(t *testing.T) {
+	clock.Set(clock.Fixed(time.Date(2026, 2, 3, 23, 59, 30, 0, clock.Location())))
+	t.Cleanup(clock.Reset)
+
+	h := New(memory.New())
+	ctx := context.Background()
+	unitID, _ := h.repos.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Dukcapil", IsActive: "y", MainDisplay: "active"})
+	ktp, _ := h.repos.Services.Create(ctx, models.Service{UnitID: unitID, NamaService: "KTP", Code: "KTP", IsActive: "y"})
+	kk, _ := h.repos.Services.Create(ctx, models.Service{UnitID: unitID, NamaService: "KK", Code: "KK", IsActive: "y"})
+	h.repos.Tickets.Create(ctx, repository.NewTicket{TicketCode: "KTP001", UnitID: unitID, ServiceID: ktp})
+	clock.Set(clock.Fixed(time.Date(2026, 2, 4, 8, 0, 0, 0, clock.Location())))
+	h.repos.Tickets.Create(ctx, repository.NewTicket{TicketCode: "KK001", UnitID: unitID, ServiceID: kk})
+
+	dates := []string{"03/02/26", "04/02/26"}
+	rows, err := h.getUnitServiceReportData(ctx, unitID, dates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Layanan urut nama: KK lalu KTP
+	if len(rows) != 2 || rows[0].Name != "KK" || rows[0].DateCounts["04/02/26"] != 1 ||
+		rows[1].Name != "KTP" || rows[1].DateCounts["03/02/26"] != 1 || rows[1].Total != 1 {
+		t.Fatalf("rows = %+v", rows)
+	}
+
+	units, err := h.getUnitReportData(ctx, dates)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(units) != 1 || units[0].Total != 2 || units[0].No != 1 {
+		t.Fatalf("units = %+v", units)
+	}
+}
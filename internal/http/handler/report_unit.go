@@ -1,7 +1,8 @@
 package handler
 
 import (
-	"backend-antrian/internal/config"
+	"backend-antrian/internal/clock"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -20,13 +21,11 @@ type VisitorReportDataUnit struct {
 }
 
 // ExportUnitVisitorReport generates and downloads visitor report for specific unit (unit role)
-func ExportUnitVisitorReport(c *fiber.Ctx) error {
+func (h *Handler) ExportUnitVisitorReport(c *fiber.Ctx) error {
 	// Get unit_id from JWT token
-	unitID, ok := c.Locals("unit_id").(int64)
-	if !ok {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "Unit ID not found in token",
-		})
+	unitID, err := h.activeUnitID(c)
+	if err != nil {
+		return unitAccessError(c, err)
 	}
 
 	// Parse query parameters
@@ -63,17 +62,16 @@ func ExportUnitVisitorReport(c *fiber.Ctx) error {
 	dateColumns := generateDateColumnsUnit(startDate, endDate)
 
 	// Get unit name
-	db := config.DB
-	var unitName string
-	err = db.QueryRow("SELECT nama_unit FROM units WHERE id = ?", unitID).Scan(&unitName)
+	unit, err := h.repos.Units.Get(c.UserContext(), unitID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get unit information: " + err.Error(),
 		})
 	}
+	unitName := unit.NamaUnit
 
 	// Get service report data for this unit only
-	serviceReportData, err := getUnitServiceReportData(unitID, startDate, endDate, dateColumns)
+	serviceReportData, err := h.getUnitServiceReportData(c.UserContext(), unitID, dateColumns)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to generate service report: " + err.Error(),
@@ -84,9 +82,9 @@ func ExportUnitVisitorReport(c *fiber.Ctx) error {
 	htmlContent := generateUnitHTMLReport(unitName, serviceReportData, dateColumns)
 
 	// Create temporary file
-	timestamp := time.Now().Format("20060102_150405")
+	timestamp := clock.Now().Format("20060102_150405")
 	filename := fmt.Sprintf("laporan_kunjungan_%s_%s.xls", sanitizeFilename(unitName), timestamp)
-	tempDir := "./temp"
+	tempDir := TempDir
 	
 	// Create temp directory if not exists
 	if err := os.MkdirAll(tempDir, 0755); err != nil {
@@ -119,10 +117,10 @@ func ExportUnitVisitorReport(c *fiber.Ctx) error {
 			time.Sleep(10 * time.Second) 
 			
 			if err := os.Remove(path); err == nil {
-				fmt.Printf("Successfully deleted temp file: %s\n", path)
+				reportLog.Debug("temp file dihapus", "path", path)
 				return
 			} else if i == maxRetries-1 {
-				fmt.Printf("Failed to delete after %d retries: %s - %v\n", maxRetries, path, err)
+				reportLog.Error("gagal hapus temp file", "path", path, "retries", maxRetries, "err", err)
 			}
 		}
 	}(filePath)
@@ -145,70 +143,21 @@ func generateDateColumnsUnit(start, end time.Time) []string {
 }
 
 // getUnitServiceReportData retrieves visitor count data for services of a specific unit
-func getUnitServiceReportData(unitID int64, startDate, endDate time.Time, dateColumns []string) ([]VisitorReportData, error) {
-	db := config.DB
-
-	// Query to get active services from this unit only
-	type Service struct {
-		ID          int64
-		NamaService string
-	}
-
-	var services []Service
-	query := `
-		SELECT s.id, s.nama_service 
-		FROM services s
-		WHERE s.unit_id = ? 
-		ORDER BY s.nama_service
-	`
-	
-	rows, err := db.Query(query, unitID)
+func (h *Handler) getUnitServiceReportData(ctx context.Context, unitID int64, dateColumns []string) ([]VisitorReportData, error) {
+	services, err := h.servicesByName(ctx, unitID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var service Service
-		if err := rows.Scan(&service.ID, &service.NamaService); err != nil {
-			continue
-		}
-		services = append(services, service)
-	}
 
 	var reportData []VisitorReportData
 
 	for idx, service := range services {
-		data := VisitorReportData{
-			No:         idx + 1,
-			Name:       service.NamaService,
-			DateCounts: make(map[string]int),
-			Total:      0,
+		data, err := h.countPerDate(ctx, 0, service.ID, dateColumns)
+		if err != nil {
+			return nil, err
 		}
-
-		// Get visitor counts per date for this service
-		for _, dateStr := range dateColumns {
-			// Parse date string back to time.Time for querying
-			date, _ := time.Parse("02/01/06", dateStr)
-			dayStart := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-			dayEnd := time.Date(date.Year(), date.Month(), date.Day(), 23, 59, 59, 0, date.Location())
-
-			var count int
-			countQuery := `
-				SELECT COUNT(*) 
-				FROM queue_tickets 
-				WHERE service_id = ? 
-				AND created_at >= ? 
-				AND created_at <= ?
-			`
-			if err := db.QueryRow(countQuery, service.ID, dayStart, dayEnd).Scan(&count); err != nil {
-				return nil, err
-			}
-
-			data.DateCounts[dateStr] = count
-			data.Total += count
-		}
-
+		data.No = idx + 1
+		data.Name = service.NamaService
 		reportData = append(reportData, data)
 	}
 
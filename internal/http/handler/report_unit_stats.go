@@ -1,20 +1,18 @@
 package handler
 
 import (
-	"backend-antrian/internal/config"
+	"backend-antrian/internal/repository"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 // GetUnitVisitorStatistics - Endpoint untuk data visualisasi laporan unit
-func GetUnitVisitorStatistics(c *fiber.Ctx) error {
+func (h *Handler) GetUnitVisitorStatistics(c *fiber.Ctx) error {
 	// Get unit_id from JWT token
-	unitID, ok := c.Locals("unit_id").(int64)
-	if !ok {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "Unit ID not found in token",
-		})
+	unitID, err := h.activeUnitID(c)
+	if err != nil {
+		return unitAccessError(c, err)
 	}
 
 	// Parse query parameters
@@ -46,118 +44,39 @@ func GetUnitVisitorStatistics(c *fiber.Ctx) error {
 		})
 	}
 
+	ctx := c.UserContext()
+	filter := repository.ReportFilter{UnitID: unitID, From: startDate, To: endDate}
+
 	// ===========================
 	// 1. SUMMARY DATA
 	// ===========================
-	
-	// Total Visitors untuk unit ini
-	var totalVisitors int
-	queryTotalVisitors := `
-		SELECT COUNT(qt.id)
-		FROM queue_tickets qt
-		JOIN services s ON s.id = qt.service_id
-		WHERE qt.unit_id = ?
-			AND DATE(qt.created_at) BETWEEN ? AND ?
-	`
-	err := config.DB.QueryRow(queryTotalVisitors, unitID, startDate, endDate).Scan(&totalVisitors)
+	// Total kunjungan & layanan yang punya queue dalam range untuk unit ini
+	summary, err := h.repos.Reports.Summary(ctx, filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal mengambil total kunjungan",
 		})
 	}
 
-	// Total Layanan aktif yang punya queue dalam range
-	var totalLayanan int
-	queryTotalLayanan := `
-		SELECT COUNT(DISTINCT qt.service_id)
-		FROM queue_tickets qt
-		INNER JOIN services s ON qt.service_id = s.id
-		WHERE qt.unit_id = ?
-		AND DATE(qt.created_at) BETWEEN ? AND ?
-	`
-	err = config.DB.QueryRow(queryTotalLayanan, unitID, startDate, endDate).Scan(&totalLayanan)
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Gagal mengambil total layanan",
-		})
-	}
-
 	// ===========================
 	// 2. DAILY VISITORS
 	// ===========================
-	type DailyVisitor struct {
-		Date  string `json:"date"`
-		Total int    `json:"total"`
-	}
-
-	queryDaily := `
-		SELECT 
-			t.date,
-			COUNT(*) AS total
-		FROM (
-			SELECT DATE(qt.created_at) AS date
-			FROM queue_tickets qt
-			WHERE qt.unit_id = ?
-			AND DATE(qt.created_at) BETWEEN ? AND ?
-		) t
-		GROUP BY t.date
-		ORDER BY t.date ASC
-	`
-
-	rows, err := config.DB.Query(queryDaily, unitID, startDate, endDate)
+	daily, err := h.repos.Reports.Daily(ctx, filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Gagal mengambil data harian: " + err.Error(),
+			"error": "Gagal mengambil data harian",
 		})
 	}
-	defer rows.Close()
-
-	dailyVisitors := []DailyVisitor{}
-	for rows.Next() {
-		var dv DailyVisitor
-		if err := rows.Scan(&dv.Date, &dv.Total); err != nil {
-			continue
-		}
-		dailyVisitors = append(dailyVisitors, dv)
-	}
 
 	// ===========================
 	// 3. LAYANAN DATA (Services dari unit ini)
 	// ===========================
-	type LayananData struct {
-		Nama  string `json:"nama"`
-		Total int    `json:"total"`
-	}
-
-	queryLayanan := `
-		SELECT 
-			s.nama_service as nama,
-			COUNT(qt.id) as total
-		FROM queue_tickets qt
-		INNER JOIN services s ON qt.service_id = s.id
-		WHERE qt.unit_id = ?
-		AND DATE(qt.created_at) BETWEEN ? AND ?
-		GROUP BY qt.service_id, s.nama_service
-		HAVING total > 0
-		ORDER BY total DESC
-	`
-
-	rows, err = config.DB.Query(queryLayanan, unitID, startDate, endDate)
+	byService, err := h.repos.Reports.ByService(ctx, filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal mengambil data layanan",
 		})
 	}
-	defer rows.Close()
-
-	layananData := []LayananData{}
-	for rows.Next() {
-		var ld LayananData
-		if err := rows.Scan(&ld.Nama, &ld.Total); err != nil {
-			continue
-		}
-		layananData = append(layananData, ld)
-	}
 
 	// ===========================
 	// RESPONSE
@@ -166,11 +85,11 @@ func GetUnitVisitorStatistics(c *fiber.Ctx) error {
 		"success": true,
 		"data": fiber.Map{
 			"summary": fiber.Map{
-				"total_visitors": totalVisitors,
-				"total_layanan":  totalLayanan,
+				"total_visitors": summary.Visitors,
+				"total_layanan":  summary.Services,
 			},
-			"daily_visitors": dailyVisitors,
-			"layanan_data":   layananData,
+			"daily_visitors": dailyVisitors(daily),
+			"layanan_data":   nameTotals(byService),
 		},
 	})
 }
\ No newline at end of file
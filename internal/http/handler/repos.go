@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"backend-antrian/internal/helper"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"errors"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Handler - handler HTTP & worker antrian beserta akses datanya
+// (sqlrepo di server, memory di test)
+type Handler struct {
+	repos *repository.Repos
+	zones zoneCache
+}
+
+// New buat Handler di atas container repository r
+func New(r *repository.Repos) *Handler {
+	return &Handler{repos: r}
+}
+
+// paramID - path param numerik; ID tidak valid jadi 0 (pasti tidak ditemukan)
+func paramID(c *fiber.Ctx, name string) int64 {
+	id, _ := strconv.ParseInt(c.Params(name), 10, 64)
+	return id
+}
+
+// unitOpenStatus - status buka/tutup unit berdasarkan jadwal hari ini
+// menurut zona waktu unit
+func (h *Handler) unitOpenStatus(ctx context.Context, unit models.Unit) helper.UnitScheduleStatus {
+	loc := unitLocation(unit)
+	schedule, err := h.repos.Schedules.ForDay(ctx, unit.ID, helper.Today(loc))
+	if errors.Is(err, repository.ErrNotFound) {
+		return helper.ScheduleStatus(nil, loc)
+	}
+	if err != nil {
+		return helper.UnitScheduleStatus{}
+	}
+	return helper.ScheduleStatus(&schedule, loc)
+}
@@ -0,0 +1,367 @@
+package handler
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/realtime"
+	"backend-antrian/internal/repository"
+	"backend-antrian/internal/repository/memory"
+	"backend-antrian/internal/validation"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newFiber - app Fiber dengan ErrorHandler seperti server: *validation.Error jadi 422
+func newFiber() *fiber.App {
+	return fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			var ve *validation.Error
+			if errors.As(err, &ve) {
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(ve.Body())
+			}
+			return fiber.DefaultErrorHandler(c, err)
+		},
+	})
+}
+
+// testApp - app Fiber dengan handler di atas repository in-memory (tanpa MySQL)
+func testApp(t *testing.T) (*Handler, *fiber.App) {
+	t.Helper()
+	h := New(memory.New())
+
+	app := newFiber()
+	app.Get("/units", h.GetAllUnits)
+	app.Get("/units/:id", h.GetUnitByID)
+	app.Post("/units", h.CreateUnit)
+	app.Put("/units/:id", h.UpdateUnit)
+	app.Delete("/units/:id", h.DeleteUnit)
+	app.Delete("/units/:id/permanent", h.HardDeleteUnit)
+	app.Get("/faqs/paginate", h.GetAllFAQsPagination)
+	app.Post("/faqs", h.CreateFAQ)
+	app.Get("/config", h.GetConfig)
+	app.Post("/config", h.CreateConfig)
+	app.Put("/config", h.UpdateConfig)
+	app.Delete("/users/:id/permanent", h.HardDeleteUser)
+	app.Get("/services/status", h.GetServicesByUnitIDWithStatus)
+	app.Get("/queue/display", h.GetQueueDisplay)
+	return h, app
+}
+
+// do - kirim request JSON, kembalikan status & body ter-decode
+func do(t *testing.T, app *fiber.App, method, path, body string) (int, map[string]any) {
+	t.Helper()
+	var r io.Reader
+	if body != "" {
+		r = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, r)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	var out map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&out)
+	return resp.StatusCode, out
+}
+
+func TestUnitCRUD(t *testing.T) {
+	_, app := testApp(t)
+
+	status, body := do(t, app, "POST", "/units", `{"code":" a ","nama_unit":"Dukcapil"}`)
+	if status != fiber.StatusCreated {
+		t.Fatalf("create = %d %v", status, body)
+	}
+	unit := body["data"].(map[string]any)
+	if unit["code"] != "A" || unit["is_active"] != "y" || unit["main_display"] != "active" {
+		t.Fatalf("default unit tidak sesuai: %v", unit)
+	}
+
+	if status, _ := do(t, app, "POST", "/units", `{"code":"A","nama_unit":"Lain"}`); status != fiber.StatusConflict {
+		t.Fatalf("code duplikat = %d, want 409", status)
+	}
+
+	if status, _ := do(t, app, "PUT", "/units/1", `{}`); status != fiber.StatusBadRequest {
+		t.Fatalf("update kosong = %d, want 400", status)
+	}
+	status, body = do(t, app, "PUT", "/units/1", `{"nama_unit":"Disdukcapil"}`)
+	if status != fiber.StatusOK || body["data"].(map[string]any)["nama_unit"] != "Disdukcapil" {
+		t.Fatalf("update = %d %v", status, body)
+	}
+
+	if status, _ := do(t, app, "DELETE", "/units/1", ""); status != fiber.StatusOK {
+		t.Fatalf("soft delete = %d", status)
+	}
+	_, body = do(t, app, "GET", "/units/1", "")
+	if body["data"].(map[string]any)["is_active"] != "n" {
+		t.Fatalf("soft delete tidak menonaktifkan unit: %v", body)
+	}
+
+	if status, _ := do(t, app, "GET", "/units/99", ""); status != fiber.StatusNotFound {
+		t.Fatalf("unit tidak ada = %d, want 404", status)
+	}
+}
+
+func TestHardDeleteUnitInUse(t *testing.T) {
+	h, app := testApp(t)
+	ctx := context.Background()
+
+	unitID, _ := h.repos.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Dukcapil", IsActive: "y", MainDisplay: "active"})
+	serviceID, _ := h.repos.Services.Create(ctx, models.Service{UnitID: unitID, NamaService: "KTP", Code: "KTP", IsActive: "y"})
+
+	if status, _ := do(t, app, "DELETE", "/units/1/permanent", ""); status != fiber.StatusConflict {
+		t.Fatalf("hapus unit yang punya service = %d, want 409", status)
+	}
+
+	_ = h.repos.Services.Delete(ctx, serviceID)
+	if status, _ := do(t, app, "DELETE", "/units/1/permanent", ""); status != fiber.StatusOK {
+		t.Fatalf("hapus unit = %d, want 200", status)
+	}
+	if status, _ := do(t, app, "DELETE", "/units/1/permanent", ""); status != fiber.StatusNotFound {
+		t.Fatalf("hapus ulang = %d, want 404", status)
+	}
+}
+
+func TestUnitZonesInvalidatedByUnitsStatus(t *testing.T) {
+	h, _ := testApp(t)
+	ctx := context.Background()
+
+	unitID, _ := h.repos.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Dukcapil", IsActive: "y", MainDisplay: "active"})
+	if zones := h.unitZones(ctx); len(zones) != 0 {
+		t.Fatalf("zones awal = %v", zones)
+	}
+
+	// Replica lain mengubah zona unit lalu publish units:status
+	wita := "Asia/Makassar"
+	if err := h.repos.Units.Update(ctx, unitID, repository.UnitPatch{Timezone: &wita}); err != nil {
+		t.Fatal(err)
+	}
+	go func() { <-realtime.Units.Broadcast }()
+	h.onUnitsStatus([]byte(`{"type":"units_status","units":[]}`))
+
+	if zones := h.unitZones(ctx); zones[unitID] != wita {
+		t.Fatalf("zones setelah units:status = %v, want %d=%s", zones, unitID, wita)
+	}
+}
+
+func TestFAQPagination(t *testing.T) {
+	_, app := testApp(t)
+
+	// Isi yang cuma spasi ditolak validator, bukan cek ad-hoc
+	status, body := do(t, app, "POST", "/faqs", `{"question":"  ","answer":"-"}`)
+	if status != fiber.StatusUnprocessableEntity || body["errors"].([]any)[0].(map[string]any)["field"] != "question" {
+		t.Fatalf("question kosong = %d %v, want 422", status, body)
+	}
+	for _, q := range []string{"Jam buka?", "Syarat KTP?", "Syarat KK?"} {
+		if status, body := do(t, app, "POST", "/faqs", `{"question":"`+q+`","answer":"-"}`); status != fiber.StatusCreated {
+			t.Fatalf("create faq = %d %v", status, body)
+		}
+	}
+
+	status, body = do(t, app, "GET", "/faqs/paginate?search=syarat&limit=1", "")
+	if status != fiber.StatusOK {
+		t.Fatalf("paginate = %d %v", status, body)
+	}
+	pagination := body["pagination"].(map[string]any)
+	if pagination["total_data"] != float64(2) || pagination["total_pages"] != float64(2) {
+		t.Fatalf("pagination = %v", pagination)
+	}
+	if data := body["data"].([]any); len(data) != 1 {
+		t.Fatalf("data = %v, want 1 item", data)
+	}
+}
+
+func TestConfigLifecycle(t *testing.T) {
+	_, app := testApp(t)
+
+	if status, _ := do(t, app, "GET", "/config", ""); status != fiber.StatusNotFound {
+		t.Fatalf("config kosong = %d, want 404", status)
+	}
+	if status, _ := do(t, app, "PUT", "/config", `{"text_marque":"x"}`); status != fiber.StatusNotFound {
+		t.Fatalf("update sebelum create = %d, want 404", status)
+	}
+	if status, _ := do(t, app, "POST", "/config", `{"text_marque":"Selamat datang"}`); status != fiber.StatusCreated {
+		t.Fatalf("create = %d", status)
+	}
+	if status, _ := do(t, app, "POST", "/config", `{"text_marque":"Lagi"}`); status != fiber.StatusConflict {
+		t.Fatalf("create kedua = %d, want 409", status)
+	}
+	if status, _ := do(t, app, "PUT", "/config", `{"text_marque":"Antrian dibuka"}`); status != fiber.StatusOK {
+		t.Fatalf("update = %d", status)
+	}
+
+	_, body := do(t, app, "GET", "/config", "")
+	if body["data"].(map[string]any)["text_marque"] != "Antrian dibuka" {
+		t.Fatalf("config = %v", body)
+	}
+}
+
+func TestHardDeleteUser(t *testing.T) {
+	h, app := testApp(t)
+	ctx := context.Background()
+
+	admin, _ := h.repos.Users.CreateWithUnits(ctx, models.User{Nama: "Admin", Email: "admin@x.id", Password: "-", Role: "super_user", IsBanned: "n"}, nil)
+	staff, _ := h.repos.Users.CreateWithUnits(ctx, models.User{Nama: "Petugas", Email: "petugas@x.id", Password: "-", Role: "unit", IsBanned: "n"}, []int64{1, 2})
+
+	if status, _ := do(t, app, "DELETE", fmt.Sprintf("/users/%d/permanent", admin), `{}`); status != fiber.StatusBadRequest {
+		t.Fatalf("hapus super_user terakhir = %d, want 400", status)
+	}
+	if status, body := do(t, app, "DELETE", fmt.Sprintf("/users/%d/permanent", staff), `{}`); status != fiber.StatusOK {
+		t.Fatalf("hapus user = %d %v", status, body)
+	}
+	if _, err := h.repos.Users.Get(ctx, staff); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("user masih ada: %v", err)
+	}
+	if ids, _ := h.repos.Users.UnitIDs(ctx, staff); len(ids) != 0 {
+		t.Fatalf("keanggotaan unit masih ada: %v", ids)
+	}
+	if status, _ := do(t, app, "DELETE", fmt.Sprintf("/users/%d/permanent", staff), `{}`); status != fiber.StatusNotFound {
+		t.Fatalf("hapus ulang = %d, want 404", status)
+	}
+}
+
+// queueFixture - unit A (aktif) dengan KTP (aktif, kuota 2) & KK (tutup),
+// unit B nonaktif; KTP001 dipanggil, KTP002 menunggu
+func queueFixture(t *testing.T, h *Handler) (unitID, ktpID int64) {
+	t.Helper()
+	ctx := context.Background()
+	limit := 2
+
+	unitID, _ = h.repos.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Dukcapil", IsActive: "y", MainDisplay: "active"})
+	ktpID, _ = h.repos.Services.Create(ctx, models.Service{UnitID: unitID, NamaService: "KTP", Code: "KTP", LimitsQueue: limit, IsActive: "y"})
+	_, _ = h.repos.Services.Create(ctx, models.Service{UnitID: unitID, NamaService: "KK", Code: "KK", IsActive: "n"})
+	otherID, _ := h.repos.Units.Create(ctx, models.Unit{Code: "B", NamaUnit: "Imigrasi", IsActive: "n", MainDisplay: "active"})
+	_, _ = h.repos.Services.Create(ctx, models.Service{UnitID: otherID, NamaService: "Paspor", Code: "PSP", IsActive: "y"})
+
+	first, err := h.repos.Tickets.Create(ctx, repository.NewTicket{TicketCode: "KTP001", UnitID: unitID, ServiceID: ktpID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.repos.Tickets.Create(ctx, repository.NewTicket{TicketCode: "KTP002", UnitID: unitID, ServiceID: ktpID}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.repos.Tickets.Call(ctx, first, 1); err != nil {
+		t.Fatal(err)
+	}
+	return unitID, ktpID
+}
+
+func TestServicesStatus(t *testing.T) {
+	h, app := testApp(t)
+	unitID, _ := queueFixture(t, h)
+
+	if status, _ := do(t, app, "GET", "/services/status", ""); status != fiber.StatusBadRequest {
+		t.Fatalf("tanpa unit_id = %d, want 400", status)
+	}
+	if status, _ := do(t, app, "GET", "/services/status?unit_id=99", ""); status != fiber.StatusNotFound {
+		t.Fatalf("unit tidak ada = %d, want 404", status)
+	}
+
+	status, body := do(t, app, "GET", fmt.Sprintf("/services/status?unit_id=%d", unitID), "")
+	if status != fiber.StatusOK {
+		t.Fatalf("status = %d %v", status, body)
+	}
+	data := body["data"].(map[string]any)
+	services := data["services"].([]any)
+	if data["unit_name"] != "Dukcapil" || len(services) != 2 {
+		t.Fatalf("data = %v", data)
+	}
+	ktp, kk := services[0].(map[string]any), services[1].(map[string]any)
+	if ktp["status"] != "quota_full" || ktp["today_queue_count"] != float64(2) ||
+		ktp["waiting_queue_count"] != float64(1) || ktp["loket"] != "Dukcapil" {
+		t.Fatalf("KTP = %v", ktp)
+	}
+	if kk["status"] != "closed" || kk["today_queue_count"] != float64(0) {
+		t.Fatalf("KK = %v", kk)
+	}
+}
+
+func TestQueueDisplay(t *testing.T) {
+	h, app := testApp(t)
+	unitID, ktpID := queueFixture(t, h)
+
+	status, body := do(t, app, "GET", "/queue/display", "")
+	if status != fiber.StatusOK {
+		t.Fatalf("display = %d %v", status, body)
+	}
+	// Hanya layanan aktif di unit aktif
+	data := body["data"].([]any)
+	if len(data) != 1 {
+		t.Fatalf("data = %v, want 1 layanan", data)
+	}
+	row := data[0].(map[string]any)
+	if row["unit_id"] != float64(unitID) || row["service_id"] != float64(ktpID) ||
+		row["current_ticket"] != "KTP001" || row["current_loket"] != "Dukcapil" ||
+		row["total_waiting"] != float64(1) || row["total_called_today"] != float64(1) {
+		t.Fatalf("row = %v", row)
+	}
+}
+
+func TestQueueSnapshotData(t *testing.T) {
+	h, _ := testApp(t)
+	_, ktpID := queueFixture(t, h)
+
+	queues, err := h.getQueueData(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// KTP: tiket terakhir dipanggil + waiting tertua; Paspor (unit nonaktif)
+	// tetap ada tanpa tiket; KK tutup tidak ikut
+	got := map[string]string{}
+	for _, q := range queues {
+		got[q.TicketCode] = q.Status
+	}
+	if len(queues) != 3 || got["KTP001"] != "called" || got["KTP002"] != "waiting" || got["-"] != "waiting" {
+		t.Fatalf("queues = %+v", queues)
+	}
+	for _, q := range queues {
+		if q.TicketCode == "KTP001" && (!q.mainDisplay || q.LastCalledAt == nil || q.Loket != "Dukcapil") {
+			t.Fatalf("KTP001 = %+v", q)
+		}
+	}
+
+	if only, _ := h.getQueueData(ktpID); len(only) != 2 {
+		t.Fatalf("getQueueData(ktp) = %+v, want 2 baris", only)
+	}
+
+	stats := h.calculateServiceStats(0)
+	if len(stats) != 1 || stats[ktpID] != (ServiceStats{WaitingCount: 1, HasNext: true}) {
+		t.Fatalf("stats = %v", stats)
+	}
+}
+
+func TestQueueActionsRejectKiosk(t *testing.T) {
+	h := New(memory.New())
+
+	// Principal kiosk: hanya kiosk_id di context, tanpa user_id
+	app := newFiber()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("kiosk_id", int64(1))
+		return c.Next()
+	})
+	app.Post("/queue/call-next", h.CallNextQueue)
+	app.Post("/queue/skip-and-next", h.SkipAndNext)
+	app.Post("/queue/update-status", h.UpdateQueueStatus)
+	app.Post("/queue/recall/:id", h.RecallQueue)
+
+	for _, r := range []struct{ path, body string }{
+		{"/queue/call-next", `{"service_id":1}`},
+		{"/queue/skip-and-next", `{"service_id":1}`},
+		{"/queue/update-status", `{"ticket_id":1,"status":"done"}`},
+		{"/queue/recall/1", ""},
+	} {
+		if status, body := do(t, app, "POST", r.path, r.body); status != fiber.StatusForbidden {
+			t.Fatalf("%s oleh kiosk = %d %v, want 403", r.path, status, body)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package handler
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/permission"
+	"backend-antrian/internal/realtime"
+	"backend-antrian/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// Permission role ini dikunci: super_user supaya admin tidak bisa mengunci
+// dirinya sendiri, kiosk supaya perangkat di lobby tidak bisa diberi akses admin.
+var lockedRolePermissions = map[string]bool{
+	"super_user": true,
+	"kiosk":      true,
+}
+
+// GetPermissionCatalog - Daftar semua permission yang dikenal aplikasi
+func GetPermissionCatalog(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    permission.Catalog,
+	})
+}
+
+// GetAllRoles - Daftar role beserta permission dan jumlah user
+func (h *Handler) GetAllRoles(c *fiber.Ctx) error {
+	roles, err := h.repos.Roles.List(c.UserContext())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil data role",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    roles,
+	})
+}
+
+// GetRoleByName - Detail satu role
+func (h *Handler) GetRoleByName(c *fiber.Ctx) error {
+	role, err := h.repos.Roles.Get(c.UserContext(), c.Params("name"))
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Role tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil data role",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    role,
+	})
+}
+
+// CreateRole - Buat role baru dengan daftar permission
+func (h *Handler) CreateRole(c *fiber.Ctx) error {
+	var req models.CreateRoleRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	req.Label = strings.TrimSpace(req.Label)
+	if !roleNamePattern.MatchString(req.Name) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Nama role hanya boleh huruf kecil, angka, dan underscore (2-50 karakter)",
+		})
+	}
+
+	if req.UnitScoped == "" {
+		req.UnitScoped = "n"
+	}
+	if req.UnitScoped != "y" && req.UnitScoped != "n" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "unit_scoped harus 'y' atau 'n'",
+		})
+	}
+
+	if req.Require2FA == "" {
+		req.Require2FA = "n"
+	}
+	if req.Require2FA != "y" && req.Require2FA != "n" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "require_2fa harus 'y' atau 'n'",
+		})
+	}
+
+	if msg := validatePermissions(req.Permissions); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	ctx := c.UserContext()
+	description := strings.TrimSpace(req.Description)
+	err := h.repos.Roles.Create(ctx, models.Role{
+		Name:        req.Name,
+		Label:       req.Label,
+		Description: &description,
+		UnitScoped:  req.UnitScoped,
+		Require2FA:  req.Require2FA,
+		Permissions: req.Permissions,
+	})
+	if errors.Is(err, repository.ErrDuplicate) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Role sudah ada",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal membuat role",
+		})
+	}
+
+	publishRoleChange(ctx)
+	role, _ := h.repos.Roles.Get(ctx, req.Name)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Role berhasil dibuat",
+		"data":    role,
+	})
+}
+
+// UpdateRole - Update label, deskripsi, unit_scoped, require_2fa, atau permission role.
+// Permission berlaku langsung untuk semua user dengan role ini (tanpa login ulang).
+func (h *Handler) UpdateRole(c *fiber.Ctx) error {
+	var req models.UpdateRoleRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	ctx := c.UserContext()
+	role, err := h.repos.Roles.Get(ctx, c.Params("name"))
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Role tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil data role",
+		})
+	}
+
+	var patch repository.RolePatch
+	patch.Label = strings.TrimSpace(req.Label)
+	if req.Description != nil {
+		description := strings.TrimSpace(*req.Description)
+		patch.Description = &description
+	}
+
+	if req.UnitScoped != "" && req.UnitScoped != role.UnitScoped {
+		if req.UnitScoped != "y" && req.UnitScoped != "n" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "unit_scoped harus 'y' atau 'n'",
+			})
+		}
+		if role.IsSystem == "y" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "unit_scoped role sistem tidak dapat diubah",
+			})
+		}
+		patch.UnitScoped = req.UnitScoped
+	}
+
+	// Kebijakan 2FA boleh diubah termasuk untuk role sistem
+	if req.Require2FA != "" && req.Require2FA != role.Require2FA {
+		if req.Require2FA != "y" && req.Require2FA != "n" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "require_2fa harus 'y' atau 'n'",
+			})
+		}
+		patch.Require2FA = req.Require2FA
+	}
+
+	if req.Permissions != nil {
+		if lockedRolePermissions[role.Name] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("Permission role %s tidak dapat diubah", role.Name),
+			})
+		}
+		if msg := validatePermissions(*req.Permissions); msg != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": msg,
+			})
+		}
+		patch.Permissions = req.Permissions
+	}
+
+	if patch == (repository.RolePatch{}) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Tidak ada data yang diupdate",
+		})
+	}
+
+	if err := h.repos.Roles.Update(ctx, role.Name, patch); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengupdate role",
+		})
+	}
+
+	publishRoleChange(ctx)
+	role, _ = h.repos.Roles.Get(ctx, role.Name)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Role berhasil diupdate",
+		"data":    role,
+	})
+}
+
+// DeleteRole - Hapus role non-sistem yang tidak dipakai user mana pun
+func (h *Handler) DeleteRole(c *fiber.Ctx) error {
+	ctx := c.UserContext()
+	role, err := h.repos.Roles.Get(ctx, c.Params("name"))
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Role tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil data role",
+		})
+	}
+
+	if role.IsSystem == "y" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Role sistem tidak dapat dihapus",
+		})
+	}
+	if role.UserCount > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": fmt.Sprintf("Role masih dipakai %d user", role.UserCount),
+		})
+	}
+
+	if err := h.repos.Roles.Delete(ctx, role.Name); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal menghapus role",
+		})
+	}
+
+	publishRoleChange(ctx)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Role berhasil dihapus",
+	})
+}
+
+/*
+|--------------------------------------------------------------------------
+| Helper
+|--------------------------------------------------------------------------
+*/
+
+// validatePermissions pastikan semua permission ada di katalog
+func validatePermissions(perms []string) string {
+	for _, p := range perms {
+		if !permission.Known(p) {
+			return fmt.Sprintf("Permission '%s' tidak dikenal", p)
+		}
+	}
+	return ""
+}
+
+// lookupAssignableRole cek role bisa diberikan ke user dan apakah wajib punya unit.
+// Role kiosk khusus untuk perangkat, bukan akun user.
+func (h *Handler) lookupAssignableRole(ctx context.Context, name string) (unitScoped bool, msg string) {
+	if name == "kiosk" {
+		return false, "Role 'kiosk' hanya untuk perangkat kiosk"
+	}
+
+	role, err := h.repos.Roles.Get(ctx, name)
+	if errors.Is(err, repository.ErrNotFound) {
+		return false, fmt.Sprintf("Role '%s' tidak ditemukan", name)
+	}
+	if err != nil {
+		return false, "Gagal validasi role"
+	}
+	return role.UnitScoped == "y", ""
+}
+
+// publishRoleChange minta semua replica memuat ulang cache permission
+func publishRoleChange(ctx context.Context) {
+	publishRealtime(ctx, realtime.ChannelRoleChanges, []byte("{}"))
+}
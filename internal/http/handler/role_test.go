@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository/memory"
+	"context"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRoleCRUD(t *testing.T) {
+	h := New(memory.New())
+	ctx := context.Background()
+	app := newFiber()
+	app.Get("/roles", h.GetAllRoles)
+	app.Post("/roles", h.CreateRole)
+	app.Put("/roles/:name", h.UpdateRole)
+	app.Delete("/roles/:name", h.DeleteRole)
+
+	status, body := do(t, app, "POST", "/roles", `{"name":"loket","label":"Loket","description":" ","permissions":["queue.call","queue.call"]}`)
+	if status != fiber.StatusCreated {
+		t.Fatalf("create = %d %v", status, body)
+	}
+	role := body["data"].(map[string]any)
+	if role["description"] != nil || role["unit_scoped"] != "n" || len(role["permissions"].([]any)) != 1 {
+		t.Fatalf("role = %v", role)
+	}
+	if status, _ := do(t, app, "POST", "/roles", `{"name":"loket","label":"Lain"}`); status != fiber.StatusConflict {
+		t.Fatalf("nama duplikat = %d, want 409", status)
+	}
+	if status, _ := do(t, app, "POST", "/roles", `{"name":"x","label":"X","permissions":["tidak.ada"]}`); status != fiber.StatusBadRequest {
+		t.Fatalf("permission asing = %d, want 400", status)
+	}
+
+	if status, _ := do(t, app, "PUT", "/roles/loket", `{}`); status != fiber.StatusBadRequest {
+		t.Fatalf("update kosong = %d, want 400", status)
+	}
+	status, body = do(t, app, "PUT", "/roles/loket", `{"unit_scoped":"y","permissions":["queue.take"]}`)
+	role = body["data"].(map[string]any)
+	if status != fiber.StatusOK || role["unit_scoped"] != "y" || role["permissions"].([]any)[0] != "queue.take" {
+		t.Fatalf("update = %d %v", status, body)
+	}
+	if scoped, msg := h.lookupAssignableRole(ctx, "loket"); !scoped || msg != "" {
+		t.Fatalf("lookupAssignableRole = %v %q", scoped, msg)
+	}
+	if _, msg := h.lookupAssignableRole(ctx, "tamu"); msg == "" {
+		t.Fatal("role tidak terdaftar lolos validasi")
+	}
+
+	// Role yang masih dipakai user tidak boleh dihapus
+	h.repos.Users.CreateWithUnits(ctx, models.User{Nama: "A", Email: "a@x.id", Password: "-", Role: "loket", IsBanned: "n"}, nil)
+	if status, _ := do(t, app, "DELETE", "/roles/loket", ""); status != fiber.StatusConflict {
+		t.Fatalf("hapus role dipakai = %d, want 409", status)
+	}
+	_ = h.repos.Users.HardDelete(ctx, 1)
+	if status, _ := do(t, app, "DELETE", "/roles/loket", ""); status != fiber.StatusOK {
+		t.Fatalf("hapus role = %d, want 200", status)
+	}
+	if _, body := do(t, app, "GET", "/roles", ""); len(body["data"].([]any)) != 0 {
+		t.Fatalf("roles = %v", body)
+	}
+}
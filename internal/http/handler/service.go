@@ -1,10 +1,9 @@
 package handler
 
 import (
-	"backend-antrian/internal/config"
 	"backend-antrian/internal/models"
-	"database/sql"
-	"regexp"
+	"backend-antrian/internal/repository"
+	"errors"
 	"strconv"
 	"strings"
 
@@ -12,54 +11,16 @@ import (
 )
 
 // GetAllServices - Ambil semua service (untuk super_user bisa lihat semua, untuk unit hanya miliknya)
-func GetAllServices(c *fiber.Ctx) error {
-	isActive := c.Query("is_active")
-
-	query := `
-		SELECT 
-			s.id, s.unit_id, s.nama_service, s.code, s.limits_queue, 
-			s.is_active, s.created_at, s.updated_at,
-			u.nama_unit as loket
-		FROM services s
-		INNER JOIN units u ON s.unit_id = u.id
-		WHERE 1=1
-	`
-	args := []interface{}{}
-
+func (h *Handler) GetAllServices(c *fiber.Ctx) error {
 	// Filter aktif / tidak aktif (opsional)
-	if isActive != "" {
-		query += " AND s.is_active = ?"
-		args = append(args, isActive)
-	}
-
-	query += " ORDER BY s.created_at DESC"
-
-	rows, err := config.DB.Query(query, args...)
+	services, err := h.repos.Services.List(c.UserContext(), repository.ServiceFilter{
+		IsActive: c.Query("is_active"),
+	})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal mengambil data service",
 		})
 	}
-	defer rows.Close()
-
-	services := []models.Service{}
-	for rows.Next() {
-		var service models.Service
-		if err := rows.Scan(
-			&service.ID,
-			&service.UnitID,
-			&service.NamaService,
-			&service.Code,
-			&service.LimitsQueue,
-			&service.IsActive,
-			&service.CreatedAt,
-			&service.UpdatedAt,
-			&service.Loket, // dari units.nama_unit
-		); err != nil {
-			continue
-		}
-		services = append(services, service)
-	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -67,62 +28,34 @@ func GetAllServices(c *fiber.Ctx) error {
 	})
 }
 
-func GetServicesByUnitID(c *fiber.Ctx) error {
-	unitID := c.Query("unit_id")
-	isActive := c.Query("is_active")
-
+func (h *Handler) GetServicesByUnitID(c *fiber.Ctx) error {
 	// unit_id wajib
-	if unitID == "" {
+	if c.Query("unit_id") == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "unit_id wajib diisi",
 		})
 	}
 
-	query := `
-		SELECT 
-			s.id, s.unit_id, s.nama_service, s.code, s.limits_queue,
-			s.is_active, s.created_at, s.updated_at,
-			u.nama_unit as loket
-		FROM services s
-		INNER JOIN units u ON s.unit_id = u.id
-		WHERE s.unit_id = ?
-	`
-	args := []interface{}{unitID}
-
-	// filter aktif / nonaktif (opsional)
-	if isActive != "" {
-		query += " AND s.is_active = ?"
-		args = append(args, isActive)
+	unitID, err := strconv.ParseInt(c.Query("unit_id"), 10, 64)
+	if err != nil || unitID <= 0 {
+		// ID tidak valid: tidak ada service yang cocok
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data":    []models.Service{},
+		})
 	}
 
-	query += " ORDER BY s.created_at ASC"
-
-	rows, err := config.DB.Query(query, args...)
+	// filter aktif / nonaktif (opsional)
+	services, err := h.repos.Services.List(c.UserContext(), repository.ServiceFilter{
+		UnitID:      unitID,
+		IsActive:    c.Query("is_active"),
+		OldestFirst: true,
+	})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal mengambil data service",
 		})
 	}
-	defer rows.Close()
-
-	services := []models.Service{}
-	for rows.Next() {
-		var service models.Service
-		if err := rows.Scan(
-			&service.ID,
-			&service.UnitID,
-			&service.NamaService,
-			&service.Code,
-			&service.LimitsQueue,
-			&service.IsActive,
-			&service.CreatedAt,
-			&service.UpdatedAt,
-			&service.Loket, // dari units.nama_unit
-		); err != nil {
-			continue
-		}
-		services = append(services, service)
-	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -131,18 +64,14 @@ func GetServicesByUnitID(c *fiber.Ctx) error {
 }
 
 // GetAllServicesPagination - Ambil semua service dengan pagination (filter by user's unit_id)
-func GetAllServicesPagination(c *fiber.Ctx) error {
-	claims := c.Locals("claims").(*config.JWTClaims)
-	isActive := c.Query("is_active")
-	search := c.Query("search")
+func (h *Handler) GetAllServicesPagination(c *fiber.Ctx) error {
 	page := c.QueryInt("page", 1)
 	limit := c.QueryInt("limit", 10)
 
-	// Validasi: user harus punya unit_id (role unit)
-	if claims.UnitID == nil {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "User tidak memiliki unit",
-		})
+	// Validasi: user harus anggota unit aktif
+	unitID, err := h.activeUnitID(c)
+	if err != nil {
+		return unitAccessError(c, err)
 	}
 
 	// Validasi pagination
@@ -153,83 +82,28 @@ func GetAllServicesPagination(c *fiber.Ctx) error {
 		limit = 10
 	}
 
-	offset := (page - 1) * limit
-
-	// Query untuk hitung total data - SELALU filter by unit_id user yang login
-	countQuery := "SELECT COUNT(*) FROM services WHERE unit_id = ?"
-	countArgs := []interface{}{*claims.UnitID}
-
-	if isActive != "" {
-		countQuery += " AND is_active = ?"
-		countArgs = append(countArgs, isActive)
-	}
-
-	if search != "" {
-		search = "%" + strings.TrimSpace(search) + "%"
-		countQuery += " AND (code LIKE ? OR nama_service LIKE ?)"
-		countArgs = append(countArgs, search, search)
+	// SELALU filter by unit_id user yang login
+	filter := repository.ServiceFilter{
+		UnitID:   unitID,
+		IsActive: c.Query("is_active"),
+		Search:   c.Query("search"),
+		Limit:    limit,
+		Offset:   (page - 1) * limit,
 	}
 
-	var totalData int
-	err := config.DB.QueryRow(countQuery, countArgs...).Scan(&totalData)
+	totalData, err := h.repos.Services.Count(c.UserContext(), filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal menghitung total data",
 		})
 	}
 
-	// Query untuk ambil data dengan pagination - SELALU filter by unit_id user yang login
-	query := `
-		SELECT 
-			s.id, s.unit_id, s.nama_service, s.code, s.limits_queue, 
-			s.is_active, s.created_at, s.updated_at,
-			u.nama_unit as loket
-		FROM services s
-		INNER JOIN units u ON s.unit_id = u.id
-		WHERE s.unit_id = ?
-	`
-	args := []interface{}{*claims.UnitID}
-
-	if isActive != "" {
-		query += " AND s.is_active = ?"
-		args = append(args, isActive)
-	}
-
-	if search != "" {
-		query += " AND (s.code LIKE ? OR s.nama_service LIKE ?)"
-		args = append(args, search, search)
-	}
-
-	query += " ORDER BY s.created_at DESC LIMIT ? OFFSET ?"
-	args = append(args, limit, offset)
-
-	rows, err := config.DB.Query(query, args...)
+	services, err := h.repos.Services.List(c.UserContext(), filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal mengambil data service",
 		})
 	}
-	defer rows.Close()
-
-	services := []models.Service{}
-	for rows.Next() {
-		var service models.Service
-		err := rows.Scan(
-			&service.ID,
-			&service.UnitID,
-			&service.NamaService,
-			&service.Code,
-			&service.LimitsQueue,
-			&service.IsActive,
-			&service.CreatedAt,
-			&service.UpdatedAt,
-			&service.Loket, // dari units.nama_unit
-		)
-		if err != nil {
-			continue
-		}
-		services = append(services, service)
-	}
 
 	// Hitung total pages
 	totalPages := (totalData + limit - 1) / limit
@@ -246,42 +120,25 @@ func GetAllServicesPagination(c *fiber.Ctx) error {
 	})
 }
 
+// unitService - service milik unit aktif user; ErrNotFound jika milik unit lain
+func (h *Handler) unitService(c *fiber.Ctx, id, unitID int64) (models.Service, error) {
+	service, err := h.repos.Services.Get(c.UserContext(), id)
+	if err == nil && service.UnitID != unitID {
+		return models.Service{}, repository.ErrNotFound
+	}
+	return service, err
+}
+
 // GetServiceByID - Ambil service berdasarkan ID
-func GetServiceByID(c *fiber.Ctx) error {
-	claims := c.Locals("claims").(*config.JWTClaims)
-	id := c.Params("id")
-
-	var service models.Service
-	query := `
-		SELECT 
-			s.id, s.unit_id, s.nama_service, s.code, s.limits_queue, 
-			s.is_active, s.created_at, s.updated_at,
-			u.nama_unit as loket
-		FROM services s
-		INNER JOIN units u ON s.unit_id = u.id
-		WHERE s.id = ?
-	`
-	args := []interface{}{id}
-
-	// Jika role unit, pastikan service milik unit tersebut
-	if claims.Role == "unit" && claims.UnitID != nil {
-		query += " AND s.unit_id = ?"
-		args = append(args, *claims.UnitID)
-	}
-
-	err := config.DB.QueryRow(query, args...).Scan(
-		&service.ID,
-		&service.UnitID,
-		&service.NamaService,
-		&service.Code,
-		&service.LimitsQueue,
-		&service.IsActive,
-		&service.CreatedAt,
-		&service.UpdatedAt,
-		&service.Loket, // dari units.nama_unit
-	)
-
-	if err == sql.ErrNoRows {
+func (h *Handler) GetServiceByID(c *fiber.Ctx) error {
+	// Pastikan service milik unit aktif user
+	unitID, err := h.activeUnitID(c)
+	if err != nil {
+		return unitAccessError(c, err)
+	}
+
+	service, err := h.unitService(c, paramID(c, "id"), unitID)
+	if errors.Is(err, repository.ErrNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Service tidak ditemukan",
 		})
@@ -300,99 +157,63 @@ func GetServiceByID(c *fiber.Ctx) error {
 }
 
 // CreateService - Buat service baru (hanya untuk role unit)
-func CreateService(c *fiber.Ctx) error {
-	claims := c.Locals("claims").(*config.JWTClaims)
+func (h *Handler) CreateService(c *fiber.Ctx) error {
 
-	// Validasi: hanya role unit yang bisa create service
-	if claims.Role != "unit" || claims.UnitID == nil {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "Hanya user unit yang bisa membuat service",
-		})
+	// Validasi: user harus anggota unit aktif
+	unitID, err := h.activeUnitID(c)
+	if err != nil {
+		return unitAccessError(c, err)
 	}
 
-	var req struct {
-		NamaService string `json:"nama_service"`
-		Code        string `json:"code"`
-		LimitsQueue int    `json:"limits_queue"`
-		IsActive    string `json:"is_active"`
-	}
+	var req models.CreateServiceRequest
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := bindBody(c, &req); err != nil {
+		return err
 	}
 
-	// Validasi input service
-	if req.NamaService == "" || req.Code == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Nama service dan code wajib diisi",
-		})
-	}
-
-	// Normalisasi code
+	// Normalisasi code (format sudah dicek tag ticketcode)
 	req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
 
-	// Validasi: hanya huruf A-Z, panjang 1-10
-	re := regexp.MustCompile(`^[A-Z]{1,10}$`)
-	if !re.MatchString(req.Code) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Code service harus 1-10 huruf dan tanpa angka atau karakter khusus",
-		})
-	}
-
 	// Set default is_active jika kosong
 	if req.IsActive == "" {
 		req.IsActive = "y"
 	}
 
 	// Cek apakah code sudah ada
-	var count int
-	err := config.DB.QueryRow("SELECT COUNT(*) FROM services WHERE code = ?", req.Code).Scan(&count)
+	exists, err := h.repos.Services.CodeExists(c.UserContext(), req.Code, 0)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal validasi code",
 		})
 	}
 
-	if count > 0 {
+	if exists {
 		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 			"error": "Code service sudah digunakan",
 		})
 	}
 
-	// Insert ke database (unit_id dari JWT token) - TANPA LOKET
-	query := "INSERT INTO services (unit_id, nama_service, code, limits_queue, is_active) VALUES (?, ?, ?, ?, ?)"
-	result, err := config.DB.Exec(query, *claims.UnitID, req.NamaService, req.Code, req.LimitsQueue, req.IsActive)
+	// unit_id dari JWT token - TANPA LOKET
+	id, err := h.repos.Services.Create(c.UserContext(), models.Service{
+		UnitID:      unitID,
+		NamaService: req.NamaService,
+		Code:        req.Code,
+		LimitsQueue: req.LimitsQueue,
+		IsActive:    req.IsActive,
+	})
+	if errors.Is(err, repository.ErrDuplicate) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Code service sudah digunakan",
+		})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal membuat service",
 		})
 	}
 
-	id, _ := result.LastInsertId()
-
-	// Ambil data yang baru dibuat dengan JOIN ke units
-	var service models.Service
-	config.DB.QueryRow(`
-		SELECT 
-			s.id, s.unit_id, s.nama_service, s.code, s.limits_queue, 
-			s.is_active, s.created_at, s.updated_at,
-			u.nama_unit as loket
-		FROM services s
-		INNER JOIN units u ON s.unit_id = u.id
-		WHERE s.id = ?
-	`, id).Scan(
-		&service.ID,
-		&service.UnitID,
-		&service.NamaService,
-		&service.Code,
-		&service.LimitsQueue,
-		&service.IsActive,
-		&service.CreatedAt,
-		&service.UpdatedAt,
-		&service.Loket, // dari units.nama_unit
-	)
+	// Ambil data yang baru dibuat (loket = nama unit)
+	service, _ := h.repos.Services.Get(c.UserContext(), id)
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"success": true,
@@ -402,118 +223,60 @@ func CreateService(c *fiber.Ctx) error {
 }
 
 // UpdateService - Update service berdasarkan ID (hanya untuk role unit)
-func UpdateService(c *fiber.Ctx) error {
-	claims := c.Locals("claims").(*config.JWTClaims)
-	id := c.Params("id")
-
-	// Validasi: hanya role unit yang bisa update service
-	if claims.Role != "unit" || claims.UnitID == nil {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "Hanya user unit yang bisa mengupdate service",
-		})
-	}
+func (h *Handler) UpdateService(c *fiber.Ctx) error {
+	id := paramID(c, "id")
 
-	var req struct {
-		NamaService string `json:"nama_service"`
-		Code        string `json:"code"`
-		LimitsQueue *int   `json:"limits_queue"`
-		IsActive    string `json:"is_active"`
+	// Validasi: user harus anggota unit aktif
+	unitID, err := h.activeUnitID(c)
+	if err != nil {
+		return unitAccessError(c, err)
 	}
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	var req models.UpdateServiceRequest
+
+	if err := bindBody(c, &req); err != nil {
+		return err
 	}
 
-	var exists int
-	err := config.DB.QueryRow("SELECT COUNT(*) FROM services WHERE id = ? AND unit_id = ?", id, *claims.UnitID).Scan(&exists)
-	if err != nil || exists == 0 {
+	if _, err := h.unitService(c, id, unitID); err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Service tidak ditemukan atau bukan milik unit Anda",
 		})
 	}
 
-	query := "UPDATE services SET "
-	args := []interface{}{}
-	updates := []string{}
+	patch := repository.ServicePatch{
+		NamaService: req.NamaService,
+		LimitsQueue: req.LimitsQueue,
+		IsActive:    req.IsActive,
+	}
 
 	if req.Code != "" {
-		req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
-		re := regexp.MustCompile(`^[A-Z]{1,10}$`)
-		if !re.MatchString(req.Code) {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Code service harus 1-10 huruf dan tanpa angka atau karakter khusus",
-			})
-		}
-		var count int
-		config.DB.QueryRow("SELECT COUNT(*) FROM services WHERE code = ? AND id != ?", req.Code, id).Scan(&count)
-		if count > 0 {
+		patch.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+		if exists, _ := h.repos.Services.CodeExists(c.UserContext(), patch.Code, id); exists {
 			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 				"error": "Code service sudah digunakan",
 			})
 		}
-		updates = append(updates, "code = ?")
-		args = append(args, req.Code)
-	}
-
-	if req.NamaService != "" {
-		updates = append(updates, "nama_service = ?")
-		args = append(args, req.NamaService)
 	}
 
-	if req.LimitsQueue != nil {
-		updates = append(updates, "limits_queue = ?")
-		args = append(args, *req.LimitsQueue)
-	}
-
-	if req.IsActive != "" {
-		updates = append(updates, "is_active = ?")
-		args = append(args, req.IsActive)
-	}
-
-	if len(updates) == 0 {
+	if patch == (repository.ServicePatch{}) {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Tidak ada data yang diupdate",
 		})
 	}
 
-	for i, update := range updates {
-		if i > 0 {
-			query += ", "
+	if err := h.repos.Services.Update(c.UserContext(), id, patch); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Code service sudah digunakan",
+			})
 		}
-		query += update
-	}
-	query += " WHERE id = ? AND unit_id = ?"
-	args = append(args, id, *claims.UnitID)
-
-	_, err = config.DB.Exec(query, args...)
-	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal mengupdate service",
 		})
 	}
 
-	var service models.Service
-	config.DB.QueryRow(`
-		SELECT 
-			s.id, s.unit_id, s.nama_service, s.code, s.limits_queue, 
-			s.is_active, s.created_at, s.updated_at,
-			u.nama_unit as loket
-		FROM services s
-		INNER JOIN units u ON s.unit_id = u.id
-		WHERE s.id = ?
-	`, id).Scan(
-		&service.ID,
-		&service.UnitID,
-		&service.NamaService,
-		&service.Code,
-		&service.LimitsQueue,
-		&service.IsActive,
-		&service.CreatedAt,
-		&service.UpdatedAt,
-		&service.Loket, 
-	)
+	service, _ := h.repos.Services.Get(c.UserContext(), id)
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -523,29 +286,24 @@ func UpdateService(c *fiber.Ctx) error {
 }
 
 // DeleteService - Hapus service (soft delete)
-func DeleteService(c *fiber.Ctx) error {
-	claims := c.Locals("claims").(*config.JWTClaims)
-	id := c.Params("id")
-
-	// Validasi: hanya role unit yang bisa delete service
-	if claims.Role != "unit" || claims.UnitID == nil {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "Hanya user unit yang bisa menghapus service",
-		})
+func (h *Handler) DeleteService(c *fiber.Ctx) error {
+	id := paramID(c, "id")
+
+	// Validasi: user harus anggota unit aktif
+	unitID, err := h.activeUnitID(c)
+	if err != nil {
+		return unitAccessError(c, err)
 	}
 
 	// Cek apakah service ada dan milik unit ini
-	var exists int
-	err := config.DB.QueryRow("SELECT COUNT(*) FROM services WHERE id = ? AND unit_id = ?", id, *claims.UnitID).Scan(&exists)
-	if err != nil || exists == 0 {
+	if _, err := h.unitService(c, id, unitID); err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Service tidak ditemukan atau bukan milik unit Anda",
 		})
 	}
 
 	// Soft delete
-	_, err = config.DB.Exec("UPDATE services SET is_active = 'n' WHERE id = ? AND unit_id = ?", id, *claims.UnitID)
-	if err != nil {
+	if err := h.repos.Services.Update(c.UserContext(), id, repository.ServicePatch{IsActive: "n"}); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal menghapus service",
 		})
@@ -558,33 +316,34 @@ func DeleteService(c *fiber.Ctx) error {
 }
 
 // HardDeleteService - Hapus service permanent
-func HardDeleteService(c *fiber.Ctx) error {
-	claims := c.Locals("claims").(*config.JWTClaims)
-	id, _ := strconv.ParseInt(c.Params("id"), 10, 64)
-
-	// Validasi: hanya role unit yang bisa hard delete service
-	if claims.Role != "unit" || claims.UnitID == nil {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "Hanya user unit yang bisa menghapus permanent service",
-		})
-	}
+func (h *Handler) HardDeleteService(c *fiber.Ctx) error {
+	id := paramID(c, "id")
 
-	result, err := config.DB.Exec("DELETE FROM services WHERE id = ? AND unit_id = ?", id, *claims.UnitID)
+	// Validasi: user harus anggota unit aktif
+	unitID, err := h.activeUnitID(c)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Gagal menghapus service",
-		})
+		return unitAccessError(c, err)
+	}
+
+	_, err = h.unitService(c, id, unitID)
+	if err == nil {
+		err = h.repos.Services.Delete(c.UserContext(), id)
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
+	if errors.Is(err, repository.ErrNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Service tidak ditemukan atau bukan milik unit Anda",
 		})
 	}
 
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal menghapus service",
+		})
+	}
+
 	return c.JSON(fiber.Map{
 		"success": true,
 		"message": "Service berhasil dihapus permanent",
 	})
-}
\ No newline at end of file
+}
@@ -1,9 +1,10 @@
 package handler
 
 import (
-	"backend-antrian/internal/config"
-	"database/sql"
+	"backend-antrian/internal/repository"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -30,7 +31,7 @@ type ServiceWithStatus struct {
 }
 
 // GetServicesByUnitIDWithStatus - Menampilkan semua layanan dari unit tertentu dengan status
-func GetServicesByUnitIDWithStatus(c *fiber.Ctx) error {
+func (h *Handler) GetServicesByUnitIDWithStatus(c *fiber.Ctx) error {
 	unitID := c.Query("unit_id")
 
 	if unitID == "" {
@@ -40,15 +41,10 @@ func GetServicesByUnitIDWithStatus(c *fiber.Ctx) error {
 		})
 	}
 
-	// Cek apakah unit ada dan aktif
-	var unitActive string
-	var unitName string
-	err := config.DB.QueryRow(
-		"SELECT is_active, nama_unit FROM units WHERE id = ?",
-		unitID,
-	).Scan(&unitActive, &unitName)
-
-	if err == sql.ErrNoRows {
+	// Cek apakah unit ada
+	id, _ := strconv.ParseInt(unitID, 10, 64)
+	unit, err := h.repos.Units.Get(c.UserContext(), id)
+	if errors.Is(err, repository.ErrNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"success": false,
 			"error":   "Unit tidak ditemukan",
@@ -62,83 +58,45 @@ func GetServicesByUnitIDWithStatus(c *fiber.Ctx) error {
 		})
 	}
 
-	// Query semua services dari unit ini (TANPA filter is_active)
-	// JOIN ke units untuk ambil nama_unit sebagai loket
-	query := `
-		SELECT 
-			s.id, s.unit_id, s.nama_service, s.code, s.limits_queue,
-			s.is_active, s.created_at, s.updated_at,
-			u.nama_unit
-		FROM services s
-		JOIN units u ON s.unit_id = u.id
-		WHERE s.unit_id = ?
-		ORDER BY s.created_at ASC
-	`
+	// Semua services dari unit ini (TANPA filter is_active);
+	// Loket diisi nama unit oleh repository
+	list, err := h.repos.Services.List(c.UserContext(), repository.ServiceFilter{
+		UnitID:      unit.ID,
+		OldestFirst: true,
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   "Gagal mengambil data layanan",
+		})
+	}
 
-	rows, err := config.DB.Query(query, unitID)
+	// Jumlah antrian hari ini (zona unit) per layanan
+	stats, err := h.repos.Tickets.StatsToday(c.UserContext(), unit.ID, unitToday(unit))
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error":   "Gagal mengambil data layanan",
 		})
 	}
-	defer rows.Close()
 
 	services := []ServiceWithStatus{}
-	for rows.Next() {
-		var service ServiceWithStatus
-		var namaUnit string
-		
-		err := rows.Scan(
-			&service.ID,
-			&service.UnitID,
-			&service.NamaService,
-			&service.Code,
-			&service.LimitsQueue,
-			&service.IsActive,
-			&service.CreatedAt,
-			&service.UpdatedAt,
-			&namaUnit,
-		)
-		if err != nil {
-			continue
-		}
-
-		// Field Loket diisi dari nama_unit
-		service.Loket = namaUnit
-
-		// Hitung jumlah antrian hari ini untuk service ini
-		var todayCount int
-		err = config.DB.QueryRow(`
-			SELECT COUNT(*) 
-			FROM queue_tickets 
-			WHERE service_id = ? 
-			AND unit_id = ? 
-			AND DATE(created_at) = CURDATE()
-		`, service.ID, unitID).Scan(&todayCount)
-
-		if err != nil {
-			todayCount = 0
+	for _, s := range list {
+		service := ServiceWithStatus{
+			ID:          s.ID,
+			UnitID:      s.UnitID,
+			NamaService: s.NamaService,
+			Code:        s.Code,
+			Loket:       s.Loket,
+			LimitsQueue: s.LimitsQueue,
+			IsActive:    s.IsActive,
+			CreatedAt:   s.CreatedAt,
+			UpdatedAt:   s.UpdatedAt,
 		}
 
+		todayCount := stats[s.ID].Total
 		service.TodayQueueCount = todayCount
-
-		// TAMBAHAN BARU: Hitung jumlah antrian yang sedang menunggu (status = 'waiting')
-		var waitingCount int
-		err = config.DB.QueryRow(`
-			SELECT COUNT(*) 
-			FROM queue_tickets 
-			WHERE service_id = ? 
-			AND unit_id = ? 
-			AND status = 'waiting'
-			AND DATE(created_at) = CURDATE()
-		`, service.ID, unitID).Scan(&waitingCount)
-
-		if err != nil {
-			waitingCount = 0
-		}
-
-		service.WaitingQueueCount = waitingCount
+		service.WaitingQueueCount = stats[s.ID].Waiting
 
 		// Tentukan status dan message
 		if service.IsActive != "y" {
@@ -166,7 +124,7 @@ func GetServicesByUnitIDWithStatus(c *fiber.Ctx) error {
 		"success": true,
 		"data": fiber.Map{
 			"unit_id":   unitID,
-			"unit_name": unitName,
+			"unit_name": unit.NamaUnit,
 			"services":  services,
 		},
 	})
@@ -0,0 +1,231 @@
+package handler
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/config"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/permission"
+	"backend-antrian/internal/repository"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+|--------------------------------------------------------------------------
+| Session & Refresh Token
+|--------------------------------------------------------------------------
+| Login membuat satu baris user_sessions. Access token (JWT, umur pendek)
+| membawa sid & jti; refresh token disimpan sebagai hash dan dirotasi setiap
+| dipakai. Refresh token lama yang dipakai ulang me-revoke seluruh sesi.
+*/
+
+const sessionJanitorPeriod = time.Hour
+
+// createSession buat sesi baru + access & refresh token untuk user.
+// user.UnitID dipakai sebagai unit aktif sesi.
+func (h *Handler) createSession(c *fiber.Ctx, user models.User) (fiber.Map, error) {
+	sessionID, err := config.RandomHex(16)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := config.RandomHex(32)
+	if err != nil {
+		return nil, err
+	}
+
+	userAgent := c.Get("User-Agent")
+	if len(userAgent) > 255 {
+		userAgent = userAgent[:255]
+	}
+
+	err = h.repos.Sessions.Create(c.UserContext(), repository.NewSession{
+		ID:           sessionID,
+		UserID:       user.ID,
+		ActiveUnitID: user.UnitID,
+		IPAddress:    c.IP(),
+		UserAgent:    userAgent,
+		ExpiresAt:    clock.Now().Add(config.RefreshTokenTTL()),
+		RefreshHash:  hashRefreshToken(refreshToken),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("insert session: %w", err)
+	}
+
+	return sessionTokens(user, sessionID, refreshToken)
+}
+
+// sessionTokens buat access token baru dan susun payload response.
+func sessionTokens(user models.User, sessionID, refreshToken string) (fiber.Map, error) {
+	var unitID *int64
+	if user.UnitID.Valid {
+		unitID = &user.UnitID.Int64
+	}
+
+	accessToken, _, err := config.GenerateAccessToken(user.ID, user.Nama, user.Email, user.Role, unitID, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("generate access token: %w", err)
+	}
+
+	return fiber.Map{
+		"token":         accessToken,
+		"token_type":    "Bearer",
+		"expires_in":    int(config.AccessTokenTTL().Seconds()),
+		"refresh_token": refreshToken,
+		"permissions":   permission.ForRole(user.Role),
+	}, nil
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// RefreshSession - Tukar refresh token dengan access token + refresh token baru (public)
+func (h *Handler) RefreshSession(c *fiber.Ctx) error {
+	var req models.RefreshTokenRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	req.RefreshToken = strings.TrimSpace(req.RefreshToken)
+
+	ctx := c.UserContext()
+	token, err := h.repos.Sessions.RefreshToken(ctx, hashRefreshToken(req.RefreshToken))
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Refresh token tidak valid",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	if token.SessionRevoked {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Sesi sudah berakhir, silakan login ulang",
+		})
+	}
+
+	// Token yang sudah dirotasi dipakai lagi — kemungkinan dicuri
+	if token.Used {
+		return h.refreshReused(c, token.SessionID)
+	}
+
+	if clock.Now().After(token.ExpiresAt) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Refresh token sudah kedaluwarsa, silakan login ulang",
+		})
+	}
+
+	// Ambil data user terbaru — role/unit/status bisa sudah berubah
+	found, err := h.repos.Users.Get(ctx, token.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "User tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	user := found.User
+
+	if user.IsBanned == "y" {
+		h.revokeSession(ctx, token.SessionID, "banned")
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Akun Anda telah diblokir",
+		})
+	}
+
+	// Unit aktif sesi dipertahankan selama user masih anggotanya
+	user.UnitID, err = h.resolveActiveUnit(ctx, user.ID, token.ActiveUnitID, user.UnitID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	newRefreshToken, err := config.RandomHex(32)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal merotasi refresh token",
+		})
+	}
+	err = h.repos.Sessions.Rotate(ctx, token.ID, token.SessionID, hashRefreshToken(newRefreshToken),
+		clock.Now().Add(config.RefreshTokenTTL()), user.UnitID)
+	if errors.Is(err, repository.ErrNotFound) {
+		// Keduluan request lain dengan token yang sama
+		return h.refreshReused(c, token.SessionID)
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal merotasi refresh token",
+		})
+	}
+
+	tokens, err := sessionTokens(user, token.SessionID, newRefreshToken)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate token",
+		})
+	}
+
+	tokens["user"] = models.ToUserResponse(user)
+	return c.JSON(tokens)
+}
+
+// refreshReused revoke sesi yang refresh token lamanya dipakai ulang
+func (h *Handler) refreshReused(c *fiber.Ctx, sessionID string) error {
+	if err := h.revokeSession(c.UserContext(), sessionID, "refresh_reuse"); err != nil {
+		sessionLog.ErrorContext(c.UserContext(), "revoke error", "session_id", sessionID, "err", err)
+	}
+	sessionLog.WarnContext(c.UserContext(), "refresh token reuse detected, session revoked", "session_id", sessionID)
+	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+		"error": "Sesi sudah berakhir, silakan login ulang",
+	})
+}
+
+// revokeSession akhiri satu sesi; semua access token sesi ini langsung ditolak JWTAuth.
+func (h *Handler) revokeSession(ctx context.Context, sessionID, reason string) error {
+	return h.repos.Sessions.Revoke(ctx, sessionID, reason)
+}
+
+// revokeUserSessions akhiri semua sesi aktif milik user (ban, ganti role/unit, logout semua).
+func (h *Handler) revokeUserSessions(ctx context.Context, userID int64, reason string) (int64, error) {
+	return h.repos.Sessions.RevokeUser(ctx, userID, reason)
+}
+
+// denylistToken masukkan jti access token ke denylist sampai token kedaluwarsa.
+func (h *Handler) denylistToken(ctx context.Context, claims *config.JWTClaims) error {
+	if claims == nil || claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+	return h.repos.Sessions.Denylist(ctx, claims.ID, claims.UserID, claims.ExpiresAt.Time)
+}
+
+// RunSessionJanitor hapus denylist, sesi, dan challenge 2FA yang sudah kedaluwarsa secara berkala.
+func (h *Handler) RunSessionJanitor() {
+	ticker := time.NewTicker(sessionJanitorPeriod)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		now := clock.Now()
+		// Sesi kedaluwarsa disimpan 30 hari untuk jejak audit
+		if err := h.repos.Sessions.Cleanup(context.Background(), now, now.Add(-30*24*time.Hour)); err != nil {
+			sessionLog.Error("cleanup sessions error", "err", err)
+		}
+		if err := h.repos.TwoFactor.CleanupChallenges(context.Background(), now); err != nil {
+			sessionLog.Error("cleanup login challenge error", "err", err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"backend-antrian/internal/config"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/permission"
+	"backend-antrian/internal/repository/memory"
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// sessionApp - handler sesi di atas repository in-memory. POST /login
+// membuat sesi untuk user tanpa cek password (password asli: userPassword);
+// route /api membaca access token dari query ?token= (tanpa cek sesi
+// seperti JWTAuth).
+func sessionApp(t *testing.T) (*Handler, *fiber.App, models.User) {
+	t.Helper()
+	t.Setenv("JWT_SECRET", "test-secret")
+	permission.SetSource(func(context.Context) (map[string][]string, error) {
+		return map[string][]string{"unit": {permission.QueueCall}}, nil
+	})
+	t.Cleanup(func() { permission.SetSource(nil) })
+	h := New(memory.New())
+	ctx := context.Background()
+
+	dukcapil, _ := h.repos.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Dukcapil", IsActive: "y", MainDisplay: "active"})
+	pajak, _ := h.repos.Units.Create(ctx, models.Unit{Code: "B", NamaUnit: "Pajak", IsActive: "y", MainDisplay: "active"})
+	hash, _ := bcrypt.GenerateFromPassword([]byte(userPassword), bcrypt.MinCost)
+	user := models.User{Nama: "Petugas", Email: "petugas@x.id", Password: string(hash), Role: "unit", IsBanned: "n",
+		UnitID: sql.NullInt64{Int64: dukcapil, Valid: true}}
+	id, err := h.repos.Users.CreateWithUnits(ctx, user, []int64{pajak, dukcapil})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.ID = id
+	h.recordPasswordHistory(ctx, id, user.Password)
+
+	app := newFiber()
+	app.Post("/login", func(c *fiber.Ctx) error {
+		tokens, err := h.createSession(c, user)
+		if err != nil {
+			return err
+		}
+		return c.JSON(tokens)
+	})
+	app.Post("/refresh", h.RefreshSession)
+	app.Post("/san/login", h.Login)
+	app.Post("/san/login/2fa", h.VerifyLoginTwoFactor)
+	app.Post("/san/password/reset", h.ResetPasswordWithToken)
+	app.Post("/users/:id/password-reset", h.ForcePasswordReset)
+
+	api := app.Group("/api", func(c *fiber.Ctx) error {
+		claims, err := config.ValidateToken(c.Query("token"))
+		if err != nil {
+			return c.SendStatus(fiber.StatusUnauthorized)
+		}
+		c.Locals("claims", claims)
+		c.Locals("user_id", claims.UserID)
+		c.Locals("email", claims.Email)
+		c.Locals("role", claims.Role)
+		c.Locals("session_id", claims.SessionID)
+		if claims.UnitID != nil {
+			c.Locals("unit_id", *claims.UnitID)
+		}
+		return c.Next()
+	})
+	api.Get("/me/units", h.GetMyUnits)
+	api.Post("/me/unit", h.SwitchActiveUnit)
+	api.Post("/logout", h.Logout)
+	api.Get("/me", h.GetMe)
+	api.Put("/me/password", h.ChangeMyPassword)
+	api.Get("/me/2fa", h.GetMyTwoFactor)
+	api.Post("/me/2fa/setup", h.SetupMyTwoFactor)
+	api.Post("/me/2fa/enable", h.EnableMyTwoFactor)
+	return h, app, user
+}
+
+const userPassword = "rahasia123"
+
+func refresh(t *testing.T, app *fiber.App, token string) (int, map[string]any) {
+	t.Helper()
+	return do(t, app, "POST", "/refresh", fmt.Sprintf(`{"refresh_token":%q}`, token))
+}
+
+func TestRefreshRotationAndReuse(t *testing.T) {
+	_, app, _ := sessionApp(t)
+
+	_, login := do(t, app, "POST", "/login", "")
+	first, _ := login["refresh_token"].(string)
+	if first == "" {
+		t.Fatalf("login tanpa refresh token: %v", login)
+	}
+
+	status, body := refresh(t, app, first)
+	if status != fiber.StatusOK || len(body["permissions"].([]any)) != 1 {
+		t.Fatalf("refresh = %d %v", status, body)
+	}
+	second := body["refresh_token"].(string)
+	if second == first {
+		t.Fatal("refresh token tidak dirotasi")
+	}
+
+	if status, _ := refresh(t, app, "salah"); status != fiber.StatusUnauthorized {
+		t.Fatalf("token asing = %d, want 401", status)
+	}
+
+	// Token lama dipakai ulang: sesi di-revoke, token terbaru ikut mati
+	if status, _ := refresh(t, app, first); status != fiber.StatusUnauthorized {
+		t.Fatalf("reuse = %d, want 401", status)
+	}
+	if status, body := refresh(t, app, second); status != fiber.StatusUnauthorized {
+		t.Fatalf("token terbaru setelah reuse = %d %v, want 401", status, body)
+	}
+}
+
+func TestSwitchActiveUnitAndLogout(t *testing.T) {
+	h, app, user := sessionApp(t)
+	ctx := context.Background()
+
+	_, login := do(t, app, "POST", "/login", "")
+	access := login["token"].(string)
+	send := func(method, path, body string) (int, map[string]any) {
+		t.Helper()
+		return do(t, app, method, path+"?token="+access, body)
+	}
+
+	status, body := send("GET", "/api/me/units", "")
+	data := body["data"].(map[string]any)
+	units := data["units"].([]any)
+	if status != fiber.StatusOK || len(units) != 2 || units[0].(map[string]any)["nama_unit"] != "Dukcapil" ||
+		data["active_unit_id"] != float64(user.UnitID.Int64) {
+		t.Fatalf("units = %d %v", status, body)
+	}
+
+	other, _ := h.repos.Units.Create(ctx, models.Unit{Code: "C", NamaUnit: "Lain", IsActive: "y", MainDisplay: "active"})
+	if status, _ := send("POST", "/api/me/unit", fmt.Sprintf(`{"unit_id":%d}`, other)); status != fiber.StatusForbidden {
+		t.Fatalf("switch ke unit bukan anggota = %d, want 403", status)
+	}
+
+	ids, _ := h.repos.Users.UnitIDs(ctx, user.ID)
+	pajak := ids[1]
+	status, body = send("POST", "/api/me/unit", fmt.Sprintf(`{"unit_id":%d}`, pajak))
+	if status != fiber.StatusOK || body["refresh_token"] != nil {
+		t.Fatalf("switch = %d %v", status, body)
+	}
+	access = body["token"].(string)
+
+	// Refresh mempertahankan unit aktif sesi
+	status, body = refresh(t, app, login["refresh_token"].(string))
+	if status != fiber.StatusOK || body["user"].(map[string]any)["unit_id"] != float64(pajak) {
+		t.Fatalf("refresh setelah switch = %d %v", status, body)
+	}
+
+	if status, _ := send("POST", "/api/logout", ""); status != fiber.StatusOK {
+		t.Fatalf("logout = %d", status)
+	}
+	if status, _ := refresh(t, app, body["refresh_token"].(string)); status != fiber.StatusUnauthorized {
+		t.Fatalf("refresh setelah logout = %d, want 401", status)
+	}
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"backend-antrian/internal/realtime"
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gofiber/websocket/v2"
+)
+
+/*
+|--------------------------------------------------------------------------
+| Graceful shutdown
+|--------------------------------------------------------------------------
+|
+| Urutan dari main: listener ditutup & request HTTP ditunggu (Fiber),
+| CloseWebSockets memutus display dengan close frame 1012 "reconnect"
+| supaya langsung menyambung ulang ke instance baru, lalu DrainBroadcasts
+| menunggu broadcast debounce yang masih terjadwal sebelum DB ditutup.
+*/
+
+var (
+	// shuttingDown - broadcast baru tidak dijadwalkan lagi
+	shuttingDown atomic.Bool
+	// pendingBroadcasts - timer debounce (snapshot & legacy) yang belum selesai
+	pendingBroadcasts sync.WaitGroup
+)
+
+// CloseWebSockets kirim close frame "service restart" dengan reason ke semua
+// client /ws/queue dan /ws/units. Koneksi WebSocket di-hijack dari fasthttp,
+// jadi tidak ikut ditunggu app.Shutdown — harus diputus sendiri.
+func CloseWebSockets(ctx context.Context, reason string) error {
+	queueMutex.RLock()
+	clients := make([]*ClientInfo, 0, len(queueClients))
+	for _, client := range queueClients {
+		clients = append(clients, client)
+	}
+	queueMutex.RUnlock()
+
+	// Paralel: satu display lambat tidak menahan yang lain (deadline tulis 1 detik)
+	var wg sync.WaitGroup
+	for _, client := range clients {
+		wg.Add(1)
+		go func(c *ClientInfo) {
+			defer wg.Done()
+			closeClient(c, websocket.CloseServiceRestart, reason)
+		}(client)
+	}
+
+	unitsErr := realtime.Units.CloseAll(ctx, reason)
+
+	if err := waitContext(ctx, wg.Wait); err != nil {
+		return err
+	}
+	queueLog.Info("websocket ditutup untuk shutdown", "queue_clients", len(clients), "reason", reason)
+	return unitsErr
+}
+
+// DrainBroadcasts hentikan penjadwalan broadcast lalu tunggu timer debounce
+// dan delta yang sedang diproses selesai (keduanya masih memakai DB).
+func DrainBroadcasts(ctx context.Context) error {
+	shuttingDown.Store(true)
+
+	// Barrier: schedule* yang sedang memegang mutex selesai menambah pendingBroadcasts
+	broadcastTimerMu.Lock()
+	broadcastTimerMu.Unlock()
+	legacyTimerMu.Lock()
+	legacyTimerMu.Unlock()
+
+	return waitContext(ctx, func() {
+		pendingBroadcasts.Wait()
+		// delta / rebuild yang sedang berjalan memegang queueState.mu
+		queueState.mu.Lock()
+		queueState.mu.Unlock()
+	})
+}
+
+// waitContext jalankan wait (blocking) dan kembali saat selesai atau ctx habis
+func waitContext(ctx context.Context, wait func()) error {
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
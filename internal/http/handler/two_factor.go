@@ -0,0 +1,654 @@
+package handler
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/config"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"backend-antrian/internal/totp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+|--------------------------------------------------------------------------
+| Two-factor authentication (TOTP)
+|--------------------------------------------------------------------------
+| Login dua langkah: password benar → challenge token berumur pendek →
+| kode TOTP (atau kode pemulihan) ditukar dengan access & refresh token.
+| Berlaku untuk user yang sudah mengaktifkan 2FA dan semua user dengan role
+| require_2fa='y'; user tersebut yang belum enrol diarahkan enrolment dulu
+| memakai challenge token yang sama.
+*/
+
+const (
+	loginChallengeTTL         = 5 * time.Minute
+	loginChallengeMaxAttempts = 5
+	recoveryCodeCount         = 10
+)
+
+var (
+	errTOTPNotEnrolled = errors.New("2FA belum diaktifkan")
+	errChallenge       = errors.New("challenge login tidak valid atau kedaluwarsa")
+)
+
+func totpKey() string {
+	return config.GetEnv("TOTP_ENCRYPTION_KEY", os.Getenv("JWT_SECRET"))
+}
+
+func totpIssuer() string {
+	return config.GetEnv("TOTP_ISSUER", "Antrian MPP")
+}
+
+// userTOTP secret (sudah didekripsi) dan status enrolment user
+type userTOTP struct {
+	Secret   string
+	Enabled  bool
+	LastStep int64
+}
+
+func (h *Handler) getUserTOTP(ctx context.Context, userID int64) (userTOTP, error) {
+	t, err := h.repos.TwoFactor.Secret(ctx, userID)
+	if err != nil {
+		return userTOTP{}, err
+	}
+	secret, err := totp.Open(totpKey(), t.SecretEnc)
+	if err != nil {
+		return userTOTP{}, err
+	}
+	return userTOTP{Secret: secret, Enabled: t.Enabled, LastStep: t.LastStep}, nil
+}
+
+// twoFactorState apakah user wajib 2FA (policy role) dan apakah sudah enrol
+func (h *Handler) twoFactorState(ctx context.Context, userID int64, role string) (required, enrolled bool, err error) {
+	r, err := h.repos.Roles.Get(ctx, role)
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return false, false, err
+	}
+
+	t, err := h.repos.TwoFactor.Secret(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return r.Require2FA == "y", false, nil
+	}
+	return r.Require2FA == "y", t.Enabled, err
+}
+
+// startTOTPEnrollment buat (atau ganti) secret yang belum dikonfirmasi
+func (h *Handler) startTOTPEnrollment(ctx context.Context, userID int64, email string) (fiber.Map, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := totp.Seal(totpKey(), secret)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.repos.TwoFactor.StartEnrollment(ctx, userID, sealed); err != nil {
+		return nil, err
+	}
+
+	return fiber.Map{
+		"secret":      secret,
+		"otpauth_uri": totp.URI(totpIssuer(), email, secret),
+		"issuer":      totpIssuer(),
+		"digits":      totp.Digits,
+		"period":      totp.Period,
+	}, nil
+}
+
+// checkTOTPCode validasi kode terhadap secret user dan tandai time step-nya
+// terpakai. pending=true untuk konfirmasi enrolment (secret belum aktif).
+func (h *Handler) checkTOTPCode(ctx context.Context, userID int64, code string, pending bool) (bool, error) {
+	t, err := h.getUserTOTP(ctx, userID)
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && t.Enabled == pending) {
+		return false, errTOTPNotEnrolled
+	}
+	if err != nil {
+		return false, err
+	}
+
+	step, ok := totp.Validate(t.Secret, code, clock.Now())
+	if !ok || step <= t.LastStep {
+		return false, nil
+	}
+	return h.repos.TwoFactor.UseStep(ctx, userID, step)
+}
+
+/*
+|--------------------------------------------------------------------------
+| Kode pemulihan
+|--------------------------------------------------------------------------
+*/
+
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// regenerateRecoveryCodes ganti semua kode pemulihan; plaintext hanya dikembalikan sekali
+func (h *Handler) regenerateRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw, err := config.RandomHex(5)
+		if err != nil {
+			return nil, err
+		}
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	if err := h.repos.TwoFactor.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (h *Handler) remainingRecoveryCodes(ctx context.Context, userID int64) int {
+	n, _ := h.repos.TwoFactor.RecoveryCodesLeft(ctx, userID)
+	return n
+}
+
+/*
+|--------------------------------------------------------------------------
+| Challenge login
+|--------------------------------------------------------------------------
+*/
+
+func hashChallengeToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// createLoginChallenge token langkah kedua; unit aktif yang dipilih ikut disimpan
+func (h *Handler) createLoginChallenge(c *fiber.Ctx, user models.User) (string, error) {
+	token, err := config.RandomHex(32)
+	if err != nil {
+		return "", err
+	}
+	err = h.repos.TwoFactor.CreateChallenge(c.UserContext(), repository.LoginChallenge{
+		Hash:      hashChallengeToken(token),
+		UserID:    user.ID,
+		UnitID:    user.UnitID,
+		IPAddress: c.IP(),
+		ExpiresAt: clock.Now().Add(loginChallengeTTL),
+	})
+	return token, err
+}
+
+// loadLoginChallenge ambil user dari challenge yang masih berlaku
+func (h *Handler) loadLoginChallenge(ctx context.Context, token string) (models.User, error) {
+	ch, err := h.repos.TwoFactor.Challenge(ctx, hashChallengeToken(token), clock.Now())
+	if errors.Is(err, repository.ErrNotFound) || (err == nil && ch.Attempts >= loginChallengeMaxAttempts) {
+		return models.User{}, errChallenge
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+
+	u, err := h.repos.Users.Get(ctx, ch.UserID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.User{}, errChallenge
+	}
+	user := u.User
+	user.UnitID = ch.UnitID
+	return user, err
+}
+
+func challengeError(c *fiber.Ctx, err error) error {
+	if err == errChallenge {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Sesi verifikasi 2FA tidak valid atau kedaluwarsa, silakan login ulang",
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Database error",
+	})
+}
+
+// twoFactorChallengeResponse response Login saat langkah kedua dibutuhkan
+func (h *Handler) twoFactorChallengeResponse(c *fiber.Ctx, user models.User, enrolled bool) error {
+	token, err := h.createLoginChallenge(c, user)
+	if err != nil {
+		authLog.ErrorContext(c.UserContext(), "create 2fa challenge error", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal memulai verifikasi 2FA",
+		})
+	}
+
+	message := "Masukkan kode dari aplikasi authenticator"
+	if !enrolled {
+		message = "Role Anda wajib 2FA. Aktifkan authenticator untuk melanjutkan"
+	}
+	return c.JSON(fiber.Map{
+		"mfa_required":        true,
+		"enrollment_required": !enrolled,
+		"challenge_token":     token,
+		"expires_in":          int(loginChallengeTTL.Seconds()),
+		"message":             message,
+	})
+}
+
+// SetupLoginTwoFactor - Enrolment TOTP di tengah login untuk role wajib 2FA
+func (h *Handler) SetupLoginTwoFactor(c *fiber.Ctx) error {
+	var req models.TwoFactorSetupRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	user, err := h.loadLoginChallenge(c.UserContext(), req.ChallengeToken)
+	if err != nil {
+		return challengeError(c, err)
+	}
+
+	_, enrolled, err := h.twoFactorState(c.UserContext(), user.ID, user.Role)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if enrolled {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "2FA sudah aktif, masukkan kode dari authenticator",
+		})
+	}
+
+	setup, err := h.startTOTPEnrollment(c.UserContext(), user.ID, user.Email)
+	if err != nil {
+		authLog.ErrorContext(c.UserContext(), "setup 2fa error", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal membuat secret 2FA",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Pindai QR lalu kirim kode pertama untuk menyelesaikan login",
+		"data":    setup,
+	})
+}
+
+// VerifyLoginTwoFactor - Tukar challenge token + kode TOTP / kode pemulihan dengan token sesi
+func (h *Handler) VerifyLoginTwoFactor(c *fiber.Ctx) error {
+	var req models.TwoFactorLoginRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	user, err := h.loadLoginChallenge(c.UserContext(), req.ChallengeToken)
+	if err != nil {
+		return challengeError(c, err)
+	}
+
+	guardKeys := loginKeys(c, "user", user.Email)
+	if blocked, err := loginBlocked(c, guardKeys); blocked {
+		return err
+	}
+
+	if user.IsBanned == "y" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Akun Anda telah diblokir",
+		})
+	}
+
+	_, enrolled, err := h.twoFactorState(c.UserContext(), user.ID, user.Role)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	var (
+		ok     bool
+		method = "totp"
+	)
+	switch {
+	case req.RecoveryCode != "" && enrolled:
+		method = "recovery_code"
+		ok, err = h.repos.TwoFactor.UseRecoveryCode(c.UserContext(), user.ID, hashRecoveryCode(req.RecoveryCode))
+	case req.RecoveryCode != "":
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Kode pemulihan belum tersedia, selesaikan enrolment 2FA",
+		})
+	default:
+		// Belum enrol: kode pertama sekaligus konfirmasi secret yang baru dibuat
+		ok, err = h.checkTOTPCode(c.UserContext(), user.ID, req.Code, !enrolled)
+	}
+
+	if err == errTOTPNotEnrolled {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Authenticator belum disiapkan, panggil /san/login/2fa/setup terlebih dahulu",
+		})
+	}
+	if err != nil {
+		authLog.ErrorContext(c.UserContext(), "verify 2fa error", "user_id", user.ID, "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal verifikasi 2FA",
+		})
+	}
+
+	if !ok {
+		if err := h.repos.TwoFactor.FailChallenge(c.UserContext(), hashChallengeToken(req.ChallengeToken)); err != nil {
+			authLog.ErrorContext(c.UserContext(), "2fa challenge attempt error", "err", err)
+		}
+		recordLoginFailure(c, "user", &user.ID, user.Email, "invalid_"+method, guardKeys)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Kode 2FA tidak valid",
+		})
+	}
+
+	// Challenge sekali pakai; gagal hapus = sudah dipakai request paralel
+	err = h.repos.TwoFactor.DeleteChallenge(c.UserContext(), hashChallengeToken(req.ChallengeToken))
+	if errors.Is(err, repository.ErrNotFound) {
+		return challengeError(c, errChallenge)
+	}
+	if err != nil {
+		return challengeError(c, err)
+	}
+	recordLoginSuccess(c, "user", user.Email)
+
+	var recoveryCodes []string
+	if !enrolled {
+		if err := h.repos.TwoFactor.Confirm(c.UserContext(), user.ID); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Gagal mengaktifkan 2FA",
+			})
+		}
+		if recoveryCodes, err = h.regenerateRecoveryCodes(c.UserContext(), user.ID); err != nil {
+			authLog.ErrorContext(c.UserContext(), "recovery codes error", "user_id", user.ID, "err", err)
+		}
+	}
+
+	user.UnitID, err = h.resolveActiveUnit(c.UserContext(), user.ID, user.UnitID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	response, err := h.createSession(c, user)
+	if err != nil {
+		authLog.ErrorContext(c.UserContext(), "create session error", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate token",
+		})
+	}
+
+	creds, _ := h.repos.Users.Credentials(c.UserContext(), user.ID)
+
+	response["user"] = models.ToUserResponse(user)
+	response["units"], _ = h.userUnits(c.UserContext(), user.ID)
+	response["must_change_password"] = creds.MustChangePassword
+	response["message"] = "Login berhasil! Selamat datang kembali, " + user.Nama
+	if recoveryCodes != nil {
+		response["recovery_codes"] = recoveryCodes
+	}
+	if method == "recovery_code" {
+		response["recovery_codes_remaining"] = h.remainingRecoveryCodes(c.UserContext(), user.ID)
+	}
+	return c.JSON(response)
+}
+
+/*
+|--------------------------------------------------------------------------
+| Pengelolaan 2FA oleh user sendiri (/api/me/2fa)
+|--------------------------------------------------------------------------
+*/
+
+// currentUser user yang sedang login (bukan kiosk)
+func currentUser(c *fiber.Ctx) (int64, string, string, bool) {
+	userID, ok := c.Locals("user_id").(int64)
+	if !ok {
+		return 0, "", "", false
+	}
+	email, _ := c.Locals("email").(string)
+	role, _ := c.Locals("role").(string)
+	return userID, email, role, true
+}
+
+func userOnly(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"error": "Hanya untuk akun user",
+	})
+}
+
+// GetMyTwoFactor - Status 2FA user yang sedang login
+func (h *Handler) GetMyTwoFactor(c *fiber.Ctx) error {
+	userID, _, role, ok := currentUser(c)
+	if !ok {
+		return userOnly(c)
+	}
+
+	required, enrolled, err := h.twoFactorState(c.UserContext(), userID, role)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"enabled":                  enrolled,
+			"required":                 required,
+			"recovery_codes_remaining": h.remainingRecoveryCodes(c.UserContext(), userID),
+		},
+	})
+}
+
+// SetupMyTwoFactor - Mulai enrolment: secret baru + URI otpauth untuk QR
+func (h *Handler) SetupMyTwoFactor(c *fiber.Ctx) error {
+	userID, email, role, ok := currentUser(c)
+	if !ok {
+		return userOnly(c)
+	}
+
+	_, enrolled, err := h.twoFactorState(c.UserContext(), userID, role)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if enrolled {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "2FA sudah aktif. Nonaktifkan terlebih dahulu untuk mengganti perangkat",
+		})
+	}
+
+	setup, err := h.startTOTPEnrollment(c.UserContext(), userID, email)
+	if err != nil {
+		authLog.ErrorContext(c.UserContext(), "setup 2fa error", "err", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal membuat secret 2FA",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Pindai QR lalu konfirmasi dengan kode pertama",
+		"data":    setup,
+	})
+}
+
+// EnableMyTwoFactor - Konfirmasi enrolment dengan kode pertama, kembalikan kode pemulihan
+func (h *Handler) EnableMyTwoFactor(c *fiber.Ctx) error {
+	userID, _, _, ok := currentUser(c)
+	if !ok {
+		return userOnly(c)
+	}
+
+	var req models.TwoFactorCodeRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	valid, err := h.checkTOTPCode(c.UserContext(), userID, req.Code, true)
+	if err == errTOTPNotEnrolled {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Tidak ada enrolment 2FA yang menunggu konfirmasi",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal verifikasi kode",
+		})
+	}
+	if !valid {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Kode 2FA tidak valid",
+		})
+	}
+
+	if err := h.repos.TwoFactor.Confirm(c.UserContext(), userID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengaktifkan 2FA",
+		})
+	}
+	codes, err := h.regenerateRecoveryCodes(c.UserContext(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal membuat kode pemulihan",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "2FA berhasil diaktifkan. Simpan kode pemulihan di tempat aman",
+		"data": fiber.Map{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+// RegenerateMyRecoveryCodes - Ganti semua kode pemulihan (butuh kode TOTP saat ini)
+func (h *Handler) RegenerateMyRecoveryCodes(c *fiber.Ctx) error {
+	userID, _, _, ok := currentUser(c)
+	if !ok {
+		return userOnly(c)
+	}
+
+	if err := h.requireCurrentTOTP(c, userID); err != nil {
+		return err
+	}
+
+	codes, err := h.regenerateRecoveryCodes(c.UserContext(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal membuat kode pemulihan",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Kode pemulihan baru berhasil dibuat, kode lama tidak berlaku",
+		"data": fiber.Map{
+			"recovery_codes": codes,
+		},
+	})
+}
+
+// DisableMyTwoFactor - Nonaktifkan 2FA (butuh kode TOTP); ditolak jika role mewajibkan 2FA
+func (h *Handler) DisableMyTwoFactor(c *fiber.Ctx) error {
+	userID, _, role, ok := currentUser(c)
+	if !ok {
+		return userOnly(c)
+	}
+
+	required, _, err := h.twoFactorState(c.UserContext(), userID, role)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	if required {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": fmt.Sprintf("Role %s wajib menggunakan 2FA", role),
+		})
+	}
+
+	if err := h.requireCurrentTOTP(c, userID); err != nil {
+		return err
+	}
+
+	if err := h.repos.TwoFactor.Delete(c.UserContext(), userID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal menonaktifkan 2FA",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "2FA berhasil dinonaktifkan",
+	})
+}
+
+// requireCurrentTOTP parse body {code} dan pastikan cocok dengan 2FA aktif.
+// Mengembalikan response error yang sudah ditulis, atau nil jika lolos.
+func (h *Handler) requireCurrentTOTP(c *fiber.Ctx, userID int64) error {
+	var req models.TwoFactorCodeRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+
+	valid, err := h.checkTOTPCode(c.UserContext(), userID, req.Code, false)
+	if err == errTOTPNotEnrolled {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "2FA belum aktif",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal verifikasi kode",
+		})
+	}
+	if !valid {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Kode 2FA tidak valid",
+		})
+	}
+	return nil
+}
+
+// ResetUserTwoFactor - Admin hapus 2FA user (perangkat hilang). Sesi user ikut di-revoke;
+// jika role mewajibkan 2FA, user akan diminta enrol ulang saat login.
+func (h *Handler) ResetUserTwoFactor(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	if _, err := h.repos.Users.Get(c.UserContext(), int64(id)); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User tidak ditemukan",
+		})
+	}
+
+	if err := h.repos.TwoFactor.Delete(c.UserContext(), int64(id)); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mereset 2FA",
+		})
+	}
+
+	revoked, err := h.revokeUserSessions(c.UserContext(), int64(id), "2fa_reset")
+	if err != nil {
+		sessionLog.ErrorContext(c.UserContext(), "revoke sessions error", "user_id", id, "err", err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success":          true,
+		"message":          "2FA user berhasil direset",
+		"sessions_revoked": revoked,
+	})
+}
@@ -1,10 +1,9 @@
 package handler
 
 import (
-	"backend-antrian/internal/config"
 	"backend-antrian/internal/models"
-	"database/sql"
-	"regexp"
+	"backend-antrian/internal/repository"
+	"errors"
 	"strconv"
 	"strings"
 
@@ -12,45 +11,15 @@ import (
 )
 
 // GetAllUnits - Ambil semua unit
-func GetAllUnits(c *fiber.Ctx) error {
-	isActive := c.Query("is_active")
-
-	query := "SELECT id, code, nama_unit, is_active, main_display, audio_file, created_at, updated_at FROM units WHERE 1=1"
-	args := []interface{}{}
-
-	if isActive != "" {
-		query += " AND is_active = ?"
-		args = append(args, isActive)
-	}
-
-	query += " ORDER BY nama_unit ASC"
-
-	rows, err := config.DB.Query(query, args...)
+func (h *Handler) GetAllUnits(c *fiber.Ctx) error {
+	units, err := h.repos.Units.List(c.UserContext(), repository.UnitFilter{
+		IsActive: c.Query("is_active"),
+	})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal mengambil data unit",
 		})
 	}
-	defer rows.Close()
-
-	units := []models.Unit{}
-	for rows.Next() {
-		var unit models.Unit
-		err := rows.Scan(
-			&unit.ID,
-			&unit.Code,
-			&unit.NamaUnit,
-			&unit.IsActive,
-			&unit.MainDisplay,
-			&unit.AudioFile,
-			&unit.CreatedAt,
-			&unit.UpdatedAt,
-		)
-		if err != nil {
-			continue
-		}
-		units = append(units, unit)
-	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -59,9 +28,7 @@ func GetAllUnits(c *fiber.Ctx) error {
 }
 
 // GetAllUnitsPagination - Ambil semua unit dengan pagination
-func GetAllUnitsPagination(c *fiber.Ctx) error {
-	isActive := c.Query("is_active")
-	search := c.Query("search")
+func (h *Handler) GetAllUnitsPagination(c *fiber.Ctx) error {
 	page := c.QueryInt("page", 1)
 	limit := c.QueryInt("limit", 10)
 
@@ -73,74 +40,27 @@ func GetAllUnitsPagination(c *fiber.Ctx) error {
 		limit = 10
 	}
 
-	offset := (page - 1) * limit
-
-	// Query untuk hitung total data
-	countQuery := "SELECT COUNT(*) FROM units WHERE 1=1"
-	countArgs := []interface{}{}
-
-	if isActive != "" {
-		countQuery += " AND is_active = ?"
-		countArgs = append(countArgs, isActive)
-	}
-
-	if search != "" {
-		search = "%" + strings.TrimSpace(search) + "%"
-		countQuery += " AND (code LIKE ? OR nama_unit LIKE ?)"
-		countArgs = append(countArgs, search, search)
+	filter := repository.UnitFilter{
+		IsActive: c.Query("is_active"),
+		Search:   c.Query("search"),
+		Limit:    limit,
+		Offset:   (page - 1) * limit,
 	}
 
-	var totalData int
-	err := config.DB.QueryRow(countQuery, countArgs...).Scan(&totalData)
+	// Hitung total data
+	totalData, err := h.repos.Units.Count(c.UserContext(), filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal menghitung total data",
 		})
 	}
 
-	// Query untuk ambil data dengan pagination
-	query := "SELECT id, code, nama_unit, is_active, main_display, audio_file, created_at, updated_at FROM units WHERE 1=1"
-	args := []interface{}{}
-
-	if isActive != "" {
-		query += " AND is_active = ?"
-		args = append(args, isActive)
-	}
-
-	if search != "" {
-		query += " AND (code LIKE ? OR nama_unit LIKE ?)"
-		args = append(args, search, search)
-	}
-
-	query += " ORDER BY nama_unit ASC LIMIT ? OFFSET ?"
-	args = append(args, limit, offset)
-
-	rows, err := config.DB.Query(query, args...)
+	units, err := h.repos.Units.List(c.UserContext(), filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal mengambil data unit",
 		})
 	}
-	defer rows.Close()
-
-	units := []models.Unit{}
-	for rows.Next() {
-		var unit models.Unit
-		err := rows.Scan(
-			&unit.ID,
-			&unit.Code,
-			&unit.NamaUnit,
-			&unit.IsActive,
-			&unit.MainDisplay,
-			&unit.AudioFile,
-			&unit.CreatedAt,
-			&unit.UpdatedAt,
-		)
-		if err != nil {
-			continue
-		}
-		units = append(units, unit)
-	}
 
 	// Hitung total pages
 	totalPages := (totalData + limit - 1) / limit
@@ -158,24 +78,9 @@ func GetAllUnitsPagination(c *fiber.Ctx) error {
 }
 
 // GetUnitByID - Ambil unit berdasarkan ID
-func GetUnitByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-
-	var unit models.Unit
-	query := "SELECT id, code, nama_unit, is_active, main_display, audio_file, created_at, updated_at FROM units WHERE id = ?"
-
-	err := config.DB.QueryRow(query, id).Scan(
-		&unit.ID,
-		&unit.Code,
-		&unit.NamaUnit,
-		&unit.IsActive,
-		&unit.MainDisplay,
-		&unit.AudioFile,
-		&unit.CreatedAt,
-		&unit.UpdatedAt,
-	)
-
-	if err == sql.ErrNoRows {
+func (h *Handler) GetUnitByID(c *fiber.Ctx) error {
+	unit, err := h.repos.Units.Get(c.UserContext(), paramID(c, "id"))
+	if errors.Is(err, repository.ErrNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Unit tidak ditemukan",
 		})
@@ -194,39 +99,16 @@ func GetUnitByID(c *fiber.Ctx) error {
 }
 
 // CreateUnit - Buat unit baru
-func CreateUnit(c *fiber.Ctx) error {
-	var req struct {
-		Code        string  `json:"code"`
-		NamaUnit    string  `json:"nama_unit"`
-		IsActive    string  `json:"is_active"`
-		MainDisplay string  `json:"main_display"`
-		AudioFile   *string `json:"audio_file"`
-	}
+func (h *Handler) CreateUnit(c *fiber.Ctx) error {
+	var req models.CreateUnitRequest
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := bindBody(c, &req); err != nil {
+		return err
 	}
 
-	// Validasi input unit
-	if req.Code == "" || req.NamaUnit == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Code dan nama unit wajib diisi",
-		})
-	}
-	
-	// Normalisasi code
+	// Normalisasi code (format sudah dicek tag ticketcode)
 	req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
 
-	// Validasi: hanya huruf A-Z, panjang 1–10
-	re := regexp.MustCompile(`^[A-Z]{1,10}$`)
-	if !re.MatchString(req.Code) {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Code unit harus 1–10 huruf dan tanpa angka atau karakter khusus",
-		})
-	}
-
 	// Set default is_active jika kosong
 	if req.IsActive == "" {
 		req.IsActive = "y"
@@ -235,41 +117,48 @@ func CreateUnit(c *fiber.Ctx) error {
 	if req.MainDisplay == "" {
 		req.MainDisplay = "active"
 	}
+	// Timezone kosong = ikut APP_TIMEZONE
+	if req.Timezone != nil && *req.Timezone == "" {
+		req.Timezone = nil
+	}
 
 	// Cek apakah code sudah ada
-	var count int
-	err := config.DB.QueryRow("SELECT COUNT(*) FROM units WHERE code = ?", req.Code).Scan(&count)
+	exists, err := h.repos.Units.CodeExists(c.UserContext(), req.Code, 0)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal validasi code",
 		})
 	}
 
-	if count > 0 {
+	if exists {
 		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 			"error": "Code unit sudah digunakan",
 		})
 	}
 
-	// Insert ke database
-	query := "INSERT INTO units (code, nama_unit, is_active, main_display, audio_file) VALUES (?, ?, ?, ?, ?)"
-	result, err := config.DB.Exec(query, req.Code, req.NamaUnit, req.IsActive, req.MainDisplay, req.AudioFile)
+	id, err := h.repos.Units.Create(c.UserContext(), models.Unit{
+		Code:        req.Code,
+		NamaUnit:    req.NamaUnit,
+		IsActive:    req.IsActive,
+		MainDisplay: req.MainDisplay,
+		AudioFile:   req.AudioFile,
+		Timezone:    req.Timezone,
+	})
+	if errors.Is(err, repository.ErrDuplicate) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Code unit sudah digunakan",
+		})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal membuat unit",
 		})
 	}
 
-	id, _ := result.LastInsertId()
-
 	// Ambil data yang baru dibuat
-	var unit models.Unit
-	config.DB.QueryRow(
-		"SELECT id, code, nama_unit, is_active, main_display, audio_file, created_at, updated_at FROM units WHERE id = ?",
-		id,
-	).Scan(&unit.ID, &unit.Code, &unit.NamaUnit, &unit.IsActive, &unit.MainDisplay, &unit.AudioFile, &unit.CreatedAt, &unit.UpdatedAt)
-	
-	broadcastUnitsUpdate()
+	unit, _ := h.repos.Units.Get(c.UserContext(), id)
+
+	h.broadcastUnitsUpdate()
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"success": true,
@@ -279,110 +168,60 @@ func CreateUnit(c *fiber.Ctx) error {
 }
 
 // UpdateUnit - Update unit berdasarkan ID
-func UpdateUnit(c *fiber.Ctx) error {
-	id := c.Params("id")
-
-	var req struct {
-		Code        string  `json:"code"`
-		NamaUnit    string  `json:"nama_unit"`
-		IsActive    string  `json:"is_active"`
-		MainDisplay string  `json:"main_display"`
-		AudioFile   *string `json:"audio_file"`
-	}
+func (h *Handler) UpdateUnit(c *fiber.Ctx) error {
+	id := paramID(c, "id")
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	var req models.UpdateUnitRequest
+
+	if err := bindBody(c, &req); err != nil {
+		return err
 	}
 
 	// Cek apakah unit ada
-	var exists int
-	err := config.DB.QueryRow("SELECT COUNT(*) FROM units WHERE id = ?", id).Scan(&exists)
-	if err != nil || exists == 0 {
+	if _, err := h.repos.Units.Get(c.UserContext(), id); err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Unit tidak ditemukan",
 		})
 	}
 
-	// Build dynamic update query
-	query := "UPDATE units SET "
-	args := []interface{}{}
-	updates := []string{}
+	// AudioFile / Timezone bisa di-set null jika dikirim sebagai empty string
+	patch := repository.UnitPatch{
+		NamaUnit:    req.NamaUnit,
+		IsActive:    req.IsActive,
+		MainDisplay: req.MainDisplay,
+		AudioFile:   req.AudioFile,
+		Timezone:    req.Timezone,
+	}
 
 	if req.Code != "" {
-		req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
-		re := regexp.MustCompile(`^[A-Z]{1,10}$`)
-		if !re.MatchString(req.Code) {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Code unit harus 1–10 huruf dan tanpa angka atau karakter khusus",
-			})
-		}
-		var count int
-		config.DB.QueryRow("SELECT COUNT(*) FROM units WHERE code = ? AND id != ?", req.Code, id).Scan(&count)
-		if count > 0 {
+		patch.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+		if exists, _ := h.repos.Units.CodeExists(c.UserContext(), patch.Code, id); exists {
 			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 				"error": "Code unit sudah digunakan",
 			})
 		}
-		updates = append(updates, "code = ?")
-		args = append(args, req.Code)
-	}
-
-	if req.NamaUnit != "" {
-		updates = append(updates, "nama_unit = ?")
-		args = append(args, req.NamaUnit)
 	}
 
-	if req.IsActive != "" {
-		updates = append(updates, "is_active = ?")
-		args = append(args, req.IsActive)
-	}
-	
-	if req.MainDisplay != "" {
-		updates = append(updates, "main_display = ?")
-		args = append(args, req.MainDisplay)
-	}
-
-	// AudioFile bisa di-set null jika dikirim sebagai empty string
-	if req.AudioFile != nil {
-		updates = append(updates, "audio_file = ?")
-		if *req.AudioFile == "" {
-			args = append(args, nil)
-		} else {
-			args = append(args, *req.AudioFile)
-		}
-	}
-
-	if len(updates) == 0 {
+	if patch == (repository.UnitPatch{}) {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Tidak ada data yang diupdate",
 		})
 	}
 
-	for i, update := range updates {
-		if i > 0 {
-			query += ", "
+	if err := h.repos.Units.Update(c.UserContext(), id, patch); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Code unit sudah digunakan",
+			})
 		}
-		query += update
-	}
-	query += " WHERE id = ?"
-	args = append(args, id)
-
-	_, err = config.DB.Exec(query, args...)
-	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal mengupdate unit",
 		})
 	}
 
-	var unit models.Unit
-	config.DB.QueryRow(
-		"SELECT id, code, nama_unit, is_active, main_display, audio_file, created_at, updated_at FROM units WHERE id = ?",
-		id,
-	).Scan(&unit.ID, &unit.Code, &unit.NamaUnit, &unit.IsActive, &unit.MainDisplay, &unit.AudioFile, &unit.CreatedAt, &unit.UpdatedAt)
-	
-	broadcastUnitsUpdate()
+	unit, _ := h.repos.Units.Get(c.UserContext(), id)
+
+	h.broadcastUnitsUpdate()
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -392,27 +231,24 @@ func UpdateUnit(c *fiber.Ctx) error {
 }
 
 // DeleteUnit - Hapus unit (soft delete)
-func DeleteUnit(c *fiber.Ctx) error {
-	id := c.Params("id")
+func (h *Handler) DeleteUnit(c *fiber.Ctx) error {
+	id := paramID(c, "id")
 
 	// Cek apakah unit ada
-	var exists int
-	err := config.DB.QueryRow("SELECT COUNT(*) FROM units WHERE id = ?", id).Scan(&exists)
-	if err != nil || exists == 0 {
+	if _, err := h.repos.Units.Get(c.UserContext(), id); err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Unit tidak ditemukan",
 		})
 	}
 
 	// Soft delete
-	_, err = config.DB.Exec("UPDATE units SET is_active = 'n' WHERE id = ?", id)
-	if err != nil {
+	if err := h.repos.Units.Update(c.UserContext(), id, repository.UnitPatch{IsActive: "n"}); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal menghapus unit",
 		})
 	}
-	
-	broadcastUnitsUpdate()
+
+	h.broadcastUnitsUpdate()
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -421,7 +257,7 @@ func DeleteUnit(c *fiber.Ctx) error {
 }
 
 // HardDeleteUnit - Hapus unit permanent
-func HardDeleteUnit(c *fiber.Ctx) error {
+func (h *Handler) HardDeleteUnit(c *fiber.Ctx) error {
 	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -430,29 +266,26 @@ func HardDeleteUnit(c *fiber.Ctx) error {
 	}
 
 	// Langsung hapus, biar database yang handle foreign key constraint
-	result, err := config.DB.Exec("DELETE FROM units WHERE id = ?", id)
-	if err != nil {
-		// Cek apakah error karena foreign key constraint
-		if strings.Contains(err.Error(), "foreign key constraint") || 
-		   strings.Contains(err.Error(), "FOREIGN KEY") {
-			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
-				"error": "Unit tidak dapat dihapus karena masih digunakan oleh data lain",
-			})
-		}
-		
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Gagal menghapus unit",
+	err = h.repos.Units.Delete(c.UserContext(), id)
+	if errors.Is(err, repository.ErrInUse) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Unit tidak dapat dihapus karena masih digunakan oleh data lain",
 		})
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
+	if errors.Is(err, repository.ErrNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Unit tidak ditemukan",
 		})
 	}
-	
-	broadcastUnitsUpdate()
+
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal menghapus unit",
+		})
+	}
+
+	h.broadcastUnitsUpdate()
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -460,6 +293,8 @@ func HardDeleteUnit(c *fiber.Ctx) error {
 	})
 }
 
-func broadcastUnitsUpdate() {
-	BroadcastUnitsStatus()
-}
\ No newline at end of file
+func (h *Handler) broadcastUnitsUpdate() {
+	// Zona waktu unit bisa berubah
+	h.invalidateUnitZones()
+	h.BroadcastUnitsStatus()
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// unitLocation - zona waktu unit (kolom units.timezone), kosong = APP_TIMEZONE
+func unitLocation(u models.Unit) *time.Location {
+	if u.Timezone == nil {
+		return clock.Location()
+	}
+	return clock.In(*u.Timezone)
+}
+
+// unitToday - rentang hari ini menurut zona unit
+func unitToday(u models.Unit) clock.Day {
+	return clock.Today(unitLocation(u))
+}
+
+// unitTodayByID - unitToday untuk handler yang hanya punya unit_id;
+// unit tidak ditemukan memakai zona aplikasi
+func (h *Handler) unitTodayByID(ctx context.Context, unitID int64) clock.Day {
+	u, err := h.repos.Units.Get(ctx, unitID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrNotFound) {
+			clockLog.ErrorContext(ctx, "load unit error", "unit_id", unitID, "err", err)
+		}
+		return clock.Today(nil)
+	}
+	return unitToday(u)
+}
+
+// zoneCache - unit yang punya zona sendiri (unit_id -> zona), dipakai
+// query lintas unit (display, broadcast). Dikosongkan saat unit berubah,
+// di semua replica lewat onUnitsStatus.
+type zoneCache struct {
+	mu     sync.Mutex
+	loaded bool
+	zones  map[int64]string
+}
+
+// invalidateUnitZones - paksa muat ulang zona unit pada pemakaian berikutnya
+func (h *Handler) invalidateUnitZones() {
+	h.zones.mu.Lock()
+	h.zones.loaded = false
+	h.zones.mu.Unlock()
+}
+
+// unitZones - salinan peta unit_id -> zona untuk unit yang tidak ikut APP_TIMEZONE
+func (h *Handler) unitZones(ctx context.Context) map[int64]string {
+	h.zones.mu.Lock()
+	defer h.zones.mu.Unlock()
+
+	if !h.zones.loaded {
+		list, err := h.repos.Units.List(ctx, repository.UnitFilter{})
+		if err != nil {
+			// Jangan cache kegagalan; sementara semua unit ikut zona aplikasi
+			clockLog.ErrorContext(ctx, "load unit timezones error", "err", err)
+			return map[int64]string{}
+		}
+		zones := map[int64]string{}
+		for _, u := range list {
+			if u.Timezone != nil && clock.In(*u.Timezone) != clock.Location() {
+				zones[u.ID] = *u.Timezone
+			}
+		}
+		h.zones.zones = zones
+		h.zones.loaded = true
+	}
+
+	zones := make(map[int64]string, len(h.zones.zones))
+	for id, name := range h.zones.zones {
+		zones[id] = name
+	}
+	return zones
+}
+
+// today - batas "hari ini" untuk query lintas unit; tiap unit memakai
+// batas hari zonanya sendiri
+func (h *Handler) today(ctx context.Context) repository.Today {
+	t := repository.Today{Default: clock.Today(nil)}
+	zones := h.unitZones(ctx)
+	if len(zones) == 0 {
+		return t
+	}
+	t.Units = make(map[int64]clock.Day, len(zones))
+	for id, name := range zones {
+		t.Units[id] = clock.Today(clock.In(name))
+	}
+	return t
+}
+
+// latestDayStart - awal hari paling akhir di antara zona yang dipakai;
+// data "hari ini" yang dibuat sebelum titik ini sudah basi untuk sebagian unit
+func (h *Handler) latestDayStart(ctx context.Context) time.Time {
+	latest := clock.Today(nil).Start
+	for _, name := range h.unitZones(ctx) {
+		if start := clock.Today(clock.In(name)).Start; start.After(latest) {
+			latest = start
+		}
+	}
+	return latest
+}
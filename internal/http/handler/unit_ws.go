@@ -1,10 +1,9 @@
 package handler
 
 import (
-	"backend-antrian/internal/config"
-	"backend-antrian/internal/helper"
-	"backend-antrian/internal/models"
 	"backend-antrian/internal/realtime"
+	"backend-antrian/internal/repository"
+	"context"
 	"encoding/json"
 
 	"github.com/gofiber/fiber/v2"
@@ -27,14 +26,15 @@ type UnitWithStatus struct {
 	IsActiveDay bool   `json:"is_active_day"` // false = hari ini is_active='n' (libur)
 }
 
-func UnitsWS(c *websocket.Conn) {
+func (h *Handler) UnitsWS(c *websocket.Conn) {
 	realtime.Units.Register <- c
 	defer func() {
 		realtime.Units.Unregister <- c
+		c.Close()
 	}()
 
 	// Kirim status awal semua unit saat client connect
-	payload := buildUnitsStatusPayload()
+	payload := h.buildUnitsStatusPayload()
 	_ = c.WriteMessage(websocket.TextMessage, payload)
 
 	// Listen client (untuk detect disconnect)
@@ -46,12 +46,9 @@ func UnitsWS(c *websocket.Conn) {
 }
 
 // buildUnitsStatusPayload - bangun JSON payload semua unit dengan status jadwal
-func buildUnitsStatusPayload() []byte {
-	rows, err := config.DB.Query(`
-		SELECT id, code, nama_unit, is_active, main_display, audio_file
-		FROM units
-		ORDER BY nama_unit ASC
-	`)
+func (h *Handler) buildUnitsStatusPayload() []byte {
+	ctx := context.Background()
+	list, err := h.repos.Units.List(ctx, repository.UnitFilter{})
 	if err != nil {
 		payload, _ := json.Marshal(fiber.Map{
 			"type":  "units_status",
@@ -59,18 +56,10 @@ func buildUnitsStatusPayload() []byte {
 		})
 		return payload
 	}
-	defer rows.Close()
 
 	var units []UnitWithStatus
-	for rows.Next() {
-		var u models.Unit
-		if err := rows.Scan(
-			&u.ID, &u.Code, &u.NamaUnit, &u.IsActive, &u.MainDisplay, &u.AudioFile,
-		); err != nil {
-			continue
-		}
-
-		status := helper.IsUnitOpen(config.DB, u.ID)
+	for _, u := range list {
+		status := h.unitOpenStatus(ctx, u)
 
 		queueStr := "closed"
 		if status.IsOpen {
@@ -103,9 +92,9 @@ func buildUnitsStatusPayload() []byte {
 	return payload
 }
 
-// BroadcastUnitsStatus - broadcast status semua unit ke semua WS client
+// BroadcastUnitsStatus - broadcast status semua unit ke semua WS client di semua replica
 // Dipanggil setiap kali ada perubahan unit atau jadwal
-func BroadcastUnitsStatus() {
-	payload := buildUnitsStatusPayload()
-	realtime.Units.Broadcast <- payload
+func (h *Handler) BroadcastUnitsStatus() {
+	payload := h.buildUnitsStatusPayload()
+	publishRealtime(context.Background(), realtime.ChannelUnitsStatus, payload)
 }
\ No newline at end of file
@@ -1,43 +1,20 @@
 package handler
 
 import (
-	"backend-antrian/internal/config"
 	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
 	"database/sql"
-	"strconv"
-	"strings"
+	"errors"
+	"fmt"
 
 	"github.com/gofiber/fiber/v2"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // GetUserByID - Ambil user berdasarkan ID
-func GetUserByID(c *fiber.Ctx) error {
-	id := c.Params("id")
-
-	var user models.User
-	var unitName string
-	query := `
-		SELECT u.id, u.nama, u.email, u.role, u.is_banned, u.unit_id, 
-		       COALESCE(un.nama_unit, '') as unit_name, u.created_at, u.updated_at 
-		FROM users u
-		LEFT JOIN units un ON u.unit_id = un.id
-		WHERE u.id = ?
-	`
-
-	err := config.DB.QueryRow(query, id).Scan(
-		&user.ID,
-		&user.Nama,
-		&user.Email,
-		&user.Role,
-		&user.IsBanned,
-		&user.UnitID,
-		&unitName,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
-
-	if err == sql.ErrNoRows {
+func (h *Handler) GetUserByID(c *fiber.Ctx) error {
+	user, err := h.repos.Users.Get(c.UserContext(), paramID(c, "id"))
+	if errors.Is(err, repository.ErrNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "User tidak ditemukan",
 		})
@@ -49,78 +26,44 @@ func GetUserByID(c *fiber.Ctx) error {
 		})
 	}
 
+	response := models.ToUserDetailResponse(user.User, user.UnitName)
+	response.UnitIDs, _ = h.repos.Users.UnitIDs(c.UserContext(), user.ID)
+
 	return c.JSON(fiber.Map{
 		"success": true,
-		"data":    models.ToUserDetailResponse(user, unitName),
+		"data":    response,
 	})
 }
 
-// GetAllUsers - Ambil semua user
-func GetAllUsers(c *fiber.Ctx) error {
-	isBanned := c.Query("is_banned")
-	search := c.Query("search")
-
-	query := `
-		SELECT u.id, u.nama, u.email, u.role, u.is_banned, u.unit_id, 
-		       COALESCE(un.nama_unit, '') as unit_name, u.created_at, u.updated_at 
-		FROM users u
-		LEFT JOIN units un ON u.unit_id = un.id
-		WHERE 1=1
-	`
-	args := []interface{}{}
-
-	if isBanned != "" {
-		query += " AND u.is_banned = ?"
-		args = append(args, isBanned)
+// toUserDetailResponses - list user (repository) ke response API
+func toUserDetailResponses(list []repository.UserWithUnit) []models.UserDetailResponse {
+	users := make([]models.UserDetailResponse, 0, len(list))
+	for _, u := range list {
+		users = append(users, models.ToUserDetailResponse(u.User, u.UnitName))
 	}
+	return users
+}
 
-	if search != "" {
-		search = "%" + strings.TrimSpace(search) + "%"
-		query += " AND (u.email LIKE ? OR u.nama LIKE ?)"
-		args = append(args, search, search)
-	}
-
-	query += " ORDER BY u.created_at DESC"
-
-	rows, err := config.DB.Query(query, args...)
+// GetAllUsers - Ambil semua user
+func (h *Handler) GetAllUsers(c *fiber.Ctx) error {
+	list, err := h.repos.Users.List(c.UserContext(), repository.UserFilter{
+		IsBanned: c.Query("is_banned"),
+		Search:   c.Query("search"),
+	})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal mengambil data user",
 		})
 	}
-	defer rows.Close()
-
-	users := []models.UserDetailResponse{}
-	for rows.Next() {
-		var user models.User
-		var unitName string
-		err := rows.Scan(
-			&user.ID,
-			&user.Nama,
-			&user.Email,
-			&user.Role,
-			&user.IsBanned,
-			&user.UnitID,
-			&unitName,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
-		if err != nil {
-			continue
-		}
-		users = append(users, models.ToUserDetailResponse(user, unitName))
-	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
-		"data":    users,
+		"data":    toUserDetailResponses(list),
 	})
 }
 
 // GetAllUsersPagination - Ambil semua user dengan pagination
-func GetAllUsersPagination(c *fiber.Ctx) error {
-	isBanned := c.Query("is_banned")
-	search := c.Query("search")
+func (h *Handler) GetAllUsersPagination(c *fiber.Ctx) error {
 	page := c.QueryInt("page", 1)
 	limit := c.QueryInt("limit", 10)
 
@@ -132,89 +75,34 @@ func GetAllUsersPagination(c *fiber.Ctx) error {
 		limit = 10
 	}
 
-	offset := (page - 1) * limit
-
-	// Query untuk hitung total data
-	countQuery := "SELECT COUNT(*) FROM users WHERE 1=1"
-	countArgs := []interface{}{}
-
-	if isBanned != "" {
-		countQuery += " AND is_banned = ?"
-		countArgs = append(countArgs, isBanned)
+	filter := repository.UserFilter{
+		IsBanned: c.Query("is_banned"),
+		Search:   c.Query("search"),
+		Limit:    limit,
+		Offset:   (page - 1) * limit,
 	}
 
-	if search != "" {
-		search = "%" + strings.TrimSpace(search) + "%"
-		countQuery += " AND (email LIKE ? OR nama LIKE ?)"
-		countArgs = append(countArgs, search, search)
-	}
-
-	var totalData int
-	err := config.DB.QueryRow(countQuery, countArgs...).Scan(&totalData)
+	// Hitung total data
+	totalData, err := h.repos.Users.Count(c.UserContext(), filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal menghitung total data",
 		})
 	}
 
-	// Query untuk ambil data dengan pagination
-	query := `
-		SELECT u.id, u.nama, u.email, u.role, u.is_banned, u.unit_id, 
-		       COALESCE(un.nama_unit, '') as unit_name, u.created_at, u.updated_at 
-		FROM users u
-		LEFT JOIN units un ON u.unit_id = un.id
-		WHERE 1=1
-	`
-	args := []interface{}{}
-
-	if isBanned != "" {
-		query += " AND u.is_banned = ?"
-		args = append(args, isBanned)
-	}
-
-	if search != "" {
-		query += " AND (u.email LIKE ? OR u.nama LIKE ?)"
-		args = append(args, search, search)
-	}
-
-	query += " ORDER BY u.created_at DESC LIMIT ? OFFSET ?"
-	args = append(args, limit, offset)
-
-	rows, err := config.DB.Query(query, args...)
+	list, err := h.repos.Users.List(c.UserContext(), filter)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal mengambil data user",
 		})
 	}
-	defer rows.Close()
-
-	users := []models.UserDetailResponse{}
-	for rows.Next() {
-		var user models.User
-		var unitName string
-		err := rows.Scan(
-			&user.ID,
-			&user.Nama,
-			&user.Email,
-			&user.Role,
-			&user.IsBanned,
-			&user.UnitID,
-			&unitName,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
-		if err != nil {
-			continue
-		}
-		users = append(users, models.ToUserDetailResponse(user, unitName))
-	}
 
 	// Hitung total pages
 	totalPages := (totalData + limit - 1) / limit
 
 	return c.JSON(fiber.Map{
 		"success": true,
-		"data":    users,
+		"data":    toUserDetailResponses(list),
 		"pagination": fiber.Map{
 			"page":        page,
 			"limit":       limit,
@@ -225,49 +113,35 @@ func GetAllUsersPagination(c *fiber.Ctx) error {
 }
 
 // CreateUser - Buat user baru
-func CreateUser(c *fiber.Ctx) error {
+func (h *Handler) CreateUser(c *fiber.Ctx) error {
 	// Role sudah divalidasi di middleware
 	var req struct {
-		Email     string         `json:"email"`
-		Nama      string         `json:"nama"`
-		UserEmail string         `json:"user_email"`
-		Password  string         `json:"password"`
-		Role      string         `json:"role"`
-		IsBanned  string         `json:"is_banned"`
-		UnitID    *int64 `json:"unit_id"`
-	}
-
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
-	}
-
-	// Validasi input wajib
-	if req.Nama == "" || req.UserEmail == "" || req.Password == "" || req.Role == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Nama, email, password, dan role wajib diisi",
-		})
+		Email     string  `json:"email"`
+		Nama      string  `json:"nama" validate:"required,max=255"`
+		UserEmail string  `json:"user_email" validate:"required,email,max=255"`
+		Password  string  `json:"password" validate:"required"` // aturan lain di validatePassword
+		Role      string  `json:"role" validate:"required,max=50"`
+		IsBanned  string  `json:"is_banned" validate:"omitempty,oneof=y n"`
+		UnitID    *int64  `json:"unit_id" validate:"omitempty,min=0"`
+		UnitIDs   []int64 `json:"unit_ids" validate:"omitempty,dive,gt=0"` // unit tambahan (multi-unit)
 	}
 
-	// Validasi email format
-	if !strings.Contains(req.UserEmail, "@") {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Format email tidak valid",
-		})
+	if err := bindBody(c, &req); err != nil {
+		return err
 	}
 
-	// Validasi role
-	if req.Role != "super_user" && req.Role != "unit" {
+	// Validasi role (harus terdaftar di tabel roles)
+	unitScoped, msg := h.lookupAssignableRole(c.UserContext(), req.Role)
+	if msg != "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Role harus 'super_user' atau 'unit'",
+			"error": msg,
 		})
 	}
 
-	// Validasi password minimal 6 karakter
-	if len(req.Password) < 6 {
+	// Validasi password sesuai policy
+	if msg := validatePassword(req.Password, req.UserEmail); msg != "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Password minimal 6 karakter",
+			"error": msg,
 		})
 	}
 
@@ -276,35 +150,34 @@ func CreateUser(c *fiber.Ctx) error {
 		req.IsBanned = "n"
 	}
 
-	// Validasi is_banned
-	if req.IsBanned != "y" && req.IsBanned != "n" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "is_banned harus 'y' atau 'n'",
-		})
-	}
-
 	// Cek apakah email sudah digunakan
-	var count int
-	err := config.DB.QueryRow("SELECT COUNT(*) FROM users WHERE email = ?", req.UserEmail).Scan(&count)
+	emailUsed, err := h.repos.Users.EmailExists(c.UserContext(), req.UserEmail, 0)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal validasi email",
 		})
 	}
 
-	if count > 0 {
+	if emailUsed {
 		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 			"error": "Email sudah digunakan",
 		})
 	}
 
-	// Validasi unit_id jika role = unit
+	// Validasi unit_id jika role terikat unit (mis. unit, unit_head)
 	var unitID sql.NullInt64
 
-	if req.Role == "unit" {
+	var memberUnitIDs []int64
+
+	if unitScoped {
+		// unit_id = unit default; jika kosong pakai unit pertama dari unit_ids
+		if (req.UnitID == nil || *req.UnitID == 0) && len(uniqueIDs(req.UnitIDs)) > 0 {
+			first := uniqueIDs(req.UnitIDs)[0]
+			req.UnitID = &first
+		}
 		if req.UnitID == nil || *req.UnitID == 0 {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "unit_id wajib diisi untuk role 'unit'",
+				"error": fmt.Sprintf("unit_id wajib diisi untuk role '%s'", req.Role),
 			})
 		}
 
@@ -313,15 +186,10 @@ func CreateUser(c *fiber.Ctx) error {
 			Valid: true,
 		}
 
-		var unitExists int
-		config.DB.QueryRow(
-			"SELECT COUNT(*) FROM units WHERE id = ?", 
-			unitID.Int64,
-		).Scan(&unitExists)
-
-		if unitExists == 0 {
+		memberUnitIDs = uniqueIDs(append(req.UnitIDs, unitID.Int64))
+		if msg := h.validateDisplayScope(c.UserContext(), memberUnitIDs, nil); msg != "" {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Unit ID tidak ditemukan",
+				"error": msg,
 			})
 		}
 	}
@@ -335,111 +203,97 @@ func CreateUser(c *fiber.Ctx) error {
 	}
 
 	// Insert ke database
-	query := "INSERT INTO users (nama, email, password, role, is_banned, unit_id) VALUES (?, ?, ?, ?, ?, ?)"
-	result, err := config.DB.Exec(query, req.Nama, req.UserEmail, string(hashedPassword), req.Role, req.IsBanned, unitID)
+	// Password dari admin: user wajib menggantinya setelah login pertama
+	id, err := h.repos.Users.CreateWithUnits(c.UserContext(), models.User{
+		Nama:     req.Nama,
+		Email:    req.UserEmail,
+		Password: string(hashedPassword),
+		Role:     req.Role,
+		IsBanned: req.IsBanned,
+		UnitID:   unitID,
+	}, memberUnitIDs)
+	if errors.Is(err, repository.ErrDuplicate) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Email sudah digunakan",
+		})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal membuat user",
 		})
 	}
-
-	id, _ := result.LastInsertId()
+	h.recordPasswordHistory(c.UserContext(), id, string(hashedPassword))
 
 	// Ambil data yang baru dibuat dengan join
-	var user models.User
-	var unitName string
-	querySelect := `
-		SELECT u.id, u.nama, u.email, u.role, u.is_banned, u.unit_id, 
-		       COALESCE(un.nama_unit, '') as unit_name, u.created_at, u.updated_at 
-		FROM users u
-		LEFT JOIN units un ON u.unit_id = un.id
-		WHERE u.id = ?
-	`
-	config.DB.QueryRow(querySelect, id).Scan(
-		&user.ID,
-		&user.Nama,
-		&user.Email,
-		&user.Role,
-		&user.IsBanned,
-		&user.UnitID,
-		&unitName,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	created, _ := h.repos.Users.Get(c.UserContext(), id)
+
+	response := models.ToUserDetailResponse(created.User, created.UnitName)
+	response.UnitIDs, _ = h.repos.Users.UnitIDs(c.UserContext(), id)
 
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
 		"success": true,
 		"message": "User berhasil dibuat",
-		"data":    models.ToUserDetailResponse(user, unitName),
+		"data":    response,
 	})
 }
 
 // UpdateUser - Update user berdasarkan ID
-func UpdateUser(c *fiber.Ctx) error {
-	id := c.Params("id")
-
+func (h *Handler) UpdateUser(c *fiber.Ctx) error {
 	// Role sudah divalidasi di middleware
 	var req struct {
-		Email        string        `json:"email"`
-		Nama         string        `json:"nama"`
-		UserEmail    string        `json:"user_email"`
-		Password     string        `json:"password"`
-		Role         string        `json:"role"`
-		IsBanned     string        `json:"is_banned"`
-		UnitID    *int64  `json:"unit_id"`
+		Email     string   `json:"email"`
+		Nama      string   `json:"nama" validate:"omitempty,max=255"`
+		UserEmail string   `json:"user_email" validate:"omitempty,email,max=255"`
+		Password  string   `json:"password"`
+		Role      string   `json:"role" validate:"omitempty,max=50"`
+		IsBanned  string   `json:"is_banned" validate:"omitempty,oneof=y n"`
+		UnitID    *int64   `json:"unit_id" validate:"omitempty,min=0"`
+		UnitIDs   *[]int64 `json:"unit_ids" validate:"omitempty,dive,gt=0"` // ganti seluruh keanggotaan unit
 	}
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := bindBody(c, &req); err != nil {
+		return err
 	}
 
-	// Cek apakah user ada
-	var exists int
-	err := config.DB.QueryRow("SELECT COUNT(*) FROM users WHERE id = ?", id).Scan(&exists)
-	if err != nil || exists == 0 {
+	// Cek apakah user ada, sekaligus simpan role/status/unit lama
+	old, err := h.repos.Users.Get(c.UserContext(), paramID(c, "id"))
+	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "User tidak ditemukan",
 		})
 	}
+	oldUserID, oldRole, oldBanned, oldUnitID := old.ID, old.Role, old.IsBanned, old.UnitID
 
-	// Build dynamic update query
-	query := "UPDATE users SET "
-	args := []interface{}{}
-	updates := []string{}
-
-	if req.Nama != "" {
-		updates = append(updates, "nama = ?")
-		args = append(args, req.Nama)
+	patch := repository.UserPatch{
+		Nama:     req.Nama,
+		IsBanned: req.IsBanned,
 	}
 
 	if req.UserEmail != "" {
-		// Validasi email format (basic)
-		if !strings.Contains(req.UserEmail, "@") {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Format email tidak valid",
-			})
-		}
-
 		// Cek apakah email sudah digunakan user lain
-		var count int
-		config.DB.QueryRow("SELECT COUNT(*) FROM users WHERE email = ? AND id != ?", req.UserEmail, id).Scan(&count)
-		if count > 0 {
+		if used, _ := h.repos.Users.EmailExists(c.UserContext(), req.UserEmail, oldUserID); used {
 			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
 				"error": "Email sudah digunakan",
 			})
 		}
-
-		updates = append(updates, "email = ?")
-		args = append(args, req.UserEmail)
+		patch.Email = req.UserEmail
 	}
 
 	if req.Password != "" {
-		// Validasi minimal length password
-		if len(req.Password) < 6 {
+		// Validasi policy + riwayat password terhadap email yang berlaku
+		email := old.Email
+		if req.UserEmail != "" {
+			email = req.UserEmail
+		}
+		msg, err := h.checkNewPassword(c.UserContext(), oldUserID, req.Password, email)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Gagal validasi password",
+			})
+		}
+		if msg != "" {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Password minimal 6 karakter",
+				"error": msg,
 			})
 		}
 		// Hash password baru
@@ -449,33 +303,24 @@ func UpdateUser(c *fiber.Ctx) error {
 				"error": "Gagal mengenkripsi password",
 			})
 		}
-		updates = append(updates, "password = ?")
-		args = append(args, string(hashedPassword))
+		// Password di-set admin: user wajib menggantinya sendiri
+		patch.PasswordHash = string(hashedPassword)
 	}
+	newPasswordHash := patch.PasswordHash
 
+	newRole := oldRole
 	if req.Role != "" {
-		if req.Role != "super_user" && req.Role != "unit" {
+		if _, msg := h.lookupAssignableRole(c.UserContext(), req.Role); msg != "" {
 			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Role harus 'super_user' atau 'unit'",
+				"error": msg,
 			})
 		}
-		updates = append(updates, "role = ?")
-		args = append(args, req.Role)
+		newRole = req.Role
+		patch.Role = req.Role
 	}
 
-	if req.IsBanned != "" {
-		if req.IsBanned != "y" && req.IsBanned != "n" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "is_banned harus 'y' atau 'n'",
-			})
-		}
-		updates = append(updates, "is_banned = ?")
-		args = append(args, req.IsBanned)
-	}
-
-	// Handle UnitID (bisa null)
-	// Cek apakah field unit_id ada di request body
-	// Handle unit_id (optional)
+	// Handle unit_id (optional, bisa null)
+	newUnitID := oldUnitID
 	if req.UnitID != nil {
 		var unitID sql.NullInt64
 
@@ -486,13 +331,7 @@ func UpdateUser(c *fiber.Ctx) error {
 			}
 		} else {
 			// validasi unit_id
-			var unitExists int
-			config.DB.QueryRow(
-				"SELECT COUNT(*) FROM units WHERE id = ?",
-				*req.UnitID,
-			).Scan(&unitExists)
-
-			if unitExists == 0 {
+			if _, err := h.repos.Units.Get(c.UserContext(), *req.UnitID); err != nil {
 				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 					"error": "Unit ID tidak ditemukan",
 				})
@@ -504,80 +343,132 @@ func UpdateUser(c *fiber.Ctx) error {
 			}
 		}
 
-		updates = append(updates, "unit_id = ?")
-		args = append(args, unitID)
+		newUnitID = unitID
+		patch.UnitID = &newUnitID
 	}
 
+	// Keanggotaan unit (multi-unit); unit default selalu ikut jadi anggota
+	var memberUnitIDs []int64
+	membersChanged := req.UnitIDs != nil || req.UnitID != nil
+	if req.UnitIDs != nil {
+		if msg := h.validateDisplayScope(c.UserContext(), *req.UnitIDs, nil); msg != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": msg,
+			})
+		}
+		memberUnitIDs = uniqueIDs(*req.UnitIDs)
+
+		// Unit default dikeluarkan dari daftar: pindah ke unit pertama
+		if req.UnitID == nil && newUnitID.Valid && !containsID(memberUnitIDs, newUnitID.Int64) {
+			newUnitID = sql.NullInt64{}
+			if len(memberUnitIDs) > 0 {
+				newUnitID = sql.NullInt64{Int64: memberUnitIDs[0], Valid: true}
+			}
+			patch.UnitID = &newUnitID
+		}
+	} else if req.UnitID != nil {
+		// Hanya unit_id yang diganti: pindahkan keanggotaan unit default lama
+		current, err := h.repos.Users.UnitIDs(c.UserContext(), oldUserID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Gagal mengambil unit user",
+			})
+		}
+		for _, uid := range current {
+			if !oldUnitID.Valid || uid != oldUnitID.Int64 {
+				memberUnitIDs = append(memberUnitIDs, uid)
+			}
+		}
+	}
+	if newUnitID.Valid {
+		memberUnitIDs = append(memberUnitIDs, newUnitID.Int64)
+	}
+
+	// Role terikat unit wajib tetap punya unit setelah update
+	if req.Role != "" || membersChanged {
+		if unitScoped, _ := h.lookupAssignableRole(c.UserContext(), newRole); unitScoped && !newUnitID.Valid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": fmt.Sprintf("unit_id wajib diisi untuk role '%s'", newRole),
+			})
+		}
+	}
 
-	if len(updates) == 0 {
+	hasUpdates := patch != (repository.UserPatch{})
+	if !hasUpdates && !membersChanged {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Tidak ada data yang diupdate",
 		})
 	}
 
-	for i, update := range updates {
-		if i > 0 {
-			query += ", "
-		}
-		query += update
+	// nil = keanggotaan tidak diubah; kosong = keluarkan dari semua unit
+	if membersChanged && memberUnitIDs == nil {
+		memberUnitIDs = []int64{}
 	}
-	query += " WHERE id = ?"
-	args = append(args, id)
-
-	_, err = config.DB.Exec(query, args...)
-	if err != nil {
+	if err := h.repos.Users.UpdateWithUnits(c.UserContext(), oldUserID, patch, memberUnitIDs); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": "Email sudah digunakan",
+			})
+		}
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal mengupdate user",
 		})
 	}
+	if newPasswordHash != "" {
+		h.recordPasswordHistory(c.UserContext(), oldUserID, newPasswordHash)
+	}
 
-	// Ambil data user yang sudah diupdate dengan join
-	var user models.User
-	var unitName string
-	querySelect := `
-		SELECT u.id, u.nama, u.email, u.role, u.is_banned, u.unit_id, 
-		       COALESCE(un.nama_unit, '') as unit_name, u.created_at, u.updated_at 
-		FROM users u
-		LEFT JOIN units un ON u.unit_id = un.id
-		WHERE u.id = ?
-	`
-	config.DB.QueryRow(querySelect, id).Scan(
-		&user.ID,
-		&user.Nama,
-		&user.Email,
-		&user.Role,
-		&user.IsBanned,
-		&user.UnitID,
-		&unitName,
-		&user.CreatedAt,
-		&user.UpdatedAt,
-	)
+	// Ambil data user yang sudah diupdate
+	updated, _ := h.repos.Users.Get(c.UserContext(), oldUserID)
+	user := updated.User
+
+	// Ban, ganti role, atau pindah unit: semua sesi lama harus login ulang
+	var revokeReason string
+	switch {
+	case user.IsBanned == "y" && oldBanned != "y":
+		revokeReason = "banned"
+	case user.Role != oldRole:
+		revokeReason = "role_changed"
+	case user.UnitID != oldUnitID:
+		revokeReason = "unit_changed"
+	case newPasswordHash != "":
+		revokeReason = "password_changed"
+	}
+
+	var sessionsRevoked int64
+	if revokeReason != "" {
+		sessionsRevoked, err = h.revokeUserSessions(c.UserContext(), user.ID, revokeReason)
+		if err != nil {
+			sessionLog.ErrorContext(c.UserContext(), "revoke sessions error", "user_id", user.ID, "err", err)
+		}
+	}
+
+	response := models.ToUserDetailResponse(user, updated.UnitName)
+	response.UnitIDs, _ = h.repos.Users.UnitIDs(c.UserContext(), user.ID)
 
 	return c.JSON(fiber.Map{
-		"success": true,
-		"message": "User berhasil diupdate",
-		"data":    models.ToUserDetailResponse(user, unitName),
+		"success":          true,
+		"message":          "User berhasil diupdate",
+		"data":             response,
+		"sessions_revoked": sessionsRevoked,
 	})
 }
 
 // HardDeleteUser - Hapus user permanent
-func HardDeleteUser(c *fiber.Ctx) error {
-	id, _ := strconv.ParseInt(c.Params("id"), 10, 64)
+func (h *Handler) HardDeleteUser(c *fiber.Ctx) error {
+	id := paramID(c, "id")
 
 	// Role sudah divalidasi di middleware
 	var req struct {
 		Email string `json:"email"`
 	}
 
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := bindBody(c, &req); err != nil {
+		return err
 	}
 
 	// Cek apakah user yang akan dihapus adalah super_user terakhir
-	var superUserCount int
-	err := config.DB.QueryRow("SELECT COUNT(*) FROM users WHERE role = 'super_user'").Scan(&superUserCount)
+	superUserCount, err := h.repos.Users.CountByRole(c.UserContext(), "super_user")
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal validasi user",
@@ -585,35 +476,34 @@ func HardDeleteUser(c *fiber.Ctx) error {
 	}
 
 	// Cek role user yang akan dihapus
-	var userRole string
-	err = config.DB.QueryRow("SELECT role FROM users WHERE id = ?", id).Scan(&userRole)
-	if err == sql.ErrNoRows {
+	target, err := h.repos.Users.Get(c.UserContext(), id)
+	if errors.Is(err, repository.ErrNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "User tidak ditemukan",
 		})
 	}
 
 	// Jika super_user tinggal 1 dan yang dihapus adalah super_user, tolak
-	if superUserCount == 1 && userRole == "super_user" {
+	if superUserCount == 1 && target.Role == "super_user" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Tidak dapat menghapus super_user terakhir",
 		})
 	}
 
-	result, err := config.DB.Exec("DELETE FROM users WHERE id = ?", id)
+	// User beserta sesi (refresh token ikut lewat ON DELETE CASCADE), unit,
+	// dan 2FA-nya dihapus dalam satu transaksi
+	err = h.repos.Users.HardDelete(c.UserContext(), id)
+	if errors.Is(err, repository.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User tidak ditemukan",
+		})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Gagal menghapus user",
 		})
 	}
 
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "User tidak ditemukan",
-		})
-	}
-
 	return c.JSON(fiber.Map{
 		"success": true,
 		"message": "User berhasil dihapus permanent",
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"backend-antrian/internal/config"
+	"backend-antrian/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+/*
+|--------------------------------------------------------------------------
+| User ↔ Unit
+|--------------------------------------------------------------------------
+| User bisa anggota beberapa unit (tabel user_units). Unit aktif disimpan di
+| sesi dan dibawa JWT sebagai unit_id. Handler yang butuh unit wajib lewat
+| activeUnitID supaya keanggotaan dicek ulang — anggota yang dikeluarkan dari
+| unit langsung kehilangan akses tanpa menunggu token kedaluwarsa.
+*/
+
+var (
+	errNoActiveUnit  = errors.New("user tidak memiliki unit aktif")
+	errNotUnitMember = errors.New("user bukan anggota unit")
+)
+
+// activeUnitID ambil unit aktif dari token dan pastikan user masih anggotanya.
+func (h *Handler) activeUnitID(c *fiber.Ctx) (int64, error) {
+	unitID, ok := c.Locals("unit_id").(int64)
+	if !ok {
+		return 0, errNoActiveUnit
+	}
+	userID, ok := c.Locals("user_id").(int64)
+	if !ok {
+		return 0, errNoActiveUnit
+	}
+
+	member, err := h.repos.Users.IsUnitMember(c.UserContext(), userID, unitID)
+	if err != nil {
+		return 0, err
+	}
+	if !member {
+		return 0, errNotUnitMember
+	}
+	return unitID, nil
+}
+
+// unitAccessError response standar untuk error dari activeUnitID.
+func unitAccessError(c *fiber.Ctx, err error) error {
+	switch err {
+	case errNoActiveUnit:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error":   "User tidak memiliki unit",
+		})
+	case errNotUnitMember:
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error":   "Anda bukan lagi anggota unit ini, silakan pilih unit lain",
+		})
+	}
+
+	userLog.ErrorContext(c.UserContext(), "cek keanggotaan unit error", "err", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"success": false,
+		"error":   "Gagal memvalidasi unit",
+	})
+}
+
+// userUnits daftar unit anggota user beserta namanya (untuk pilihan di frontend)
+func (h *Handler) userUnits(ctx context.Context, userID int64) ([]fiber.Map, error) {
+	list, err := h.repos.Users.Units(ctx, userID)
+	if err != nil {
+		return []fiber.Map{}, err
+	}
+
+	units := make([]fiber.Map, 0, len(list))
+	for _, u := range list {
+		units = append(units, fiber.Map{
+			"id":        u.ID,
+			"nama_unit": u.NamaUnit,
+		})
+	}
+	return units, nil
+}
+
+// resolveActiveUnit pilih unit aktif: kandidat pertama yang masih dianggotai user,
+// lalu unit anggota dengan id terkecil, atau NULL jika user tidak punya unit.
+func (h *Handler) resolveActiveUnit(ctx context.Context, userID int64, candidates ...sql.NullInt64) (sql.NullInt64, error) {
+	for _, cand := range candidates {
+		if !cand.Valid {
+			continue
+		}
+		member, err := h.repos.Users.IsUnitMember(ctx, userID, cand.Int64)
+		if err != nil {
+			return sql.NullInt64{}, err
+		}
+		if member {
+			return cand, nil
+		}
+	}
+
+	ids, err := h.repos.Users.UnitIDs(ctx, userID)
+	if err != nil || len(ids) == 0 {
+		return sql.NullInt64{}, err
+	}
+	return sql.NullInt64{Int64: ids[0], Valid: true}, nil
+}
+
+func containsID(ids []int64, id int64) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// GetMyUnits - Daftar unit milik user yang sedang login beserta unit aktif
+func (h *Handler) GetMyUnits(c *fiber.Ctx) error {
+	userID, ok := c.Locals("user_id").(int64)
+	if !ok {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Hanya untuk akun user",
+		})
+	}
+
+	units, err := h.userUnits(c.UserContext(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengambil unit user",
+		})
+	}
+
+	var active *int64
+	if id, ok := c.Locals("unit_id").(int64); ok {
+		active = &id
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"active_unit_id": active,
+			"units":          units,
+		},
+	})
+}
+
+// SwitchActiveUnit - Ganti unit aktif sesi saat ini dan terbitkan access token baru.
+// Refresh token tetap sama; access token lama langsung masuk denylist.
+func (h *Handler) SwitchActiveUnit(c *fiber.Ctx) error {
+	claims, _ := c.Locals("claims").(*config.JWTClaims)
+	if claims == nil || claims.SessionID == "" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Hanya untuk akun user",
+		})
+	}
+
+	var req models.SwitchUnitRequest
+	if err := bindBody(c, &req); err != nil {
+		return err
+	}
+	ctx := c.UserContext()
+	member, err := h.repos.Users.IsUnitMember(ctx, claims.UserID, req.UnitID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal memvalidasi unit",
+		})
+	}
+	if !member {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Anda bukan anggota unit ini",
+		})
+	}
+
+	found, err := h.repos.Users.Get(ctx, claims.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+	user := found.User
+	user.UnitID = sql.NullInt64{Int64: req.UnitID, Valid: true}
+
+	if err := h.repos.Sessions.SetActiveUnit(ctx, claims.SessionID, req.UnitID); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Gagal mengganti unit aktif",
+		})
+	}
+
+	if err := h.denylistToken(ctx, claims); err != nil {
+		sessionLog.ErrorContext(c.UserContext(), "denylist error", "jti", claims.ID, "err", err)
+	}
+
+	tokens, err := sessionTokens(user, claims.SessionID, "")
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate token",
+		})
+	}
+	delete(tokens, "refresh_token")
+
+	tokens["user"] = models.ToUserResponse(user)
+	tokens["message"] = "Unit aktif berhasil diganti"
+	return c.JSON(tokens)
+}
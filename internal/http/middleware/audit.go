@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"backend-antrian/internal/audit"
+	"backend-antrian/internal/validation"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Audit - Catat setiap request yang mengubah data (POST/PUT/PATCH/DELETE) ke audit_logs,
+// plus request lain yang ditandai AuditAction (mis. download backup).
+// Dipasang di group /api setelah JWTAuth, jadi actor selalu diketahui.
+func Audit() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		mutating := c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead && c.Method() != fiber.MethodOptions
+
+		// Snapshot sebelum handler berjalan; route belum diketahui di titik ini,
+		// jadi entity & id diambil dari path mentah.
+		entity, entityID := auditTarget(c.Path())
+		var before interface{}
+		if mutating && entityID != "" {
+			before = audit.Snapshot(c.UserContext(), entity, entityID)
+		}
+
+		err := c.Next()
+
+		action, forced := c.Locals("audit_action").(string)
+		if !mutating && !forced {
+			return err
+		}
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			var ve *validation.Error
+			if fe, ok := err.(*fiber.Error); ok {
+				status = fe.Code
+			} else if errors.As(err, &ve) {
+				status = fiber.StatusUnprocessableEntity
+			} else {
+				status = fiber.StatusInternalServerError
+			}
+		}
+
+		if !forced {
+			action = auditAction(c.Method(), c.Route().Path, entity)
+		}
+
+		// Entity baru: ambil id dari response {"data": {"id": ...}}
+		success := status < 400
+		if success && entityID == "" && c.Method() == fiber.MethodPost && audit.Known(entity) {
+			entityID = createdID(c.Response().Body())
+		}
+
+		var after interface{}
+		if success && entityID != "" {
+			after = audit.Snapshot(c.UserContext(), entity, entityID)
+		}
+
+		entry := audit.Entry{
+			Action:     action,
+			Entity:     entity,
+			EntityID:   entityID,
+			Before:     before,
+			After:      after,
+			StatusCode: status,
+			Method:     c.Method(),
+			Path:       c.OriginalURL(),
+			IP:         c.IP(),
+			UserAgent:  c.Get("User-Agent"),
+		}
+		entry.ActorType, entry.ActorID, entry.ActorName = auditActor(c)
+
+		audit.Record(c.UserContext(), entry)
+		return err
+	}
+}
+
+// AuditAction - Tandai route agar dicatat dengan nama aksi tertentu,
+// termasuk request GET yang sensitif (backup, ekspor).
+func AuditAction(action string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.Locals("audit_action", action)
+		return c.Next()
+	}
+}
+
+func auditActor(c *fiber.Ctx) (string, *int64, string) {
+	nama, _ := c.Locals("nama").(string)
+	if id, ok := c.Locals("user_id").(int64); ok {
+		return "user", &id, nama
+	}
+	if id, ok := c.Locals("kiosk_id").(int64); ok {
+		return "kiosk", &id, nama
+	}
+	return "system", nil, nama
+}
+
+// auditTarget petakan path /api/... ke entity audit dan id-nya.
+func auditTarget(path string) (string, string) {
+	segs := strings.Split(strings.Trim(strings.TrimPrefix(path, "/api"), "/"), "/")
+
+	switch {
+	case len(segs) >= 3 && segs[0] == "units" && segs[2] == "schedules":
+		return "unit_schedules", segs[1]
+	case len(segs) >= 3 && segs[0] == "queue":
+		// /queue/recall/:id, /queue/announce/:id
+		return "queue_tickets", segs[2]
+	case len(segs) >= 2 && audit.Known(segs[0]):
+		return segs[0], segs[1]
+	}
+	return segs[0], ""
+}
+
+// auditAction nama aksi dari pola route, mis.
+// POST /api/units → units.create, DELETE /api/units/:id/permanent → units.hard_delete,
+// POST /api/queue/call-next → queue.call_next.
+func auditAction(method, routePath, entity string) string {
+	var statics []string
+	for _, seg := range strings.Split(strings.Trim(strings.TrimPrefix(routePath, "/api"), "/"), "/") {
+		if seg == "" || strings.HasPrefix(seg, ":") {
+			continue
+		}
+		statics = append(statics, strings.ReplaceAll(seg, "-", "_"))
+	}
+	if len(statics) == 0 {
+		return strings.ToLower(method)
+	}
+
+	if len(statics) == 1 {
+		if !audit.Known(entity) {
+			return statics[0]
+		}
+		switch method {
+		case fiber.MethodPost:
+			return statics[0] + ".create"
+		case fiber.MethodDelete:
+			return statics[0] + ".delete"
+		}
+		return statics[0] + ".update"
+	}
+
+	if statics[len(statics)-1] == "permanent" {
+		return strings.Join(statics[:len(statics)-1], ".") + ".hard_delete"
+	}
+	action := strings.Join(statics, ".")
+	if method == fiber.MethodDelete {
+		action += ".delete"
+	}
+	return action
+}
+
+func createdID(body []byte) string {
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if json.Unmarshal(body, &resp) != nil || resp.Data == nil {
+		return ""
+	}
+
+	if id := idValue(resp.Data); id != "" {
+		return id
+	}
+	// Response bersarang, mis. {"data": {"kiosk": {...}, "secret": "..."}}
+	for _, v := range resp.Data {
+		if m, ok := v.(map[string]interface{}); ok {
+			if id := idValue(m); id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+func idValue(m map[string]interface{}) string {
+	for _, key := range []string{"id", "name"} {
+		switch v := m[key].(type) {
+		case float64:
+			return fmt.Sprintf("%.0f", v)
+		case string:
+			if v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
@@ -1,16 +1,23 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"os"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/basicauth"
 )
 
+// BasicAuth - kredensial dari BASIC_AUTH_USER / BASIC_AUTH_PASS.
+// Jika BASIC_AUTH_USER kosong semua request ditolak (bukan terbuka).
 func BasicAuth() fiber.Handler {
+	user := os.Getenv("BASIC_AUTH_USER")
+	pass := os.Getenv("BASIC_AUTH_PASS")
 	return basicauth.New(basicauth.Config{
-		Users: map[string]string{
-			os.Getenv("BASIC_AUTH_USER"): os.Getenv("BASIC_AUTH_PASS"),
+		Authorizer: func(u, p string) bool {
+			return user != "" &&
+				subtle.ConstantTimeCompare([]byte(u), []byte(user)) == 1 &&
+				subtle.ConstantTimeCompare([]byte(p), []byte(pass)) == 1
 		},
 		Unauthorized: func(c *fiber.Ctx) error {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
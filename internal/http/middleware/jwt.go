@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"backend-antrian/internal/clock"
 	"backend-antrian/internal/config"
+	"database/sql"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
@@ -30,11 +32,59 @@ func JWTAuth() fiber.Handler {
 			})
 		}
 
+		// Principal kiosk divalidasi terhadap tabel kiosks, bukan users
+		if claims.Role == "kiosk" {
+			return kioskAuth(c, claims)
+		}
+
+		// Token lama (tanpa sesi) tidak diterima lagi
+		if claims.ID == "" || claims.SessionID == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid or expired token",
+			})
+		}
+
+		// Cek status user, sesi, dan denylist jti dalam satu query
+		var isBanned string
+		var sessionRevoked, tokenRevoked bool
+		err = config.DB.QueryRow(`
+			SELECT u.is_banned,
+			       (s.id IS NULL OR s.revoked_at IS NOT NULL OR s.expires_at < ?),
+			       EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = ?)
+			FROM users u
+			LEFT JOIN user_sessions s ON s.id = ? AND s.user_id = u.id
+			WHERE u.id = ?
+		`, clock.SQL(clock.Now()), claims.ID, claims.SessionID, claims.UserID).Scan(&isBanned, &sessionRevoked, &tokenRevoked)
+		if err == sql.ErrNoRows {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User tidak ditemukan",
+			})
+		}
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Database error",
+			})
+		}
+
+		if isBanned == "y" {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Akun Anda telah diblokir",
+			})
+		}
+
+		if sessionRevoked || tokenRevoked {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Sesi sudah berakhir, silakan login ulang",
+			})
+		}
+
 		c.Locals("claims", claims)
 		c.Locals("user_id", claims.UserID)
 		c.Locals("nama", claims.Nama)
 		c.Locals("email", claims.Email)
 		c.Locals("role", claims.Role)
+		c.Locals("session_id", claims.SessionID)
+		c.Locals("jti", claims.ID)
 		if claims.UnitID != nil {
 			c.Locals("unit_id", *claims.UnitID)
 		}
@@ -64,3 +114,51 @@ func RoleAuth(allowedRoles ...string) fiber.Handler {
 		})
 	}
 }
+
+// kioskAuth validasi token perangkat kiosk: kiosk masih aktif, token terbit
+// setelah rotasi kredensial terakhir, dan jti belum masuk denylist.
+func kioskAuth(c *fiber.Ctx, claims *config.JWTClaims) error {
+	if claims.ID == "" || claims.KioskID == 0 || claims.IssuedAt == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or expired token",
+		})
+	}
+
+	var isActive string
+	var tokenStale, tokenRevoked bool
+	err := config.DB.QueryRow(`
+		SELECT is_active, tokens_valid_after > ?,
+		       EXISTS(SELECT 1 FROM revoked_tokens WHERE jti = ?)
+		FROM kiosks WHERE id = ?
+	`, clock.SQL(claims.IssuedAt.Time), claims.ID, claims.KioskID).Scan(&isActive, &tokenStale, &tokenRevoked)
+	if err == sql.ErrNoRows {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Kiosk tidak ditemukan",
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Database error",
+		})
+	}
+
+	if isActive != "y" {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Kiosk tidak aktif",
+		})
+	}
+
+	if tokenRevoked || tokenStale {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Sesi sudah berakhir, silakan login ulang",
+		})
+	}
+
+	c.Locals("claims", claims)
+	c.Locals("nama", claims.Nama)
+	c.Locals("role", claims.Role)
+	c.Locals("kiosk_id", claims.KioskID)
+	c.Locals("jti", claims.ID)
+
+	return c.Next()
+}
@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"backend-antrian/internal/permission"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Require - Middleware cek permission role user (semua permission wajib dimiliki).
+// Mapping role → permission diambil dari tabel role_permissions.
+func Require(perms ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, ok := c.Locals("role").(string)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Role tidak valid",
+			})
+		}
+
+		for _, perm := range perms {
+			if !permission.Has(role, perm) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "Anda tidak memiliki akses ke resource ini",
+				})
+			}
+		}
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"backend-antrian/internal/logger"
+	"backend-antrian/internal/metrics"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// HeaderRequestID header korelasi request (diterima dari proxy / client, dikembalikan di response)
+const HeaderRequestID = "X-Request-ID"
+
+// requestIDPattern ID dari luar hanya dipakai jika aman ditulis ke log
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+var httpLog = logger.For("http")
+
+// probePaths health check & scrape — access log-nya cukup di level debug
+var probePaths = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true}
+
+// httpDuration latensi per route (pola, bukan path mentah, supaya label tidak meledak)
+var httpDuration = metrics.NewHistogram("http_request_duration_seconds",
+	"Latensi request HTTP per route", metrics.DefBuckets, "method", "route", "status")
+
+// RequestID - beri setiap request ID (X-Request-ID dari nginx/client jika valid,
+// selain itu acak), simpan di c.UserContext() supaya log handler, query repository,
+// dan broadcast yang dipicu request ini membawa request_id yang sama.
+// Dipasang paling awal; sekaligus mencatat access log dan metrik latensi
+// setelah request selesai.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(HeaderRequestID)
+		if !requestIDPattern.MatchString(id) {
+			id = newRequestID()
+		}
+		c.Set(HeaderRequestID, id)
+		c.Locals("request_id", id)
+		c.SetUserContext(logger.WithRequestID(c.UserContext(), id))
+
+		start := time.Now()
+		err := c.Next()
+		if err != nil {
+			// Jalankan ErrorHandler sekarang supaya status di access log sudah final
+			if herr := c.App().Config().ErrorHandler(c, err); herr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		elapsed := time.Since(start)
+		// Upgrade WebSocket (101) berdurasi selama koneksi hidup — bukan latensi
+		if status != fiber.StatusSwitchingProtocols {
+			httpDuration.Observe(elapsed.Seconds(), c.Method(), c.Route().Path, strconv.Itoa(status))
+		}
+
+		level := slog.LevelInfo
+		if probePaths[c.Path()] {
+			level = slog.LevelDebug // dipanggil tiap beberapa detik oleh LB / Docker
+		}
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		httpLog.LogAttrs(c.UserContext(), level, "request",
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.String("route", c.Route().Path),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+			slog.String("ip", c.IP()),
+		)
+		return nil
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,114 @@
+// Package logger konfigurasi log/slog aplikasi.
+//
+// Output JSON satu baris per event (LOG_FORMAT=text untuk development),
+// level minimum dari LOG_LEVEL. request_id yang ditempel middleware ke
+// context ikut tercatat otomatis di setiap log *Context (InfoContext,
+// ErrorContext, ...), termasuk log broadcast yang dipicu request tsb —
+// cukup grep satu request_id untuk menelusuri handler sampai WebSocket.
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// KeyRequestID nama atribut request ID di log
+const KeyRequestID = "request_id"
+
+type ctxKey struct{}
+
+// WithRequestID tempel request ID ke context
+func WithRequestID(ctx context.Context, id string) context.Context {
+	if id == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// RequestID request ID dari context; kosong jika tidak ada
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// ParseLevel debug | info | warn | error (default info jika kosong)
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return slog.LevelInfo, fmt.Errorf("level log %q tidak dikenal (debug|info|warn|error)", s)
+}
+
+// New logger ke w dengan format "json" (default) atau "text"
+func New(w io.Writer, level slog.Level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+
+	var h slog.Handler
+	if format == "text" {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(contextHandler{h})
+}
+
+// contextHandler tambahkan request_id dari context ke setiap record
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String(KeyRequestID, id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// For logger dengan atribut component (mis. "queue", "display").
+// Aman dipakai sebagai variabel package: handler default dibaca saat log
+// ditulis, jadi tetap mengikuti slog.SetDefault yang dipanggil belakangan.
+func For(component string) *slog.Logger {
+	return slog.New(lazyHandler{attrs: []slog.Attr{slog.String("component", component)}})
+}
+
+// lazyHandler teruskan ke slog.Default() pada saat log ditulis
+type lazyHandler struct {
+	attrs []slog.Attr
+}
+
+func (h lazyHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return slog.Default().Handler().Enabled(ctx, level)
+}
+
+func (h lazyHandler) Handle(ctx context.Context, r slog.Record) error {
+	return slog.Default().Handler().WithAttrs(h.attrs).Handle(ctx, r)
+}
+
+func (h lazyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return lazyHandler{attrs: append(h.attrs[:len(h.attrs):len(h.attrs)], attrs...)}
+}
+
+func (h lazyHandler) WithGroup(name string) slog.Handler {
+	return slog.Default().Handler().WithAttrs(h.attrs).WithGroup(name)
+}
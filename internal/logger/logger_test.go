@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestRequestIDAndComponent(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	t.Cleanup(func() { slog.SetDefault(prev) })
+
+	// For dibuat sebelum SetDefault, seperti variabel package
+	queueLog := For("queue")
+	slog.SetDefault(New(&buf, slog.LevelInfo, "json"))
+
+	ctx := WithRequestID(context.Background(), "abc123")
+	queueLog.InfoContext(ctx, "delta broadcast", "seq", 7)
+	queueLog.Debug("tidak tercatat")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("output bukan satu baris JSON: %q", buf.String())
+	}
+	if rec["request_id"] != "abc123" || rec["component"] != "queue" || rec["seq"] != float64(7) {
+		t.Fatalf("record = %v", rec)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	if l, err := ParseLevel(""); err != nil || l != slog.LevelInfo {
+		t.Fatalf("default = %v %v", l, err)
+	}
+	if l, err := ParseLevel("DEBUG"); err != nil || l != slog.LevelDebug {
+		t.Fatalf("DEBUG = %v %v", l, err)
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Fatal("level tidak dikenal harus error")
+	}
+}
@@ -0,0 +1,186 @@
+// Package loginguard melacak percobaan login gagal per akun dan per IP,
+// lalu menerapkan jeda eksponensial dan lockout sementara.
+package loginguard
+
+import (
+	"backend-antrian/internal/config"
+	"context"
+	"time"
+)
+
+// Store penyimpanan counter gagal dan lock. Implementasi: memori (satu
+// replica) dan Redis (dipakai bersama semua replica).
+type Store interface {
+	// Incr tambah counter gagal; counter kedaluwarsa window setelah gagal pertama.
+	Incr(ctx context.Context, key string, window time.Duration) (int, error)
+	// Lock kunci key selama d.
+	Lock(ctx context.Context, key string, d time.Duration) error
+	// LockTTL sisa waktu lock; 0 jika tidak terkunci.
+	LockTTL(ctx context.Context, key string) (time.Duration, error)
+	// Reset hapus counter dan lock key.
+	Reset(ctx context.Context, key string) error
+	// Claim tandai key sekali pakai selama d; false jika key sudah ada
+	// (dipakai juga untuk anti-replay challenge captcha).
+	Claim(ctx context.Context, key string, d time.Duration) (bool, error)
+}
+
+// Policy aturan untuk satu jenis key (akun atau IP).
+type Policy struct {
+	FreeAttempts int           // gagal tanpa jeda
+	MaxAttempts  int           // gagal sebelum lockout penuh
+	BaseDelay    time.Duration // jeda setelah FreeAttempts, digandakan tiap gagal
+	MaxDelay     time.Duration // batas atas jeda backoff
+	Lockout      time.Duration // lama lockout setelah MaxAttempts
+	Window       time.Duration // umur counter gagal
+}
+
+// delay jeda setelah gagal ke-n; lockout true jika sudah mencapai MaxAttempts.
+func (p Policy) delay(n int) (time.Duration, bool) {
+	if n >= p.MaxAttempts {
+		return p.Lockout, true
+	}
+	if n <= p.FreeAttempts {
+		return 0, false
+	}
+	d := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < n && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return d, false
+}
+
+// Key target pelacakan. Scope menentukan policy yang dipakai.
+type Key struct {
+	Scope string // ScopeAccount atau ScopeIP
+	ID    string // mis. "user:budi@x.id", "kiosk:kiosk-ab12", "10.0.0.1"
+}
+
+const (
+	ScopeAccount = "account"
+	ScopeIP      = "ip"
+)
+
+// Account key akun; kind membedakan user dan kiosk.
+func Account(kind, id string) Key { return Key{Scope: ScopeAccount, ID: kind + ":" + id} }
+
+// IP key alamat IP klien.
+func IP(ip string) Key { return Key{Scope: ScopeIP, ID: ip} }
+
+func (k Key) failKey() string { return "fail:" + k.Scope + ":" + k.ID }
+func (k Key) lockKey() string { return "lock:" + k.Scope + ":" + k.ID }
+
+// Result hasil pencatatan satu kegagalan.
+type Result struct {
+	Key      Key
+	Failures int
+	LockFor  time.Duration // 0 jika belum ada jeda
+	Lockout  bool          // true jika lockout penuh (MaxAttempts tercapai)
+}
+
+// Guard gabungan store dan policy per scope.
+type Guard struct {
+	store    Store
+	policies map[string]Policy
+}
+
+// New buat guard dengan policy akun dan IP.
+func New(store Store, account, ip Policy) *Guard {
+	return &Guard{
+		store:    store,
+		policies: map[string]Policy{ScopeAccount: account, ScopeIP: ip},
+	}
+}
+
+// Check sisa waktu tunggu terbesar dari semua key; 0 berarti boleh mencoba.
+func (g *Guard) Check(ctx context.Context, keys ...Key) (time.Duration, error) {
+	var wait time.Duration
+	for _, k := range keys {
+		ttl, err := g.store.LockTTL(ctx, k.lockKey())
+		if err != nil {
+			return 0, err
+		}
+		if ttl > wait {
+			wait = ttl
+		}
+	}
+	return wait, nil
+}
+
+// Fail catat kegagalan untuk setiap key dan pasang lock sesuai policy.
+func (g *Guard) Fail(ctx context.Context, keys ...Key) ([]Result, error) {
+	results := make([]Result, 0, len(keys))
+	for _, k := range keys {
+		p := g.policies[k.Scope]
+		n, err := g.store.Incr(ctx, k.failKey(), p.Window)
+		if err != nil {
+			return results, err
+		}
+		res := Result{Key: k, Failures: n}
+		res.LockFor, res.Lockout = p.delay(n)
+		if res.LockFor > 0 {
+			if err := g.store.Lock(ctx, k.lockKey(), res.LockFor); err != nil {
+				return results, err
+			}
+		}
+		results = append(results, res)
+	}
+	return results, nil
+}
+
+// Success login berhasil: hapus counter key (biasanya hanya key akun, supaya
+// satu akun valid tidak bisa mereset counter IP penyerang).
+func (g *Guard) Success(ctx context.Context, keys ...Key) error {
+	return g.Unlock(ctx, keys...)
+}
+
+// Unlock hapus counter dan lock (dipakai admin).
+func (g *Guard) Unlock(ctx context.Context, keys ...Key) error {
+	for _, k := range keys {
+		if err := g.store.Reset(ctx, k.failKey()); err != nil {
+			return err
+		}
+		if err := g.store.Reset(ctx, k.lockKey()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Default guard aplikasi (store memori); main memanggil Configure saat start.
+var Default = New(NewMemoryStore(), DefaultAccountPolicy, DefaultIPPolicy)
+
+var (
+	DefaultAccountPolicy = Policy{
+		FreeAttempts: 3,
+		MaxAttempts:  10,
+		BaseDelay:    2 * time.Second,
+		MaxDelay:     5 * time.Minute,
+		Lockout:      15 * time.Minute,
+		Window:       15 * time.Minute,
+	}
+	DefaultIPPolicy = Policy{
+		FreeAttempts: 10,
+		MaxAttempts:  50,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		Lockout:      15 * time.Minute,
+		Window:       15 * time.Minute,
+	}
+)
+
+// Configure pasang store untuk Default dengan policy dari env:
+// LOGIN_MAX_ATTEMPTS, LOGIN_IP_MAX_ATTEMPTS, LOGIN_LOCKOUT (durasi, mis. "15m").
+func Configure(store Store) {
+	account, ip := DefaultAccountPolicy, DefaultIPPolicy
+
+	account.MaxAttempts = config.GetEnvInt("LOGIN_MAX_ATTEMPTS", account.MaxAttempts)
+	ip.MaxAttempts = config.GetEnvInt("LOGIN_IP_MAX_ATTEMPTS", ip.MaxAttempts)
+	lockout := config.GetEnvDuration("LOGIN_LOCKOUT", account.Lockout)
+	account.Lockout, account.Window = lockout, lockout
+	ip.Lockout, ip.Window = lockout, lockout
+
+	Default = New(store, account, ip)
+}
@@ -0,0 +1,90 @@
+package loginguard
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore store in-process; cukup untuk satu replica.
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]memoryEntry
+	now     func() time.Time
+}
+
+type memoryEntry struct {
+	count     int
+	expiresAt time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: map[string]memoryEntry{}, now: time.Now}
+}
+
+func (s *MemoryStore) Incr(ctx context.Context, key string, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	e, ok := s.entries[key]
+	if !ok {
+		e = memoryEntry{expiresAt: now.Add(window)}
+	}
+	e.count++
+	s.entries[key] = e
+	return e.count, nil
+}
+
+func (s *MemoryStore) Lock(ctx context.Context, key string, d time.Duration) error {
+	s.mu.Lock()
+	s.entries[key] = memoryEntry{count: 1, expiresAt: s.now().Add(d)}
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryStore) LockTTL(ctx context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return 0, nil
+	}
+	ttl := e.expiresAt.Sub(s.now())
+	if ttl <= 0 {
+		delete(s.entries, key)
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (s *MemoryStore) Reset(ctx context.Context, key string) error {
+	s.mu.Lock()
+	delete(s.entries, key)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *MemoryStore) Claim(ctx context.Context, key string, d time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if e, ok := s.entries[key]; ok && now.Before(e.expiresAt) {
+		return false, nil
+	}
+	s.entries[key] = memoryEntry{count: 1, expiresAt: now.Add(d)}
+	return true, nil
+}
+
+// sweep buang entry kedaluwarsa supaya map tidak tumbuh tanpa batas
+func (s *MemoryStore) sweep(now time.Time) {
+	for k, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+}
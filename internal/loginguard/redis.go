@@ -0,0 +1,60 @@
+package loginguard
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore store bersama untuk multi replica.
+type RedisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore buat store Redis; prefix ditambahkan ke setiap key.
+func NewRedisStore(client *redis.Client, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// incrScript INCR lalu set TTL hanya pada gagal pertama (atomik, tanpa butuh EXPIRE NX).
+var incrScript = redis.NewScript(`
+local n = redis.call("INCR", KEYS[1])
+if n == 1 then
+	redis.call("PEXPIRE", KEYS[1], ARGV[1])
+end
+return n
+`)
+
+func (s *RedisStore) Incr(ctx context.Context, key string, window time.Duration) (int, error) {
+	n, err := incrScript.Run(ctx, s.client, []string{s.prefix + key}, window.Milliseconds()).Int()
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (s *RedisStore) Lock(ctx context.Context, key string, d time.Duration) error {
+	return s.client.Set(ctx, s.prefix+key, 1, d).Err()
+}
+
+func (s *RedisStore) LockTTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(ctx, s.prefix+key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// -2: key tidak ada, -1: tanpa TTL (tidak seharusnya terjadi)
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (s *RedisStore) Reset(ctx context.Context, key string) error {
+	return s.client.Del(ctx, s.prefix+key).Err()
+}
+
+func (s *RedisStore) Claim(ctx context.Context, key string, d time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, s.prefix+key, 1, d).Result()
+}
@@ -0,0 +1,360 @@
+// Package metrics registry metrik sederhana dengan output format teks
+// Prometheus (exposition format 0.0.4) untuk endpoint /metrics.
+//
+// Counter, Gauge dan Histogram didaftarkan ke Default saat dibuat, jadi
+// cukup dideklarasikan sebagai variabel package di tempat metrik dicatat.
+// Nilai yang lebih murah dihitung saat scrape (jumlah client, statistik
+// pool DB, antrian menunggu) memakai Collect.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets bucket latensi (detik) untuk request HTTP & broadcast
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// SizeBuckets bucket ukuran payload (byte)
+var SizeBuckets = []float64{256, 1024, 4096, 16384, 65536, 262144, 1048576}
+
+// Registry kumpulan metrik yang ditulis bersama
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+	names   map[string]bool
+}
+
+type metric interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Default registry yang dipakai konstruktor package dan endpoint /metrics
+var Default = &Registry{names: map[string]bool{}}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[m.name()] {
+		panic("metrics: " + m.name() + " sudah terdaftar")
+	}
+	r.names[m.name()] = true
+	r.metrics = append(r.metrics, m)
+}
+
+// WriteTo tulis semua metrik dalam format teks Prometheus, urut nama
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	list := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].name() < list[j].name() })
+
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, m := range list {
+		m.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+/*
+|--------------------------------------------------------------------------
+| Series berlabel
+|--------------------------------------------------------------------------
+*/
+
+// desc nama, help, tipe dan nama label satu metrik
+type desc struct {
+	metricName string
+	help       string
+	typ        string
+	labels     []string
+}
+
+func (d desc) name() string { return d.metricName }
+
+func (d desc) header(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.metricName, escapeHelp(d.help), d.metricName, d.typ)
+}
+
+// key gabungan nilai label; panic jika jumlah tidak cocok (bug pemanggil)
+func (d desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s butuh %d label, dapat %d", d.metricName, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelString {a="x",b="y"}; extra dipakai histogram untuk le
+func (d desc) labelString(values []string, extra ...string) string {
+	if len(d.labels) == 0 && len(extra) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, l := range d.labels {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(l)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabel(values[i]))
+		b.WriteByte('"')
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		if b.Len() > 1 {
+			b.WriteByte(',')
+		}
+		b.WriteString(extra[i])
+		b.WriteString(`="`)
+		b.WriteString(extra[i+1])
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+type series struct {
+	values []string
+	value  float64
+}
+
+// vec series per kombinasi label, dipakai Counter & Gauge
+type vec struct {
+	desc
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+func (v *vec) get(values []string) *series {
+	k := v.key(values)
+	s, ok := v.series[k]
+	if !ok {
+		s = &series{values: cloneValues(values)}
+		v.series[k] = s
+	}
+	return s
+}
+
+func (v *vec) write(w *bufio.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.header(w)
+	for _, k := range sortedKeys(v.series) {
+		s := v.series[k]
+		fmt.Fprintf(w, "%s%s %s\n", v.metricName, v.labelString(s.values), formatFloat(s.value))
+	}
+}
+
+// Counter nilai yang hanya naik (total request, tiket diambil, ...)
+type Counter struct{ vec }
+
+// NewCounter daftarkan counter ke Default
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{vec{desc: desc{name, help, "counter", labels}, series: map[string]*series{}}}
+	Default.register(c)
+	return c
+}
+
+// Inc tambah 1
+func (c *Counter) Inc(labelValues ...string) { c.Add(1, labelValues...) }
+
+// Add tambah v (harus >= 0)
+func (c *Counter) Add(v float64, labelValues ...string) {
+	if v < 0 {
+		panic("metrics: counter tidak boleh turun")
+	}
+	c.mu.Lock()
+	c.get(labelValues).value += v
+	c.mu.Unlock()
+}
+
+// Gauge nilai yang bisa naik turun
+type Gauge struct{ vec }
+
+// NewGauge daftarkan gauge ke Default
+func NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{vec{desc: desc{name, help, "gauge", labels}, series: map[string]*series{}}}
+	Default.register(g)
+	return g
+}
+
+// Set ganti nilai
+func (g *Gauge) Set(v float64, labelValues ...string) {
+	g.mu.Lock()
+	g.get(labelValues).value = v
+	g.mu.Unlock()
+}
+
+// Add tambah (atau kurangi jika negatif)
+func (g *Gauge) Add(v float64, labelValues ...string) {
+	g.mu.Lock()
+	g.get(labelValues).value += v
+	g.mu.Unlock()
+}
+
+/*
+|--------------------------------------------------------------------------
+| Histogram
+|--------------------------------------------------------------------------
+*/
+
+type histSeries struct {
+	values []string
+	counts []uint64 // per bucket, kumulatif saat ditulis
+	sum    float64
+	count  uint64
+}
+
+// Histogram distribusi nilai (durasi, ukuran payload)
+type Histogram struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histSeries
+}
+
+// NewHistogram daftarkan histogram ke Default; buckets harus urut naik
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	h := &Histogram{
+		desc:    desc{name, help, "histogram", labels},
+		buckets: buckets,
+		series:  map[string]*histSeries{},
+	}
+	Default.register(h)
+	return h
+}
+
+// Observe catat satu nilai
+func (h *Histogram) Observe(v float64, labelValues ...string) {
+	k := h.key(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[k]
+	if !ok {
+		s = &histSeries{values: cloneValues(labelValues), counts: make([]uint64, len(h.buckets))}
+		h.series[k] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.header(w)
+	for _, k := range sortedKeys(h.series) {
+		s := h.series[k]
+		var cum uint64
+		for i, b := range h.buckets {
+			cum += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelString(s.values, "le", formatFloat(b)), cum)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelString(s.values, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, h.labelString(s.values), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, h.labelString(s.values), s.count)
+	}
+}
+
+/*
+|--------------------------------------------------------------------------
+| Nilai saat scrape
+|--------------------------------------------------------------------------
+*/
+
+// collectFunc metrik yang nilainya diambil saat /metrics dibaca
+type collectFunc struct {
+	desc
+	fn func(emit func(value float64, labelValues ...string))
+}
+
+// Collect daftarkan gauge/counter yang dihitung saat scrape. fn memanggil
+// emit sekali per series; typ "gauge" atau "counter".
+func Collect(name, help, typ string, fn func(emit func(value float64, labelValues ...string)), labels ...string) {
+	Default.register(&collectFunc{desc: desc{name, help, typ, labels}, fn: fn})
+}
+
+func (c *collectFunc) write(w *bufio.Writer) {
+	type point struct {
+		values []string
+		value  float64
+	}
+	points := map[string]point{}
+	c.fn(func(value float64, labelValues ...string) {
+		points[c.key(labelValues)] = point{cloneValues(labelValues), value}
+	})
+
+	c.header(w)
+	for _, k := range sortedKeys(points) {
+		p := points[k]
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.labelString(p.values), formatFloat(p.value))
+	}
+}
+
+/*
+|--------------------------------------------------------------------------
+| Helper
+|--------------------------------------------------------------------------
+*/
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// cloneValues salin nilai label — string dari Fiber (c.Method(), c.Params())
+// memakai buffer yang dipakai ulang antar request
+func cloneValues(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = strings.Clone(v)
+	}
+	return out
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteTo(t *testing.T) {
+	c := NewCounter("test_events_total", "Event uji", "kind")
+	c.Inc("a")
+	c.Add(2, `b"x`)
+
+	h := NewHistogram("test_latency_seconds", "Latensi uji", []float64{0.1, 1}, "route")
+	h.Observe(0.05, "/a")
+	h.Observe(0.5, "/a")
+	h.Observe(3, "/a")
+
+	Collect("test_clients", "Client uji", "gauge", func(emit func(float64, ...string)) {
+		emit(4)
+	})
+
+	var b strings.Builder
+	if _, err := Default.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+
+	for _, want := range []string{
+		"# TYPE test_events_total counter\n",
+		`test_events_total{kind="a"} 1` + "\n",
+		`test_events_total{kind="b\"x"} 2` + "\n",
+		"# TYPE test_latency_seconds histogram\n",
+		`test_latency_seconds_bucket{route="/a",le="0.1"} 1` + "\n",
+		`test_latency_seconds_bucket{route="/a",le="1"} 2` + "\n",
+		`test_latency_seconds_bucket{route="/a",le="+Inf"} 3` + "\n",
+		`test_latency_seconds_sum{route="/a"} 3.55` + "\n",
+		`test_latency_seconds_count{route="/a"} 3` + "\n",
+		"test_clients 4\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output tanpa %q:\n%s", want, out)
+		}
+	}
+
+	// Urut nama: clients < events < latency
+	if strings.Index(out, "test_clients") > strings.Index(out, "test_events_total") {
+		t.Error("metrik tidak urut nama")
+	}
+}
+
+func TestLabelCountMismatchPanics(t *testing.T) {
+	g := NewGauge("test_mismatch", "Label salah", "a", "b")
+	defer func() {
+		if recover() == nil {
+			t.Fatal("jumlah label salah harus panic")
+		}
+	}()
+	g.Set(1, "only-one")
+}
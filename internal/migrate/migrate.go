@@ -0,0 +1,432 @@
+// Package migrate menjalankan migrasi skema SQL bernomor yang ikut di-embed
+// ke binary. Setiap versi punya pasangan file NNNN_nama.up.sql / .down.sql;
+// versi yang sudah diterapkan dicatat di tabel schema_migrations.
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed mysql/*.sql sqlite/*.sql
+var files embed.FS
+
+// Table nama tabel versi skema
+const Table = "schema_migrations"
+
+var (
+	// ErrOutdated masih ada migrasi yang belum diterapkan
+	ErrOutdated = errors.New("skema database belum versi terbaru")
+	// ErrDirty migrasi sebelumnya gagal di tengah jalan
+	ErrDirty = errors.New("skema database dalam status dirty")
+)
+
+var fileRegex = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration satu versi skema
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status migrasi beserta waktu diterapkan (nil = pending)
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+	Dirty     bool
+}
+
+// Load baca semua migrasi dari fsys, urut berdasarkan versi
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	for _, e := range entries {
+		m := fileRegex.FindStringSubmatch(e.Name())
+		if e.IsDir() || m == nil {
+			continue
+		}
+		version, _ := strconv.ParseInt(m[1], 10, 64)
+		body, err := fs.ReadFile(fsys, e.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		mig := byVersion[version]
+		if mig == nil {
+			mig = &Migration{Version: version, Name: m[2]}
+			byVersion[version] = mig
+		} else if mig.Name != m[2] {
+			return nil, fmt.Errorf("migrasi %d punya dua nama: %s dan %s", version, mig.Name, m[2])
+		}
+		if m[3] == "up" {
+			mig.Up = string(body)
+		} else {
+			mig.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mig := range byVersion {
+		if strings.TrimSpace(mig.Up) == "" {
+			return nil, fmt.Errorf("migrasi %d_%s tidak punya file .up.sql", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Migrator menerapkan migrasi ke satu database
+type Migrator struct {
+	DB         *sql.DB
+	Migrations []Migration
+}
+
+// New migrator dengan migrasi bawaan untuk driver ("mysql" atau "sqlite")
+func New(db *sql.DB, driver string) (*Migrator, error) {
+	sub, err := fs.Sub(files, driver)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := Load(sub)
+	if err != nil {
+		return nil, err
+	}
+	if len(migrations) == 0 {
+		return nil, fmt.Errorf("tidak ada migrasi untuk driver %q", driver)
+	}
+	return &Migrator{DB: db, Migrations: migrations}, nil
+}
+
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	_, err := m.DB.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS `+Table+` (
+			version    BIGINT       NOT NULL,
+			name       VARCHAR(255) NOT NULL,
+			dirty      CHAR(1)      NOT NULL DEFAULT 'n',
+			applied_at DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (version)
+		)
+	`)
+	return err
+}
+
+type appliedRow struct {
+	at    time.Time
+	dirty bool
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int64]appliedRow, error) {
+	if err := m.ensureTable(ctx); err != nil {
+		return nil, err
+	}
+	rows, err := m.DB.QueryContext(ctx, "SELECT version, dirty, applied_at FROM "+Table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]appliedRow{}
+	for rows.Next() {
+		var version int64
+		var dirty string
+		var at time.Time
+		if err := rows.Scan(&version, &dirty, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedRow{at: at, dirty: dirty == "y"}
+	}
+	return applied, rows.Err()
+}
+
+// Status semua migrasi yang dikenal binary
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Status, 0, len(m.Migrations))
+	for _, mig := range m.Migrations {
+		s := Status{Migration: mig}
+		if row, ok := applied[mig.Version]; ok {
+			at := row.at
+			s.AppliedAt = &at
+			s.Dirty = row.dirty
+		}
+		list = append(list, s)
+	}
+	return list, nil
+}
+
+// Version versi tertinggi yang tercatat di database (0 = belum ada)
+func (m *Migrator) Version(ctx context.Context) (version int64, dirty bool, err error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	for v, row := range applied {
+		if v > version {
+			version = v
+		}
+		dirty = dirty || row.dirty
+	}
+	return version, dirty, nil
+}
+
+// Latest versi terbaru yang dibawa binary
+func (m *Migrator) Latest() int64 {
+	return m.Migrations[len(m.Migrations)-1].Version
+}
+
+// Check pastikan semua migrasi sudah diterapkan; dipanggil sebelum server melayani request
+func (m *Migrator) Check(ctx context.Context) error {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	pending := []string{}
+	for _, s := range statuses {
+		if s.Dirty {
+			return fmt.Errorf("%w: versi %d (%s) gagal sebagian, perbaiki manual lalu jalankan `migrate force %d`",
+				ErrDirty, s.Version, s.Name, s.Version)
+		}
+		if s.AppliedAt == nil {
+			pending = append(pending, fmt.Sprintf("%04d_%s", s.Version, s.Name))
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: pending %s, jalankan `migrate up`", ErrOutdated, strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// Up terapkan semua migrasi yang belum ada, urut dari versi terkecil
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for _, s := range statuses {
+		if s.Dirty {
+			return done, fmt.Errorf("%w: versi %d", ErrDirty, s.Version)
+		}
+		if s.AppliedAt != nil {
+			continue
+		}
+		if err := m.run(ctx, s.Migration, true); err != nil {
+			return done, err
+		}
+		done = append(done, s.Migration)
+	}
+	return done, nil
+}
+
+// Down batalkan sejumlah steps migrasi terakhir yang sudah diterapkan
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	done := []Migration{}
+	for i := len(statuses) - 1; i >= 0 && len(done) < steps; i-- {
+		s := statuses[i]
+		if s.AppliedAt == nil {
+			continue
+		}
+		if s.Dirty {
+			return done, fmt.Errorf("%w: versi %d", ErrDirty, s.Version)
+		}
+		if strings.TrimSpace(s.Down) == "" {
+			return done, fmt.Errorf("migrasi %04d_%s tidak punya file .down.sql", s.Version, s.Name)
+		}
+		if err := m.run(ctx, s.Migration, false); err != nil {
+			return done, err
+		}
+		done = append(done, s.Migration)
+	}
+	return done, nil
+}
+
+// Force tandai versi <= version sebagai sudah diterapkan (tanpa menjalankan SQL)
+// dan hapus catatan versi di atasnya. Dipakai untuk database lama yang skemanya
+// sudah dibuat manual, atau memulihkan status dirty setelah perbaikan manual.
+func (m *Migrator) Force(ctx context.Context, version int64) error {
+	if err := m.ensureTable(ctx); err != nil {
+		return err
+	}
+	if _, err := m.DB.ExecContext(ctx, "DELETE FROM "+Table+" WHERE version > ? OR dirty = 'y'", version); err != nil {
+		return err
+	}
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return err
+	}
+	for _, mig := range m.Migrations {
+		if mig.Version > version {
+			break
+		}
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+		if _, err := m.DB.ExecContext(ctx,
+			"INSERT INTO "+Table+" (version, name, dirty) VALUES (?, ?, 'n')", mig.Version, mig.Name,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// run jalankan satu migrasi. DDL MySQL auto-commit (tidak bisa di-rollback),
+// jadi versi ditandai dirty dulu dan baru dibersihkan setelah semua statement sukses.
+func (m *Migrator) run(ctx context.Context, mig Migration, up bool) error {
+	body := mig.Up
+	if !up {
+		body = mig.Down
+	}
+
+	if up {
+		if _, err := m.DB.ExecContext(ctx,
+			"INSERT INTO "+Table+" (version, name, dirty) VALUES (?, ?, 'y')", mig.Version, mig.Name,
+		); err != nil {
+			return err
+		}
+	} else if _, err := m.DB.ExecContext(ctx, "UPDATE "+Table+" SET dirty = 'y' WHERE version = ?", mig.Version); err != nil {
+		return err
+	}
+
+	for i, stmt := range Split(body) {
+		if _, err := m.DB.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("migrasi %04d_%s statement #%d: %w", mig.Version, mig.Name, i+1, err)
+		}
+	}
+
+	var err error
+	if up {
+		_, err = m.DB.ExecContext(ctx, "UPDATE "+Table+" SET dirty = 'n', applied_at = NOW() WHERE version = ?", mig.Version)
+	} else {
+		_, err = m.DB.ExecContext(ctx, "DELETE FROM "+Table+" WHERE version = ?", mig.Version)
+	}
+	return err
+}
+
+// Split pecah script SQL per statement (pemisah ';'), mengabaikan ';' di dalam
+// string/identifier, komentar, dan badan CREATE TRIGGER ... BEGIN ... END.
+// Driver MySQL tidak menerima multi statement.
+func Split(script string) []string {
+	var (
+		stmts []string
+		buf   strings.Builder
+		quote byte
+	)
+	flush := func() {
+		if s := strings.TrimSpace(buf.String()); s != "" {
+			stmts = append(stmts, s)
+		}
+		buf.Reset()
+	}
+
+	// Semua pemisah ASCII, jadi aman iterasi per byte untuk teks UTF-8
+	for i := 0; i < len(script); i++ {
+		ch := script[i]
+		switch {
+		case quote != 0:
+			buf.WriteByte(ch)
+			if ch == '\\' && quote != '`' && i+1 < len(script) {
+				i++
+				buf.WriteByte(script[i])
+			} else if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"' || ch == '`':
+			quote = ch
+			buf.WriteByte(ch)
+		case ch == '#' || strings.HasPrefix(script[i:], "--"):
+			// komentar sampai akhir baris
+			if end := strings.IndexByte(script[i:], '\n'); end >= 0 {
+				i += end
+				buf.WriteByte('\n')
+			} else {
+				i = len(script)
+			}
+		case strings.HasPrefix(script[i:], "/*"):
+			if end := strings.Index(script[i+2:], "*/"); end >= 0 {
+				i += end + 3
+			} else {
+				i = len(script)
+			}
+		case ch == ';' && inTriggerBody(buf.String()):
+			buf.WriteByte(ch)
+		case ch == ';':
+			flush()
+		default:
+			buf.WriteByte(ch)
+		}
+	}
+	flush()
+	return stmts
+}
+
+// inTriggerBody - stmt adalah CREATE TRIGGER yang belum ditutup END
+func inTriggerBody(stmt string) bool {
+	fields := strings.Fields(strings.ToUpper(stmt))
+	if len(fields) < 2 || fields[0] != "CREATE" {
+		return false
+	}
+	i := 1
+	if fields[i] == "TEMP" || fields[i] == "TEMPORARY" {
+		i++
+	}
+	if i >= len(fields) || fields[i] != "TRIGGER" {
+		return false
+	}
+	return fields[len(fields)-1] != "END"
+}
+
+// Create buat pasangan file migrasi kosong dengan versi berikutnya di dir
+func Create(dir, name string) (upPath, downPath string, err error) {
+	name = strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", "", errors.New("nama migrasi wajib diisi")
+	}
+
+	existing, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+	next := int64(1)
+	if len(existing) > 0 {
+		next = existing[len(existing)-1].Version + 1
+	}
+
+	base := fmt.Sprintf("%04d_%s", next, name)
+	upPath = filepath.Join(dir, base+".up.sql")
+	downPath = filepath.Join(dir, base+".down.sql")
+	if err := os.WriteFile(upPath, []byte("-- "+base+"\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	if err := os.WriteFile(downPath, []byte("-- Kebalikan dari "+base+".up.sql\n"), 0o644); err != nil {
+		return "", "", err
+	}
+	return upPath, downPath, nil
+}
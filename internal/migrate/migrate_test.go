@@ -0,0 +1,158 @@
+package migrate
+
+import (
+	"backend-antrian/internal/config"
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestSplit(t *testing.T) {
+	script := `-- komentar; tidak dipecah
+CREATE TABLE a (x VARCHAR(10) DEFAULT 'a;b'); # komentar lain
+/* blok; komentar */ INSERT INTO a VALUES ('it\'s;'), ("q;");
+UPDATE ` + "`a;`" + ` SET x = 'y'`
+
+	want := []string{
+		"CREATE TABLE a (x VARCHAR(10) DEFAULT 'a;b')",
+		`INSERT INTO a VALUES ('it\'s;'), ("q;")`,
+		"UPDATE `a;` SET x = 'y'",
+	}
+	if got := Split(script); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Split() =\n%q\nmau\n%q", got, want)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_b.up.sql":   {Data: []byte("B")},
+		"0001_a.up.sql":   {Data: []byte("A")},
+		"0001_a.down.sql": {Data: []byte("-A")},
+		"README.md":       {Data: []byte("abaikan")},
+	}
+	got, err := Load(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Migration{{1, "a", "A", "-A"}, {2, "b", "B", ""}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Load() = %+v", got)
+	}
+
+	if _, err := Load(fstest.MapFS{"0001_a.down.sql": {Data: []byte("x")}}); err == nil {
+		t.Fatal("migrasi tanpa .up.sql harus error")
+	}
+}
+
+func TestSplitTrigger(t *testing.T) {
+	script := `CREATE TABLE a (x INT);
+CREATE TRIGGER trg_a AFTER UPDATE ON a
+BEGIN
+    UPDATE a SET x = 1; UPDATE a SET x = 2;
+END;
+DROP TABLE a`
+
+	got := Split(script)
+	if len(got) != 3 || !strings.HasSuffix(got[1], "x = 2;\nEND") {
+		t.Fatalf("Split() = %q", got)
+	}
+}
+
+// Migrasi bawaan: versi berurutan tanpa celah, setiap versi bisa di-rollback,
+// dan versi mysql/sqlite selalu berpasangan
+func TestEmbeddedMigrations(t *testing.T) {
+	names := map[string][]string{}
+	for _, driver := range []string{"mysql", "sqlite"} {
+		sub, _ := fs.Sub(files, driver)
+		migrations, err := Load(sub)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, mig := range migrations {
+			if mig.Version != int64(i+1) {
+				t.Errorf("%s: versi ke-%d = %d, mau %d", driver, i, mig.Version, i+1)
+			}
+			if len(Split(mig.Down)) == 0 {
+				t.Errorf("%s: %04d_%s tidak punya statement down", driver, mig.Version, mig.Name)
+			}
+			names[driver] = append(names[driver], fmt.Sprintf("%04d_%s", mig.Version, mig.Name))
+		}
+	}
+	if !reflect.DeepEqual(names["mysql"], names["sqlite"]) {
+		t.Fatalf("migrasi mysql dan sqlite berbeda:\n%v\n%v", names["mysql"], names["sqlite"])
+	}
+}
+
+// Migrasi sqlite dijalankan sungguhan: up, down sampai habis, lalu up lagi
+func TestSQLiteUpDown(t *testing.T) {
+	db, err := config.OpenSQLite(filepath.Join(t.TempDir(), "migrate.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	m, err := New(db, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal("up: ", err)
+	}
+	if err := m.Check(ctx); err != nil {
+		t.Fatal("check: ", err)
+	}
+
+	// Trigger updated_at jalan
+	if _, err := db.Exec("INSERT INTO units (code, nama_unit, updated_at) VALUES ('A', 'Unit A', '2000-01-01 00:00:00')"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("UPDATE units SET nama_unit = 'Unit B' WHERE code = 'A'"); err != nil {
+		t.Fatal(err)
+	}
+	var updated time.Time
+	if err := db.QueryRow("SELECT updated_at FROM units WHERE code = 'A'").Scan(&updated); err != nil {
+		t.Fatal(err)
+	}
+	if updated.Year() == 2000 {
+		t.Fatal("trigger updated_at tidak jalan")
+	}
+
+	if _, err := m.Down(ctx, len(m.Migrations)); err != nil {
+		t.Fatal("down: ", err)
+	}
+	if v, _, _ := m.Version(ctx); v != 0 {
+		t.Fatalf("versi setelah down = %d", v)
+	}
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatal("up ulang: ", err)
+	}
+
+	if err := m.Force(ctx, 3); err != nil {
+		t.Fatal("force: ", err)
+	}
+	if v, _, _ := m.Version(ctx); v != 3 {
+		t.Fatalf("versi setelah force = %d", v)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "0007_lama.up.sql"), []byte("SELECT 1"), 0o644)
+
+	up, down, err := Create(dir, "Tambah Kolom X")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(up) != "0008_tambah_kolom_x.up.sql" || filepath.Base(down) != "0008_tambah_kolom_x.down.sql" {
+		t.Fatalf("Create() = %s, %s", up, down)
+	}
+}
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS faqs;
+DROP TABLE IF EXISTS configs;
+DROP TABLE IF EXISTS tts_audio_cache;
+DROP TABLE IF EXISTS unit_schedules;
+DROP TABLE IF EXISTS queue_transactions;
+DROP TABLE IF EXISTS queue_tickets;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS services;
+DROP TABLE IF EXISTS units;
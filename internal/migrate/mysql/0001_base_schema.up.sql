@@ -0,0 +1,119 @@
+-- Skema dasar aplikasi antrian (tabel yang sudah ada sebelum migrasi bernomor).
+
+CREATE TABLE IF NOT EXISTS units (
+    id           BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    code         VARCHAR(10)  NOT NULL,
+    nama_unit    VARCHAR(255) NOT NULL,
+    is_active    ENUM('y', 'n') NOT NULL DEFAULT 'y',
+    main_display ENUM('active', 'inactive') NOT NULL DEFAULT 'active',
+    audio_file   VARCHAR(255) NULL,     -- nama_audio di tts_audio_cache
+    created_at   DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    UNIQUE KEY uq_units_code (code)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Layanan per unit. code jadi prefix nomor tiket (mis. A12), unik global.
+CREATE TABLE IF NOT EXISTS services (
+    id           BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    unit_id      BIGINT UNSIGNED NOT NULL,
+    nama_service VARCHAR(255) NOT NULL,
+    code         VARCHAR(10)  NOT NULL,
+    limits_queue INT          NOT NULL DEFAULT 0, -- 0 = tanpa batas per hari
+    is_active    ENUM('y', 'n') NOT NULL DEFAULT 'y',
+    created_at   DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    UNIQUE KEY uq_services_code (code),
+    KEY idx_services_unit (unit_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS users (
+    id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    nama       VARCHAR(255) NOT NULL,
+    email      VARCHAR(255) NOT NULL,
+    password   VARCHAR(255) NOT NULL,   -- bcrypt
+    role       ENUM('super_user', 'unit') NOT NULL,
+    is_banned  ENUM('y', 'n') NOT NULL DEFAULT 'n',
+    unit_id    BIGINT UNSIGNED NULL,
+    created_at DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    UNIQUE KEY uq_users_email (email),
+    KEY idx_users_unit (unit_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Tiket antrian. Nomor urut dihitung ulang tiap hari per service.
+CREATE TABLE IF NOT EXISTS queue_tickets (
+    id             BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    ticket_code    VARCHAR(50)  NOT NULL,
+    unit_id        BIGINT UNSIGNED NOT NULL,
+    service_id     BIGINT UNSIGNED NOT NULL,
+    user_id        BIGINT UNSIGNED NULL,    -- pengambil tiket (super_user); NULL jika dari kiosk
+    status         ENUM('waiting', 'called', 'done', 'skipped') NOT NULL DEFAULT 'waiting',
+    last_called_at DATETIME     NULL,
+    created_at     DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at     DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    KEY idx_queue_tickets_service (service_id, status, created_at),
+    KEY idx_queue_tickets_unit (unit_id, created_at),
+    KEY idx_queue_tickets_created (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Riwayat event tiket untuk laporan (waktu tunggu, waktu layanan).
+CREATE TABLE IF NOT EXISTS queue_transactions (
+    id            BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    ticket_id     BIGINT UNSIGNED NOT NULL,
+    event         ENUM('take', 'call', 'finish', 'skip', 'recall') NOT NULL,
+    actor_user_id BIGINT UNSIGNED NULL,
+    created_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at    DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    KEY idx_queue_transactions_ticket (ticket_id, event),
+    KEY idx_queue_transactions_created (created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Jadwal buka per hari; unique key dipakai INSERT ... ON DUPLICATE KEY UPDATE.
+CREATE TABLE IF NOT EXISTS unit_schedules (
+    id          BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    unit_id     BIGINT UNSIGNED NOT NULL,
+    day_of_week TINYINT      NOT NULL, -- 0=Minggu ... 6=Sabtu
+    jam_buka    TIME         NOT NULL,
+    jam_tutup   TIME         NOT NULL,
+    is_active   ENUM('y', 'n') NOT NULL DEFAULT 'y',
+    created_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    UNIQUE KEY uq_unit_schedules_day (unit_id, day_of_week)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- File audio TTS / rekaman (public/audio) yang dipakai pengumuman.
+CREATE TABLE IF NOT EXISTS tts_audio_cache (
+    id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    tts_text   TEXT         NULL,
+    nama_audio VARCHAR(255) NOT NULL,
+    path_audio VARCHAR(255) NOT NULL,
+    created_at DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    UNIQUE KEY uq_tts_audio_cache_nama (nama_audio)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Konfigurasi tampilan (satu baris).
+CREATE TABLE IF NOT EXISTS configs (
+    id          BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    text_marque TEXT NOT NULL,
+    PRIMARY KEY (id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+CREATE TABLE IF NOT EXISTS faqs (
+    id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    question   VARCHAR(255) NOT NULL,
+    answer     TEXT         NOT NULL,
+    is_active  ENUM('y', 'n') NOT NULL DEFAULT 'y',
+    sort_order INT          NOT NULL DEFAULT 1,
+    created_at DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    KEY idx_faqs_sort (is_active, sort_order)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,23 @@
+-- Antrian pengumuman (announcement) untuk display.
+-- id dipakai sebagai nomor urut (sequence) pengumuman.
+CREATE TABLE IF NOT EXISTS queue_announcements (
+    id           BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    ticket_id    BIGINT UNSIGNED NOT NULL,
+    unit_id      BIGINT UNSIGNED NOT NULL,
+    service_id   BIGINT UNSIGNED NOT NULL,
+    ticket_code  VARCHAR(50)  NOT NULL,
+    event        ENUM('call', 'recall') NOT NULL DEFAULT 'call',
+    audio_paths  TEXT         NOT NULL,
+    status       ENUM('pending', 'done', 'expired') NOT NULL DEFAULT 'pending',
+    claimed_by   VARCHAR(100) NULL,
+    claimed_at   DATETIME     NULL,
+    lease_until  DATETIME     NULL,
+    attempts     INT          NOT NULL DEFAULT 0,
+    acked_by     VARCHAR(100) NULL,
+    acked_at     DATETIME     NULL,
+    created_at   DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    KEY idx_queue_announcements_status (status, id),
+    KEY idx_queue_announcements_ticket (ticket_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS display_services;
+DROP TABLE IF EXISTS display_units;
+DROP TABLE IF EXISTS displays;
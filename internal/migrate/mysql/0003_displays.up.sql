@@ -0,0 +1,34 @@
+-- Registry perangkat display (TV) beserta konfigurasi per layar.
+CREATE TABLE IF NOT EXISTS displays (
+    id                 BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    nama               VARCHAR(255) NOT NULL,
+    device_id          VARCHAR(100) NULL,
+    token_hash         CHAR(64)     NULL,
+    pairing_code       VARCHAR(12)  NULL,
+    pairing_expires_at DATETIME     NULL,
+    paired_at          DATETIME     NULL,
+    theme              VARCHAR(50)  NOT NULL DEFAULT 'default',
+    plays_audio        ENUM('y', 'n') NOT NULL DEFAULT 'n',
+    is_active          ENUM('y', 'n') NOT NULL DEFAULT 'y',
+    created_at         DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at         DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    UNIQUE KEY uq_displays_device_id (device_id),
+    UNIQUE KEY uq_displays_pairing_code (pairing_code)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Unit yang ditampilkan display (semua layanan aktif di unit tsb).
+CREATE TABLE IF NOT EXISTS display_units (
+    display_id BIGINT UNSIGNED NOT NULL,
+    unit_id    BIGINT UNSIGNED NOT NULL,
+    PRIMARY KEY (display_id, unit_id),
+    CONSTRAINT fk_display_units_display FOREIGN KEY (display_id) REFERENCES displays (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Layanan tambahan yang ditampilkan display (di luar unit yang di-assign).
+CREATE TABLE IF NOT EXISTS display_services (
+    display_id BIGINT UNSIGNED NOT NULL,
+    service_id BIGINT UNSIGNED NOT NULL,
+    PRIMARY KEY (display_id, service_id),
+    CONSTRAINT fk_display_services_display FOREIGN KEY (display_id) REFERENCES displays (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
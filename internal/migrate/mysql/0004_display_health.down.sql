@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS display_commands;
+DROP TABLE IF EXISTS display_status;
@@ -0,0 +1,30 @@
+-- Status koneksi terakhir tiap display (heartbeat dari /ws/queue).
+CREATE TABLE IF NOT EXISTS display_status (
+    display_id      BIGINT UNSIGNED NOT NULL,
+    client_id       VARCHAR(100) NULL,
+    connected_since DATETIME     NULL,
+    disconnected_at DATETIME     NULL,
+    last_seen_at    DATETIME     NULL,
+    ip_address      VARCHAR(45)  NULL,
+    app_version     VARCHAR(50)  NULL,
+    updated_at      DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (display_id),
+    CONSTRAINT fk_display_status_display FOREIGN KEY (display_id) REFERENCES displays (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Perintah remote dari super user ke display beserta status ack-nya.
+CREATE TABLE IF NOT EXISTS display_commands (
+    id          BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    display_id  BIGINT UNSIGNED NOT NULL,
+    command     ENUM('reload', 'mute', 'unmute', 'set_volume', 'show_message') NOT NULL,
+    params      TEXT         NULL,
+    status      ENUM('sent', 'acked', 'failed', 'expired') NOT NULL DEFAULT 'sent',
+    error       VARCHAR(255) NULL,
+    issued_by   BIGINT UNSIGNED NULL,
+    acked_by    VARCHAR(100) NULL,
+    created_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    acked_at    DATETIME     NULL,
+    PRIMARY KEY (id),
+    KEY idx_display_commands_display (display_id, id),
+    CONSTRAINT fk_display_commands_display FOREIGN KEY (display_id) REFERENCES displays (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
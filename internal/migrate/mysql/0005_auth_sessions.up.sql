@@ -0,0 +1,40 @@
+-- Sesi login (satu per login/perangkat). Access token membawa id sesi (sid);
+-- sesi yang di-revoke langsung mematikan semua access token-nya.
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id             CHAR(32)        NOT NULL,
+    user_id        BIGINT UNSIGNED NOT NULL,
+    ip_address     VARCHAR(45)     NULL,
+    user_agent     VARCHAR(255)    NULL,
+    created_at     DATETIME        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_used_at   DATETIME        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at     DATETIME        NOT NULL,
+    revoked_at     DATETIME        NULL,
+    revoked_reason VARCHAR(50)     NULL,
+    PRIMARY KEY (id),
+    KEY idx_user_sessions_user (user_id, revoked_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Refresh token berotasi. Token lama yang dipakai ulang = indikasi pencurian,
+-- seluruh sesi di-revoke.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    session_id CHAR(32)        NOT NULL,
+    token_hash CHAR(64)        NOT NULL,
+    expires_at DATETIME        NOT NULL,
+    used_at    DATETIME        NULL,
+    created_at DATETIME        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    UNIQUE KEY uq_refresh_tokens_hash (token_hash),
+    KEY idx_refresh_tokens_session (session_id),
+    CONSTRAINT fk_refresh_tokens_session FOREIGN KEY (session_id) REFERENCES user_sessions (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Denylist jti access token (logout). Baris dihapus setelah token kedaluwarsa.
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti        CHAR(32)        NOT NULL,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    expires_at DATETIME        NOT NULL,
+    created_at DATETIME        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (jti),
+    KEY idx_revoked_tokens_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,29 @@
+-- Perangkat kiosk (mesin ambil antrian) dengan kredensial sendiri, bukan akun user.
+CREATE TABLE IF NOT EXISTS kiosks (
+    id                 BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    nama               VARCHAR(255) NOT NULL,
+    device_id          VARCHAR(100) NOT NULL,
+    secret_hash        CHAR(64)     NOT NULL,
+    public_key         TEXT         NULL,     -- PEM (PUBLIC KEY / CERTIFICATE) untuk autentikasi tanda tangan
+    key_fingerprint    CHAR(64)     NULL,
+    is_active          ENUM('y', 'n') NOT NULL DEFAULT 'y',
+    tokens_valid_after DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP, -- token yang terbit sebelum ini ditolak
+    last_assertion_at  BIGINT       NULL,     -- timestamp tanda tangan terakhir (anti replay)
+    last_auth_at       DATETIME     NULL,
+    last_auth_ip       VARCHAR(45)  NULL,
+    created_at         DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at         DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    UNIQUE KEY uq_kiosks_device_id (device_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Unit yang boleh dilayani kiosk (wajib minimal satu).
+CREATE TABLE IF NOT EXISTS kiosk_units (
+    kiosk_id BIGINT UNSIGNED NOT NULL,
+    unit_id  BIGINT UNSIGNED NOT NULL,
+    PRIMARY KEY (kiosk_id, unit_id),
+    CONSTRAINT fk_kiosk_units_kiosk FOREIGN KEY (kiosk_id) REFERENCES kiosks (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Tiket yang diambil lewat kiosk mencatat kiosk asalnya.
+ALTER TABLE queue_tickets ADD COLUMN kiosk_id BIGINT UNSIGNED NULL AFTER user_id;
@@ -0,0 +1,6 @@
+-- Gagal (data truncated) jika masih ada user dengan role selain super_user / unit;
+-- pindahkan dulu user tersebut sebelum rollback.
+ALTER TABLE users MODIFY role ENUM('super_user', 'unit') NOT NULL;
+
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS roles;
@@ -0,0 +1,15 @@
+-- User bisa menjadi anggota beberapa unit; unit aktif dipilih per sesi.
+CREATE TABLE IF NOT EXISTS user_units (
+    user_id    BIGINT UNSIGNED NOT NULL,
+    unit_id    BIGINT UNSIGNED NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, unit_id),
+    KEY idx_user_units_unit (unit_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- users.unit_id tetap ada sebagai unit default; salin ke mapping.
+INSERT IGNORE INTO user_units (user_id, unit_id)
+SELECT id, unit_id FROM users WHERE unit_id IS NOT NULL;
+
+-- Unit aktif sesi (dipilih saat login atau lewat POST /api/me/unit).
+ALTER TABLE user_sessions ADD COLUMN active_unit_id BIGINT UNSIGNED NULL AFTER user_id;
@@ -0,0 +1,28 @@
+-- Jejak aksi administratif: siapa, apa, kapan, dari mana, dan perubahan datanya.
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id          BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    actor_type  VARCHAR(20)  NOT NULL,          -- user, kiosk, system
+    actor_id    BIGINT UNSIGNED NULL,
+    actor_name  VARCHAR(255) NULL,
+    action      VARCHAR(100) NOT NULL,          -- mis. units.hard_delete, backup.download
+    entity      VARCHAR(50)  NULL,
+    entity_id   VARCHAR(100) NULL,
+    before_data LONGTEXT     NULL,              -- JSON snapshot sebelum
+    after_data  LONGTEXT     NULL,              -- JSON snapshot sesudah
+    diff        LONGTEXT     NULL,              -- JSON {field: {before, after}}
+    status_code SMALLINT     NOT NULL DEFAULT 0,
+    method      VARCHAR(10)  NULL,
+    path        VARCHAR(255) NULL,
+    ip_address  VARCHAR(45)  NULL,
+    user_agent  VARCHAR(255) NULL,
+    created_at  DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    KEY idx_audit_logs_created (created_at),
+    KEY idx_audit_logs_actor (actor_type, actor_id, created_at),
+    KEY idx_audit_logs_entity (entity, entity_id),
+    KEY idx_audit_logs_action (action, created_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+INSERT IGNORE INTO role_permissions (role, permission) VALUES
+    ('super_user', 'audit.view'),
+    ('auditor', 'audit.view');
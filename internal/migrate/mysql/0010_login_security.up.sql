@@ -0,0 +1,3 @@
+-- Permission untuk membuka lockout login (counter gagal disimpan di memori/Redis, bukan tabel).
+INSERT IGNORE INTO role_permissions (role, permission) VALUES
+    ('super_user', 'security.manage');
@@ -0,0 +1,40 @@
+-- Kebijakan 2FA per role: user dengan role require_2fa='y' wajib TOTP saat login.
+ALTER TABLE roles ADD COLUMN require_2fa ENUM('y', 'n') NOT NULL DEFAULT 'n' AFTER unit_scoped;
+UPDATE roles SET require_2fa = 'y' WHERE name = 'super_user';
+
+-- Secret TOTP per user (terenkripsi AES-GCM). enabled='n' = enrolment belum dikonfirmasi.
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id      BIGINT UNSIGNED NOT NULL,
+    secret_enc   VARCHAR(255)    NOT NULL,
+    enabled      ENUM('y', 'n')  NOT NULL DEFAULT 'n',
+    last_step    BIGINT          NOT NULL DEFAULT 0, -- time step terakhir yang dipakai (anti-replay)
+    confirmed_at DATETIME        NULL,
+    created_at   DATETIME        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at   DATETIME        NOT NULL DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Kode pemulihan sekali pakai (disimpan sebagai hash).
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id         BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    code_hash  CHAR(64)        NOT NULL,
+    used_at    DATETIME        NULL,
+    created_at DATETIME        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    KEY idx_user_recovery_codes_user (user_id, used_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Langkah kedua login: token challenge setelah password benar, ditukar dengan kode TOTP.
+CREATE TABLE IF NOT EXISTS login_challenges (
+    token_hash CHAR(64)        NOT NULL,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    unit_id    BIGINT UNSIGNED NULL,      -- unit aktif yang dipilih saat login
+    attempts   INT             NOT NULL DEFAULT 0,
+    ip_address VARCHAR(45)     NULL,
+    expires_at DATETIME        NOT NULL,
+    created_at DATETIME        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (token_hash),
+    KEY idx_login_challenges_user (user_id),
+    KEY idx_login_challenges_expires (expires_at)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
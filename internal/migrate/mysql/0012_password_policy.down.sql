@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS password_reset_tokens;
+DROP TABLE IF EXISTS password_history;
+ALTER TABLE users
+    DROP COLUMN must_change_password,
+    DROP COLUMN password_changed_at;
@@ -0,0 +1,26 @@
+-- Status password user: must_change_password='y' setelah password di-set admin.
+ALTER TABLE users
+    ADD COLUMN password_changed_at  DATETIME       NULL AFTER password,
+    ADD COLUMN must_change_password ENUM('y', 'n') NOT NULL DEFAULT 'n' AFTER password_changed_at;
+
+-- Riwayat hash password untuk mencegah pemakaian ulang (PASSWORD_HISTORY terakhir).
+CREATE TABLE IF NOT EXISTS password_history (
+    id            BIGINT UNSIGNED NOT NULL AUTO_INCREMENT,
+    user_id       BIGINT UNSIGNED NOT NULL,
+    password_hash VARCHAR(255)    NOT NULL,
+    created_at    DATETIME        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id),
+    KEY idx_password_history_user (user_id, id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Token reset sekali pakai yang diterbitkan admin (disimpan sebagai hash).
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    token_hash CHAR(64)        NOT NULL,
+    user_id    BIGINT UNSIGNED NOT NULL,
+    created_by BIGINT UNSIGNED NULL,
+    expires_at DATETIME        NOT NULL,
+    used_at    DATETIME        NULL,
+    created_at DATETIME        NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (token_hash),
+    KEY idx_password_reset_tokens_user (user_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,3 @@
+-- Ambil nomor antrian hanya lewat kiosk (token ber-role kiosk);
+-- super_user tidak lagi memegang queue.take.
+DELETE FROM role_permissions WHERE role = 'super_user' AND permission = 'queue.take';
@@ -0,0 +1,162 @@
+-- Skema dasar aplikasi antrian (versi SQLite dari mysql/0001_base_schema.up.sql).
+-- ENUM jadi TEXT + CHECK; ON UPDATE CURRENT_TIMESTAMP jadi trigger.
+-- Waktu disimpan sebagai teks waktu lokal server, sama dengan NOW() aplikasi.
+
+CREATE TABLE IF NOT EXISTS units (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    code         VARCHAR(10)  NOT NULL,
+    nama_unit    VARCHAR(255) NOT NULL,
+    is_active    TEXT         NOT NULL DEFAULT 'y' CHECK (is_active IN ('y', 'n')),
+    main_display TEXT         NOT NULL DEFAULT 'active' CHECK (main_display IN ('active', 'inactive')),
+    audio_file   VARCHAR(255) NULL,     -- nama_audio di tts_audio_cache
+    created_at   DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    updated_at   DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    CONSTRAINT uq_units_code UNIQUE (code)
+);
+
+CREATE TRIGGER IF NOT EXISTS trg_units_updated_at
+AFTER UPDATE ON units FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE units SET updated_at = datetime('now', 'localtime') WHERE id = NEW.id;
+END;
+
+-- Layanan per unit. code jadi prefix nomor tiket (mis. A12), unik global.
+CREATE TABLE IF NOT EXISTS services (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    unit_id      INTEGER      NOT NULL,
+    nama_service VARCHAR(255) NOT NULL,
+    code         VARCHAR(10)  NOT NULL,
+    limits_queue INT          NOT NULL DEFAULT 0, -- 0 = tanpa batas per hari
+    is_active    TEXT         NOT NULL DEFAULT 'y' CHECK (is_active IN ('y', 'n')),
+    created_at   DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    updated_at   DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    CONSTRAINT uq_services_code UNIQUE (code)
+);
+CREATE INDEX IF NOT EXISTS idx_services_unit ON services (unit_id);
+
+CREATE TRIGGER IF NOT EXISTS trg_services_updated_at
+AFTER UPDATE ON services FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE services SET updated_at = datetime('now', 'localtime') WHERE id = NEW.id;
+END;
+
+-- role sudah VARCHAR sejak awal: CHECK ENUM lama tidak bisa diubah tanpa
+-- membangun ulang tabel (lihat 0007).
+CREATE TABLE IF NOT EXISTS users (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    nama       VARCHAR(255) NOT NULL,
+    email      VARCHAR(255) NOT NULL,
+    password   VARCHAR(255) NOT NULL,   -- bcrypt
+    role       VARCHAR(50)  NOT NULL,
+    is_banned  TEXT         NOT NULL DEFAULT 'n' CHECK (is_banned IN ('y', 'n')),
+    unit_id    INTEGER      NULL,
+    created_at DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    updated_at DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    CONSTRAINT uq_users_email UNIQUE (email)
+);
+CREATE INDEX IF NOT EXISTS idx_users_unit ON users (unit_id);
+
+CREATE TRIGGER IF NOT EXISTS trg_users_updated_at
+AFTER UPDATE ON users FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE users SET updated_at = datetime('now', 'localtime') WHERE id = NEW.id;
+END;
+
+-- Tiket antrian. Nomor urut dihitung ulang tiap hari per service.
+CREATE TABLE IF NOT EXISTS queue_tickets (
+    id             INTEGER PRIMARY KEY AUTOINCREMENT,
+    ticket_code    VARCHAR(50)  NOT NULL,
+    unit_id        INTEGER      NOT NULL,
+    service_id     INTEGER      NOT NULL,
+    user_id        INTEGER      NULL,    -- pengambil tiket (super_user); NULL jika dari kiosk
+    status         TEXT         NOT NULL DEFAULT 'waiting' CHECK (status IN ('waiting', 'called', 'done', 'skipped')),
+    last_called_at DATETIME     NULL,
+    created_at     DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    updated_at     DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime'))
+);
+CREATE INDEX IF NOT EXISTS idx_queue_tickets_service ON queue_tickets (service_id, status, created_at);
+CREATE INDEX IF NOT EXISTS idx_queue_tickets_unit ON queue_tickets (unit_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_queue_tickets_created ON queue_tickets (created_at);
+
+CREATE TRIGGER IF NOT EXISTS trg_queue_tickets_updated_at
+AFTER UPDATE ON queue_tickets FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE queue_tickets SET updated_at = datetime('now', 'localtime') WHERE id = NEW.id;
+END;
+
+-- Riwayat event tiket untuk laporan (waktu tunggu, waktu layanan).
+CREATE TABLE IF NOT EXISTS queue_transactions (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    ticket_id     INTEGER  NOT NULL,
+    event         TEXT     NOT NULL CHECK (event IN ('take', 'call', 'finish', 'skip', 'recall')),
+    actor_user_id INTEGER  NULL,
+    created_at    DATETIME NOT NULL DEFAULT (datetime('now', 'localtime')),
+    updated_at    DATETIME NOT NULL DEFAULT (datetime('now', 'localtime'))
+);
+CREATE INDEX IF NOT EXISTS idx_queue_transactions_ticket ON queue_transactions (ticket_id, event);
+CREATE INDEX IF NOT EXISTS idx_queue_transactions_created ON queue_transactions (created_at);
+
+CREATE TRIGGER IF NOT EXISTS trg_queue_transactions_updated_at
+AFTER UPDATE ON queue_transactions FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE queue_transactions SET updated_at = datetime('now', 'localtime') WHERE id = NEW.id;
+END;
+
+-- Jadwal buka per hari; unique key dipakai upsert (ON CONFLICT).
+CREATE TABLE IF NOT EXISTS unit_schedules (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    unit_id     INTEGER  NOT NULL,
+    day_of_week TINYINT  NOT NULL, -- 0=Minggu ... 6=Sabtu
+    jam_buka    TIME     NOT NULL,
+    jam_tutup   TIME     NOT NULL,
+    is_active   TEXT     NOT NULL DEFAULT 'y' CHECK (is_active IN ('y', 'n')),
+    created_at  DATETIME NOT NULL DEFAULT (datetime('now', 'localtime')),
+    updated_at  DATETIME NOT NULL DEFAULT (datetime('now', 'localtime')),
+    CONSTRAINT uq_unit_schedules_day UNIQUE (unit_id, day_of_week)
+);
+
+CREATE TRIGGER IF NOT EXISTS trg_unit_schedules_updated_at
+AFTER UPDATE ON unit_schedules FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE unit_schedules SET updated_at = datetime('now', 'localtime') WHERE id = NEW.id;
+END;
+
+-- File audio TTS / rekaman (public/audio) yang dipakai pengumuman.
+CREATE TABLE IF NOT EXISTS tts_audio_cache (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    tts_text   TEXT         NULL,
+    nama_audio VARCHAR(255) NOT NULL,
+    path_audio VARCHAR(255) NOT NULL,
+    created_at DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    updated_at DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    CONSTRAINT uq_tts_audio_cache_nama UNIQUE (nama_audio)
+);
+
+CREATE TRIGGER IF NOT EXISTS trg_tts_audio_cache_updated_at
+AFTER UPDATE ON tts_audio_cache FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE tts_audio_cache SET updated_at = datetime('now', 'localtime') WHERE id = NEW.id;
+END;
+
+-- Konfigurasi tampilan (satu baris).
+CREATE TABLE IF NOT EXISTS configs (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    text_marque TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS faqs (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    question   VARCHAR(255) NOT NULL,
+    answer     TEXT         NOT NULL,
+    is_active  TEXT         NOT NULL DEFAULT 'y' CHECK (is_active IN ('y', 'n')),
+    sort_order INT          NOT NULL DEFAULT 1,
+    created_at DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    updated_at DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime'))
+);
+CREATE INDEX IF NOT EXISTS idx_faqs_sort ON faqs (is_active, sort_order);
+
+CREATE TRIGGER IF NOT EXISTS trg_faqs_updated_at
+AFTER UPDATE ON faqs FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE faqs SET updated_at = datetime('now', 'localtime') WHERE id = NEW.id;
+END;
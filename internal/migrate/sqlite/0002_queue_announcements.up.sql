@@ -0,0 +1,28 @@
+-- Antrian pengumuman (announcement) untuk display.
+-- id dipakai sebagai nomor urut (sequence) pengumuman.
+CREATE TABLE IF NOT EXISTS queue_announcements (
+    id           INTEGER PRIMARY KEY AUTOINCREMENT,
+    ticket_id    INTEGER      NOT NULL,
+    unit_id      INTEGER      NOT NULL,
+    service_id   INTEGER      NOT NULL,
+    ticket_code  VARCHAR(50)  NOT NULL,
+    event        TEXT         NOT NULL DEFAULT 'call' CHECK (event IN ('call', 'recall')),
+    audio_paths  TEXT         NOT NULL,
+    status       TEXT         NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'done', 'expired')),
+    claimed_by   VARCHAR(100) NULL,
+    claimed_at   DATETIME     NULL,
+    lease_until  DATETIME     NULL,
+    attempts     INT          NOT NULL DEFAULT 0,
+    acked_by     VARCHAR(100) NULL,
+    acked_at     DATETIME     NULL,
+    created_at   DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    updated_at   DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime'))
+);
+CREATE INDEX IF NOT EXISTS idx_queue_announcements_status ON queue_announcements (status, id);
+CREATE INDEX IF NOT EXISTS idx_queue_announcements_ticket ON queue_announcements (ticket_id);
+
+CREATE TRIGGER IF NOT EXISTS trg_queue_announcements_updated_at
+AFTER UPDATE ON queue_announcements FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE queue_announcements SET updated_at = datetime('now', 'localtime') WHERE id = NEW.id;
+END;
@@ -0,0 +1,39 @@
+-- Registry perangkat display (TV) beserta konfigurasi per layar.
+CREATE TABLE IF NOT EXISTS displays (
+    id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+    nama               VARCHAR(255) NOT NULL,
+    device_id          VARCHAR(100) NULL,
+    token_hash         CHAR(64)     NULL,
+    pairing_code       VARCHAR(12)  NULL,
+    pairing_expires_at DATETIME     NULL,
+    paired_at          DATETIME     NULL,
+    theme              VARCHAR(50)  NOT NULL DEFAULT 'default',
+    plays_audio        TEXT         NOT NULL DEFAULT 'n' CHECK (plays_audio IN ('y', 'n')),
+    is_active          TEXT         NOT NULL DEFAULT 'y' CHECK (is_active IN ('y', 'n')),
+    created_at         DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    updated_at         DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    CONSTRAINT uq_displays_device_id UNIQUE (device_id),
+    CONSTRAINT uq_displays_pairing_code UNIQUE (pairing_code)
+);
+
+CREATE TRIGGER IF NOT EXISTS trg_displays_updated_at
+AFTER UPDATE ON displays FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE displays SET updated_at = datetime('now', 'localtime') WHERE id = NEW.id;
+END;
+
+-- Unit yang ditampilkan display (semua layanan aktif di unit tsb).
+CREATE TABLE IF NOT EXISTS display_units (
+    display_id INTEGER NOT NULL,
+    unit_id    INTEGER NOT NULL,
+    PRIMARY KEY (display_id, unit_id),
+    CONSTRAINT fk_display_units_display FOREIGN KEY (display_id) REFERENCES displays (id) ON DELETE CASCADE
+);
+
+-- Layanan tambahan yang ditampilkan display (di luar unit yang di-assign).
+CREATE TABLE IF NOT EXISTS display_services (
+    display_id INTEGER NOT NULL,
+    service_id INTEGER NOT NULL,
+    PRIMARY KEY (display_id, service_id),
+    CONSTRAINT fk_display_services_display FOREIGN KEY (display_id) REFERENCES displays (id) ON DELETE CASCADE
+);
@@ -0,0 +1,35 @@
+-- Status koneksi terakhir tiap display (heartbeat dari /ws/queue).
+CREATE TABLE IF NOT EXISTS display_status (
+    display_id      INTEGER      NOT NULL,
+    client_id       VARCHAR(100) NULL,
+    connected_since DATETIME     NULL,
+    disconnected_at DATETIME     NULL,
+    last_seen_at    DATETIME     NULL,
+    ip_address      VARCHAR(45)  NULL,
+    app_version     VARCHAR(50)  NULL,
+    updated_at      DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    PRIMARY KEY (display_id),
+    CONSTRAINT fk_display_status_display FOREIGN KEY (display_id) REFERENCES displays (id) ON DELETE CASCADE
+);
+
+CREATE TRIGGER IF NOT EXISTS trg_display_status_updated_at
+AFTER UPDATE ON display_status FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE display_status SET updated_at = datetime('now', 'localtime') WHERE display_id = NEW.display_id;
+END;
+
+-- Perintah remote dari super user ke display beserta status ack-nya.
+CREATE TABLE IF NOT EXISTS display_commands (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    display_id  INTEGER      NOT NULL,
+    command     TEXT         NOT NULL CHECK (command IN ('reload', 'mute', 'unmute', 'set_volume', 'show_message')),
+    params      TEXT         NULL,
+    status      TEXT         NOT NULL DEFAULT 'sent' CHECK (status IN ('sent', 'acked', 'failed', 'expired')),
+    error       VARCHAR(255) NULL,
+    issued_by   INTEGER      NULL,
+    acked_by    VARCHAR(100) NULL,
+    created_at  DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    acked_at    DATETIME     NULL,
+    CONSTRAINT fk_display_commands_display FOREIGN KEY (display_id) REFERENCES displays (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_display_commands_display ON display_commands (display_id, id);
@@ -0,0 +1,39 @@
+-- Sesi login (satu per login/perangkat). Access token membawa id sesi (sid);
+-- sesi yang di-revoke langsung mematikan semua access token-nya.
+CREATE TABLE IF NOT EXISTS user_sessions (
+    id             CHAR(32)     NOT NULL,
+    user_id        INTEGER      NOT NULL,
+    ip_address     VARCHAR(45)  NULL,
+    user_agent     VARCHAR(255) NULL,
+    created_at     DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    last_used_at   DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    expires_at     DATETIME     NOT NULL,
+    revoked_at     DATETIME     NULL,
+    revoked_reason VARCHAR(50)  NULL,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user ON user_sessions (user_id, revoked_at);
+
+-- Refresh token berotasi. Token lama yang dipakai ulang = indikasi pencurian,
+-- seluruh sesi di-revoke.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    session_id CHAR(32) NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    expires_at DATETIME NOT NULL,
+    used_at    DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now', 'localtime')),
+    CONSTRAINT uq_refresh_tokens_hash UNIQUE (token_hash),
+    CONSTRAINT fk_refresh_tokens_session FOREIGN KEY (session_id) REFERENCES user_sessions (id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session ON refresh_tokens (session_id);
+
+-- Denylist jti access token (logout). Baris dihapus setelah token kedaluwarsa.
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti        CHAR(32) NOT NULL,
+    user_id    INTEGER  NOT NULL,
+    expires_at DATETIME NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now', 'localtime')),
+    PRIMARY KEY (jti)
+);
+CREATE INDEX IF NOT EXISTS idx_revoked_tokens_expires ON revoked_tokens (expires_at);
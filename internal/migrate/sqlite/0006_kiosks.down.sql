@@ -0,0 +1,3 @@
+ALTER TABLE queue_tickets DROP COLUMN kiosk_id;
+DROP TABLE IF EXISTS kiosk_units;
+DROP TABLE IF EXISTS kiosks;
@@ -0,0 +1,34 @@
+-- Perangkat kiosk (mesin ambil antrian) dengan kredensial sendiri, bukan akun user.
+CREATE TABLE IF NOT EXISTS kiosks (
+    id                 INTEGER PRIMARY KEY AUTOINCREMENT,
+    nama               VARCHAR(255) NOT NULL,
+    device_id          VARCHAR(100) NOT NULL,
+    secret_hash        CHAR(64)     NOT NULL,
+    public_key         TEXT         NULL,     -- PEM (PUBLIC KEY / CERTIFICATE) untuk autentikasi tanda tangan
+    key_fingerprint    CHAR(64)     NULL,
+    is_active          TEXT         NOT NULL DEFAULT 'y' CHECK (is_active IN ('y', 'n')),
+    tokens_valid_after DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')), -- token yang terbit sebelum ini ditolak
+    last_assertion_at  BIGINT       NULL,     -- timestamp tanda tangan terakhir (anti replay)
+    last_auth_at       DATETIME     NULL,
+    last_auth_ip       VARCHAR(45)  NULL,
+    created_at         DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    updated_at         DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    CONSTRAINT uq_kiosks_device_id UNIQUE (device_id)
+);
+
+CREATE TRIGGER IF NOT EXISTS trg_kiosks_updated_at
+AFTER UPDATE ON kiosks FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE kiosks SET updated_at = datetime('now', 'localtime') WHERE id = NEW.id;
+END;
+
+-- Unit yang boleh dilayani kiosk (wajib minimal satu).
+CREATE TABLE IF NOT EXISTS kiosk_units (
+    kiosk_id INTEGER NOT NULL,
+    unit_id  INTEGER NOT NULL,
+    PRIMARY KEY (kiosk_id, unit_id),
+    CONSTRAINT fk_kiosk_units_kiosk FOREIGN KEY (kiosk_id) REFERENCES kiosks (id) ON DELETE CASCADE
+);
+
+-- Tiket yang diambil lewat kiosk mencatat kiosk asalnya.
+ALTER TABLE queue_tickets ADD COLUMN kiosk_id INTEGER NULL;
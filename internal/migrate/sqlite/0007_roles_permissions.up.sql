@@ -0,0 +1,60 @@
+-- Role dinamis: users.role merujuk roles.name (sebelumnya hanya super_user / unit).
+CREATE TABLE IF NOT EXISTS roles (
+    name        VARCHAR(50)  NOT NULL,
+    label       VARCHAR(100) NOT NULL,
+    description VARCHAR(255) NULL,
+    unit_scoped TEXT         NOT NULL DEFAULT 'n' CHECK (unit_scoped IN ('y', 'n')), -- user dengan role ini wajib punya unit_id
+    is_system   TEXT         NOT NULL DEFAULT 'n' CHECK (is_system IN ('y', 'n')),   -- tidak bisa dihapus / di-rename
+    created_at  DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    updated_at  DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    PRIMARY KEY (name)
+);
+
+CREATE TRIGGER IF NOT EXISTS trg_roles_updated_at
+AFTER UPDATE ON roles FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE roles SET updated_at = datetime('now', 'localtime') WHERE name = NEW.name;
+END;
+
+-- Permission per role (mis. queue.call, report.export, audio.manage).
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role       VARCHAR(50)  NOT NULL,
+    permission VARCHAR(100) NOT NULL,
+    PRIMARY KEY (role, permission),
+    CONSTRAINT fk_role_permissions_role FOREIGN KEY (role) REFERENCES roles (name) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+-- users.role di SQLite sudah VARCHAR sejak 0001, tidak perlu diubah.
+
+INSERT OR IGNORE INTO roles (name, label, description, unit_scoped, is_system) VALUES
+    ('super_user', 'Super User', 'Administrator sistem', 'n', 'y'),
+    ('unit', 'Petugas Unit', 'Petugas loket yang memanggil antrian', 'y', 'y'),
+    ('kiosk', 'Kiosk', 'Perangkat pengambilan nomor antrian', 'n', 'y'),
+    ('supervisor', 'Supervisor', 'Melihat dan mengekspor semua laporan', 'n', 'n'),
+    ('unit_head', 'Kepala Unit', 'Mengelola layanan unit tanpa memanggil antrian', 'y', 'n'),
+    ('auditor', 'Auditor', 'Akses baca saja', 'n', 'n');
+
+INSERT OR IGNORE INTO role_permissions (role, permission) VALUES
+    ('super_user', 'user.view'), ('super_user', 'user.manage'),
+    ('super_user', 'role.view'), ('super_user', 'role.manage'),
+    ('super_user', 'unit.manage'), ('super_user', 'schedule.view'), ('super_user', 'schedule.manage'),
+    ('super_user', 'display.view'), ('super_user', 'display.manage'),
+    ('super_user', 'kiosk.view'), ('super_user', 'kiosk.manage'),
+    ('super_user', 'audio.manage'), ('super_user', 'config.manage'),
+    ('super_user', 'faq.view'), ('super_user', 'faq.manage'),
+    ('super_user', 'backup.export'), ('super_user', 'queue.take'),
+    ('super_user', 'report.view'), ('super_user', 'report.export'),
+
+    ('unit', 'service.view'), ('unit', 'service.manage'), ('unit', 'queue.call'),
+    ('unit', 'report.unit.view'), ('unit', 'report.unit.export'), ('unit', 'dashboard.unit.view'),
+
+    ('kiosk', 'queue.take'),
+
+    ('supervisor', 'report.view'), ('supervisor', 'report.export'),
+
+    ('unit_head', 'service.view'), ('unit_head', 'service.manage'),
+    ('unit_head', 'report.unit.view'), ('unit_head', 'report.unit.export'), ('unit_head', 'dashboard.unit.view'),
+
+    ('auditor', 'user.view'), ('auditor', 'role.view'), ('auditor', 'schedule.view'),
+    ('auditor', 'display.view'), ('auditor', 'kiosk.view'), ('auditor', 'faq.view'),
+    ('auditor', 'report.view');
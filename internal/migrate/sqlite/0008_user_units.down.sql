@@ -0,0 +1,2 @@
+ALTER TABLE user_sessions DROP COLUMN active_unit_id;
+DROP TABLE IF EXISTS user_units;
@@ -0,0 +1,15 @@
+-- User bisa menjadi anggota beberapa unit; unit aktif dipilih per sesi.
+CREATE TABLE IF NOT EXISTS user_units (
+    user_id    INTEGER  NOT NULL,
+    unit_id    INTEGER  NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now', 'localtime')),
+    PRIMARY KEY (user_id, unit_id)
+);
+CREATE INDEX IF NOT EXISTS idx_user_units_unit ON user_units (unit_id);
+
+-- users.unit_id tetap ada sebagai unit default; salin ke mapping.
+INSERT OR IGNORE INTO user_units (user_id, unit_id)
+SELECT id, unit_id FROM users WHERE unit_id IS NOT NULL;
+
+-- Unit aktif sesi (dipilih saat login atau lewat POST /api/me/unit).
+ALTER TABLE user_sessions ADD COLUMN active_unit_id INTEGER NULL;
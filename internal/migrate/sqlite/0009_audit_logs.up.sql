@@ -0,0 +1,27 @@
+-- Jejak aksi administratif: siapa, apa, kapan, dari mana, dan perubahan datanya.
+CREATE TABLE IF NOT EXISTS audit_logs (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    actor_type  VARCHAR(20)  NOT NULL,          -- user, kiosk, system
+    actor_id    INTEGER      NULL,
+    actor_name  VARCHAR(255) NULL,
+    action      VARCHAR(100) NOT NULL,          -- mis. units.hard_delete, backup.download
+    entity      VARCHAR(50)  NULL,
+    entity_id   VARCHAR(100) NULL,
+    before_data TEXT         NULL,              -- JSON snapshot sebelum
+    after_data  TEXT         NULL,              -- JSON snapshot sesudah
+    diff        TEXT         NULL,              -- JSON {field: {before, after}}
+    status_code SMALLINT     NOT NULL DEFAULT 0,
+    method      VARCHAR(10)  NULL,
+    path        VARCHAR(255) NULL,
+    ip_address  VARCHAR(45)  NULL,
+    user_agent  VARCHAR(255) NULL,
+    created_at  DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime'))
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created ON audit_logs (created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor ON audit_logs (actor_type, actor_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_entity ON audit_logs (entity, entity_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_action ON audit_logs (action, created_at);
+
+INSERT OR IGNORE INTO role_permissions (role, permission) VALUES
+    ('super_user', 'audit.view'),
+    ('auditor', 'audit.view');
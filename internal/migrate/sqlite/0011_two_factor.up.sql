@@ -0,0 +1,45 @@
+-- Kebijakan 2FA per role: user dengan role require_2fa='y' wajib TOTP saat login.
+ALTER TABLE roles ADD COLUMN require_2fa TEXT NOT NULL DEFAULT 'n' CHECK (require_2fa IN ('y', 'n'));
+UPDATE roles SET require_2fa = 'y' WHERE name = 'super_user';
+
+-- Secret TOTP per user (terenkripsi AES-GCM). enabled='n' = enrolment belum dikonfirmasi.
+CREATE TABLE IF NOT EXISTS user_totp (
+    user_id      INTEGER      NOT NULL,
+    secret_enc   VARCHAR(255) NOT NULL,
+    enabled      TEXT         NOT NULL DEFAULT 'n' CHECK (enabled IN ('y', 'n')),
+    last_step    BIGINT       NOT NULL DEFAULT 0, -- time step terakhir yang dipakai (anti-replay)
+    confirmed_at DATETIME     NULL,
+    created_at   DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    updated_at   DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime')),
+    PRIMARY KEY (user_id)
+);
+
+CREATE TRIGGER IF NOT EXISTS trg_user_totp_updated_at
+AFTER UPDATE ON user_totp FOR EACH ROW WHEN NEW.updated_at = OLD.updated_at
+BEGIN
+    UPDATE user_totp SET updated_at = datetime('now', 'localtime') WHERE user_id = NEW.user_id;
+END;
+
+-- Kode pemulihan sekali pakai (disimpan sebagai hash).
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id    INTEGER  NOT NULL,
+    code_hash  CHAR(64) NOT NULL,
+    used_at    DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now', 'localtime'))
+);
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user ON user_recovery_codes (user_id, used_at);
+
+-- Langkah kedua login: token challenge setelah password benar, ditukar dengan kode TOTP.
+CREATE TABLE IF NOT EXISTS login_challenges (
+    token_hash CHAR(64)    NOT NULL,
+    user_id    INTEGER     NOT NULL,
+    unit_id    INTEGER     NULL,      -- unit aktif yang dipilih saat login
+    attempts   INT         NOT NULL DEFAULT 0,
+    ip_address VARCHAR(45) NULL,
+    expires_at DATETIME    NOT NULL,
+    created_at DATETIME    NOT NULL DEFAULT (datetime('now', 'localtime')),
+    PRIMARY KEY (token_hash)
+);
+CREATE INDEX IF NOT EXISTS idx_login_challenges_user ON login_challenges (user_id);
+CREATE INDEX IF NOT EXISTS idx_login_challenges_expires ON login_challenges (expires_at);
@@ -0,0 +1,24 @@
+-- Status password user: must_change_password='y' setelah password di-set admin.
+ALTER TABLE users ADD COLUMN password_changed_at DATETIME NULL;
+ALTER TABLE users ADD COLUMN must_change_password TEXT NOT NULL DEFAULT 'n' CHECK (must_change_password IN ('y', 'n'));
+
+-- Riwayat hash password untuk mencegah pemakaian ulang (PASSWORD_HISTORY terakhir).
+CREATE TABLE IF NOT EXISTS password_history (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id       INTEGER      NOT NULL,
+    password_hash VARCHAR(255) NOT NULL,
+    created_at    DATETIME     NOT NULL DEFAULT (datetime('now', 'localtime'))
+);
+CREATE INDEX IF NOT EXISTS idx_password_history_user ON password_history (user_id, id);
+
+-- Token reset sekali pakai yang diterbitkan admin (disimpan sebagai hash).
+CREATE TABLE IF NOT EXISTS password_reset_tokens (
+    token_hash CHAR(64) NOT NULL,
+    user_id    INTEGER  NOT NULL,
+    created_by INTEGER  NULL,
+    expires_at DATETIME NOT NULL,
+    used_at    DATETIME NULL,
+    created_at DATETIME NOT NULL DEFAULT (datetime('now', 'localtime')),
+    PRIMARY KEY (token_hash)
+);
+CREATE INDEX IF NOT EXISTS idx_password_reset_tokens_user ON password_reset_tokens (user_id);
@@ -0,0 +1 @@
+ALTER TABLE units DROP COLUMN timezone;
@@ -0,0 +1,3 @@
+-- Zona waktu per unit (nama IANA, mis. Asia/Makassar untuk MPP di WITA).
+-- NULL berarti ikut APP_TIMEZONE.
+ALTER TABLE units ADD COLUMN timezone VARCHAR(64) NULL;
@@ -0,0 +1 @@
+INSERT OR IGNORE INTO role_permissions (role, permission) VALUES ('super_user', 'queue.take');
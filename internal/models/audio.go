@@ -10,4 +10,33 @@ type Audio struct {
 	PathAudio string    `json:"path_audio"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
-}
\ No newline at end of file
+}
+// AudioUnitRef - unit yang memakai audio (units.audio_file)
+type AudioUnitRef struct {
+	ID       int64  `json:"id"`
+	Code     string `json:"code"`
+	NamaUnit string `json:"nama_unit"`
+	IsActive string `json:"is_active"`
+}
+
+// AudioUsage - audio beserta daftar unit yang mereferensikannya
+type AudioUsage struct {
+	Audio
+	UsageCount int            `json:"usage_count"`
+	Units      []AudioUnitRef `json:"units"`
+}
+
+// CreateAudioRequest field multipart upload audio; file dibaca terpisah
+type CreateAudioRequest struct {
+	TTSText   string `json:"tts_text" form:"tts_text" validate:"notblank"`
+	NamaAudio string `json:"nama_audio" form:"nama_audio" validate:"notblank,max=255"`
+}
+
+// UpdateAudioRequest field multipart ganti audio; kosong = tidak diubah
+type UpdateAudioRequest struct {
+	TTSText string `json:"tts_text" form:"tts_text"`
+}
+
+type RenameAudioRequest struct {
+	NamaAudio string `json:"nama_audio" validate:"notblank,max=255"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditLog - satu baris jejak aksi di audit_logs
+type AuditLog struct {
+	ID         int64           `json:"id"`
+	ActorType  string          `json:"actor_type"`
+	ActorID    *int64          `json:"actor_id"`
+	ActorName  *string         `json:"actor_name"`
+	Action     string          `json:"action"`
+	Entity     *string         `json:"entity"`
+	EntityID   *string         `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	Diff       json.RawMessage `json:"diff"`
+	StatusCode int             `json:"status_code"`
+	Method     *string         `json:"method"`
+	Path       *string         `json:"path"`
+	IPAddress  *string         `json:"ip_address"`
+	UserAgent  *string         `json:"user_agent"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
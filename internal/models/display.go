@@ -0,0 +1,80 @@
+package models
+
+import "time"
+
+// Display - perangkat layar antrian (TV) yang terdaftar
+type Display struct {
+	ID               int64      `json:"id"`
+	Nama             string     `json:"nama"`
+	DeviceID         *string    `json:"device_id"`
+	PairingCode      *string    `json:"pairing_code,omitempty"`
+	PairingExpiresAt *time.Time `json:"pairing_expires_at,omitempty"`
+	PairedAt         *time.Time `json:"paired_at"`
+	Theme            string     `json:"theme"`
+	PlaysAudio       string     `json:"plays_audio"`
+	IsActive         string     `json:"is_active"`
+	UnitIDs          []int64    `json:"unit_ids"`
+	ServiceIDs       []int64    `json:"service_ids"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+type CreateDisplayRequest struct {
+	Nama       string  `json:"nama" validate:"notblank,max=255"`
+	Theme      string  `json:"theme" validate:"omitempty,max=50"`
+	PlaysAudio string  `json:"plays_audio" validate:"omitempty,oneof=y n"`
+	IsActive   string  `json:"is_active" validate:"omitempty,oneof=y n"`
+	UnitIDs    []int64 `json:"unit_ids"`
+	ServiceIDs []int64 `json:"service_ids"`
+}
+
+type UpdateDisplayRequest struct {
+	Nama       string   `json:"nama" validate:"omitempty,max=255"`
+	Theme      string   `json:"theme" validate:"omitempty,max=50"`
+	PlaysAudio string   `json:"plays_audio" validate:"omitempty,oneof=y n"`
+	IsActive   string   `json:"is_active" validate:"omitempty,oneof=y n"`
+	UnitIDs    *[]int64 `json:"unit_ids"`
+	ServiceIDs *[]int64 `json:"service_ids"`
+}
+
+type PairDisplayRequest struct {
+	PairingCode string `json:"pairing_code" validate:"notblank"`
+	DeviceID    string `json:"device_id" validate:"notblank,max=100"`
+}
+
+// DisplayHealth - status koneksi display untuk monitoring admin
+type DisplayHealth struct {
+	ID              int64      `json:"id"`
+	Nama            string     `json:"nama"`
+	DeviceID        *string    `json:"device_id"`
+	IsActive        string     `json:"is_active"`
+	Status          string     `json:"status"` // live, dead, never
+	ConnectedSince  *time.Time `json:"connected_since"`
+	DisconnectedAt  *time.Time `json:"disconnected_at"`
+	LastSeenAt      *time.Time `json:"last_seen_at"`
+	SecondsOffline  *int64     `json:"seconds_offline"`
+	IPAddress       *string    `json:"ip_address"`
+	AppVersion      *string    `json:"app_version"`
+	LiveConnections int        `json:"live_connections"`
+}
+
+// DisplayCommand - perintah remote ke display
+type DisplayCommand struct {
+	ID        int64                  `json:"id"`
+	DisplayID int64                  `json:"display_id"`
+	Command   string                 `json:"command"`
+	Params    map[string]interface{} `json:"params"`
+	Status    string                 `json:"status"` // sent, acked, failed, expired
+	Error     *string                `json:"error"`
+	IssuedBy  *int64                 `json:"issued_by"`
+	AckedBy   *string                `json:"acked_by"`
+	CreatedAt time.Time              `json:"created_at"`
+	AckedAt   *time.Time             `json:"acked_at"`
+}
+
+type DisplayCommandRequest struct {
+	Command  string `json:"command" validate:"required,oneof=reload mute unmute set_volume show_message"`
+	Volume   *int   `json:"volume" validate:"required_if=Command set_volume,omitempty,min=0,max=100"`
+	Message  string `json:"message" validate:"required_if=Command show_message,omitempty,notblank,max=500"`
+	Duration int    `json:"duration" validate:"omitempty,min=1,max=3600"`
+}
@@ -10,4 +10,17 @@ type FAQ struct {
 	SortOrder  int       `json:"sort_order"`
 	CreatedAt  time.Time `json:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at"`
-}
\ No newline at end of file
+}
+type CreateFAQRequest struct {
+	Question  string `json:"question" validate:"notblank,max=255"`
+	Answer    string `json:"answer" validate:"notblank,max=3000"`
+	IsActive  string `json:"is_active" validate:"omitempty,oneof=y n"`
+	SortOrder int    `json:"sort_order" validate:"min=0"`
+}
+
+type UpdateFAQRequest struct {
+	Question  string `json:"question" validate:"omitempty,max=255"`
+	Answer    string `json:"answer" validate:"omitempty,max=3000"`
+	IsActive  string `json:"is_active" validate:"omitempty,oneof=y n"`
+	SortOrder *int   `json:"sort_order" validate:"omitempty,min=0"`
+}
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Kiosk - perangkat pengambilan nomor antrian dengan kredensial sendiri
+type Kiosk struct {
+	ID             int64      `json:"id"`
+	Nama           string     `json:"nama"`
+	DeviceID       string     `json:"device_id"`
+	HasPublicKey   bool       `json:"has_public_key"`
+	KeyFingerprint *string    `json:"key_fingerprint"`
+	IsActive       string     `json:"is_active"`
+	UnitIDs        []int64    `json:"unit_ids"`
+	LastAuthAt     *time.Time `json:"last_auth_at"`
+	LastAuthIP     *string    `json:"last_auth_ip"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+type CreateKioskRequest struct {
+	Nama      string  `json:"nama" validate:"notblank,max=255"`
+	IsActive  string  `json:"is_active" validate:"omitempty,oneof=y n"`
+	PublicKey string  `json:"public_key"`
+	UnitIDs   []int64 `json:"unit_ids" validate:"required,min=1"`
+}
+
+type UpdateKioskRequest struct {
+	Nama      string   `json:"nama" validate:"omitempty,max=255"`
+	IsActive  string   `json:"is_active" validate:"omitempty,oneof=y n"`
+	PublicKey *string  `json:"public_key"`
+	UnitIDs   *[]int64 `json:"unit_ids"`
+}
+
+// KioskAuthRequest - autentikasi kiosk dengan secret ATAU tanda tangan sertifikat.
+// Tanda tangan dibuat atas "<device_id>\n<timestamp>" dengan private key perangkat.
+type KioskAuthRequest struct {
+	DeviceID  string `json:"device_id" validate:"notblank,max=100"`
+	Secret    string `json:"secret" validate:"required_without=Signature"`
+	Timestamp int64  `json:"timestamp"`
+	Signature string `json:"signature" validate:"required_without=Secret"`
+}
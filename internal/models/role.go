@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// Role - role user beserta permission-nya
+type Role struct {
+	Name        string    `json:"name"`
+	Label       string    `json:"label"`
+	Description *string   `json:"description"`
+	UnitScoped  string    `json:"unit_scoped"`
+	Require2FA  string    `json:"require_2fa"`
+	IsSystem    string    `json:"is_system"`
+	Permissions []string  `json:"permissions"`
+	UserCount   int       `json:"user_count"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,max=50"`
+	Label       string   `json:"label" validate:"notblank,max=100"`
+	Description string   `json:"description" validate:"omitempty,max=255"`
+	UnitScoped  string   `json:"unit_scoped" validate:"omitempty,oneof=y n"`
+	Require2FA  string   `json:"require_2fa" validate:"omitempty,oneof=y n"`
+	Permissions []string `json:"permissions"`
+}
+
+type UpdateRoleRequest struct {
+	Label       string    `json:"label" validate:"omitempty,max=100"`
+	Description *string   `json:"description" validate:"omitempty,max=255"`
+	UnitScoped  string    `json:"unit_scoped" validate:"omitempty,oneof=y n"`
+	Require2FA  string    `json:"require_2fa" validate:"omitempty,oneof=y n"`
+	Permissions *[]string `json:"permissions"`
+}
@@ -16,14 +16,14 @@ type Service struct {
 
 type CreateServiceRequest struct {
 	NamaService string `json:"nama_service" validate:"required,max=255"`
-	Code        string `json:"code" validate:"required,max=10"`
+	Code        string `json:"code" validate:"required,ticketcode"`
 	LimitsQueue int    `json:"limits_queue" validate:"min=0"`
 	IsActive    string `json:"is_active" validate:"omitempty,oneof=y n"`
 }
 
 type UpdateServiceRequest struct {
 	NamaService string `json:"nama_service" validate:"omitempty,max=255"`
-	Code        string `json:"code" validate:"omitempty,max=10"`
+	Code        string `json:"code" validate:"omitempty,ticketcode"`
 	LimitsQueue *int   `json:"limits_queue" validate:"omitempty,min=0"`
 	IsActive    string `json:"is_active" validate:"omitempty,oneof=y n"`
 }
\ No newline at end of file
@@ -0,0 +1,18 @@
+package models
+
+// TwoFactorLoginRequest langkah kedua login: challenge token + kode TOTP atau kode pemulihan
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required_without=RecoveryCode"`
+	RecoveryCode   string `json:"recovery_code" validate:"required_without=Code"`
+}
+
+// TwoFactorSetupRequest enrolment TOTP di tengah login (role wajib 2FA)
+type TwoFactorSetupRequest struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+}
+
+// TwoFactorCodeRequest konfirmasi aksi 2FA dengan kode TOTP saat ini
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
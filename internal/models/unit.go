@@ -7,24 +7,27 @@ type Unit struct {
 	Code        string     `json:"code"`
 	NamaUnit    string     `json:"nama_unit"`
 	IsActive    string     `json:"is_active"`
-	MainDisplay string     `json:"main_display"`
+	MainDisplay string     `json:"main_display"` // audio display anonim saja — display terpasang pakai displays.plays_audio
 	AudioFile   *string    `json:"audio_file"`  
+	Timezone    *string    `json:"timezone"` // nil = ikut APP_TIMEZONE
 	CreatedAt   time.Time  `json:"created_at"`
 	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
 type CreateUnitRequest struct {
-	Code        string  `json:"code" validate:"required,max=10"`
+	Code        string  `json:"code" validate:"required,ticketcode"`
 	NamaUnit    string  `json:"nama_unit" validate:"required,max=255"`
 	IsActive    string  `json:"is_active" validate:"omitempty,oneof=y n"`
 	MainDisplay string  `json:"main_display" validate:"omitempty,oneof=active inactive"`
 	AudioFile   *string `json:"audio_file" validate:"omitempty,max=255"`  
+	Timezone    *string `json:"timezone" validate:"omitempty,timezone"`
 }
 
 type UpdateUnitRequest struct {
-	Code        string  `json:"code" validate:"omitempty,max=10"`
+	Code        string  `json:"code" validate:"omitempty,ticketcode"`
 	NamaUnit    string  `json:"nama_unit" validate:"omitempty,max=255"`
 	IsActive    string  `json:"is_active" validate:"omitempty,oneof=y n"`
 	MainDisplay string  `json:"main_display" validate:"omitempty,oneof=active inactive"`
 	AudioFile   *string `json:"audio_file" validate:"omitempty,max=255"`  
+	Timezone    *string `json:"timezone" validate:"omitempty,timezone"`
 }
\ No newline at end of file
@@ -27,11 +27,11 @@ type UnitSchedule struct {
 
 type UpsertScheduleItem struct {
 	DayOfWeek int    `json:"day_of_week" validate:"min=0,max=6"`
-	JamBuka   string `json:"jam_buka"`
-	JamTutup  string `json:"jam_tutup"`
+	JamBuka   string `json:"jam_buka" validate:"required_if=IsActive y,omitempty,timeofday"`  // HH:MM atau HH:MM:SS
+	JamTutup  string `json:"jam_tutup" validate:"required_if=IsActive y,omitempty,timeofday"` // wajib jika hari aktif
 	IsActive  string `json:"is_active" validate:"oneof=y n"`
 }
 
 type UpsertUnitSchedulesRequest struct {
-	Schedules []UpsertScheduleItem `json:"schedules" validate:"required,min=1"`
+	Schedules []UpsertScheduleItem `json:"schedules" validate:"required,min=1,dive"`
 }
@@ -31,14 +31,35 @@ type User struct {
 type LoginRequest struct {
 	Email          string `json:"email" validate:"required"`
 	Password       string `json:"password" validate:"required"`
-	RecaptchaToken string `json:"recaptcha_token"`
+	CaptchaToken   string `json:"captcha_token"`
+	RecaptchaToken string `json:"recaptcha_token"` // nama lama, tetap diterima
+	UnitID         *int64 `json:"unit_id"` // opsional, unit aktif untuk user multi-unit
+}
+
+// UnlockLoginRequest buka lockout login; minimal satu field diisi
+type UnlockLoginRequest struct {
+	Email    string `json:"email" validate:"omitempty,max=255"`
+	DeviceID string `json:"device_id" validate:"omitempty,max=100"`
+	IP       string `json:"ip" validate:"omitempty,ip"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"notblank"`
+}
+
+type SwitchUnitRequest struct {
+	UnitID int64 `json:"unit_id" validate:"required"`
+}
+
+type LogoutRequest struct {
+	All bool `json:"all"` // logout dari semua perangkat
 }
 
 type UpdateUserRequest struct {
 	Nama     string         `json:"nama" validate:"omitempty,max=255"`
 	Email    string         `json:"email" validate:"omitempty,email,max=255"`
 	Password string         `json:"password" validate:"omitempty,min=6"`
-	Role     string         `json:"role" validate:"omitempty,oneof=super_user unit"`
+	Role     string         `json:"role" validate:"omitempty,max=50"` // harus ada di tabel roles
 	IsBanned string         `json:"is_banned" validate:"omitempty,oneof=y n"`
 	UnitID   sql.NullInt64  `json:"unit_id"`
 }
@@ -71,6 +92,7 @@ type UserDetailResponse struct {
 	IsBanned  string    `json:"is_banned"`
 	UnitID    *int64    `json:"unit_id"`
 	UnitName  string    `json:"unit_name,omitempty"`
+	UnitIDs   []int64   `json:"unit_ids,omitempty"` // semua unit anggota (detail saja)
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -116,4 +138,15 @@ func ToUserDetailResponse(u User, unitName string) UserDetailResponse {
 		CreatedAt: u.CreatedAt,
 		UpdatedAt: u.UpdatedAt,
 	}
-}
\ No newline at end of file
+}
+// ChangePasswordRequest ganti password sendiri
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+// ResetPasswordRequest pakai token reset dari admin
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
@@ -0,0 +1,188 @@
+package permission
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Daftar permission yang dikenal aplikasi. Role → permission disimpan di
+// tabel role_permissions dan bisa diubah lewat admin API.
+const (
+	UserView          = "user.view"
+	UserManage        = "user.manage"
+	RoleView          = "role.view"
+	RoleManage        = "role.manage"
+	UnitManage        = "unit.manage"
+	ScheduleView      = "schedule.view"
+	ScheduleManage    = "schedule.manage"
+	DisplayView       = "display.view"
+	DisplayManage     = "display.manage"
+	KioskView         = "kiosk.view"
+	KioskManage       = "kiosk.manage"
+	AudioManage       = "audio.manage"
+	ConfigManage      = "config.manage"
+	FAQView           = "faq.view"
+	FAQManage         = "faq.manage"
+	BackupExport      = "backup.export"
+	ServiceView       = "service.view"
+	ServiceManage     = "service.manage"
+	QueueTake         = "queue.take"
+	QueueCall         = "queue.call"
+	ReportView        = "report.view"
+	ReportExport      = "report.export"
+	ReportUnitView    = "report.unit.view"
+	ReportUnitExport  = "report.unit.export"
+	DashboardUnitView = "dashboard.unit.view"
+	AuditView         = "audit.view"
+	SecurityManage    = "security.manage"
+)
+
+// Definition permission beserta keterangannya untuk UI admin
+type Definition struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+var Catalog = []Definition{
+	{UserView, "Melihat daftar user"},
+	{UserManage, "Membuat, mengubah, dan menghapus user"},
+	{RoleView, "Melihat role dan permission"},
+	{RoleManage, "Mengelola role dan permission"},
+	{UnitManage, "Membuat, mengubah, dan menghapus unit"},
+	{ScheduleView, "Melihat jadwal operasional unit"},
+	{ScheduleManage, "Mengelola jadwal operasional unit"},
+	{DisplayView, "Melihat display dan status koneksinya"},
+	{DisplayManage, "Mengelola display dan mengirim perintah remote"},
+	{KioskView, "Melihat kiosk terdaftar"},
+	{KioskManage, "Mengelola kiosk dan kredensialnya"},
+	{AudioManage, "Mengelola file audio panggilan"},
+	{ConfigManage, "Mengubah konfigurasi aplikasi"},
+	{FAQView, "Melihat FAQ (admin)"},
+	{FAQManage, "Mengelola FAQ"},
+	{BackupExport, "Mengunduh backup database"},
+	{ServiceView, "Melihat layanan unit"},
+	{ServiceManage, "Mengelola layanan unit"},
+	{QueueTake, "Mengambil nomor antrian"},
+	{QueueCall, "Memanggil, melewati, dan menyelesaikan antrian"},
+	{ReportView, "Melihat statistik semua unit"},
+	{ReportExport, "Mengekspor laporan semua unit"},
+	{ReportUnitView, "Melihat statistik unit sendiri"},
+	{ReportUnitExport, "Mengekspor laporan unit sendiri"},
+	{DashboardUnitView, "Melihat dashboard unit sendiri"},
+	{AuditView, "Melihat dan mengekspor audit log"},
+	{SecurityManage, "Membuka lockout login akun, kiosk, dan IP"},
+}
+
+// Known cek apakah nama permission ada di katalog
+func Known(name string) bool {
+	for _, d := range Catalog {
+		if d.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+/*
+|--------------------------------------------------------------------------
+| Cache role → permission
+|--------------------------------------------------------------------------
+| Dibaca di setiap request terproteksi, jadi disimpan di memori dan dimuat
+| ulang setelah cacheTTL atau saat Invalidate dipanggil (role diubah).
+*/
+
+const cacheTTL = time.Minute
+
+var cache = struct {
+	sync.RWMutex
+	roles    map[string]map[string]bool
+	loadedAt time.Time
+	source   Source
+}{source: noSource}
+
+// Source sumber mapping role → daftar permission
+type Source func(ctx context.Context) (map[string][]string, error)
+
+// SetSource pasang sumber permission (repository role, atau data statis
+// di test) dan kosongkan cache
+func SetSource(s Source) {
+	if s == nil {
+		s = noSource
+	}
+	cache.Lock()
+	cache.source = s
+	cache.roles = nil
+	cache.loadedAt = time.Time{}
+	cache.Unlock()
+}
+
+var errNoSource = errors.New("sumber permission belum di-set")
+
+// noSource - sebelum SetSource semua permission ditolak
+func noSource(context.Context) (map[string][]string, error) {
+	return nil, errNoSource
+}
+
+// Has cek apakah role memiliki permission
+func Has(role, perm string) bool {
+	perms := load()
+	return perms[role][perm]
+}
+
+// ForRole daftar permission role, terurut
+func ForRole(role string) []string {
+	result := []string{}
+	for p := range load()[role] {
+		result = append(result, p)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// Invalidate paksa cache dimuat ulang pada pemeriksaan berikutnya
+func Invalidate() {
+	cache.Lock()
+	cache.loadedAt = time.Time{}
+	cache.Unlock()
+}
+
+func load() map[string]map[string]bool {
+	cache.RLock()
+	if cache.roles != nil && time.Since(cache.loadedAt) < cacheTTL {
+		roles := cache.roles
+		cache.RUnlock()
+		return roles
+	}
+	cache.RUnlock()
+
+	cache.Lock()
+	defer cache.Unlock()
+
+	// Goroutine lain mungkin sudah memuat ulang
+	if cache.roles != nil && time.Since(cache.loadedAt) < cacheTTL {
+		return cache.roles
+	}
+
+	loaded, err := cache.source(context.Background())
+	if err != nil {
+		// Pakai cache lama jika ada; tanpa cache semua permission ditolak
+		slog.Error("load error", "component", "permission", "err", err)
+		return cache.roles
+	}
+
+	roles := map[string]map[string]bool{}
+	for role, perms := range loaded {
+		roles[role] = map[string]bool{}
+		for _, perm := range perms {
+			roles[role][perm] = true
+		}
+	}
+
+	cache.roles = roles
+	cache.loadedAt = time.Now()
+	return roles
+}
@@ -0,0 +1,37 @@
+package realtime
+
+import "context"
+
+// Channel pub/sub antar replica. Setiap replica subscribe ke semua channel
+// dan meneruskan pesan ke socket yang terhubung di proses itu sendiri.
+const (
+	ChannelQueueChanges  = "queue:changes"  // []TicketChange — delta antrian
+	ChannelQueueRefresh  = "queue:refresh"  // rebuild snapshot antrian penuh
+	ChannelAnnouncements = "queue:announce" // pengumuman baru / lease dilepas
+	ChannelUnitsStatus   = "units:status"   // payload /ws/units siap kirim
+	ChannelDisplayEvents = "display:events" // refresh, disconnect, command display
+	ChannelRoleChanges   = "roles:changes"  // role/permission diubah, muat ulang cache
+)
+
+// Handler dipanggil untuk setiap pesan yang diterima di channel.
+type Handler func(payload []byte)
+
+// Broadcaster menyebarkan pesan ke semua replica, termasuk replica pengirim.
+// Pengirim tidak boleh fan-out langsung ke socket lokal — cukup Publish,
+// fan-out terjadi di Handler yang didaftarkan lewat Subscribe.
+type Broadcaster interface {
+	// Publish kirim payload ke channel.
+	Publish(ctx context.Context, channel string, payload []byte) error
+	// Subscribe daftarkan handler lokal. Panggil sebelum Start.
+	Subscribe(channel string, handler Handler)
+	// Start mulai menerima pesan.
+	Start(ctx context.Context) error
+	// Close hentikan penerimaan pesan.
+	Close() error
+	// Alive error jika langganan pesan sudah terputus (dipakai /readyz).
+	Alive(ctx context.Context) error
+}
+
+// Bus broadcaster yang dipakai aplikasi. Default in-memory (single replica);
+// main mengganti dengan Redis jika REDIS_ADDR diisi.
+var Bus Broadcaster = NewMemoryBroadcaster()
@@ -0,0 +1,154 @@
+package realtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func receive(t *testing.T, ch <-chan string) string {
+	t.Helper()
+	select {
+	case msg := <-ch:
+		return msg
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout menunggu pesan")
+		return ""
+	}
+}
+
+func expectNothing(t *testing.T, ch <-chan string) {
+	t.Helper()
+	select {
+	case msg := <-ch:
+		t.Fatalf("tidak seharusnya menerima pesan, dapat %q", msg)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func collect(b Broadcaster, channel string) <-chan string {
+	ch := make(chan string, 10)
+	b.Subscribe(channel, func(payload []byte) {
+		ch <- string(payload)
+	})
+	return ch
+}
+
+func TestMemoryBroadcaster(t *testing.T) {
+	b := NewMemoryBroadcaster()
+	queue := collect(b, ChannelQueueRefresh)
+	units := collect(b, ChannelUnitsStatus)
+
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer b.Close()
+
+	if err := b.Publish(context.Background(), ChannelQueueRefresh, []byte("refresh")); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	if got := receive(t, queue); got != "refresh" {
+		t.Errorf("payload = %q, want %q", got, "refresh")
+	}
+	expectNothing(t, units)
+}
+
+func newRedisReplica(t *testing.T, addr, prefix string) *RedisBroadcaster {
+	t.Helper()
+	client := redis.NewClient(&redis.Options{Addr: addr})
+	t.Cleanup(func() { client.Close() })
+	return NewRedisBroadcaster(client, prefix)
+}
+
+func TestRedisBroadcasterFanOutAcrossReplicas(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	replicaA := newRedisReplica(t, mr.Addr(), "antrian:")
+	replicaB := newRedisReplica(t, mr.Addr(), "antrian:")
+
+	queueA := collect(replicaA, ChannelQueueChanges)
+	queueB := collect(replicaB, ChannelQueueChanges)
+	unitsB := collect(replicaB, ChannelUnitsStatus)
+
+	for _, r := range []*RedisBroadcaster{replicaA, replicaB} {
+		if err := r.Start(context.Background()); err != nil {
+			t.Fatalf("Start: %v", err)
+		}
+	}
+	defer replicaA.Close()
+	defer replicaB.Close()
+
+	// Ticket dipanggil lewat replica A — replica A sendiri dan B sama-sama menerima
+	payload := `[{"ticket_id":1,"event":"called"}]`
+	if err := replicaA.Publish(context.Background(), ChannelQueueChanges, []byte(payload)); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+
+	if got := receive(t, queueA); got != payload {
+		t.Errorf("replica A payload = %q, want %q", got, payload)
+	}
+	if got := receive(t, queueB); got != payload {
+		t.Errorf("replica B payload = %q, want %q", got, payload)
+	}
+	expectNothing(t, unitsB)
+
+	// Urutan pesan di satu channel tetap terjaga
+	for _, p := range []string{"1", "2", "3"} {
+		replicaB.Publish(context.Background(), ChannelQueueChanges, []byte(p))
+	}
+	for _, want := range []string{"1", "2", "3"} {
+		if got := receive(t, queueA); got != want {
+			t.Errorf("urutan replica A = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestRedisBroadcasterPrefixIsolation(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	app := newRedisReplica(t, mr.Addr(), "antrian:")
+	other := newRedisReplica(t, mr.Addr(), "lain:")
+
+	received := collect(app, ChannelQueueRefresh)
+	if err := app.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer app.Close()
+
+	other.Publish(context.Background(), ChannelQueueRefresh, []byte("x"))
+	expectNothing(t, received)
+}
+
+func TestRedisBroadcasterStartWithoutSubscription(t *testing.T) {
+	mr := miniredis.RunT(t)
+
+	b := newRedisReplica(t, mr.Addr(), "antrian:")
+	if err := b.Start(context.Background()); err == nil {
+		t.Fatal("Start tanpa subscription seharusnya error")
+	}
+}
+
+func TestRedisBroadcasterAlive(t *testing.T) {
+	mr := miniredis.RunT(t)
+	b := newRedisReplica(t, mr.Addr(), "antrian:")
+	collect(b, ChannelQueueRefresh)
+
+	if err := b.Alive(context.Background()); err == nil {
+		t.Fatal("Alive sebelum Start harus error")
+	}
+	if err := b.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := b.Alive(context.Background()); err != nil {
+		t.Fatalf("Alive: %v", err)
+	}
+
+	b.Close()
+	if err := b.Alive(context.Background()); err == nil {
+		t.Fatal("Alive setelah Close harus error")
+	}
+}
@@ -0,0 +1,33 @@
+package realtime
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// HeartbeatInterval seberapa sering goroutine latar belakang memanggil Beat
+const HeartbeatInterval = 5 * time.Second
+
+// Heartbeat penanda goroutine latar belakang (broadcaster, worker) masih
+// berputar. Loop memanggil Beat tiap HeartbeatInterval; /readyz memeriksa Alive.
+type Heartbeat struct {
+	last atomic.Int64 // unix nano
+}
+
+// Beat catat bahwa loop masih jalan
+func (h *Heartbeat) Beat() {
+	h.last.Store(time.Now().UnixNano())
+}
+
+// Alive error jika belum pernah Beat atau Beat terakhir lebih tua dari maxAge
+func (h *Heartbeat) Alive(maxAge time.Duration) error {
+	last := h.last.Load()
+	if last == 0 {
+		return fmt.Errorf("belum berjalan")
+	}
+	if age := time.Since(time.Unix(0, last)); age > maxAge {
+		return fmt.Errorf("tidak ada heartbeat selama %s", age.Round(time.Second))
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package realtime
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryBroadcaster broadcaster satu proses — pesan langsung diteruskan ke handler.
+type MemoryBroadcaster struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+func NewMemoryBroadcaster() *MemoryBroadcaster {
+	return &MemoryBroadcaster{
+		handlers: make(map[string][]Handler),
+	}
+}
+
+func (b *MemoryBroadcaster) Publish(_ context.Context, channel string, payload []byte) error {
+	b.mu.RLock()
+	handlers := b.handlers[channel]
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(payload)
+	}
+	return nil
+}
+
+func (b *MemoryBroadcaster) Subscribe(channel string, handler Handler) {
+	b.mu.Lock()
+	b.handlers[channel] = append(b.handlers[channel], handler)
+	b.mu.Unlock()
+}
+
+func (b *MemoryBroadcaster) Start(context.Context) error { return nil }
+
+func (b *MemoryBroadcaster) Close() error { return nil }
+
+func (b *MemoryBroadcaster) Alive(context.Context) error { return nil }
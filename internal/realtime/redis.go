@@ -0,0 +1,117 @@
+package realtime
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisBroadcaster broadcaster antar replica lewat Redis pub/sub.
+type RedisBroadcaster struct {
+	client *redis.Client
+	prefix string
+
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+	pubsub   *redis.PubSub
+	done     chan struct{}
+}
+
+// NewRedisBroadcaster buat broadcaster Redis. prefix ditambahkan ke nama channel
+// supaya beberapa aplikasi bisa berbagi satu Redis.
+func NewRedisBroadcaster(client *redis.Client, prefix string) *RedisBroadcaster {
+	return &RedisBroadcaster{
+		client:   client,
+		prefix:   prefix,
+		handlers: make(map[string][]Handler),
+		done:     make(chan struct{}),
+	}
+}
+
+func (b *RedisBroadcaster) Publish(ctx context.Context, channel string, payload []byte) error {
+	return b.client.Publish(ctx, b.prefix+channel, payload).Err()
+}
+
+func (b *RedisBroadcaster) Subscribe(channel string, handler Handler) {
+	b.mu.Lock()
+	b.handlers[channel] = append(b.handlers[channel], handler)
+	b.mu.Unlock()
+}
+
+// Start subscribe semua channel yang terdaftar dan tunggu konfirmasi Redis,
+// sehingga pesan yang di-publish setelah Start pasti diterima.
+func (b *RedisBroadcaster) Start(ctx context.Context) error {
+	b.mu.RLock()
+	channels := make([]string, 0, len(b.handlers))
+	for ch := range b.handlers {
+		channels = append(channels, b.prefix+ch)
+	}
+	b.mu.RUnlock()
+
+	if len(channels) == 0 {
+		return fmt.Errorf("realtime: tidak ada channel yang di-subscribe")
+	}
+
+	pubsub := b.client.Subscribe(ctx, channels...)
+	for range channels {
+		if _, err := pubsub.Receive(ctx); err != nil {
+			pubsub.Close()
+			return fmt.Errorf("realtime: subscribe: %w", err)
+		}
+	}
+	b.pubsub = pubsub
+
+	go b.run(pubsub.Channel())
+	return nil
+}
+
+func (b *RedisBroadcaster) run(messages <-chan *redis.Message) {
+	defer close(b.done)
+
+	for msg := range messages {
+		channel := msg.Channel[len(b.prefix):]
+
+		b.mu.RLock()
+		handlers := b.handlers[channel]
+		b.mu.RUnlock()
+
+		for _, h := range handlers {
+			b.dispatch(channel, h, []byte(msg.Payload))
+		}
+	}
+}
+
+// dispatch jalankan handler; panic di satu handler tidak mematikan subscriber.
+func (b *RedisBroadcaster) dispatch(channel string, h Handler, payload []byte) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("handler panic", "component", "realtime", "channel", channel, "panic", fmt.Sprint(r))
+		}
+	}()
+	h(payload)
+}
+
+// Alive cek goroutine subscriber masih jalan dan koneksi pub/sub merespons ping
+func (b *RedisBroadcaster) Alive(ctx context.Context) error {
+	if b.pubsub == nil {
+		return fmt.Errorf("realtime: belum start")
+	}
+	select {
+	case <-b.done:
+		return fmt.Errorf("realtime: subscriber berhenti")
+	default:
+	}
+	return b.pubsub.Ping(ctx)
+}
+
+func (b *RedisBroadcaster) Close() error {
+	if b.pubsub == nil {
+		return nil
+	}
+	err := b.pubsub.Close()
+	<-b.done
+	return err
+}
@@ -1,12 +1,25 @@
 package realtime
 
-import "github.com/gofiber/websocket/v2"
+import (
+	"backend-antrian/internal/metrics"
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/websocket/v2"
+)
 
 type UnitsHub struct {
 	Register   chan *websocket.Conn
 	Unregister chan *websocket.Conn
 	Broadcast  chan []byte
 	Clients    map[*websocket.Conn]bool
+
+	count    atomic.Int64 // len(Clients), aman dibaca dari goroutine lain
+	closeAll chan closeRequest
+
+	// Heartbeat loop RunUnitsBroadcaster, diperiksa /readyz
+	Heartbeat Heartbeat
 }
 
 var Units = UnitsHub{
@@ -14,20 +27,76 @@ var Units = UnitsHub{
 	Unregister: make(chan *websocket.Conn),
 	Broadcast:  make(chan []byte),
 	Clients:    make(map[*websocket.Conn]bool),
+	closeAll:   make(chan closeRequest),
+}
+
+type closeRequest struct {
+	reason string
+	done   chan struct{}
+}
+
+// Metrik fan-out WebSocket, label kind = jenis pesan (units, queue_delta, ...)
+var (
+	BroadcastDuration = metrics.NewHistogram("antrian_ws_broadcast_duration_seconds",
+		"Durasi fan-out satu pesan WebSocket ke semua client", metrics.DefBuckets, "kind")
+	BroadcastBytes = metrics.NewHistogram("antrian_ws_broadcast_payload_bytes",
+		"Ukuran payload WebSocket per client penerima", metrics.SizeBuckets, "kind")
+)
+
+// CloseAll kirim close frame 1012 (service restart) dengan reason ke semua
+// client lalu putus koneksinya. Dikerjakan goroutine broadcaster supaya tidak
+// bentrok dengan broadcast yang sedang jalan; menunggu sampai selesai atau ctx habis.
+func (h *UnitsHub) CloseAll(ctx context.Context, reason string) error {
+	req := closeRequest{reason: reason, done: make(chan struct{})}
+	select {
+	case h.closeAll <- req:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-req.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Count jumlah client /ws/units yang terhubung
+func (h *UnitsHub) Count() int {
+	return int(h.count.Load())
 }
 
 func RunUnitsBroadcaster() {
+	ticker := time.NewTicker(HeartbeatInterval)
+	defer ticker.Stop()
+	Units.Heartbeat.Beat()
+
 	for {
 		select {
+		case <-ticker.C:
+			Units.Heartbeat.Beat()
 		case c := <-Units.Register:
 			Units.Clients[c] = true
+			Units.count.Store(int64(len(Units.Clients)))
 		case c := <-Units.Unregister:
+			// Conn ditutup handler sendiri: setelah Unregister diterima,
+			// handler langsung return dan Conn dikembalikan ke pool gofiber
 			delete(Units.Clients, c)
-			c.Close()
+			Units.count.Store(int64(len(Units.Clients)))
 		case msg := <-Units.Broadcast:
+			start := time.Now()
 			for c := range Units.Clients {
 				c.WriteMessage(websocket.TextMessage, msg)
+				BroadcastBytes.Observe(float64(len(msg)), "units")
+			}
+			BroadcastDuration.Observe(time.Since(start).Seconds(), "units")
+		case req := <-Units.closeAll:
+			msg := websocket.FormatCloseMessage(websocket.CloseServiceRestart, req.reason)
+			for c := range Units.Clients {
+				c.WriteControl(websocket.CloseMessage, msg, time.Now().Add(time.Second))
+				c.Close()
 			}
+			close(req.done)
 		}
 	}
 }
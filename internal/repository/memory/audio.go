@@ -0,0 +1,179 @@
+package memory
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"sort"
+)
+
+type audioRepo struct {
+	s *store
+}
+
+func (r *audioRepo) List(ctx context.Context) ([]models.Audio, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	audios := []models.Audio{}
+	for _, a := range r.s.audios {
+		audios = append(audios, a)
+	}
+	sort.Slice(audios, func(i, j int) bool {
+		if !audios[i].CreatedAt.Equal(audios[j].CreatedAt) {
+			return audios[i].CreatedAt.After(audios[j].CreatedAt)
+		}
+		return audios[i].ID > audios[j].ID
+	})
+	return audios, nil
+}
+
+func (r *audioRepo) Get(ctx context.Context, id int64) (models.Audio, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	a, ok := r.s.audios[id]
+	if !ok {
+		return models.Audio{}, repository.ErrNotFound
+	}
+	return a, nil
+}
+
+func (r *audioRepo) NameExists(ctx context.Context, namaAudio string, excludeID int64) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, a := range r.s.audios {
+		if a.ID != excludeID && a.NamaAudio == namaAudio {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *audioRepo) Create(ctx context.Context, a models.Audio) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, existing := range r.s.audios {
+		if existing.NamaAudio == a.NamaAudio {
+			return 0, repository.ErrDuplicate
+		}
+	}
+	a.ID = r.s.nextID("tts_audio_cache")
+	a.CreatedAt = r.s.stamp()
+	a.UpdatedAt = a.CreatedAt
+	r.s.audios[a.ID] = a
+	return a.ID, nil
+}
+
+func (r *audioRepo) CreateMany(ctx context.Context, audios []models.Audio) ([]int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	names := map[string]bool{}
+	for _, existing := range r.s.audios {
+		names[existing.NamaAudio] = true
+	}
+	for _, a := range audios {
+		if names[a.NamaAudio] {
+			return nil, repository.ErrDuplicate
+		}
+		names[a.NamaAudio] = true
+	}
+
+	ids := make([]int64, 0, len(audios))
+	for _, a := range audios {
+		a.ID = r.s.nextID("tts_audio_cache")
+		a.CreatedAt = r.s.stamp()
+		a.UpdatedAt = a.CreatedAt
+		r.s.audios[a.ID] = a
+		ids = append(ids, a.ID)
+	}
+	return ids, nil
+}
+
+func (r *audioRepo) UpdateText(ctx context.Context, id int64, ttsText string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	a, ok := r.s.audios[id]
+	if !ok {
+		return nil
+	}
+	a.TTSText = ttsText
+	a.UpdatedAt = r.s.stamp()
+	r.s.audios[id] = a
+	return nil
+}
+
+func (r *audioRepo) Rename(ctx context.Context, id int64, namaAudio, pathAudio string) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	a, ok := r.s.audios[id]
+	if !ok {
+		return 0, repository.ErrNotFound
+	}
+	for _, existing := range r.s.audios {
+		if existing.ID != id && existing.NamaAudio == namaAudio {
+			return 0, repository.ErrDuplicate
+		}
+	}
+
+	oldName := a.NamaAudio
+	a.NamaAudio = namaAudio
+	a.PathAudio = pathAudio
+	a.UpdatedAt = r.s.stamp()
+	r.s.audios[id] = a
+
+	var n int64
+	for uid, u := range r.s.units {
+		if u.AudioFile != nil && *u.AudioFile == oldName {
+			file := namaAudio
+			u.AudioFile = &file
+			u.UpdatedAt = a.UpdatedAt
+			r.s.units[uid] = u
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *audioRepo) Delete(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.audios[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.s.audios, id)
+	return nil
+}
+
+func (r *audioRepo) Usage(ctx context.Context, audioID int64) ([]models.AudioUsage, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	usages := []models.AudioUsage{}
+	for _, a := range r.s.audios {
+		if audioID > 0 && a.ID != audioID {
+			continue
+		}
+		usage := models.AudioUsage{Audio: a, Units: []models.AudioUnitRef{}}
+		for _, u := range r.s.units {
+			if u.AudioFile != nil && *u.AudioFile == a.NamaAudio {
+				usage.Units = append(usage.Units, models.AudioUnitRef{
+					ID:       u.ID,
+					Code:     u.Code,
+					NamaUnit: u.NamaUnit,
+					IsActive: u.IsActive,
+				})
+			}
+		}
+		sort.Slice(usage.Units, func(i, j int) bool {
+			if usage.Units[i].NamaUnit != usage.Units[j].NamaUnit {
+				return usage.Units[i].NamaUnit < usage.Units[j].NamaUnit
+			}
+			return usage.Units[i].ID < usage.Units[j].ID
+		})
+		usage.UsageCount = len(usage.Units)
+		usages = append(usages, usage)
+	}
+	sort.Slice(usages, func(i, j int) bool {
+		return usages[i].NamaAudio < usages[j].NamaAudio
+	})
+	return usages, nil
+}
@@ -0,0 +1,44 @@
+package memory
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+)
+
+type configRepo struct {
+	s *store
+}
+
+func (r *configRepo) Get(ctx context.Context) (models.Config, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	var first *models.Config
+	for _, cfg := range r.s.configs {
+		if first == nil || cfg.ID < first.ID {
+			cfg := cfg
+			first = &cfg
+		}
+	}
+	if first == nil {
+		return models.Config{}, repository.ErrNotFound
+	}
+	return *first, nil
+}
+
+func (r *configRepo) Create(ctx context.Context, textMarque string) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	id := r.s.nextID("configs")
+	r.s.configs[id] = models.Config{ID: id, TextMarque: textMarque}
+	return id, nil
+}
+
+func (r *configRepo) Update(ctx context.Context, id int64, textMarque string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.configs[id]; ok {
+		r.s.configs[id] = models.Config{ID: id, TextMarque: textMarque}
+	}
+	return nil
+}
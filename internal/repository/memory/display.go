@@ -0,0 +1,354 @@
+package memory
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+)
+
+// display - displays beserta hash token perangkat
+type display struct {
+	models.Display
+	TokenHash string
+}
+
+// displayStatus - display_status; satu baris per display
+type displayStatus struct {
+	ClientID       string
+	ConnectedSince time.Time
+	DisconnectedAt *time.Time
+	LastSeenAt     time.Time
+	IPAddress      string
+	AppVersion     string
+}
+
+type displayRepo struct {
+	s *store
+}
+
+// copyDisplay - salinan display dengan slice scope sendiri
+func copyDisplay(d display) models.Display {
+	out := d.Display
+	out.UnitIDs = append([]int64{}, d.UnitIDs...)
+	out.ServiceIDs = append([]int64{}, d.ServiceIDs...)
+	return out
+}
+
+// pairingTaken - padanan UNIQUE pairing_code; caller memegang lock
+func (r *displayRepo) pairingTaken(code *string, excludeID int64) bool {
+	if code == nil {
+		return false
+	}
+	for _, d := range r.s.displays {
+		if d.ID != excludeID && d.PairingCode != nil && *d.PairingCode == *code {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *displayRepo) List(ctx context.Context) ([]models.Display, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	displays := []models.Display{}
+	for _, d := range r.s.displays {
+		displays = append(displays, copyDisplay(d))
+	}
+	sort.Slice(displays, func(i, j int) bool { return displays[i].Nama < displays[j].Nama })
+	return displays, nil
+}
+
+func (r *displayRepo) Get(ctx context.Context, id int64) (models.Display, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	d, ok := r.s.displays[id]
+	if !ok {
+		return models.Display{}, repository.ErrNotFound
+	}
+	return copyDisplay(d), nil
+}
+
+func (r *displayRepo) Create(ctx context.Context, md models.Display) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if r.pairingTaken(md.PairingCode, 0) {
+		return 0, repository.ErrDuplicate
+	}
+	md.ID = r.s.nextID("displays")
+	md.DeviceID, md.PairedAt = nil, nil
+	md.UnitIDs = sortedIDs(md.UnitIDs)
+	md.ServiceIDs = sortedIDs(md.ServiceIDs)
+	md.CreatedAt = r.s.stamp()
+	md.UpdatedAt = md.CreatedAt
+	r.s.displays[md.ID] = display{Display: md}
+	return md.ID, nil
+}
+
+func (r *displayRepo) Update(ctx context.Context, id int64, p repository.DisplayPatch) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	d, ok := r.s.displays[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if p.Nama != "" {
+		d.Nama = p.Nama
+	}
+	if p.Theme != "" {
+		d.Theme = p.Theme
+	}
+	if p.PlaysAudio != "" {
+		d.PlaysAudio = p.PlaysAudio
+	}
+	if p.IsActive != "" {
+		d.IsActive = p.IsActive
+	}
+	if p.UnitIDs != nil {
+		d.UnitIDs = sortedIDs(*p.UnitIDs)
+	}
+	if p.ServiceIDs != nil {
+		d.ServiceIDs = sortedIDs(*p.ServiceIDs)
+	}
+	d.UpdatedAt = r.s.stamp()
+	r.s.displays[id] = d
+	return nil
+}
+
+func (r *displayRepo) ResetPairing(ctx context.Context, id int64, code string, expiresAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	d, ok := r.s.displays[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if r.pairingTaken(&code, id) {
+		return repository.ErrDuplicate
+	}
+	d.PairingCode, d.PairingExpiresAt = &code, &expiresAt
+	d.DeviceID, d.PairedAt, d.TokenHash = nil, nil, ""
+	d.UpdatedAt = r.s.stamp()
+	r.s.displays[id] = d
+	return nil
+}
+
+func (r *displayRepo) Delete(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.displays[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.s.displays, id)
+	delete(r.s.displayStatus, id)
+	for cid, cmd := range r.s.commands {
+		if cmd.DisplayID == id {
+			delete(r.s.commands, cid)
+		}
+	}
+	return nil
+}
+
+func (r *displayRepo) ByPairingCode(ctx context.Context, code string) (models.Display, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, d := range r.s.displays {
+		if d.PairingCode != nil && *d.PairingCode == code {
+			return copyDisplay(d), nil
+		}
+	}
+	return models.Display{}, repository.ErrNotFound
+}
+
+func (r *displayRepo) DeviceIDExists(ctx context.Context, deviceID string, excludeID int64) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, d := range r.s.displays {
+		if d.ID != excludeID && d.DeviceID != nil && *d.DeviceID == deviceID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *displayRepo) Pair(ctx context.Context, id int64, deviceID, tokenHash string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	d, ok := r.s.displays[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	for _, other := range r.s.displays {
+		if other.ID != id && other.DeviceID != nil && *other.DeviceID == deviceID {
+			return repository.ErrDuplicate
+		}
+	}
+	now := r.s.stamp()
+	d.DeviceID, d.TokenHash, d.PairedAt = &deviceID, tokenHash, &now
+	d.PairingCode, d.PairingExpiresAt = nil, nil
+	d.UpdatedAt = now
+	r.s.displays[id] = d
+	return nil
+}
+
+func (r *displayRepo) Token(ctx context.Context, deviceID string) (repository.DisplayToken, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, d := range r.s.displays {
+		if d.DeviceID != nil && *d.DeviceID == deviceID {
+			return repository.DisplayToken{ID: d.ID, TokenHash: d.TokenHash, IsActive: d.IsActive}, nil
+		}
+	}
+	return repository.DisplayToken{}, repository.ErrNotFound
+}
+
+func (r *displayRepo) Connected(ctx context.Context, c repository.DisplayConnection) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	now := r.s.stamp()
+	st := displayStatus{ClientID: c.ClientID, ConnectedSince: now, LastSeenAt: now, IPAddress: c.IPAddress, AppVersion: c.AppVersion}
+	if st.AppVersion == "" {
+		st.AppVersion = r.s.displayStatus[c.DisplayID].AppVersion
+	}
+	r.s.displayStatus[c.DisplayID] = st
+	return nil
+}
+
+// status - baris display_status milik clientID; caller memegang lock
+func (r *displayRepo) status(displayID int64, clientID string) (displayStatus, bool) {
+	st, ok := r.s.displayStatus[displayID]
+	return st, ok && st.ClientID == clientID
+}
+
+func (r *displayRepo) Heartbeat(ctx context.Context, displayID int64, clientID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if st, ok := r.status(displayID, clientID); ok {
+		st.LastSeenAt = r.s.stamp()
+		r.s.displayStatus[displayID] = st
+	}
+	return nil
+}
+
+func (r *displayRepo) Disconnected(ctx context.Context, displayID int64, clientID string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if st, ok := r.status(displayID, clientID); ok {
+		now := r.s.stamp()
+		st.DisconnectedAt, st.LastSeenAt = &now, now
+		r.s.displayStatus[displayID] = st
+	}
+	return nil
+}
+
+func (r *displayRepo) SetAppVersion(ctx context.Context, displayID int64, clientID, version string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if st, ok := r.status(displayID, clientID); ok {
+		st.AppVersion = version
+		r.s.displayStatus[displayID] = st
+	}
+	return nil
+}
+
+func (r *displayRepo) Health(ctx context.Context, now time.Time) ([]repository.DisplayHealth, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	result := []repository.DisplayHealth{}
+	for _, d := range r.s.displays {
+		h := repository.DisplayHealth{DisplayHealth: models.DisplayHealth{
+			ID: d.ID, Nama: d.Nama, DeviceID: d.DeviceID, IsActive: d.IsActive,
+		}}
+		if st, ok := r.s.displayStatus[d.ID]; ok {
+			connected, lastSeen := st.ConnectedSince, st.LastSeenAt
+			h.ConnectedSince, h.LastSeenAt = &connected, &lastSeen
+			h.DisconnectedAt = st.DisconnectedAt
+			ip := st.IPAddress
+			h.IPAddress = &ip
+			if st.AppVersion != "" {
+				version := st.AppVersion
+				h.AppVersion = &version
+			}
+			h.SecondsSince = int64(now.Sub(lastSeen).Round(time.Second).Seconds())
+		}
+		result = append(result, h)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Nama < result[j].Nama })
+	return result, nil
+}
+
+func (r *displayRepo) Online(ctx context.Context, displayID int64, since time.Time) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	st, ok := r.s.displayStatus[displayID]
+	return ok && st.DisconnectedAt == nil && !st.LastSeenAt.Before(since), nil
+}
+
+func (r *displayRepo) CreateCommand(ctx context.Context, c repository.NewDisplayCommand) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.displays[c.DisplayID]; !ok {
+		return 0, repository.ErrInUse
+	}
+	cmd := models.DisplayCommand{
+		ID:        r.s.nextID("display_commands"),
+		DisplayID: c.DisplayID,
+		Command:   c.Command,
+		Params:    map[string]interface{}{},
+		Status:    "sent",
+		IssuedBy:  c.IssuedBy,
+		CreatedAt: r.s.stamp(),
+	}
+	json.Unmarshal([]byte(c.Params), &cmd.Params)
+	r.s.commands[cmd.ID] = cmd
+	return cmd.ID, nil
+}
+
+func (r *displayRepo) Command(ctx context.Context, id int64) (models.DisplayCommand, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	cmd, ok := r.s.commands[id]
+	if !ok {
+		return cmd, repository.ErrNotFound
+	}
+	return cmd, nil
+}
+
+func (r *displayRepo) Commands(ctx context.Context, displayID int64, limit int) ([]models.DisplayCommand, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	commands := []models.DisplayCommand{}
+	for _, cmd := range r.s.commands {
+		if cmd.DisplayID == displayID {
+			commands = append(commands, cmd)
+		}
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].ID > commands[j].ID })
+	return paginate(commands, limit, 0), nil
+}
+
+func (r *displayRepo) AckCommand(ctx context.Context, id, displayID int64, clientID, status string, errMsg *string) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	cmd, ok := r.s.commands[id]
+	if !ok || cmd.DisplayID != displayID || cmd.Status != "sent" {
+		return false, nil
+	}
+	now := r.s.stamp()
+	cmd.Status, cmd.Error, cmd.AckedBy, cmd.AckedAt = status, errMsg, &clientID, &now
+	r.s.commands[id] = cmd
+	return true, nil
+}
+
+func (r *displayRepo) ExpireCommands(ctx context.Context, before time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for id, cmd := range r.s.commands {
+		if cmd.Status == "sent" && cmd.CreatedAt.Before(before) {
+			cmd.Status = "expired"
+			r.s.commands[id] = cmd
+		}
+	}
+	return nil
+}
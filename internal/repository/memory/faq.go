@@ -0,0 +1,102 @@
+package memory
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"sort"
+)
+
+type faqRepo struct {
+	s *store
+}
+
+func (r *faqRepo) filter(f repository.FAQFilter) []models.FAQ {
+	faqs := []models.FAQ{}
+	for _, faq := range r.s.faqs {
+		if f.IsActive != "" && faq.IsActive != f.IsActive {
+			continue
+		}
+		if f.Search != "" && !like(faq.Question, f.Search) && !like(faq.Answer, f.Search) {
+			continue
+		}
+		faqs = append(faqs, faq)
+	}
+	sort.Slice(faqs, func(i, j int) bool {
+		a, b := faqs[i], faqs[j]
+		if a.SortOrder != b.SortOrder {
+			return a.SortOrder < b.SortOrder
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		return a.ID < b.ID
+	})
+	return faqs
+}
+
+func (r *faqRepo) List(ctx context.Context, f repository.FAQFilter) ([]models.FAQ, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return paginate(r.filter(f), f.Limit, f.Offset), nil
+}
+
+func (r *faqRepo) Count(ctx context.Context, f repository.FAQFilter) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return len(r.filter(f)), nil
+}
+
+func (r *faqRepo) Get(ctx context.Context, id int64) (models.FAQ, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	faq, ok := r.s.faqs[id]
+	if !ok {
+		return models.FAQ{}, repository.ErrNotFound
+	}
+	return faq, nil
+}
+
+func (r *faqRepo) Create(ctx context.Context, faq models.FAQ) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	faq.ID = r.s.nextID("faqs")
+	faq.CreatedAt = r.s.stamp()
+	faq.UpdatedAt = faq.CreatedAt
+	r.s.faqs[faq.ID] = faq
+	return faq.ID, nil
+}
+
+func (r *faqRepo) Update(ctx context.Context, id int64, p repository.FAQPatch) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	faq, ok := r.s.faqs[id]
+	if !ok {
+		return nil
+	}
+	if p.Question != "" {
+		faq.Question = p.Question
+	}
+	if p.Answer != "" {
+		faq.Answer = p.Answer
+	}
+	if p.IsActive != "" {
+		faq.IsActive = p.IsActive
+	}
+	if p.SortOrder != nil {
+		faq.SortOrder = *p.SortOrder
+	}
+	faq.UpdatedAt = r.s.stamp()
+	r.s.faqs[id] = faq
+	return nil
+}
+
+func (r *faqRepo) Delete(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.faqs[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.s.faqs, id)
+	return nil
+}
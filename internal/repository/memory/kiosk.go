@@ -0,0 +1,192 @@
+package memory
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"sort"
+	"time"
+)
+
+// kiosk - kiosks beserta kredensial perangkat
+type kiosk struct {
+	models.Kiosk
+	SecretHash    string
+	PublicKey     string
+	ValidAfter    time.Time
+	LastAssertion *int64
+}
+
+type kioskRepo struct {
+	s *store
+}
+
+func copyKiosk(k kiosk) models.Kiosk {
+	out := k.Kiosk
+	out.UnitIDs = append([]int64{}, k.UnitIDs...)
+	return out
+}
+
+// setKey - padanan kolom public_key & key_fingerprint
+func (k *kiosk) setKey(key repository.KioskKey) {
+	k.PublicKey = key.PublicKey.String
+	k.HasPublicKey = key.PublicKey.Valid && key.PublicKey.String != ""
+	k.KeyFingerprint = nil
+	if key.Fingerprint.Valid {
+		fingerprint := key.Fingerprint.String
+		k.KeyFingerprint = &fingerprint
+	}
+}
+
+func (r *kioskRepo) List(ctx context.Context) ([]models.Kiosk, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	kiosks := []models.Kiosk{}
+	for _, k := range r.s.kiosks {
+		kiosks = append(kiosks, copyKiosk(k))
+	}
+	sort.Slice(kiosks, func(i, j int) bool { return kiosks[i].Nama < kiosks[j].Nama })
+	return kiosks, nil
+}
+
+func (r *kioskRepo) Get(ctx context.Context, id int64) (models.Kiosk, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	k, ok := r.s.kiosks[id]
+	if !ok {
+		return models.Kiosk{}, repository.ErrNotFound
+	}
+	return copyKiosk(k), nil
+}
+
+func (r *kioskRepo) Credentials(ctx context.Context, deviceID string) (repository.KioskCredentials, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, k := range r.s.kiosks {
+		if k.DeviceID == deviceID {
+			return repository.KioskCredentials{Kiosk: copyKiosk(k), SecretHash: k.SecretHash, PublicKey: k.PublicKey}, nil
+		}
+	}
+	return repository.KioskCredentials{}, repository.ErrNotFound
+}
+
+func (r *kioskRepo) Create(ctx context.Context, nk repository.NewKiosk) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, existing := range r.s.kiosks {
+		if existing.DeviceID == nk.DeviceID {
+			return 0, repository.ErrDuplicate
+		}
+	}
+	k := kiosk{
+		Kiosk: models.Kiosk{
+			ID:       r.s.nextID("kiosks"),
+			Nama:     nk.Nama,
+			DeviceID: nk.DeviceID,
+			IsActive: nk.IsActive,
+			UnitIDs:  sortedIDs(nk.UnitIDs),
+		},
+		SecretHash: nk.SecretHash,
+		ValidAfter: nk.ValidAfter,
+	}
+	k.setKey(nk.Key)
+	k.CreatedAt = r.s.stamp()
+	k.UpdatedAt = k.CreatedAt
+	r.s.kiosks[k.ID] = k
+	return k.ID, nil
+}
+
+func (r *kioskRepo) Update(ctx context.Context, id int64, p repository.KioskPatch) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	k, ok := r.s.kiosks[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if p.Nama != "" {
+		k.Nama = p.Nama
+	}
+	if p.IsActive != "" {
+		k.IsActive = p.IsActive
+	}
+	if p.Key != nil {
+		k.setKey(*p.Key)
+		k.LastAssertion = nil
+	}
+	if p.UnitIDs != nil {
+		k.UnitIDs = sortedIDs(*p.UnitIDs)
+	}
+	k.UpdatedAt = r.s.stamp()
+	r.s.kiosks[id] = k
+	return nil
+}
+
+func (r *kioskRepo) RotateSecret(ctx context.Context, id int64, secretHash string, validAfter time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	k, ok := r.s.kiosks[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	k.SecretHash, k.ValidAfter = secretHash, validAfter
+	k.UpdatedAt = r.s.stamp()
+	r.s.kiosks[id] = k
+	return nil
+}
+
+func (r *kioskRepo) Delete(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.kiosks[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.s.kiosks, id)
+	return nil
+}
+
+func (r *kioskRepo) Units(ctx context.Context, kioskID int64) ([]models.Unit, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	units := []models.Unit{}
+	for _, id := range r.s.kiosks[kioskID].UnitIDs {
+		if u, ok := r.s.units[id]; ok {
+			units = append(units, u)
+		}
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i].NamaUnit < units[j].NamaUnit })
+	return units, nil
+}
+
+func (r *kioskRepo) AllowsUnit(ctx context.Context, kioskID, unitID int64) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, id := range r.s.kiosks[kioskID].UnitIDs {
+		if id == unitID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *kioskRepo) RecordAuth(ctx context.Context, id int64, ip string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if k, ok := r.s.kiosks[id]; ok {
+		now := r.s.stamp()
+		k.LastAuthAt, k.LastAuthIP = &now, &ip
+		r.s.kiosks[id] = k
+	}
+	return nil
+}
+
+func (r *kioskRepo) UseAssertion(ctx context.Context, id int64, timestamp int64) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	k, ok := r.s.kiosks[id]
+	if !ok || (k.LastAssertion != nil && *k.LastAssertion >= timestamp) {
+		return false, nil
+	}
+	k.LastAssertion = &timestamp
+	r.s.kiosks[id] = k
+	return true, nil
+}
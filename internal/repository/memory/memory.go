@@ -0,0 +1,149 @@
+// Package memory implementasi repository in-memory untuk test handler
+// tanpa database. Perilakunya meniru sqlrepo: urutan list, filter,
+// ErrNotFound / ErrInUse, dan timestamp created_at / updated_at.
+package memory
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// store - semua tabel dalam satu mutex supaya relasi (mis. nama unit
+// pada service) konsisten seperti JOIN
+type store struct {
+	mu  sync.Mutex
+	now func() time.Time
+	seq map[string]int64
+
+	units         map[int64]models.Unit
+	services      map[int64]models.Service
+	tickets       map[int64]ticket
+	txns          []models.QueueTransaction
+	users         map[int64]user
+	userUnits     map[int64][]int64 // user_id -> unit_id, urut
+	schedules     map[int64]models.UnitSchedule
+	faqs          map[int64]models.FAQ
+	audios        map[int64]models.Audio
+	configs       map[int64]models.Config
+	sessions      map[string]session
+	refreshes     map[int64]refreshToken
+	denylist      map[string]time.Time // jti -> expires_at
+	roles         map[string]models.Role
+	history       map[int64][]string // user_id -> hash password, terlama dulu
+	resets        map[string]resetToken
+	totp          map[int64]repository.TOTPSecret
+	recovery      map[int64][]recoveryCode
+	challenge     map[string]repository.LoginChallenge
+	displays      map[int64]display
+	displayStatus map[int64]displayStatus
+	commands      map[int64]models.DisplayCommand
+	kiosks        map[int64]kiosk
+}
+
+// ticket - queue_tickets beserta kiosk pengambil
+type ticket struct {
+	models.QueueTicket
+	KioskID *int64
+}
+
+// user - users beserta status password
+type user struct {
+	models.User
+	MustChangePassword bool
+	PasswordChangedAt  *time.Time
+}
+
+// New - repository kosong; tiap pemanggilan punya data sendiri
+func New() *repository.Repos {
+	s := &store{
+		now:           clock.Now,
+		seq:           map[string]int64{},
+		units:         map[int64]models.Unit{},
+		services:      map[int64]models.Service{},
+		tickets:       map[int64]ticket{},
+		users:         map[int64]user{},
+		userUnits:     map[int64][]int64{},
+		schedules:     map[int64]models.UnitSchedule{},
+		faqs:          map[int64]models.FAQ{},
+		audios:        map[int64]models.Audio{},
+		configs:       map[int64]models.Config{},
+		sessions:      map[string]session{},
+		refreshes:     map[int64]refreshToken{},
+		denylist:      map[string]time.Time{},
+		roles:         map[string]models.Role{},
+		history:       map[int64][]string{},
+		resets:        map[string]resetToken{},
+		totp:          map[int64]repository.TOTPSecret{},
+		recovery:      map[int64][]recoveryCode{},
+		challenge:     map[string]repository.LoginChallenge{},
+		displays:      map[int64]display{},
+		displayStatus: map[int64]displayStatus{},
+		commands:      map[int64]models.DisplayCommand{},
+		kiosks:        map[int64]kiosk{},
+	}
+	return &repository.Repos{
+		Units:     &unitRepo{s},
+		Services:  &serviceRepo{s},
+		Tickets:   &ticketRepo{s},
+		Users:     &userRepo{s},
+		Schedules: &scheduleRepo{s},
+		FAQs:      &faqRepo{s},
+		Audios:    &audioRepo{s},
+		Configs:   &configRepo{s},
+		Reports:   &reportRepo{s},
+		Sessions:  &sessionRepo{s},
+		Roles:     &roleRepo{s},
+		Passwords: &passwordRepo{s},
+		TwoFactor: &twoFactorRepo{s},
+		Displays:  &displayRepo{s},
+		Kiosks:    &kioskRepo{s},
+	}
+}
+
+// nextID - auto increment per tabel
+func (s *store) nextID(table string) int64 {
+	s.seq[table]++
+	return s.seq[table]
+}
+
+// stamp - waktu sekarang dibulatkan ke detik seperti kolom DATETIME
+func (s *store) stamp() time.Time {
+	return s.now().Truncate(time.Second)
+}
+
+// like - padanan LIKE '%kata%' dengan collation case-insensitive
+func like(value, search string) bool {
+	return strings.Contains(strings.ToLower(value), strings.ToLower(strings.TrimSpace(search)))
+}
+
+// sortedIDs - padanan tabel relasi: ID <= 0 dan duplikat dibuang, urut
+func sortedIDs(ids []int64) []int64 {
+	out := []int64{}
+	for _, id := range ids {
+		if id > 0 && !slices.Contains(out, id) {
+			out = append(out, id)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// paginate - Limit 0 berarti tanpa batas
+func paginate[T any](items []T, limit, offset int) []T {
+	if limit <= 0 {
+		return items
+	}
+	if offset >= len(items) {
+		return []T{}
+	}
+	end := offset + limit
+	if end > len(items) {
+		end = len(items)
+	}
+	return items[offset:end]
+}
@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"time"
+)
+
+// resetToken - password_reset_tokens
+type resetToken struct {
+	repository.NewResetToken
+	Used bool
+}
+
+type passwordRepo struct {
+	s *store
+}
+
+func (r *passwordRepo) Recent(ctx context.Context, userID int64, limit int) ([]string, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	hashes := []string{}
+	if u, ok := r.s.users[userID]; ok {
+		hashes = append(hashes, u.Password)
+	}
+	history := r.s.history[userID]
+	for i := len(history) - 1; i >= 0 && len(hashes) <= limit; i-- {
+		hashes = append(hashes, history[i])
+	}
+	return hashes, nil
+}
+
+// record - caller memegang lock
+func (r *passwordRepo) record(userID int64, hash string, keep int) {
+	history := append(r.s.history[userID], hash)
+	if len(history) > keep {
+		history = history[len(history)-keep:]
+	}
+	r.s.history[userID] = history
+}
+
+func (r *passwordRepo) Record(ctx context.Context, userID int64, hash string, keep int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.record(userID, hash, keep)
+	return nil
+}
+
+func (r *passwordRepo) Set(ctx context.Context, userID int64, hash string, mustChange bool, keep int) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	u, ok := r.s.users[userID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	now := r.s.stamp()
+	u.Password = hash
+	u.MustChangePassword = mustChange
+	u.PasswordChangedAt = &now
+	r.s.users[userID] = u
+	r.record(userID, hash, keep)
+	return nil
+}
+
+func (r *passwordRepo) ForceReset(ctx context.Context, t repository.NewResetToken, lockedHash string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	u, ok := r.s.users[t.UserID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if _, ok := r.s.resets[t.Hash]; ok {
+		return repository.ErrDuplicate
+	}
+	for hash, old := range r.s.resets {
+		if old.UserID == t.UserID && !old.Used {
+			delete(r.s.resets, hash)
+		}
+	}
+	r.s.resets[t.Hash] = resetToken{NewResetToken: t}
+	u.Password = lockedHash
+	u.MustChangePassword = true
+	r.s.users[t.UserID] = u
+	return nil
+}
+
+func (r *passwordRepo) ResetTokenUser(ctx context.Context, hash string, now time.Time) (models.User, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	t, ok := r.s.resets[hash]
+	if !ok || t.Used || !t.ExpiresAt.After(now) {
+		return models.User{}, repository.ErrNotFound
+	}
+	u, ok := r.s.users[t.UserID]
+	if !ok {
+		return models.User{}, repository.ErrNotFound
+	}
+	return models.User{ID: u.ID, Email: u.Email}, nil
+}
+
+func (r *passwordRepo) UseResetToken(ctx context.Context, hash string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	t, ok := r.s.resets[hash]
+	if !ok || t.Used {
+		return repository.ErrNotFound
+	}
+	t.Used = true
+	r.s.resets[hash] = t
+	return nil
+}
@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/repository"
+	"context"
+	"sort"
+	"time"
+)
+
+type reportRepo struct {
+	s *store
+}
+
+// tickets - tiket dalam rentang laporan; caller memegang lock
+func (r *reportRepo) tickets(f repository.ReportFilter) []ticket {
+	var list []ticket
+	for _, t := range r.s.tickets {
+		date := t.CreatedAt.Format(time.DateOnly)
+		if date < f.From || date > f.To || (f.UnitID > 0 && t.UnitID != f.UnitID) {
+			continue
+		}
+		list = append(list, t)
+	}
+	return list
+}
+
+func (r *reportRepo) Summary(ctx context.Context, f repository.ReportFilter) (repository.ReportSummary, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	units, services := map[int64]bool{}, map[int64]bool{}
+	var s repository.ReportSummary
+	for _, t := range r.tickets(f) {
+		s.Visitors++
+		units[t.UnitID] = true
+		services[t.ServiceID] = true
+	}
+	s.Units, s.Services = len(units), len(services)
+	return s, nil
+}
+
+func (r *reportRepo) Daily(ctx context.Context, f repository.ReportFilter) ([]repository.DayCount, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	totals := map[string]int{}
+	for _, t := range r.tickets(f) {
+		totals[t.CreatedAt.Format(time.DateOnly)]++
+	}
+	days := []repository.DayCount{}
+	for date, total := range totals {
+		days = append(days, repository.DayCount{Date: date, Total: total})
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Date < days[j].Date })
+	return days, nil
+}
+
+func (r *reportRepo) ByUnit(ctx context.Context, f repository.ReportFilter) ([]repository.NameCount, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	totals := map[int64]int{}
+	for _, t := range r.tickets(f) {
+		totals[t.UnitID]++
+	}
+	return nameCounts(totals, func(id int64) string { return r.s.units[id].NamaUnit }), nil
+}
+
+func (r *reportRepo) ByService(ctx context.Context, f repository.ReportFilter) ([]repository.NameCount, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	totals := map[int64]int{}
+	for _, t := range r.tickets(f) {
+		totals[t.ServiceID]++
+	}
+	return nameCounts(totals, func(id int64) string { return r.s.services[id].NamaService }), nil
+}
+
+// nameCounts - total per id jadi daftar nama, urut terbanyak
+func nameCounts(totals map[int64]int, name func(int64) string) []repository.NameCount {
+	counts := []repository.NameCount{}
+	for id, total := range totals {
+		counts = append(counts, repository.NameCount{Name: name(id), Total: total})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Total != counts[j].Total {
+			return counts[i].Total > counts[j].Total
+		}
+		return counts[i].Name < counts[j].Name
+	})
+	return counts
+}
+
+func (r *reportRepo) CountBetween(ctx context.Context, unitID, serviceID int64, from, to time.Time) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	// Bandingkan jam dinding seperti kolom DATETIME
+	lo, hi := from.Format(clock.SQLLayout), to.Format(clock.SQLLayout)
+	count := 0
+	for _, t := range r.s.tickets {
+		at := t.CreatedAt.Format(clock.SQLLayout)
+		if at < lo || at > hi {
+			continue
+		}
+		if (unitID > 0 && t.UnitID != unitID) || (serviceID > 0 && t.ServiceID != serviceID) {
+			continue
+		}
+		count++
+	}
+	return count, nil
+}
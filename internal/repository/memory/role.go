@@ -0,0 +1,129 @@
+package memory
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"slices"
+	"sort"
+)
+
+type roleRepo struct {
+	s *store
+}
+
+// withCount - role beserta salinan permission dan jumlah user; caller memegang lock
+func (r *roleRepo) withCount(role models.Role) models.Role {
+	role.Permissions = slices.Clone(role.Permissions)
+	role.UserCount = 0
+	for _, u := range r.s.users {
+		if u.Role == role.Name {
+			role.UserCount++
+		}
+	}
+	return role
+}
+
+// uniqueSorted - padanan PRIMARY KEY (role, permission) + ORDER BY permission
+func uniqueSorted(perms []string) []string {
+	out := slices.Clone(perms)
+	if out == nil {
+		out = []string{}
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+func (r *roleRepo) List(ctx context.Context) ([]models.Role, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	roles := []models.Role{}
+	for _, role := range r.s.roles {
+		roles = append(roles, r.withCount(role))
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		if roles[i].IsSystem != roles[j].IsSystem {
+			return roles[i].IsSystem == "y"
+		}
+		return roles[i].Name < roles[j].Name
+	})
+	return roles, nil
+}
+
+func (r *roleRepo) Get(ctx context.Context, name string) (models.Role, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	role, ok := r.s.roles[name]
+	if !ok {
+		return models.Role{}, repository.ErrNotFound
+	}
+	return r.withCount(role), nil
+}
+
+func (r *roleRepo) Create(ctx context.Context, role models.Role) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.roles[role.Name]; ok {
+		return repository.ErrDuplicate
+	}
+	if role.Description != nil && *role.Description == "" {
+		role.Description = nil
+	}
+	role.IsSystem = "n"
+	role.Permissions = uniqueSorted(role.Permissions)
+	role.CreatedAt = r.s.stamp()
+	role.UpdatedAt = role.CreatedAt
+	r.s.roles[role.Name] = role
+	return nil
+}
+
+func (r *roleRepo) Update(ctx context.Context, name string, p repository.RolePatch) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	role, ok := r.s.roles[name]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if p.Label != "" {
+		role.Label = p.Label
+	}
+	if p.Description != nil {
+		role.Description = nil
+		if *p.Description != "" {
+			desc := *p.Description
+			role.Description = &desc
+		}
+	}
+	if p.UnitScoped != "" {
+		role.UnitScoped = p.UnitScoped
+	}
+	if p.Require2FA != "" {
+		role.Require2FA = p.Require2FA
+	}
+	if p.Permissions != nil {
+		role.Permissions = uniqueSorted(*p.Permissions)
+	}
+	role.UpdatedAt = r.s.stamp()
+	r.s.roles[name] = role
+	return nil
+}
+
+func (r *roleRepo) Delete(ctx context.Context, name string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.roles[name]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.s.roles, name)
+	return nil
+}
+
+func (r *roleRepo) Permissions(ctx context.Context) (map[string][]string, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	out := map[string][]string{}
+	for name, role := range r.s.roles {
+		out[name] = slices.Clone(role.Permissions)
+	}
+	return out, nil
+}
@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"sort"
+)
+
+type scheduleRepo struct {
+	s *store
+}
+
+func (r *scheduleRepo) ListByUnit(ctx context.Context, unitID int64) ([]models.UnitSchedule, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	schedules := []models.UnitSchedule{}
+	for _, s := range r.s.schedules {
+		if s.UnitID == unitID {
+			schedules = append(schedules, s)
+		}
+	}
+	sort.Slice(schedules, func(i, j int) bool {
+		return schedules[i].DayOfWeek < schedules[j].DayOfWeek
+	})
+	return schedules, nil
+}
+
+func (r *scheduleRepo) ForDay(ctx context.Context, unitID int64, dayOfWeek int) (models.UnitSchedule, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, s := range r.s.schedules {
+		if s.UnitID == unitID && s.DayOfWeek == dayOfWeek {
+			return s, nil
+		}
+	}
+	return models.UnitSchedule{}, repository.ErrNotFound
+}
+
+func (r *scheduleRepo) Get(ctx context.Context, id int64) (models.UnitSchedule, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	s, ok := r.s.schedules[id]
+	if !ok {
+		return models.UnitSchedule{}, repository.ErrNotFound
+	}
+	return s, nil
+}
+
+// Upsert - padanan UNIQUE(unit_id, day_of_week) + ON DUPLICATE KEY UPDATE
+func (r *scheduleRepo) Upsert(ctx context.Context, unitID int64, items []models.UnitSchedule) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	now := r.s.stamp()
+
+	for _, item := range items {
+		var existing *models.UnitSchedule
+		for _, s := range r.s.schedules {
+			if s.UnitID == unitID && s.DayOfWeek == item.DayOfWeek {
+				s := s
+				existing = &s
+				break
+			}
+		}
+
+		if existing == nil {
+			item.ID = r.s.nextID("unit_schedules")
+			item.UnitID = unitID
+			item.DayName = models.DayName[item.DayOfWeek]
+			item.CreatedAt = now
+			item.UpdatedAt = now
+			r.s.schedules[item.ID] = item
+			continue
+		}
+
+		existing.JamBuka = item.JamBuka
+		existing.JamTutup = item.JamTutup
+		existing.IsActive = item.IsActive
+		existing.UpdatedAt = now
+		r.s.schedules[existing.ID] = *existing
+	}
+	return nil
+}
+
+func (r *scheduleRepo) Delete(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.schedules[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.s.schedules, id)
+	return nil
+}
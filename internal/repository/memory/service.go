@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"sort"
+)
+
+type serviceRepo struct {
+	s *store
+}
+
+// withLoket - isi Loket dari nama unit; false jika unit tidak ada (INNER JOIN)
+func (r *serviceRepo) withLoket(svc models.Service) (models.Service, bool) {
+	u, ok := r.s.units[svc.UnitID]
+	if !ok {
+		return svc, false
+	}
+	svc.Loket = u.NamaUnit
+	return svc, true
+}
+
+func (r *serviceRepo) filter(f repository.ServiceFilter) []models.Service {
+	services := []models.Service{}
+	for _, svc := range r.s.services {
+		if f.UnitID != 0 && svc.UnitID != f.UnitID {
+			continue
+		}
+		if f.IsActive != "" && svc.IsActive != f.IsActive {
+			continue
+		}
+		if f.Search != "" && !like(svc.Code, f.Search) && !like(svc.NamaService, f.Search) {
+			continue
+		}
+		svc, ok := r.withLoket(svc)
+		if !ok {
+			continue
+		}
+		services = append(services, svc)
+	}
+	sort.Slice(services, func(i, j int) bool {
+		a, b := services[i], services[j]
+		if f.OldestFirst {
+			a, b = b, a
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	})
+	return services
+}
+
+func (r *serviceRepo) List(ctx context.Context, f repository.ServiceFilter) ([]models.Service, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return paginate(r.filter(f), f.Limit, f.Offset), nil
+}
+
+func (r *serviceRepo) Count(ctx context.Context, f repository.ServiceFilter) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return len(r.filter(f)), nil
+}
+
+func (r *serviceRepo) Get(ctx context.Context, id int64) (models.Service, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	svc, ok := r.s.services[id]
+	if !ok {
+		return models.Service{}, repository.ErrNotFound
+	}
+	if svc, ok = r.withLoket(svc); !ok {
+		return models.Service{}, repository.ErrNotFound
+	}
+	return svc, nil
+}
+
+func (r *serviceRepo) CodeExists(ctx context.Context, code string, excludeID int64) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, svc := range r.s.services {
+		if svc.ID != excludeID && svc.Code == code {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *serviceRepo) Create(ctx context.Context, svc models.Service) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, existing := range r.s.services {
+		if existing.Code == svc.Code {
+			return 0, repository.ErrDuplicate
+		}
+	}
+	svc.ID = r.s.nextID("services")
+	svc.Loket = ""
+	svc.CreatedAt = r.s.stamp()
+	svc.UpdatedAt = svc.CreatedAt
+	r.s.services[svc.ID] = svc
+	return svc.ID, nil
+}
+
+func (r *serviceRepo) Update(ctx context.Context, id int64, p repository.ServicePatch) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	svc, ok := r.s.services[id]
+	if !ok {
+		return nil
+	}
+	if p.Code != "" {
+		svc.Code = p.Code
+	}
+	if p.NamaService != "" {
+		svc.NamaService = p.NamaService
+	}
+	if p.LimitsQueue != nil {
+		svc.LimitsQueue = *p.LimitsQueue
+	}
+	if p.IsActive != "" {
+		svc.IsActive = p.IsActive
+	}
+	svc.UpdatedAt = r.s.stamp()
+	r.s.services[id] = svc
+	return nil
+}
+
+func (r *serviceRepo) Delete(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.services[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.s.services, id)
+	return nil
+}
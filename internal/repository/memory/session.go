@@ -0,0 +1,194 @@
+package memory
+
+import (
+	"backend-antrian/internal/repository"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// session - user_sessions
+type session struct {
+	ID            string
+	UserID        int64
+	ActiveUnitID  sql.NullInt64
+	IPAddress     string
+	UserAgent     string
+	ExpiresAt     time.Time
+	LastUsedAt    time.Time
+	RevokedAt     *time.Time
+	RevokedReason string
+}
+
+// refreshToken - refresh_tokens
+type refreshToken struct {
+	ID        int64
+	SessionID string
+	Hash      string
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+type sessionRepo struct {
+	s *store
+}
+
+// deleteSession - hapus sesi beserta refresh token-nya (ON DELETE CASCADE);
+// caller memegang lock
+func (s *store) deleteSession(id string) {
+	delete(s.sessions, id)
+	for tid, t := range s.refreshes {
+		if t.SessionID == id {
+			delete(s.refreshes, tid)
+		}
+	}
+}
+
+// addRefreshToken - caller memegang lock
+func (r *sessionRepo) addRefreshToken(sessionID, hash string, expiresAt time.Time) error {
+	for _, t := range r.s.refreshes {
+		if t.Hash == hash {
+			return repository.ErrDuplicate
+		}
+	}
+	id := r.s.nextID("refresh_tokens")
+	r.s.refreshes[id] = refreshToken{ID: id, SessionID: sessionID, Hash: hash, ExpiresAt: expiresAt}
+	return nil
+}
+
+func (r *sessionRepo) Create(ctx context.Context, ns repository.NewSession) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.sessions[ns.ID]; ok {
+		return repository.ErrDuplicate
+	}
+	if err := r.addRefreshToken(ns.ID, ns.RefreshHash, ns.ExpiresAt); err != nil {
+		return err
+	}
+	r.s.sessions[ns.ID] = session{
+		ID:           ns.ID,
+		UserID:       ns.UserID,
+		ActiveUnitID: ns.ActiveUnitID,
+		IPAddress:    ns.IPAddress,
+		UserAgent:    ns.UserAgent,
+		ExpiresAt:    ns.ExpiresAt,
+		LastUsedAt:   r.s.stamp(),
+	}
+	return nil
+}
+
+func (r *sessionRepo) RefreshToken(ctx context.Context, hash string) (repository.RefreshToken, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, t := range r.s.refreshes {
+		if t.Hash != hash {
+			continue
+		}
+		sess, ok := r.s.sessions[t.SessionID]
+		if !ok {
+			break
+		}
+		return repository.RefreshToken{
+			ID:             t.ID,
+			SessionID:      t.SessionID,
+			UserID:         sess.UserID,
+			ActiveUnitID:   sess.ActiveUnitID,
+			ExpiresAt:      t.ExpiresAt,
+			Used:           t.UsedAt != nil,
+			SessionRevoked: sess.RevokedAt != nil,
+		}, nil
+	}
+	return repository.RefreshToken{}, repository.ErrNotFound
+}
+
+func (r *sessionRepo) Rotate(ctx context.Context, tokenID int64, sessionID, newHash string, expiresAt time.Time, activeUnit sql.NullInt64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	t, ok := r.s.refreshes[tokenID]
+	if !ok || t.UsedAt != nil {
+		return repository.ErrNotFound
+	}
+	if err := r.addRefreshToken(sessionID, newHash, expiresAt); err != nil {
+		return err
+	}
+	now := r.s.stamp()
+	t.UsedAt = &now
+	r.s.refreshes[tokenID] = t
+
+	if sess, ok := r.s.sessions[sessionID]; ok {
+		sess.LastUsedAt = now
+		sess.ExpiresAt = expiresAt
+		sess.ActiveUnitID = activeUnit
+		r.s.sessions[sessionID] = sess
+	}
+	return nil
+}
+
+func (r *sessionRepo) SetActiveUnit(ctx context.Context, sessionID string, unitID int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if sess, ok := r.s.sessions[sessionID]; ok {
+		sess.ActiveUnitID = sql.NullInt64{Int64: unitID, Valid: true}
+		sess.LastUsedAt = r.s.stamp()
+		r.s.sessions[sessionID] = sess
+	}
+	return nil
+}
+
+// revoke - akhiri sesi yang cocok dengan match; caller memegang lock
+func (r *sessionRepo) revoke(reason string, match func(session) bool) int64 {
+	var n int64
+	now := r.s.stamp()
+	for id, sess := range r.s.sessions {
+		if sess.RevokedAt != nil || !match(sess) {
+			continue
+		}
+		sess.RevokedAt = &now
+		sess.RevokedReason = reason
+		r.s.sessions[id] = sess
+		n++
+	}
+	return n
+}
+
+func (r *sessionRepo) Revoke(ctx context.Context, sessionID, reason string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.revoke(reason, func(sess session) bool { return sess.ID == sessionID })
+	return nil
+}
+
+func (r *sessionRepo) RevokeUser(ctx context.Context, userID int64, reason string) (int64, error) {
+	return r.RevokeOthers(ctx, userID, "", reason)
+}
+
+func (r *sessionRepo) RevokeOthers(ctx context.Context, userID int64, keepID, reason string) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.revoke(reason, func(sess session) bool { return sess.UserID == userID && sess.ID != keepID }), nil
+}
+
+func (r *sessionRepo) Denylist(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.denylist[jti]; !ok {
+		r.s.denylist[jti] = expiresAt
+	}
+	return nil
+}
+
+func (r *sessionRepo) Cleanup(ctx context.Context, now, keepSince time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for jti, exp := range r.s.denylist {
+		if exp.Before(now) {
+			delete(r.s.denylist, jti)
+		}
+	}
+	for id, sess := range r.s.sessions {
+		if sess.ExpiresAt.Before(keepSince) {
+			r.s.deleteSession(id)
+		}
+	}
+	return nil
+}
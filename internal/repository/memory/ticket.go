@@ -0,0 +1,334 @@
+package memory
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"sort"
+	"time"
+)
+
+type ticketRepo struct {
+	s *store
+}
+
+// onDay - padanan created_at >= start AND created_at < end
+func onDay(t time.Time, day clock.Day) bool {
+	return !t.Before(day.Start) && t.Before(day.End)
+}
+
+func (r *ticketRepo) CountToday(ctx context.Context, unitID, serviceID int64, today clock.Day) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	count := 0
+	for _, t := range r.s.tickets {
+		if t.UnitID == unitID && t.ServiceID == serviceID && onDay(t.CreatedAt, today) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *ticketRepo) Create(ctx context.Context, nt repository.NewTicket) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	now := r.s.stamp()
+	t := ticket{
+		QueueTicket: models.QueueTicket{
+			ID:         r.s.nextID("queue_tickets"),
+			TicketCode: nt.TicketCode,
+			UnitID:     nt.UnitID,
+			ServiceID:  nt.ServiceID,
+			UserID:     nt.UserID,
+			Status:     "waiting",
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		},
+		KioskID: nt.KioskID,
+	}
+	r.s.tickets[t.ID] = t
+	r.addTransaction(t.ID, "take", nt.UserID)
+	return t.ID, nil
+}
+
+func (r *ticketRepo) Get(ctx context.Context, id int64) (models.QueueTicket, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	t, ok := r.s.tickets[id]
+	if !ok {
+		return models.QueueTicket{}, repository.ErrNotFound
+	}
+	return t.QueueTicket, nil
+}
+
+func (r *ticketRepo) NextWaiting(ctx context.Context, serviceID int64, today clock.Day) (models.QueueTicket, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.nextWaiting(serviceID, today)
+}
+
+// nextWaiting - caller memegang lock
+func (r *ticketRepo) nextWaiting(serviceID int64, today clock.Day) (models.QueueTicket, error) {
+	var next *models.QueueTicket
+	for _, t := range r.s.tickets {
+		if t.ServiceID != serviceID || t.Status != "waiting" || !onDay(t.CreatedAt, today) {
+			continue
+		}
+		if next == nil || t.CreatedAt.Before(next.CreatedAt) ||
+			(t.CreatedAt.Equal(next.CreatedAt) && t.ID < next.ID) {
+			qt := t.QueueTicket
+			next = &qt
+		}
+	}
+	if next == nil {
+		return models.QueueTicket{}, repository.ErrNotFound
+	}
+	return *next, nil
+}
+
+func (r *ticketRepo) CurrentCalled(ctx context.Context, serviceID int64) (models.QueueTicket, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.currentCalled(serviceID)
+}
+
+// currentCalled - caller memegang lock
+func (r *ticketRepo) currentCalled(serviceID int64) (models.QueueTicket, error) {
+	var current *models.QueueTicket
+	for _, t := range r.s.tickets {
+		if t.ServiceID == serviceID && t.Status == "called" && (current == nil || t.ID < current.ID) {
+			qt := t.QueueTicket
+			current = &qt
+		}
+	}
+	if current == nil {
+		return models.QueueTicket{}, repository.ErrNotFound
+	}
+	return *current, nil
+}
+
+func (r *ticketRepo) SetStatus(ctx context.Context, id int64, status string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	t, ok := r.s.tickets[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	t.Status = status
+	t.UpdatedAt = r.s.stamp()
+	r.s.tickets[id] = t
+	return nil
+}
+
+func (r *ticketRepo) CallNext(ctx context.Context, serviceID int64, today clock.Day, userID int64, closeStatus, closeEvent string) (repository.CallNextResult, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	var res repository.CallNextResult
+	next, err := r.nextWaiting(serviceID, today)
+	if err != nil {
+		return res, err
+	}
+	if current, err := r.currentCalled(serviceID); err == nil {
+		t := r.s.tickets[current.ID]
+		t.Status = closeStatus
+		t.UpdatedAt = r.s.stamp()
+		r.s.tickets[t.ID] = t
+		r.addTransaction(t.ID, closeEvent, &userID)
+		res.ClosedID = t.ID
+	}
+	if err := r.call(next.ID, userID); err != nil {
+		return res, err
+	}
+	r.addTransaction(next.ID, "call", &userID)
+	res.Called = r.s.tickets[next.ID].QueueTicket
+	return res, nil
+}
+
+func (r *ticketRepo) Call(ctx context.Context, id, userID int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return r.call(id, userID)
+}
+
+// call - caller memegang lock
+func (r *ticketRepo) call(id, userID int64) error {
+	t, ok := r.s.tickets[id]
+	if !ok || t.Status != "waiting" {
+		return repository.ErrNotFound
+	}
+	now := r.s.stamp()
+	t.Status = "called"
+	t.LastCalledAt = &now
+	t.UserID = &userID
+	t.UpdatedAt = now
+	r.s.tickets[id] = t
+	return nil
+}
+
+func (r *ticketRepo) AddTransaction(ctx context.Context, ticketID int64, event string, actorUserID *int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.addTransaction(ticketID, event, actorUserID)
+	return nil
+}
+
+// addTransaction - caller memegang lock
+func (r *ticketRepo) addTransaction(ticketID int64, event string, actorUserID *int64) {
+	now := r.s.stamp()
+	r.s.txns = append(r.s.txns, models.QueueTransaction{
+		ID:          r.s.nextID("queue_transactions"),
+		TicketID:    ticketID,
+		Event:       event,
+		ActorUserID: actorUserID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	})
+}
+
+func (r *ticketRepo) StatsToday(ctx context.Context, unitID int64, today clock.Day) (map[int64]repository.ServiceDayStats, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	stats := map[int64]repository.ServiceDayStats{}
+	latest := map[int64]ticket{} // tiket called terakhir per layanan
+	for _, t := range r.s.tickets {
+		if t.UnitID != unitID || !onDay(t.CreatedAt, today) {
+			continue
+		}
+		st := stats[t.ServiceID]
+		st.Total++
+		switch t.Status {
+		case "waiting":
+			st.Waiting++
+		case "called", "done":
+			st.Served++
+		case "skipped":
+			st.Skipped++
+		}
+		stats[t.ServiceID] = st
+
+		if t.Status == "called" && t.LastCalledAt != nil {
+			cur, ok := latest[t.ServiceID]
+			if !ok || t.LastCalledAt.After(*cur.LastCalledAt) ||
+				(t.LastCalledAt.Equal(*cur.LastCalledAt) && t.ID > cur.ID) {
+				latest[t.ServiceID] = t
+			}
+		}
+	}
+	for serviceID, t := range latest {
+		st := stats[serviceID]
+		st.CurrentTicket = t.TicketCode
+		stats[serviceID] = st
+	}
+	return stats, nil
+}
+
+func (r *ticketRepo) DisplayRows(ctx context.Context, today repository.Today, serviceID int64) ([]repository.DisplayRow, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+
+	// Tiket hari ini per layanan: terakhir dipanggil & waiting tertua
+	lastCalled := map[int64]time.Time{}
+	oldestWaiting := map[int64]time.Time{}
+	for _, t := range r.s.tickets {
+		if !onDay(t.CreatedAt, today.For(t.UnitID)) {
+			continue
+		}
+		if t.LastCalledAt != nil {
+			if cur, ok := lastCalled[t.ServiceID]; !ok || t.LastCalledAt.After(cur) {
+				lastCalled[t.ServiceID] = *t.LastCalledAt
+			}
+		}
+		if t.Status == "waiting" {
+			if cur, ok := oldestWaiting[t.ServiceID]; !ok || t.CreatedAt.Before(cur) {
+				oldestWaiting[t.ServiceID] = t.CreatedAt
+			}
+		}
+	}
+
+	rows := []repository.DisplayRow{}
+	for _, svc := range r.s.services {
+		if svc.IsActive != "y" || (serviceID > 0 && svc.ID != serviceID) {
+			continue
+		}
+		u := r.s.units[svc.UnitID]
+		base := repository.DisplayRow{
+			ServiceID:   svc.ID,
+			ServiceName: svc.NamaService,
+			ServiceCode: svc.Code,
+			UnitID:      svc.UnitID,
+			UnitName:    u.NamaUnit,
+			MainDisplay: u.MainDisplay,
+			AudioFile:   u.AudioFile,
+			TicketCode:  "-",
+			Status:      "waiting",
+		}
+
+		matched := false
+		for _, t := range r.s.tickets {
+			if t.ServiceID != svc.ID {
+				continue
+			}
+			called, hasCalled := lastCalled[svc.ID]
+			waiting, hasWaiting := oldestWaiting[svc.ID]
+			// Tiket yang memenuhi kedua subquery muncul dua kali seperti UNION ALL
+			if hasCalled && t.LastCalledAt != nil && t.LastCalledAt.Equal(called) {
+				rows = append(rows, displayRow(base, t))
+				matched = true
+			}
+			if hasWaiting && t.Status == "waiting" && t.CreatedAt.Equal(waiting) {
+				rows = append(rows, displayRow(base, t))
+				matched = true
+			}
+		}
+		if !matched {
+			rows = append(rows, base)
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].UnitName != rows[j].UnitName {
+			return rows[i].UnitName < rows[j].UnitName
+		}
+		return rows[i].TicketID > rows[j].TicketID
+	})
+	return rows, nil
+}
+
+// displayRow - baris papan untuk tiket t
+func displayRow(d repository.DisplayRow, t ticket) repository.DisplayRow {
+	d.TicketID = t.ID
+	d.TicketCode = t.TicketCode
+	d.Status = t.Status
+	d.LastCalledAt = t.LastCalledAt
+	return d
+}
+
+func (r *ticketRepo) WaitingToday(ctx context.Context, today repository.Today, serviceID int64) (map[int64]int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	counts := map[int64]int{}
+	for _, t := range r.s.tickets {
+		if t.Status != "waiting" || (serviceID > 0 && t.ServiceID != serviceID) {
+			continue
+		}
+		if onDay(t.CreatedAt, today.For(t.UnitID)) {
+			counts[t.ServiceID]++
+		}
+	}
+	return counts, nil
+}
+
+func (r *ticketRepo) CreatedSince(ctx context.Context, since time.Time) (map[int64]int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	counts := map[int64]int{}
+	for _, t := range r.s.tickets {
+		if !t.CreatedAt.Before(since) {
+			counts[t.ServiceID]++
+		}
+	}
+	return counts, nil
+}
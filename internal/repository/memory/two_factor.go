@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"backend-antrian/internal/repository"
+	"context"
+	"time"
+)
+
+// recoveryCode - user_recovery_codes
+type recoveryCode struct {
+	Hash string
+	Used bool
+}
+
+type twoFactorRepo struct {
+	s *store
+}
+
+func (r *twoFactorRepo) Secret(ctx context.Context, userID int64) (repository.TOTPSecret, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	t, ok := r.s.totp[userID]
+	if !ok {
+		return repository.TOTPSecret{}, repository.ErrNotFound
+	}
+	return t, nil
+}
+
+func (r *twoFactorRepo) StartEnrollment(ctx context.Context, userID int64, secretEnc string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	r.s.totp[userID] = repository.TOTPSecret{SecretEnc: secretEnc}
+	return nil
+}
+
+func (r *twoFactorRepo) UseStep(ctx context.Context, userID, step int64) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	t, ok := r.s.totp[userID]
+	if !ok || t.LastStep >= step {
+		return false, nil
+	}
+	t.LastStep = step
+	r.s.totp[userID] = t
+	return true, nil
+}
+
+func (r *twoFactorRepo) Confirm(ctx context.Context, userID int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if t, ok := r.s.totp[userID]; ok {
+		t.Enabled = true
+		r.s.totp[userID] = t
+	}
+	return nil
+}
+
+func (r *twoFactorRepo) Delete(ctx context.Context, userID int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	delete(r.s.totp, userID)
+	delete(r.s.recovery, userID)
+	return nil
+}
+
+func (r *twoFactorRepo) ReplaceRecoveryCodes(ctx context.Context, userID int64, hashes []string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	codes := make([]recoveryCode, 0, len(hashes))
+	for _, hash := range hashes {
+		codes = append(codes, recoveryCode{Hash: hash})
+	}
+	r.s.recovery[userID] = codes
+	return nil
+}
+
+func (r *twoFactorRepo) UseRecoveryCode(ctx context.Context, userID int64, hash string) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for i, code := range r.s.recovery[userID] {
+		if code.Hash == hash && !code.Used {
+			r.s.recovery[userID][i].Used = true
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *twoFactorRepo) RecoveryCodesLeft(ctx context.Context, userID int64) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	n := 0
+	for _, code := range r.s.recovery[userID] {
+		if !code.Used {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *twoFactorRepo) CreateChallenge(ctx context.Context, ch repository.LoginChallenge) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.challenge[ch.Hash]; ok {
+		return repository.ErrDuplicate
+	}
+	ch.Attempts = 0
+	r.s.challenge[ch.Hash] = ch
+	return nil
+}
+
+func (r *twoFactorRepo) Challenge(ctx context.Context, hash string, now time.Time) (repository.LoginChallenge, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	ch, ok := r.s.challenge[hash]
+	if !ok || !ch.ExpiresAt.After(now) {
+		return repository.LoginChallenge{}, repository.ErrNotFound
+	}
+	return ch, nil
+}
+
+func (r *twoFactorRepo) FailChallenge(ctx context.Context, hash string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if ch, ok := r.s.challenge[hash]; ok {
+		ch.Attempts++
+		r.s.challenge[hash] = ch
+	}
+	return nil
+}
+
+func (r *twoFactorRepo) DeleteChallenge(ctx context.Context, hash string) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.challenge[hash]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.s.challenge, hash)
+	return nil
+}
+
+func (r *twoFactorRepo) CleanupChallenges(ctx context.Context, now time.Time) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for hash, ch := range r.s.challenge {
+		if ch.ExpiresAt.Before(now) {
+			delete(r.s.challenge, hash)
+		}
+	}
+	return nil
+}
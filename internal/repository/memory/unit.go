@@ -0,0 +1,134 @@
+package memory
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"sort"
+)
+
+type unitRepo struct {
+	s *store
+}
+
+func (r *unitRepo) filter(f repository.UnitFilter) []models.Unit {
+	units := []models.Unit{}
+	for _, u := range r.s.units {
+		if f.IsActive != "" && u.IsActive != f.IsActive {
+			continue
+		}
+		if f.Search != "" && !like(u.Code, f.Search) && !like(u.NamaUnit, f.Search) {
+			continue
+		}
+		units = append(units, u)
+	}
+	sort.Slice(units, func(i, j int) bool {
+		if units[i].NamaUnit != units[j].NamaUnit {
+			return units[i].NamaUnit < units[j].NamaUnit
+		}
+		return units[i].ID < units[j].ID
+	})
+	return units
+}
+
+func (r *unitRepo) List(ctx context.Context, f repository.UnitFilter) ([]models.Unit, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return paginate(r.filter(f), f.Limit, f.Offset), nil
+}
+
+func (r *unitRepo) Count(ctx context.Context, f repository.UnitFilter) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return len(r.filter(f)), nil
+}
+
+func (r *unitRepo) Get(ctx context.Context, id int64) (models.Unit, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	u, ok := r.s.units[id]
+	if !ok {
+		return models.Unit{}, repository.ErrNotFound
+	}
+	return u, nil
+}
+
+func (r *unitRepo) CodeExists(ctx context.Context, code string, excludeID int64) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, u := range r.s.units {
+		if u.ID != excludeID && u.Code == code {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *unitRepo) Create(ctx context.Context, u models.Unit) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, existing := range r.s.units {
+		if existing.Code == u.Code {
+			return 0, repository.ErrDuplicate
+		}
+	}
+	u.ID = r.s.nextID("units")
+	u.CreatedAt = r.s.stamp()
+	u.UpdatedAt = u.CreatedAt
+	r.s.units[u.ID] = u
+	return u.ID, nil
+}
+
+func (r *unitRepo) Update(ctx context.Context, id int64, p repository.UnitPatch) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	u, ok := r.s.units[id]
+	if !ok {
+		return nil
+	}
+	if p.Code != "" {
+		u.Code = p.Code
+	}
+	if p.NamaUnit != "" {
+		u.NamaUnit = p.NamaUnit
+	}
+	if p.IsActive != "" {
+		u.IsActive = p.IsActive
+	}
+	if p.MainDisplay != "" {
+		u.MainDisplay = p.MainDisplay
+	}
+	if p.AudioFile != nil {
+		u.AudioFile = nil
+		if *p.AudioFile != "" {
+			file := *p.AudioFile
+			u.AudioFile = &file
+		}
+	}
+	if p.Timezone != nil {
+		u.Timezone = nil
+		if *p.Timezone != "" {
+			tz := *p.Timezone
+			u.Timezone = &tz
+		}
+	}
+	u.UpdatedAt = r.s.stamp()
+	r.s.units[id] = u
+	return nil
+}
+
+// Delete - unit yang masih punya layanan dianggap terikat foreign key
+func (r *unitRepo) Delete(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.units[id]; !ok {
+		return repository.ErrNotFound
+	}
+	for _, svc := range r.s.services {
+		if svc.UnitID == id {
+			return repository.ErrInUse
+		}
+	}
+	delete(r.s.units, id)
+	return nil
+}
@@ -0,0 +1,235 @@
+package memory
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"slices"
+	"sort"
+)
+
+type userRepo struct {
+	s *store
+}
+
+// withUnit - padanan LEFT JOIN units
+func (r *userRepo) withUnit(u user) repository.UserWithUnit {
+	out := repository.UserWithUnit{User: u.User}
+	out.Password = ""
+	if u.UnitID.Valid {
+		out.UnitName = r.s.units[u.UnitID.Int64].NamaUnit
+	}
+	return out
+}
+
+func (r *userRepo) filter(f repository.UserFilter) []repository.UserWithUnit {
+	users := []repository.UserWithUnit{}
+	for _, u := range r.s.users {
+		if f.IsBanned != "" && u.IsBanned != f.IsBanned {
+			continue
+		}
+		if f.Search != "" && !like(u.Email, f.Search) && !like(u.Nama, f.Search) {
+			continue
+		}
+		users = append(users, r.withUnit(u))
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if !users[i].CreatedAt.Equal(users[j].CreatedAt) {
+			return users[i].CreatedAt.After(users[j].CreatedAt)
+		}
+		return users[i].ID > users[j].ID
+	})
+	return users
+}
+
+func (r *userRepo) List(ctx context.Context, f repository.UserFilter) ([]repository.UserWithUnit, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return paginate(r.filter(f), f.Limit, f.Offset), nil
+}
+
+func (r *userRepo) Count(ctx context.Context, f repository.UserFilter) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return len(r.filter(f)), nil
+}
+
+func (r *userRepo) Get(ctx context.Context, id int64) (repository.UserWithUnit, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	u, ok := r.s.users[id]
+	if !ok {
+		return repository.UserWithUnit{}, repository.ErrNotFound
+	}
+	return r.withUnit(u), nil
+}
+
+func (r *userRepo) EmailExists(ctx context.Context, email string, excludeID int64) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, u := range r.s.users {
+		if u.ID != excludeID && u.Email == email {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *userRepo) CountByRole(ctx context.Context, role string) (int, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	count := 0
+	for _, u := range r.s.users {
+		if u.Role == role {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// credentials - caller memegang lock
+func credentials(u user) repository.UserCredentials {
+	return repository.UserCredentials{User: u.User, MustChangePassword: u.MustChangePassword, PasswordChangedAt: u.PasswordChangedAt}
+}
+
+func (r *userRepo) GetByEmail(ctx context.Context, email string) (repository.UserCredentials, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, u := range r.s.users {
+		if u.Email == email {
+			return credentials(u), nil
+		}
+	}
+	return repository.UserCredentials{}, repository.ErrNotFound
+}
+
+func (r *userRepo) Credentials(ctx context.Context, id int64) (repository.UserCredentials, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	u, ok := r.s.users[id]
+	if !ok {
+		return repository.UserCredentials{}, repository.ErrNotFound
+	}
+	return credentials(u), nil
+}
+
+func (r *userRepo) UnitIDs(ctx context.Context, userID int64) ([]int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return append([]int64{}, r.s.userUnits[userID]...), nil
+}
+
+func (r *userRepo) IsUnitMember(ctx context.Context, userID, unitID int64) (bool, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	return slices.Contains(r.s.userUnits[userID], unitID), nil
+}
+
+func (r *userRepo) Units(ctx context.Context, userID int64) ([]models.Unit, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	units := []models.Unit{}
+	for _, id := range r.s.userUnits[userID] {
+		if u, ok := r.s.units[id]; ok {
+			units = append(units, u)
+		}
+	}
+	sort.Slice(units, func(i, j int) bool { return units[i].NamaUnit < units[j].NamaUnit })
+	return units, nil
+}
+
+// setUnits - padanan DELETE + INSERT user_units; caller memegang lock
+func (r *userRepo) setUnits(userID int64, unitIDs []int64) {
+	ids := sortedIDs(unitIDs)
+	if len(ids) == 0 {
+		delete(r.s.userUnits, userID)
+		return
+	}
+	r.s.userUnits[userID] = ids
+}
+
+func (r *userRepo) CreateWithUnits(ctx context.Context, mu models.User, unitIDs []int64) (int64, error) {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	for _, existing := range r.s.users {
+		if existing.Email == mu.Email {
+			return 0, repository.ErrDuplicate
+		}
+	}
+	mu.ID = r.s.nextID("users")
+	mu.CreatedAt = r.s.stamp()
+	mu.UpdatedAt = mu.CreatedAt
+	r.s.users[mu.ID] = user{User: mu, MustChangePassword: true, PasswordChangedAt: &mu.CreatedAt}
+	r.setUnits(mu.ID, unitIDs)
+	return mu.ID, nil
+}
+
+func (r *userRepo) UpdateWithUnits(ctx context.Context, id int64, p repository.UserPatch, unitIDs []int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	u, ok := r.s.users[id]
+	if !ok {
+		return nil
+	}
+	if p.Email != "" {
+		for _, existing := range r.s.users {
+			if existing.ID != id && existing.Email == p.Email {
+				return repository.ErrDuplicate
+			}
+		}
+		u.Email = p.Email
+	}
+	if p.Nama != "" {
+		u.Nama = p.Nama
+	}
+	if p.PasswordHash != "" {
+		now := r.s.stamp()
+		u.Password = p.PasswordHash
+		u.MustChangePassword = true
+		u.PasswordChangedAt = &now
+	}
+	if p.Role != "" {
+		u.Role = p.Role
+	}
+	if p.IsBanned != "" {
+		u.IsBanned = p.IsBanned
+	}
+	if p.UnitID != nil {
+		u.UnitID = *p.UnitID
+	}
+	u.UpdatedAt = r.s.stamp()
+	r.s.users[id] = u
+	if unitIDs != nil {
+		r.setUnits(id, unitIDs)
+	}
+	return nil
+}
+
+func (r *userRepo) HardDelete(ctx context.Context, id int64) error {
+	r.s.mu.Lock()
+	defer r.s.mu.Unlock()
+	if _, ok := r.s.users[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.s.users, id)
+	delete(r.s.userUnits, id)
+	for sid, sess := range r.s.sessions {
+		if sess.UserID == id {
+			r.s.deleteSession(sid)
+		}
+	}
+	delete(r.s.totp, id)
+	delete(r.s.recovery, id)
+	delete(r.s.history, id)
+	for hash, ch := range r.s.challenge {
+		if ch.UserID == id {
+			delete(r.s.challenge, hash)
+		}
+	}
+	for hash, t := range r.s.resets {
+		if t.UserID == id {
+			delete(r.s.resets, hash)
+		}
+	}
+	return nil
+}
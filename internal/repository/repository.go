@@ -0,0 +1,724 @@
+// Package repository memisahkan akses data dari handler HTTP.
+// Handler hanya mengenal interface di sini; implementasi MySQL ada di
+// sqlrepo, implementasi in-memory (untuk test) ada di memory.
+package repository
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrNotFound data yang dicari / dihapus tidak ada
+	ErrNotFound = errors.New("data tidak ditemukan")
+	// ErrInUse data masih direferensikan data lain (foreign key)
+	ErrInUse = errors.New("data masih digunakan oleh data lain")
+	// ErrDuplicate melanggar unique key
+	ErrDuplicate = errors.New("data sudah ada")
+)
+
+// Repos - container semua repository yang dipakai handler
+type Repos struct {
+	Units     UnitRepo
+	Services  ServiceRepo
+	Tickets   TicketRepo
+	Users     UserRepo
+	Schedules ScheduleRepo
+	FAQs      FAQRepo
+	Audios    AudioRepo
+	Configs   ConfigRepo
+	Reports   ReportRepo
+	Sessions  SessionRepo
+	Roles     RoleRepo
+	Passwords PasswordRepo
+	TwoFactor TwoFactorRepo
+	Displays  DisplayRepo
+	Kiosks    KioskRepo
+}
+
+/*
+|--------------------------------------------------------------------------
+| Units
+|--------------------------------------------------------------------------
+*/
+
+// UnitFilter - filter list unit; Limit 0 berarti tanpa batas
+type UnitFilter struct {
+	IsActive string
+	Search   string // code / nama_unit
+	Limit    int
+	Offset   int
+}
+
+// UnitPatch - field kosong tidak diubah; AudioFile / Timezone "" di-set NULL
+type UnitPatch struct {
+	Code        string
+	NamaUnit    string
+	IsActive    string
+	MainDisplay string
+	AudioFile   *string
+	Timezone    *string
+}
+
+type UnitRepo interface {
+	List(ctx context.Context, f UnitFilter) ([]models.Unit, error)
+	Count(ctx context.Context, f UnitFilter) (int, error)
+	Get(ctx context.Context, id int64) (models.Unit, error)
+	CodeExists(ctx context.Context, code string, excludeID int64) (bool, error)
+	Create(ctx context.Context, u models.Unit) (int64, error)
+	Update(ctx context.Context, id int64, p UnitPatch) error
+	// Delete mengembalikan ErrInUse jika unit masih dipakai data lain
+	Delete(ctx context.Context, id int64) error
+}
+
+/*
+|--------------------------------------------------------------------------
+| Services
+|--------------------------------------------------------------------------
+*/
+
+// ServiceFilter - UnitID 0 berarti semua unit; default urut terbaru dulu
+type ServiceFilter struct {
+	UnitID      int64
+	IsActive    string
+	Search      string // code / nama_service
+	OldestFirst bool
+	Limit       int
+	Offset      int
+}
+
+// ServicePatch - field kosong / nil tidak diubah
+type ServicePatch struct {
+	NamaService string
+	Code        string
+	LimitsQueue *int
+	IsActive    string
+}
+
+// ServiceRepo - Service.Loket diisi nama unit pemilik
+type ServiceRepo interface {
+	List(ctx context.Context, f ServiceFilter) ([]models.Service, error)
+	Count(ctx context.Context, f ServiceFilter) (int, error)
+	Get(ctx context.Context, id int64) (models.Service, error)
+	CodeExists(ctx context.Context, code string, excludeID int64) (bool, error)
+	Create(ctx context.Context, s models.Service) (int64, error)
+	Update(ctx context.Context, id int64, p ServicePatch) error
+	Delete(ctx context.Context, id int64) error
+}
+
+/*
+|--------------------------------------------------------------------------
+| Queue tickets
+|--------------------------------------------------------------------------
+*/
+
+// NewTicket - tiket baru; pengambilnya user (UserID) atau kiosk (KioskID)
+type NewTicket struct {
+	TicketCode string
+	UnitID     int64
+	ServiceID  int64
+	UserID     *int64
+	KioskID    *int64
+}
+
+// CallNextResult - hasil TicketRepo.CallNext
+type CallNextResult struct {
+	// Called tiket yang baru dipanggil
+	Called models.QueueTicket
+	// ClosedID tiket called sebelumnya yang ditutup, 0 jika tidak ada
+	ClosedID int64
+}
+
+// ServiceDayStats - rekap tiket satu layanan pada satu hari
+type ServiceDayStats struct {
+	Total   int
+	Waiting int
+	Served  int // called + done
+	Skipped int
+	// CurrentTicket kode tiket called yang terakhir dipanggil, "" jika tidak ada
+	CurrentTicket string
+}
+
+// Today - batas "hari ini" untuk query lintas unit: unit dengan zona
+// sendiri memakai Units, sisanya Default
+type Today struct {
+	Default clock.Day
+	Units   map[int64]clock.Day
+}
+
+// For batas hari ini untuk satu unit
+func (t Today) For(unitID int64) clock.Day {
+	if d, ok := t.Units[unitID]; ok {
+		return d
+	}
+	return t.Default
+}
+
+// DisplayRow - satu baris papan antrian: layanan aktif beserta tiket terakhir
+// dipanggil dan tiket waiting tertua hari ini. Layanan tanpa tiket muncul
+// sekali dengan TicketID 0, TicketCode "-" dan Status "waiting".
+type DisplayRow struct {
+	ServiceID    int64
+	ServiceName  string
+	ServiceCode  string
+	UnitID       int64
+	UnitName     string
+	MainDisplay  string
+	AudioFile    *string
+	TicketID     int64
+	TicketCode   string
+	Status       string
+	LastCalledAt *time.Time
+}
+
+type TicketRepo interface {
+	// CountToday jumlah tiket pada hari today (lihat clock.Today) untuk
+	// satu layanan di satu unit
+	CountToday(ctx context.Context, unitID, serviceID int64, today clock.Day) (int, error)
+	// Create simpan tiket berstatus waiting beserta transaksi 'take'
+	Create(ctx context.Context, t NewTicket) (int64, error)
+	Get(ctx context.Context, id int64) (models.QueueTicket, error)
+	// NextWaiting tiket waiting paling awal pada hari today
+	NextWaiting(ctx context.Context, serviceID int64, today clock.Day) (models.QueueTicket, error)
+	// CurrentCalled tiket yang sedang dipanggil di layanan tsb
+	CurrentCalled(ctx context.Context, serviceID int64) (models.QueueTicket, error)
+	SetStatus(ctx context.Context, id int64, status string) error
+	// CallNext dalam satu transaksi: tutup tiket called layanan tsb dengan
+	// status/event closeStatus/closeEvent (jika ada) lalu panggil tiket waiting
+	// paling awal hari today. Call-next serentak untuk layanan yang sama
+	// dijalankan berurutan. ErrNotFound jika tidak ada tiket waiting — tiket
+	// called tidak ditutup.
+	CallNext(ctx context.Context, serviceID int64, today clock.Day, userID int64, closeStatus, closeEvent string) (CallNextResult, error)
+	// Call tandai tiket waiting jadi called oleh petugas userID.
+	// ErrNotFound jika tiket sudah tidak waiting (keduluan petugas lain).
+	Call(ctx context.Context, id, userID int64) error
+	AddTransaction(ctx context.Context, ticketID int64, event string, actorUserID *int64) error
+	// StatsToday rekap tiket hari today per layanan (service_id) di satu unit;
+	// layanan tanpa tiket tidak ada di map
+	StatsToday(ctx context.Context, unitID int64, today clock.Day) (map[int64]ServiceDayStats, error)
+	// DisplayRows isi papan antrian, urut nama unit lalu tiket terbaru;
+	// serviceID > 0 membatasi ke satu layanan
+	DisplayRows(ctx context.Context, today Today, serviceID int64) ([]DisplayRow, error)
+	// WaitingToday jumlah tiket waiting hari ini per layanan (service_id);
+	// serviceID > 0 membatasi ke satu layanan, layanan tanpa antrian tidak ada di map
+	WaitingToday(ctx context.Context, today Today, serviceID int64) (map[int64]int, error)
+	// CreatedSince jumlah tiket per layanan yang dibuat sejak since
+	CreatedSince(ctx context.Context, since time.Time) (map[int64]int, error)
+}
+
+/*
+|--------------------------------------------------------------------------
+| Users
+|--------------------------------------------------------------------------
+*/
+
+// UserWithUnit - user beserta nama unit default-nya ("" jika tanpa unit)
+type UserWithUnit struct {
+	models.User
+	UnitName string
+}
+
+// UserFilter - Search mencocokkan email / nama
+type UserFilter struct {
+	IsBanned string
+	Search   string
+	Limit    int
+	Offset   int
+}
+
+// UserPatch - field kosong / nil tidak diubah.
+// PasswordHash diisi admin: user wajib mengganti password setelah login.
+type UserPatch struct {
+	Nama         string
+	Email        string
+	PasswordHash string
+	Role         string
+	IsBanned     string
+	UnitID       *sql.NullInt64
+}
+
+// UserCredentials - user beserta hash password (models.User.Password) dan
+// status password-nya
+type UserCredentials struct {
+	models.User
+	MustChangePassword bool
+	PasswordChangedAt  *time.Time
+}
+
+type UserRepo interface {
+	List(ctx context.Context, f UserFilter) ([]UserWithUnit, error)
+	Count(ctx context.Context, f UserFilter) (int, error)
+	Get(ctx context.Context, id int64) (UserWithUnit, error)
+	// GetByEmail user untuk login; ErrNotFound jika email tidak terdaftar
+	GetByEmail(ctx context.Context, email string) (UserCredentials, error)
+	Credentials(ctx context.Context, id int64) (UserCredentials, error)
+	EmailExists(ctx context.Context, email string, excludeID int64) (bool, error)
+	CountByRole(ctx context.Context, role string) (int, error)
+	// UnitIDs keanggotaan unit user (user_units), urut unit_id
+	UnitIDs(ctx context.Context, userID int64) ([]int64, error)
+	IsUnitMember(ctx context.Context, userID, unitID int64) (bool, error)
+	// Units unit anggota user, urut nama_unit
+	Units(ctx context.Context, userID int64) ([]models.Unit, error)
+	// CreateWithUnits user dari admin (Password berisi hash) beserta
+	// keanggotaan unitnya dalam satu transaksi; wajib ganti password saat login
+	CreateWithUnits(ctx context.Context, u models.User, unitIDs []int64) (int64, error)
+	// UpdateWithUnits ubah user dan ganti seluruh keanggotaan unitnya dalam
+	// satu transaksi. unitIDs nil berarti keanggotaan tidak diubah.
+	UpdateWithUnits(ctx context.Context, id int64, p UserPatch, unitIDs []int64) error
+	// HardDelete hapus user beserta sesi, keanggotaan unit, 2FA, riwayat
+	// password & token reset-nya dalam satu transaksi
+	HardDelete(ctx context.Context, id int64) error
+}
+
+/*
+|--------------------------------------------------------------------------
+| Passwords
+|--------------------------------------------------------------------------
+*/
+
+// NewResetToken - token reset password sekali pakai dari admin (hash)
+type NewResetToken struct {
+	Hash      string
+	UserID    int64
+	CreatedBy *int64
+	ExpiresAt time.Time
+}
+
+type PasswordRepo interface {
+	// Recent hash password saat ini diikuti maksimal limit hash riwayat terbaru
+	Recent(ctx context.Context, userID int64, limit int) ([]string, error)
+	// Record simpan hash ke riwayat dan buang riwayat di luar keep terbaru
+	Record(ctx context.Context, userID int64, hash string, keep int) error
+	// Set ganti password user lalu Record dalam satu transaksi
+	Set(ctx context.Context, userID int64, hash string, mustChange bool, keep int) error
+	// ForceReset dalam satu transaksi: batalkan token reset user yang belum
+	// dipakai, simpan token t, dan kunci password dengan lockedHash
+	ForceReset(ctx context.Context, t NewResetToken, lockedHash string) error
+	// ResetTokenUser pemilik token yang belum dipakai dan belum kedaluwarsa
+	// pada now (ID & Email); ErrNotFound jika tidak ada
+	ResetTokenUser(ctx context.Context, hash string, now time.Time) (models.User, error)
+	// UseResetToken tandai token terpakai; ErrNotFound jika sudah dipakai
+	UseResetToken(ctx context.Context, hash string) error
+}
+
+/*
+|--------------------------------------------------------------------------
+| Two-factor (TOTP)
+|--------------------------------------------------------------------------
+*/
+
+// TOTPSecret - secret TOTP user (terenkripsi) beserta status enrolment
+type TOTPSecret struct {
+	SecretEnc string
+	Enabled   bool
+	LastStep  int64
+}
+
+// LoginChallenge - token langkah kedua login (hash) beserta unit aktif pilihan
+type LoginChallenge struct {
+	Hash      string
+	UserID    int64
+	UnitID    sql.NullInt64
+	IPAddress string
+	ExpiresAt time.Time
+	Attempts  int
+}
+
+type TwoFactorRepo interface {
+	// Secret ErrNotFound jika user belum pernah memulai enrolment
+	Secret(ctx context.Context, userID int64) (TOTPSecret, error)
+	// StartEnrollment simpan (atau ganti) secret yang belum dikonfirmasi
+	StartEnrollment(ctx context.Context, userID int64, secretEnc string) error
+	// UseStep catat time step yang dipakai; false jika step tidak lebih baru
+	// dari last_step (kode yang sama dipakai ulang / request paralel)
+	UseStep(ctx context.Context, userID, step int64) (bool, error)
+	// Confirm aktifkan secret hasil enrolment
+	Confirm(ctx context.Context, userID int64) error
+	// Delete hapus secret beserta kode pemulihan user
+	Delete(ctx context.Context, userID int64) error
+	// ReplaceRecoveryCodes ganti semua kode pemulihan (hash) dalam satu transaksi
+	ReplaceRecoveryCodes(ctx context.Context, userID int64, hashes []string) error
+	// UseRecoveryCode pakai satu kode; false jika tidak cocok atau sudah dipakai
+	UseRecoveryCode(ctx context.Context, userID int64, hash string) (bool, error)
+	RecoveryCodesLeft(ctx context.Context, userID int64) (int, error)
+	CreateChallenge(ctx context.Context, ch LoginChallenge) error
+	// Challenge yang belum kedaluwarsa pada now; ErrNotFound jika tidak ada
+	Challenge(ctx context.Context, hash string, now time.Time) (LoginChallenge, error)
+	// FailChallenge tambah hitungan percobaan gagal
+	FailChallenge(ctx context.Context, hash string) error
+	// DeleteChallenge ErrNotFound jika sudah dihapus (dipakai request paralel)
+	DeleteChallenge(ctx context.Context, hash string) error
+	// CleanupChallenges hapus challenge yang kedaluwarsa pada now
+	CleanupChallenges(ctx context.Context, now time.Time) error
+}
+
+/*
+|--------------------------------------------------------------------------
+| Roles & permissions
+|--------------------------------------------------------------------------
+*/
+
+// RolePatch - field kosong / nil tidak diubah; Description "" di-set NULL.
+// Permissions non-nil mengganti seluruh permission role.
+type RolePatch struct {
+	Label       string
+	Description *string
+	UnitScoped  string
+	Require2FA  string
+	Permissions *[]string
+}
+
+// RoleRepo - models.Role selalu berisi Permissions (urut) dan UserCount
+type RoleRepo interface {
+	// List semua role, role sistem dulu lalu urut nama
+	List(ctx context.Context) ([]models.Role, error)
+	Get(ctx context.Context, name string) (models.Role, error)
+	// Create role non-sistem beserta permission-nya dalam satu transaksi;
+	// ErrDuplicate jika nama sudah dipakai
+	Create(ctx context.Context, r models.Role) error
+	Update(ctx context.Context, name string, p RolePatch) error
+	// Delete hapus role beserta permission-nya
+	Delete(ctx context.Context, name string) error
+	// Permissions mapping role -> permission untuk cache permission
+	Permissions(ctx context.Context) (map[string][]string, error)
+}
+
+/*
+|--------------------------------------------------------------------------
+| Sessions & refresh tokens
+|--------------------------------------------------------------------------
+*/
+
+// NewSession - sesi login beserta hash refresh token pertamanya
+type NewSession struct {
+	ID           string
+	UserID       int64
+	ActiveUnitID sql.NullInt64
+	IPAddress    string
+	UserAgent    string
+	ExpiresAt    time.Time
+	RefreshHash  string
+}
+
+// RefreshToken - refresh token beserta sesi pemiliknya
+type RefreshToken struct {
+	ID             int64
+	SessionID      string
+	UserID         int64
+	ActiveUnitID   sql.NullInt64
+	ExpiresAt      time.Time
+	Used           bool
+	SessionRevoked bool
+}
+
+type SessionRepo interface {
+	// Create simpan sesi beserta refresh token pertamanya dalam satu transaksi
+	Create(ctx context.Context, s NewSession) error
+	// RefreshToken cari refresh token dari hash-nya; ErrNotFound jika tidak ada
+	RefreshToken(ctx context.Context, hash string) (RefreshToken, error)
+	// Rotate dalam satu transaksi: tandai token tokenID terpakai, simpan token
+	// baru newHash, lalu perpanjang sesi sampai expiresAt dengan unit aktif
+	// activeUnit. ErrNotFound jika token sudah terpakai (request paralel).
+	Rotate(ctx context.Context, tokenID int64, sessionID, newHash string, expiresAt time.Time, activeUnit sql.NullInt64) error
+	SetActiveUnit(ctx context.Context, sessionID string, unitID int64) error
+	// Revoke akhiri satu sesi; sesi yang sudah berakhir tidak diubah
+	Revoke(ctx context.Context, sessionID, reason string) error
+	// RevokeUser akhiri semua sesi aktif user, kembalikan jumlahnya
+	RevokeUser(ctx context.Context, userID int64, reason string) (int64, error)
+	// RevokeOthers seperti RevokeUser kecuali sesi keepID
+	RevokeOthers(ctx context.Context, userID int64, keepID, reason string) (int64, error)
+	// Denylist tolak jti access token sampai expiresAt
+	Denylist(ctx context.Context, jti string, userID int64, expiresAt time.Time) error
+	// Cleanup hapus denylist yang sudah kedaluwarsa pada now dan sesi yang
+	// kedaluwarsa sebelum keepSince
+	Cleanup(ctx context.Context, now, keepSince time.Time) error
+}
+
+/*
+|--------------------------------------------------------------------------
+| Displays
+|--------------------------------------------------------------------------
+*/
+
+// DisplayPatch - field kosong tidak diubah; UnitIDs / ServiceIDs non-nil
+// mengganti scope display (keduanya ditulis ulang bersama)
+type DisplayPatch struct {
+	Nama       string
+	Theme      string
+	PlaysAudio string
+	IsActive   string
+	UnitIDs    *[]int64
+	ServiceIDs *[]int64
+}
+
+// DisplayToken - kredensial display hasil pairing; TokenHash "" jika belum
+// dipasangkan
+type DisplayToken struct {
+	ID        int64
+	TokenHash string
+	IsActive  string
+}
+
+// DisplayConnection - koneksi WebSocket display untuk display_status
+type DisplayConnection struct {
+	DisplayID  int64
+	ClientID   string
+	IPAddress  string
+	AppVersion string
+}
+
+// DisplayHealth - status koneksi terakhir display; SecondsSince detik sejak
+// last_seen_at sampai now (0 jika belum pernah terhubung). Status dan
+// LiveConnections diisi handler.
+type DisplayHealth struct {
+	models.DisplayHealth
+	SecondsSince int64
+}
+
+// NewDisplayCommand - perintah remote berstatus 'sent'; Params JSON
+type NewDisplayCommand struct {
+	DisplayID int64
+	Command   string
+	Params    string
+	IssuedBy  *int64
+}
+
+// DisplayRepo - models.Display selalu berisi UnitIDs & ServiceIDs (urut)
+type DisplayRepo interface {
+	// List semua display urut nama
+	List(ctx context.Context) ([]models.Display, error)
+	Get(ctx context.Context, id int64) (models.Display, error)
+	// Create display beserta pairing code dan scope-nya dalam satu transaksi
+	Create(ctx context.Context, d models.Display) (int64, error)
+	Update(ctx context.Context, id int64, p DisplayPatch) error
+	// ResetPairing pasang pairing code baru dan cabut device & token lama
+	ResetPairing(ctx context.Context, id int64, code string, expiresAt time.Time) error
+	Delete(ctx context.Context, id int64) error
+	// ByPairingCode ErrNotFound jika kode tidak terdaftar
+	ByPairingCode(ctx context.Context, code string) (models.Display, error)
+	DeviceIDExists(ctx context.Context, deviceID string, excludeID int64) (bool, error)
+	// Pair simpan device & hash token lalu hapus pairing code
+	Pair(ctx context.Context, id int64, deviceID, tokenHash string) error
+	// Token kredensial display dari device_id; ErrNotFound jika tidak terdaftar
+	Token(ctx context.Context, deviceID string) (DisplayToken, error)
+
+	// Connected catat awal koneksi; menimpa status koneksi sebelumnya
+	Connected(ctx context.Context, c DisplayConnection) error
+	Heartbeat(ctx context.Context, displayID int64, clientID string) error
+	// Disconnected hanya mengubah status jika clientID masih koneksi terakhir
+	Disconnected(ctx context.Context, displayID int64, clientID string) error
+	SetAppVersion(ctx context.Context, displayID int64, clientID, version string) error
+	// Health status koneksi semua display urut nama
+	Health(ctx context.Context, now time.Time) ([]DisplayHealth, error)
+	// Online display masih terhubung dengan heartbeat terakhir sejak since
+	Online(ctx context.Context, displayID int64, since time.Time) (bool, error)
+
+	CreateCommand(ctx context.Context, c NewDisplayCommand) (int64, error)
+	Command(ctx context.Context, id int64) (models.DisplayCommand, error)
+	// Commands riwayat perintah display, terbaru dulu
+	Commands(ctx context.Context, displayID int64, limit int) ([]models.DisplayCommand, error)
+	// AckCommand ubah perintah 'sent' milik displayID jadi status (acked /
+	// failed) oleh clientID; false jika tidak ada yang cocok
+	AckCommand(ctx context.Context, id, displayID int64, clientID, status string, errMsg *string) (bool, error)
+	// ExpireCommands tandai perintah 'sent' yang dibuat sebelum before jadi expired
+	ExpireCommands(ctx context.Context, before time.Time) error
+}
+
+/*
+|--------------------------------------------------------------------------
+| Kiosks
+|--------------------------------------------------------------------------
+*/
+
+// KioskKey - public key PEM beserta fingerprint SHA-256; Valid false berarti
+// kiosk tanpa public key
+type KioskKey struct {
+	PublicKey   sql.NullString
+	Fingerprint sql.NullString
+}
+
+// NewKiosk - kiosk baru; SecretHash hash secret perangkat, token yang
+// terbit sebelum ValidAfter ditolak
+type NewKiosk struct {
+	Nama       string
+	DeviceID   string
+	SecretHash string
+	Key        KioskKey
+	IsActive   string
+	UnitIDs    []int64
+	ValidAfter time.Time
+}
+
+// KioskPatch - field kosong / nil tidak diubah. Key non-nil mengganti public
+// key sekaligus me-reset assertion terakhir; UnitIDs non-nil mengganti unit.
+type KioskPatch struct {
+	Nama     string
+	IsActive string
+	Key      *KioskKey
+	UnitIDs  *[]int64
+}
+
+// KioskCredentials - kiosk beserta kredensialnya untuk autentikasi
+type KioskCredentials struct {
+	models.Kiosk
+	SecretHash string
+	PublicKey  string
+}
+
+// KioskRepo - models.Kiosk selalu berisi UnitIDs (urut)
+type KioskRepo interface {
+	// List semua kiosk urut nama
+	List(ctx context.Context) ([]models.Kiosk, error)
+	Get(ctx context.Context, id int64) (models.Kiosk, error)
+	// Credentials kiosk dari device_id; ErrNotFound jika tidak terdaftar
+	Credentials(ctx context.Context, deviceID string) (KioskCredentials, error)
+	// Create kiosk beserta unitnya dalam satu transaksi
+	Create(ctx context.Context, k NewKiosk) (int64, error)
+	Update(ctx context.Context, id int64, p KioskPatch) error
+	// RotateSecret ganti hash secret; token yang terbit sebelum validAfter
+	// tidak berlaku
+	RotateSecret(ctx context.Context, id int64, secretHash string, validAfter time.Time) error
+	Delete(ctx context.Context, id int64) error
+	// Units unit yang boleh dilayani kiosk, urut nama_unit
+	Units(ctx context.Context, kioskID int64) ([]models.Unit, error)
+	AllowsUnit(ctx context.Context, kioskID, unitID int64) (bool, error)
+	// RecordAuth catat waktu & IP login terakhir
+	RecordAuth(ctx context.Context, id int64, ip string) error
+	// UseAssertion catat timestamp tanda tangan perangkat; false jika tidak
+	// lebih baru dari assertion sebelumnya (replay)
+	UseAssertion(ctx context.Context, id int64, timestamp int64) (bool, error)
+}
+
+/*
+|--------------------------------------------------------------------------
+| Unit schedules
+|--------------------------------------------------------------------------
+*/
+
+type ScheduleRepo interface {
+	ListByUnit(ctx context.Context, unitID int64) ([]models.UnitSchedule, error)
+	// ForDay jadwal unit pada hari tsb (0=Minggu..6=Sabtu)
+	ForDay(ctx context.Context, unitID int64, dayOfWeek int) (models.UnitSchedule, error)
+	Get(ctx context.Context, id int64) (models.UnitSchedule, error)
+	// Upsert simpan jadwal per hari (insert atau update berdasarkan unit + hari)
+	Upsert(ctx context.Context, unitID int64, items []models.UnitSchedule) error
+	Delete(ctx context.Context, id int64) error
+}
+
+/*
+|--------------------------------------------------------------------------
+| FAQ
+|--------------------------------------------------------------------------
+*/
+
+// FAQFilter - Search mencocokkan question / answer
+type FAQFilter struct {
+	IsActive string
+	Search   string
+	Limit    int
+	Offset   int
+}
+
+// FAQPatch - field kosong / nil tidak diubah
+type FAQPatch struct {
+	Question  string
+	Answer    string
+	IsActive  string
+	SortOrder *int
+}
+
+// FAQRepo - list selalu urut sort_order lalu created_at
+type FAQRepo interface {
+	List(ctx context.Context, f FAQFilter) ([]models.FAQ, error)
+	Count(ctx context.Context, f FAQFilter) (int, error)
+	Get(ctx context.Context, id int64) (models.FAQ, error)
+	Create(ctx context.Context, f models.FAQ) (int64, error)
+	Update(ctx context.Context, id int64, p FAQPatch) error
+	Delete(ctx context.Context, id int64) error
+}
+
+/*
+|--------------------------------------------------------------------------
+| TTS audio cache
+|--------------------------------------------------------------------------
+*/
+
+type AudioRepo interface {
+	List(ctx context.Context) ([]models.Audio, error)
+	Get(ctx context.Context, id int64) (models.Audio, error)
+	NameExists(ctx context.Context, namaAudio string, excludeID int64) (bool, error)
+	Create(ctx context.Context, a models.Audio) (int64, error)
+	// CreateMany insert semua audio dalam satu transaksi; satu gagal = tidak ada yang tersimpan
+	CreateMany(ctx context.Context, audios []models.Audio) ([]int64, error)
+	UpdateText(ctx context.Context, id int64, ttsText string) error
+	// Rename ganti nama & path audio sekaligus units.audio_file yang memakainya
+	// (satu transaksi); kembalikan jumlah unit yang ikut berubah
+	Rename(ctx context.Context, id int64, namaAudio, pathAudio string) (int64, error)
+	Delete(ctx context.Context, id int64) error
+	// Usage audio beserta unit yang memakainya (units.audio_file), urut
+	// nama audio lalu nama unit; audioID > 0 untuk satu audio saja
+	Usage(ctx context.Context, audioID int64) ([]models.AudioUsage, error)
+}
+
+/*
+|--------------------------------------------------------------------------
+| Config
+|--------------------------------------------------------------------------
+*/
+
+// ConfigRepo - hanya ada satu baris konfigurasi
+type ConfigRepo interface {
+	// Get konfigurasi; ErrNotFound jika belum dibuat
+	Get(ctx context.Context) (models.Config, error)
+	Create(ctx context.Context, textMarque string) (int64, error)
+	Update(ctx context.Context, id int64, textMarque string) error
+}
+
+/*
+|--------------------------------------------------------------------------
+| Reports
+|--------------------------------------------------------------------------
+*/
+
+// ReportFilter - rentang tanggal DATE(created_at) inklusif (YYYY-MM-DD);
+// UnitID 0 berarti semua unit
+type ReportFilter struct {
+	UnitID int64
+	From   string
+	To     string
+}
+
+// ReportSummary - rekap tiket dalam rentang laporan
+type ReportSummary struct {
+	Visitors int
+	Units    int // unit berbeda yang punya tiket
+	Services int // layanan berbeda yang punya tiket
+}
+
+// DayCount - jumlah tiket per tanggal (YYYY-MM-DD)
+type DayCount struct {
+	Date  string
+	Total int
+}
+
+// NameCount - jumlah tiket per unit / layanan
+type NameCount struct {
+	Name  string
+	Total int
+}
+
+type ReportRepo interface {
+	Summary(ctx context.Context, f ReportFilter) (ReportSummary, error)
+	// Daily jumlah tiket per tanggal, urut tanggal; tanggal tanpa tiket tidak ada
+	Daily(ctx context.Context, f ReportFilter) ([]DayCount, error)
+	// ByUnit / ByService jumlah tiket per unit / layanan, urut terbanyak
+	ByUnit(ctx context.Context, f ReportFilter) ([]NameCount, error)
+	ByService(ctx context.Context, f ReportFilter) ([]NameCount, error)
+	// CountBetween jumlah tiket dengan from <= created_at <= to (jam dinding,
+	// zona time.Time diabaikan) untuk satu unit dan/atau layanan; 0 = semua
+	CountBetween(ctx context.Context, unitID, serviceID int64, from, to time.Time) (int, error)
+}
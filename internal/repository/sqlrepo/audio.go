@@ -0,0 +1,188 @@
+package sqlrepo
+
+import (
+	"backend-antrian/internal/models"
+	"context"
+	"database/sql"
+)
+
+const audioColumns = "id, tts_text, nama_audio, path_audio, created_at, updated_at"
+
+type audioRepo struct {
+	db *sql.DB
+}
+
+func scanAudio(s scanner) (models.Audio, error) {
+	var a models.Audio
+	err := s.Scan(&a.ID, &a.TTSText, &a.NamaAudio, &a.PathAudio, &a.CreatedAt, &a.UpdatedAt)
+	return a, err
+}
+
+func (r *audioRepo) List(ctx context.Context) ([]models.Audio, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+audioColumns+" FROM tts_audio_cache ORDER BY created_at DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	audios := []models.Audio{}
+	for rows.Next() {
+		a, err := scanAudio(rows)
+		if err != nil {
+			return nil, err
+		}
+		audios = append(audios, a)
+	}
+	return audios, rows.Err()
+}
+
+func (r *audioRepo) Get(ctx context.Context, id int64) (models.Audio, error) {
+	a, err := scanAudio(r.db.QueryRowContext(ctx, "SELECT "+audioColumns+" FROM tts_audio_cache WHERE id = ?", id))
+	return a, mapErr(err)
+}
+
+func (r *audioRepo) NameExists(ctx context.Context, namaAudio string, excludeID int64) (bool, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM tts_audio_cache WHERE nama_audio = ? AND id != ?",
+		namaAudio, excludeID,
+	).Scan(&count)
+	return count > 0, err
+}
+
+func (r *audioRepo) Create(ctx context.Context, a models.Audio) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		"INSERT INTO tts_audio_cache (tts_text, nama_audio, path_audio) VALUES (?, ?, ?)",
+		a.TTSText, a.NamaAudio, a.PathAudio,
+	)
+	if err != nil {
+		return 0, mapErr(err)
+	}
+	return res.LastInsertId()
+}
+
+func (r *audioRepo) CreateMany(ctx context.Context, audios []models.Audio) ([]int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	ids := make([]int64, 0, len(audios))
+	for _, a := range audios {
+		res, err := tx.ExecContext(ctx,
+			"INSERT INTO tts_audio_cache (tts_text, nama_audio, path_audio) VALUES (?, ?, ?)",
+			a.TTSText, a.NamaAudio, a.PathAudio,
+		)
+		if err != nil {
+			return nil, mapErr(err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, tx.Commit()
+}
+
+func (r *audioRepo) UpdateText(ctx context.Context, id int64, ttsText string) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE tts_audio_cache SET tts_text = ?, updated_at = NOW() WHERE id = ?",
+		ttsText, id,
+	)
+	return mapErr(err)
+}
+
+func (r *audioRepo) Rename(ctx context.Context, id int64, namaAudio, pathAudio string) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var oldName string
+	if err := tx.QueryRowContext(ctx, "SELECT nama_audio FROM tts_audio_cache WHERE id = ?", id).Scan(&oldName); err != nil {
+		return 0, mapErr(err)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE tts_audio_cache SET nama_audio = ?, path_audio = ?, updated_at = NOW() WHERE id = ?",
+		namaAudio, pathAudio, id,
+	)
+	if err != nil {
+		return 0, mapErr(err)
+	}
+
+	res, err := tx.ExecContext(ctx,
+		"UPDATE units SET audio_file = ?, updated_at = NOW() WHERE audio_file = ?",
+		namaAudio, oldName,
+	)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return n, tx.Commit()
+}
+
+func (r *audioRepo) Delete(ctx context.Context, id int64) error {
+	return affectedOne(r.db.ExecContext(ctx, "DELETE FROM tts_audio_cache WHERE id = ?", id))
+}
+
+func (r *audioRepo) Usage(ctx context.Context, audioID int64) ([]models.AudioUsage, error) {
+	query := `
+		SELECT
+			a.id, a.tts_text, a.nama_audio, a.path_audio, a.created_at, a.updated_at,
+			u.id, u.code, u.nama_unit, u.is_active
+		FROM tts_audio_cache a
+		LEFT JOIN units u ON u.audio_file = a.nama_audio
+	`
+	args := []interface{}{}
+	if audioID > 0 {
+		query += " WHERE a.id = ?"
+		args = append(args, audioID)
+	}
+	query += " ORDER BY a.nama_audio ASC, u.nama_unit ASC"
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	usages := []models.AudioUsage{}
+	index := map[int64]int{}
+	for rows.Next() {
+		var (
+			a                              models.Audio
+			unitID                         sql.NullInt64
+			unitCode, unitName, unitActive sql.NullString
+		)
+		if err := rows.Scan(
+			&a.ID, &a.TTSText, &a.NamaAudio, &a.PathAudio, &a.CreatedAt, &a.UpdatedAt,
+			&unitID, &unitCode, &unitName, &unitActive,
+		); err != nil {
+			return nil, err
+		}
+
+		i, ok := index[a.ID]
+		if !ok {
+			i = len(usages)
+			index[a.ID] = i
+			usages = append(usages, models.AudioUsage{Audio: a, Units: []models.AudioUnitRef{}})
+		}
+		if unitID.Valid {
+			usages[i].Units = append(usages[i].Units, models.AudioUnitRef{
+				ID:       unitID.Int64,
+				Code:     unitCode.String,
+				NamaUnit: unitName.String,
+				IsActive: unitActive.String,
+			})
+			usages[i].UsageCount++
+		}
+	}
+	return usages, rows.Err()
+}
@@ -0,0 +1,30 @@
+package sqlrepo
+
+import (
+	"backend-antrian/internal/models"
+	"context"
+	"database/sql"
+)
+
+type configRepo struct {
+	db *sql.DB
+}
+
+func (r *configRepo) Get(ctx context.Context) (models.Config, error) {
+	var cfg models.Config
+	err := r.db.QueryRowContext(ctx, "SELECT id, text_marque FROM configs ORDER BY id LIMIT 1").Scan(&cfg.ID, &cfg.TextMarque)
+	return cfg, mapErr(err)
+}
+
+func (r *configRepo) Create(ctx context.Context, textMarque string) (int64, error) {
+	res, err := r.db.ExecContext(ctx, "INSERT INTO configs (text_marque) VALUES (?)", textMarque)
+	if err != nil {
+		return 0, mapErr(err)
+	}
+	return res.LastInsertId()
+}
+
+func (r *configRepo) Update(ctx context.Context, id int64, textMarque string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE configs SET text_marque = ? WHERE id = ?", textMarque, id)
+	return mapErr(err)
+}
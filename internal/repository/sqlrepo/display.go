@@ -0,0 +1,427 @@
+package sqlrepo
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/dialect"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+const displaySelect = `
+	SELECT id, nama, device_id, pairing_code, pairing_expires_at, paired_at,
+	       theme, plays_audio, is_active, created_at, updated_at
+	FROM displays
+`
+
+type displayRepo struct {
+	db *sql.DB
+	d  dialect.Dialect
+}
+
+func scanDisplay(s scanner) (models.Display, error) {
+	var (
+		d           models.Display
+		deviceID    sql.NullString
+		pairingCode sql.NullString
+		pairingExp  sql.NullTime
+		pairedAt    sql.NullTime
+	)
+	err := s.Scan(
+		&d.ID, &d.Nama, &deviceID, &pairingCode, &pairingExp, &pairedAt,
+		&d.Theme, &d.PlaysAudio, &d.IsActive, &d.CreatedAt, &d.UpdatedAt,
+	)
+	if deviceID.Valid {
+		d.DeviceID = &deviceID.String
+	}
+	if pairingCode.Valid {
+		d.PairingCode = &pairingCode.String
+	}
+	if pairingExp.Valid {
+		d.PairingExpiresAt = &pairingExp.Time
+	}
+	if pairedAt.Valid {
+		d.PairedAt = &pairedAt.Time
+	}
+	return d, err
+}
+
+// withScope - isi UnitIDs & ServiceIDs display
+func (r *displayRepo) withScope(ctx context.Context, d models.Display) (models.Display, error) {
+	var err error
+	if d.UnitIDs, err = queryIDs(ctx, r.db, "SELECT unit_id FROM display_units WHERE display_id = ? ORDER BY unit_id", d.ID); err != nil {
+		return d, err
+	}
+	d.ServiceIDs, err = queryIDs(ctx, r.db, "SELECT service_id FROM display_services WHERE display_id = ? ORDER BY service_id", d.ID)
+	return d, err
+}
+
+func (r *displayRepo) List(ctx context.Context) ([]models.Display, error) {
+	rows, err := r.db.QueryContext(ctx, displaySelect+" ORDER BY nama ASC")
+	if err != nil {
+		return nil, err
+	}
+	displays := []models.Display{}
+	for rows.Next() {
+		d, err := scanDisplay(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		displays = append(displays, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range displays {
+		if displays[i], err = r.withScope(ctx, displays[i]); err != nil {
+			return nil, err
+		}
+	}
+	return displays, nil
+}
+
+func (r *displayRepo) Get(ctx context.Context, id int64) (models.Display, error) {
+	d, err := scanDisplay(r.db.QueryRowContext(ctx, displaySelect+" WHERE id = ?", id))
+	if err != nil {
+		return d, mapErr(err)
+	}
+	return r.withScope(ctx, d)
+}
+
+func (r *displayRepo) Create(ctx context.Context, d models.Display) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO displays (nama, pairing_code, pairing_expires_at, theme, plays_audio, is_active)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, d.Nama, d.PairingCode, d.PairingExpiresAt, d.Theme, d.PlaysAudio, d.IsActive)
+	if err != nil {
+		return 0, mapErr(err)
+	}
+	id, _ := res.LastInsertId()
+
+	if err := replaceDisplayScope(ctx, tx, id, d.UnitIDs, d.ServiceIDs); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+func replaceDisplayScope(ctx context.Context, db execer, displayID int64, unitIDs, serviceIDs []int64) error {
+	if err := replaceIDs(ctx, db, "display_units", "display_id", "unit_id", displayID, unitIDs); err != nil {
+		return err
+	}
+	return replaceIDs(ctx, db, "display_services", "display_id", "service_id", displayID, serviceIDs)
+}
+
+func (r *displayRepo) Update(ctx context.Context, id int64, p repository.DisplayPatch) error {
+	var set updateSet
+	if p.Nama != "" {
+		set.set("nama", p.Nama)
+	}
+	if p.Theme != "" {
+		set.set("theme", p.Theme)
+	}
+	if p.PlaysAudio != "" {
+		set.set("plays_audio", p.PlaysAudio)
+	}
+	if p.IsActive != "" {
+		set.set("is_active", p.IsActive)
+	}
+
+	// Scope ditulis ulang utuh: sisi yang tidak diubah diambil dari DB
+	var scope models.Display
+	if p.UnitIDs != nil || p.ServiceIDs != nil {
+		var err error
+		if scope, err = r.withScope(ctx, models.Display{ID: id}); err != nil {
+			return err
+		}
+		if p.UnitIDs != nil {
+			scope.UnitIDs = *p.UnitIDs
+		}
+		if p.ServiceIDs != nil {
+			scope.ServiceIDs = *p.ServiceIDs
+		}
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if !set.empty() {
+		query, args := set.query("displays", id)
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return mapErr(err)
+		}
+	}
+	if p.UnitIDs != nil || p.ServiceIDs != nil {
+		if err := replaceDisplayScope(ctx, tx, id, scope.UnitIDs, scope.ServiceIDs); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *displayRepo) ResetPairing(ctx context.Context, id int64, code string, expiresAt time.Time) error {
+	return affectedOne(r.db.ExecContext(ctx, `
+		UPDATE displays
+		SET pairing_code = ?, pairing_expires_at = ?, device_id = NULL, token_hash = NULL, paired_at = NULL
+		WHERE id = ?
+	`, code, expiresAt, id))
+}
+
+func (r *displayRepo) Delete(ctx context.Context, id int64) error {
+	return affectedOne(r.db.ExecContext(ctx, "DELETE FROM displays WHERE id = ?", id))
+}
+
+func (r *displayRepo) ByPairingCode(ctx context.Context, code string) (models.Display, error) {
+	d, err := scanDisplay(r.db.QueryRowContext(ctx, displaySelect+" WHERE pairing_code = ?", code))
+	if err != nil {
+		return d, mapErr(err)
+	}
+	return r.withScope(ctx, d)
+}
+
+func (r *displayRepo) DeviceIDExists(ctx context.Context, deviceID string, excludeID int64) (bool, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM displays WHERE device_id = ? AND id != ?", deviceID, excludeID,
+	).Scan(&count)
+	return count > 0, err
+}
+
+func (r *displayRepo) Pair(ctx context.Context, id int64, deviceID, tokenHash string) error {
+	return affectedOne(r.db.ExecContext(ctx, `
+		UPDATE displays
+		SET device_id = ?, token_hash = ?, paired_at = NOW(), pairing_code = NULL, pairing_expires_at = NULL
+		WHERE id = ?
+	`, deviceID, tokenHash, id))
+}
+
+func (r *displayRepo) Token(ctx context.Context, deviceID string) (repository.DisplayToken, error) {
+	var (
+		t    repository.DisplayToken
+		hash sql.NullString
+	)
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, token_hash, is_active FROM displays WHERE device_id = ?", deviceID,
+	).Scan(&t.ID, &hash, &t.IsActive)
+	t.TokenHash = hash.String
+	return t, mapErr(err)
+}
+
+func (r *displayRepo) Connected(ctx context.Context, c repository.DisplayConnection) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO display_status (display_id, client_id, connected_since, disconnected_at, last_seen_at, ip_address, app_version)
+		VALUES (?, ?, NOW(), NULL, NOW(), ?, NULLIF(?, ''))
+		`+r.d.OnConflict("display_id")+`
+			client_id = `+r.d.Inserted("client_id")+`,
+			connected_since = `+r.d.Inserted("connected_since")+`,
+			disconnected_at = NULL,
+			last_seen_at = `+r.d.Inserted("last_seen_at")+`,
+			ip_address = `+r.d.Inserted("ip_address")+`,
+			app_version = COALESCE(`+r.d.Inserted("app_version")+`, app_version)
+	`, c.DisplayID, c.ClientID, c.IPAddress, c.AppVersion)
+	return err
+}
+
+func (r *displayRepo) Heartbeat(ctx context.Context, displayID int64, clientID string) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE display_status SET last_seen_at = NOW() WHERE display_id = ? AND client_id = ?",
+		displayID, clientID,
+	)
+	return err
+}
+
+func (r *displayRepo) Disconnected(ctx context.Context, displayID int64, clientID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE display_status
+		SET disconnected_at = NOW(), last_seen_at = NOW()
+		WHERE display_id = ? AND client_id = ?
+	`, displayID, clientID)
+	return err
+}
+
+func (r *displayRepo) SetAppVersion(ctx context.Context, displayID int64, clientID, version string) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE display_status SET app_version = ? WHERE display_id = ? AND client_id = ?",
+		version, displayID, clientID,
+	)
+	return err
+}
+
+func (r *displayRepo) Health(ctx context.Context, now time.Time) ([]repository.DisplayHealth, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			d.id, d.nama, d.device_id, d.is_active,
+			ds.connected_since, ds.disconnected_at, ds.last_seen_at,
+			`+r.d.SecondsBetween("ds.last_seen_at", "?")+`,
+			ds.ip_address, ds.app_version
+		FROM displays d
+		LEFT JOIN display_status ds ON ds.display_id = d.id
+		ORDER BY d.nama ASC
+	`, clock.SQL(now))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []repository.DisplayHealth{}
+	for rows.Next() {
+		var (
+			h              repository.DisplayHealth
+			deviceID       sql.NullString
+			connectedSince sql.NullTime
+			disconnectedAt sql.NullTime
+			lastSeen       sql.NullTime
+			secondsSince   sql.NullInt64
+			ip             sql.NullString
+			appVersion     sql.NullString
+		)
+		if err := rows.Scan(
+			&h.ID, &h.Nama, &deviceID, &h.IsActive,
+			&connectedSince, &disconnectedAt, &lastSeen, &secondsSince,
+			&ip, &appVersion,
+		); err != nil {
+			return nil, err
+		}
+
+		if deviceID.Valid {
+			h.DeviceID = &deviceID.String
+		}
+		if connectedSince.Valid {
+			h.ConnectedSince = &connectedSince.Time
+		}
+		if disconnectedAt.Valid {
+			h.DisconnectedAt = &disconnectedAt.Time
+		}
+		if lastSeen.Valid {
+			h.LastSeenAt = &lastSeen.Time
+		}
+		if ip.Valid {
+			h.IPAddress = &ip.String
+		}
+		if appVersion.Valid {
+			h.AppVersion = &appVersion.String
+		}
+		h.SecondsSince = secondsSince.Int64
+		result = append(result, h)
+	}
+	return result, rows.Err()
+}
+
+func (r *displayRepo) Online(ctx context.Context, displayID int64, since time.Time) (bool, error) {
+	var live int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM display_status
+		WHERE display_id = ? AND disconnected_at IS NULL AND last_seen_at >= ?
+	`, displayID, clock.SQL(since)).Scan(&live)
+	return live > 0, err
+}
+
+func (r *displayRepo) CreateCommand(ctx context.Context, c repository.NewDisplayCommand) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		INSERT INTO display_commands (display_id, command, params, status, issued_by, created_at)
+		VALUES (?, ?, ?, 'sent', ?, NOW())
+	`, c.DisplayID, c.Command, c.Params, c.IssuedBy)
+	if err != nil {
+		return 0, mapErr(err)
+	}
+	return res.LastInsertId()
+}
+
+const displayCommandSelect = `
+	SELECT id, display_id, command, params, status, error, issued_by, acked_by, created_at, acked_at
+	FROM display_commands
+`
+
+func scanDisplayCommand(s scanner) (models.DisplayCommand, error) {
+	var (
+		cmd      models.DisplayCommand
+		params   sql.NullString
+		errMsg   sql.NullString
+		issuedBy sql.NullInt64
+		ackedBy  sql.NullString
+		ackedAt  sql.NullTime
+	)
+	err := s.Scan(
+		&cmd.ID, &cmd.DisplayID, &cmd.Command, &params, &cmd.Status,
+		&errMsg, &issuedBy, &ackedBy, &cmd.CreatedAt, &ackedAt,
+	)
+	if err != nil {
+		return cmd, err
+	}
+
+	cmd.Params = map[string]interface{}{}
+	if params.Valid {
+		json.Unmarshal([]byte(params.String), &cmd.Params)
+	}
+	if errMsg.Valid {
+		cmd.Error = &errMsg.String
+	}
+	if issuedBy.Valid {
+		cmd.IssuedBy = &issuedBy.Int64
+	}
+	if ackedBy.Valid {
+		cmd.AckedBy = &ackedBy.String
+	}
+	if ackedAt.Valid {
+		cmd.AckedAt = &ackedAt.Time
+	}
+	return cmd, nil
+}
+
+func (r *displayRepo) Command(ctx context.Context, id int64) (models.DisplayCommand, error) {
+	cmd, err := scanDisplayCommand(r.db.QueryRowContext(ctx, displayCommandSelect+" WHERE id = ?", id))
+	return cmd, mapErr(err)
+}
+
+func (r *displayRepo) Commands(ctx context.Context, displayID int64, limit int) ([]models.DisplayCommand, error) {
+	rows, err := r.db.QueryContext(ctx, displayCommandSelect+" WHERE display_id = ? ORDER BY id DESC LIMIT ?", displayID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	commands := []models.DisplayCommand{}
+	for rows.Next() {
+		cmd, err := scanDisplayCommand(rows)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+	}
+	return commands, rows.Err()
+}
+
+func (r *displayRepo) AckCommand(ctx context.Context, id, displayID int64, clientID, status string, errMsg *string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE display_commands
+		SET status = ?, error = ?, acked_by = ?, acked_at = NOW()
+		WHERE id = ? AND display_id = ? AND status = 'sent'
+	`, status, errMsg, clientID, id, displayID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+func (r *displayRepo) ExpireCommands(ctx context.Context, before time.Time) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE display_commands SET status = 'expired' WHERE status = 'sent' AND created_at < ?",
+		clock.SQL(before),
+	)
+	return err
+}
@@ -0,0 +1,110 @@
+package sqlrepo
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"database/sql"
+)
+
+const faqColumns = "id, question, answer, is_active, sort_order, created_at, updated_at"
+
+type faqRepo struct {
+	db *sql.DB
+}
+
+func scanFAQ(s scanner) (models.FAQ, error) {
+	var f models.FAQ
+	err := s.Scan(&f.ID, &f.Question, &f.Answer, &f.IsActive, &f.SortOrder, &f.CreatedAt, &f.UpdatedAt)
+	return f, err
+}
+
+func faqWhere(f repository.FAQFilter) (string, []interface{}) {
+	where := " WHERE 1=1"
+	args := []interface{}{}
+	if f.IsActive != "" {
+		where += " AND is_active = ?"
+		args = append(args, f.IsActive)
+	}
+	if f.Search != "" {
+		search := likePattern(f.Search)
+		where += " AND (question LIKE ? OR answer LIKE ?)"
+		args = append(args, search, search)
+	}
+	return where, args
+}
+
+func (r *faqRepo) List(ctx context.Context, f repository.FAQFilter) ([]models.FAQ, error) {
+	where, args := faqWhere(f)
+	query := "SELECT " + faqColumns + " FROM faqs" + where + " ORDER BY sort_order ASC, created_at ASC"
+	if f.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, f.Limit, f.Offset)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	faqs := []models.FAQ{}
+	for rows.Next() {
+		faq, err := scanFAQ(rows)
+		if err != nil {
+			return nil, err
+		}
+		faqs = append(faqs, faq)
+	}
+	return faqs, rows.Err()
+}
+
+func (r *faqRepo) Count(ctx context.Context, f repository.FAQFilter) (int, error) {
+	where, args := faqWhere(f)
+	var total int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM faqs"+where, args...).Scan(&total)
+	return total, err
+}
+
+func (r *faqRepo) Get(ctx context.Context, id int64) (models.FAQ, error) {
+	f, err := scanFAQ(r.db.QueryRowContext(ctx, "SELECT "+faqColumns+" FROM faqs WHERE id = ?", id))
+	return f, mapErr(err)
+}
+
+func (r *faqRepo) Create(ctx context.Context, f models.FAQ) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		"INSERT INTO faqs (question, answer, is_active, sort_order) VALUES (?, ?, ?, ?)",
+		f.Question, f.Answer, f.IsActive, f.SortOrder,
+	)
+	if err != nil {
+		return 0, mapErr(err)
+	}
+	return res.LastInsertId()
+}
+
+func (r *faqRepo) Update(ctx context.Context, id int64, p repository.FAQPatch) error {
+	var set updateSet
+	if p.Question != "" {
+		set.set("question", p.Question)
+	}
+	if p.Answer != "" {
+		set.set("answer", p.Answer)
+	}
+	if p.IsActive != "" {
+		set.set("is_active", p.IsActive)
+	}
+	if p.SortOrder != nil {
+		set.set("sort_order", *p.SortOrder)
+	}
+	if set.empty() {
+		return nil
+	}
+
+	query, args := set.query("faqs", id)
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return mapErr(err)
+}
+
+func (r *faqRepo) Delete(ctx context.Context, id int64) error {
+	return affectedOne(r.db.ExecContext(ctx, "DELETE FROM faqs WHERE id = ?", id))
+}
@@ -0,0 +1,216 @@
+package sqlrepo
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"database/sql"
+	"time"
+)
+
+const kioskColumns = `id, nama, device_id, public_key, key_fingerprint, is_active,
+	last_auth_at, last_auth_ip, created_at, updated_at`
+
+type kioskRepo struct {
+	db *sql.DB
+}
+
+func scanKiosk(s scanner, extra ...interface{}) (models.Kiosk, sql.NullString, error) {
+	var (
+		k           models.Kiosk
+		publicKey   sql.NullString
+		fingerprint sql.NullString
+		lastAuthAt  sql.NullTime
+		lastAuthIP  sql.NullString
+	)
+	dest := append([]interface{}{
+		&k.ID, &k.Nama, &k.DeviceID, &publicKey, &fingerprint, &k.IsActive,
+		&lastAuthAt, &lastAuthIP, &k.CreatedAt, &k.UpdatedAt,
+	}, extra...)
+	if err := s.Scan(dest...); err != nil {
+		return k, publicKey, err
+	}
+
+	k.HasPublicKey = publicKey.Valid && publicKey.String != ""
+	if fingerprint.Valid {
+		k.KeyFingerprint = &fingerprint.String
+	}
+	if lastAuthAt.Valid {
+		k.LastAuthAt = &lastAuthAt.Time
+	}
+	if lastAuthIP.Valid {
+		k.LastAuthIP = &lastAuthIP.String
+	}
+	return k, publicKey, nil
+}
+
+func (r *kioskRepo) withUnits(ctx context.Context, k models.Kiosk) (models.Kiosk, error) {
+	var err error
+	k.UnitIDs, err = queryIDs(ctx, r.db, "SELECT unit_id FROM kiosk_units WHERE kiosk_id = ? ORDER BY unit_id", k.ID)
+	return k, err
+}
+
+func (r *kioskRepo) List(ctx context.Context) ([]models.Kiosk, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT "+kioskColumns+" FROM kiosks ORDER BY nama ASC")
+	if err != nil {
+		return nil, err
+	}
+	kiosks := []models.Kiosk{}
+	for rows.Next() {
+		k, _, err := scanKiosk(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		kiosks = append(kiosks, k)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range kiosks {
+		if kiosks[i], err = r.withUnits(ctx, kiosks[i]); err != nil {
+			return nil, err
+		}
+	}
+	return kiosks, nil
+}
+
+func (r *kioskRepo) Get(ctx context.Context, id int64) (models.Kiosk, error) {
+	k, _, err := scanKiosk(r.db.QueryRowContext(ctx, "SELECT "+kioskColumns+" FROM kiosks WHERE id = ?", id))
+	if err != nil {
+		return k, mapErr(err)
+	}
+	return r.withUnits(ctx, k)
+}
+
+func (r *kioskRepo) Credentials(ctx context.Context, deviceID string) (repository.KioskCredentials, error) {
+	var c repository.KioskCredentials
+	k, publicKey, err := scanKiosk(r.db.QueryRowContext(ctx,
+		"SELECT "+kioskColumns+", secret_hash FROM kiosks WHERE device_id = ?", deviceID,
+	), &c.SecretHash)
+	if err != nil {
+		return c, mapErr(err)
+	}
+	c.PublicKey = publicKey.String
+	c.Kiosk, err = r.withUnits(ctx, k)
+	return c, err
+}
+
+func (r *kioskRepo) Create(ctx context.Context, k repository.NewKiosk) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO kiosks (nama, device_id, secret_hash, public_key, key_fingerprint, is_active, tokens_valid_after)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, k.Nama, k.DeviceID, k.SecretHash, k.Key.PublicKey, k.Key.Fingerprint, k.IsActive, clock.SQL(k.ValidAfter))
+	if err != nil {
+		return 0, mapErr(err)
+	}
+	id, _ := res.LastInsertId()
+
+	if err := replaceIDs(ctx, tx, "kiosk_units", "kiosk_id", "unit_id", id, k.UnitIDs); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+func (r *kioskRepo) Update(ctx context.Context, id int64, p repository.KioskPatch) error {
+	var set updateSet
+	if p.Nama != "" {
+		set.set("nama", p.Nama)
+	}
+	if p.IsActive != "" {
+		set.set("is_active", p.IsActive)
+	}
+	if p.Key != nil {
+		set.set("public_key", p.Key.PublicKey)
+		set.set("key_fingerprint", p.Key.Fingerprint)
+		set.raw("last_assertion_at = NULL")
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if !set.empty() {
+		query, args := set.query("kiosks", id)
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return mapErr(err)
+		}
+	}
+	if p.UnitIDs != nil {
+		if err := replaceIDs(ctx, tx, "kiosk_units", "kiosk_id", "unit_id", id, *p.UnitIDs); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *kioskRepo) RotateSecret(ctx context.Context, id int64, secretHash string, validAfter time.Time) error {
+	return affectedOne(r.db.ExecContext(ctx,
+		"UPDATE kiosks SET secret_hash = ?, tokens_valid_after = ? WHERE id = ?",
+		secretHash, clock.SQL(validAfter), id,
+	))
+}
+
+func (r *kioskRepo) Delete(ctx context.Context, id int64) error {
+	return affectedOne(r.db.ExecContext(ctx, "DELETE FROM kiosks WHERE id = ?", id))
+}
+
+func (r *kioskRepo) Units(ctx context.Context, kioskID int64) ([]models.Unit, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT u.id, u.code, u.nama_unit, u.is_active, u.main_display, u.audio_file, u.timezone, u.created_at, u.updated_at
+		FROM kiosk_units ku
+		JOIN units u ON u.id = ku.unit_id
+		WHERE ku.kiosk_id = ?
+		ORDER BY u.nama_unit ASC
+	`, kioskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	units := []models.Unit{}
+	for rows.Next() {
+		u, err := scanUnit(rows)
+		if err != nil {
+			return nil, err
+		}
+		units = append(units, u)
+	}
+	return units, rows.Err()
+}
+
+func (r *kioskRepo) AllowsUnit(ctx context.Context, kioskID, unitID int64) (bool, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM kiosk_units WHERE kiosk_id = ? AND unit_id = ?", kioskID, unitID,
+	).Scan(&count)
+	return count > 0, err
+}
+
+func (r *kioskRepo) RecordAuth(ctx context.Context, id int64, ip string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE kiosks SET last_auth_at = NOW(), last_auth_ip = ? WHERE id = ?", ip, id)
+	return err
+}
+
+func (r *kioskRepo) UseAssertion(ctx context.Context, id int64, timestamp int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE kiosks SET last_assertion_at = ?
+		WHERE id = ? AND (last_assertion_at IS NULL OR last_assertion_at < ?)
+	`, timestamp, id, timestamp)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
@@ -0,0 +1,124 @@
+package sqlrepo
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type passwordRepo struct {
+	db *sql.DB
+}
+
+func (r *passwordRepo) Recent(ctx context.Context, userID int64, limit int) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT password FROM users WHERE id = ?
+		UNION ALL
+		SELECT password_hash FROM (
+			SELECT password_hash FROM password_history WHERE user_id = ? ORDER BY id DESC LIMIT ?
+		) h
+	`, userID, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := []string{}
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, rows.Err()
+}
+
+func (r *passwordRepo) Record(ctx context.Context, userID int64, hash string, keep int) error {
+	return recordPassword(ctx, r.db, userID, hash, keep)
+}
+
+func recordPassword(ctx context.Context, db execer, userID int64, hash string, keep int) error {
+	if _, err := db.ExecContext(ctx,
+		"INSERT INTO password_history (user_id, password_hash) VALUES (?, ?)", userID, hash,
+	); err != nil {
+		return err
+	}
+	// Subquery berlapis: MySQL tidak mengizinkan LIMIT langsung di dalam IN
+	_, err := db.ExecContext(ctx, `
+		DELETE FROM password_history
+		WHERE user_id = ? AND id NOT IN (
+			SELECT id FROM (
+				SELECT id FROM password_history WHERE user_id = ? ORDER BY id DESC LIMIT ?
+			) keep
+		)
+	`, userID, userID, keep)
+	return err
+}
+
+func (r *passwordRepo) Set(ctx context.Context, userID int64, hash string, mustChange bool, keep int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	must := "n"
+	if mustChange {
+		must = "y"
+	}
+	if err := affectedOne(tx.ExecContext(ctx, `
+		UPDATE users SET password = ?, password_changed_at = NOW(), must_change_password = ?
+		WHERE id = ?
+	`, hash, must, userID)); err != nil {
+		return err
+	}
+	if err := recordPassword(ctx, tx, userID, hash, keep); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *passwordRepo) ForceReset(ctx context.Context, t repository.NewResetToken, lockedHash string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM password_reset_tokens WHERE user_id = ? AND used_at IS NULL", t.UserID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO password_reset_tokens (token_hash, user_id, created_by, expires_at)
+		VALUES (?, ?, ?, ?)
+	`, t.Hash, t.UserID, t.CreatedBy, t.ExpiresAt); err != nil {
+		return mapErr(err)
+	}
+	if err := affectedOne(tx.ExecContext(ctx,
+		"UPDATE users SET password = ?, must_change_password = 'y' WHERE id = ?", lockedHash, t.UserID,
+	)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *passwordRepo) ResetTokenUser(ctx context.Context, hash string, now time.Time) (models.User, error) {
+	var u models.User
+	err := r.db.QueryRowContext(ctx, `
+		SELECT u.id, u.email
+		FROM password_reset_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = ? AND t.used_at IS NULL AND t.expires_at > ?
+	`, hash, clock.SQL(now)).Scan(&u.ID, &u.Email)
+	return u, mapErr(err)
+}
+
+func (r *passwordRepo) UseResetToken(ctx context.Context, hash string) error {
+	return affectedOne(r.db.ExecContext(ctx,
+		"UPDATE password_reset_tokens SET used_at = NOW() WHERE token_hash = ? AND used_at IS NULL", hash,
+	))
+}
@@ -0,0 +1,151 @@
+package sqlrepo
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/repository"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+type reportRepo struct {
+	db *sql.DB
+}
+
+// reportWhere - klausa WHERE untuk tiket (alias qt) dalam rentang laporan
+func reportWhere(f repository.ReportFilter) (string, []interface{}) {
+	where := " WHERE DATE(qt.created_at) BETWEEN ? AND ?"
+	args := []interface{}{f.From, f.To}
+	if f.UnitID > 0 {
+		where += " AND qt.unit_id = ?"
+		args = append(args, f.UnitID)
+	}
+	return where, args
+}
+
+// dateString - kolom DATE(): MySQL (parseTime) mengembalikan time.Time,
+// SQLite mengembalikan teks
+type dateString string
+
+func (d *dateString) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case time.Time:
+		*d = dateString(v.Format(time.DateOnly))
+	case string:
+		*d = dateString(trimDate(v))
+	case []byte:
+		*d = dateString(trimDate(string(v)))
+	default:
+		return fmt.Errorf("tipe tanggal tidak dikenal: %T", src)
+	}
+	return nil
+}
+
+func trimDate(s string) string {
+	if len(s) > len(time.DateOnly) {
+		return s[:len(time.DateOnly)]
+	}
+	return s
+}
+
+func (r *reportRepo) Summary(ctx context.Context, f repository.ReportFilter) (repository.ReportSummary, error) {
+	where, args := reportWhere(f)
+	var s repository.ReportSummary
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(qt.id), COUNT(DISTINCT qt.unit_id), COUNT(DISTINCT qt.service_id)
+		FROM queue_tickets qt
+		INNER JOIN units u ON qt.unit_id = u.id
+	`+where, args...).Scan(&s.Visitors, &s.Units, &s.Services)
+	return s, err
+}
+
+func (r *reportRepo) Daily(ctx context.Context, f repository.ReportFilter) ([]repository.DayCount, error) {
+	where, args := reportWhere(f)
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT t.date, COUNT(*)
+		FROM (
+			SELECT DATE(qt.created_at) AS date
+			FROM queue_tickets qt
+			INNER JOIN units u ON qt.unit_id = u.id
+	`+where+`
+		) t
+		GROUP BY t.date
+		ORDER BY t.date ASC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	days := []repository.DayCount{}
+	for rows.Next() {
+		var date dateString
+		var total int
+		if err := rows.Scan(&date, &total); err != nil {
+			return nil, err
+		}
+		days = append(days, repository.DayCount{Date: string(date), Total: total})
+	}
+	return days, rows.Err()
+}
+
+func (r *reportRepo) ByUnit(ctx context.Context, f repository.ReportFilter) ([]repository.NameCount, error) {
+	where, args := reportWhere(f)
+	return r.nameCounts(ctx, `
+		SELECT u.nama_unit, COUNT(qt.id) AS total
+		FROM queue_tickets qt
+		INNER JOIN units u ON qt.unit_id = u.id
+	`+where+`
+		GROUP BY qt.unit_id, u.nama_unit
+		ORDER BY total DESC
+	`, args...)
+}
+
+func (r *reportRepo) ByService(ctx context.Context, f repository.ReportFilter) ([]repository.NameCount, error) {
+	where, args := reportWhere(f)
+	return r.nameCounts(ctx, `
+		SELECT s.nama_service, COUNT(qt.id) AS total
+		FROM queue_tickets qt
+		INNER JOIN units u ON qt.unit_id = u.id
+		INNER JOIN services s ON qt.service_id = s.id
+	`+where+`
+		GROUP BY qt.service_id, s.nama_service
+		ORDER BY total DESC
+	`, args...)
+}
+
+func (r *reportRepo) nameCounts(ctx context.Context, query string, args ...interface{}) ([]repository.NameCount, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := []repository.NameCount{}
+	for rows.Next() {
+		var n repository.NameCount
+		if err := rows.Scan(&n.Name, &n.Total); err != nil {
+			return nil, err
+		}
+		counts = append(counts, n)
+	}
+	return counts, rows.Err()
+}
+
+func (r *reportRepo) CountBetween(ctx context.Context, unitID, serviceID int64, from, to time.Time) (int, error) {
+	query := "SELECT COUNT(*) FROM queue_tickets WHERE created_at >= ? AND created_at <= ?"
+	args := []interface{}{from.Format(clock.SQLLayout), to.Format(clock.SQLLayout)}
+	if unitID > 0 {
+		query += " AND unit_id = ?"
+		args = append(args, unitID)
+	}
+	if serviceID > 0 {
+		query += " AND service_id = ?"
+		args = append(args, serviceID)
+	}
+
+	var count int
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	return count, err
+}
@@ -0,0 +1,187 @@
+package sqlrepo
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"database/sql"
+	"strings"
+)
+
+type roleRepo struct {
+	db *sql.DB
+}
+
+func (r *roleRepo) List(ctx context.Context) ([]models.Role, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT name FROM roles ORDER BY is_system DESC, name ASC")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	roles := []models.Role{}
+	for _, name := range names {
+		role, err := r.Get(ctx, name)
+		if err == repository.ErrNotFound {
+			continue // dihapus di antara dua query
+		}
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+func (r *roleRepo) Get(ctx context.Context, name string) (models.Role, error) {
+	var (
+		role        models.Role
+		description sql.NullString
+	)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT r.name, r.label, r.description, r.unit_scoped, r.require_2fa, r.is_system, r.created_at, r.updated_at,
+		       (SELECT COUNT(*) FROM users u WHERE u.role = r.name)
+		FROM roles r
+		WHERE r.name = ?
+	`, name).Scan(
+		&role.Name, &role.Label, &description, &role.UnitScoped, &role.Require2FA, &role.IsSystem, &role.CreatedAt, &role.UpdatedAt,
+		&role.UserCount,
+	)
+	if err != nil {
+		return role, mapErr(err)
+	}
+	if description.Valid {
+		role.Description = &description.String
+	}
+
+	rows, err := r.db.QueryContext(ctx, "SELECT permission FROM role_permissions WHERE role = ? ORDER BY permission", role.Name)
+	if err != nil {
+		return role, err
+	}
+	defer rows.Close()
+
+	role.Permissions = []string{}
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return role, err
+		}
+		role.Permissions = append(role.Permissions, p)
+	}
+	return role, rows.Err()
+}
+
+func (r *roleRepo) Create(ctx context.Context, role models.Role) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var description string
+	if role.Description != nil {
+		description = *role.Description
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO roles (name, label, description, unit_scoped, require_2fa, is_system)
+		VALUES (?, ?, NULLIF(?, ''), ?, ?, 'n')
+	`, role.Name, role.Label, description, role.UnitScoped, role.Require2FA); err != nil {
+		return mapErr(err)
+	}
+	if err := replaceRolePermissions(ctx, tx, role.Name, role.Permissions); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *roleRepo) Update(ctx context.Context, name string, p repository.RolePatch) error {
+	var cols []string
+	var args []interface{}
+	if p.Label != "" {
+		cols = append(cols, "label = ?")
+		args = append(args, p.Label)
+	}
+	if p.Description != nil {
+		cols = append(cols, "description = NULLIF(?, '')")
+		args = append(args, *p.Description)
+	}
+	if p.UnitScoped != "" {
+		cols = append(cols, "unit_scoped = ?")
+		args = append(args, p.UnitScoped)
+	}
+	if p.Require2FA != "" {
+		cols = append(cols, "require_2fa = ?")
+		args = append(args, p.Require2FA)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if len(cols) > 0 {
+		args = append(args, name)
+		if err := affectedOne(tx.ExecContext(ctx, "UPDATE roles SET "+strings.Join(cols, ", ")+" WHERE name = ?", args...)); err != nil {
+			return err
+		}
+	}
+	if p.Permissions != nil {
+		if err := replaceRolePermissions(ctx, tx, name, *p.Permissions); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// replaceRolePermissions ganti seluruh permission role
+func replaceRolePermissions(ctx context.Context, db execer, role string, perms []string) error {
+	if _, err := db.ExecContext(ctx, "DELETE FROM role_permissions WHERE role = ?", role); err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for _, p := range perms {
+		if seen[p] {
+			continue
+		}
+		seen[p] = true
+		if _, err := db.ExecContext(ctx, "INSERT INTO role_permissions (role, permission) VALUES (?, ?)", role, p); err != nil {
+			return mapErr(err)
+		}
+	}
+	return nil
+}
+
+func (r *roleRepo) Delete(ctx context.Context, name string) error {
+	return affectedOne(r.db.ExecContext(ctx, "DELETE FROM roles WHERE name = ?", name))
+}
+
+func (r *roleRepo) Permissions(ctx context.Context) (map[string][]string, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT role, permission FROM role_permissions")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := map[string][]string{}
+	for rows.Next() {
+		var role, perm string
+		if err := rows.Scan(&role, &perm); err != nil {
+			return nil, err
+		}
+		roles[role] = append(roles[role], perm)
+	}
+	return roles, rows.Err()
+}
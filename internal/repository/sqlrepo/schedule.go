@@ -0,0 +1,91 @@
+package sqlrepo
+
+import (
+	"backend-antrian/internal/dialect"
+	"backend-antrian/internal/models"
+	"context"
+	"database/sql"
+)
+
+const scheduleColumns = "id, unit_id, day_of_week, jam_buka, jam_tutup, is_active, created_at, updated_at"
+
+type scheduleRepo struct {
+	db *sql.DB
+	d  dialect.Dialect
+}
+
+func scanSchedule(sc scanner) (models.UnitSchedule, error) {
+	var s models.UnitSchedule
+	err := sc.Scan(
+		&s.ID, &s.UnitID, &s.DayOfWeek,
+		&s.JamBuka, &s.JamTutup, &s.IsActive,
+		&s.CreatedAt, &s.UpdatedAt,
+	)
+	s.DayName = models.DayName[s.DayOfWeek]
+	return s, err
+}
+
+func (r *scheduleRepo) ListByUnit(ctx context.Context, unitID int64) ([]models.UnitSchedule, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+scheduleColumns+`
+		FROM unit_schedules
+		WHERE unit_id = ?
+		ORDER BY day_of_week ASC
+	`, unitID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schedules := []models.UnitSchedule{}
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, rows.Err()
+}
+
+func (r *scheduleRepo) ForDay(ctx context.Context, unitID int64, dayOfWeek int) (models.UnitSchedule, error) {
+	s, err := scanSchedule(r.db.QueryRowContext(ctx,
+		"SELECT "+scheduleColumns+" FROM unit_schedules WHERE unit_id = ? AND day_of_week = ?",
+		unitID, dayOfWeek,
+	))
+	return s, mapErr(err)
+}
+
+func (r *scheduleRepo) Get(ctx context.Context, id int64) (models.UnitSchedule, error) {
+	s, err := scanSchedule(r.db.QueryRowContext(ctx, "SELECT "+scheduleColumns+" FROM unit_schedules WHERE id = ?", id))
+	return s, mapErr(err)
+}
+
+func (r *scheduleRepo) Upsert(ctx context.Context, unitID int64, items []models.UnitSchedule) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, s := range items {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO unit_schedules (unit_id, day_of_week, jam_buka, jam_tutup, is_active)
+			VALUES (?, ?, ?, ?, ?)
+			`+r.d.OnConflict("unit_id", "day_of_week")+`
+				jam_buka   = `+r.d.Inserted("jam_buka")+`,
+				jam_tutup  = `+r.d.Inserted("jam_tutup")+`,
+				is_active  = `+r.d.Inserted("is_active")+`,
+				updated_at = NOW()
+		`, unitID, s.DayOfWeek, s.JamBuka, s.JamTutup, s.IsActive)
+		if err != nil {
+			return mapErr(err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *scheduleRepo) Delete(ctx context.Context, id int64) error {
+	return affectedOne(r.db.ExecContext(ctx, "DELETE FROM unit_schedules WHERE id = ?", id))
+}
@@ -0,0 +1,136 @@
+package sqlrepo
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"database/sql"
+)
+
+const serviceSelect = `
+	SELECT
+		s.id, s.unit_id, s.nama_service, s.code, s.limits_queue,
+		s.is_active, s.created_at, s.updated_at,
+		u.nama_unit as loket
+	FROM services s
+	INNER JOIN units u ON s.unit_id = u.id
+`
+
+type serviceRepo struct {
+	db *sql.DB
+}
+
+func scanService(s scanner) (models.Service, error) {
+	var svc models.Service
+	err := s.Scan(
+		&svc.ID, &svc.UnitID, &svc.NamaService, &svc.Code, &svc.LimitsQueue,
+		&svc.IsActive, &svc.CreatedAt, &svc.UpdatedAt,
+		&svc.Loket, // dari units.nama_unit
+	)
+	return svc, err
+}
+
+func serviceWhere(f repository.ServiceFilter) (string, []interface{}) {
+	where := " WHERE 1=1"
+	args := []interface{}{}
+	if f.UnitID != 0 {
+		where += " AND s.unit_id = ?"
+		args = append(args, f.UnitID)
+	}
+	if f.IsActive != "" {
+		where += " AND s.is_active = ?"
+		args = append(args, f.IsActive)
+	}
+	if f.Search != "" {
+		search := likePattern(f.Search)
+		where += " AND (s.code LIKE ? OR s.nama_service LIKE ?)"
+		args = append(args, search, search)
+	}
+	return where, args
+}
+
+func (r *serviceRepo) List(ctx context.Context, f repository.ServiceFilter) ([]models.Service, error) {
+	where, args := serviceWhere(f)
+	query := serviceSelect + where
+	if f.OldestFirst {
+		query += " ORDER BY s.created_at ASC"
+	} else {
+		query += " ORDER BY s.created_at DESC"
+	}
+	if f.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, f.Limit, f.Offset)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	services := []models.Service{}
+	for rows.Next() {
+		svc, err := scanService(rows)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, svc)
+	}
+	return services, rows.Err()
+}
+
+func (r *serviceRepo) Count(ctx context.Context, f repository.ServiceFilter) (int, error) {
+	where, args := serviceWhere(f)
+	var total int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM services s"+where, args...).Scan(&total)
+	return total, err
+}
+
+func (r *serviceRepo) Get(ctx context.Context, id int64) (models.Service, error) {
+	svc, err := scanService(r.db.QueryRowContext(ctx, serviceSelect+" WHERE s.id = ?", id))
+	return svc, mapErr(err)
+}
+
+func (r *serviceRepo) CodeExists(ctx context.Context, code string, excludeID int64) (bool, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM services WHERE code = ? AND id != ?", code, excludeID).Scan(&count)
+	return count > 0, err
+}
+
+func (r *serviceRepo) Create(ctx context.Context, s models.Service) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		"INSERT INTO services (unit_id, nama_service, code, limits_queue, is_active) VALUES (?, ?, ?, ?, ?)",
+		s.UnitID, s.NamaService, s.Code, s.LimitsQueue, s.IsActive,
+	)
+	if err != nil {
+		return 0, mapErr(err)
+	}
+	return res.LastInsertId()
+}
+
+func (r *serviceRepo) Update(ctx context.Context, id int64, p repository.ServicePatch) error {
+	var set updateSet
+	if p.Code != "" {
+		set.set("code", p.Code)
+	}
+	if p.NamaService != "" {
+		set.set("nama_service", p.NamaService)
+	}
+	if p.LimitsQueue != nil {
+		set.set("limits_queue", *p.LimitsQueue)
+	}
+	if p.IsActive != "" {
+		set.set("is_active", p.IsActive)
+	}
+	if set.empty() {
+		return nil
+	}
+
+	query, args := set.query("services", id)
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return mapErr(err)
+}
+
+func (r *serviceRepo) Delete(ctx context.Context, id int64) error {
+	return affectedOne(r.db.ExecContext(ctx, "DELETE FROM services WHERE id = ?", id))
+}
@@ -0,0 +1,131 @@
+package sqlrepo
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/dialect"
+	"backend-antrian/internal/repository"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type sessionRepo struct {
+	db *sql.DB
+	d  dialect.Dialect
+}
+
+func (r *sessionRepo) Create(ctx context.Context, s repository.NewSession) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO user_sessions (id, user_id, active_unit_id, ip_address, user_agent, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, s.ID, s.UserID, s.ActiveUnitID, s.IPAddress, s.UserAgent, s.ExpiresAt); err != nil {
+		return mapErr(err)
+	}
+	if err := insertRefreshToken(ctx, tx, s.ID, s.RefreshHash, s.ExpiresAt); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertRefreshToken(ctx context.Context, db execer, sessionID, hash string, expiresAt time.Time) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (session_id, token_hash, expires_at)
+		VALUES (?, ?, ?)
+	`, sessionID, hash, expiresAt)
+	return mapErr(err)
+}
+
+func (r *sessionRepo) RefreshToken(ctx context.Context, hash string) (repository.RefreshToken, error) {
+	var (
+		t         repository.RefreshToken
+		usedAt    sql.NullTime
+		revokedAt sql.NullTime
+	)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT rt.id, rt.session_id, s.user_id, s.active_unit_id, rt.expires_at, rt.used_at, s.revoked_at
+		FROM refresh_tokens rt
+		JOIN user_sessions s ON s.id = rt.session_id
+		WHERE rt.token_hash = ?
+	`, hash).Scan(&t.ID, &t.SessionID, &t.UserID, &t.ActiveUnitID, &t.ExpiresAt, &usedAt, &revokedAt)
+	t.Used = usedAt.Valid
+	t.SessionRevoked = revokedAt.Valid
+	return t, mapErr(err)
+}
+
+func (r *sessionRepo) Rotate(ctx context.Context, tokenID int64, sessionID, newHash string, expiresAt time.Time, activeUnit sql.NullInt64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Update bersyarat: dari dua rotasi paralel token yang sama hanya satu yang lolos
+	if err := affectedOne(tx.ExecContext(ctx,
+		"UPDATE refresh_tokens SET used_at = NOW() WHERE id = ? AND used_at IS NULL", tokenID,
+	)); err != nil {
+		return err
+	}
+	if err := insertRefreshToken(ctx, tx, sessionID, newHash, expiresAt); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE user_sessions SET last_used_at = NOW(), expires_at = ?, active_unit_id = ? WHERE id = ?
+	`, expiresAt, activeUnit, sessionID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *sessionRepo) SetActiveUnit(ctx context.Context, sessionID string, unitID int64) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE user_sessions SET active_unit_id = ?, last_used_at = NOW() WHERE id = ?", unitID, sessionID,
+	)
+	return err
+}
+
+func (r *sessionRepo) Revoke(ctx context.Context, sessionID, reason string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE user_sessions
+		SET revoked_at = NOW(), revoked_reason = ?
+		WHERE id = ? AND revoked_at IS NULL
+	`, reason, sessionID)
+	return err
+}
+
+func (r *sessionRepo) RevokeUser(ctx context.Context, userID int64, reason string) (int64, error) {
+	return r.RevokeOthers(ctx, userID, "", reason)
+}
+
+func (r *sessionRepo) RevokeOthers(ctx context.Context, userID int64, keepID, reason string) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE user_sessions
+		SET revoked_at = NOW(), revoked_reason = ?
+		WHERE user_id = ? AND id != ? AND revoked_at IS NULL
+	`, reason, userID, keepID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (r *sessionRepo) Denylist(ctx context.Context, jti string, userID int64, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		`+r.d.InsertIgnore()+` INTO revoked_tokens (jti, user_id, expires_at)
+		VALUES (?, ?, ?)
+	`, jti, userID, expiresAt)
+	return err
+}
+
+func (r *sessionRepo) Cleanup(ctx context.Context, now, keepSince time.Time) error {
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM revoked_tokens WHERE expires_at < ?", clock.SQL(now)); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, "DELETE FROM user_sessions WHERE expires_at < ?", clock.SQL(keepSince))
+	return err
+}
@@ -0,0 +1,141 @@
+// Package sqlrepo implementasi repository di atas database/sql (MySQL / SQLite).
+package sqlrepo
+
+import (
+	"backend-antrian/internal/dialect"
+	"backend-antrian/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// New - semua repository SQL di atas satu koneksi pool; d menentukan
+// sintaks yang berbeda antar backend (upsert)
+func New(db *sql.DB, d dialect.Dialect) *repository.Repos {
+	return &repository.Repos{
+		Units:     &unitRepo{db: db},
+		Services:  &serviceRepo{db: db},
+		Tickets:   &ticketRepo{db: db, d: d},
+		Users:     &userRepo{db: db},
+		Schedules: &scheduleRepo{db: db, d: d},
+		FAQs:      &faqRepo{db: db},
+		Audios:    &audioRepo{db: db},
+		Configs:   &configRepo{db: db},
+		Reports:   &reportRepo{db: db},
+		Sessions:  &sessionRepo{db: db, d: d},
+		Roles:     &roleRepo{db: db},
+		Passwords: &passwordRepo{db: db},
+		TwoFactor: &twoFactorRepo{db: db, d: d},
+		Displays:  &displayRepo{db: db, d: d},
+		Kiosks:    &kioskRepo{db: db},
+	}
+}
+
+// scanner - *sql.Row atau *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// mapErr terjemahkan error driver ke error repository
+func mapErr(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return repository.ErrNotFound
+	}
+	// Tipe error tiap driver berbeda, jadi aman dicek untuk semua dialect
+	for _, d := range []dialect.Dialect{dialect.MySQL{}, dialect.SQLite{}} {
+		switch {
+		case d.IsDuplicate(err):
+			return repository.ErrDuplicate
+		case d.IsReferenced(err):
+			return repository.ErrInUse
+		}
+	}
+	return err
+}
+
+// affectedOne - ErrNotFound jika tidak ada baris yang kena
+func affectedOne(res sql.Result, err error) error {
+	if err != nil {
+		return mapErr(err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+// likePattern - %kata% untuk pencarian LIKE
+func likePattern(search string) string {
+	return "%" + strings.TrimSpace(search) + "%"
+}
+
+// placeholders - "?,?,?" sebanyak n untuk klausa IN
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// updateSet - pembangun klausa SET dinamis
+type updateSet struct {
+	cols []string
+	args []interface{}
+}
+
+func (u *updateSet) set(col string, v interface{}) {
+	u.cols = append(u.cols, col+" = ?")
+	u.args = append(u.args, v)
+}
+
+// raw kolom dengan ekspresi SQL tetap (mis. NOW())
+func (u *updateSet) raw(expr string) {
+	u.cols = append(u.cols, expr)
+}
+
+func (u *updateSet) empty() bool {
+	return len(u.cols) == 0
+}
+
+// query "UPDATE table SET ... WHERE id = ?"
+func (u *updateSet) query(table string, id int64) (string, []interface{}) {
+	return "UPDATE " + table + " SET " + strings.Join(u.cols, ", ") + " WHERE id = ?", append(u.args, id)
+}
+
+// queryIDs - satu kolom ID dari query
+func queryIDs(ctx context.Context, db *sql.DB, query string, args ...interface{}) ([]int64, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// replaceIDs - ganti seluruh baris tabel relasi milik ownerID; ID <= 0 dan
+// duplikat diabaikan
+func replaceIDs(ctx context.Context, db execer, table, ownerCol, idCol string, ownerID int64, ids []int64) error {
+	if _, err := db.ExecContext(ctx, "DELETE FROM "+table+" WHERE "+ownerCol+" = ?", ownerID); err != nil {
+		return err
+	}
+	seen := map[int64]bool{}
+	for _, id := range ids {
+		if id <= 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		if _, err := db.ExecContext(ctx,
+			"INSERT INTO "+table+" ("+ownerCol+", "+idCol+") VALUES (?, ?)", ownerID, id,
+		); err != nil {
+			return mapErr(err)
+		}
+	}
+	return nil
+}
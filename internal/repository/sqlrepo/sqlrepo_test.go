@@ -0,0 +1,700 @@
+package sqlrepo
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/config"
+	"backend-antrian/internal/dialect"
+	"backend-antrian/internal/migrate"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// sqliteRepos - repository di atas file SQLite sementara yang sudah dimigrasi
+func sqliteRepos(t *testing.T) *repository.Repos {
+	t.Helper()
+	db, err := config.OpenSQLite(filepath.Join(t.TempDir(), "repo.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	m, err := migrate.New(db, "sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Fatal("migrate: ", err)
+	}
+	return New(db, dialect.SQLite{})
+}
+
+func TestSQLiteUnitSchedule(t *testing.T) {
+	r := sqliteRepos(t)
+	ctx := context.Background()
+
+	unitID, err := r.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Dukcapil", IsActive: "y", MainDisplay: "active"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Lain", IsActive: "y", MainDisplay: "active"}); !errors.Is(err, repository.ErrDuplicate) {
+		t.Fatalf("code duplikat = %v, want ErrDuplicate", err)
+	}
+
+	// Upsert dua kali: baris yang sama diperbarui, bukan ditambah
+	items := []models.UnitSchedule{{DayOfWeek: 1, JamBuka: "08:00:00", JamTutup: "15:00:00", IsActive: "y"}}
+	if err := r.Schedules.Upsert(ctx, unitID, items); err != nil {
+		t.Fatal("upsert: ", err)
+	}
+	items[0].JamTutup = "12:00:00"
+	if err := r.Schedules.Upsert(ctx, unitID, items); err != nil {
+		t.Fatal("upsert ulang: ", err)
+	}
+	list, err := r.Schedules.ListByUnit(ctx, unitID)
+	if err != nil || len(list) != 1 || list[0].JamTutup != "12:00:00" {
+		t.Fatalf("schedules = %+v, %v", list, err)
+	}
+
+}
+
+func TestSQLiteTicketFlow(t *testing.T) {
+	r := sqliteRepos(t)
+	ctx := context.Background()
+
+	unitID, _ := r.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Dukcapil", IsActive: "y", MainDisplay: "active"})
+	serviceID, _ := r.Services.Create(ctx, models.Service{UnitID: unitID, NamaService: "KTP", Code: "KTP", IsActive: "y"})
+	userID, err := r.Users.CreateWithUnits(ctx, models.User{Nama: "Petugas", Email: "petugas@x.id", Password: "-", Role: "unit", IsBanned: "n"}, []int64{unitID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, code := range []string{"KTP1", "KTP2"} {
+		if _, err := r.Tickets.Create(ctx, repository.NewTicket{TicketCode: code, UnitID: unitID, ServiceID: serviceID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Batas hari dari Go harus cocok dengan NOW() terdaftar saat insert
+	today := clock.Today(nil)
+	if n, err := r.Tickets.CountToday(ctx, unitID, serviceID, today); err != nil || n != 2 {
+		t.Fatalf("CountToday = %d, %v", n, err)
+	}
+
+	next, err := r.Tickets.NextWaiting(ctx, serviceID, today)
+	if err != nil || next.TicketCode != "KTP1" {
+		t.Fatalf("NextWaiting = %+v, %v", next, err)
+	}
+	if err := r.Tickets.Call(ctx, next.ID, userID); err != nil {
+		t.Fatal("call: ", err)
+	}
+	current, err := r.Tickets.CurrentCalled(ctx, serviceID)
+	if err != nil || current.ID != next.ID || current.LastCalledAt == nil {
+		t.Fatalf("CurrentCalled = %+v, %v", current, err)
+	}
+
+	res, err := r.Tickets.CallNext(ctx, serviceID, today, userID, "done", "finish")
+	if err != nil || res.ClosedID != current.ID || res.Called.TicketCode != "KTP2" || res.Called.Status != "called" {
+		t.Fatalf("CallNext = %+v, %v", res, err)
+	}
+	// Tidak ada waiting: ticket called tetap terbuka
+	if _, err := r.Tickets.CallNext(ctx, serviceID, today, userID, "done", "finish"); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("CallNext kosong = %v, want ErrNotFound", err)
+	}
+	if current, _ := r.Tickets.CurrentCalled(ctx, serviceID); current.ID != res.Called.ID {
+		t.Fatalf("CurrentCalled = %+v, want %d", current, res.Called.ID)
+	}
+
+	stats, err := r.Tickets.StatsToday(ctx, unitID, today)
+	want := repository.ServiceDayStats{Total: 2, Served: 2, CurrentTicket: "KTP2"}
+	if err != nil || len(stats) != 1 || stats[serviceID] != want {
+		t.Fatalf("StatsToday = %+v, %v; want %+v", stats, err, want)
+	}
+}
+
+func TestSQLiteUserUnits(t *testing.T) {
+	r := sqliteRepos(t)
+	ctx := context.Background()
+
+	userID, err := r.Users.CreateWithUnits(ctx, models.User{Nama: "Petugas", Email: "petugas@x.id", Password: "-", Role: "unit", IsBanned: "n"}, []int64{3, 1, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids, err := r.Users.UnitIDs(ctx, userID); err != nil || !slices.Equal(ids, []int64{1, 3}) {
+		t.Fatalf("UnitIDs = %v, %v", ids, err)
+	}
+	creds, err := r.Users.GetByEmail(ctx, "petugas@x.id")
+	if err != nil || creds.ID != userID || creds.Password != "-" || !creds.MustChangePassword {
+		t.Fatalf("GetByEmail = %+v, %v", creds, err)
+	}
+
+	// unitIDs nil: keanggotaan tetap
+	if err := r.Users.UpdateWithUnits(ctx, userID, repository.UserPatch{Nama: "Baru"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := r.Users.UnitIDs(ctx, userID); !slices.Equal(ids, []int64{1, 3}) {
+		t.Fatalf("UnitIDs setelah patch = %v", ids)
+	}
+
+	// Email duplikat: seluruh update batal, keanggotaan tidak berubah
+	if _, err := r.Users.CreateWithUnits(ctx, models.User{Nama: "Lain", Email: "lain@x.id", Password: "-", Role: "unit", IsBanned: "n"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Users.UpdateWithUnits(ctx, userID, repository.UserPatch{Email: "lain@x.id"}, []int64{2}); !errors.Is(err, repository.ErrDuplicate) {
+		t.Fatalf("email duplikat = %v, want ErrDuplicate", err)
+	}
+	if ids, _ := r.Users.UnitIDs(ctx, userID); !slices.Equal(ids, []int64{1, 3}) {
+		t.Fatalf("UnitIDs setelah gagal = %v", ids)
+	}
+
+	if err := r.Users.UpdateWithUnits(ctx, userID, repository.UserPatch{}, []int64{}); err != nil {
+		t.Fatal(err)
+	}
+	if ids, _ := r.Users.UnitIDs(ctx, userID); len(ids) != 0 {
+		t.Fatalf("UnitIDs dikosongkan = %v", ids)
+	}
+
+	if err := r.Users.UpdateWithUnits(ctx, userID, repository.UserPatch{}, []int64{2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Users.HardDelete(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Users.Get(ctx, userID); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("Get setelah hapus = %v", err)
+	}
+	if ids, _ := r.Users.UnitIDs(ctx, userID); len(ids) != 0 {
+		t.Fatalf("UnitIDs setelah hapus = %v", ids)
+	}
+	if err := r.Users.HardDelete(ctx, userID); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("hapus ulang = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSQLiteAudioRenameAndImport(t *testing.T) {
+	r := sqliteRepos(t)
+	ctx := context.Background()
+
+	ids, err := r.Audios.CreateMany(ctx, []models.Audio{
+		{TTSText: "loket", NamaAudio: "loket.mp3", PathAudio: "public/audio/loket.mp3"},
+		{TTSText: "kasir", NamaAudio: "kasir.mp3", PathAudio: "public/audio/kasir.mp3"},
+	})
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("CreateMany = %v, %v", ids, err)
+	}
+
+	// Satu nama duplikat: seluruh batch batal
+	if _, err := r.Audios.CreateMany(ctx, []models.Audio{
+		{TTSText: "baru", NamaAudio: "baru.mp3", PathAudio: "public/audio/baru.mp3"},
+		{TTSText: "loket", NamaAudio: "loket.mp3", PathAudio: "public/audio/loket.mp3"},
+	}); !errors.Is(err, repository.ErrDuplicate) {
+		t.Fatalf("CreateMany duplikat = %v, want ErrDuplicate", err)
+	}
+	if exists, _ := r.Audios.NameExists(ctx, "baru.mp3", 0); exists {
+		t.Fatal("baru.mp3 tersimpan walau batch batal")
+	}
+
+	file := "loket.mp3"
+	unitID, err := r.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Dukcapil", AudioFile: &file, IsActive: "y", MainDisplay: "active"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Audios.Rename(ctx, ids[0], "kasir.mp3", "public/audio/kasir.mp3"); !errors.Is(err, repository.ErrDuplicate) {
+		t.Fatalf("Rename ke nama terpakai = %v, want ErrDuplicate", err)
+	}
+	n, err := r.Audios.Rename(ctx, ids[0], "loket_1.mp3", "public/audio/loket_1.mp3")
+	if err != nil || n != 1 {
+		t.Fatalf("Rename = %d, %v", n, err)
+	}
+	if u, _ := r.Units.Get(ctx, unitID); u.AudioFile == nil || *u.AudioFile != "loket_1.mp3" {
+		t.Fatalf("audio_file unit = %v", u.AudioFile)
+	}
+	if _, err := r.Audios.Rename(ctx, 999, "x.mp3", "public/audio/x.mp3"); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("Rename audio tidak ada = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSQLiteTicketDayBounds(t *testing.T) {
+	r := sqliteRepos(t)
+	ctx := context.Background()
+	defer clock.Set(clock.Default())
+
+	wib := clock.In("Asia/Jakarta")
+	wita := clock.In("Asia/Makassar")
+
+	unitID, _ := r.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Dukcapil", IsActive: "y", MainDisplay: "active"})
+	serviceID, _ := r.Services.Create(ctx, models.Service{UnitID: unitID, NamaService: "KTP", Code: "KTP", IsActive: "y"})
+
+	// 23:30 WIB = 00:30 WITA keesokan harinya; created_at dari NOW() = jam clock
+	clock.Set(clock.Fixed(time.Date(2026, 10, 14, 23, 30, 0, 0, wib)))
+	if _, err := r.Tickets.Create(ctx, repository.NewTicket{TicketCode: "KTP1", UnitID: unitID, ServiceID: serviceID}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Satu jam kemudian: hari WIB sudah ganti, hari WITA belum
+	clock.Set(clock.Fixed(time.Date(2026, 10, 15, 0, 30, 0, 0, wib)))
+	if n, _ := r.Tickets.CountToday(ctx, unitID, serviceID, clock.Today(wib)); n != 0 {
+		t.Fatalf("CountToday WIB = %d, want 0", n)
+	}
+	if n, _ := r.Tickets.CountToday(ctx, unitID, serviceID, clock.Today(wita)); n != 1 {
+		t.Fatalf("CountToday WITA = %d, want 1", n)
+	}
+	if _, err := r.Tickets.NextWaiting(ctx, serviceID, clock.Today(wita)); err != nil {
+		t.Fatal("NextWaiting WITA: ", err)
+	}
+}
+
+func TestSQLiteDisplayRowsPerUnitDay(t *testing.T) {
+	r := sqliteRepos(t)
+	ctx := context.Background()
+	defer clock.Set(clock.Default())
+
+	wib := clock.In("Asia/Jakarta")
+	wita := clock.In("Asia/Makassar")
+
+	jkt, _ := r.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Dukcapil", IsActive: "y", MainDisplay: "active"})
+	mks, _ := r.Units.Create(ctx, models.Unit{Code: "B", NamaUnit: "Pajak", IsActive: "y", MainDisplay: "inactive"})
+	ktp, _ := r.Services.Create(ctx, models.Service{UnitID: jkt, NamaService: "KTP", Code: "KTP", IsActive: "y"})
+	pbb, _ := r.Services.Create(ctx, models.Service{UnitID: mks, NamaService: "PBB", Code: "PBB", IsActive: "y"})
+
+	// 23:30 WIB: tiket kedua unit; sejam kemudian hanya hari WITA yang masih sama
+	clock.Set(clock.Fixed(time.Date(2026, 10, 14, 23, 30, 0, 0, wib)))
+	r.Tickets.Create(ctx, repository.NewTicket{TicketCode: "KTP1", UnitID: jkt, ServiceID: ktp})
+	r.Tickets.Create(ctx, repository.NewTicket{TicketCode: "PBB1", UnitID: mks, ServiceID: pbb})
+	clock.Set(clock.Fixed(time.Date(2026, 10, 15, 0, 30, 0, 0, wib)))
+
+	today := repository.Today{Default: clock.Today(wib), Units: map[int64]clock.Day{mks: clock.Today(wita)}}
+	rows, err := r.Tickets.DisplayRows(ctx, today, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[int64]string{}
+	for _, row := range rows {
+		got[row.ServiceID] = row.TicketCode
+	}
+	if len(rows) != 2 || got[ktp] != "-" || got[pbb] != "PBB1" {
+		t.Fatalf("rows = %+v", rows)
+	}
+
+	waiting, err := r.Tickets.WaitingToday(ctx, today, 0)
+	if err != nil || len(waiting) != 1 || waiting[pbb] != 1 {
+		t.Fatalf("WaitingToday = %v, %v", waiting, err)
+	}
+	if n, _ := r.Tickets.CreatedSince(ctx, clock.Now().Add(-2*time.Hour)); n[ktp] != 1 || n[pbb] != 1 {
+		t.Fatalf("CreatedSince = %v", n)
+	}
+}
+
+func TestSQLiteReports(t *testing.T) {
+	r := sqliteRepos(t)
+	ctx := context.Background()
+	defer clock.Set(clock.Default())
+
+	unitID, _ := r.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Dukcapil", IsActive: "y", MainDisplay: "active"})
+	ktp, _ := r.Services.Create(ctx, models.Service{UnitID: unitID, NamaService: "KTP", Code: "KTP", IsActive: "y"})
+	kk, _ := r.Services.Create(ctx, models.Service{UnitID: unitID, NamaService: "KK", Code: "KK", IsActive: "y"})
+
+	clock.Set(clock.Fixed(time.Date(2026, 2, 3, 9, 0, 0, 0, clock.Location())))
+	r.Tickets.Create(ctx, repository.NewTicket{TicketCode: "KTP1", UnitID: unitID, ServiceID: ktp})
+	r.Tickets.Create(ctx, repository.NewTicket{TicketCode: "KTP2", UnitID: unitID, ServiceID: ktp})
+	clock.Set(clock.Fixed(time.Date(2026, 2, 4, 9, 0, 0, 0, clock.Location())))
+	r.Tickets.Create(ctx, repository.NewTicket{TicketCode: "KK1", UnitID: unitID, ServiceID: kk})
+
+	f := repository.ReportFilter{UnitID: unitID, From: "2026-02-01", To: "2026-02-28"}
+	if s, err := r.Reports.Summary(ctx, f); err != nil || s != (repository.ReportSummary{Visitors: 3, Units: 1, Services: 2}) {
+		t.Fatalf("Summary = %+v, %v", s, err)
+	}
+	days, err := r.Reports.Daily(ctx, f)
+	want := []repository.DayCount{{Date: "2026-02-03", Total: 2}, {Date: "2026-02-04", Total: 1}}
+	if err != nil || !slices.Equal(days, want) {
+		t.Fatalf("Daily = %+v, %v", days, err)
+	}
+	if byService, _ := r.Reports.ByService(ctx, f); len(byService) != 2 || byService[0] != (repository.NameCount{Name: "KTP", Total: 2}) {
+		t.Fatalf("ByService = %+v", byService)
+	}
+
+	from := time.Date(2026, 2, 4, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2026, 2, 4, 23, 59, 59, 0, time.UTC)
+	if n, err := r.Reports.CountBetween(ctx, 0, kk, from, to); err != nil || n != 1 {
+		t.Fatalf("CountBetween kk = %d, %v", n, err)
+	}
+	if n, _ := r.Reports.CountBetween(ctx, unitID, 0, from, to); n != 1 {
+		t.Fatalf("CountBetween unit = %d, want 1", n)
+	}
+}
+
+func TestSQLiteSessionRotation(t *testing.T) {
+	r := sqliteRepos(t)
+	ctx := context.Background()
+
+	unitID, _ := r.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Dukcapil", IsActive: "y", MainDisplay: "active"})
+	userID, _ := r.Users.CreateWithUnits(ctx, models.User{Nama: "Petugas", Email: "petugas@x.id", Password: "-", Role: "unit", IsBanned: "n"}, []int64{unitID})
+	if units, err := r.Users.Units(ctx, userID); err != nil || len(units) != 1 || units[0].NamaUnit != "Dukcapil" {
+		t.Fatalf("Units = %+v, %v", units, err)
+	}
+
+	expires := clock.Now().Add(time.Hour)
+	err := r.Sessions.Create(ctx, repository.NewSession{ID: "s1", UserID: userID, IPAddress: "127.0.0.1", ExpiresAt: expires, RefreshHash: "h1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tok, err := r.Sessions.RefreshToken(ctx, "h1")
+	if err != nil || tok.SessionID != "s1" || tok.UserID != userID || tok.Used || !tok.ExpiresAt.After(clock.Now()) {
+		t.Fatalf("RefreshToken = %+v, %v", tok, err)
+	}
+
+	active := sql.NullInt64{Int64: unitID, Valid: true}
+	if err := r.Sessions.Rotate(ctx, tok.ID, "s1", "h2", expires, active); err != nil {
+		t.Fatal("rotate: ", err)
+	}
+	// Rotasi kedua token yang sama (request paralel) ditolak
+	if err := r.Sessions.Rotate(ctx, tok.ID, "s1", "h3", expires, active); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("rotate ulang = %v, want ErrNotFound", err)
+	}
+	if tok, _ := r.Sessions.RefreshToken(ctx, "h1"); !tok.Used {
+		t.Fatal("token lama belum ditandai terpakai")
+	}
+	if tok, _ := r.Sessions.RefreshToken(ctx, "h2"); tok.ActiveUnitID != active {
+		t.Fatalf("unit aktif = %+v", tok.ActiveUnitID)
+	}
+
+	if n, err := r.Sessions.RevokeOthers(ctx, userID, "s1", "password_changed"); err != nil || n != 0 {
+		t.Fatalf("RevokeOthers = %d, %v", n, err)
+	}
+	if n, err := r.Sessions.RevokeUser(ctx, userID, "logout_all"); err != nil || n != 1 {
+		t.Fatalf("RevokeUser = %d, %v", n, err)
+	}
+	if tok, _ := r.Sessions.RefreshToken(ctx, "h2"); !tok.SessionRevoked {
+		t.Fatal("sesi belum di-revoke")
+	}
+
+	if err := r.Sessions.Denylist(ctx, "jti", userID, clock.Now().Add(-time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Sessions.Denylist(ctx, "jti", userID, clock.Now()); err != nil {
+		t.Fatal("denylist ulang: ", err)
+	}
+	if err := r.Sessions.Cleanup(ctx, clock.Now(), expires.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Sessions.RefreshToken(ctx, "h2"); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("sesi kedaluwarsa tidak dibersihkan: %v", err)
+	}
+}
+
+func TestSQLiteRoles(t *testing.T) {
+	r := sqliteRepos(t)
+	ctx := context.Background()
+
+	roles, err := r.Roles.List(ctx)
+	if err != nil || len(roles) == 0 || roles[0].IsSystem != "y" {
+		t.Fatalf("List = %+v, %v", roles, err)
+	}
+	if su, _ := r.Roles.Get(ctx, "super_user"); su.Require2FA != "y" {
+		t.Fatalf("super_user = %+v", su)
+	}
+
+	empty := ""
+	if err := r.Roles.Create(ctx, models.Role{Name: "loket", Label: "Loket", Description: &empty, UnitScoped: "n", Require2FA: "n", Permissions: []string{"queue.take", "queue.take"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Roles.Create(ctx, models.Role{Name: "loket", Label: "Lain", UnitScoped: "n", Require2FA: "n"}); !errors.Is(err, repository.ErrDuplicate) {
+		t.Fatalf("nama duplikat = %v, want ErrDuplicate", err)
+	}
+
+	perms := []string{"queue.call", "queue.take"}
+	if err := r.Roles.Update(ctx, "loket", repository.RolePatch{UnitScoped: "y", Permissions: &perms}); err != nil {
+		t.Fatal(err)
+	}
+	role, err := r.Roles.Get(ctx, "loket")
+	if err != nil || role.Description != nil || role.UnitScoped != "y" || !slices.Equal(role.Permissions, perms) {
+		t.Fatalf("Get = %+v, %v", role, err)
+	}
+	if all, _ := r.Roles.Permissions(ctx); len(all["loket"]) != 2 {
+		t.Fatalf("Permissions = %v", all)
+	}
+	if err := r.Roles.Update(ctx, "tamu", repository.RolePatch{Label: "Tamu"}); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("update role tidak ada = %v", err)
+	}
+
+	if err := r.Roles.Delete(ctx, "loket"); err != nil {
+		t.Fatal(err)
+	}
+	if all, _ := r.Roles.Permissions(ctx); all["loket"] != nil {
+		t.Fatalf("permission role terhapus masih ada: %v", all["loket"])
+	}
+}
+
+func TestSQLitePasswords(t *testing.T) {
+	r := sqliteRepos(t)
+	ctx := context.Background()
+
+	userID, _ := r.Users.CreateWithUnits(ctx, models.User{Nama: "Petugas", Email: "petugas@x.id", Password: "h0", Role: "unit", IsBanned: "n"}, nil)
+	for _, hash := range []string{"h1", "h2", "h3"} {
+		if err := r.Passwords.Set(ctx, userID, hash, false, 2); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if hashes, err := r.Passwords.Recent(ctx, userID, 2); err != nil || !slices.Equal(hashes, []string{"h3", "h3", "h2"}) {
+		t.Fatalf("Recent = %v, %v", hashes, err)
+	}
+	if creds, err := r.Users.Credentials(ctx, userID); err != nil || creds.Password != "h3" || creds.PasswordChangedAt == nil {
+		t.Fatalf("Credentials = %+v, %v", creds, err)
+	}
+
+	expires := clock.Now().Add(time.Hour)
+	if err := r.Passwords.ForceReset(ctx, repository.NewResetToken{Hash: "t1", UserID: userID, ExpiresAt: expires}, "locked"); err != nil {
+		t.Fatal(err)
+	}
+	// Token baru menggantikan token lama yang belum dipakai
+	if err := r.Passwords.ForceReset(ctx, repository.NewResetToken{Hash: "t2", UserID: userID, ExpiresAt: expires}, "locked"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Passwords.ResetTokenUser(ctx, "t1", clock.Now()); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("token lama = %v, want ErrNotFound", err)
+	}
+	if _, err := r.Passwords.ResetTokenUser(ctx, "t2", expires.Add(time.Minute)); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("token kedaluwarsa = %v, want ErrNotFound", err)
+	}
+	if u, err := r.Passwords.ResetTokenUser(ctx, "t2", clock.Now()); err != nil || u.ID != userID || u.Email != "petugas@x.id" {
+		t.Fatalf("ResetTokenUser = %+v, %v", u, err)
+	}
+	if creds, _ := r.Users.Credentials(ctx, userID); creds.Password != "locked" || !creds.MustChangePassword {
+		t.Fatalf("setelah force reset = %+v", creds)
+	}
+
+	if err := r.Passwords.UseResetToken(ctx, "t2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Passwords.UseResetToken(ctx, "t2"); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("token dipakai ulang = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSQLiteTwoFactor(t *testing.T) {
+	r := sqliteRepos(t)
+	ctx := context.Background()
+
+	userID, _ := r.Users.CreateWithUnits(ctx, models.User{Nama: "Petugas", Email: "petugas@x.id", Password: "-", Role: "unit", IsBanned: "n"}, nil)
+	if _, err := r.TwoFactor.Secret(ctx, userID); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("Secret sebelum enrol = %v, want ErrNotFound", err)
+	}
+
+	if err := r.TwoFactor.StartEnrollment(ctx, userID, "sealed"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := r.TwoFactor.UseStep(ctx, userID, 10); err != nil || !ok {
+		t.Fatalf("UseStep = %v, %v", ok, err)
+	}
+	// Time step yang sama tidak bisa dipakai dua kali
+	if ok, _ := r.TwoFactor.UseStep(ctx, userID, 10); ok {
+		t.Fatal("step dipakai ulang")
+	}
+	if err := r.TwoFactor.Confirm(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := r.TwoFactor.Secret(ctx, userID); err != nil || s.SecretEnc != "sealed" || !s.Enabled || s.LastStep != 10 {
+		t.Fatalf("Secret = %+v, %v", s, err)
+	}
+
+	if err := r.TwoFactor.ReplaceRecoveryCodes(ctx, userID, []string{"c1", "c2"}); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := r.TwoFactor.UseRecoveryCode(ctx, userID, "c1"); !ok {
+		t.Fatal("kode pemulihan ditolak")
+	}
+	if ok, _ := r.TwoFactor.UseRecoveryCode(ctx, userID, "c1"); ok {
+		t.Fatal("kode pemulihan dipakai ulang")
+	}
+	if n, _ := r.TwoFactor.RecoveryCodesLeft(ctx, userID); n != 1 {
+		t.Fatalf("RecoveryCodesLeft = %d", n)
+	}
+
+	now := clock.Now()
+	unit := sql.NullInt64{Int64: 7, Valid: true}
+	if err := r.TwoFactor.CreateChallenge(ctx, repository.LoginChallenge{Hash: "ch", UserID: userID, UnitID: unit, IPAddress: "127.0.0.1", ExpiresAt: now.Add(time.Minute)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.TwoFactor.FailChallenge(ctx, "ch"); err != nil {
+		t.Fatal(err)
+	}
+	if ch, err := r.TwoFactor.Challenge(ctx, "ch", now); err != nil || ch.UserID != userID || ch.UnitID != unit || ch.Attempts != 1 {
+		t.Fatalf("Challenge = %+v, %v", ch, err)
+	}
+	if _, err := r.TwoFactor.Challenge(ctx, "ch", now.Add(2*time.Minute)); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("challenge kedaluwarsa = %v, want ErrNotFound", err)
+	}
+	if err := r.TwoFactor.CleanupChallenges(ctx, now.Add(2*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.TwoFactor.DeleteChallenge(ctx, "ch"); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("challenge tidak dibersihkan: %v", err)
+	}
+
+	if err := r.TwoFactor.Delete(ctx, userID); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := r.TwoFactor.RecoveryCodesLeft(ctx, userID); n != 0 {
+		t.Fatalf("kode pemulihan tersisa setelah Delete: %d", n)
+	}
+}
+
+func TestSQLiteDisplays(t *testing.T) {
+	start := time.Date(2026, 2, 3, 9, 0, 0, 0, clock.Location())
+	clock.Set(clock.Fixed(start))
+	t.Cleanup(clock.Reset)
+	r := sqliteRepos(t)
+	ctx := context.Background()
+
+	unitID, _ := r.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Dukcapil", IsActive: "y", MainDisplay: "active"})
+	serviceID, _ := r.Services.Create(ctx, models.Service{UnitID: unitID, Code: "A1", NamaService: "KTP", IsActive: "y"})
+	code, expires := "ABC234", start.Add(15*time.Minute)
+	id, err := r.Displays.Create(ctx, models.Display{
+		Nama: "TV", PairingCode: &code, PairingExpiresAt: &expires, Theme: "default", PlaysAudio: "n", IsActive: "y",
+		UnitIDs: []int64{unitID, unitID}, ServiceIDs: []int64{serviceID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := r.Displays.ByPairingCode(ctx, code)
+	if err != nil || d.ID != id || !d.PairingExpiresAt.Equal(expires) || !slices.Equal(d.UnitIDs, []int64{unitID}) {
+		t.Fatalf("ByPairingCode = %+v, %v", d, err)
+	}
+	if err := r.Displays.Pair(ctx, id, "tv-1", "hash"); err != nil {
+		t.Fatal(err)
+	}
+	if tok, err := r.Displays.Token(ctx, "tv-1"); err != nil || tok.ID != id || tok.TokenHash != "hash" {
+		t.Fatalf("Token = %+v, %v", tok, err)
+	}
+	if used, _ := r.Displays.DeviceIDExists(ctx, "tv-1", 0); !used {
+		t.Fatal("device_id terpasang tidak terdeteksi")
+	}
+
+	// Ubah unit saja: service scope tetap
+	if err := r.Displays.Update(ctx, id, repository.DisplayPatch{PlaysAudio: "y", UnitIDs: &[]int64{}}); err != nil {
+		t.Fatal(err)
+	}
+	if d, _ := r.Displays.Get(ctx, id); d.PlaysAudio != "y" || len(d.UnitIDs) != 0 || !slices.Equal(d.ServiceIDs, []int64{serviceID}) {
+		t.Fatalf("Update = %+v", d)
+	}
+
+	if err := r.Displays.Connected(ctx, repository.DisplayConnection{DisplayID: id, ClientID: "c1", IPAddress: "10.0.0.2", AppVersion: "1.2"}); err != nil {
+		t.Fatal(err)
+	}
+	if live, _ := r.Displays.Online(ctx, id, start.Add(-90*time.Second)); !live {
+		t.Fatal("display baru terhubung dianggap offline")
+	}
+	cmdID, err := r.Displays.CreateCommand(ctx, repository.NewDisplayCommand{DisplayID: id, Command: "set_volume", Params: `{"volume":40}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := r.Displays.AckCommand(ctx, cmdID, id+1, "c1", "acked", nil); ok {
+		t.Fatal("ack dari display lain diterima")
+	}
+	r.Displays.CreateCommand(ctx, repository.NewDisplayCommand{DisplayID: id, Command: "reload", Params: `{}`})
+	if ok, _ := r.Displays.AckCommand(ctx, cmdID, id, "c1", "acked", nil); !ok {
+		t.Fatal("ack ditolak")
+	}
+
+	// Jam aplikasi maju 2 menit: heartbeat basi, perintah tanpa ack expired
+	now := start.Add(2 * time.Minute)
+	clock.Set(clock.Fixed(now))
+	if live, _ := r.Displays.Online(ctx, id, now.Add(-90*time.Second)); live {
+		t.Fatal("heartbeat basi dianggap online")
+	}
+	if err := r.Displays.ExpireCommands(ctx, now.Add(-30*time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	cmds, err := r.Displays.Commands(ctx, id, 10)
+	if err != nil || len(cmds) != 2 || cmds[0].Status != "expired" || cmds[1].Status != "acked" || cmds[1].Params["volume"] != float64(40) {
+		t.Fatalf("Commands = %+v, %v", cmds, err)
+	}
+	health, err := r.Displays.Health(ctx, now)
+	if err != nil || len(health) != 1 || health[0].SecondsSince != 120 || *health[0].AppVersion != "1.2" {
+		t.Fatalf("Health = %+v, %v", health, err)
+	}
+
+	if err := r.Displays.Disconnected(ctx, id, "c-lama"); err != nil {
+		t.Fatal(err)
+	}
+	if h, _ := r.Displays.Health(ctx, now); h[0].DisconnectedAt != nil {
+		t.Fatal("client lama mengubah status koneksi terbaru")
+	}
+	if err := r.Displays.Delete(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Displays.Command(ctx, cmdID); !errors.Is(err, repository.ErrNotFound) {
+		t.Fatalf("perintah display terhapus = %v, want ErrNotFound", err)
+	}
+}
+
+func TestSQLiteKiosks(t *testing.T) {
+	r := sqliteRepos(t)
+	ctx := context.Background()
+
+	pajak, _ := r.Units.Create(ctx, models.Unit{Code: "B", NamaUnit: "Pajak", IsActive: "y", MainDisplay: "active"})
+	dukcapil, _ := r.Units.Create(ctx, models.Unit{Code: "A", NamaUnit: "Dukcapil", IsActive: "y", MainDisplay: "active"})
+	id, err := r.Kiosks.Create(ctx, repository.NewKiosk{
+		Nama: "Kiosk", DeviceID: "kiosk-1", SecretHash: "s1", IsActive: "y",
+		Key:        repository.KioskKey{PublicKey: sql.NullString{String: "PEM", Valid: true}, Fingerprint: sql.NullString{String: "fp", Valid: true}},
+		UnitIDs:    []int64{pajak, dukcapil},
+		ValidAfter: clock.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Kiosks.Create(ctx, repository.NewKiosk{Nama: "Lain", DeviceID: "kiosk-1", SecretHash: "s", IsActive: "y"}); !errors.Is(err, repository.ErrDuplicate) {
+		t.Fatalf("device_id duplikat = %v, want ErrDuplicate", err)
+	}
+
+	creds, err := r.Kiosks.Credentials(ctx, "kiosk-1")
+	if err != nil || creds.ID != id || creds.SecretHash != "s1" || creds.PublicKey != "PEM" || !creds.HasPublicKey || len(creds.UnitIDs) != 2 {
+		t.Fatalf("Credentials = %+v, %v", creds, err)
+	}
+	if units, _ := r.Kiosks.Units(ctx, id); len(units) != 2 || units[0].NamaUnit != "Dukcapil" {
+		t.Fatalf("Units = %+v", units)
+	}
+
+	if ok, _ := r.Kiosks.UseAssertion(ctx, id, 100); !ok {
+		t.Fatal("assertion pertama ditolak")
+	}
+	if ok, _ := r.Kiosks.UseAssertion(ctx, id, 100); ok {
+		t.Fatal("replay assertion diterima")
+	}
+	// Ganti public key me-reset assertion terakhir
+	if err := r.Kiosks.Update(ctx, id, repository.KioskPatch{Key: &repository.KioskKey{}, UnitIDs: &[]int64{pajak}}); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := r.Kiosks.UseAssertion(ctx, id, 50); !ok {
+		t.Fatal("assertion setelah ganti key ditolak")
+	}
+	if k, _ := r.Kiosks.Get(ctx, id); k.HasPublicKey || k.KeyFingerprint != nil {
+		t.Fatalf("public key tidak dihapus: %+v", k)
+	}
+	if ok, _ := r.Kiosks.AllowsUnit(ctx, id, dukcapil); ok {
+		t.Fatal("unit yang dilepas masih diizinkan")
+	}
+
+	if err := r.Kiosks.RecordAuth(ctx, id, "10.0.0.3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Kiosks.RotateSecret(ctx, id, "s2", clock.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if k, _ := r.Kiosks.Credentials(ctx, "kiosk-1"); k.SecretHash != "s2" || *k.LastAuthIP != "10.0.0.3" {
+		t.Fatalf("setelah rotasi = %+v", k)
+	}
+	if err := r.Kiosks.Delete(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := r.Kiosks.List(ctx); len(list) != 0 {
+		t.Fatalf("List setelah Delete = %+v", list)
+	}
+}
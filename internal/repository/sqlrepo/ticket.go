@@ -0,0 +1,436 @@
+package sqlrepo
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/dialect"
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+const ticketColumns = `id, ticket_code, unit_id, service_id, user_id, status,
+	last_called_at, created_at, updated_at`
+
+type ticketRepo struct {
+	db *sql.DB
+	d  dialect.Dialect
+}
+
+// rowQuerier - *sql.DB atau *sql.Tx
+type rowQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func scanTicket(s scanner) (models.QueueTicket, error) {
+	var t models.QueueTicket
+	err := s.Scan(
+		&t.ID, &t.TicketCode, &t.UnitID, &t.ServiceID, &t.UserID, &t.Status,
+		&t.LastCalledAt, &t.CreatedAt, &t.UpdatedAt,
+	)
+	return t, err
+}
+
+func (r *ticketRepo) CountToday(ctx context.Context, unitID, serviceID int64, today clock.Day) (int, error) {
+	start, end := today.Bounds()
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM queue_tickets
+		WHERE service_id = ?
+		AND unit_id = ?
+		AND created_at >= ? AND created_at < ?
+	`, serviceID, unitID, start, end).Scan(&count)
+	return count, err
+}
+
+func (r *ticketRepo) Create(ctx context.Context, t repository.NewTicket) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO queue_tickets
+		(ticket_code, unit_id, service_id, user_id, kiosk_id, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, 'waiting', NOW(), NOW())
+	`, t.TicketCode, t.UnitID, t.ServiceID, t.UserID, t.KioskID)
+	if err != nil {
+		return 0, mapErr(err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO queue_transactions
+		(ticket_id, event, actor_user_id, created_at, updated_at)
+		VALUES (?, 'take', ?, NOW(), NOW())
+	`, id, t.UserID); err != nil {
+		return 0, err
+	}
+
+	return id, tx.Commit()
+}
+
+func (r *ticketRepo) Get(ctx context.Context, id int64) (models.QueueTicket, error) {
+	t, err := scanTicket(r.db.QueryRowContext(ctx, "SELECT "+ticketColumns+" FROM queue_tickets WHERE id = ?", id))
+	return t, mapErr(err)
+}
+
+func (r *ticketRepo) NextWaiting(ctx context.Context, serviceID int64, today clock.Day) (models.QueueTicket, error) {
+	return nextWaiting(ctx, r.db, serviceID, today)
+}
+
+func nextWaiting(ctx context.Context, q rowQuerier, serviceID int64, today clock.Day) (models.QueueTicket, error) {
+	start, end := today.Bounds()
+	t, err := scanTicket(q.QueryRowContext(ctx, `
+		SELECT `+ticketColumns+`
+		FROM queue_tickets
+		WHERE service_id = ?
+		AND status = 'waiting'
+		AND created_at >= ? AND created_at < ?
+		ORDER BY created_at ASC, id ASC
+		LIMIT 1
+	`, serviceID, start, end))
+	return t, mapErr(err)
+}
+
+func (r *ticketRepo) CurrentCalled(ctx context.Context, serviceID int64) (models.QueueTicket, error) {
+	return currentCalled(ctx, r.db, serviceID)
+}
+
+func currentCalled(ctx context.Context, q rowQuerier, serviceID int64) (models.QueueTicket, error) {
+	t, err := scanTicket(q.QueryRowContext(ctx, `
+		SELECT `+ticketColumns+`
+		FROM queue_tickets
+		WHERE service_id = ? AND status = 'called'
+		LIMIT 1
+	`, serviceID))
+	return t, mapErr(err)
+}
+
+func (r *ticketRepo) SetStatus(ctx context.Context, id int64, status string) error {
+	return affectedOne(r.db.ExecContext(ctx,
+		"UPDATE queue_tickets SET status = ?, updated_at = NOW() WHERE id = ?",
+		status, id,
+	))
+}
+
+func (r *ticketRepo) CallNext(ctx context.Context, serviceID int64, today clock.Day, userID int64, closeStatus, closeEvent string) (repository.CallNextResult, error) {
+	var res repository.CallNextResult
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback()
+
+	// Lock baris layanan: call-next lain untuk layanan ini menunggu sampai
+	// commit, sehingga tidak ada dua request yang menutup tiket called yang
+	// sama lalu masing-masing memanggil tiket berbeda
+	var lockedID int64
+	if err := tx.QueryRowContext(ctx,
+		"SELECT id FROM services WHERE id = ? "+r.d.ForUpdate(), serviceID,
+	).Scan(&lockedID); err != nil {
+		return res, mapErr(err)
+	}
+
+	next, err := nextWaiting(ctx, tx, serviceID, today)
+	if err != nil {
+		return res, err
+	}
+
+	current, err := currentCalled(ctx, tx, serviceID)
+	switch {
+	case err == nil:
+		if err := affectedOne(tx.ExecContext(ctx,
+			"UPDATE queue_tickets SET status = ?, updated_at = NOW() WHERE id = ? AND status = 'called'",
+			closeStatus, current.ID,
+		)); err != nil {
+			return res, err
+		}
+		if err := addTransaction(ctx, tx, current.ID, closeEvent, &userID); err != nil {
+			return res, err
+		}
+		res.ClosedID = current.ID
+	case !errors.Is(err, repository.ErrNotFound):
+		return res, err
+	}
+
+	if err := affectedOne(tx.ExecContext(ctx, `
+		UPDATE queue_tickets
+		SET status = 'called',
+		    last_called_at = NOW(),
+		    user_id = ?,
+		    updated_at = NOW()
+		WHERE id = ? AND status = 'waiting'
+	`, userID, next.ID)); err != nil {
+		return res, err
+	}
+	if err := addTransaction(ctx, tx, next.ID, "call", &userID); err != nil {
+		return res, err
+	}
+
+	if res.Called, err = scanTicket(tx.QueryRowContext(ctx,
+		"SELECT "+ticketColumns+" FROM queue_tickets WHERE id = ?", next.ID,
+	)); err != nil {
+		return res, mapErr(err)
+	}
+	return res, tx.Commit()
+}
+
+func (r *ticketRepo) Call(ctx context.Context, id, userID int64) error {
+	return affectedOne(r.db.ExecContext(ctx, `
+		UPDATE queue_tickets
+		SET status = 'called',
+		    last_called_at = NOW(),
+		    user_id = ?,
+		    updated_at = NOW()
+		WHERE id = ? AND status = 'waiting'
+	`, userID, id))
+}
+
+func (r *ticketRepo) AddTransaction(ctx context.Context, ticketID int64, event string, actorUserID *int64) error {
+	return addTransaction(ctx, r.db, ticketID, event, actorUserID)
+}
+
+// execer - *sql.DB atau *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+func addTransaction(ctx context.Context, db execer, ticketID int64, event string, actorUserID *int64) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO queue_transactions
+		(ticket_id, event, actor_user_id, created_at, updated_at)
+		VALUES (?, ?, ?, NOW(), NOW())
+	`, ticketID, event, actorUserID)
+	return mapErr(err)
+}
+
+func (r *ticketRepo) StatsToday(ctx context.Context, unitID int64, today clock.Day) (map[int64]repository.ServiceDayStats, error) {
+	start, end := today.Bounds()
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT service_id,
+		       COUNT(*),
+		       SUM(CASE WHEN status = 'waiting' THEN 1 ELSE 0 END),
+		       SUM(CASE WHEN status IN ('called', 'done') THEN 1 ELSE 0 END),
+		       SUM(CASE WHEN status = 'skipped' THEN 1 ELSE 0 END)
+		FROM queue_tickets
+		WHERE unit_id = ?
+		AND created_at >= ? AND created_at < ?
+		GROUP BY service_id
+	`, unitID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := map[int64]repository.ServiceDayStats{}
+	for rows.Next() {
+		var serviceID int64
+		var st repository.ServiceDayStats
+		if err := rows.Scan(&serviceID, &st.Total, &st.Waiting, &st.Served, &st.Skipped); err != nil {
+			return nil, err
+		}
+		stats[serviceID] = st
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Tiket called terakhir per layanan; baris pertama tiap layanan yang dipakai
+	called, err := r.db.QueryContext(ctx, `
+		SELECT service_id, ticket_code
+		FROM queue_tickets
+		WHERE unit_id = ?
+		AND status = 'called'
+		AND created_at >= ? AND created_at < ?
+		ORDER BY last_called_at DESC, id DESC
+	`, unitID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	defer called.Close()
+
+	for called.Next() {
+		var serviceID int64
+		var code string
+		if err := called.Scan(&serviceID, &code); err != nil {
+			return nil, err
+		}
+		if st := stats[serviceID]; st.CurrentTicket == "" {
+			st.CurrentTicket = code
+			stats[serviceID] = st
+		}
+	}
+	return stats, called.Err()
+}
+
+// todayCond - kondisi "tiket hari ini" untuk query lintas unit. prefix alias
+// tabel queue_tickets ("" atau "qt."). Tiap unit memakai batas hari zonanya
+// sendiri; tanpa unit ber-zona khusus hasilnya cukup
+// created_at >= ? AND created_at < ?.
+func todayCond(prefix string, today repository.Today) (string, []interface{}) {
+	col := prefix + "created_at"
+	unitCol := prefix + "unit_id"
+	between := col + " >= ? AND " + col + " < ?"
+
+	start, end := today.Default.Bounds()
+	if len(today.Units) == 0 {
+		return between, []interface{}{start, end}
+	}
+
+	// Kelompokkan unit per batas hari supaya jumlah parameter tetap kecil
+	byDay := map[[2]string][]interface{}{}
+	custom := make([]interface{}, 0, len(today.Units))
+	for id, day := range today.Units {
+		s, e := day.Bounds()
+		byDay[[2]string{s, e}] = append(byDay[[2]string{s, e}], id)
+		custom = append(custom, id)
+	}
+	keys := make([][2]string, 0, len(byDay))
+	for k := range byDay {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i][0] < keys[j][0] })
+
+	parts := []string{"(" + unitCol + " NOT IN (" + placeholders(len(custom)) + ") AND " + between + ")"}
+	args := append(custom, start, end)
+	for _, k := range keys {
+		ids := byDay[k]
+		parts = append(parts, "("+unitCol+" IN ("+placeholders(len(ids))+") AND "+between+")")
+		args = append(append(args, ids...), k[0], k[1])
+	}
+	return "(" + strings.Join(parts, " OR ") + ")", args
+}
+
+func (r *ticketRepo) DisplayRows(ctx context.Context, today repository.Today, serviceID int64) ([]repository.DisplayRow, error) {
+	cond, condArgs := todayCond("", today)
+	filter, outerFilter := "", ""
+	var filterArgs, outerArgs []interface{}
+	if serviceID > 0 {
+		filter = " AND service_id = ?"
+		outerFilter = " AND s.id = ?"
+		filterArgs = []interface{}{serviceID}
+		outerArgs = []interface{}{serviceID}
+	}
+
+	// Urutan parameter mengikuti urutan placeholder: dua subquery lalu filter luar
+	var args []interface{}
+	for i := 0; i < 2; i++ {
+		args = append(args, condArgs...)
+		args = append(args, filterArgs...)
+	}
+	args = append(args, outerArgs...)
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT
+			s.id, s.nama_service, s.code, s.unit_id,
+			u.nama_unit, u.main_display, u.audio_file,
+			COALESCE(qt.id, 0),
+			COALESCE(qt.ticket_code, '-'),
+			COALESCE(qt.status, 'waiting'),
+			qt.last_called_at
+		FROM services s
+		JOIN units u ON s.unit_id = u.id
+		LEFT JOIN (
+			SELECT qt1.*
+			FROM queue_tickets qt1
+			INNER JOIN (
+				SELECT service_id, MAX(last_called_at) as max_called
+				FROM queue_tickets
+				WHERE last_called_at IS NOT NULL
+				  AND `+cond+filter+`
+				GROUP BY service_id
+			) qt2 ON qt1.service_id = qt2.service_id
+				 AND qt1.last_called_at = qt2.max_called
+
+			UNION ALL
+
+			SELECT qt3.*
+			FROM queue_tickets qt3
+			INNER JOIN (
+				SELECT service_id, MIN(created_at) as min_created
+				FROM queue_tickets
+				WHERE status = 'waiting'
+				  AND `+cond+filter+`
+				GROUP BY service_id
+			) qt4 ON qt3.service_id = qt4.service_id
+				 AND qt3.created_at = qt4.min_created
+			WHERE qt3.status = 'waiting'
+		) qt ON s.id = qt.service_id
+		WHERE s.is_active = 'y'`+outerFilter+`
+		ORDER BY u.nama_unit ASC, qt.id DESC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []repository.DisplayRow{}
+	for rows.Next() {
+		var d repository.DisplayRow
+		if err := rows.Scan(
+			&d.ServiceID, &d.ServiceName, &d.ServiceCode, &d.UnitID,
+			&d.UnitName, &d.MainDisplay, &d.AudioFile,
+			&d.TicketID, &d.TicketCode, &d.Status, &d.LastCalledAt,
+		); err != nil {
+			return nil, err
+		}
+		result = append(result, d)
+	}
+	return result, rows.Err()
+}
+
+func (r *ticketRepo) WaitingToday(ctx context.Context, today repository.Today, serviceID int64) (map[int64]int, error) {
+	cond, args := todayCond("", today)
+	filter := ""
+	if serviceID > 0 {
+		filter = " AND service_id = ?"
+		args = append(args, serviceID)
+	}
+	return countByService(ctx, r.db, `
+		SELECT service_id, COUNT(*)
+		FROM queue_tickets
+		WHERE status = 'waiting'
+		  AND `+cond+filter+`
+		GROUP BY service_id
+	`, args...)
+}
+
+func (r *ticketRepo) CreatedSince(ctx context.Context, since time.Time) (map[int64]int, error) {
+	return countByService(ctx, r.db, `
+		SELECT service_id, COUNT(*)
+		FROM queue_tickets
+		WHERE created_at >= ?
+		GROUP BY service_id
+	`, clock.SQL(since))
+}
+
+// countByService jalankan query (service_id, jumlah) jadi map
+func countByService(ctx context.Context, db *sql.DB, query string, args ...interface{}) (map[int64]int, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := map[int64]int{}
+	for rows.Next() {
+		var serviceID int64
+		var n int
+		if err := rows.Scan(&serviceID, &n); err != nil {
+			return nil, err
+		}
+		counts[serviceID] = n
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,150 @@
+package sqlrepo
+
+import (
+	"backend-antrian/internal/clock"
+	"backend-antrian/internal/dialect"
+	"backend-antrian/internal/repository"
+	"context"
+	"database/sql"
+	"time"
+)
+
+type twoFactorRepo struct {
+	db *sql.DB
+	d  dialect.Dialect
+}
+
+func (r *twoFactorRepo) Secret(ctx context.Context, userID int64) (repository.TOTPSecret, error) {
+	var (
+		t       repository.TOTPSecret
+		enabled string
+	)
+	err := r.db.QueryRowContext(ctx,
+		"SELECT secret_enc, enabled, last_step FROM user_totp WHERE user_id = ?", userID,
+	).Scan(&t.SecretEnc, &enabled, &t.LastStep)
+	t.Enabled = enabled == "y"
+	return t, mapErr(err)
+}
+
+func (r *twoFactorRepo) StartEnrollment(ctx context.Context, userID int64, secretEnc string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_totp (user_id, secret_enc, enabled, last_step)
+		VALUES (?, ?, 'n', 0)
+		`+r.d.OnConflict("user_id")+` secret_enc = `+r.d.Inserted("secret_enc")+`, enabled = 'n', last_step = 0, confirmed_at = NULL
+	`, userID, secretEnc)
+	return err
+}
+
+func (r *twoFactorRepo) UseStep(ctx context.Context, userID, step int64) (bool, error) {
+	// Update bersyarat: dua request paralel dengan kode sama, hanya satu yang lolos
+	res, err := r.db.ExecContext(ctx,
+		"UPDATE user_totp SET last_step = ? WHERE user_id = ? AND last_step < ?",
+		step, userID, step,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+func (r *twoFactorRepo) Confirm(ctx context.Context, userID int64) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE user_totp SET enabled = 'y', confirmed_at = NOW() WHERE user_id = ?", userID,
+	)
+	return err
+}
+
+func (r *twoFactorRepo) Delete(ctx context.Context, userID int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_totp WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *twoFactorRepo) ReplaceRecoveryCodes(ctx context.Context, userID int64, hashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM user_recovery_codes WHERE user_id = ?", userID); err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT INTO user_recovery_codes (user_id, code_hash) VALUES (?, ?)", userID, hash,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UseRecoveryCode - (user_id, code_hash) hanya cocok satu baris;
+// UPDATE ... LIMIT tidak didukung SQLite
+func (r *twoFactorRepo) UseRecoveryCode(ctx context.Context, userID int64, hash string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+		UPDATE user_recovery_codes SET used_at = NOW()
+		WHERE user_id = ? AND code_hash = ? AND used_at IS NULL
+	`, userID, hash)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+func (r *twoFactorRepo) RecoveryCodesLeft(ctx context.Context, userID int64) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = ? AND used_at IS NULL", userID,
+	).Scan(&n)
+	return n, err
+}
+
+func (r *twoFactorRepo) CreateChallenge(ctx context.Context, ch repository.LoginChallenge) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO login_challenges (token_hash, user_id, unit_id, ip_address, expires_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, ch.Hash, ch.UserID, ch.UnitID, ch.IPAddress, ch.ExpiresAt)
+	return mapErr(err)
+}
+
+func (r *twoFactorRepo) Challenge(ctx context.Context, hash string, now time.Time) (repository.LoginChallenge, error) {
+	var (
+		ch repository.LoginChallenge
+		ip sql.NullString
+	)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT token_hash, user_id, unit_id, ip_address, expires_at, attempts
+		FROM login_challenges
+		WHERE token_hash = ? AND expires_at > ?
+	`, hash, clock.SQL(now)).Scan(&ch.Hash, &ch.UserID, &ch.UnitID, &ip, &ch.ExpiresAt, &ch.Attempts)
+	ch.IPAddress = ip.String
+	return ch, mapErr(err)
+}
+
+func (r *twoFactorRepo) FailChallenge(ctx context.Context, hash string) error {
+	_, err := r.db.ExecContext(ctx, "UPDATE login_challenges SET attempts = attempts + 1 WHERE token_hash = ?", hash)
+	return err
+}
+
+func (r *twoFactorRepo) DeleteChallenge(ctx context.Context, hash string) error {
+	return affectedOne(r.db.ExecContext(ctx, "DELETE FROM login_challenges WHERE token_hash = ?", hash))
+}
+
+func (r *twoFactorRepo) CleanupChallenges(ctx context.Context, now time.Time) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM login_challenges WHERE expires_at < ?", clock.SQL(now))
+	return err
+}
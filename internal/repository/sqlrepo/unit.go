@@ -0,0 +1,122 @@
+package sqlrepo
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"database/sql"
+)
+
+const unitColumns = "id, code, nama_unit, is_active, main_display, audio_file, timezone, created_at, updated_at"
+
+type unitRepo struct {
+	db *sql.DB
+}
+
+func scanUnit(s scanner) (models.Unit, error) {
+	var u models.Unit
+	err := s.Scan(&u.ID, &u.Code, &u.NamaUnit, &u.IsActive, &u.MainDisplay, &u.AudioFile, &u.Timezone, &u.CreatedAt, &u.UpdatedAt)
+	return u, err
+}
+
+func unitWhere(f repository.UnitFilter) (string, []interface{}) {
+	where := " WHERE 1=1"
+	args := []interface{}{}
+	if f.IsActive != "" {
+		where += " AND is_active = ?"
+		args = append(args, f.IsActive)
+	}
+	if f.Search != "" {
+		search := likePattern(f.Search)
+		where += " AND (code LIKE ? OR nama_unit LIKE ?)"
+		args = append(args, search, search)
+	}
+	return where, args
+}
+
+func (r *unitRepo) List(ctx context.Context, f repository.UnitFilter) ([]models.Unit, error) {
+	where, args := unitWhere(f)
+	query := "SELECT " + unitColumns + " FROM units" + where + " ORDER BY nama_unit ASC"
+	if f.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, f.Limit, f.Offset)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	units := []models.Unit{}
+	for rows.Next() {
+		u, err := scanUnit(rows)
+		if err != nil {
+			return nil, err
+		}
+		units = append(units, u)
+	}
+	return units, rows.Err()
+}
+
+func (r *unitRepo) Count(ctx context.Context, f repository.UnitFilter) (int, error) {
+	where, args := unitWhere(f)
+	var total int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM units"+where, args...).Scan(&total)
+	return total, err
+}
+
+func (r *unitRepo) Get(ctx context.Context, id int64) (models.Unit, error) {
+	u, err := scanUnit(r.db.QueryRowContext(ctx, "SELECT "+unitColumns+" FROM units WHERE id = ?", id))
+	return u, mapErr(err)
+}
+
+func (r *unitRepo) CodeExists(ctx context.Context, code string, excludeID int64) (bool, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM units WHERE code = ? AND id != ?", code, excludeID).Scan(&count)
+	return count > 0, err
+}
+
+func (r *unitRepo) Create(ctx context.Context, u models.Unit) (int64, error) {
+	res, err := r.db.ExecContext(ctx,
+		"INSERT INTO units (code, nama_unit, is_active, main_display, audio_file, timezone) VALUES (?, ?, ?, ?, ?, ?)",
+		u.Code, u.NamaUnit, u.IsActive, u.MainDisplay, u.AudioFile, u.Timezone,
+	)
+	if err != nil {
+		return 0, mapErr(err)
+	}
+	return res.LastInsertId()
+}
+
+func (r *unitRepo) Update(ctx context.Context, id int64, p repository.UnitPatch) error {
+	var set updateSet
+	if p.Code != "" {
+		set.set("code", p.Code)
+	}
+	if p.NamaUnit != "" {
+		set.set("nama_unit", p.NamaUnit)
+	}
+	if p.IsActive != "" {
+		set.set("is_active", p.IsActive)
+	}
+	if p.MainDisplay != "" {
+		set.set("main_display", p.MainDisplay)
+	}
+	if p.AudioFile != nil {
+		set.set("audio_file", sql.NullString{String: *p.AudioFile, Valid: *p.AudioFile != ""})
+	}
+	if p.Timezone != nil {
+		set.set("timezone", sql.NullString{String: *p.Timezone, Valid: *p.Timezone != ""})
+	}
+	if set.empty() {
+		return nil
+	}
+
+	query, args := set.query("units", id)
+	_, err := r.db.ExecContext(ctx, query, args...)
+	return mapErr(err)
+}
+
+func (r *unitRepo) Delete(ctx context.Context, id int64) error {
+	return affectedOne(r.db.ExecContext(ctx, "DELETE FROM units WHERE id = ?", id))
+}
@@ -0,0 +1,264 @@
+package sqlrepo
+
+import (
+	"backend-antrian/internal/models"
+	"backend-antrian/internal/repository"
+	"context"
+	"database/sql"
+)
+
+const userSelect = `
+	SELECT u.id, u.nama, u.email, u.role, u.is_banned, u.unit_id,
+	       COALESCE(un.nama_unit, '') as unit_name, u.created_at, u.updated_at
+	FROM users u
+	LEFT JOIN units un ON u.unit_id = un.id
+`
+
+type userRepo struct {
+	db *sql.DB
+}
+
+func scanUser(s scanner) (repository.UserWithUnit, error) {
+	var u repository.UserWithUnit
+	err := s.Scan(
+		&u.ID, &u.Nama, &u.Email, &u.Role, &u.IsBanned, &u.UnitID,
+		&u.UnitName, &u.CreatedAt, &u.UpdatedAt,
+	)
+	return u, err
+}
+
+func userWhere(f repository.UserFilter) (string, []interface{}) {
+	where := " WHERE 1=1"
+	args := []interface{}{}
+	if f.IsBanned != "" {
+		where += " AND u.is_banned = ?"
+		args = append(args, f.IsBanned)
+	}
+	if f.Search != "" {
+		search := likePattern(f.Search)
+		where += " AND (u.email LIKE ? OR u.nama LIKE ?)"
+		args = append(args, search, search)
+	}
+	return where, args
+}
+
+func (r *userRepo) List(ctx context.Context, f repository.UserFilter) ([]repository.UserWithUnit, error) {
+	where, args := userWhere(f)
+	query := userSelect + where + " ORDER BY u.created_at DESC"
+	if f.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		args = append(args, f.Limit, f.Offset)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []repository.UserWithUnit{}
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (r *userRepo) Count(ctx context.Context, f repository.UserFilter) (int, error) {
+	where, args := userWhere(f)
+	var total int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users u"+where, args...).Scan(&total)
+	return total, err
+}
+
+func (r *userRepo) Get(ctx context.Context, id int64) (repository.UserWithUnit, error) {
+	u, err := scanUser(r.db.QueryRowContext(ctx, userSelect+" WHERE u.id = ?", id))
+	return u, mapErr(err)
+}
+
+func (r *userRepo) EmailExists(ctx context.Context, email string, excludeID int64) (bool, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE email = ? AND id != ?", email, excludeID).Scan(&count)
+	return count > 0, err
+}
+
+func (r *userRepo) CountByRole(ctx context.Context, role string) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE role = ?", role).Scan(&count)
+	return count, err
+}
+
+const credentialsSelect = `
+	SELECT id, nama, email, password, role, is_banned, unit_id, created_at, updated_at,
+	       must_change_password, password_changed_at
+	FROM users
+`
+
+func scanCredentials(s scanner) (repository.UserCredentials, error) {
+	var (
+		u          repository.UserCredentials
+		mustChange string
+		changedAt  sql.NullTime
+	)
+	err := s.Scan(
+		&u.ID, &u.Nama, &u.Email, &u.Password, &u.Role, &u.IsBanned, &u.UnitID, &u.CreatedAt, &u.UpdatedAt,
+		&mustChange, &changedAt,
+	)
+	u.MustChangePassword = mustChange == "y"
+	if changedAt.Valid {
+		u.PasswordChangedAt = &changedAt.Time
+	}
+	return u, mapErr(err)
+}
+
+func (r *userRepo) GetByEmail(ctx context.Context, email string) (repository.UserCredentials, error) {
+	return scanCredentials(r.db.QueryRowContext(ctx, credentialsSelect+" WHERE email = ?", email))
+}
+
+func (r *userRepo) Credentials(ctx context.Context, id int64) (repository.UserCredentials, error) {
+	return scanCredentials(r.db.QueryRowContext(ctx, credentialsSelect+" WHERE id = ?", id))
+}
+
+func (r *userRepo) UnitIDs(ctx context.Context, userID int64) ([]int64, error) {
+	return queryIDs(ctx, r.db, "SELECT unit_id FROM user_units WHERE user_id = ? ORDER BY unit_id", userID)
+}
+
+func (r *userRepo) IsUnitMember(ctx context.Context, userID, unitID int64) (bool, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM user_units WHERE user_id = ? AND unit_id = ?", userID, unitID,
+	).Scan(&count)
+	return count > 0, err
+}
+
+func (r *userRepo) Units(ctx context.Context, userID int64) ([]models.Unit, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT u.id, u.code, u.nama_unit, u.is_active, u.main_display, u.audio_file, u.timezone, u.created_at, u.updated_at
+		FROM user_units uu
+		JOIN units u ON u.id = uu.unit_id
+		WHERE uu.user_id = ?
+		ORDER BY u.nama_unit ASC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	units := []models.Unit{}
+	for rows.Next() {
+		u, err := scanUnit(rows)
+		if err != nil {
+			return nil, err
+		}
+		units = append(units, u)
+	}
+	return units, rows.Err()
+}
+
+func (r *userRepo) CreateWithUnits(ctx context.Context, u models.User, unitIDs []int64) (int64, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		"INSERT INTO users (nama, email, password, password_changed_at, must_change_password, role, is_banned, unit_id) VALUES (?, ?, ?, NOW(), 'y', ?, ?, ?)",
+		u.Nama, u.Email, u.Password, u.Role, u.IsBanned, u.UnitID,
+	)
+	if err != nil {
+		return 0, mapErr(err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err := replaceUserUnits(ctx, tx, id, unitIDs); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+func (r *userRepo) UpdateWithUnits(ctx context.Context, id int64, p repository.UserPatch, unitIDs []int64) error {
+	var set updateSet
+	if p.Nama != "" {
+		set.set("nama", p.Nama)
+	}
+	if p.Email != "" {
+		set.set("email", p.Email)
+	}
+	if p.PasswordHash != "" {
+		set.set("password", p.PasswordHash)
+		set.raw("password_changed_at = NOW()")
+		set.raw("must_change_password = 'y'")
+	}
+	if p.Role != "" {
+		set.set("role", p.Role)
+	}
+	if p.IsBanned != "" {
+		set.set("is_banned", p.IsBanned)
+	}
+	if p.UnitID != nil {
+		set.set("unit_id", *p.UnitID)
+	}
+	if set.empty() && unitIDs == nil {
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if !set.empty() {
+		query, args := set.query("users", id)
+		if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+			return mapErr(err)
+		}
+	}
+	if unitIDs != nil {
+		if err := replaceUserUnits(ctx, tx, id, unitIDs); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// replaceUserUnits ganti seluruh keanggotaan unit user
+func replaceUserUnits(ctx context.Context, db execer, userID int64, unitIDs []int64) error {
+	return replaceIDs(ctx, db, "user_units", "user_id", "unit_id", userID, unitIDs)
+}
+
+// userOwnedTables - data milik user yang ikut dihapus bersama user.
+// refresh_tokens ikut terhapus lewat user_sessions (ON DELETE CASCADE).
+var userOwnedTables = []string{
+	"user_sessions",
+	"user_units",
+	"user_totp",
+	"user_recovery_codes",
+	"login_challenges",
+	"password_history",
+	"password_reset_tokens",
+}
+
+func (r *userRepo) HardDelete(ctx context.Context, id int64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := affectedOne(tx.ExecContext(ctx, "DELETE FROM users WHERE id = ?", id)); err != nil {
+		return err
+	}
+	for _, table := range userOwnedTables {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE user_id = ?", id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
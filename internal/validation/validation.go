@@ -0,0 +1,198 @@
+// Package validation menjalankan tag `validate` pada request body dengan
+// pesan error berbahasa Indonesia (default) atau Inggris.
+package validation
+
+import (
+	"backend-antrian/internal/clock"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/go-playground/validator/v10/non-standard/validators"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	id_translations "github.com/go-playground/validator/v10/translations/id"
+)
+
+const (
+	LangID = "id"
+	LangEN = "en"
+)
+
+var (
+	// timeOfDayRegex HH:MM atau HH:MM:SS (24 jam)
+	timeOfDayRegex = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9](:[0-5][0-9])?$`)
+	// ticketCodeRegex kode huruf di depan nomor tiket (code service), mis. "A" pada A12
+	ticketCodeRegex = regexp.MustCompile(`^[A-Za-z]{1,10}$`)
+)
+
+// FieldError satu field yang tidak lolos validasi
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Error hasil validasi yang gagal; dirender jadi 422 oleh ErrorHandler
+type Error struct {
+	Lang   string
+	Fields []FieldError
+}
+
+func (e *Error) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Message)
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Body response 422 yang seragam untuk semua endpoint
+func (e *Error) Body() map[string]interface{} {
+	message := "Validasi gagal"
+	if e.Lang == LangEN {
+		message = "Validation failed"
+	}
+	return map[string]interface{}{
+		"success": false,
+		"error":   message,
+		"errors":  e.Fields,
+	}
+}
+
+var (
+	once     sync.Once
+	validate *validator.Validate
+	uni      *ut.UniversalTranslator
+)
+
+func setup() {
+	validate = validator.New(validator.WithRequiredStructEnabled())
+
+	// Nama field di pesan error mengikuti tag json
+	validate.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" || name == "" {
+			return f.Name
+		}
+		return name
+	})
+
+	// Seperti required tapi string yang isinya cuma spasi juga ditolak
+	validate.RegisterValidation("notblank", validators.NotBlank)
+	validate.RegisterValidation("timeofday", func(fl validator.FieldLevel) bool {
+		return timeOfDayRegex.MatchString(fl.Field().String())
+	})
+	validate.RegisterValidation("ticketcode", func(fl validator.FieldLevel) bool {
+		return ticketCodeRegex.MatchString(strings.TrimSpace(fl.Field().String()))
+	})
+	// Nama zona IANA (mis. Asia/Makassar); "Local" ditolak karena tergantung server
+	validate.RegisterValidation("timezone", func(fl validator.FieldLevel) bool {
+		name := fl.Field().String()
+		if name == "Local" {
+			return false
+		}
+		_, err := clock.LoadLocation(name)
+		return err == nil
+	})
+
+	idLocale := id.New()
+	uni = ut.New(idLocale, idLocale, en.New())
+
+	idTrans, _ := uni.GetTranslator(LangID)
+	enTrans, _ := uni.GetTranslator(LangEN)
+	id_translations.RegisterDefaultTranslations(validate, idTrans)
+	en_translations.RegisterDefaultTranslations(validate, enTrans)
+
+	custom := map[string]map[string]string{
+		"timeofday": {
+			LangID: "{0} harus berformat jam HH:MM atau HH:MM:SS",
+			LangEN: "{0} must be a time of day in HH:MM or HH:MM:SS format",
+		},
+		"ticketcode": {
+			LangID: "{0} harus 1-10 huruf tanpa angka atau karakter khusus",
+			LangEN: "{0} must be 1-10 letters without digits or special characters",
+		},
+		"timezone": {
+			LangID: "{0} harus nama zona waktu yang valid (mis. Asia/Jakarta)",
+			LangEN: "{0} must be a valid time zone name (e.g. Asia/Jakarta)",
+		},
+		"notblank": {
+			LangID: "{0} wajib diisi",
+			LangEN: "{0} is a required field",
+		},
+		// Bawaan menyebut nama field Go di param (mis. "IsActive y"); cukup pesan wajib biasa
+		"required_if": {
+			LangID: "{0} wajib diisi",
+			LangEN: "{0} is a required field",
+		},
+		"required_without": {
+			LangID: "{0} wajib diisi",
+			LangEN: "{0} is a required field",
+		},
+	}
+	for tag, msgs := range custom {
+		registerTranslation(tag, idTrans, msgs[LangID])
+		registerTranslation(tag, enTrans, msgs[LangEN])
+	}
+}
+
+func registerTranslation(tag string, trans ut.Translator, text string) {
+	validate.RegisterTranslation(tag, trans,
+		func(ut ut.Translator) error { return ut.Add(tag, text, true) },
+		func(ut ut.Translator, fe validator.FieldError) string {
+			msg, _ := ut.T(tag, fe.Field())
+			return msg
+		},
+	)
+}
+
+// Lang pilih bahasa dari header Accept-Language; default Indonesia
+func Lang(acceptLanguage string) string {
+	if strings.HasPrefix(strings.ToLower(strings.TrimSpace(acceptLanguage)), LangEN) {
+		return LangEN
+	}
+	return LangID
+}
+
+// Struct validasi struct; nil jika lolos, *Error jika ada field yang gagal
+func Struct(v interface{}, lang string) error {
+	once.Do(setup)
+
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	errs, ok := err.(validator.ValidationErrors)
+	if !ok {
+		// Bukan struct (mis. body array) — tidak ada tag untuk dicek
+		return nil
+	}
+
+	trans, _ := uni.GetTranslator(lang)
+	fields := make([]FieldError, 0, len(errs))
+	for _, fe := range errs {
+		fields = append(fields, FieldError{
+			Field:   fieldPath(fe),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fe.Translate(trans),
+		})
+	}
+	return &Error{Lang: lang, Fields: fields}
+}
+
+// fieldPath path field tanpa nama struct root, mis. "schedules[0].jam_buka"
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+	return ns
+}
@@ -0,0 +1,80 @@
+package validation
+
+import (
+	"errors"
+	"testing"
+)
+
+type sample struct {
+	Code  string `json:"code" validate:"required,ticketcode"`
+	Buka  string `json:"jam_buka" validate:"omitempty,timeofday"`
+	Nama  string `json:"nama" validate:"omitempty,notblank"`
+	Items []item `json:"items" validate:"dive"`
+}
+
+type item struct {
+	Qty int `json:"qty" validate:"min=1"`
+}
+
+func TestCustomRules(t *testing.T) {
+	cases := []struct {
+		name  string
+		in    sample
+		field string
+		rule  string
+	}{
+		{"lolos", sample{Code: "A", Buka: "08:00"}, "", ""},
+		{"jam dengan detik", sample{Code: "abc", Buka: "23:59:59"}, "", ""},
+		{"jam di luar rentang", sample{Code: "A", Buka: "24:00"}, "jam_buka", "timeofday"},
+		{"jam tanpa nol", sample{Code: "A", Buka: "8:00"}, "jam_buka", "timeofday"},
+		{"code dengan angka", sample{Code: "A1"}, "code", "ticketcode"},
+		{"code terlalu panjang", sample{Code: "ABCDEFGHIJK"}, "code", "ticketcode"},
+		{"code kosong", sample{}, "code", "required"},
+		{"nama cuma spasi", sample{Code: "A", Nama: "  "}, "nama", "notblank"},
+		{"field bersarang", sample{Code: "A", Items: []item{{Qty: 1}, {}}}, "items[1].qty", "min"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Struct(&tc.in, LangID)
+			if tc.field == "" {
+				if err != nil {
+					t.Fatalf("Struct() = %v", err)
+				}
+				return
+			}
+
+			var ve *Error
+			if !errors.As(err, &ve) {
+				t.Fatalf("Struct() = %v, mau *Error", err)
+			}
+			if len(ve.Fields) != 1 || ve.Fields[0].Field != tc.field || ve.Fields[0].Rule != tc.rule {
+				t.Fatalf("Fields = %+v, mau %s/%s", ve.Fields, tc.field, tc.rule)
+			}
+		})
+	}
+}
+
+func TestLocalizedMessages(t *testing.T) {
+	in := sample{Code: "A1"}
+
+	var id, en *Error
+	errors.As(Struct(&in, Lang("id-ID,id;q=0.9")), &id)
+	errors.As(Struct(&in, Lang("en-US,en;q=0.8")), &en)
+	if id == nil || en == nil {
+		t.Fatal("mau *Error untuk kedua bahasa")
+	}
+
+	if got := id.Fields[0].Message; got != "code harus 1-10 huruf tanpa angka atau karakter khusus" {
+		t.Errorf("pesan id = %q", got)
+	}
+	if got := en.Fields[0].Message; got != "code must be 1-10 letters without digits or special characters" {
+		t.Errorf("pesan en = %q", got)
+	}
+	if id.Body()["error"] != "Validasi gagal" || en.Body()["error"] != "Validation failed" {
+		t.Errorf("body = %v / %v", id.Body(), en.Body())
+	}
+	if Lang("") != LangID {
+		t.Errorf("default bahasa harus %s", LangID)
+	}
+}